@@ -0,0 +1,268 @@
+// Package archive exports a project's index as a compressed, portable
+// bundle and imports it into another checkout of the same project.
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+// FormatVersion is the version of the archive layout
+const FormatVersion = 1
+
+// Entry names inside an archive
+const (
+	manifestName = "manifest.json"
+	indexName    = "index.db"
+)
+
+// Manifest describes an exported index
+type Manifest struct {
+	FormatVersion    int       `json:"format_version"`
+	SchemaVersion    int       `json:"schema_version"`
+	CodegraphVersion string    `json:"codegraph_version"`
+	Created          time.Time `json:"created"`
+	Project          string    `json:"project"` // Base name of the project root
+	Root             string    `json:"root"`    // Absolute project root the index was built in
+	GitCommit        string    `json:"git_commit,omitempty"`
+	GitDirty         bool      `json:"git_dirty,omitempty"` // Uncommitted changes at export
+	Files            int       `json:"files"`
+	Symbols          int       `json:"symbols"`
+	Calls            int       `json:"calls"`
+}
+
+// ErrCommitMismatch is returned by Import when the archive was exported at
+// another git commit than the one checked out
+var ErrCommitMismatch = errors.New("archive commit does not match the checkout")
+
+// gitState returns the HEAD commit of the repository at root and whether it
+// has uncommitted changes; the commit is empty outside a git repository
+func gitState(root string) (string, bool) {
+	out, err := exec.Command("git", "-C", root, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return "", false
+	}
+	commit := strings.TrimSpace(string(out))
+	status, err := exec.Command("git", "-C", root, "status", "--porcelain", "--untracked-files=no").Output()
+	return commit, err == nil && len(strings.TrimSpace(string(status))) > 0
+}
+
+// Export writes the index in dbManager, built in root, to w as a gzipped
+// tar holding a manifest and a consistent copy of the database
+func Export(dbManager *db.Manager, root, version string, w io.Writer) (*Manifest, error) {
+	dir, err := os.MkdirTemp("", "codegraph-export-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	copyPath := filepath.Join(dir, indexName)
+	if err := dbManager.SnapshotTo(copyPath); err != nil {
+		return nil, err
+	}
+	stats, err := dbManager.GetStats()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index statistics: %w", err)
+	}
+	commit, dirty := gitState(root)
+	manifest := &Manifest{
+		FormatVersion:    FormatVersion,
+		SchemaVersion:    db.SchemaVersion,
+		CodegraphVersion: version,
+		Created:          time.Now().UTC().Truncate(time.Second),
+		Project:          filepath.Base(root),
+		Root:             root,
+		GitCommit:        commit,
+		GitDirty:         dirty,
+		Files:            stats.FileCount,
+		Symbols:          stats.SymbolCount,
+		Calls:            stats.CallCount,
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	gz, err := gzip.NewWriterLevel(w, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{Name: manifestName, Mode: 0644, Size: int64(len(data)), ModTime: manifest.Created}); err != nil {
+		return nil, err
+	}
+	if _, err := tw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := addFile(tw, copyPath, indexName, manifest.Created); err != nil {
+		return nil, err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+func addFile(tw *tar.Writer, path, name string, modTime time.Time) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: info.Size(), ModTime: modTime}); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// read extracts the manifest of the archive at path and, when indexPath is
+// not empty, its database to indexPath
+func read(path, indexPath string) (*Manifest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a codegraph archive: %w", path, err)
+	}
+	defer gz.Close()
+
+	var manifest *Manifest
+	extracted := false
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a codegraph archive: %w", path, err)
+		}
+		switch hdr.Name {
+		case manifestName:
+			manifest = &Manifest{}
+			if err := json.NewDecoder(tr).Decode(manifest); err != nil {
+				return nil, fmt.Errorf("invalid archive manifest: %w", err)
+			}
+			if indexPath == "" {
+				return manifest, nil
+			}
+		case indexName:
+			if indexPath == "" {
+				continue
+			}
+			out, err := os.Create(indexPath)
+			if err != nil {
+				return nil, err
+			}
+			_, err = io.Copy(out, tr)
+			if closeErr := out.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to extract index: %w", err)
+			}
+			extracted = true
+		}
+	}
+	if manifest == nil {
+		return nil, fmt.Errorf("%s is not a codegraph archive: no %s", path, manifestName)
+	}
+	if indexPath != "" && !extracted {
+		return nil, fmt.Errorf("%s is not a codegraph archive: no %s", path, indexName)
+	}
+	return manifest, nil
+}
+
+// ReadManifest returns the manifest of the archive at path
+func ReadManifest(path string) (*Manifest, error) {
+	return read(path, "")
+}
+
+// ImportOptions configures Import
+type ImportOptions struct {
+	// AllowCommitMismatch imports an archive exported at another git commit
+	AllowCommitMismatch bool
+}
+
+// Import replaces the database at dbPath with the index in the archive at
+// path, for the project checked out at root. The archive must match the
+// current schema version and, unless opts allow otherwise, the checkout's
+// git commit. File paths are rewritten from the exporting root to root.
+func Import(path, root, dbPath string, opts ImportOptions) (*Manifest, error) {
+	manifest, err := ReadManifest(path)
+	if err != nil {
+		return nil, err
+	}
+	if manifest.FormatVersion > FormatVersion {
+		return nil, fmt.Errorf("archive format %d is newer than this codegraph supports (%d)", manifest.FormatVersion, FormatVersion)
+	}
+	if manifest.SchemaVersion != db.SchemaVersion {
+		return nil, fmt.Errorf("archive has schema version %d, this codegraph needs %d; re-export it with a matching version", manifest.SchemaVersion, db.SchemaVersion)
+	}
+	if !opts.AllowCommitMismatch {
+		if commit, _ := gitState(root); manifest.GitCommit != commit {
+			return nil, fmt.Errorf("%w: exported at %s, checked out %s", ErrCommitMismatch, describeCommit(manifest.GitCommit), describeCommit(commit))
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		return nil, err
+	}
+	tmp := dbPath + ".import"
+	defer os.Remove(tmp)
+	if _, err := read(path, tmp); err != nil {
+		return nil, err
+	}
+	if version, err := db.FileSchemaVersion(tmp); err != nil || version != manifest.SchemaVersion {
+		return nil, fmt.Errorf("archive index does not match its manifest")
+	}
+
+	imported, err := db.NewManager(tmp)
+	if err != nil {
+		return nil, err
+	}
+	err = imported.RebaseFiles(manifest.Root, root)
+	if closeErr := imported.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp, dbPath); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// describeCommit abbreviates a commit for messages
+func describeCommit(commit string) string {
+	if commit == "" {
+		return "no git commit"
+	}
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
@@ -0,0 +1,106 @@
+package archive
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+func gitInit(t *testing.T, root, message string) {
+	t.Helper()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"-c", "user.name=Alice", "-c", "user.email=alice@example.com", "commit", "-q", "--allow-empty", "-m", message},
+	} {
+		cmd := exec.Command("git", append([]string{"-C", root}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Skipf("git unavailable: %v: %s", err, out)
+		}
+	}
+}
+
+func TestExportImportRebasesPaths(t *testing.T) {
+	from := t.TempDir()
+	gitInit(t, from, "built")
+	m, err := db.NewManager(filepath.Join(from, ".codegraph", "codegraph.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	if err := m.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(from, "pkg", "a.go")
+	for _, s := range []db.Symbol{
+		{ID: "pkg/a.go#f", Name: "f", Line: 1},
+		{ID: "pkg/a.go#g", Name: "g", Line: 5},
+	} {
+		s.Kind, s.Language, s.File, s.CreatedAt = "function", "go", file, time.Unix(0, 0)
+		if err := m.InsertSymbol(&s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.InsertCall(&db.Call{CallerID: "pkg/a.go#f", CalleeID: "pkg/a.go#g", File: file, Line: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.UpdateFileMeta(file, time.Unix(0, 0), "go", "lsp"); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	manifest, err := Export(m, from, "test", &buf)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if manifest.GitCommit == "" || manifest.Symbols != 2 || manifest.Calls != 1 || manifest.Files != 1 {
+		t.Fatalf("manifest = %+v", manifest)
+	}
+	archivePath := filepath.Join(t.TempDir(), "index.tar.gz")
+	if err := os.WriteFile(archivePath, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another checkout at another commit
+	to := t.TempDir()
+	gitInit(t, to, "checked out")
+	dbPath := filepath.Join(to, ".codegraph", "codegraph.db")
+	if _, err := Import(archivePath, to, dbPath, ImportOptions{}); !errors.Is(err, ErrCommitMismatch) {
+		t.Fatalf("Import at another commit = %v, want ErrCommitMismatch", err)
+	}
+	if _, err := os.Stat(dbPath); !os.IsNotExist(err) {
+		t.Fatal("rejected import left a database behind")
+	}
+	if _, err := Import(archivePath, to, dbPath, ImportOptions{AllowCommitMismatch: true}); err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+
+	imported, err := db.NewManager(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer imported.Close()
+	syms, err := imported.ListSymbols(db.QueryOptions{})
+	if err != nil || len(syms) != 2 {
+		t.Fatalf("symbols = %+v, %v", syms, err)
+	}
+	want := filepath.Join(to, "pkg", "a.go")
+	for _, s := range syms {
+		if s.File != want {
+			t.Errorf("%s file = %s, want %s", s.Name, s.File, want)
+		}
+	}
+	callers, err := imported.GetCallers("g", db.QueryOptions{})
+	if err != nil || len(callers) != 1 || callers[0].CallFile != want {
+		t.Errorf("callers = %+v, %v", callers, err)
+	}
+
+	if _, err := Import(filepath.Join(from, "pkg"), to, dbPath, ImportOptions{AllowCommitMismatch: true}); err == nil {
+		t.Error("Import of a non-archive succeeded")
+	}
+}
@@ -0,0 +1,82 @@
+package bundle
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Packer admits pieces of text in the order they are offered until a token
+// budget is spent. Callers offer the most important text first.
+type Packer struct {
+	max  int
+	used int
+}
+
+// NewPacker creates a packer with a budget of maxTokens. A budget of zero or
+// less admits everything.
+func NewPacker(maxTokens int) *Packer {
+	return &Packer{max: maxTokens}
+}
+
+// Used returns the estimated tokens admitted so far.
+func (p *Packer) Used() int {
+	return p.used
+}
+
+// Remaining returns the tokens left in the budget (-1 when unlimited).
+func (p *Packer) Remaining() int {
+	if p.max <= 0 {
+		return -1
+	}
+	if p.used >= p.max {
+		return 0
+	}
+	return p.max - p.used
+}
+
+// Add admits text unconditionally, for content that must always be present.
+func (p *Packer) Add(text string) {
+	p.used += EstimateTokens(text)
+}
+
+// Fit admits text if it fits in the remaining budget.
+func (p *Packer) Fit(text string) bool {
+	cost := EstimateTokens(text)
+	if p.max > 0 && p.used+cost > p.max {
+		return false
+	}
+	p.used += cost
+	return true
+}
+
+// FitLines admits as many leading lines of text as fit, spending at most
+// limit tokens on it (limit <= 0 allows the whole remaining budget). When
+// lines are cut, a marker noting how many were omitted is appended and
+// truncated is true.
+func (p *Packer) FitLines(text string, limit int) (kept string, truncated bool) {
+	remaining := p.Remaining()
+	if limit > 0 && (remaining < 0 || limit < remaining) {
+		remaining = limit
+	}
+	if remaining < 0 || EstimateTokens(text) <= remaining {
+		p.Add(text)
+		return text, false
+	}
+
+	lines := strings.Split(text, "\n")
+	var out []string
+	for i, line := range lines {
+		marker := fmt.Sprintf("... (%d more lines)", len(lines)-i)
+		// Keep room for the marker so the result never exceeds the budget
+		cost := EstimateTokens(line)
+		if remaining < cost+EstimateTokens(marker) {
+			p.Add(marker)
+			out = append(out, marker)
+			return strings.Join(out, "\n"), true
+		}
+		p.Add(line)
+		remaining -= cost
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n"), false
+}
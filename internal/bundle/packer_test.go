@@ -0,0 +1,79 @@
+package bundle
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	cases := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"   \n\t", 0},
+		{"main", 1},
+		{"authenticate", 3},
+		{"func main() {}", 6},
+		{"a.b", 3},
+	}
+	for _, tc := range cases {
+		if got := EstimateTokens(tc.text); got != tc.want {
+			t.Errorf("EstimateTokens(%q) = %d, want %d", tc.text, got, tc.want)
+		}
+	}
+}
+
+func TestPackerFit(t *testing.T) {
+	p := NewPacker(5)
+	if !p.Fit("func main()") { // 4 tokens
+		t.Fatal("first text should fit")
+	}
+	if p.Fit("return nil") { // 2 tokens, over budget
+		t.Error("second text should not fit")
+	}
+	if !p.Fit("x") {
+		t.Error("one-token text should fit")
+	}
+	if p.Remaining() != 0 {
+		t.Errorf("Remaining = %d, want 0", p.Remaining())
+	}
+}
+
+func TestPackerUnlimited(t *testing.T) {
+	p := NewPacker(0)
+	big := strings.Repeat("word ", 10000)
+	if !p.Fit(big) {
+		t.Error("unlimited packer should admit everything")
+	}
+	if p.Remaining() != -1 {
+		t.Errorf("Remaining = %d, want -1", p.Remaining())
+	}
+}
+
+func TestPackerFitLines(t *testing.T) {
+	p := NewPacker(15)
+	text := strings.TrimSuffix(strings.Repeat("next line\n", 10), "\n") // 20 tokens
+	kept, truncated := p.FitLines(text, 0)
+	if !truncated {
+		t.Fatal("expected truncation")
+	}
+	if kept != "next line\nnext line\nnext line\n... (7 more lines)" {
+		t.Errorf("kept = %q", kept)
+	}
+	if p.Used() > 15 {
+		t.Errorf("Used = %d, exceeds budget", p.Used())
+	}
+}
+
+func TestPackerFitLinesLimit(t *testing.T) {
+	p := NewPacker(100)
+	text := strings.TrimSuffix(strings.Repeat("next line\n", 10), "\n")
+	kept, truncated := p.FitLines(text, 13)
+	if !truncated || kept != "next line\nnext line\n... (8 more lines)" {
+		t.Errorf("kept = %q, truncated = %v", kept, truncated)
+	}
+	if p.Remaining() < 80 {
+		t.Errorf("Remaining = %d, limit should leave the rest of the budget", p.Remaining())
+	}
+}
@@ -0,0 +1,105 @@
+package bundle
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/tk-425/Codegraph/internal/lsp"
+)
+
+// Snippet is a contiguous range of source lines around a symbol.
+type Snippet struct {
+	File      string
+	StartLine int      // First line in Lines (1-indexed)
+	Lines     []string // Source lines, trailing whitespace removed
+}
+
+// EndLine returns the last line number covered by the snippet.
+func (s *Snippet) EndLine() int {
+	return s.StartLine + len(s.Lines) - 1
+}
+
+// Text returns the snippet as source text.
+func (s *Snippet) Text() string {
+	return strings.Join(s.Lines, "\n")
+}
+
+// Numbered returns the snippet with a right-aligned line number gutter.
+func (s *Snippet) Numbered() string {
+	width := len(fmt.Sprint(s.EndLine()))
+	out := make([]string, len(s.Lines))
+	for i, line := range s.Lines {
+		out[i] = strings.TrimRight(fmt.Sprintf("%*d  %s", width, s.StartLine+i, line), " ")
+	}
+	return strings.Join(out, "\n")
+}
+
+// ReadSnippet reads lines startLine..endLine (1-indexed, inclusive) of path
+// plus contextLines lines on either side. When endColumn > 0 and no context
+// is requested, the last line is cut at endColumn (exclusive, 0-indexed) so
+// trailing code after the symbol is not included. endColumn counts bytes,
+// as tree-sitter does.
+func ReadSnippet(path string, startLine, endLine, endColumn, contextLines int) (*Snippet, error) {
+	return readSnippet(path, startLine, endLine, contextLines, func(string) int { return endColumn })
+}
+
+// ReadLSPSnippet is ReadSnippet for an end column counted in UTF-16 code
+// units, as language servers report it, so lines with non-ASCII text are
+// cut where the symbol ends rather than inside a character.
+func ReadLSPSnippet(path string, startLine, endLine, endCharacter, contextLines int) (*Snippet, error) {
+	return readSnippet(path, startLine, endLine, contextLines, func(line string) int {
+		if endCharacter <= 0 {
+			return 0
+		}
+		return lsp.ByteOffset(line, endCharacter)
+	})
+}
+
+// readSnippet reads a snippet, cutting its last line at the byte offset
+// endColumn returns for it
+func readSnippet(path string, startLine, endLine, contextLines int, endColumn func(line string) int) (*Snippet, error) {
+	if endLine < startLine {
+		endLine = startLine
+	}
+	if contextLines < 0 {
+		contextLines = 0
+	}
+	from := max(startLine-contextLines, 1)
+	to := endLine + contextLines
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	snippet := &Snippet{File: path, StartLine: from}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	current := 0
+	for scanner.Scan() {
+		current++
+		if current < from {
+			continue
+		}
+		if current > to {
+			break
+		}
+		line := scanner.Text()
+		if current == endLine && contextLines == 0 {
+			if end := endColumn(line); end > 0 && end < len(line) {
+				line = line[:end]
+			}
+		}
+		snippet.Lines = append(snippet.Lines, strings.TrimRight(line, " \t\r"))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(snippet.Lines) == 0 {
+		return nil, fmt.Errorf("%s has no line %d", path, startLine)
+	}
+	return snippet, nil
+}
@@ -0,0 +1,81 @@
+package bundle
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"unicode/utf8"
+)
+
+func writeSource(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "main.go")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write source: %v", err)
+	}
+	return path
+}
+
+func TestReadSnippet(t *testing.T) {
+	path := writeSource(t, "package main\n\nfunc a() {\n\tb()\n}; var x = 1\n\nfunc b() {}\n")
+
+	s, err := ReadSnippet(path, 3, 5, 1, 0)
+	if err != nil {
+		t.Fatalf("ReadSnippet: %v", err)
+	}
+	if want := "func a() {\n\tb()\n}"; s.Text() != want {
+		t.Errorf("Text = %q, want %q", s.Text(), want)
+	}
+
+	s, err = ReadSnippet(path, 3, 5, 1, 1)
+	if err != nil {
+		t.Fatalf("ReadSnippet: %v", err)
+	}
+	if s.StartLine != 2 || s.EndLine() != 6 {
+		t.Errorf("range = %d-%d, want 2-6", s.StartLine, s.EndLine())
+	}
+	if want := "}; var x = 1"; s.Lines[3] != want {
+		t.Errorf("context keeps whole lines: got %q, want %q", s.Lines[3], want)
+	}
+}
+
+func TestReadLSPSnippetCutsNonASCIILinesAtTheCharacter(t *testing.T) {
+	path := writeSource(t, "func a() {\n}\nvar s = \"é😀\"; var y = 2\n")
+
+	// The server counts é as one UTF-16 unit and 😀 as two: character 13
+	// is byte 16
+	s, err := ReadLSPSnippet(path, 3, 3, 13, 0)
+	if err != nil {
+		t.Fatalf("ReadLSPSnippet: %v", err)
+	}
+	if want := `var s = "é😀"`; s.Text() != want || !utf8.ValidString(s.Text()) {
+		t.Errorf("Text = %q, want %q", s.Text(), want)
+	}
+
+	s, err = ReadSnippet(path, 3, 3, 16, 0)
+	if err != nil {
+		t.Fatalf("ReadSnippet: %v", err)
+	}
+	if want := `var s = "é😀"`; s.Text() != want {
+		t.Errorf("byte column: Text = %q, want %q", s.Text(), want)
+	}
+}
+
+func TestReadSnippetNumbered(t *testing.T) {
+	path := writeSource(t, "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n")
+
+	s, err := ReadSnippet(path, 9, 10, 0, 1)
+	if err != nil {
+		t.Fatalf("ReadSnippet: %v", err)
+	}
+	if want := " 8  8\n 9  9\n10  10\n11  11"; s.Numbered() != want {
+		t.Errorf("Numbered = %q, want %q", s.Numbered(), want)
+	}
+}
+
+func TestReadSnippetMissingLine(t *testing.T) {
+	path := writeSource(t, "one line\n")
+	if _, err := ReadSnippet(path, 5, 6, 0, 0); err == nil {
+		t.Error("expected error for line past end of file")
+	}
+}
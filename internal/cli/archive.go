@@ -0,0 +1,209 @@
+package cli
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/spf13/cobra"
+	"github.com/tk-425/Codegraph/internal/archive"
+	"github.com/tk-425/Codegraph/internal/config"
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+var (
+	exportFormatFlag string
+	exportOutputFlag string
+	exportTableFlag  string
+	importForceFlag  bool
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the index as a compressed, portable archive or as CSV",
+	Long: `Write the index to a compressed archive holding a consistent copy of the
+database and a manifest: the codegraph and schema versions, the project
+root, the git commit it was built at, and its size. Load it in another
+checkout of the project with 'codegraph import'.
+
+--format=csv instead writes one table with a header row, for pandas,
+Excel and the like, to standard output or -o:
+
+  symbols    id, name, kind, file, line, column, end_line, end_column,
+             scope, signature, language, source, package, is_test
+  calls      caller_id, caller, callee_id, callee, file, line, column,
+             arg_count, args (one row per call site)
+  hierarchy  child_id, child, parent_id, parent, relationship, type_args
+
+Files are relative to the project root.
+
+Examples:
+  codegraph export
+  codegraph export --format=archive -o /tmp/index.tar.gz
+  codegraph export --format=csv --table=calls > calls.csv
+  codegraph export --format=csv --table=hierarchy -o hierarchy.csv`,
+	Args: cobra.NoArgs,
+	RunE: runExport,
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import <archive>",
+	Short: "Replace the index with one from 'codegraph export'",
+	Long: `Replace the project's index with the one in an archive made by
+'codegraph export'. The archive must come from a codegraph with the same
+schema version, and from the git commit currently checked out; --force
+skips the commit check (run 'codegraph build' afterwards to catch up on
+changed files). File paths are rewritten to this checkout's root.
+
+Examples:
+  codegraph import index.tar.gz
+  codegraph import ci-index.tar.gz --force && codegraph build`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImport,
+}
+
+func init() {
+	exportCmd.Flags().StringVar(&exportFormatFlag, "format", "archive", "Export format (archive: gzipped tar of the database and a manifest; csv: one table)")
+	exportCmd.Flags().StringVarP(&exportOutputFlag, "output", "o", "", "Output path (default: <project>-index.tar.gz, or standard output for csv)")
+	exportCmd.Flags().StringVar(&exportTableFlag, "table", "", "Table to export with --format=csv: symbols, calls, or hierarchy")
+	importCmd.Flags().BoolVar(&importForceFlag, "force", false, "Import even if the archive was exported at another git commit")
+	rootCmd.AddCommand(exportCmd, importCmd)
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	switch exportFormatFlag {
+	case "archive":
+		if exportTableFlag != "" {
+			return fmt.Errorf("--table only applies to --format=csv")
+		}
+	case formatCSV:
+		if exportTableFlag == "" {
+			return fmt.Errorf("--format=csv needs --table (symbols, calls, or hierarchy)")
+		}
+		if !slices.Contains(db.ExportTables, exportTableFlag) {
+			return fmt.Errorf("invalid --table %q (expected symbols, calls, or hierarchy)", exportTableFlag)
+		}
+	default:
+		return fmt.Errorf("unsupported export format %q (supported: archive, csv)", exportFormatFlag)
+	}
+	cwd, _, dbManager, _, err := openProject(true)
+	if err != nil {
+		return err
+	}
+	defer dbManager.Close()
+
+	if exportFormatFlag == formatCSV {
+		return exportCSV(cmd, dbManager, cwd)
+	}
+
+	output := exportOutputFlag
+	if output == "" {
+		output = filepath.Base(cwd) + "-index.tar.gz"
+	}
+	f, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+	manifest, err := archive.Export(dbManager, cwd, Version, f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(output)
+		return fmt.Errorf("failed to export index: %w", err)
+	}
+
+	info, err := os.Stat(output)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("📦 Exported %s symbols and %s calls from %s files to %s (%s)\n",
+		Info(formatNumber(manifest.Symbols)), Info(formatNumber(manifest.Calls)), Info(formatNumber(manifest.Files)),
+		Path(output), Info(formatBytes(info.Size())))
+	if manifest.GitCommit == "" {
+		fmt.Printf("⚠️  %s\n", Warning("Not a git repository: the archive cannot be checked against a commit on import"))
+	} else if manifest.GitDirty {
+		fmt.Printf("⚠️  %s\n", Warning("Uncommitted changes: the index may not match commit "+manifest.GitCommit[:12]))
+	}
+	return nil
+}
+
+// exportCSV writes the table named by --table to -o or standard output
+func exportCSV(cmd *cobra.Command, dbManager *db.Manager, root string) error {
+	var out io.Writer = cmd.OutOrStdout()
+	var f *os.File
+	if exportOutputFlag != "" {
+		var err error
+		if f, err = os.Create(exportOutputFlag); err != nil {
+			return fmt.Errorf("failed to create %s: %w", exportOutputFlag, err)
+		}
+		out = f
+	}
+
+	count, err := writeTableCSV(out, dbManager, exportTableFlag, root)
+	if f != nil {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(exportOutputFlag)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to export %s: %w", exportTableFlag, err)
+	}
+	if f != nil {
+		fmt.Printf("📦 Exported %s rows of %s to %s\n", Info(formatNumber(count)), exportTableFlag, Path(exportOutputFlag))
+	}
+	return nil
+}
+
+// writeTableCSV writes table as CSV with a header row, files relative to
+// root, and returns the number of rows
+func writeTableCSV(out io.Writer, dbManager *db.Manager, table, root string) (int, error) {
+	w := csv.NewWriter(out)
+	var columns []string
+	count, err := dbManager.ExportTable(table, func(row []string) error {
+		if columns == nil {
+			columns = row
+			return w.Write(row)
+		}
+		for i, name := range columns {
+			if name == "file" {
+				row[i] = relativePath(root, row[i])
+			}
+		}
+		return w.Write(row)
+	})
+	if err != nil {
+		return count, err
+	}
+	w.Flush()
+	return count, w.Error()
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+	if _, err := os.Stat(filepath.Join(cwd, config.DefaultConfigDir)); os.IsNotExist(err) {
+		return errNotInitialized
+	}
+	cfg, err := config.Load(cwd)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	manifest, err := archive.Import(args[0], cwd, cfg.GetDatabasePath(cwd), archive.ImportOptions{AllowCommitMismatch: importForceFlag})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("📦 Imported %s symbols and %s calls from %s files (exported %s by codegraph %s)\n",
+		Info(formatNumber(manifest.Symbols)), Info(formatNumber(manifest.Calls)), Info(formatNumber(manifest.Files)),
+		manifest.Created.Local().Format("2006-01-02 15:04"), manifest.CodegraphVersion)
+	return nil
+}
@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// stdinFlagUsage describes the --stdin flag of batch-capable commands
+const stdinFlagUsage = "Read symbols from standard input, one per line, and answer for each (with --json, results are keyed by symbol)"
+
+// symbolOrStdinArgs accepts one symbol argument, or none when *stdin is set
+func symbolOrStdinArgs(stdin *bool) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if *stdin {
+			if len(args) > 0 {
+				return fmt.Errorf("--stdin reads symbols from standard input; do not also pass %q", args[0])
+			}
+			return nil
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	}
+}
+
+// readBatchSymbols reads newline-separated symbols, skipping blank lines,
+// # comments and repeats
+func readBatchSymbols(r io.Reader) ([]string, error) {
+	var symbols []string
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		symbol := strings.TrimSpace(scanner.Text())
+		if symbol == "" || strings.HasPrefix(symbol, "#") || seen[symbol] {
+			continue
+		}
+		seen[symbol] = true
+		symbols = append(symbols, symbol)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read symbols from standard input: %w", err)
+	}
+	if len(symbols) == 0 {
+		return nil, fmt.Errorf("no symbols on standard input")
+	}
+	return symbols, nil
+}
+
+// printBatch prints the answer for each symbol. It returns errNoResults
+// only when no symbol had results.
+func printBatch(cmd *cobra.Command, symbols []string, print func(symbol string) error) error {
+	found := false
+	for _, symbol := range symbols {
+		err := print(symbol)
+		if errors.Is(err, errNoResults) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		found = true
+	}
+	if !found {
+		return noResults(cmd)
+	}
+	return nil
+}
+
+// batchResult is one input symbol's answer in a --stdin --json envelope:
+// the envelope the command would have printed for the symbol alone
+type batchResult struct {
+	Symbol  string          `json:"symbol"`
+	Count   int             `json:"count"`
+	Results json.RawMessage `json:"results"`
+	Errors  []EnvelopeError `json:"errors"`
+}
+
+// emitBatchJSON writes one envelope with a batchResult per symbol, each
+// filled by query writing the symbol's own envelope. A failed symbol keeps
+// its errors in its result without stopping the others; the first failure
+// is returned once every symbol is answered, else errNoResults when no
+// symbol had results.
+func emitBatchJSON(out io.Writer, command string, symbols []string, query func(w io.Writer, symbol string) error) error {
+	stream := StartJSON(out, command, nil, len(symbols))
+	var firstErr error
+	found := false
+	for _, symbol := range symbols {
+		var buf bytes.Buffer
+		err := query(&buf, symbol)
+		if errors.Is(err, errNoResults) {
+			err = nil
+		} else if err == nil {
+			found = true
+		}
+
+		var env struct {
+			Count   int             `json:"count"`
+			Results json.RawMessage `json:"results"`
+			Errors  []EnvelopeError `json:"errors"`
+		}
+		if derr := json.Unmarshal(buf.Bytes(), &env); derr != nil {
+			if err == nil {
+				err = derr
+			}
+			env.Results = json.RawMessage("[]")
+			env.Errors = []EnvelopeError{{Code: "batch_query_failed", Message: err.Error()}}
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if werr := stream.Write(batchResult{Symbol: symbol, Count: env.Count, Results: env.Results, Errors: env.Errors}); werr != nil {
+			return werr
+		}
+	}
+	if err := stream.Close(nil); err != nil {
+		return err
+	}
+	if firstErr == nil && !found {
+		return errNoResults
+	}
+	return firstErr
+}
@@ -0,0 +1,200 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tk-425/Codegraph/internal/config"
+	"github.com/tk-425/Codegraph/internal/indexer"
+)
+
+var benchPprofFlag string
+
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Time the build phases on the current project",
+	Long: `Time scanning, parsing, symbol insertion and call extraction on the
+current project, so performance changes across releases can be measured.
+
+The benchmark indexes with tree-sitter alone, so its numbers do not depend
+on the language servers installed, into a scratch database that is removed
+afterwards: the project's index is left untouched.
+
+--pprof writes a CPU profile (cpu.pprof) of the run and a heap profile
+(heap.pprof) at its end into a directory, for 'go tool pprof'. --json prints
+the timings as a JSON envelope, to keep them for comparison. --profile
+applies a config.toml profile, as for every command.
+
+Examples:
+  codegraph bench
+  codegraph bench --pprof=profiles && go tool pprof -top profiles/cpu.pprof
+  codegraph bench --json > bench.json`,
+	Args: cobra.NoArgs,
+	RunE: runBench,
+}
+
+func init() {
+	benchCmd.Flags().StringVar(&benchPprofFlag, "pprof", "", "Write CPU and heap pprof profiles into this directory")
+	rootCmd.AddCommand(benchCmd)
+}
+
+type benchRecord struct {
+	Phase      string  `json:"phase"`
+	DurationMs float64 `json:"duration_ms"`
+	Files      int     `json:"files"`
+	Items      int     `json:"items"`
+	Unit       string  `json:"unit"` // What items counts
+	PerSecond  float64 `json:"per_second"`
+}
+
+// benchUnits names what each phase's items are
+var benchUnits = map[string]string{
+	indexer.BenchScan:   "files",
+	indexer.BenchParse:  "symbols",
+	indexer.BenchInsert: "symbols",
+	indexer.BenchCalls:  "calls",
+}
+
+func newBenchRecord(p indexer.BenchPhase) benchRecord {
+	r := benchRecord{
+		Phase:      p.Name,
+		DurationMs: float64(p.Duration.Microseconds()) / 1000,
+		Files:      p.Files,
+		Items:      p.Items,
+		Unit:       benchUnits[p.Name],
+	}
+	if p.Duration > 0 {
+		r.PerSecond = float64(p.Items) / p.Duration.Seconds()
+	}
+	return r
+}
+
+// runBenchmark runs the benchmark, profiled into benchPprofFlag when set,
+// and returns the written profiles
+func runBenchmark(cwd string, cfg *config.Config) ([]indexer.BenchPhase, []string, error) {
+	cgignorePath := filepath.Join(cwd, ".codegraph", ".cgignore")
+	if benchPprofFlag == "" {
+		phases, err := indexer.Bench(context.Background(), cfg, cwd, cgignorePath)
+		return phases, nil, err
+	}
+
+	if err := os.MkdirAll(benchPprofFlag, 0755); err != nil {
+		return nil, nil, fmt.Errorf("failed to create profile directory: %w", err)
+	}
+	cpuPath := filepath.Join(benchPprofFlag, "cpu.pprof")
+	cpu, err := os.Create(cpuPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create CPU profile: %w", err)
+	}
+	defer cpu.Close()
+	if err := pprof.StartCPUProfile(cpu); err != nil {
+		return nil, nil, fmt.Errorf("failed to start CPU profile: %w", err)
+	}
+	phases, err := indexer.Bench(context.Background(), cfg, cwd, cgignorePath)
+	pprof.StopCPUProfile()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	heapPath := filepath.Join(benchPprofFlag, "heap.pprof")
+	heap, err := os.Create(heapPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create heap profile: %w", err)
+	}
+	defer heap.Close()
+	runtime.GC() // Profile the live heap, not garbage
+	if err := pprof.WriteHeapProfile(heap); err != nil {
+		return nil, nil, fmt.Errorf("failed to write heap profile: %w", err)
+	}
+	return phases, []string{cpuPath, heapPath}, nil
+}
+
+func runBench(cmd *cobra.Command, args []string) error {
+	if jsonOutputFlag {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		return runBenchJSON(cmd)
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+	if _, err := os.Stat(filepath.Join(cwd, ".codegraph")); os.IsNotExist(err) {
+		return errNotInitialized
+	}
+	cfg, err := config.Load(cwd)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	cmd.SilenceUsage = true
+
+	fmt.Printf("⏱️  %s\n", Bold("Benchmarking the build phases (tree-sitter, scratch database)..."))
+	phases, profiles, err := runBenchmark(cwd, cfg)
+	if err != nil {
+		return fmt.Errorf("benchmark failed: %w", err)
+	}
+
+	fmt.Println()
+	var total time.Duration
+	for _, p := range phases {
+		r := newBenchRecord(p)
+		total += p.Duration
+		line := fmt.Sprintf("   %-8s %9s  %s files", p.Name, formatBenchDuration(p.Duration), formatNumber(p.Files))
+		if p.Name != indexer.BenchScan {
+			line += fmt.Sprintf(", %s %s  %s", formatNumber(p.Items), r.Unit, Dim(fmt.Sprintf("(%.0f %s/s)", r.PerSecond, r.Unit)))
+		}
+		fmt.Println(line)
+	}
+	fmt.Printf("   %s    %9s\n", Bold("total"), Info(formatBenchDuration(total)))
+
+	if len(profiles) > 0 {
+		fmt.Printf("\n📈 Profiles written: %s, %s\n", Path(relativePath(cwd, profiles[0])), Path(relativePath(cwd, profiles[1])))
+		fmt.Printf("   %s\n", Dim("Inspect them with 'go tool pprof -top <file>'"))
+	}
+	return nil
+}
+
+// formatBenchDuration prints d in milliseconds, or seconds from one second
+func formatBenchDuration(d time.Duration) string {
+	if d >= time.Second {
+		return fmt.Sprintf("%.2fs", d.Seconds())
+	}
+	return fmt.Sprintf("%.1fms", float64(d.Microseconds())/1000)
+}
+
+func runBenchJSON(cmd *cobra.Command) error {
+	out := cmd.OutOrStdout()
+	emitErr := func(code string, err error) error {
+		_ = EmitJSON(out, "bench", nil, []benchRecord{}, []EnvelopeError{{Code: code, Message: err.Error()}})
+		return err
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return emitErr("cwd_failed", fmt.Errorf("failed to get current directory: %w", err))
+	}
+	if _, err := os.Stat(filepath.Join(cwd, ".codegraph")); os.IsNotExist(err) {
+		return emitErr("not_initialized", errNotInitialized)
+	}
+	cfg, err := config.Load(cwd)
+	if err != nil {
+		return emitErr("config_load_failed", fmt.Errorf("failed to load config: %w", err))
+	}
+
+	phases, _, err := runBenchmark(cwd, cfg)
+	if err != nil {
+		return emitErr("bench_failed", fmt.Errorf("benchmark failed: %w", err))
+	}
+	records := make([]benchRecord, 0, len(phases))
+	for _, p := range phases {
+		records = append(records, newBenchRecord(p))
+	}
+	return EmitJSON(out, "bench", nil, records, nil)
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tk-425/Codegraph/internal/config"
+)
+
+func TestBenchProfileSelectsTheConfigProfile(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	if err := config.Save(dir, config.DefaultConfig()); err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(dir, config.DefaultConfigDir, "config.toml")
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = append(data, "\n[profiles.ci.index]\nstrategy = \"treesitter\"\n"...)
+	if err := os.WriteFile(configPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, config.DefaultConfigDir, ".cgignore"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(config.ProfileEnv, "")
+	t.Cleanup(func() {
+		profileFlag, benchPprofFlag = "", ""
+		rootCmd.SetArgs(nil)
+	})
+
+	pprofDir := filepath.Join(t.TempDir(), "pprof")
+	rootCmd.SetArgs([]string{"bench", "--profile=ci", "--pprof=" + pprofDir})
+	captureStdout(t, func() {
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("bench: %v", err)
+		}
+	})
+
+	if got := os.Getenv(config.ProfileEnv); got != "ci" {
+		t.Errorf("%s = %q, want ci", config.ProfileEnv, got)
+	}
+	if _, err := os.Stat(filepath.Join(pprofDir, "cpu.pprof")); err != nil {
+		t.Errorf("--pprof wrote no CPU profile: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ci")); !os.IsNotExist(err) {
+		t.Errorf("--profile=ci was taken as a pprof directory (stat err %v)", err)
+	}
+}
@@ -0,0 +1,187 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tk-425/Codegraph/internal/db"
+	"github.com/tk-425/Codegraph/internal/history"
+)
+
+var (
+	blameLangFlag string
+	blameKindFlag string
+)
+
+var blameCmd = &cobra.Command{
+	Use:   "blame <symbol>",
+	Short: "Show when a symbol was introduced, last changed, and by whom",
+	Long: `Show the git history of the specified symbol: the commit that
+introduced its lines, the commit that changed them last, and how many
+commits touched them (its churn) by how many authors.
+
+History is read with git log -L the first time a symbol is blamed and
+stored in the index until HEAD or the symbol's lines change. Blamed
+symbols then rank by churn in the commands taking --sort=churn. Changes
+not committed yet are not part of the history.
+
+Examples:
+  codegraph blame parseConfig
+  codegraph blame Save --lang=go --kind=method
+  codegraph blame handleRequest --json
+  codegraph callers parseConfig --sort=churn`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBlame,
+}
+
+func init() {
+	blameCmd.Flags().StringVar(&blameLangFlag, "lang", "", "Filter by language(s), comma-separated")
+	blameCmd.Flags().StringVar(&blameKindFlag, "kind", "", kindFlagUsage)
+	rootCmd.AddCommand(blameCmd)
+}
+
+// blamedSymbol is a symbol with its history, or why it has none
+type blamedSymbol struct {
+	db.Symbol
+	History *db.SymbolHistory
+	Err     error
+}
+
+// commitRecord is a commit in the JSON form of a blamedSymbol
+type commitRecord struct {
+	Commit string `json:"commit"`
+	Author string `json:"author"`
+	Date   string `json:"date"`
+}
+
+// blameRecord is the JSON form of a blamedSymbol
+type blameRecord struct {
+	Name       string        `json:"name"`
+	Kind       string        `json:"kind"`
+	File       string        `json:"file"`
+	Line       int           `json:"line"`
+	Introduced *commitRecord `json:"introduced"`
+	Modified   *commitRecord `json:"modified"`
+	Commits    int           `json:"commits"`
+	Authors    []string      `json:"authors"`
+	Error      string        `json:"error,omitempty"` // Why the history is unknown, e.g. a file not committed yet
+}
+
+// findHistories returns the symbols named symbol with their git history
+func findHistories(ctx context.Context, dbManager *db.Manager, root, symbol string, opts db.QueryOptions) ([]blamedSymbol, error) {
+	symbols, err := dbManager.FindSymbolsByName(symbol, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find symbol: %w", err)
+	}
+	if len(symbols) == 0 {
+		return nil, nil
+	}
+	resolver, err := history.NewResolver(ctx, dbManager, root)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]blamedSymbol, 0, len(symbols))
+	for _, sym := range symbols {
+		h, err := resolver.Symbol(ctx, sym)
+		result = append(result, blamedSymbol{Symbol: sym, History: h, Err: err})
+	}
+	return result, nil
+}
+
+func runBlame(cmd *cobra.Command, args []string) error {
+	symbol := args[0]
+	if jsonOutputFlag {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		return runBlameJSON(cmd, symbol)
+	}
+
+	cwd, _, dbManager, _, err := openProject(true)
+	if err != nil {
+		return err
+	}
+	defer dbManager.Close()
+
+	symbols, err := findHistories(cmd.Context(), dbManager, cwd, symbol, queryOptions(blameLangFlag, blameKindFlag))
+	if err != nil {
+		return err
+	}
+	if len(symbols) == 0 {
+		fmt.Printf("🕰️  No symbol named '%s' found in database\n", symbol)
+		return noResults(cmd)
+	}
+
+	commit := func(hash, author, date string) string {
+		return fmt.Sprintf("%s %s by %s", Keyword(shortHash(hash)), date, Info(author))
+	}
+	for i, s := range symbols {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("🕰️  %s [%s] %s\n", Symbol(s.Name), Keyword(s.Kind), Path(fmt.Sprintf("%s:%d", relativePath(cwd, s.File), s.Line)))
+		switch {
+		case s.Err != nil:
+			fmt.Printf("  %s\n", Warning(fmt.Sprintf("No history: %v", s.Err)))
+		case s.History.Commits == 0:
+			fmt.Printf("  %s\n", Dim("Not committed yet"))
+		default:
+			h := s.History
+			fmt.Printf("  Introduced: %s\n", commit(h.IntroducedCommit, h.IntroducedAuthor, h.IntroducedAt.Format("2006-01-02")))
+			fmt.Printf("  Modified:   %s\n", commit(h.ModifiedCommit, h.ModifiedAuthor, h.ModifiedAt.Format("2006-01-02")))
+			fmt.Printf("  Churn:      %s commits by %s\n", Info(h.Commits), Dim(strings.Join(h.Authors, ", ")))
+		}
+	}
+	return nil
+}
+
+func runBlameJSON(cmd *cobra.Command, symbol string) error {
+	out := cmd.OutOrStdout()
+	emitErr := func(code string, err error) error {
+		_ = EmitJSON(out, "blame", &symbol, []blameRecord{}, []EnvelopeError{{Code: code, Message: err.Error()}})
+		return err
+	}
+
+	cwd, _, dbManager, code, err := openProject(true)
+	if err != nil {
+		return emitErr(code, err)
+	}
+	defer dbManager.Close()
+
+	symbols, err := findHistories(cmd.Context(), dbManager, cwd, symbol, queryOptions(blameLangFlag, blameKindFlag))
+	if err != nil {
+		return emitErr("blame_failed", err)
+	}
+	records := make([]blameRecord, 0, len(symbols))
+	for _, s := range symbols {
+		rec := blameRecord{
+			Name:    s.Name,
+			Kind:    s.Kind,
+			File:    relativePath(cwd, s.File),
+			Line:    s.Line,
+			Authors: []string{},
+		}
+		switch {
+		case s.Err != nil:
+			rec.Error = s.Err.Error()
+		case s.History.Commits > 0:
+			h := s.History
+			rec.Introduced = &commitRecord{Commit: h.IntroducedCommit, Author: h.IntroducedAuthor, Date: h.IntroducedAt.Format(time.RFC3339)}
+			rec.Modified = &commitRecord{Commit: h.ModifiedCommit, Author: h.ModifiedAuthor, Date: h.ModifiedAt.Format(time.RFC3339)}
+			rec.Commits = h.Commits
+			rec.Authors = h.Authors
+		}
+		records = append(records, rec)
+	}
+	return emitResults(cmd, "blame", &symbol, records)
+}
+
+// shortHash abbreviates a commit hash like git does by default
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
@@ -2,10 +2,14 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/tk-425/Codegraph/internal/config"
@@ -13,7 +17,16 @@ import (
 	"github.com/tk-425/Codegraph/internal/indexer"
 )
 
-var forceFlag bool
+var (
+	forceFlag      bool
+	progressFlag   string
+	noProgressFlag bool
+	reportFlag     bool
+	shardFlag      string
+	fastFlag       bool
+	strategyFlag   string
+	buildTimeout   time.Duration
+)
 
 var buildCmd = &cobra.Command{
 	Use:   "build",
@@ -24,25 +37,108 @@ var buildCmd = &cobra.Command{
 		"2. Starts LSP servers for detected languages\n" +
 		"3. Extracts symbols from all source files\n" +
 		"4. Stores symbols in the database\n\n" +
-		"Edit `.codegraph/.cgignore` and rerun `codegraph build` to change what gets indexed.\n\n" +
-		"Use --force to perform a full rebuild (delete and recreate database).",
+		"Edit `.codegraph/.cgignore` and rerun `codegraph build` to change what gets indexed.\n" +
+		"Set `use_gitignore = true` under [index] in config.toml to also skip files\n" +
+		"ignored by the project's .gitignore files; .cgignore rules take precedence.\n" +
+		"[index] also sets max_file_size (bytes), exclude_generated, follow_symlinks,\n" +
+		"and per-language include/exclude globs under [index.languages.<lang>].\n" +
+		"In a monorepo, [[workspaces]] entries (root, languages) limit indexing to\n" +
+		"those roots while sharing one database.\n\n" +
+		"Incremental builds re-index only changed files and refresh just the call\n" +
+		"edges made from and to them.\n\n" +
+		"Use --force to perform a full rebuild (delete and recreate database).\n\n" +
+		"Progress is shown as per-language bars with files/sec, ETA, and symbols\n" +
+		"found. Use --no-progress for CI logs, or --progress=json to stream one JSON\n" +
+		"event per line to stderr (language_started, file, language_finished).\n\n" +
+		"Every build records per-file status, extractor, symbol and call counts,\n" +
+		"durations, and errors in .codegraph/last-build.json. Use --report to print\n" +
+		"a summary of it after the build, or `codegraph stats --last-build` later.\n\n" +
+		"Use --shard i/N to index only the i-th of N shards of the files, so CI can\n" +
+		"build a large repository on N machines in parallel. Files are assigned by\n" +
+		"a hash of their path, so every machine agrees on the shards. A shard build\n" +
+		"always starts from an empty database and skips the call graph and other\n" +
+		"links between files; copy each shard's database out of .codegraph and\n" +
+		"combine them with `codegraph merge`, which links them.\n\n" +
+		"Use --fast to skip the language servers: symbols, calls and type hierarchy\n" +
+		"all come from tree-sitter. Calls are resolved by name only, so the index is\n" +
+		"less precise, but large repositories build in a fraction of the time, which\n" +
+		"suits CI. Files indexed by a fast build are kept by later incremental builds\n" +
+		"until they change; use --force to re-index everything with the servers.\n\n" +
+		"--fast is the treesitter extraction strategy for every language. By default\n" +
+		"each language is extracted with the hybrid strategy: its language server,\n" +
+		"falling back to tree-sitter per file when the server fails or finds nothing.\n" +
+		"Use --strategy=lsp|treesitter|hybrid to pick one for every language, or set\n" +
+		"`strategy` under [index] (project) or [index.languages.<lang>] (one language)\n" +
+		"in config.toml. With lsp, files the server cannot index fail instead of\n" +
+		"falling back, so every symbol and edge comes from the server. The report\n" +
+		"records each language's strategy and each file's extractor.\n\n" +
+		"Ctrl-C stops the build cleanly: language servers are shut down, the file\n" +
+		"being written is rolled back, and the files indexed so far are kept. The\n" +
+		"next `codegraph build` indexes the remaining files and links every file\n" +
+		"again. Press Ctrl-C twice to exit at once. --timeout stops a build the same\n" +
+		"way once it has run that long, so a hung language server cannot hold up CI.\n\n" +
+		"Examples:\n" +
+		"  codegraph build\n" +
+		"  codegraph build --no-progress\n" +
+		"  codegraph build --progress=json 2> progress.jsonl\n" +
+		"  codegraph build --report\n" +
+		"  codegraph build --shard 2/4 --no-progress\n" +
+		"  codegraph build --fast --no-progress\n" +
+		"  codegraph build --force --strategy=lsp --report\n" +
+		"  codegraph build --timeout=30m --no-progress",
 	RunE: runBuild,
 }
 
 func init() {
 	buildCmd.Flags().BoolVar(&forceFlag, "force", false, "Force full rebuild (delete and recreate database)")
+	buildCmd.Flags().StringVar(&progressFlag, "progress", progressBar, "Progress output: bar, json (events on stderr), or none")
+	buildCmd.Flags().BoolVar(&noProgressFlag, "no-progress", false, "Disable progress output (same as --progress=none)")
+	buildCmd.Flags().BoolVar(&reportFlag, "report", false, "Print the build report (failed files, warnings, slowest files) when done")
+	buildCmd.Flags().StringVar(&shardFlag, "shard", "", "Index only shard i of N (i/N, e.g. 2/4), for merging with 'codegraph merge'")
+	buildCmd.Flags().BoolVar(&fastFlag, "fast", false, "Index with tree-sitter only, skipping the language servers")
+	buildCmd.Flags().DurationVar(&buildTimeout, "timeout", 0, "Stop the build after this long, keeping the files indexed so far, e.g. 30m (0 = no limit)")
+	buildCmd.Flags().StringVar(&strategyFlag, "strategy", "", "Extraction strategy for every language: lsp, treesitter or hybrid (default: config.toml, else hybrid)")
 	rootCmd.AddCommand(buildCmd)
 }
 
 func runBuild(cmd *cobra.Command, args []string) error {
+	mode := progressFlag
+	if noProgressFlag {
+		mode = progressNone
+	}
+	progress, err := newProgress(mode, cmd.OutOrStdout(), cmd.ErrOrStderr())
+	if err != nil {
+		return err
+	}
+	var shard *indexer.Shard
+	if shardFlag != "" {
+		s, err := indexer.ParseShard(shardFlag)
+		if err != nil {
+			return err
+		}
+		shard = &s
+	}
+	force := forceFlag || shard != nil
+	if strategyFlag != "" && !config.ValidStrategy(strategyFlag) {
+		return fmt.Errorf("invalid --strategy %q: must be lsp, treesitter or hybrid", strategyFlag)
+	}
+	if fastFlag && strategyFlag != "" && strategyFlag != config.StrategyTreeSitter {
+		return fmt.Errorf("--fast cannot be combined with --strategy=%s", strategyFlag)
+	}
+
 	printBanner(cmd.OutOrStdout())
 	fmt.Println()
 
-	if forceFlag {
+	if shard != nil {
+		fmt.Printf("🔄 %s\n", Bold(fmt.Sprintf("Building shard %s of the database...", shard)))
+	} else if forceFlag {
 		fmt.Printf("🔄 %s\n", Bold("Force rebuilding database..."))
 	} else {
 		fmt.Printf("🔨 %s\n", Bold("Building database..."))
 	}
+	if fastFlag {
+		fmt.Printf("   %s\n", Dim("Fast mode: tree-sitter only, no language servers"))
+	}
 
 	// Get current directory
 	cwd, err := os.Getwd()
@@ -53,7 +149,7 @@ func runBuild(cmd *cobra.Command, args []string) error {
 	// Check if codegraph is initialized
 	codegraphDir := filepath.Join(cwd, ".codegraph")
 	if _, err := os.Stat(codegraphDir); os.IsNotExist(err) {
-		return fmt.Errorf("codegraph not initialized. Run 'codegraph init' first")
+		return errNotInitialized
 	}
 
 	// Load config
@@ -64,10 +160,11 @@ func runBuild(cmd *cobra.Command, args []string) error {
 
 	// Scan for files
 	cgignorePath := filepath.Join(codegraphDir, ".cgignore")
-	scanner, err := indexer.NewScanner(cwd, cgignorePath)
+	scanner, err := indexer.NewScannerWithConfig(cwd, cgignorePath, cfg.Index)
 	if err != nil {
 		return fmt.Errorf("failed to prepare scanner: %w", err)
 	}
+	scanner.SetWorkspaces(cfg.Workspaces)
 	files, err := scanner.Scan()
 	if err != nil {
 		return fmt.Errorf("failed to scan files: %w", err)
@@ -80,10 +177,40 @@ func runBuild(cmd *cobra.Command, args []string) error {
 	}
 	fmt.Printf("🔍 Found %s files in %s languages (%s)\n",
 		Info(len(files)), Info(len(languages)), Keyword(strings.Join(languages, ", ")))
+	if shard != nil {
+		// An empty shard still gets a database, so merge finds every shard
+		total := len(files)
+		files = shard.Files(files)
+		fmt.Printf("📦 Shard %s holds %s of %s files\n", Info(shard), Info(len(files)), Info(total))
+	}
+	if skipped := scanner.Skipped(); len(skipped) > 0 {
+		total := 0
+		var reasons []string
+		for _, reason := range []string{indexer.SkipTooLarge, indexer.SkipGenerated, indexer.SkipLanguage, indexer.SkipSymlink, indexer.SkipWorkspace} {
+			if n := skipped[reason]; n > 0 {
+				total += n
+				reasons = append(reasons, fmt.Sprintf("%d %s", n, reason))
+			}
+		}
+		fmt.Printf("⏭️  Skipped %s files (%s)\n", Info(total), Dim(strings.Join(reasons, ", ")))
+	}
+	if warnings := scanner.Warnings(); len(warnings) > 0 {
+		const maxShown = 10
+		for i, w := range warnings {
+			if i == maxShown {
+				fmt.Printf("⚠️  %s\n", Warning(fmt.Sprintf("... and %d more scan warnings", len(warnings)-maxShown)))
+				break
+			}
+			fmt.Printf("⚠️  %s\n", Warning(w))
+		}
+	}
 
 	// Open database
 	dbPath := cfg.GetDatabasePath(cwd)
-	dbManager, err := db.NewManager(dbPath)
+	if cfg.Database.ReadOnly {
+		return fmt.Errorf("the index is a read-only shared index (database.read_only in config.toml); refresh it with 'codegraph pull' or unset read_only to build locally")
+	}
+	dbManager, err := db.NewBuildManager(dbPath)
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
@@ -96,11 +223,56 @@ func runBuild(cmd *cobra.Command, args []string) error {
 	// Create indexer and run
 	idx := indexer.NewIndexer(cfg, dbManager, cwd)
 	defer idx.Close()
+	idx.SetProgress(progress)
+	if shard != nil {
+		idx.SetShard(*shard)
+	}
+	idx.SetFast(fastFlag)
+	idx.SetStrategy(strategyFlag)
 
-	ctx := context.Background()
-	if err := idx.IndexProject(ctx, files, forceFlag); err != nil {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := cancelAfter(ctx, buildTimeout)
+	defer cancel()
+	go func() {
+		<-ctx.Done()
+		stop() // A second Ctrl-C exits at once
+	}()
+	if err := idx.IndexProject(ctx, files, force); err != nil {
+		var interrupted *indexer.InterruptedError
+		if errors.As(err, &interrupted) {
+			cmd.SilenceUsage = true
+			var timeout timeoutError
+			if errors.As(context.Cause(ctx), &timeout) {
+				printInterrupted(interrupted, timeout.Error())
+				return staleIndex(fmt.Errorf("build %w", timeout))
+			}
+			printInterrupted(interrupted, "interrupted")
+			return staleIndex(errors.New("build interrupted"))
+		}
 		return fmt.Errorf("indexing failed: %w", err)
 	}
 
+	if err := recordBuildStats(cwd, dbManager); err != nil {
+		fmt.Printf("⚠️  %s: %v\n", Warning("Could not update project registry"), err)
+	}
+
+	if reportFlag && idx.Report() != nil {
+		fmt.Println()
+		printBuildReport(cmd.OutOrStdout(), idx.Report())
+	}
+
 	return nil
 }
+
+// printInterrupted tells what a stopped build kept and how to resume it;
+// reason says what stopped it ("interrupted", or the --timeout it hit)
+func printInterrupted(e *indexer.InterruptedError, reason string) {
+	fmt.Printf("\n⏸️  %s\n", Warning(fmt.Sprintf("Build %s while %s; stopping language servers...", reason, e.Phase)))
+	if e.Pending > 0 {
+		fmt.Printf("   Kept %s indexed files, %s left to index\n", Info(e.Indexed), Info(e.Pending))
+	} else {
+		fmt.Printf("   Kept %s indexed files; links between files are incomplete\n", Info(e.Indexed))
+	}
+	fmt.Printf("   Run %s to resume\n", Keyword("codegraph build"))
+}
@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tk-425/Codegraph/internal/config"
+	"github.com/tk-425/Codegraph/internal/indexer"
+)
+
+// slowestShown is how many of the slowest files a build report lists
+const slowestShown = 5
+
+// printBuildReport summarizes a build report: totals, failed files with
+// their errors, build warnings, and the slowest files
+func printBuildReport(w io.Writer, report *indexer.BuildReport) {
+	fmt.Fprintf(w, "📋 %s\n", Bold("Build Report"))
+	fmt.Fprintf(w, "   Started:  %s (%s)\n", Info(formatTime(&report.StartedAt)),
+		Info((time.Duration(report.DurationMs) * time.Millisecond).String()))
+	var modes []string
+	if report.Shard != "" {
+		modes = append(modes, "shard "+report.Shard)
+	} else if report.Force {
+		modes = append(modes, "full rebuild")
+	}
+	if report.Fast {
+		modes = append(modes, "fast (tree-sitter only)")
+	}
+	if len(modes) > 0 {
+		fmt.Fprintf(w, "   Mode:     %s\n", Keyword(strings.Join(modes, ", ")))
+	}
+	if strategies := formatStrategies(report.Strategies); strategies != "" && !report.Fast {
+		fmt.Fprintf(w, "   Strategy: %s\n", Keyword(strategies))
+	}
+	fmt.Fprintf(w, "   Files:    %s indexed, %s skipped, %s failed\n",
+		Info(formatNumber(report.Indexed)), Info(formatNumber(report.Skipped)), Info(formatNumber(report.Failed)))
+	fmt.Fprintf(w, "   Found:    %s symbols, %s calls, %s type relations\n",
+		Info(formatNumber(report.Symbols)), Info(formatNumber(report.Calls)), Info(formatNumber(report.TypeRelations)))
+	if report.Conflicts > 0 {
+		fmt.Fprintf(w, "   Conflicts: %s between extractors %s\n", Info(formatNumber(report.Conflicts)), Dim("(codegraph verify --conflicts)"))
+	}
+
+	var failed, timed []indexer.FileReport
+	for _, f := range report.Files {
+		switch f.Status {
+		case indexer.StatusFailed:
+			failed = append(failed, f)
+		case indexer.StatusIndexed:
+			timed = append(timed, f)
+		}
+	}
+
+	if len(failed) > 0 {
+		fmt.Fprintf(w, "\n❌ %s\n", Bold("Failed files"))
+		for _, f := range failed {
+			fmt.Fprintf(w, "   %s %s\n", Path(f.Path), Dim(f.Error))
+		}
+	}
+
+	if len(report.Warnings) > 0 {
+		fmt.Fprintf(w, "\n⚠️  %s\n", Bold("Warnings"))
+		for _, warning := range report.Warnings {
+			fmt.Fprintf(w, "   %s\n", Warning(warning))
+		}
+	}
+
+	if len(timed) > 0 {
+		sort.SliceStable(timed, func(a, b int) bool { return timed[a].DurationMs > timed[b].DurationMs })
+		if len(timed) > slowestShown {
+			timed = timed[:slowestShown]
+		}
+		fmt.Fprintf(w, "\n🐢 %s\n", Bold("Slowest files"))
+		for _, f := range timed {
+			fmt.Fprintf(w, "   %6dms  %s %s\n", f.DurationMs, Path(f.Path),
+				Dim(fmt.Sprintf("(%s, %d symbols, %d calls)", f.Source, f.Symbols, f.Calls)))
+		}
+	}
+}
+
+// formatStrategies describes the extraction strategies of a build: nothing
+// when every language used the default hybrid one, the strategy when all
+// languages shared it, else each language's
+func formatStrategies(strategies map[string]string) string {
+	languages := make([]string, 0, len(strategies))
+	shared := true
+	for language, strategy := range strategies {
+		languages = append(languages, language)
+		shared = shared && strategy == strategies[languages[0]]
+	}
+	if len(languages) == 0 {
+		return ""
+	}
+	if shared {
+		if strategies[languages[0]] == config.StrategyHybrid {
+			return ""
+		}
+		return strategies[languages[0]]
+	}
+	sort.Strings(languages)
+	parts := make([]string, len(languages))
+	for idx, language := range languages {
+		parts[idx] = language + "=" + strategies[language]
+	}
+	return strings.Join(parts, ", ")
+}
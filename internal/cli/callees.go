@@ -2,18 +2,22 @@ package cli
 
 import (
 	"fmt"
-	"os"
+	"io"
 	"path/filepath"
-	"strings"
 
 	"github.com/spf13/cobra"
-	"github.com/tk-425/Codegraph/internal/config"
 	"github.com/tk-425/Codegraph/internal/db"
 )
 
 var (
 	calleesDepthFlag int
 	calleesLangFlag  string
+	calleesKindFlag  string
+	calleesTagFlag   string
+	calleesExternal  bool
+	calleesPageFlags pageFlags
+	calleesFormat    formatFlag
+	calleesStdinFlag bool
 )
 
 var calleesCmd = &cobra.Command{
@@ -21,80 +25,136 @@ var calleesCmd = &cobra.Command{
 	Short: "Find all functions called by a given symbol",
 	Long: `Find all functions that the specified symbol calls.
 
+--tag keeps callees carrying an annotation, decorator or attribute:
+--tag=deprecated lists the deprecated APIs the symbol uses.
+
+--include-external also lists the calls to functions outside the index,
+such as the standard library and third-party packages, by the name called.
+They have no kind or tags, so --kind and --tag leave them out.
+
 Examples:
   codegraph callees main
   codegraph callees handleRequest --depth=2
-  codegraph callees process --lang=go`,
-	Args: cobra.ExactArgs(1),
+  codegraph callees process --lang=go
+  codegraph callees main --kind=method
+  codegraph callees main --tag=deprecated --depth=3
+  codegraph callees main --format=vimgrep
+  codegraph callees main --include-external
+  codegraph callees --stdin --json < symbols.txt`,
+	Args: symbolOrStdinArgs(&calleesStdinFlag),
 	RunE: runCallees,
 }
 
 func init() {
 	calleesCmd.Flags().IntVar(&calleesDepthFlag, "depth", 1, "Depth of call chain to traverse")
 	calleesCmd.Flags().StringVar(&calleesLangFlag, "lang", "", "Filter by language(s), comma-separated")
+	calleesCmd.Flags().StringVar(&calleesKindFlag, "kind", "", kindFlagUsage)
+	calleesCmd.Flags().StringVar(&calleesTagFlag, "tag", "", tagFlagUsage)
+	calleesCmd.Flags().BoolVar(&calleesExternal, "include-external", false, "Also list calls to functions outside the index")
+	calleesPageFlags.register(calleesCmd, 0)
+	calleesFormat.register(calleesCmd)
+	calleesCmd.Flags().BoolVar(&calleesStdinFlag, "stdin", false, stdinFlagUsage)
 	rootCmd.AddCommand(calleesCmd)
 }
 
 type calleeRecord struct {
-	Name string `json:"name"`
-	Kind string `json:"kind"`
-	File string `json:"file"`
-	Line int    `json:"line"`
+	Name     string `json:"name"`
+	Kind     string `json:"kind"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	External bool   `json:"external,omitempty"` // Not an indexed symbol; Kind is empty
+	// Dependency declaring an external callee, when dependencies are indexed
+	Module    string `json:"module,omitempty"`
+	Signature string `json:"signature,omitempty"`
 }
 
 func runCallees(cmd *cobra.Command, args []string) error {
-	symbol := args[0]
+	vimgrep, err := calleesFormat.vimgrep()
+	if err != nil {
+		return err
+	}
 	if jsonOutputFlag {
 		cmd.SilenceUsage = true
 		cmd.SilenceErrors = true
-		return runCalleesJSON(cmd, symbol)
-	}
-
-	// Get current directory
-	cwd, err := os.Getwd()
-	if err != nil {
-		return fmt.Errorf("failed to get current directory: %w", err)
+		return runCalleesJSON(cmd, args)
 	}
 
-	// Check if codegraph is initialized
-	codegraphDir := filepath.Join(cwd, ".codegraph")
-	if _, err := os.Stat(codegraphDir); os.IsNotExist(err) {
-		return fmt.Errorf("codegraph not initialized. Run 'codegraph init' first")
+	symbols := args
+	if calleesStdinFlag {
+		if symbols, err = readBatchSymbols(cmd.InOrStdin()); err != nil {
+			return err
+		}
 	}
 
-	// Load config
-	cfg, err := config.Load(cwd)
+	cwd, _, dbManager, _, err := openProject(false)
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return err
 	}
+	defer dbManager.Close()
 
-	// Open database
-	dbPath := cfg.GetDatabasePath(cwd)
-	dbManager, err := db.NewManager(dbPath)
-	if err != nil {
-		return fmt.Errorf("failed to open database: %w", err)
+	// Build language/kind filter and paging
+	opts := queryOptions(calleesLangFlag, calleesKindFlag)
+	opts.Tags = parseListFlag(calleesTagFlag)
+	if err := calleesPageFlags.apply(&opts); err != nil {
+		return err
 	}
-	defer dbManager.Close()
 
-	// Parse languages filter
-	var languages []string
-	if calleesLangFlag != "" {
-		languages = strings.Split(calleesLangFlag, ",")
+	return printBatch(cmd, symbols, func(symbol string) error {
+		return printCallees(cmd, cwd, dbManager, symbol, opts, vimgrep)
+	})
+}
+
+// printCallees prints the callees of one symbol, as text or vimgrep lines
+func printCallees(cmd *cobra.Command, cwd string, dbManager *db.Manager, symbol string, opts db.QueryOptions, vimgrep bool) error {
+	// Callees are streamed from the database as they are printed
+	if vimgrep {
+		written := 0
+		err := dbManager.EachCallee(symbol, opts, func(c db.CalleeInfo) error {
+			written++
+			relPath, _ := filepath.Rel(cwd, c.CallFile)
+			writeVimgrep(cmd.OutOrStdout(), relPath, c.CallLine, c.CallColumn+1,
+				fmt.Sprintf("%s calls %s: %s", symbol, c.Name, getSourceLine(c.CallFile, c.CallLine)))
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to find callees: %w", err)
+		}
+		if calleesExternal {
+			err = dbManager.EachExternalCall(symbol, opts, func(c db.ExternalCall) error {
+				written++
+				relPath, _ := filepath.Rel(cwd, c.File)
+				writeVimgrep(cmd.OutOrStdout(), relPath, c.Line, c.Column+1,
+					fmt.Sprintf("%s calls %s (external): %s", symbol, c.CalleeName, getSourceLine(c.File, c.Line)))
+				return nil
+			})
+			if err != nil {
+				return fmt.Errorf("failed to find external calls: %w", err)
+			}
+		}
+		if written == 0 {
+			return errNoResults
+		}
+		return nil
 	}
 
-	// Find callees
-	callees, err := dbManager.GetCallees(symbol, languages)
+	count, err := dbManager.CountCallees(symbol, opts)
 	if err != nil {
 		return fmt.Errorf("failed to find callees: %w", err)
 	}
-
-	if len(callees) == 0 {
+	external := 0
+	if calleesExternal {
+		if external, err = dbManager.CountExternalCalls(symbol, opts); err != nil {
+			return fmt.Errorf("failed to find external calls: %w", err)
+		}
+	}
+	if count == 0 && external == 0 {
 		fmt.Printf("📤 No callees found for: %s\n", Warning(symbol))
-		return nil
+		return errNoResults
 	}
-
-	fmt.Printf("📤 Callees of %s (%s found):\n\n", Symbol(symbol), Info(len(callees)))
-	for _, c := range callees {
+	if count > 0 {
+		fmt.Printf("📤 Callees of %s (%s found):\n\n", Symbol(symbol), Info(count))
+	}
+	err = dbManager.EachCallee(symbol, opts, func(c db.CalleeInfo) error {
 		relPath, _ := filepath.Rel(cwd, c.CallFile)
 		fmt.Printf("  %s [%s]\n", Symbol(c.Name), Keyword(c.Kind))
 		fmt.Printf("    %s\n", Path(fmt.Sprintf("%s:%d", relPath, c.CallLine)))
@@ -104,47 +164,133 @@ func runCallees(cmd *cobra.Command, args []string) error {
 			fmt.Printf("    %s\n", Dim(line))
 		}
 		fmt.Println()
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to find callees: %w", err)
+	}
+	if external == 0 {
+		return nil
+	}
+
+	fmt.Printf("🌐 External calls of %s (%s found):\n\n", Symbol(symbol), Info(external))
+	err = dbManager.EachExternalCall(symbol, opts, func(c db.ExternalCall) error {
+		relPath, _ := filepath.Rel(cwd, c.File)
+		if c.Module != "" {
+			fmt.Printf("  %s [%s] %s\n", Symbol(c.CalleeName), Dim("external"), Dim(c.Module))
+			fmt.Printf("    %s\n", Type(c.Signature))
+		} else {
+			fmt.Printf("  %s [%s]\n", Symbol(c.CalleeName), Dim("external"))
+		}
+		fmt.Printf("    %s\n", Path(fmt.Sprintf("%s:%d", relPath, c.Line)))
+		if line := getSourceLine(c.File, c.Line); line != "" {
+			fmt.Printf("    %s\n", Dim(line))
+		}
+		fmt.Println()
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to find external calls: %w", err)
 	}
 
 	return nil
 }
 
-func runCalleesJSON(cmd *cobra.Command, symbol string) error {
+func runCalleesJSON(cmd *cobra.Command, args []string) error {
 	out := cmd.OutOrStdout()
+	var query *string
+	if len(args) > 0 {
+		query = &args[0]
+	}
 	emitErr := func(code string, err error) error {
-		_ = EmitJSON(out, "callees", &symbol, []calleeRecord{}, []EnvelopeError{{Code: code, Message: err.Error()}})
+		_ = EmitJSON(out, "callees", query, []calleeRecord{}, []EnvelopeError{{Code: code, Message: err.Error()}})
 		return err
 	}
 
+	opts := queryOptions(calleesLangFlag, calleesKindFlag)
+	opts.Tags = parseListFlag(calleesTagFlag)
+	if err := calleesPageFlags.apply(&opts); err != nil {
+		return emitErr("invalid_flag", err)
+	}
+
 	cwd, _, dbManager, code, err := openProject(false)
 	if err != nil {
 		return emitErr(code, err)
 	}
 	defer dbManager.Close()
 
-	var languages []string
-	if calleesLangFlag != "" {
-		languages = strings.Split(calleesLangFlag, ",")
+	if !calleesStdinFlag {
+		return writeCalleesJSON(out, cwd, dbManager, args[0], opts)
 	}
+	symbols, err := readBatchSymbols(cmd.InOrStdin())
+	if err != nil {
+		return emitErr("invalid_input", err)
+	}
+	return emitBatchJSON(out, "callees", symbols, func(w io.Writer, symbol string) error {
+		return writeCalleesJSON(w, cwd, dbManager, symbol, opts)
+	})
+}
 
-	callees, err := dbManager.GetCallees(symbol, languages)
+// writeCalleesJSON writes the envelope of one symbol's callees to out,
+// returning errNoResults when there are none
+func writeCalleesJSON(out io.Writer, cwd string, dbManager *db.Manager, symbol string, opts db.QueryOptions) error {
+	emitErr := func(code string, err error) error {
+		_ = EmitJSON(out, "callees", &symbol, []calleeRecord{}, []EnvelopeError{{Code: code, Message: err.Error()}})
+		return err
+	}
+
+	count, err := dbManager.CountCallees(symbol, opts)
 	if err != nil {
 		return emitErr("callees_lookup_failed", fmt.Errorf("failed to find callees: %w", err))
 	}
+	external := 0
+	if calleesExternal {
+		if external, err = dbManager.CountExternalCalls(symbol, opts); err != nil {
+			return emitErr("callees_lookup_failed", fmt.Errorf("failed to find external calls: %w", err))
+		}
+	}
 
-	records := make([]calleeRecord, 0, len(callees))
-	for _, c := range callees {
+	// Stream the call sites into the envelope as they are read, the
+	// external calls after the indexed callees
+	stream := StartJSON(out, "callees", &symbol, count+external)
+	err = dbManager.EachCallee(symbol, opts, func(c db.CalleeInfo) error {
 		relPath, rerr := filepath.Rel(cwd, c.CallFile)
 		if rerr != nil {
 			relPath = c.CallFile
 		}
-		records = append(records, calleeRecord{
+		return stream.Write(calleeRecord{
 			Name: c.Name,
 			Kind: c.Kind,
 			File: relPath,
 			Line: c.CallLine,
 		})
+	})
+	if err == nil && external > 0 {
+		err = dbManager.EachExternalCall(symbol, opts, func(c db.ExternalCall) error {
+			relPath, rerr := filepath.Rel(cwd, c.File)
+			if rerr != nil {
+				relPath = c.File
+			}
+			return stream.Write(calleeRecord{
+				Name:      c.CalleeName,
+				File:      relPath,
+				Line:      c.Line,
+				External:  true,
+				Module:    c.Module,
+				Signature: c.Signature,
+			})
+		})
 	}
-
-	return EmitJSON(out, "callees", &symbol, records, nil)
+	if err != nil {
+		err = fmt.Errorf("failed to find callees: %w", err)
+		_ = stream.Close([]EnvelopeError{{Code: "callees_lookup_failed", Message: err.Error()}})
+		return err
+	}
+	if err := stream.Close(nil); err != nil {
+		return err
+	}
+	if stream.written == 0 {
+		return errNoResults
+	}
+	return nil
 }
@@ -3,18 +3,28 @@ package cli
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/spf13/cobra"
-	"github.com/tk-425/Codegraph/internal/config"
 	"github.com/tk-425/Codegraph/internal/db"
+	"github.com/tk-425/Codegraph/internal/indexer"
 )
 
 var (
 	callersDepthFlag int
 	callersLangFlag  string
+	callersKindFlag  string
+	callersTagFlag   string
+	callersArgsFlag  bool
+	callersArityFlag int
+	callersContext   string
+	callersPageFlags pageFlags
+	callersFormat    formatFlag
+	callersStdinFlag bool
 )
 
 var callersCmd = &cobra.Command{
@@ -22,132 +32,317 @@ var callersCmd = &cobra.Command{
 	Short: "Find all functions that call a given symbol",
 	Long: `Find all functions that call the specified symbol.
 
+HTTP routes the symbol handles (see 'codegraph routes') are listed first,
+as callers of kind route, so a caller chain can be followed up to the
+request that starts it.
+
+For an RPC declared in a .proto file (GetUser, or UserService.GetUser),
+the server methods implementing it are listed as callers of kind server,
+followed by every client stub call to it, in any language.
+
+Call sites inside an if/switch branch, loop, defer, goroutine, or
+try/catch/finally block are annotated with that control flow (outermost
+first), so unconditional calls stand apart from error-path-only ones.
+--context filters on it; --context=none keeps only unconditional calls.
+
+--tag keeps callers carrying an annotation, decorator or attribute, e.g.
+--tag=test for the tests exercising the symbol.
+
 Examples:
   codegraph callers parseConfig
   codegraph callers handleRequest --depth=2
-  codegraph callers parse --lang=go,python`,
-	Args: cobra.ExactArgs(1),
+  codegraph callers parse --lang=go,python
+  codegraph callers parse --kind='!constructor'
+  codegraph callers NewServer --show-args --arity=2
+  codegraph callers rollback --context=catch
+  codegraph callers Close --context=none
+  codegraph callers save --tag=transactional
+  codegraph callers Log --sort=file --limit=100 --offset=200
+  codegraph callers parseConfig --format=vimgrep
+  codegraph callers UserService.GetUser
+  codegraph callers --stdin --json < symbols.txt`,
+	Args: symbolOrStdinArgs(&callersStdinFlag),
 	RunE: runCallers,
 }
 
 func init() {
 	callersCmd.Flags().IntVar(&callersDepthFlag, "depth", 1, "Depth of call chain to traverse")
 	callersCmd.Flags().StringVar(&callersLangFlag, "lang", "", "Filter by language(s), comma-separated")
+	callersCmd.Flags().StringVar(&callersKindFlag, "kind", "", kindFlagUsage)
+	callersCmd.Flags().StringVar(&callersTagFlag, "tag", "", tagFlagUsage)
+	callersCmd.Flags().BoolVar(&callersArgsFlag, "show-args", false, "Show the arguments passed at each call site")
+	callersCmd.Flags().IntVar(&callersArityFlag, "arity", -1, "Only call sites passing exactly N arguments (e.g., to pick an overload)")
+	callersCmd.Flags().StringVar(&callersContext, "context", "", "Only call sites in this control flow: "+strings.Join(indexer.CallContexts, ", ")+", or none for unconditional calls")
+	callersPageFlags.register(callersCmd, 0)
+	callersFormat.register(callersCmd)
+	callersCmd.Flags().BoolVar(&callersStdinFlag, "stdin", false, stdinFlagUsage)
 	rootCmd.AddCommand(callersCmd)
 }
 
 type callerRecord struct {
-	Name string `json:"name"`
-	Kind string `json:"kind"`
-	File string `json:"file"`
-	Line int    `json:"line"`
+	Name     string   `json:"name"`
+	Kind     string   `json:"kind"`
+	File     string   `json:"file"`
+	Line     int      `json:"line"`
+	ArgCount *int     `json:"arg_count"` // null when the call site was not parsed
+	Args     string   `json:"args,omitempty"`
+	Context  []string `json:"context"` // Enclosing control flow, outermost first; empty when unconditional
 }
 
 func runCallers(cmd *cobra.Command, args []string) error {
-	symbol := args[0]
+	vimgrep, err := callersFormat.vimgrep()
+	if err != nil {
+		return err
+	}
 	if jsonOutputFlag {
 		cmd.SilenceUsage = true
 		cmd.SilenceErrors = true
-		return runCallersJSON(cmd, symbol)
+		return runCallersJSON(cmd, args)
 	}
 
-	// Get current directory
-	cwd, err := os.Getwd()
-	if err != nil {
-		return fmt.Errorf("failed to get current directory: %w", err)
-	}
-
-	// Check if codegraph is initialized
-	codegraphDir := filepath.Join(cwd, ".codegraph")
-	if _, err := os.Stat(codegraphDir); os.IsNotExist(err) {
-		return fmt.Errorf("codegraph not initialized. Run 'codegraph init' first")
+	symbols := args
+	if callersStdinFlag {
+		if symbols, err = readBatchSymbols(cmd.InOrStdin()); err != nil {
+			return err
+		}
 	}
 
-	// Load config
-	cfg, err := config.Load(cwd)
+	cwd, _, dbManager, _, err := openProject(false)
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return err
 	}
+	defer dbManager.Close()
 
-	// Open database
-	dbPath := cfg.GetDatabasePath(cwd)
-	dbManager, err := db.NewManager(dbPath)
+	// Build language/kind/arity filter and paging
+	opts, err := callersQueryOptions()
 	if err != nil {
-		return fmt.Errorf("failed to open database: %w", err)
+		return err
 	}
-	defer dbManager.Close()
 
-	// Parse languages filter
-	var languages []string
-	if callersLangFlag != "" {
-		languages = strings.Split(callersLangFlag, ",")
-	}
+	return printBatch(cmd, symbols, func(symbol string) error {
+		return printCallers(cmd, cwd, dbManager, symbol, opts, vimgrep)
+	})
+}
 
-	// Find callers
-	callers, err := dbManager.GetCallers(symbol, languages)
+// printCallers prints the callers of one symbol, as text or vimgrep lines
+func printCallers(cmd *cobra.Command, cwd string, dbManager *db.Manager, symbol string, opts db.QueryOptions, vimgrep bool) error {
+	// Callers are streamed from the database as they are printed, so only
+	// their count is read up front
+	count, err := dbManager.CountCallers(symbol, opts)
 	if err != nil {
 		return fmt.Errorf("failed to find callers: %w", err)
 	}
+	routes, err := handlerRoutes(dbManager, symbol, opts)
+	if err != nil {
+		return fmt.Errorf("failed to find routes: %w", err)
+	}
+	servers, err := rpcServers(dbManager, symbol, opts)
+	if err != nil {
+		return fmt.Errorf("failed to find gRPC servers: %w", err)
+	}
 
-	if len(callers) == 0 {
-		fmt.Printf("📞 No callers found for: %s\n", Warning(symbol))
+	if vimgrep {
+		err := dbManager.EachCaller(symbol, opts, func(c db.CallerInfo) error {
+			relPath, _ := filepath.Rel(cwd, c.CallFile)
+			writeVimgrep(cmd.OutOrStdout(), relPath, c.CallLine, c.CallColumn+1,
+				fmt.Sprintf("%s calls %s: %s", c.Name, symbol, getSourceLine(c.CallFile, c.CallLine)))
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to find callers: %w", err)
+		}
+		for _, r := range routes {
+			writeVimgrep(cmd.OutOrStdout(), relativePath(cwd, r.File), r.Line, r.Column+1,
+				fmt.Sprintf("route %s is handled by %s", routeLabel(r), symbol))
+		}
+		for _, s := range servers {
+			writeVimgrep(cmd.OutOrStdout(), relativePath(cwd, s.File), s.Line, s.Column+1,
+				fmt.Sprintf("%s serves %s", s.Name, symbol))
+		}
+		if count == 0 && len(routes) == 0 && len(servers) == 0 {
+			return errNoResults
+		}
 		return nil
 	}
 
-	fmt.Printf("📞 Callers of %s (%s found):\n\n", Symbol(symbol), Info(len(callers)))
-	for _, c := range callers {
+	if count == 0 && len(routes) == 0 && len(servers) == 0 {
+		fmt.Printf("📞 No callers found for: %s\n", Warning(symbol))
+		return errNoResults
+	}
+
+	fmt.Printf("📞 Callers of %s (%s found):\n\n", Symbol(symbol), Info(count+len(routes)+len(servers)))
+	for _, r := range routes {
+		fmt.Printf("  %s [%s]\n", Symbol(routeLabel(r)), Keyword("route"))
+		fmt.Printf("    %s\n", Path(fmt.Sprintf("%s:%d", relativePath(cwd, r.File), r.Line)))
+		if line := getSourceLine(r.File, r.Line); line != "" {
+			fmt.Printf("    %s\n", Dim(line))
+		}
+		fmt.Println()
+	}
+	for _, s := range servers {
+		fmt.Printf("  %s [%s]\n", Symbol(s.Name), Keyword("server"))
+		fmt.Printf("    %s\n", Path(fmt.Sprintf("%s:%d", relativePath(cwd, s.File), s.Line)))
+		if line := getSourceLine(s.File, s.Line); line != "" {
+			fmt.Printf("    %s\n", Dim(line))
+		}
+		fmt.Println()
+	}
+	err = dbManager.EachCaller(symbol, opts, func(c db.CallerInfo) error {
 		relPath, _ := filepath.Rel(cwd, c.CallFile)
 		fmt.Printf("  %s [%s]\n", Symbol(c.Name), Keyword(c.Kind))
-		fmt.Printf("    %s\n", Path(fmt.Sprintf("%s:%d", relPath, c.CallLine)))
+		if c.CallContext != "" {
+			fmt.Printf("    %s %s\n", Path(fmt.Sprintf("%s:%d", relPath, c.CallLine)), Warning("in "+strings.ReplaceAll(c.CallContext, ",", " › ")))
+		} else {
+			fmt.Printf("    %s\n", Path(fmt.Sprintf("%s:%d", relPath, c.CallLine)))
+		}
+		if callersArgsFlag && c.CallArgCount != nil {
+			fmt.Printf("    %s %s\n", Keyword(fmt.Sprintf("args(%d)", *c.CallArgCount)), Type(c.CallArgs))
+		}
 		
 		// Show the actual source line
 		if line := getSourceLine(c.CallFile, c.CallLine); line != "" {
 			fmt.Printf("    %s\n", Dim(line))
 		}
 		fmt.Println()
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to find callers: %w", err)
 	}
 
 	return nil
 }
 
-func runCallersJSON(cmd *cobra.Command, symbol string) error {
+func runCallersJSON(cmd *cobra.Command, args []string) error {
 	out := cmd.OutOrStdout()
+	var query *string
+	if len(args) > 0 {
+		query = &args[0]
+	}
 	emitErr := func(code string, err error) error {
-		_ = EmitJSON(out, "callers", &symbol, []callerRecord{}, []EnvelopeError{{Code: code, Message: err.Error()}})
+		_ = EmitJSON(out, "callers", query, []callerRecord{}, []EnvelopeError{{Code: code, Message: err.Error()}})
 		return err
 	}
 
+	opts, err := callersQueryOptions()
+	if err != nil {
+		return emitErr("invalid_flag", err)
+	}
+
 	cwd, _, dbManager, code, err := openProject(false)
 	if err != nil {
 		return emitErr(code, err)
 	}
 	defer dbManager.Close()
 
-	var languages []string
-	if callersLangFlag != "" {
-		languages = strings.Split(callersLangFlag, ",")
+	if !callersStdinFlag {
+		return writeCallersJSON(out, cwd, dbManager, args[0], opts)
+	}
+	symbols, err := readBatchSymbols(cmd.InOrStdin())
+	if err != nil {
+		return emitErr("invalid_input", err)
+	}
+	return emitBatchJSON(out, "callers", symbols, func(w io.Writer, symbol string) error {
+		return writeCallersJSON(w, cwd, dbManager, symbol, opts)
+	})
+}
+
+// writeCallersJSON writes the envelope of one symbol's callers to out,
+// returning errNoResults when there are none
+func writeCallersJSON(out io.Writer, cwd string, dbManager *db.Manager, symbol string, opts db.QueryOptions) error {
+	emitErr := func(code string, err error) error {
+		_ = EmitJSON(out, "callers", &symbol, []callerRecord{}, []EnvelopeError{{Code: code, Message: err.Error()}})
+		return err
 	}
 
-	callers, err := dbManager.GetCallers(symbol, languages)
+	count, err := dbManager.CountCallers(symbol, opts)
 	if err != nil {
 		return emitErr("callers_lookup_failed", fmt.Errorf("failed to find callers: %w", err))
 	}
+	routes, err := handlerRoutes(dbManager, symbol, opts)
+	if err != nil {
+		return emitErr("callers_lookup_failed", fmt.Errorf("failed to find routes: %w", err))
+	}
+	servers, err := rpcServers(dbManager, symbol, opts)
+	if err != nil {
+		return emitErr("callers_lookup_failed", fmt.Errorf("failed to find gRPC servers: %w", err))
+	}
 
-	records := make([]callerRecord, 0, len(callers))
-	for _, c := range callers {
+	// Stream the call sites into the envelope as they are read
+	stream := StartJSON(out, "callers", &symbol, len(routes)+len(servers)+count)
+	for _, r := range routes {
+		stream.Write(callerRecord{
+			Name:    routeLabel(r),
+			Kind:    "route",
+			File:    relativePath(cwd, r.File),
+			Line:    r.Line,
+			Context: []string{},
+		})
+	}
+	for _, s := range servers {
+		stream.Write(callerRecord{
+			Name:    s.Name,
+			Kind:    "server",
+			File:    relativePath(cwd, s.File),
+			Line:    s.Line,
+			Context: []string{},
+		})
+	}
+	err = dbManager.EachCaller(symbol, opts, func(c db.CallerInfo) error {
 		relPath, rerr := filepath.Rel(cwd, c.CallFile)
 		if rerr != nil {
 			relPath = c.CallFile
 		}
-		records = append(records, callerRecord{
-			Name: c.Name,
-			Kind: c.Kind,
-			File: relPath,
-			Line: c.CallLine,
+		return stream.Write(callerRecord{
+			Name:     c.Name,
+			Kind:     c.Kind,
+			File:     relPath,
+			Line:     c.CallLine,
+			ArgCount: c.CallArgCount,
+			Args:     c.CallArgs,
+			Context:  splitCallContext(c.CallContext),
 		})
+	})
+	if err != nil {
+		err = fmt.Errorf("failed to find callers: %w", err)
+		_ = stream.Close([]EnvelopeError{{Code: "callers_lookup_failed", Message: err.Error()}})
+		return err
 	}
+	if err := stream.Close(nil); err != nil {
+		return err
+	}
+	if stream.written == 0 {
+		return errNoResults
+	}
+	return nil
+}
 
-	return EmitJSON(out, "callers", &symbol, records, nil)
+// callersQueryOptions builds the caller filter from --lang, --kind, --tag,
+// --arity, --context and the paging flags
+func callersQueryOptions() (db.QueryOptions, error) {
+	opts := queryOptions(callersLangFlag, callersKindFlag)
+	opts.Tags = parseListFlag(callersTagFlag)
+	if callersArityFlag >= 0 {
+		arity := callersArityFlag
+		opts.Arity = &arity
+	}
+	if callersContext != "" {
+		if callersContext != db.NoCallContext && !slices.Contains(indexer.CallContexts, callersContext) {
+			return opts, fmt.Errorf("invalid --context %q (expected %s, or none)", callersContext, strings.Join(indexer.CallContexts, ", "))
+		}
+		opts.CallContext = callersContext
+	}
+	err := callersPageFlags.apply(&opts)
+	return opts, err
+}
+
+// splitCallContext turns a stored call context into its labels, empty for
+// an unconditional call
+func splitCallContext(context string) []string {
+	if context == "" {
+		return []string{}
+	}
+	return strings.Split(context, ",")
 }
 
 // getSourceLine reads a specific line from a file
@@ -168,3 +363,42 @@ func getSourceLine(filePath string, lineNum int) string {
 	}
 	return ""
 }
+
+// listsLinkedCallers reports whether callers listed alongside the call
+// graph under kind (routes, gRPC servers) pass opts. They have no
+// arguments, control flow or tags, so call-site and tag filters and later
+// pages omit them.
+func listsLinkedCallers(opts db.QueryOptions, kind string) bool {
+	return opts.Offset == 0 && opts.Arity == nil && opts.CallContext == "" && len(opts.Tags) == 0 &&
+		(len(opts.Kinds) == 0 || slices.Contains(opts.Kinds, kind)) && !slices.Contains(opts.ExcludeKinds, kind)
+}
+
+// rpcServers returns the server methods implementing the .proto RPCs named
+// symbol (GetUser or UserService.GetUser), which 'codegraph callers' lists
+// as their callers since gRPC invokes them on behalf of every client
+func rpcServers(dbManager *db.Manager, symbol string, opts db.QueryOptions) ([]db.Symbol, error) {
+	if !listsLinkedCallers(opts, "server") {
+		return nil, nil
+	}
+	name := symbol[strings.LastIndex(symbol, ".")+1:]
+	rpcs, err := dbManager.FindSymbolsByName(name, db.QueryOptions{Languages: []string{"proto"}, Kinds: []string{"method"}})
+	if err != nil {
+		return nil, err
+	}
+	var servers []db.Symbol
+	for _, rpc := range rpcs {
+		if rpc.Name != symbol && !strings.HasSuffix(rpc.ID, "#"+symbol) {
+			continue
+		}
+		impls, err := dbManager.GetImplementations(rpc.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, impl := range impls {
+			if len(opts.Languages) == 0 || slices.Contains(opts.Languages, impl.Language) {
+				servers = append(servers, impl)
+			}
+		}
+	}
+	return servers, nil
+}
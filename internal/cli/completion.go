@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+// maxCompletions caps the suggestions offered for one argument
+const maxCompletions = 200
+
+func init() {
+	completeAny := completeSymbols(nil)
+	for _, cmd := range []*cobra.Command{callersCmd, calleesCmd, contextCmd, searchCmd, signatureCmd, snippetCmd, typesCmd} {
+		cmd.ValidArgsFunction = completeAny
+	}
+	completeTypes := completeSymbols(fieldOwnerKinds)
+	fieldsCmd.ValidArgsFunction = completeTypes
+	implementationsCmd.ValidArgsFunction = completeTypes
+
+	registryRemoveCmd.ValidArgsFunction = completeProjects
+	registryRenameCmd.ValidArgsFunction = completeProjects
+	registryInfoCmd.ValidArgsFunction = completeProjects
+}
+
+// completeSymbols completes a command's symbol argument with the names in
+// the project's index, limited to kinds when given. Completion runs
+// without the root command's hooks, so --project is applied here.
+func completeSymbols(kinds []string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		if err := enterProject(); err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		_, _, dbManager, _, err := openProject(true)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		defer dbManager.Close()
+
+		names, err := dbManager.CompleteSymbolNames(toComplete, db.QueryOptions{Kinds: kinds, Limit: maxCompletions})
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// registerIndexCompletion completes --kind and --tag, on every command of
+// root that has them, with the kinds and tags in the project's index. It
+// runs once all commands have registered their flags.
+func registerIndexCompletion(root *cobra.Command) {
+	if root.LocalFlags().Lookup("kind") != nil {
+		_ = root.RegisterFlagCompletionFunc("kind", completeKinds)
+	}
+	if root.LocalFlags().Lookup("tag") != nil {
+		_ = root.RegisterFlagCompletionFunc("tag", completeTags)
+	}
+	for _, cmd := range root.Commands() {
+		registerIndexCompletion(cmd)
+	}
+}
+
+// completeKinds completes the last entry of a --kind list, keeping its !
+// prefix, with the indexed kinds
+func completeKinds(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return completeIndexList(toComplete, (*db.Manager).ListKinds)
+}
+
+// completeTags completes the last entry of a --tag list with the indexed
+// tags
+func completeTags(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return completeIndexList(toComplete, (*db.Manager).ListTags)
+}
+
+// completePackages completes --package with the indexed packages
+func completePackages(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return completeIndexList(toComplete, (*db.Manager).ListPackages)
+}
+
+// completeIndexList completes the last entry of a comma-separated flag
+// value, keeping a ! prefix, with the values list reads from the index
+func completeIndexList(toComplete string, list func(*db.Manager) ([]string, error)) ([]string, cobra.ShellCompDirective) {
+	if err := enterProject(); err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	_, _, dbManager, _, err := openProject(true)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	defer dbManager.Close()
+
+	values, err := list(dbManager)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	done, last := "", toComplete
+	if i := strings.LastIndex(toComplete, ","); i >= 0 {
+		done, last = toComplete[:i+1], toComplete[i+1:]
+	}
+	if strings.HasPrefix(last, "!") {
+		done, last = done+"!", last[1:]
+	}
+	var suggestions []string
+	for _, value := range values {
+		if strings.HasPrefix(value, last) {
+			suggestions = append(suggestions, done+value)
+		}
+	}
+	return suggestions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeProjects completes a registered project name
+func completeProjects(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	reg, _, err := loadRegistry()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	seen := make(map[string]bool)
+	var names []string
+	for _, proj := range reg.Projects {
+		if !seen[proj.Name] {
+			seen[proj.Name] = true
+			names = append(names, proj.Name)
+		}
+	}
+	sort.Strings(names)
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
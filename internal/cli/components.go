@@ -0,0 +1,432 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+var (
+	componentsReverseFlag bool
+	componentsDepthFlag   int
+	componentsDotFlag     bool
+	componentsLangFlag    string
+	componentsPageFlags   pageFlags
+	componentsFormat      formatFlag
+)
+
+var componentsCmd = &cobra.Command{
+	Use:   "components [component]",
+	Short: "List UI components and the components they render",
+	Long: `List the React and Vue components 'codegraph build' found in JSX files
+(.jsx, .tsx, .js), with the components each one renders.
+
+A component is a capitalized function, function value or class whose body
+contains JSX, including values wrapped in memo, forwardRef, observer or
+defineComponent. Files importing from 'vue' are marked as Vue components;
+.vue single-file components are not indexed. Elements with a capitalized
+or dotted tag (<Card />, <UI.Card />) are rendered components; they are
+resolved in the same file, then the same directory, then anywhere, and
+stay unresolved when they come from a library.
+
+With [component], the render tree below it is shown instead; --reverse
+shows the components that render it, up to the roots of the application.
+
+--dot prints the render graph (or the tree) as a Graphviz graph;
+unresolved components are dashed.
+
+Examples:
+  codegraph components
+  codegraph components --lang=typescriptreact --format=vimgrep
+  codegraph components App --depth=2
+  codegraph components Button --reverse
+  codegraph components --dot | dot -Tsvg > components.svg`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runComponents,
+}
+
+func init() {
+	componentsCmd.Flags().BoolVar(&componentsReverseFlag, "reverse", false, "With [component], show the components that render it")
+	componentsCmd.Flags().IntVar(&componentsDepthFlag, "depth", 0, "Max tree depth with [component] (0 = unlimited)")
+	componentsCmd.Flags().BoolVar(&componentsDotFlag, "dot", false, "Print a Graphviz DOT graph")
+	componentsCmd.Flags().StringVar(&componentsLangFlag, "lang", "", "Filter by language(s), comma-separated")
+	componentsPageFlags.register(componentsCmd, 0)
+	componentsFormat.register(componentsCmd)
+	rootCmd.AddCommand(componentsCmd)
+}
+
+type componentRecord struct {
+	Name       string   `json:"name"`
+	ID         string   `json:"id"`
+	Kind       string   `json:"kind"`
+	Framework  string   `json:"framework"`
+	File       string   `json:"file"`
+	Line       int      `json:"line"`
+	Renders    []string `json:"renders"`     // Element names, in source order
+	RenderedBy []string `json:"rendered_by"` // Component names
+}
+
+type renderRecord struct {
+	Parent   string  `json:"parent"`
+	ParentID string  `json:"parent_id"`
+	Child    string  `json:"child"`
+	ChildID  *string `json:"child_id"` // null when the child did not resolve
+	Depth    int     `json:"depth"`    // 1 for the edges of [component] itself
+	File     string  `json:"file"`     // Where the element is rendered
+	Line     int     `json:"line"`
+	Column   int     `json:"column"` // 1-indexed
+}
+
+// renderEdge is a render edge at its depth in a component tree
+type renderEdge struct {
+	db.Render
+	Depth int
+}
+
+// componentsQuery validates the flags and builds the component filter
+func componentsQuery(args []string) (db.QueryOptions, error) {
+	if componentsReverseFlag && len(args) == 0 {
+		return db.QueryOptions{}, fmt.Errorf("--reverse requires a component")
+	}
+	if componentsDotFlag && jsonOutputFlag {
+		return db.QueryOptions{}, fmt.Errorf("--dot cannot be combined with --json")
+	}
+	opts := queryOptions(componentsLangFlag, "")
+	err := componentsPageFlags.apply(&opts)
+	return opts, err
+}
+
+// componentGraph holds the render edges by parent and by child
+type componentGraph struct {
+	renders  []db.Render
+	byParent map[string][]db.Render
+	byChild  map[string][]db.Render
+}
+
+func loadComponentGraph(dbManager *db.Manager) (*componentGraph, error) {
+	renders, err := dbManager.ListRenders()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list renders: %w", err)
+	}
+	g := &componentGraph{
+		renders:  renders,
+		byParent: make(map[string][]db.Render),
+		byChild:  make(map[string][]db.Render),
+	}
+	for _, r := range renders {
+		g.byParent[r.ParentID] = append(g.byParent[r.ParentID], r)
+		if r.ChildID != nil {
+			g.byChild[*r.ChildID] = append(g.byChild[*r.ChildID], r)
+		}
+	}
+	return g, nil
+}
+
+// tree returns the render edges below roots (above them with reverse) in
+// depth-first order. Each parent and child pair appears once per branch;
+// a component already on the branch is not expanded again.
+func (g *componentGraph) tree(roots []string, reverse bool, maxDepth int) []renderEdge {
+	var edges []renderEdge
+	onPath := make(map[string]bool)
+	var visit func(id string, depth int)
+	visit = func(id string, depth int) {
+		onPath[id] = true
+		defer delete(onPath, id)
+
+		next := g.byParent[id]
+		if reverse {
+			next = g.byChild[id]
+		}
+		seen := make(map[string]bool)
+		for _, r := range next {
+			key, nextID := r.Child, r.ChildID
+			if reverse {
+				key, nextID = r.ParentID, &r.ParentID
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			edges = append(edges, renderEdge{Render: r, Depth: depth})
+			if nextID != nil && !onPath[*nextID] && (maxDepth <= 0 || depth < maxDepth) {
+				visit(*nextID, depth+1)
+			}
+		}
+	}
+	for _, id := range roots {
+		visit(id, 1)
+	}
+	return edges
+}
+
+// componentRoots returns the components named name
+func componentRoots(dbManager *db.Manager, name string, opts db.QueryOptions) ([]db.Component, error) {
+	components, err := dbManager.ListComponents(db.QueryOptions{Languages: opts.Languages})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list components: %w", err)
+	}
+	var roots []db.Component
+	for _, c := range components {
+		if c.Name == name {
+			roots = append(roots, c)
+		}
+	}
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("no component named '%s' found in database", name)
+	}
+	return roots, nil
+}
+
+func componentIDs(components []db.Component) []string {
+	ids := make([]string, len(components))
+	for i, c := range components {
+		ids[i] = c.ID
+	}
+	return ids
+}
+
+// renderedNames returns the element names component renders and the names
+// of the components rendering it, each in source order without repeats
+func (g *componentGraph) renderedNames(id string) (renders, renderedBy []string) {
+	renders, renderedBy = []string{}, []string{}
+	for _, r := range g.byParent[id] {
+		if !slices.Contains(renders, r.Child) {
+			renders = append(renders, r.Child)
+		}
+	}
+	for _, r := range g.byChild[id] {
+		if !slices.Contains(renderedBy, r.Parent.Name) {
+			renderedBy = append(renderedBy, r.Parent.Name)
+		}
+	}
+	return renders, renderedBy
+}
+
+func runComponents(cmd *cobra.Command, args []string) error {
+	vimgrep, err := componentsFormat.vimgrep()
+	if err != nil {
+		return err
+	}
+	if jsonOutputFlag {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		return runComponentsJSON(cmd, args)
+	}
+
+	opts, err := componentsQuery(args)
+	if err != nil {
+		return err
+	}
+	if vimgrep && componentsDotFlag {
+		return fmt.Errorf("--dot cannot be combined with --format=vimgrep")
+	}
+	cwd, _, dbManager, _, err := openProject(true)
+	if err != nil {
+		return err
+	}
+	defer dbManager.Close()
+
+	g, err := loadComponentGraph(dbManager)
+	if err != nil {
+		return err
+	}
+	out := cmd.OutOrStdout()
+
+	if len(args) > 0 {
+		roots, err := componentRoots(dbManager, args[0], opts)
+		if err != nil {
+			return err
+		}
+		edges := g.tree(componentIDs(roots), componentsReverseFlag, componentsDepthFlag)
+		if componentsDotFlag {
+			renders := make([]db.Render, len(edges))
+			for i, e := range edges {
+				renders[i] = e.Render
+			}
+			return writeComponentsDot(out, dbManager, renders)
+		}
+		if vimgrep {
+			for _, e := range edges {
+				writeVimgrep(out, relativePath(cwd, e.File), e.Line, e.Column+1,
+					fmt.Sprintf("%s renders %s", e.Parent.Name, e.Child))
+			}
+			return nil
+		}
+		printComponentTree(cwd, roots, edges)
+		return nil
+	}
+
+	if componentsDotFlag {
+		return writeComponentsDot(out, dbManager, g.renders)
+	}
+	components, err := dbManager.ListComponents(opts)
+	if err != nil {
+		return fmt.Errorf("failed to list components: %w", err)
+	}
+	if vimgrep {
+		for _, c := range components {
+			writeVimgrep(out, relativePath(cwd, c.File), c.Line, c.Column+1,
+				fmt.Sprintf("%s [%s]", c.Name, c.Framework))
+		}
+		return nil
+	}
+
+	if len(components) == 0 {
+		fmt.Println("🧩 No components found (run 'codegraph build' to extract them)")
+		return noResults(cmd)
+	}
+	fmt.Printf("🧩 Components (%s found):\n", Info(len(components)))
+	for _, c := range components {
+		renders, renderedBy := g.renderedNames(c.ID)
+		fmt.Printf("\n%s [%s] %s\n", Symbol(c.Name), Keyword(c.Framework),
+			Path(fmt.Sprintf("%s:%d", relativePath(cwd, c.File), c.Line)))
+		if len(renders) > 0 {
+			fmt.Printf("  %s %s\n", Dim("renders    "), strings.Join(renders, ", "))
+		}
+		if len(renderedBy) > 0 {
+			fmt.Printf("  %s %s\n", Dim("rendered by"), strings.Join(renderedBy, ", "))
+		}
+	}
+	return nil
+}
+
+// printComponentTree prints the render tree of roots, indented by depth
+func printComponentTree(cwd string, roots []db.Component, edges []renderEdge) {
+	for _, root := range roots {
+		fmt.Printf("🧩 %s [%s] %s\n", Symbol(root.Name), Keyword(root.Framework),
+			Path(fmt.Sprintf("%s:%d", relativePath(cwd, root.File), root.Line)))
+	}
+	if len(edges) == 0 {
+		if componentsReverseFlag {
+			fmt.Println("  " + Dim("(not rendered by any component)"))
+		} else {
+			fmt.Println("  " + Dim("(renders no components)"))
+		}
+		return
+	}
+	for _, e := range edges {
+		name := e.Child
+		if componentsReverseFlag {
+			name = e.Parent.Name
+		}
+		label := Symbol(name)
+		if !componentsReverseFlag && e.ChildID == nil {
+			label += " " + Dim("(unresolved)")
+		}
+		fmt.Printf("%s%s %s\n", strings.Repeat("  ", e.Depth), label,
+			Path(fmt.Sprintf("%s:%d", relativePath(cwd, e.File), e.Line)))
+	}
+}
+
+// writeComponentsDot prints render edges as a Graphviz graph
+func writeComponentsDot(w io.Writer, dbManager *db.Manager, renders []db.Render) error {
+	fmt.Fprintln(w, "digraph components {")
+	fmt.Fprintln(w, "  rankdir=TB;")
+	fmt.Fprintln(w, "  node [shape=box];")
+
+	nodes := make(map[string]bool)
+	node := func(id, label, attrs string) string {
+		quoted := strconv.Quote(id)
+		if !nodes[id] {
+			nodes[id] = true
+			fmt.Fprintf(w, "  %s [label=%s%s];\n", quoted, strconv.Quote(label), attrs)
+		}
+		return quoted
+	}
+	edges := make(map[string]bool)
+	for _, r := range renders {
+		parent := node(r.ParentID, r.Parent.Name, "")
+		var child string
+		if r.ChildID != nil {
+			label := r.Child
+			if sym, err := dbManager.GetSymbolByID(*r.ChildID); err == nil && sym != nil {
+				label = sym.Name
+			}
+			child = node(*r.ChildID, label, "")
+		} else {
+			// Library components share one node per name
+			child = node("unresolved:"+r.Child, r.Child, ", style=dashed")
+		}
+		line := fmt.Sprintf("  %s -> %s;", parent, child)
+		if !edges[line] {
+			edges[line] = true
+			fmt.Fprintln(w, line)
+		}
+	}
+	fmt.Fprintln(w, "}")
+	return nil
+}
+
+func runComponentsJSON(cmd *cobra.Command, args []string) error {
+	out := cmd.OutOrStdout()
+	var query *string
+	if len(args) > 0 {
+		query = &args[0]
+	}
+	emitErr := func(code string, err error) error {
+		_ = EmitJSON(out, "components", query, []componentRecord{}, []EnvelopeError{{Code: code, Message: err.Error()}})
+		return err
+	}
+
+	opts, err := componentsQuery(args)
+	if err != nil {
+		return emitErr("invalid_flag", err)
+	}
+	cwd, _, dbManager, code, err := openProject(true)
+	if err != nil {
+		return emitErr(code, err)
+	}
+	defer dbManager.Close()
+
+	g, err := loadComponentGraph(dbManager)
+	if err != nil {
+		return emitErr("components_failed", err)
+	}
+
+	if len(args) > 0 {
+		roots, err := componentRoots(dbManager, args[0], opts)
+		if err != nil {
+			return emitErr("components_lookup_failed", err)
+		}
+		edges := g.tree(componentIDs(roots), componentsReverseFlag, componentsDepthFlag)
+		records := make([]renderRecord, 0, len(edges))
+		for _, e := range edges {
+			records = append(records, renderRecord{
+				Parent:   e.Parent.Name,
+				ParentID: e.ParentID,
+				Child:    e.Child,
+				ChildID:  e.ChildID,
+				Depth:    e.Depth,
+				File:     relativePath(cwd, e.File),
+				Line:     e.Line,
+				Column:   e.Column + 1,
+			})
+		}
+		return emitResults(cmd, "components", query, records)
+	}
+
+	components, err := dbManager.ListComponents(opts)
+	if err != nil {
+		return emitErr("components_failed", fmt.Errorf("failed to list components: %w", err))
+	}
+	records := make([]componentRecord, 0, len(components))
+	for _, c := range components {
+		renders, renderedBy := g.renderedNames(c.ID)
+		records = append(records, componentRecord{
+			Name:       c.Name,
+			ID:         c.ID,
+			Kind:       c.Kind,
+			Framework:  c.Framework,
+			File:       relativePath(cwd, c.File),
+			Line:       c.Line,
+			Renders:    renders,
+			RenderedBy: renderedBy,
+		})
+	}
+	return emitResults(cmd, "components", query, records)
+}
@@ -0,0 +1,324 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+var (
+	concurrencyOpFlag         string
+	concurrencyTransitiveFlag bool
+	concurrencyDepthFlag      int
+	concurrencyDotFlag        bool
+	concurrencyLangFlag       string
+	concurrencyPageFlags      pageFlags
+	concurrencyFormat         formatFlag
+)
+
+var concurrencyCmd = &cobra.Command{
+	Use:   "concurrency [function]",
+	Short: "List goroutine launches, channel operations and mutex operations",
+	Long: `List the concurrency sites 'codegraph build' recorded, grouped by the
+function they occur in. Only Go is indexed so far:
+
+  go       goroutine launch (go f(), go func() {...}())
+  send     channel send (ch <- v)
+  receive  channel receive (<-ch, including in select)
+  close    close(ch)
+  lock, unlock, rlock, runlock
+           sync.Mutex / sync.RWMutex calls (mu.Lock(), defer mu.Unlock())
+
+With [function], only the sites inside the functions of that name are
+listed; add --transitive to include every function they call or spawn,
+e.g. to see which goroutines a request handler starts.
+
+--dot prints a Graphviz graph instead: functions are boxes, goroutine
+launches dashed edges, and channels (ellipses) and mutexes (diamonds) are
+nodes joined to the functions that use them. Channels and mutexes are
+identified by their expression text, so two functions using 'ch' share a
+node.
+
+Examples:
+  codegraph concurrency
+  codegraph concurrency handleUpload --op=go --transitive
+  codegraph concurrency Serve --transitive --depth=3 --op=send,receive
+  codegraph concurrency --op=lock,unlock --format=vimgrep
+  codegraph concurrency Serve --transitive --dot | dot -Tsvg > serve.svg`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runConcurrency,
+}
+
+func init() {
+	concurrencyCmd.Flags().StringVar(&concurrencyOpFlag, "op", "", "Filter by operation(s), comma-separated: "+strings.Join(db.ConcurrencyOps, ", "))
+	concurrencyCmd.Flags().BoolVar(&concurrencyTransitiveFlag, "transitive", false, "Include the functions [function] calls or spawns, transitively")
+	concurrencyCmd.Flags().IntVar(&concurrencyDepthFlag, "depth", 0, "Max call-chain length to follow with --transitive (0 = unlimited)")
+	concurrencyCmd.Flags().BoolVar(&concurrencyDotFlag, "dot", false, "Print a Graphviz DOT graph")
+	concurrencyCmd.Flags().StringVar(&concurrencyLangFlag, "lang", "", "Filter by language(s), comma-separated")
+	concurrencyPageFlags.register(concurrencyCmd, 0)
+	concurrencyFormat.register(concurrencyCmd)
+	rootCmd.AddCommand(concurrencyCmd)
+}
+
+type concurrencyRecord struct {
+	Function   string  `json:"function"`
+	FunctionID string  `json:"function_id"`
+	Op         string  `json:"op"`
+	Target     string  `json:"target"`
+	TargetID   *string `json:"target_id"` // Function a goroutine runs, when indexed
+	File       string  `json:"file"`
+	Line       int     `json:"line"`
+	Column     int     `json:"column"` // 1-indexed
+	Text       string  `json:"text"`   // Source line
+}
+
+// concurrencyQuery validates the flags and builds the site filter
+func concurrencyQuery() ([]string, db.QueryOptions, error) {
+	ops := parseListFlag(concurrencyOpFlag)
+	for _, op := range ops {
+		if !slices.Contains(db.ConcurrencyOps, op) {
+			return nil, db.QueryOptions{}, fmt.Errorf("invalid --op %q (expected %s)", op, strings.Join(db.ConcurrencyOps, ", "))
+		}
+	}
+	if concurrencyDotFlag && jsonOutputFlag {
+		return nil, db.QueryOptions{}, fmt.Errorf("--dot cannot be combined with --json")
+	}
+	opts := queryOptions(concurrencyLangFlag, "")
+	err := concurrencyPageFlags.apply(&opts)
+	return ops, opts, err
+}
+
+// concurrencyFunctions returns the IDs of the functions named name and,
+// with --transitive, of every function they reach through calls and
+// goroutine launches
+func concurrencyFunctions(dbManager *db.Manager, name string) ([]string, error) {
+	roots, err := dbManager.FindSymbolsByName(name, db.QueryOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find symbol: %w", err)
+	}
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("no symbol named '%s' found in database", name)
+	}
+	var ids []string
+	seen := make(map[string]bool)
+	for _, r := range roots {
+		if !seen[r.ID] {
+			seen[r.ID] = true
+			ids = append(ids, r.ID)
+		}
+	}
+	if !concurrencyTransitiveFlag {
+		return ids, nil
+	}
+
+	calls, err := dbManager.ListCalls()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list calls: %w", err)
+	}
+	callees := make(map[string][]string)
+	for _, c := range calls {
+		callees[c.CallerID] = append(callees[c.CallerID], c.CalleeID)
+	}
+	spawns, err := dbManager.ListConcurrency(nil, []string{db.ConcurrencyGo}, db.QueryOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list goroutine launches: %w", err)
+	}
+	for _, s := range spawns {
+		if s.TargetID != nil {
+			callees[s.SymbolID] = append(callees[s.SymbolID], *s.TargetID)
+		}
+	}
+
+	frontier := ids
+	for level := 1; len(frontier) > 0 && (concurrencyDepthFlag <= 0 || level <= concurrencyDepthFlag); level++ {
+		var next []string
+		for _, id := range frontier {
+			for _, callee := range callees[id] {
+				if !seen[callee] {
+					seen[callee] = true
+					next = append(next, callee)
+				}
+			}
+		}
+		ids = append(ids, next...)
+		frontier = next
+	}
+	return ids, nil
+}
+
+// listConcurrency returns the sites matching the command line
+func listConcurrency(dbManager *db.Manager, args []string, ops []string, opts db.QueryOptions) ([]db.ConcurrencySite, error) {
+	var functionIDs []string
+	if len(args) > 0 {
+		ids, err := concurrencyFunctions(dbManager, args[0])
+		if err != nil {
+			return nil, err
+		}
+		functionIDs = ids
+	}
+	sites, err := dbManager.ListConcurrency(functionIDs, ops, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list concurrency sites: %w", err)
+	}
+	return sites, nil
+}
+
+func runConcurrency(cmd *cobra.Command, args []string) error {
+	vimgrep, err := concurrencyFormat.vimgrep()
+	if err != nil {
+		return err
+	}
+	if jsonOutputFlag {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		return runConcurrencyJSON(cmd, args)
+	}
+
+	ops, opts, err := concurrencyQuery()
+	if err != nil {
+		return err
+	}
+	if vimgrep && concurrencyDotFlag {
+		return fmt.Errorf("--dot cannot be combined with --format=vimgrep")
+	}
+	cwd, _, dbManager, _, err := openProject(true)
+	if err != nil {
+		return err
+	}
+	defer dbManager.Close()
+
+	sites, err := listConcurrency(dbManager, args, ops, opts)
+	if err != nil {
+		return err
+	}
+
+	if concurrencyDotFlag {
+		return writeConcurrencyDot(cmd.OutOrStdout(), dbManager, sites)
+	}
+	if vimgrep {
+		for _, s := range sites {
+			writeVimgrep(cmd.OutOrStdout(), relativePath(cwd, s.File), s.Line, s.Column+1,
+				fmt.Sprintf("%s %s in %s", s.Op, s.Target, s.Function.Name))
+		}
+		return nil
+	}
+
+	if len(sites) == 0 {
+		fmt.Println("🔀 No concurrency sites found (only Go is indexed)")
+		return noResults(cmd)
+	}
+
+	fmt.Printf("🔀 Concurrency sites (%s found):\n", Info(len(sites)))
+	currentFunction := ""
+	for _, s := range sites {
+		if s.SymbolID != currentFunction {
+			currentFunction = s.SymbolID
+			fmt.Printf("\n%s [%s] %s\n", Symbol(s.Function.Name), Keyword(s.Function.Kind),
+				Path(fmt.Sprintf("%s:%d", relativePath(cwd, s.Function.File), s.Function.Line)))
+		}
+		fmt.Printf("  %s %s %s  %s\n", Path(fmt.Sprintf("%4d:", s.Line)), Keyword(fmt.Sprintf("%-7s", s.Op)), Symbol(s.Target),
+			Dim(strings.TrimSpace(getSourceLine(s.File, s.Line))))
+	}
+	return nil
+}
+
+// writeConcurrencyDot prints sites as a Graphviz graph
+func writeConcurrencyDot(w io.Writer, dbManager *db.Manager, sites []db.ConcurrencySite) error {
+	fmt.Fprintln(w, "digraph concurrency {")
+	fmt.Fprintln(w, "  rankdir=LR;")
+	fmt.Fprintln(w, "  node [shape=box];")
+
+	nodes := make(map[string]bool)
+	node := func(id, label, attrs string) string {
+		quoted := strconv.Quote(id)
+		if !nodes[id] {
+			nodes[id] = true
+			fmt.Fprintf(w, "  %s [label=%s%s];\n", quoted, strconv.Quote(label), attrs)
+		}
+		return quoted
+	}
+	edges := make(map[string]bool)
+	edge := func(from, to, attrs string) {
+		line := fmt.Sprintf("  %s -> %s [%s];", from, to, attrs)
+		if !edges[line] {
+			edges[line] = true
+			fmt.Fprintln(w, line)
+		}
+	}
+
+	for _, s := range sites {
+		fn := node(s.SymbolID, s.Function.Name, "")
+		switch s.Op {
+		case db.ConcurrencyGo:
+			var target string
+			switch {
+			case s.TargetID != nil:
+				label := s.Target
+				if sym, err := dbManager.GetSymbolByID(*s.TargetID); err == nil && sym != nil {
+					label = sym.Name
+				}
+				target = node(*s.TargetID, label, "")
+			default:
+				// Function literals and unindexed functions get a node per site
+				target = node(fmt.Sprintf("go:%s:%d", s.SymbolID, s.Line), s.Target, ", style=dashed")
+			}
+			edge(fn, target, `label="go", style=dashed`)
+		case db.ConcurrencySend, db.ConcurrencyClose:
+			edge(fn, node("chan:"+s.Target, s.Target, ", shape=ellipse"), "label="+strconv.Quote(s.Op))
+		case db.ConcurrencyReceive:
+			edge(node("chan:"+s.Target, s.Target, ", shape=ellipse"), fn, `label="receive"`)
+		default:
+			edge(fn, node("mutex:"+s.Target, s.Target, ", shape=diamond"), "label="+strconv.Quote(s.Op))
+		}
+	}
+	fmt.Fprintln(w, "}")
+	return nil
+}
+
+func runConcurrencyJSON(cmd *cobra.Command, args []string) error {
+	out := cmd.OutOrStdout()
+	var query *string
+	if len(args) > 0 {
+		query = &args[0]
+	}
+	emitErr := func(code string, err error) error {
+		_ = EmitJSON(out, "concurrency", query, []concurrencyRecord{}, []EnvelopeError{{Code: code, Message: err.Error()}})
+		return err
+	}
+
+	ops, opts, err := concurrencyQuery()
+	if err != nil {
+		return emitErr("invalid_flag", err)
+	}
+	cwd, _, dbManager, code, err := openProject(true)
+	if err != nil {
+		return emitErr(code, err)
+	}
+	defer dbManager.Close()
+
+	sites, err := listConcurrency(dbManager, args, ops, opts)
+	if err != nil {
+		return emitErr("concurrency_failed", err)
+	}
+
+	records := make([]concurrencyRecord, 0, len(sites))
+	for _, s := range sites {
+		records = append(records, concurrencyRecord{
+			Function:   s.Function.Name,
+			FunctionID: s.SymbolID,
+			Op:         s.Op,
+			Target:     s.Target,
+			TargetID:   s.TargetID,
+			File:       relativePath(cwd, s.File),
+			Line:       s.Line,
+			Column:     s.Column + 1,
+			Text:       strings.TrimSpace(getSourceLine(s.File, s.Line)),
+		})
+	}
+	return emitResults(cmd, "concurrency", query, records)
+}
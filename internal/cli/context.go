@@ -0,0 +1,426 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tk-425/Codegraph/internal/bundle"
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+var (
+	contextLangFlag      string
+	contextKindFlag      string
+	contextMaxTokensFlag int
+)
+
+var contextCmd = &cobra.Command{
+	Use:   "context <symbol>",
+	Short: "Show everything needed to work on a symbol",
+	Long: `Bundle the definition, signature, docs, direct callers, direct callees,
+implementations, and the enclosing file outline of a symbol into one report.
+
+Human output is markdown, ready to paste into an LLM prompt; --json returns
+the same report as structured data.
+
+--max-tokens fits the report into an approximate token budget: the
+definition comes first, then callers ranked by how often they are called
+themselves, then callees, implementations, and the outline. Whatever does not
+fit is dropped and counted in "omitted".
+
+Examples:
+  codegraph context handleRequest
+  codegraph context Service --kind=interface
+  codegraph context parse --lang=go --json
+  codegraph context handleRequest --max-tokens=2000`,
+	Args: cobra.ExactArgs(1),
+	RunE: runContext,
+}
+
+func init() {
+	contextCmd.Flags().StringVar(&contextLangFlag, "lang", "", "Filter by language(s), comma-separated")
+	contextCmd.Flags().StringVar(&contextKindFlag, "kind", "", kindFlagUsage)
+	contextCmd.Flags().IntVar(&contextMaxTokensFlag, "max-tokens", 0, "Approximate token budget for the report (0 = unlimited)")
+	rootCmd.AddCommand(contextCmd)
+}
+
+type contextDefinition struct {
+	Name          string `json:"name"`
+	Kind          string `json:"kind"`
+	File          string `json:"file"`
+	Line          int    `json:"line"`
+	EndLine       int    `json:"end_line"`
+	Language      string `json:"language"`
+	Signature     string `json:"signature"`
+	Documentation string `json:"documentation"`
+	Source        string `json:"source"`
+}
+
+// contextReference is a related symbol. For callers the location is the call
+// site and Snippet is the calling line; otherwise it is the definition.
+type contextReference struct {
+	Name      string `json:"name"`
+	Kind      string `json:"kind"`
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	Signature string `json:"signature,omitempty"`
+	Snippet   string `json:"snippet,omitempty"`
+
+	id string // symbol ID, used to rank callers
+}
+
+type contextOutlineEntry struct {
+	Name  string `json:"name"`
+	Kind  string `json:"kind"`
+	Line  int    `json:"line"`
+	Scope string `json:"scope,omitempty"`
+	Depth int    `json:"depth,omitempty"` // Nesting under containing symbols
+}
+
+type contextRecord struct {
+	Definition      contextDefinition     `json:"definition"`
+	Callers         []contextReference    `json:"callers"`
+	Callees         []contextReference    `json:"callees"`
+	Implementations []contextReference    `json:"implementations"`
+	Outline         []contextOutlineEntry `json:"outline"`
+	Truncated       bool                  `json:"truncated,omitempty"` // Cut to fit --max-tokens
+	Omitted         int                   `json:"omitted,omitempty"`   // Entries dropped to fit --max-tokens
+}
+
+func runContext(cmd *cobra.Command, args []string) error {
+	symbol := args[0]
+	if jsonOutputFlag {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		return runContextJSON(cmd, symbol)
+	}
+
+	cwd, _, dbManager, _, err := openProject(false)
+	if err != nil {
+		return err
+	}
+	defer dbManager.Close()
+
+	records, err := buildContextRecords(dbManager, cwd, symbol)
+	if err != nil {
+		return err
+	}
+
+	if len(records) == 0 {
+		fmt.Printf("🧭 No symbol named '%s' found\n", Warning(symbol))
+		return noResults(cmd)
+	}
+
+	for i, rec := range records {
+		if i > 0 {
+			fmt.Println("\n---")
+		}
+		writeContextMarkdown(os.Stdout, rec)
+	}
+	return nil
+}
+
+func runContextJSON(cmd *cobra.Command, symbol string) error {
+	out := cmd.OutOrStdout()
+	emitErr := func(code string, err error) error {
+		_ = EmitJSON(out, "context", &symbol, []contextRecord{}, []EnvelopeError{{Code: code, Message: err.Error()}})
+		return err
+	}
+
+	cwd, _, dbManager, code, err := openProject(false)
+	if err != nil {
+		return emitErr(code, err)
+	}
+	defer dbManager.Close()
+
+	records, err := buildContextRecords(dbManager, cwd, symbol)
+	if err != nil {
+		return emitErr("context_lookup_failed", err)
+	}
+
+	return emitResults(cmd, "context", &symbol, records)
+}
+
+// buildContextRecords assembles one report per definition matching symbol.
+func buildContextRecords(dbManager *db.Manager, cwd, symbol string) ([]contextRecord, error) {
+	symbols, err := dbManager.FindSymbolsByName(symbol, queryOptions(contextLangFlag, contextKindFlag))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find symbol: %w", err)
+	}
+
+	records := make([]contextRecord, 0, len(symbols))
+	for _, sym := range symbols {
+		rec, err := buildContextRecord(dbManager, cwd, sym)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+
+	if contextMaxTokensFlag > 0 {
+		if err := packContextRecords(dbManager, records, contextMaxTokensFlag); err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
+
+func buildContextRecord(dbManager *db.Manager, cwd string, sym db.Symbol) (contextRecord, error) {
+	endLine := symbolEndLine(sym)
+
+	rec := contextRecord{
+		Definition: contextDefinition{
+			Name:          sym.Name,
+			Kind:          sym.Kind,
+			File:          relativePath(cwd, sym.File),
+			Line:          sym.Line,
+			EndLine:       endLine,
+			Language:      sym.Language,
+			Signature:     strings.TrimSpace(sym.Signature),
+			Documentation: strings.TrimSpace(sym.Documentation),
+		},
+		Callers:         []contextReference{},
+		Callees:         []contextReference{},
+		Implementations: []contextReference{},
+		Outline:         []contextOutlineEntry{},
+	}
+
+	// Unreadable sources (moved or deleted since the build) leave Source empty
+	if snippet, err := bundle.ReadSnippet(sym.File, sym.Line, endLine, 0, 0); err == nil {
+		rec.Definition.Source = snippet.Text()
+	}
+
+	callers, err := dbManager.GetCallersByID(sym.ID, db.QueryOptions{Tests: testFilter(), Owner: ownerFlag, Package: packageFlag})
+	if err != nil {
+		return rec, fmt.Errorf("failed to find callers: %w", err)
+	}
+	for _, c := range callers {
+		rec.Callers = append(rec.Callers, contextReference{
+			Name:    c.Name,
+			Kind:    c.Kind,
+			File:    relativePath(cwd, c.CallFile),
+			Line:    c.CallLine,
+			Snippet: getSourceLine(c.CallFile, c.CallLine),
+			id:      c.ID,
+		})
+	}
+
+	callees, err := dbManager.GetCalleesByID(sym.ID, db.QueryOptions{Tests: testFilter(), Owner: ownerFlag, Package: packageFlag})
+	if err != nil {
+		return rec, fmt.Errorf("failed to find callees: %w", err)
+	}
+	for _, c := range callees {
+		rec.Callees = append(rec.Callees, contextReference{
+			Name:      c.Name,
+			Kind:      c.Kind,
+			File:      relativePath(cwd, c.File),
+			Line:      c.Line,
+			Signature: strings.TrimSpace(c.Signature),
+		})
+	}
+
+	impls, err := dbManager.GetImplementations(sym.ID)
+	if err != nil {
+		return rec, fmt.Errorf("failed to find implementations: %w", err)
+	}
+	for _, impl := range impls {
+		rec.Implementations = append(rec.Implementations, contextReference{
+			Name:      impl.Name,
+			Kind:      impl.Kind,
+			File:      relativePath(cwd, impl.File),
+			Line:      impl.Line,
+			Signature: strings.TrimSpace(impl.Signature),
+		})
+	}
+
+	outline, err := dbManager.GetFileSymbols(sym.File)
+	if err != nil {
+		return rec, fmt.Errorf("failed to load file outline: %w", err)
+	}
+	parents, err := dbManager.GetFileContainment(sym.File)
+	if err != nil {
+		return rec, fmt.Errorf("failed to load file outline: %w", err)
+	}
+	for _, o := range outline {
+		rec.Outline = append(rec.Outline, contextOutlineEntry{
+			Name:  o.Name,
+			Kind:  o.Kind,
+			Line:  o.Line,
+			Scope: o.Scope,
+			Depth: containmentDepth(parents, o.ID),
+		})
+	}
+
+	return rec, nil
+}
+
+// writeContextMarkdown renders a context report as plain markdown (no ANSI
+// colors) so it can be piped straight into a prompt.
+func writeContextMarkdown(w io.Writer, rec contextRecord) {
+	def := rec.Definition
+	fmt.Fprintf(w, "# %s (%s)\n\n", def.Name, def.Kind)
+	fmt.Fprintf(w, "Defined at `%s:%d` (%s)\n\n", def.File, def.Line, def.Language)
+	if def.Signature != "" {
+		fmt.Fprintf(w, "Signature: `%s`\n\n", def.Signature)
+	}
+	if def.Documentation != "" {
+		fmt.Fprintf(w, "%s\n\n", def.Documentation)
+	}
+	if def.Source != "" {
+		fmt.Fprintf(w, "```%s\n%s\n```\n\n", def.Language, def.Source)
+	}
+
+	writeContextReferences(w, "Callers", rec.Callers)
+	writeContextReferences(w, "Callees", rec.Callees)
+	writeContextReferences(w, "Implementations", rec.Implementations)
+
+	fmt.Fprintf(w, "## Outline of %s\n\n", def.File)
+	for _, o := range rec.Outline {
+		fmt.Fprintln(w, contextOutlineLine(o))
+	}
+
+	if rec.Omitted > 0 {
+		fmt.Fprintf(w, "\n_%d entries omitted to fit the token budget_\n", rec.Omitted)
+	}
+}
+
+func writeContextReferences(w io.Writer, title string, refs []contextReference) {
+	fmt.Fprintf(w, "## %s (%d)\n\n", title, len(refs))
+	if len(refs) == 0 {
+		fmt.Fprintln(w, "_none_")
+		fmt.Fprintln(w)
+		return
+	}
+	for _, r := range refs {
+		fmt.Fprintln(w, contextReferenceLine(r))
+	}
+	fmt.Fprintln(w)
+}
+
+func contextReferenceLine(r contextReference) string {
+	line := fmt.Sprintf("- `%s` at `%s:%d`", r.Name, r.File, r.Line)
+	if r.Signature != "" {
+		line += fmt.Sprintf(" — `%s`", r.Signature)
+	} else if r.Snippet != "" {
+		line += fmt.Sprintf(" — `%s`", r.Snippet)
+	}
+	return line
+}
+
+func contextOutlineLine(o contextOutlineEntry) string {
+	// Nested entries are indented under their container; without
+	// containment (older indexes) the scope qualifies the name instead
+	if o.Depth > 0 {
+		return fmt.Sprintf("%s- %d: %s `%s`", strings.Repeat("  ", o.Depth), o.Line, o.Kind, o.Name)
+	}
+	name := o.Name
+	if o.Scope != "" {
+		name = o.Scope + "." + o.Name
+	}
+	return fmt.Sprintf("- %d: %s `%s`", o.Line, o.Kind, name)
+}
+
+// containmentDepth counts the containers above id in a child -> parent map
+func containmentDepth(parents map[string]string, id string) int {
+	depth := 0
+	for parent, ok := parents[id]; ok && depth < len(parents); parent, ok = parents[parent] {
+		depth++
+	}
+	return depth
+}
+
+// packContextRecords trims records in place to fit maxTokens. Definitions are
+// packed first (headers always, then docs and source as far as they fit),
+// then callers ranked by centrality, callees, implementations, and outlines.
+// Costs are estimated from the markdown rendering.
+func packContextRecords(dbManager *db.Manager, records []contextRecord, maxTokens int) error {
+	packer := bundle.NewPacker(maxTokens)
+
+	for i := range records {
+		rec := &records[i]
+		def := &rec.Definition
+		packer.Add(fmt.Sprintf("# %s (%s)\nDefined at `%s:%d` (%s)\nSignature: `%s`", def.Name, def.Kind, def.File, def.Line, def.Language, def.Signature))
+		if def.Documentation != "" && !packer.Fit(def.Documentation) {
+			def.Documentation = ""
+			rec.Truncated = true
+		}
+		if def.Source != "" {
+			// Long bodies may take at most half the budget so callers still fit
+			source, cut := packer.FitLines(def.Source, maxTokens/2)
+			def.Source = source
+			rec.Truncated = rec.Truncated || cut
+		}
+	}
+
+	// Rank callers by how often they are called themselves: a caller on a hot
+	// path says more about how the symbol is used than a one-off script
+	var callerIDs []string
+	for _, rec := range records {
+		for _, c := range rec.Callers {
+			callerIDs = append(callerIDs, c.id)
+		}
+	}
+	centrality, err := dbManager.GetIncomingCallCounts(callerIDs)
+	if err != nil {
+		return fmt.Errorf("failed to rank callers: %w", err)
+	}
+	for i := range records {
+		callers := records[i].Callers
+		sort.SliceStable(callers, func(a, b int) bool {
+			return centrality[callers[a].id] > centrality[callers[b].id]
+		})
+		records[i].Callers = packReferences(packer, &records[i], callers)
+	}
+
+	for i := range records {
+		records[i].Callees = packReferences(packer, &records[i], records[i].Callees)
+	}
+	for i := range records {
+		records[i].Implementations = packReferences(packer, &records[i], records[i].Implementations)
+	}
+	for i := range records {
+		rec := &records[i]
+		kept := rec.Outline[:0]
+		for _, o := range rec.Outline {
+			if packer.Fit(contextOutlineLine(o)) {
+				kept = append(kept, o)
+			} else {
+				rec.Omitted++
+				rec.Truncated = true
+			}
+		}
+		rec.Outline = kept
+	}
+	return nil
+}
+
+// packReferences keeps the references that fit, in order, counting the rest
+// as omitted on rec.
+func packReferences(packer *bundle.Packer, rec *contextRecord, refs []contextReference) []contextReference {
+	kept := refs[:0]
+	for _, r := range refs {
+		if packer.Fit(contextReferenceLine(r)) {
+			kept = append(kept, r)
+		} else {
+			rec.Omitted++
+		}
+	}
+	if len(kept) < len(refs) {
+		rec.Truncated = true
+	}
+	return kept
+}
+
+// relativePath returns path relative to cwd, or path unchanged when it cannot
+// be made relative.
+func relativePath(cwd, path string) string {
+	if rel, err := filepath.Rel(cwd, path); err == nil {
+		return rel
+	}
+	return path
+}
@@ -0,0 +1,240 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+var (
+	coverageLangFlag  string
+	coverageLimitFlag int
+)
+
+var coverageCmd = &cobra.Command{
+	Use:   "coverage",
+	Short: "Report scanned files the index holds no symbols or calls for",
+	Long: `Report where the index is silently incomplete: files that were scanned
+but produced no symbols, and files with functions but no call edges. Both
+usually mean extraction failed, e.g. a language server that returned
+nothing or a grammar that does not cover the file's syntax.
+
+A summary per language and extractor (lsp or tree-sitter) comes first,
+followed by the files themselves.
+
+Examples:
+  codegraph coverage
+  codegraph coverage --lang=python --limit=0
+  codegraph coverage --json`,
+	Args: cobra.NoArgs,
+	RunE: runCoverage,
+}
+
+func init() {
+	coverageCmd.Flags().StringVar(&coverageLangFlag, "lang", "", "Filter by language(s), comma-separated")
+	coverageCmd.Flags().IntVar(&coverageLimitFlag, "limit", 20, "Max files to list per problem (0 = unlimited)")
+	rootCmd.AddCommand(coverageCmd)
+}
+
+// Coverage problems
+const (
+	coverageNoSymbols = "no_symbols" // Scanned, but nothing extracted
+	coverageNoCalls   = "no_calls"   // Functions, but no call sites
+)
+
+// coverageGroup summarizes the files of one language and extractor
+type coverageGroup struct {
+	Language  string
+	Source    string
+	Files     int
+	NoSymbols int
+	NoCalls   int
+}
+
+// coverageRecord is the JSON form of a file with a coverage problem
+type coverageRecord struct {
+	File      string `json:"file"`
+	Language  string `json:"language"`
+	Source    string `json:"source"`
+	Problem   string `json:"problem"`
+	Symbols   int    `json:"symbols"`
+	Functions int    `json:"functions"`
+	Calls     int    `json:"calls"`
+}
+
+// coverageProblem returns the problem of f, or "" when it looks complete
+func coverageProblem(f db.FileCoverage) string {
+	switch {
+	case f.Symbols == 0:
+		return coverageNoSymbols
+	case f.Functions > 0 && f.Calls == 0:
+		return coverageNoCalls
+	}
+	return ""
+}
+
+// loadCoverage returns the scanned files in the --lang languages
+func loadCoverage(dbManager *db.Manager) ([]db.FileCoverage, error) {
+	files, err := dbManager.GetFileCoverage()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read coverage: %w", err)
+	}
+	languages := parseListFlag(coverageLangFlag)
+	if len(languages) == 0 {
+		return files, nil
+	}
+	var filtered []db.FileCoverage
+	for _, f := range files {
+		for _, lang := range languages {
+			if f.Language == lang {
+				filtered = append(filtered, f)
+				break
+			}
+		}
+	}
+	return filtered, nil
+}
+
+// summarizeCoverage groups files by language and extractor, largest first
+func summarizeCoverage(files []db.FileCoverage) []coverageGroup {
+	byKey := make(map[[2]string]*coverageGroup)
+	for _, f := range files {
+		key := [2]string{f.Language, f.Source}
+		g := byKey[key]
+		if g == nil {
+			g = &coverageGroup{Language: f.Language, Source: f.Source}
+			byKey[key] = g
+		}
+		g.Files++
+		switch coverageProblem(f) {
+		case coverageNoSymbols:
+			g.NoSymbols++
+		case coverageNoCalls:
+			g.NoCalls++
+		}
+	}
+	groups := make([]coverageGroup, 0, len(byKey))
+	for _, g := range byKey {
+		groups = append(groups, *g)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Files != groups[j].Files {
+			return groups[i].Files > groups[j].Files
+		}
+		if groups[i].Language != groups[j].Language {
+			return groups[i].Language < groups[j].Language
+		}
+		return groups[i].Source < groups[j].Source
+	})
+	return groups
+}
+
+func runCoverage(cmd *cobra.Command, args []string) error {
+	if jsonOutputFlag {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		return runCoverageJSON(cmd)
+	}
+
+	cwd, _, dbManager, _, err := openProject(true)
+	if err != nil {
+		return err
+	}
+	defer dbManager.Close()
+
+	files, err := loadCoverage(dbManager)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		fmt.Println("📋 No scanned files. Run 'codegraph build' first")
+		return nil
+	}
+
+	fmt.Printf("📋 Index coverage (%s files scanned):\n\n", Info(formatNumber(len(files))))
+	fmt.Printf("   %-12s %-12s %7s %11s %9s\n", "Language", "Source", "Files", "No symbols", "No calls")
+	for _, g := range summarizeCoverage(files) {
+		source := g.Source
+		if source == "" {
+			source = "unknown"
+		}
+		noSymbols := fmt.Sprintf("%11d", g.NoSymbols)
+		if g.NoSymbols > 0 {
+			noSymbols = Warning(noSymbols)
+		}
+		noCalls := fmt.Sprintf("%9d", g.NoCalls)
+		if g.NoCalls > 0 {
+			noCalls = Warning(noCalls)
+		}
+		fmt.Printf("   %s %-12s %7d %s %s\n", Keyword(fmt.Sprintf("%-12s", g.Language)), source, g.Files, noSymbols, noCalls)
+	}
+
+	for _, problem := range []struct{ name, title string }{
+		{coverageNoSymbols, "Files with no symbols"},
+		{coverageNoCalls, "Files with functions but no calls"},
+	} {
+		var matched []db.FileCoverage
+		for _, f := range files {
+			if coverageProblem(f) == problem.name {
+				matched = append(matched, f)
+			}
+		}
+		if len(matched) == 0 {
+			continue
+		}
+		fmt.Printf("\n⚠️  %s (%s):\n", Bold(problem.title), Info(len(matched)))
+		for i, f := range matched {
+			if coverageLimitFlag > 0 && i == coverageLimitFlag {
+				fmt.Printf("   %s\n", Dim(fmt.Sprintf("... and %d more (--limit=0 to list all)", len(matched)-i)))
+				break
+			}
+			detail := f.Language
+			if f.Source != "" {
+				detail += ", " + f.Source
+			}
+			if problem.name == coverageNoCalls {
+				detail += fmt.Sprintf(", %d functions", f.Functions)
+			}
+			fmt.Printf("   %s %s\n", Path(relativePath(cwd, f.Path)), Dim("["+detail+"]"))
+		}
+	}
+	return nil
+}
+
+func runCoverageJSON(cmd *cobra.Command) error {
+	out := cmd.OutOrStdout()
+	emitErr := func(code string, err error) error {
+		_ = EmitJSON(out, "coverage", nil, []coverageRecord{}, []EnvelopeError{{Code: code, Message: err.Error()}})
+		return err
+	}
+
+	cwd, _, dbManager, code, err := openProject(true)
+	if err != nil {
+		return emitErr(code, err)
+	}
+	defer dbManager.Close()
+
+	files, err := loadCoverage(dbManager)
+	if err != nil {
+		return emitErr("coverage_failed", err)
+	}
+	records := []coverageRecord{}
+	for _, f := range files {
+		problem := coverageProblem(f)
+		if problem == "" {
+			continue
+		}
+		records = append(records, coverageRecord{
+			File:      relativePath(cwd, f.Path),
+			Language:  f.Language,
+			Source:    f.Source,
+			Problem:   problem,
+			Symbols:   f.Symbols,
+			Functions: f.Functions,
+			Calls:     f.Calls,
+		})
+	}
+	return EmitJSON(out, "coverage", nil, records, nil)
+}
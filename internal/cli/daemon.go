@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.com/tk-425/Codegraph/internal/daemon"
+)
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Keep language servers running between commands",
+	Long: `Run a background daemon that keeps the project's language servers started
+and analyzed, and its index open. While it runs, commands that fall back to
+a language server send their requests to it instead of starting a server of
+their own, so they answer without the startup cost:
+
+  implementations --refresh   asks the servers for implementations
+  rename-check                adds the servers' references to the text search
+
+The daemon keeps the servers in sync with the files it opened on them, and
+tells them about the files each build reindexed.
+
+The daemon runs in the foreground until interrupted or stopped; it listens
+on .codegraph/daemon.sock.
+
+Examples:
+  codegraph daemon &
+  codegraph daemon status
+  codegraph daemon stop`,
+	Args: cobra.NoArgs,
+	RunE: runDaemon,
+}
+
+var daemonStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Report whether the daemon is running",
+	Args:  cobra.NoArgs,
+	RunE:  runDaemonStatus,
+}
+
+var daemonStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the daemon and its language servers",
+	Args:  cobra.NoArgs,
+	RunE:  runDaemonStop,
+}
+
+func init() {
+	daemonCmd.AddCommand(daemonStatusCmd, daemonStopCmd)
+	rootCmd.AddCommand(daemonCmd)
+}
+
+func runDaemon(cmd *cobra.Command, args []string) error {
+	cwd, cfg, dbManager, _, err := openProject(false)
+	if err != nil {
+		return err
+	}
+	defer dbManager.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Printf("🛰️  Daemon listening on %s\n", Path(relativePath(cwd, daemon.SocketPath(cwd))))
+	if err := daemon.NewServer(cfg, cwd, dbManager).Serve(ctx); err != nil {
+		return err
+	}
+	fmt.Printf("🛰️  %s\n", Dim("Daemon stopped"))
+	return nil
+}
+
+func runDaemonStatus(cmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+	client, err := daemon.Dial(cwd)
+	if err != nil {
+		fmt.Printf("🛰️  %s\n", Warning("Daemon is not running"))
+		return nil
+	}
+	defer client.Close()
+
+	languages, err := client.Ping(context.Background())
+	if err != nil {
+		return err
+	}
+	servers := Dim("no language servers started yet")
+	if len(languages) > 0 {
+		servers = Keyword(strings.Join(languages, ", "))
+	}
+	fmt.Printf("🛰️  %s (%s)\n", Success("Daemon is running"), servers)
+	return nil
+}
+
+func runDaemonStop(cmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+	client, err := daemon.Dial(cwd)
+	if errors.Is(err, daemon.ErrNotRunning) {
+		fmt.Printf("🛰️  %s\n", Warning("Daemon is not running"))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := client.Shutdown(context.Background()); err != nil {
+		return fmt.Errorf("failed to stop daemon: %w", err)
+	}
+	fmt.Printf("🛰️  %s\n", Success("Daemon stopped"))
+	return nil
+}
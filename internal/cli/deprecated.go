@@ -0,0 +1,248 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+var (
+	deprecatedFailFlag bool
+	deprecatedLangFlag string
+	deprecatedFormat   formatFlag
+)
+
+var deprecatedCmd = &cobra.Command{
+	Use:   "deprecated",
+	Short: "List the call sites of deprecated symbols, by caller package",
+	Long: `List every call to a deprecated symbol, grouped by the package (directory)
+of the calling function, with the deprecation notice of each symbol.
+
+A symbol is deprecated when 'codegraph build' tagged it deprecated: Java
+@Deprecated, Rust #[deprecated], C# [Obsolete], Swift @available(*,
+deprecated), a Python or TypeScript @deprecated decorator, or a doc comment
+with a deprecation notice (Go "Deprecated:" paragraphs, JSDoc, Javadoc and
+PHPDoc @deprecated, Sphinx ".. deprecated::" in Python docstrings). Calls
+made from deprecated symbols are left out, since they go away with them.
+
+--fail-on-use exits with an error when any call is found, so the command
+can gate CI against new uses of deprecated APIs. Callers in test files are
+left out unless --include-tests is given.
+
+Examples:
+  codegraph deprecated
+  codegraph deprecated --lang=go --fail-on-use
+  codegraph deprecated --format=vimgrep
+  codegraph deprecated --owner=@acme/storage --json`,
+	Args: cobra.NoArgs,
+	RunE: runDeprecated,
+}
+
+func init() {
+	deprecatedCmd.Flags().BoolVar(&deprecatedFailFlag, "fail-on-use", false, "Exit with an error when deprecated symbols are called")
+	deprecatedCmd.Flags().StringVar(&deprecatedLangFlag, "lang", "", "Filter callers by language(s), comma-separated")
+	deprecatedFormat.register(deprecatedCmd)
+	rootCmd.AddCommand(deprecatedCmd)
+}
+
+// deprecatedUse is a call to a deprecated symbol
+type deprecatedUse struct {
+	db.CallerInfo
+	Package string    // Caller's directory relative to the project root
+	Callee  db.Symbol // Deprecated symbol
+	Notice  string    // Deprecation message, possibly empty
+}
+
+type deprecatedRecord struct {
+	Package  string `json:"package"`
+	Caller   string `json:"caller"`
+	CallerID string `json:"caller_id"`
+	Symbol   string `json:"symbol"`
+	SymbolID string `json:"symbol_id"`
+	Notice   string `json:"notice"`
+	File     string `json:"file"` // Where the call is
+	Line     int    `json:"line"`
+	Column   int    `json:"column"` // 1-indexed
+}
+
+// findDeprecatedUses returns the calls to deprecated symbols from callers
+// matching opts that are not deprecated themselves, by package then call
+// site, and how many symbols are deprecated
+func findDeprecatedUses(dbManager *db.Manager, cwd string, opts db.QueryOptions) ([]deprecatedUse, int, error) {
+	deprecated, err := dbManager.ListSymbols(db.QueryOptions{Tags: []string{db.TagDeprecated}})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list deprecated symbols: %w", err)
+	}
+	ids := make([]string, len(deprecated))
+	isDeprecated := make(map[string]bool)
+	for i, s := range deprecated {
+		ids[i] = s.ID
+		isDeprecated[s.ID] = true
+	}
+	tags, err := dbManager.GetSymbolTags(ids)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get tags: %w", err)
+	}
+
+	var uses []deprecatedUse
+	for _, s := range deprecated {
+		callers, err := dbManager.GetCallersByID(s.ID, opts)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to get callers: %w", err)
+		}
+		notice := deprecationNotice(tags[s.ID])
+		for _, c := range callers {
+			if isDeprecated[c.ID] {
+				continue
+			}
+			uses = append(uses, deprecatedUse{
+				CallerInfo: c,
+				Package:    filepath.ToSlash(filepath.Dir(relativePath(cwd, c.File))),
+				Callee:     s,
+				Notice:     notice,
+			})
+		}
+	}
+	sort.SliceStable(uses, func(i, j int) bool {
+		a, b := uses[i], uses[j]
+		if a.Package != b.Package {
+			return a.Package < b.Package
+		}
+		if a.CallFile != b.CallFile {
+			return a.CallFile < b.CallFile
+		}
+		if a.CallLine != b.CallLine {
+			return a.CallLine < b.CallLine
+		}
+		return a.CallColumn < b.CallColumn
+	})
+	return uses, len(deprecated), nil
+}
+
+// deprecationNotice returns the first non-empty message of a symbol's
+// deprecated tags: @Deprecated(since = "2"), [Obsolete("use Y")] or the
+// text after a "Deprecated:" doc comment marker
+func deprecationNotice(tags []db.Tag) string {
+	for _, t := range tags {
+		if t.Tag == db.TagDeprecated && t.Args != "" {
+			return t.Args
+		}
+	}
+	return ""
+}
+
+// deprecatedFailure is the error of --fail-on-use
+func deprecatedFailure(uses []deprecatedUse) error {
+	return fmt.Errorf("found %d calls to deprecated symbols", len(uses))
+}
+
+func runDeprecated(cmd *cobra.Command, args []string) error {
+	vimgrep, err := deprecatedFormat.vimgrep()
+	if err != nil {
+		return err
+	}
+	if jsonOutputFlag {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		return runDeprecatedJSON(cmd)
+	}
+
+	cwd, _, dbManager, _, err := openProject(true)
+	if err != nil {
+		return err
+	}
+	defer dbManager.Close()
+
+	uses, symbols, err := findDeprecatedUses(dbManager, cwd, queryOptions(deprecatedLangFlag, ""))
+	if err != nil {
+		return err
+	}
+
+	if vimgrep {
+		for _, u := range uses {
+			message := fmt.Sprintf("%s calls deprecated %s", u.Name, u.Callee.Name)
+			if u.Notice != "" {
+				message += ": " + u.Notice
+			}
+			writeVimgrep(cmd.OutOrStdout(), relativePath(cwd, u.CallFile), u.CallLine, u.CallColumn+1, message)
+		}
+	} else if len(uses) == 0 {
+		fmt.Printf("✅ No calls to deprecated symbols (%s deprecated symbols indexed)\n", Info(symbols))
+		return nil
+	} else {
+		printDeprecatedUses(cwd, uses)
+	}
+
+	if deprecatedFailFlag && len(uses) > 0 {
+		cmd.SilenceUsage = true
+		return deprecatedFailure(uses)
+	}
+	return nil
+}
+
+// printDeprecatedUses prints the calls under a header per caller package
+func printDeprecatedUses(cwd string, uses []deprecatedUse) {
+	packages, callees := make(map[string]bool), make(map[string]bool)
+	for _, u := range uses {
+		packages[u.Package] = true
+		callees[u.Callee.ID] = true
+	}
+	fmt.Printf("⚠️  Deprecated symbols called (%s calls of %s symbols in %s packages):\n",
+		Info(len(uses)), Info(len(callees)), Info(len(packages)))
+
+	currentPackage := ""
+	for _, u := range uses {
+		if u.Package != currentPackage {
+			currentPackage = u.Package
+			fmt.Printf("\n%s\n", Path(u.Package))
+		}
+		fmt.Printf("  %s → %s %s\n", Symbol(u.Name), Warning(u.Callee.Name),
+			Path(fmt.Sprintf("%s:%d", relativePath(cwd, u.CallFile), u.CallLine)))
+		if u.Notice != "" {
+			fmt.Printf("    %s\n", Dim(u.Notice))
+		}
+	}
+}
+
+func runDeprecatedJSON(cmd *cobra.Command) error {
+	out := cmd.OutOrStdout()
+	emitErr := func(code string, err error) error {
+		_ = EmitJSON(out, "deprecated", nil, []deprecatedRecord{}, []EnvelopeError{{Code: code, Message: err.Error()}})
+		return err
+	}
+
+	cwd, _, dbManager, code, err := openProject(true)
+	if err != nil {
+		return emitErr(code, err)
+	}
+	defer dbManager.Close()
+
+	uses, _, err := findDeprecatedUses(dbManager, cwd, queryOptions(deprecatedLangFlag, ""))
+	if err != nil {
+		return emitErr("deprecated_failed", err)
+	}
+	records := make([]deprecatedRecord, 0, len(uses))
+	for _, u := range uses {
+		records = append(records, deprecatedRecord{
+			Package:  u.Package,
+			Caller:   u.Name,
+			CallerID: u.ID,
+			Symbol:   u.Callee.Name,
+			SymbolID: u.Callee.ID,
+			Notice:   u.Notice,
+			File:     relativePath(cwd, u.CallFile),
+			Line:     u.CallLine,
+			Column:   u.CallColumn + 1,
+		})
+	}
+	if err := EmitJSON(out, "deprecated", nil, records, nil); err != nil {
+		return err
+	}
+	if deprecatedFailFlag && len(uses) > 0 {
+		return deprecatedFailure(uses)
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/tk-425/Codegraph/internal/db"
+	"github.com/tk-425/Codegraph/internal/snapshot"
+)
+
+var (
+	diffLangFlag string
+	diffKindFlag string
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <label1> [label2]",
+	Short: "Compare two index snapshots",
+	Long: `Report what structurally changed between two index snapshots: the
+symbols added, removed and renamed (or moved to another file), and the
+call edges added and removed. Use "current" for the live index; it is the
+default second label.
+
+Incremental builds record a rename when a symbol disappears and one of
+the same kind and body, apart from the name, appears. Other renames are
+recognized when a removed and an added symbol have the same kind, scope
+and signature apart from the name, or the same name in another file.
+Call edges through a renamed symbol are not reported as changed.
+
+Examples:
+  codegraph snapshot create before-upgrade && codegraph build
+  codegraph diff before-upgrade
+  codegraph diff v1.2 v1.3 --lang=go
+  codegraph diff v1.2 current --kind=function --json`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runDiff,
+}
+
+func init() {
+	diffCmd.Flags().StringVar(&diffLangFlag, "lang", "", "Filter by language(s), comma-separated")
+	diffCmd.Flags().StringVar(&diffKindFlag, "kind", "", "Filter by symbol kind(s); prefix with ! to exclude")
+	rootCmd.AddCommand(diffCmd)
+}
+
+// diffRecord is one change in the JSON form of a snapshot.Diff. Change is
+// added, removed, renamed, call_added or call_removed; symbol changes fill
+// the symbol fields, call changes fill Caller and Callee.
+type diffRecord struct {
+	Change  string `json:"change"`
+	Name    string `json:"name,omitempty"`
+	Kind    string `json:"kind,omitempty"`
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	OldName string `json:"old_name,omitempty"`
+	OldFile string `json:"old_file,omitempty"`
+	OldLine int    `json:"old_line,omitempty"`
+	Caller  string `json:"caller,omitempty"`
+	Callee  string `json:"callee,omitempty"`
+}
+
+// diffLabels returns the labels to compare, defaulting the second one to
+// the live index
+func diffLabels(args []string) (string, string) {
+	if len(args) == 2 {
+		return args[0], args[1]
+	}
+	return args[0], snapshot.Current
+}
+
+// compareSnapshots opens the index states labelled from and to, with
+// snapshot.Current for the live index dbManager, and diffs them
+func compareSnapshots(cwd string, dbManager *db.Manager, from, to string) (*snapshot.Diff, error) {
+	open := func(label string) (*db.Manager, func(), error) {
+		if label == snapshot.Current {
+			return dbManager, func() {}, nil
+		}
+		m, err := snapshot.Open(cwd, label)
+		if err != nil {
+			return nil, nil, err
+		}
+		return m, func() { m.Close() }, nil
+	}
+	fromDB, closeFrom, err := open(from)
+	if err != nil {
+		return nil, err
+	}
+	defer closeFrom()
+	toDB, closeTo, err := open(to)
+	if err != nil {
+		return nil, err
+	}
+	defer closeTo()
+
+	d, err := snapshot.Compare(fromDB, toDB, queryOptions(diffLangFlag, diffKindFlag))
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare snapshots: %w", err)
+	}
+	return d, nil
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	from, to := diffLabels(args)
+	if jsonOutputFlag {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		return runDiffJSON(cmd, from, to)
+	}
+
+	cwd, _, dbManager, _, err := openProject(true)
+	if err != nil {
+		return err
+	}
+	defer dbManager.Close()
+
+	d, err := compareSnapshots(cwd, dbManager, from, to)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("🔀 Changes from %s to %s:\n", Symbol(from), Symbol(to))
+	if d.Empty() {
+		fmt.Printf("\n  %s\n", Dim("No structural changes"))
+		return nil
+	}
+
+	location := func(s db.Symbol) string {
+		return Path(fmt.Sprintf("%s:%d", relativePath(cwd, s.File), s.Line))
+	}
+	if len(d.Added) > 0 {
+		fmt.Printf("\n%s (%s):\n", Bold("Added symbols"), Info(len(d.Added)))
+		for _, s := range d.Added {
+			fmt.Printf("  %s %s [%s] %s\n", Success("+"), Symbol(s.Name), Keyword(s.Kind), location(s))
+		}
+	}
+	if len(d.Removed) > 0 {
+		fmt.Printf("\n%s (%s):\n", Bold("Removed symbols"), Info(len(d.Removed)))
+		for _, s := range d.Removed {
+			fmt.Printf("  %s %s [%s] %s\n", Error("-"), Symbol(s.Name), Keyword(s.Kind), location(s))
+		}
+	}
+	if len(d.Renamed) > 0 {
+		fmt.Printf("\n%s (%s):\n", Bold("Renamed symbols"), Info(len(d.Renamed)))
+		for _, r := range d.Renamed {
+			fmt.Printf("  %s %s → %s [%s] %s\n", Warning("~"), Symbol(r.Old.Name), Symbol(r.New.Name), Keyword(r.New.Kind), location(r.New))
+			if relativePath(cwd, r.Old.File) != relativePath(cwd, r.New.File) {
+				fmt.Printf("      %s\n", Dim("moved from "+relativePath(cwd, r.Old.File)))
+			}
+		}
+	}
+	if len(d.AddedCalls) > 0 {
+		fmt.Printf("\n%s (%s):\n", Bold("Added calls"), Info(len(d.AddedCalls)))
+		for _, e := range d.AddedCalls {
+			fmt.Printf("  %s %s → %s\n", Success("+"), Symbol(e.Caller.Name), Symbol(e.Callee.Name))
+		}
+	}
+	if len(d.RemovedCalls) > 0 {
+		fmt.Printf("\n%s (%s):\n", Bold("Removed calls"), Info(len(d.RemovedCalls)))
+		for _, e := range d.RemovedCalls {
+			fmt.Printf("  %s %s → %s\n", Error("-"), Symbol(e.Caller.Name), Symbol(e.Callee.Name))
+		}
+	}
+	return nil
+}
+
+func runDiffJSON(cmd *cobra.Command, from, to string) error {
+	out := cmd.OutOrStdout()
+	query := from + ".." + to
+	emitErr := func(code string, err error) error {
+		_ = EmitJSON(out, "diff", &query, []diffRecord{}, []EnvelopeError{{Code: code, Message: err.Error()}})
+		return err
+	}
+
+	cwd, _, dbManager, code, err := openProject(true)
+	if err != nil {
+		return emitErr(code, err)
+	}
+	defer dbManager.Close()
+
+	d, err := compareSnapshots(cwd, dbManager, from, to)
+	if err != nil {
+		return emitErr("diff_failed", err)
+	}
+
+	records := []diffRecord{}
+	symbolRecord := func(change string, s db.Symbol) diffRecord {
+		return diffRecord{Change: change, Name: s.Name, Kind: s.Kind, File: relativePath(cwd, s.File), Line: s.Line}
+	}
+	for _, s := range d.Added {
+		records = append(records, symbolRecord("added", s))
+	}
+	for _, s := range d.Removed {
+		records = append(records, symbolRecord("removed", s))
+	}
+	for _, r := range d.Renamed {
+		rec := symbolRecord("renamed", r.New)
+		rec.OldName, rec.OldFile, rec.OldLine = r.Old.Name, relativePath(cwd, r.Old.File), r.Old.Line
+		records = append(records, rec)
+	}
+	for _, e := range d.AddedCalls {
+		records = append(records, diffRecord{Change: "call_added", Caller: e.Caller.Name, Callee: e.Callee.Name})
+	}
+	for _, e := range d.RemovedCalls {
+		records = append(records, diffRecord{Change: "call_removed", Caller: e.Caller.Name, Callee: e.Callee.Name})
+	}
+	return EmitJSON(out, "diff", &query, records, nil)
+}
@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tk-425/Codegraph/internal/db"
+	"github.com/tk-425/Codegraph/internal/indexer"
+)
+
+var (
+	entrypointsTypeFlag  string
+	entrypointsLangFlag  string
+	entrypointsKindFlag  string
+	entrypointsPageFlags pageFlags
+	entrypointsFormat    formatFlag
+)
+
+var entrypointsCmd = &cobra.Command{
+	Use:   "entrypoints",
+	Short: "List the entry points found during indexing",
+	Long: `List the symbols 'codegraph build' tagged as entry points, the root set
+for dead-code and impact analyses:
+
+  main  program entry: func main, static void main, a function called
+        under if __name__ == "__main__"
+  http  HTTP route handler: registered with HandleFunc, app.get(...),
+        path(...) or .route(...), or decorated with @app.route,
+        @GetMapping, #[get(...)], [HttpGet]
+  cli   CLI command function: a cobra Run/RunE or urfave Action field,
+        @click.command, set_defaults(func=...), .action(...)
+  api   exported library API: exported Go identifiers outside main and
+        internal/ packages, pub Rust items, exported TS/JS declarations,
+        Python __all__, public Java/C# declarations
+
+A symbol matching several types is listed under the first. Test files are
+never entry points.
+
+Examples:
+  codegraph entrypoints
+  codegraph entrypoints --type=main,http,cli
+  codegraph entrypoints --type=api --lang=go --kind=function
+  codegraph entrypoints --format=vimgrep`,
+	Args: cobra.NoArgs,
+	RunE: runEntrypoints,
+}
+
+func init() {
+	entrypointsCmd.Flags().StringVar(&entrypointsTypeFlag, "type", "", "Filter by entry point type(s), comma-separated: "+strings.Join(indexer.EntrypointTypes, ", "))
+	entrypointsCmd.Flags().StringVar(&entrypointsLangFlag, "lang", "", "Filter by language(s), comma-separated")
+	entrypointsCmd.Flags().StringVar(&entrypointsKindFlag, "kind", "", kindFlagUsage)
+	entrypointsPageFlags.register(entrypointsCmd, 0)
+	entrypointsFormat.register(entrypointsCmd)
+	rootCmd.AddCommand(entrypointsCmd)
+}
+
+type entrypointRecord struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+// entrypointsQuery validates --type and builds the symbol filter
+func entrypointsQuery() ([]string, db.QueryOptions, error) {
+	types := parseListFlag(entrypointsTypeFlag)
+	for _, t := range types {
+		if !slices.Contains(indexer.EntrypointTypes, t) {
+			return nil, db.QueryOptions{}, fmt.Errorf("invalid --type %q (expected %s)", t, strings.Join(indexer.EntrypointTypes, ", "))
+		}
+	}
+	opts := queryOptions(entrypointsLangFlag, entrypointsKindFlag)
+	err := entrypointsPageFlags.apply(&opts)
+	return types, opts, err
+}
+
+func runEntrypoints(cmd *cobra.Command, args []string) error {
+	vimgrep, err := entrypointsFormat.vimgrep()
+	if err != nil {
+		return err
+	}
+	if jsonOutputFlag {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		return runEntrypointsJSON(cmd)
+	}
+
+	types, opts, err := entrypointsQuery()
+	if err != nil {
+		return err
+	}
+	cwd, _, dbManager, _, err := openProject(true)
+	if err != nil {
+		return err
+	}
+	defer dbManager.Close()
+
+	entrypoints, err := dbManager.ListEntrypoints(types, opts)
+	if err != nil {
+		return fmt.Errorf("failed to list entry points: %w", err)
+	}
+
+	if vimgrep {
+		for _, e := range entrypoints {
+			writeVimgrep(cmd.OutOrStdout(), relativePath(cwd, e.File), e.Line, e.Column+1,
+				fmt.Sprintf("%s entry point %s [%s]", e.Type, e.Name, e.Kind))
+		}
+		return nil
+	}
+
+	if len(entrypoints) == 0 {
+		fmt.Println("🚪 No entry points found (run 'codegraph build' to detect them)")
+		return noResults(cmd)
+	}
+
+	fmt.Printf("🚪 Entry points (%s found):\n", Info(len(entrypoints)))
+	for _, t := range indexer.EntrypointTypes {
+		var group []db.Entrypoint
+		for _, e := range entrypoints {
+			if e.Type == t {
+				group = append(group, e)
+			}
+		}
+		if len(group) == 0 {
+			continue
+		}
+		fmt.Printf("\n%s (%d)\n", Bold(t), len(group))
+		for _, e := range group {
+			fmt.Printf("  %s [%s] %s\n", Symbol(e.Name), Keyword(e.Kind), Path(fmt.Sprintf("%s:%d", relativePath(cwd, e.File), e.Line)))
+		}
+	}
+	return nil
+}
+
+func runEntrypointsJSON(cmd *cobra.Command) error {
+	out := cmd.OutOrStdout()
+	emitErr := func(code string, err error) error {
+		_ = EmitJSON(out, "entrypoints", nil, []entrypointRecord{}, []EnvelopeError{{Code: code, Message: err.Error()}})
+		return err
+	}
+
+	types, opts, err := entrypointsQuery()
+	if err != nil {
+		return emitErr("invalid_flag", err)
+	}
+	cwd, _, dbManager, code, err := openProject(true)
+	if err != nil {
+		return emitErr(code, err)
+	}
+	defer dbManager.Close()
+
+	entrypoints, err := dbManager.ListEntrypoints(types, opts)
+	if err != nil {
+		return emitErr("entrypoints_failed", fmt.Errorf("failed to list entry points: %w", err))
+	}
+
+	records := make([]entrypointRecord, 0, len(entrypoints))
+	for _, e := range entrypoints {
+		records = append(records, entrypointRecord{
+			Type: e.Type,
+			ID:   e.ID,
+			Name: e.Name,
+			Kind: e.Kind,
+			File: relativePath(cwd, e.File),
+			Line: e.Line,
+		})
+	}
+	return emitResults(cmd, "entrypoints", nil, records)
+}
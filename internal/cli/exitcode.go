@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+// Exit codes, so scripts and agents can branch on a command's outcome
+// without parsing its output
+const (
+	ExitOK             = 0 // Success; a query command found results
+	ExitError          = 1 // Any other failure, including invalid flags
+	ExitNoResults      = 2 // A query command ran but found nothing
+	ExitStale          = 3 // The index is missing, outdated or incomplete; run 'codegraph build'
+	ExitNotInitialized = 4 // No .codegraph directory; run 'codegraph init'
+)
+
+// exitCodesHelp documents the exit codes in the root command's help
+const exitCodesHelp = `Exit codes:
+  0  success; query commands found results
+  1  error
+  2  a query command found no results
+  3  the index is missing, was built by an older codegraph, or a build was
+     stopped before it finished; run 'codegraph build'
+  4  codegraph is not initialized; run 'codegraph init'`
+
+// exitError makes Execute exit with code when it returns err
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+
+func (e *exitError) Unwrap() error { return e.err }
+
+var (
+	errNotInitialized = &exitError{code: ExitNotInitialized, err: errors.New("codegraph not initialized. Run 'codegraph init' first")}
+	errIndexMissing   = &exitError{code: ExitStale, err: errors.New("database not found. Run 'codegraph build' first")}
+	// errNoResults is returned by query commands that found nothing, after
+	// saying so; see noResults
+	errNoResults = &exitError{code: ExitNoResults, err: errors.New("no results")}
+)
+
+// noResults returns errNoResults for cmd, which has already told the user
+// it found nothing, so cobra must print neither the error nor the usage
+func noResults(cmd *cobra.Command) error {
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	return errNoResults
+}
+
+// staleIndex marks err as leaving the index incomplete
+func staleIndex(err error) error {
+	return &exitError{code: ExitStale, err: err}
+}
+
+// ExitCode returns the process exit code for an error returned by Execute
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var exit *exitError
+	if errors.As(err, &exit) {
+		return exit.code
+	}
+	if errors.Is(err, db.ErrOutdatedIndex) {
+		return ExitStale
+	}
+	return ExitError
+}
+
+// emitResults writes a query command's envelope like EmitJSON, returning
+// errNoResults when there are no results
+func emitResults[T any](cmd *cobra.Command, command string, query *string, results []T) error {
+	if err := EmitJSON(cmd.OutOrStdout(), command, query, results, nil); err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		return noResults(cmd)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+func TestExitCode(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want int
+	}{
+		{nil, ExitOK},
+		{errors.New("boom"), ExitError},
+		{errNoResults, ExitNoResults},
+		{fmt.Errorf("wrapped: %w", errNotInitialized), ExitNotInitialized},
+		{errIndexMissing, ExitStale},
+		{staleIndex(errors.New("build interrupted")), ExitStale},
+		{fmt.Errorf("failed to open database: %w", db.ErrOutdatedIndex), ExitStale},
+	} {
+		if got := ExitCode(tc.err); got != tc.want {
+			t.Errorf("ExitCode(%v) = %d, want %d", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestExitCode_QueryCommands(t *testing.T) {
+	_, m := setupCodegraphProject(t)
+	seedSymbol(t, m, db.Symbol{ID: "a.go#parse", Name: "parse", Kind: "function", File: "a.go", Line: 3, Language: "go"})
+	seedSymbol(t, m, db.Symbol{ID: "a.go#main", Name: "main", Kind: "function", File: "a.go", Line: 7, Language: "go"})
+	if err := m.InsertCall(&db.Call{CallerID: "a.go#main", CalleeID: "a.go#parse", File: "a.go", Line: 8}); err != nil {
+		t.Fatalf("InsertCall: %v", err)
+	}
+
+	c, _ := freshCmd(t, "callers", runCallers)
+	if err := c.RunE(c, []string{"parse"}); ExitCode(err) != ExitOK {
+		t.Errorf("callers parse: %v, want results", err)
+	}
+	c, _ = freshCmd(t, "callers", runCallers)
+	if err := c.RunE(c, []string{"main"}); ExitCode(err) != ExitNoResults {
+		t.Errorf("callers main: %v, want no results", err)
+	}
+
+	// A batch exits with no results only when no symbol had any
+	t.Cleanup(func() { callersStdinFlag = false })
+	callersStdinFlag = true
+	for input, want := range map[string]int{"main\nparse\n": ExitOK, "main\nmissing\n": ExitNoResults} {
+		c, buf := freshCmdNoArgs(t, "callers", runCallers)
+		c.SetIn(strings.NewReader(input))
+		if err := c.RunE(c, nil); ExitCode(err) != want {
+			t.Errorf("callers --stdin %q: exit %d (%v), want %d", input, ExitCode(err), err, want)
+		}
+		decodeEnvelope(t, buf.Bytes())
+	}
+	callersStdinFlag = false
+
+	// Text output exits the same way
+	jsonOutputFlag = false
+	c, _ = freshCmd(t, "search", runSearch)
+	c.SetOut(io.Discard)
+	captureStdout(t, func() {
+		if err := c.RunE(c, []string{"nothingLikeThis"}); ExitCode(err) != ExitNoResults {
+			t.Errorf("search: %v, want no results", err)
+		}
+	})
+}
+
+func TestExitCode_NotInitialized(t *testing.T) {
+	t.Chdir(t.TempDir())
+	c, _ := freshCmd(t, "callers", runCallers)
+	if err := c.RunE(c, []string{"parse"}); ExitCode(err) != ExitNotInitialized {
+		t.Errorf("callers outside a project: %v, want not initialized", err)
+	}
+}
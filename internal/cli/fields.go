@@ -0,0 +1,157 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+var (
+	fieldsLangFlag string
+	fieldsKindFlag string
+)
+
+// fieldOwnerKinds are the symbol kinds that can declare members
+var fieldOwnerKinds = []string{"class", "struct", "interface", "enum", "type"}
+
+var fieldsCmd = &cobra.Command{
+	Use:     "fields <type>",
+	Aliases: []string{"members"},
+	Short:   "List the fields, properties and enum members of a type",
+	Long: `List the data members declared by a type: struct and class fields,
+properties and enum members, with their declared types and locations.
+
+Use --kind to choose other members: --kind=method lists the type's methods,
+--kind=class its nested classes.
+
+Examples:
+  codegraph fields Config
+  codegraph fields Color --kind=enum_member
+  codegraph members Server --kind=method
+  codegraph fields User --lang=go --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runFields,
+}
+
+func init() {
+	fieldsCmd.Flags().StringVar(&fieldsLangFlag, "lang", "", "Filter by language(s), comma-separated")
+	fieldsCmd.Flags().StringVar(&fieldsKindFlag, "kind", "", "Filter members by kind(s), comma-separated; prefix with ! to exclude")
+	rootCmd.AddCommand(fieldsCmd)
+}
+
+type fieldRecord struct {
+	Owner    string `json:"owner"`
+	Name     string `json:"name"`
+	Kind     string `json:"kind"`
+	Type     string `json:"type"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Language string `json:"language"`
+}
+
+func runFields(cmd *cobra.Command, args []string) error {
+	typeName := args[0]
+	if jsonOutputFlag {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		return runFieldsJSON(cmd, typeName)
+	}
+
+	cwd, _, dbManager, _, err := openProject(false)
+	if err != nil {
+		return err
+	}
+	defer dbManager.Close()
+
+	owners, err := findFieldOwners(dbManager, typeName)
+	if err != nil {
+		return err
+	}
+	if len(owners) == 0 {
+		fmt.Printf("🧱 No type named '%s' found\n", Warning(typeName))
+		return noResults(cmd)
+	}
+
+	fmt.Printf("🧱 Fields of %s (%s found):\n\n", Symbol(typeName), Info(len(owners)))
+	for _, owner := range owners {
+		members, err := dbManager.GetMembers(owner, queryOptions(fieldsLangFlag, fieldsKindFlag))
+		if err != nil {
+			return fmt.Errorf("failed to list members: %w", err)
+		}
+
+		fmt.Printf("  %s [%s]\n", Symbol(owner.Name), Keyword(owner.Kind))
+		fmt.Printf("    %s\n", Path(fmt.Sprintf("%s:%d", relativePath(cwd, owner.File), owner.Line)))
+		if len(members) == 0 {
+			fmt.Printf("    %s\n\n", Dim("(no fields indexed)"))
+			continue
+		}
+
+		width := 0
+		for _, m := range members {
+			width = max(width, len(m.Name))
+		}
+		for _, m := range members {
+			fmt.Printf("    %s  %s [%s] %s\n",
+				Symbol(fmt.Sprintf("%-*s", width, m.Name)),
+				Type(strings.TrimSpace(m.Signature)),
+				Keyword(m.Kind),
+				Dim(fmt.Sprintf(":%d", m.Line)))
+		}
+		fmt.Println()
+	}
+
+	return nil
+}
+
+func runFieldsJSON(cmd *cobra.Command, typeName string) error {
+	out := cmd.OutOrStdout()
+	emitErr := func(code string, err error) error {
+		_ = EmitJSON(out, "fields", &typeName, []fieldRecord{}, []EnvelopeError{{Code: code, Message: err.Error()}})
+		return err
+	}
+
+	cwd, _, dbManager, code, err := openProject(false)
+	if err != nil {
+		return emitErr(code, err)
+	}
+	defer dbManager.Close()
+
+	owners, err := findFieldOwners(dbManager, typeName)
+	if err != nil {
+		return emitErr("fields_lookup_failed", err)
+	}
+
+	records := make([]fieldRecord, 0)
+	for _, owner := range owners {
+		members, err := dbManager.GetMembers(owner, queryOptions(fieldsLangFlag, fieldsKindFlag))
+		if err != nil {
+			return emitErr("fields_lookup_failed", fmt.Errorf("failed to list members: %w", err))
+		}
+		for _, m := range members {
+			records = append(records, fieldRecord{
+				Owner:    owner.Name,
+				Name:     m.Name,
+				Kind:     m.Kind,
+				Type:     strings.TrimSpace(m.Signature),
+				File:     relativePath(cwd, m.File),
+				Line:     m.Line,
+				Language: m.Language,
+			})
+		}
+	}
+
+	return emitResults(cmd, "fields", &typeName, records)
+}
+
+// findFieldOwners returns the type declarations named typeName, honouring
+// --lang
+func findFieldOwners(dbManager *db.Manager, typeName string) ([]db.Symbol, error) {
+	opts := db.QueryOptions{Languages: parseListFlag(fieldsLangFlag), Kinds: fieldOwnerKinds}
+	owners, err := dbManager.FindSymbolsByName(typeName, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find type: %w", err)
+	}
+	return owners, nil
+}
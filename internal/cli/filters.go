@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+// kindFlagUsage is the shared help text for --kind on query commands.
+const kindFlagUsage = "Filter by symbol kind(s), comma-separated; prefix with ! to exclude (e.g., method or !constructor)"
+
+// tagFlagUsage is the shared help text for --tag on query commands.
+const tagFlagUsage = "Only symbols with one of these annotation tags, comma-separated (e.g., deprecated, route, test or app.route)"
+
+// parseListFlag splits a comma-separated flag value, dropping blank entries.
+func parseListFlag(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseKindFilter splits a --kind value into kinds to keep and kinds to drop.
+// A leading "!" excludes a kind: --kind=method,function or --kind='!constructor'.
+func parseKindFilter(value string) (include, exclude []string) {
+	for _, kind := range parseListFlag(value) {
+		if strings.HasPrefix(kind, "!") {
+			if kind = strings.TrimPrefix(kind, "!"); kind != "" {
+				exclude = append(exclude, kind)
+			}
+			continue
+		}
+		include = append(include, kind)
+	}
+	return include, exclude
+}
+
+// queryOptions builds the database filter from --lang and --kind flag
+// values, the test-file flags, --owner and --package.
+func queryOptions(langFlag, kindFlag string) db.QueryOptions {
+	kinds, excludeKinds := parseKindFilter(kindFlag)
+	return db.QueryOptions{
+		Languages:    parseListFlag(langFlag),
+		Kinds:        kinds,
+		ExcludeKinds: excludeKinds,
+		Tests:        testFilter(),
+		Owner:        ownerFlag,
+		Package:      packageFlag,
+	}
+}
+
+// testFilter maps --include-tests and --only-tests to a test-symbol
+// filter; without them, symbols from test files are left out.
+func testFilter() string {
+	switch {
+	case onlyTestsFlag:
+		return db.TestsOnly
+	case includeTestsFlag:
+		return db.TestsInclude
+	default:
+		return db.TestsExclude
+	}
+}
+
+// pageFlags holds the --offset, --limit and --sort values of a query command.
+type pageFlags struct {
+	offset int
+	limit  int
+	sort   string
+}
+
+// register adds the paging flags to cmd. defaultLimit 0 means unlimited.
+func (p *pageFlags) register(cmd *cobra.Command, defaultLimit int) {
+	cmd.Flags().IntVar(&p.offset, "offset", 0, "Skip the first N results")
+	cmd.Flags().IntVar(&p.limit, "limit", defaultLimit, "Max results to show (0 = unlimited)")
+	cmd.Flags().StringVar(&p.sort, "sort", "", "Sort results by name, file, line, score or churn")
+}
+
+// apply validates the flags and copies them into opts.
+func (p *pageFlags) apply(opts *db.QueryOptions) error {
+	if p.offset < 0 || p.limit < 0 {
+		return fmt.Errorf("--offset and --limit must not be negative")
+	}
+	if err := db.ValidateSort(p.sort); err != nil {
+		return err
+	}
+	opts.Offset = p.offset
+	opts.Limit = p.limit
+	opts.Sort = p.sort
+	return nil
+}
+
+// paginate applies offset and limit to results that did not come from SQL
+// (e.g. LSP fallbacks).
+func paginate[T any](items []T, offset, limit int) []T {
+	if offset >= len(items) {
+		return items[:0]
+	}
+	items = items[offset:]
+	if limit > 0 && len(items) > limit {
+		items = items[:limit]
+	}
+	return items
+}
@@ -0,0 +1,344 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+var (
+	flowsSourcesFlag string
+	flowsSinksFlag   string
+	flowsDepthFlag   int
+	flowsLimitFlag   int
+	flowsLangFlag    string
+)
+
+var flowsCmd = &cobra.Command{
+	Use:   "flows",
+	Short: "List call paths from source functions to sink functions",
+	Long: `Enumerate the call paths that connect source functions (e.g. readers of
+untrusted input) to sink functions (e.g. SQL execution): a lightweight,
+taint-style aid for security review built on the call graph.
+
+--sources and --sinks name files with one pattern per line; blank lines
+and lines starting with # are ignored. A pattern is a function name,
+optionally qualified by its type, class or package:
+
+  # sources.txt          # sinks.txt
+  Request.FormValue      sql.Exec
+  Request.ParseForm      os/exec.Command
+  read_user_input        execute
+
+A function is a source (or sink) when it is an indexed symbol matching a
+pattern, or when its body calls a function of that name; library functions
+such as sql.Exec are not indexed, so their callers stand in for them. In
+bodies the qualifier is not checked: any db.Exec(...) matches sql.Exec.
+
+For each source and sink connected by calls, the shortest path is shown.
+A function that is both a source and a sink is a path of its own.
+
+Examples:
+  codegraph flows --sources sources.txt --sinks sinks.txt
+  codegraph flows --sources sources.txt --sinks sinks.txt --depth=4 --lang=go
+  codegraph flows --sources sources.txt --sinks sinks.txt --json`,
+	Args: cobra.NoArgs,
+	RunE: runFlows,
+}
+
+func init() {
+	flowsCmd.Flags().StringVar(&flowsSourcesFlag, "sources", "", "File of source function patterns, one per line (required)")
+	flowsCmd.Flags().StringVar(&flowsSinksFlag, "sinks", "", "File of sink function patterns, one per line (required)")
+	flowsCmd.Flags().IntVar(&flowsDepthFlag, "depth", 0, "Max calls between a source and a sink (0 = unlimited)")
+	flowsCmd.Flags().IntVar(&flowsLimitFlag, "limit", 0, "Max flows to show (0 = unlimited)")
+	flowsCmd.Flags().StringVar(&flowsLangFlag, "lang", "", "Filter by language(s), comma-separated")
+	rootCmd.AddCommand(flowsCmd)
+}
+
+// flowPattern is a line of a sources or sinks file
+type flowPattern struct {
+	Raw       string
+	Qualifier string // Type, class or package; empty when unqualified
+	Name      string
+	call      *regexp.Regexp // A call to Name in a function body
+}
+
+// flowSite is where a function matched a pattern: its own declaration, or
+// the call in its body
+type flowSite struct {
+	Pattern string `json:"pattern"`
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+}
+
+// flow is a call path from a source function to a sink function
+type flow struct {
+	Path   []db.Symbol
+	Source flowSite
+	Sink   flowSite
+}
+
+type flowStep struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+type flowRecord struct {
+	Source flowSite   `json:"source"`
+	Sink   flowSite   `json:"sink"`
+	Path   []flowStep `json:"path"` // From the source function to the sink function
+}
+
+// loadFlowPatterns reads a sources or sinks file
+func loadFlowPatterns(flag, path string) ([]flowPattern, error) {
+	if path == "" {
+		return nil, fmt.Errorf("--%s is required", flag)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read --%s: %w", flag, err)
+	}
+	defer f.Close()
+
+	var patterns []flowPattern
+	scanner := bufio.NewScanner(f)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p := flowPattern{Raw: line, Name: line}
+		if i := strings.LastIndexAny(line, ".:"); i >= 0 {
+			p.Qualifier = strings.TrimRight(line[:i], ":")
+			p.Name = line[i+1:]
+			if j := strings.LastIndexAny(p.Qualifier, "./:"); j >= 0 {
+				p.Qualifier = p.Qualifier[j+1:]
+			}
+		}
+		if !identifierPattern.MatchString(p.Name) {
+			return nil, fmt.Errorf("%s:%d: %q is not a function name", path, lineNum, line)
+		}
+		p.call = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_$])` + regexp.QuoteMeta(p.Name) + `\s*\(`)
+		patterns = append(patterns, p)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("--%s file %s has no patterns", flag, path)
+	}
+	return patterns, nil
+}
+
+// matchesSymbol reports whether s is the function a pattern names
+func (p flowPattern) matchesSymbol(s db.Symbol) bool {
+	if s.Name != p.Name {
+		return false
+	}
+	if p.Qualifier == "" {
+		return true
+	}
+	scope := s.Scope
+	if i := strings.LastIndexAny(scope, ".:"); i >= 0 {
+		scope = scope[i+1:]
+	}
+	return scope == p.Qualifier || filepath.Base(filepath.Dir(s.File)) == p.Qualifier
+}
+
+// flowMatcher finds the functions matching a pattern set, reading each
+// source file once
+type flowMatcher struct {
+	lines map[string][]string
+}
+
+// match returns where fn matches one of patterns, or nil
+func (m *flowMatcher) match(fn db.Symbol, patterns []flowPattern) *flowSite {
+	for _, p := range patterns {
+		if p.matchesSymbol(fn) {
+			return &flowSite{Pattern: p.Raw, File: fn.File, Line: fn.Line}
+		}
+	}
+	if fn.EndLine == nil {
+		return nil
+	}
+	lines, ok := m.lines[fn.File]
+	if !ok {
+		if content, err := os.ReadFile(fn.File); err == nil {
+			lines = strings.Split(string(content), "\n")
+		}
+		m.lines[fn.File] = lines
+	}
+	// The declaration line is skipped so a function is not its own call
+	for line := fn.Line + 1; line <= *fn.EndLine && line <= len(lines); line++ {
+		for _, p := range patterns {
+			if p.call.MatchString(lines[line-1]) {
+				return &flowSite{Pattern: p.Raw, File: fn.File, Line: line}
+			}
+		}
+	}
+	return nil
+}
+
+// findFlows returns the shortest call path from each source function to
+// each sink function it reaches within depth calls (0 = unlimited)
+func findFlows(dbManager *db.Manager, sources, sinks []flowPattern, opts db.QueryOptions, depth int) ([]flow, error) {
+	opts.Kinds = callableKinds
+	functions, err := dbManager.ListSymbols(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list functions: %w", err)
+	}
+	calls, err := dbManager.ListCalls()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list calls: %w", err)
+	}
+	callees := make(map[string][]string)
+	for _, c := range calls {
+		callees[c.CallerID] = append(callees[c.CallerID], c.CalleeID)
+	}
+
+	matcher := &flowMatcher{lines: make(map[string][]string)}
+	byID := make(map[string]db.Symbol, len(functions))
+	sinkSites := make(map[string]flowSite)
+	var sourceIDs []string
+	sourceSites := make(map[string]flowSite)
+	for _, fn := range functions {
+		byID[fn.ID] = fn
+		if site := matcher.match(fn, sources); site != nil {
+			sourceIDs = append(sourceIDs, fn.ID)
+			sourceSites[fn.ID] = *site
+		}
+		if site := matcher.match(fn, sinks); site != nil {
+			sinkSites[fn.ID] = *site
+		}
+	}
+
+	var flows []flow
+	for _, source := range sourceIDs {
+		// Breadth-first, so the first path found to a sink is the shortest
+		parent := map[string]string{source: ""}
+		frontier := []string{source}
+		for level := 0; len(frontier) > 0; level++ {
+			for _, id := range frontier {
+				if site, ok := sinkSites[id]; ok {
+					var path []db.Symbol
+					for step := id; step != ""; step = parent[step] {
+						path = append([]db.Symbol{byID[step]}, path...)
+					}
+					flows = append(flows, flow{Path: path, Source: sourceSites[source], Sink: site})
+				}
+			}
+			if depth > 0 && level >= depth {
+				break
+			}
+			var next []string
+			for _, id := range frontier {
+				for _, callee := range callees[id] {
+					if _, seen := parent[callee]; seen {
+						continue
+					}
+					if _, ok := byID[callee]; !ok {
+						continue // Filtered out by --lang or a test
+					}
+					parent[callee] = id
+					next = append(next, callee)
+				}
+			}
+			frontier = next
+		}
+	}
+	return flows, nil
+}
+
+func runFlows(cmd *cobra.Command, args []string) error {
+	if jsonOutputFlag {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		return runFlowsJSON(cmd)
+	}
+
+	sources, err := loadFlowPatterns("sources", flowsSourcesFlag)
+	if err != nil {
+		return err
+	}
+	sinks, err := loadFlowPatterns("sinks", flowsSinksFlag)
+	if err != nil {
+		return err
+	}
+	cwd, _, dbManager, _, err := openProject(true)
+	if err != nil {
+		return err
+	}
+	defer dbManager.Close()
+
+	flows, err := findFlows(dbManager, sources, sinks, queryOptions(flowsLangFlag, ""), flowsDepthFlag)
+	if err != nil {
+		return err
+	}
+	if len(flows) == 0 {
+		fmt.Println("🌊 No call paths from sources to sinks")
+		return nil
+	}
+
+	fmt.Printf("🌊 Flows from sources to sinks (%s found):\n", Info(len(flows)))
+	for _, f := range paginate(flows, 0, flowsLimitFlag) {
+		names := make([]string, len(f.Path))
+		for i, s := range f.Path {
+			names[i] = Symbol(s.Name)
+		}
+		fmt.Printf("\n  %s\n", strings.Join(names, Dim(" → ")))
+		fmt.Printf("    %s %s %s\n", Success("source"), Keyword(f.Source.Pattern), Path(fmt.Sprintf("%s:%d", relativePath(cwd, f.Source.File), f.Source.Line)))
+		fmt.Printf("    %s   %s %s\n", Warning("sink"), Keyword(f.Sink.Pattern), Path(fmt.Sprintf("%s:%d", relativePath(cwd, f.Sink.File), f.Sink.Line)))
+	}
+	if flowsLimitFlag > 0 && len(flows) > flowsLimitFlag {
+		fmt.Printf("\n  %s\n", Dim(fmt.Sprintf("... %d more (raise --limit)", len(flows)-flowsLimitFlag)))
+	}
+	return nil
+}
+
+func runFlowsJSON(cmd *cobra.Command) error {
+	out := cmd.OutOrStdout()
+	emitErr := func(code string, err error) error {
+		_ = EmitJSON(out, "flows", nil, []flowRecord{}, []EnvelopeError{{Code: code, Message: err.Error()}})
+		return err
+	}
+
+	sources, err := loadFlowPatterns("sources", flowsSourcesFlag)
+	if err != nil {
+		return emitErr("invalid_flag", err)
+	}
+	sinks, err := loadFlowPatterns("sinks", flowsSinksFlag)
+	if err != nil {
+		return emitErr("invalid_flag", err)
+	}
+	cwd, _, dbManager, code, err := openProject(true)
+	if err != nil {
+		return emitErr(code, err)
+	}
+	defer dbManager.Close()
+
+	flows, err := findFlows(dbManager, sources, sinks, queryOptions(flowsLangFlag, ""), flowsDepthFlag)
+	if err != nil {
+		return emitErr("flows_failed", err)
+	}
+
+	flows = paginate(flows, 0, flowsLimitFlag)
+	records := make([]flowRecord, 0, len(flows))
+	for _, f := range flows {
+		record := flowRecord{Source: f.Source, Sink: f.Sink, Path: make([]flowStep, 0, len(f.Path))}
+		record.Source.File = relativePath(cwd, f.Source.File)
+		record.Sink.File = relativePath(cwd, f.Sink.File)
+		for _, s := range f.Path {
+			record.Path = append(record.Path, flowStep{Name: s.Name, Kind: s.Kind, File: relativePath(cwd, s.File), Line: s.Line})
+		}
+		records = append(records, record)
+	}
+	return EmitJSON(out, "flows", nil, records, nil)
+}
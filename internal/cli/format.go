@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Output formats accepted by --format
+const (
+	formatText    = "text"
+	formatVimgrep = "vimgrep"
+	formatCSV     = "csv"
+)
+
+// formatFlag holds the --format value of a location-listing command
+type formatFlag struct {
+	value string
+}
+
+// register adds --format to cmd
+func (f *formatFlag) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.value, "format", formatText,
+		"Output format: text, or vimgrep (file:line:col: message) for quickfix lists and editor tasks")
+}
+
+// vimgrep validates the flag and reports whether vimgrep output is wanted
+func (f *formatFlag) vimgrep() (bool, error) {
+	switch f.value {
+	case formatText, "":
+		return false, nil
+	case formatVimgrep:
+		if jsonOutputFlag {
+			return false, fmt.Errorf("--format=vimgrep cannot be combined with --json")
+		}
+		return true, nil
+	default:
+		return false, fmt.Errorf("invalid --format %q (expected text or vimgrep)", f.value)
+	}
+}
+
+// writeVimgrep writes one "file:line:col: message" line, the format read
+// by Vim's quickfix list (:cexpr, 'grepformat'), VS Code problem matchers,
+// and Emacs compilation mode. col is 1-indexed; lines without a known
+// column use 1.
+func writeVimgrep(w io.Writer, file string, line, col int, message string) {
+	if col < 1 {
+		col = 1
+	}
+	message = strings.Join(strings.Fields(message), " ")
+	fmt.Fprintf(w, "%s:%d:%d: %s\n", file, line, col, message)
+}
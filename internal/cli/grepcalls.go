@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/spf13/cobra"
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+var (
+	grepCallsLangFlag  string
+	grepCallsKindFlag  string
+	grepCallsGlobFlag  bool
+	grepCallsPageFlags pageFlags
+	grepCallsFormat    formatFlag
+)
+
+var grepCallsCmd = &cobra.Command{
+	Use:   "grep-calls <pattern>",
+	Short: "List every call site of functions whose name matches a pattern",
+	Long: `Search the call graph by callee name and print every call site, grouped
+by file, with its source line. Unlike a text search, only actual
+invocations match: comments, strings and definitions never do.
+
+<pattern> is a regular expression matched anywhere in the callee name
+(anchor it with ^ and $), or a shell glob over the whole name with --glob.
+--lang and --kind filter on the calling symbol.
+
+Examples:
+  codegraph grep-calls '^Must'
+  codegraph grep-calls 'Exec|Query' --lang=go
+  codegraph grep-calls 'log*' --glob
+  codegraph grep-calls '^(Printf|Println)$' --format=vimgrep`,
+	Args: cobra.ExactArgs(1),
+	RunE: runGrepCalls,
+}
+
+func init() {
+	grepCallsCmd.Flags().StringVar(&grepCallsLangFlag, "lang", "", "Filter by the caller's language(s), comma-separated")
+	grepCallsCmd.Flags().StringVar(&grepCallsKindFlag, "kind", "", kindFlagUsage)
+	grepCallsCmd.Flags().BoolVar(&grepCallsGlobFlag, "glob", false, "Treat the pattern as a shell glob over the whole callee name")
+	grepCallsPageFlags.register(grepCallsCmd, 0)
+	grepCallsFormat.register(grepCallsCmd)
+	rootCmd.AddCommand(grepCallsCmd)
+}
+
+type grepCallRecord struct {
+	Callee string `json:"callee"`
+	Caller string `json:"caller"`
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"` // 1-indexed
+	Text   string `json:"text"`   // Source line
+}
+
+// grepCallsOptions validates the pattern and builds the caller filter
+func grepCallsOptions(pattern string) (db.QueryOptions, error) {
+	opts := queryOptions(grepCallsLangFlag, grepCallsKindFlag)
+	if !grepCallsGlobFlag {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return opts, fmt.Errorf("invalid pattern: %w", err)
+		}
+	}
+	err := grepCallsPageFlags.apply(&opts)
+	return opts, err
+}
+
+func runGrepCalls(cmd *cobra.Command, args []string) error {
+	pattern := args[0]
+	vimgrep, err := grepCallsFormat.vimgrep()
+	if err != nil {
+		return err
+	}
+	if jsonOutputFlag {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		return runGrepCallsJSON(cmd, pattern)
+	}
+
+	opts, err := grepCallsOptions(pattern)
+	if err != nil {
+		return err
+	}
+	cwd, _, dbManager, _, err := openProject(true)
+	if err != nil {
+		return err
+	}
+	defer dbManager.Close()
+
+	sites, err := dbManager.GrepCalls(pattern, grepCallsGlobFlag, opts)
+	if err != nil {
+		return fmt.Errorf("failed to search calls: %w", err)
+	}
+
+	if vimgrep {
+		for _, s := range sites {
+			writeVimgrep(cmd.OutOrStdout(), relativePath(cwd, s.File), s.Line, s.Column+1,
+				fmt.Sprintf("%s calls %s: %s", s.Caller.Name, s.Callee.Name, getSourceLine(s.File, s.Line)))
+		}
+		return nil
+	}
+
+	if len(sites) == 0 {
+		fmt.Printf("📞 No calls matching: %s\n", Warning(pattern))
+		return noResults(cmd)
+	}
+
+	files := 0
+	currentFile := ""
+	for _, s := range sites {
+		if s.File != currentFile {
+			if currentFile != "" {
+				fmt.Println()
+			}
+			currentFile = s.File
+			files++
+			fmt.Println(Path(relativePath(cwd, s.File)))
+		}
+		fmt.Printf("  %s %s %s\n", Dim(fmt.Sprintf("%5d:%-3d", s.Line, s.Column+1)),
+			getSourceLine(s.File, s.Line), Dim("("+s.Caller.Name+" → "+s.Callee.Name+")"))
+	}
+	fmt.Printf("\n📞 %s call sites in %s files\n", Info(len(sites)), Info(files))
+	return nil
+}
+
+func runGrepCallsJSON(cmd *cobra.Command, pattern string) error {
+	out := cmd.OutOrStdout()
+	emitErr := func(code string, err error) error {
+		_ = EmitJSON(out, "grep-calls", &pattern, []grepCallRecord{}, []EnvelopeError{{Code: code, Message: err.Error()}})
+		return err
+	}
+
+	opts, err := grepCallsOptions(pattern)
+	if err != nil {
+		return emitErr("invalid_flag", err)
+	}
+	cwd, _, dbManager, code, err := openProject(true)
+	if err != nil {
+		return emitErr(code, err)
+	}
+	defer dbManager.Close()
+
+	sites, err := dbManager.GrepCalls(pattern, grepCallsGlobFlag, opts)
+	if err != nil {
+		return emitErr("grep_calls_failed", fmt.Errorf("failed to search calls: %w", err))
+	}
+
+	records := make([]grepCallRecord, 0, len(sites))
+	for _, s := range sites {
+		records = append(records, grepCallRecord{
+			Callee: s.Callee.Name,
+			Caller: s.Caller.Name,
+			File:   relativePath(cwd, s.File),
+			Line:   s.Line,
+			Column: s.Column + 1,
+			Text:   getSourceLine(s.File, s.Line),
+		})
+	}
+	return emitResults(cmd, "grep-calls", &pattern, records)
+}
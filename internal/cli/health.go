@@ -2,13 +2,14 @@ package cli
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/tk-425/Codegraph/internal/config"
-	"github.com/tk-425/Codegraph/internal/db"
 )
 
 var healthCmd = &cobra.Command{
@@ -70,7 +71,7 @@ func runHealth(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	dbManager, err := db.NewManager(dbPath)
+	dbManager, err := openDatabase(cfg, cwd)
 	if err != nil {
 		fmt.Printf("❌ %s: %v\n", Error("Database error"), err)
 		return nil
@@ -98,15 +99,19 @@ func runHealth(cmd *cobra.Command, args []string) error {
 	// Check LSP servers
 	fmt.Println()
 	fmt.Printf("🔧 %s\n", Bold("LSP Servers:"))
+	missing := false
 	for lang, lspCfg := range cfg.LSP {
-		// Check if command exists
-		_, err := exec.LookPath(lspCfg.Command)
-		if err != nil {
-			fmt.Printf("   ❌ %s: %s not found\n", Warning(lang), Error(lspCfg.Command))
+		detail, ok := lspServerStatus(lspCfg)
+		if !ok {
+			fmt.Printf("   ❌ %s: %s\n", Warning(lang), Error(detail))
+			missing = missing || !lspCfg.IsSocket() && lspCfg.Container == nil
 		} else {
-			fmt.Printf("   ✅ %s: %s\n", Keyword(lang), Dim(lspCfg.Command))
+			fmt.Printf("   ✅ %s: %s\n", Keyword(lang), Dim(detail))
 		}
 	}
+	if missing {
+		fmt.Printf("\n   Run %s to install missing servers\n", Keyword("codegraph install-lsp"))
+	}
 
 	return nil
 }
@@ -141,7 +146,7 @@ func runHealthJSON(cmd *cobra.Command) error {
 		return EmitJSON(out, "health", nil, records, nil)
 	}
 
-	dbManager, err := db.NewManager(dbPath)
+	dbManager, err := openDatabase(cfg, cwd)
 	if err != nil {
 		records = append(records, healthRecord{Category: "database", Name: "database", OK: false, Detail: err.Error()})
 		return EmitJSON(out, "health", nil, records, nil)
@@ -159,12 +164,35 @@ func runHealthJSON(cmd *cobra.Command) error {
 	records = append(records, healthRecord{Category: "stats", Name: "files", OK: true, Detail: fmt.Sprintf("%d", stats.FileCount)})
 
 	for lang, lspCfg := range cfg.LSP {
-		if _, err := exec.LookPath(lspCfg.Command); err != nil {
-			records = append(records, healthRecord{Category: "lsp", Name: lang, OK: false, Detail: lspCfg.Command + " not found"})
-		} else {
-			records = append(records, healthRecord{Category: "lsp", Name: lang, OK: true, Detail: lspCfg.Command})
-		}
+		detail, ok := lspServerStatus(lspCfg)
+		records = append(records, healthRecord{Category: "lsp", Name: lang, OK: ok, Detail: detail})
 	}
 
 	return EmitJSON(out, "health", nil, records, nil)
 }
+
+// lspServerStatus checks that a language server is available: its command
+// is on PATH, its container runtime is for a containerized server, or, for
+// a socket transport, something listens on its address
+func lspServerStatus(lspCfg config.LSPConfig) (string, bool) {
+	if lspCfg.Container != nil {
+		runtime := lspCfg.Container.RuntimeCommand()
+		if _, err := exec.LookPath(runtime); err != nil {
+			return runtime + " not found", false
+		}
+		return lspCfg.Command + " in " + lspCfg.Container.Image + " (" + runtime + ")", true
+	}
+	if lspCfg.IsSocket() {
+		detail := lspCfg.TransportMode() + "://" + lspCfg.Address
+		conn, err := net.DialTimeout(lspCfg.TransportMode(), lspCfg.Address, 2*time.Second)
+		if err != nil {
+			return detail + " unreachable", false
+		}
+		conn.Close()
+		return detail, true
+	}
+	if _, err := exec.LookPath(lspCfg.Command); err != nil {
+		return lspCfg.Command + " not found", false
+	}
+	return lspCfg.Command, true
+}
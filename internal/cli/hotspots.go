@@ -0,0 +1,305 @@
+package cli
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/tk-425/Codegraph/internal/db"
+	"github.com/tk-425/Codegraph/internal/history"
+)
+
+var (
+	hotspotsLangFlag   string
+	hotspotsKindFlag   string
+	hotspotsLimitFlag  int
+	hotspotsByFlag     string
+	hotspotsFormatFlag string
+)
+
+// defaultHotspotKinds are the kinds ranked when --kind is not given
+var defaultHotspotKinds = []string{"function", "method", "constructor"}
+
+// hotspotCandidates is how many functions per result get their own churn
+// read from git, taken from the ones in the most changed files
+const hotspotCandidates = 5
+
+var hotspotsCmd = &cobra.Command{
+	Use:   "hotspots",
+	Short: "Rank risky code by churn and call graph centrality",
+	Long: `Rank the functions that change often and that much of the code depends
+on: churn (commits that touched the function's lines) times centrality
+(distinct callers plus distinct callees).
+
+Churn is read from git log -L for the functions whose files changed the
+most (five per result) and stored like codegraph blame does, so reruns
+are fast until HEAD moves. --by=file ranks files instead: commits to the
+file times the centrality of its functions.
+
+--format=csv writes the ranking as CSV for spreadsheets and dashboards;
+--json wraps it in the usual envelope.
+
+Examples:
+  codegraph hotspots
+  codegraph hotspots --lang=go --limit=50
+  codegraph hotspots --by=file
+  codegraph hotspots --format=csv > hotspots.csv
+  codegraph hotspots --json`,
+	Args: cobra.NoArgs,
+	RunE: runHotspots,
+}
+
+func init() {
+	hotspotsCmd.Flags().StringVar(&hotspotsLangFlag, "lang", "", "Filter by language(s), comma-separated")
+	hotspotsCmd.Flags().StringVar(&hotspotsKindFlag, "kind", "", kindFlagUsage)
+	hotspotsCmd.Flags().IntVar(&hotspotsLimitFlag, "limit", 20, "Max results to show (0 = unlimited)")
+	hotspotsCmd.Flags().StringVar(&hotspotsByFlag, "by", "symbol", "Rank symbols or files")
+	hotspotsCmd.Flags().StringVar(&hotspotsFormatFlag, "format", formatText, "Output format: text or csv")
+	rootCmd.AddCommand(hotspotsCmd)
+}
+
+// hotspotRecord is one ranked symbol or, with --by=file, file; symbol
+// fields are empty for files
+type hotspotRecord struct {
+	Rank    int    `json:"rank"`
+	Name    string `json:"name,omitempty"`
+	Kind    string `json:"kind,omitempty"`
+	File    string `json:"file"`
+	Line    int    `json:"line,omitempty"`
+	Churn   int    `json:"churn"` // Commits
+	FanIn   int    `json:"fan_in"`
+	FanOut  int    `json:"fan_out"`
+	Score   int    `json:"score"` // Churn × (FanIn + FanOut)
+	Symbols int    `json:"symbols,omitempty"`
+}
+
+// hotspotOptions validates the flags and returns the symbol filter
+func hotspotOptions() (db.QueryOptions, error) {
+	if hotspotsLimitFlag < 0 {
+		return db.QueryOptions{}, fmt.Errorf("--limit must not be negative")
+	}
+	if hotspotsByFlag != "symbol" && hotspotsByFlag != "file" {
+		return db.QueryOptions{}, fmt.Errorf("invalid --by %q (expected symbol or file)", hotspotsByFlag)
+	}
+	switch hotspotsFormatFlag {
+	case formatText, "":
+	case formatCSV:
+		if jsonOutputFlag {
+			return db.QueryOptions{}, fmt.Errorf("--format=csv cannot be combined with --json")
+		}
+	default:
+		return db.QueryOptions{}, fmt.Errorf("invalid --format %q (expected text or csv)", hotspotsFormatFlag)
+	}
+	opts := queryOptions(hotspotsLangFlag, hotspotsKindFlag)
+	if len(opts.Kinds) == 0 {
+		opts.Kinds = defaultHotspotKinds
+	}
+	return opts, nil
+}
+
+// findHotspots ranks the symbols matching opts, or their files when byFile
+// is set, by churn × centrality. It returns the ranking and how many
+// symbols were considered.
+func findHotspots(ctx context.Context, dbManager *db.Manager, root string, opts db.QueryOptions, byFile bool, limit int) ([]hotspotRecord, int, error) {
+	symbols, err := dbManager.ListSymbols(opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list symbols: %w", err)
+	}
+	degrees, err := dbManager.GetCallDegrees()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count calls: %w", err)
+	}
+	fileChurn, err := history.FileChurn(ctx, root)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var records []hotspotRecord
+	if byFile {
+		byPath := make(map[string]*hotspotRecord)
+		for _, s := range symbols {
+			r := byPath[s.File]
+			if r == nil {
+				r = &hotspotRecord{File: s.File, Churn: fileChurn[s.File]}
+				byPath[s.File] = r
+			}
+			r.FanIn += degrees[s.ID].FanIn
+			r.FanOut += degrees[s.ID].FanOut
+			r.Symbols++
+		}
+		for _, r := range byPath {
+			records = append(records, *r)
+		}
+	} else {
+		// Files that changed the most narrow down whose lines are worth
+		// reading the history of
+		var candidates []hotspotRecord
+		byID := make(map[string]db.Symbol, len(symbols))
+		for _, s := range symbols {
+			d := degrees[s.ID]
+			if fileChurn[s.File] == 0 || d.FanIn+d.FanOut == 0 {
+				continue
+			}
+			byID[s.ID] = s
+			candidates = append(candidates, hotspotRecord{
+				Name: s.ID, File: s.File, Churn: fileChurn[s.File], FanIn: d.FanIn, FanOut: d.FanOut,
+			})
+		}
+		rankHotspots(candidates)
+		if limit > 0 && len(candidates) > limit*hotspotCandidates {
+			candidates = candidates[:limit*hotspotCandidates]
+		}
+
+		resolver, err := history.NewResolver(ctx, dbManager, root)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, c := range candidates {
+			if err := ctx.Err(); err != nil {
+				return nil, 0, err
+			}
+			s := byID[c.Name]
+			h, err := resolver.Symbol(ctx, s)
+			if err != nil {
+				continue // Not committed yet
+			}
+			records = append(records, hotspotRecord{
+				Name: s.Name, Kind: s.Kind, File: s.File, Line: s.Line,
+				Churn: h.Commits, FanIn: c.FanIn, FanOut: c.FanOut,
+			})
+		}
+	}
+
+	rankHotspots(records)
+	ranked := records[:0]
+	for _, r := range records {
+		if r.Score == 0 || (limit > 0 && len(ranked) == limit) {
+			break
+		}
+		r.Rank = len(ranked) + 1
+		ranked = append(ranked, r)
+	}
+	return ranked, len(symbols), nil
+}
+
+// rankHotspots scores records and sorts them by score, then churn, then
+// location
+func rankHotspots(records []hotspotRecord) {
+	for i := range records {
+		records[i].Score = records[i].Churn * (records[i].FanIn + records[i].FanOut)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		a, b := records[i], records[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Churn != b.Churn {
+			return a.Churn > b.Churn
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+}
+
+func runHotspots(cmd *cobra.Command, args []string) error {
+	if jsonOutputFlag {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		return runHotspotsJSON(cmd)
+	}
+	opts, err := hotspotOptions()
+	if err != nil {
+		return err
+	}
+
+	cwd, _, dbManager, _, err := openProject(true)
+	if err != nil {
+		return err
+	}
+	defer dbManager.Close()
+
+	byFile := hotspotsByFlag == "file"
+	records, total, err := findHotspots(context.Background(), dbManager, cwd, opts, byFile, hotspotsLimitFlag)
+	if err != nil {
+		return err
+	}
+	for i := range records {
+		records[i].File = relativePath(cwd, records[i].File)
+	}
+	if hotspotsFormatFlag == formatCSV {
+		return writeHotspotsCSV(cmd.OutOrStdout(), records, byFile)
+	}
+
+	if len(records) == 0 {
+		fmt.Printf("🔥 No hotspots: none of %d symbols both changed and has calls\n", total)
+		return nil
+	}
+	fmt.Printf("🔥 Hotspots (%s ranked by churn × (fan-in + fan-out)):\n\n", Info(len(records)))
+	for _, r := range records {
+		if byFile {
+			fmt.Printf("  %3d. %s %s\n", r.Rank, Path(r.File), Dim(fmt.Sprintf("(%d functions)", r.Symbols)))
+		} else {
+			fmt.Printf("  %3d. %s [%s] %s\n", r.Rank, Symbol(r.Name), Keyword(r.Kind), Path(fmt.Sprintf("%s:%d", r.File, r.Line)))
+		}
+		fmt.Printf("       score %s  %s\n", Warning(r.Score),
+			Dim(fmt.Sprintf("churn %d  fan-in %d  fan-out %d", r.Churn, r.FanIn, r.FanOut)))
+	}
+	return nil
+}
+
+func runHotspotsJSON(cmd *cobra.Command) error {
+	out := cmd.OutOrStdout()
+	emitErr := func(code string, err error) error {
+		_ = EmitJSON(out, "hotspots", nil, []hotspotRecord{}, []EnvelopeError{{Code: code, Message: err.Error()}})
+		return err
+	}
+
+	opts, err := hotspotOptions()
+	if err != nil {
+		return emitErr("invalid_flag", err)
+	}
+	cwd, _, dbManager, code, err := openProject(true)
+	if err != nil {
+		return emitErr(code, err)
+	}
+	defer dbManager.Close()
+
+	records, _, err := findHotspots(context.Background(), dbManager, cwd, opts, hotspotsByFlag == "file", hotspotsLimitFlag)
+	if err != nil {
+		return emitErr("hotspots_failed", err)
+	}
+	for i := range records {
+		records[i].File = relativePath(cwd, records[i].File)
+	}
+	return EmitJSON(out, "hotspots", nil, records, nil)
+}
+
+// writeHotspotsCSV writes records with a header row
+func writeHotspotsCSV(out io.Writer, records []hotspotRecord, byFile bool) error {
+	w := csv.NewWriter(out)
+	header := []string{"rank", "name", "kind", "file", "line", "churn", "fan_in", "fan_out", "score"}
+	if byFile {
+		header = []string{"rank", "file", "functions", "churn", "fan_in", "fan_out", "score"}
+	}
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	for _, r := range records {
+		itoa := strconv.Itoa
+		row := []string{itoa(r.Rank), r.Name, r.Kind, r.File, itoa(r.Line), itoa(r.Churn), itoa(r.FanIn), itoa(r.FanOut), itoa(r.Score)}
+		if byFile {
+			row = []string{itoa(r.Rank), r.File, itoa(r.Symbols), itoa(r.Churn), itoa(r.FanIn), itoa(r.FanOut), itoa(r.Score)}
+		}
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
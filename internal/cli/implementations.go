@@ -3,32 +3,53 @@ package cli
 import (
 	"context"
 	"fmt"
-	"os"
-	"path/filepath"
-	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/tk-425/Codegraph/internal/config"
+	"github.com/tk-425/Codegraph/internal/daemon"
 	"github.com/tk-425/Codegraph/internal/db"
 	"github.com/tk-425/Codegraph/internal/lsp"
 )
 
-var implementationsLangFlag string
+var (
+	implementationsLangFlag  string
+	implementationsKindFlag  string
+	implementationsPageFlags pageFlags
+	implementationsRefresh   bool
+	implementationsTimeout   time.Duration
+)
 
 var implementationsCmd = &cobra.Command{
 	Use:   "implementations <interface>",
 	Short: "Find implementations of an interface",
 	Long: `Find all types that implement the specified interface.
 
+Generic types are matched by their raw name: Repo in 'class Repo extends
+Base<User>' is an implementation of Base, shown with the type arguments it
+instantiates it with.
+
+Implementations come from the index. --refresh also asks the language
+servers (through 'codegraph daemon' when it runs) and caches what they
+find in the index, so later queries answer without starting a server.
+Run it again to pick up implementations added since. The servers get
+search.timeout_seconds from config.toml, or --timeout, to answer.
+
 Examples:
   codegraph implementations Reader
-  codegraph implementations Service --lang=go`,
+  codegraph implementations Service --lang=go
+  codegraph implementations Shape --kind=class
+  codegraph implementations Handler --refresh`,
 	Args: cobra.ExactArgs(1),
 	RunE: runImplementations,
 }
 
 func init() {
 	implementationsCmd.Flags().StringVar(&implementationsLangFlag, "lang", "", "Filter by language(s), comma-separated")
+	implementationsCmd.Flags().StringVar(&implementationsKindFlag, "kind", "", "Filter implementing symbols by kind(s), comma-separated; prefix with ! to exclude")
+	implementationsPageFlags.register(implementationsCmd, 0)
+	implementationsCmd.Flags().BoolVar(&implementationsRefresh, "refresh", false, "Ask the language servers for implementations and cache their answer in the index")
+	implementationsCmd.Flags().DurationVar(&implementationsTimeout, "timeout", 0, timeoutFlagUsage)
 	rootCmd.AddCommand(implementationsCmd)
 }
 
@@ -37,6 +58,8 @@ type implementationRecord struct {
 	Kind string `json:"kind"`
 	File string `json:"file"`
 	Line int    `json:"line"`
+	// TypeArgs instantiate the interface, "User" for Base<User>
+	TypeArgs string `json:"type_args,omitempty"`
 }
 
 func runImplementations(cmd *cobra.Command, args []string) error {
@@ -47,115 +70,63 @@ func runImplementations(cmd *cobra.Command, args []string) error {
 		return runImplementationsJSON(cmd, interfaceName)
 	}
 
-	// Get current directory
-	cwd, err := os.Getwd()
-	if err != nil {
-		return fmt.Errorf("failed to get current directory: %w", err)
-	}
-
-	// Check if codegraph is initialized
-	codegraphDir := filepath.Join(cwd, ".codegraph")
-	if _, err := os.Stat(codegraphDir); os.IsNotExist(err) {
-		return fmt.Errorf("codegraph not initialized. Run 'codegraph init' first")
-	}
-
-	// Load config
-	cfg, err := config.Load(cwd)
-	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
-	}
-
-	// Open database
-	dbPath := cfg.GetDatabasePath(cwd)
-	dbManager, err := db.NewManager(dbPath)
+	cwd, cfg, dbManager, _, err := openProject(false)
 	if err != nil {
-		return fmt.Errorf("failed to open database: %w", err)
+		return err
 	}
 	defer dbManager.Close()
 
-	// First, try to find implementations in the database (from type_hierarchy table)
-	dbImplementations, err := dbManager.GetImplementationsByName(interfaceName)
-	if err == nil && len(dbImplementations) > 0 {
-		fmt.Printf("🔧 Implementations of %s (%s found):\n\n", Symbol(interfaceName), Info(len(dbImplementations)))
-		for _, impl := range dbImplementations {
-			relPath, _ := filepath.Rel(cwd, impl.File)
-			fmt.Printf("  %s [%s]\n", Symbol(impl.Name), Keyword(impl.Kind))
-			fmt.Printf("    %s\n", Path(fmt.Sprintf("%s:%d", relPath, impl.Line)))
-			if line := getSourceLine(impl.File, impl.Line); line != "" {
-				fmt.Printf("    %s\n", Dim(line))
-			}
-			fmt.Println()
-		}
-		return nil
+	opts := queryOptions(implementationsLangFlag, implementationsKindFlag)
+	if err := implementationsPageFlags.apply(&opts); err != nil {
+		return err
 	}
 
-	// If no database results, try LSP as fallback
-	// Parse languages filter
-	var languages []string
-	if implementationsLangFlag != "" {
-		languages = strings.Split(implementationsLangFlag, ",")
+	// Language servers are only asked on --refresh; their answers are
+	// cached in type_hierarchy, so the query below returns them too
+	var unindexed []lsp.Location
+	if implementationsRefresh {
+		symbols, err := dbManager.GetSymbolByName(interfaceName, parseListFlag(implementationsLangFlag))
+		if err != nil {
+			return fmt.Errorf("failed to find symbol: %w", err)
+		}
+		if len(symbols) == 0 {
+			fmt.Printf("🔧 No interface named '%s' found in database\n", interfaceName)
+			return noResults(cmd)
+		}
+		if unindexed, err = refreshImplementations(cmd, cfg, cwd, dbManager, symbols); err != nil {
+			return err
+		}
 	}
 
-	// Find interface symbols in database
-	symbols, err := dbManager.GetSymbolByName(interfaceName, languages)
+	dbImplementations, err := dbManager.GetImplementationsByName(interfaceName, opts)
 	if err != nil {
-		return fmt.Errorf("failed to find symbol: %w", err)
-	}
-
-	if len(symbols) == 0 {
-		fmt.Printf("🔧 No interface named '%s' found in database\n", interfaceName)
-		return nil
+		return fmt.Errorf("failed to find implementations: %w", err)
 	}
-
-	// Create LSP manager
-	rootURI := "file://" + cwd
-	lspManager := lsp.NewManager(cfg, rootURI)
-	defer lspManager.ShutdownAll()
-
-	ctx := context.Background()
-	found := false
-
-	for _, sym := range symbols {
-		// Only process interface-like symbols
-		if sym.Kind != "interface" && sym.Kind != "class" && sym.Kind != "struct" {
-			continue
-		}
-
-		// Get LSP client for this language
-		client, err := lspManager.GetClient(ctx, sym.Language)
-		if err != nil {
-			continue
+	if len(dbImplementations) == 0 && len(unindexed) == 0 {
+		fmt.Printf("🔧 No implementations found for: %s\n", Warning(interfaceName))
+		if !implementationsRefresh {
+			fmt.Printf("   %s\n", Dim("Run with --refresh to ask the language servers"))
 		}
+		return noResults(cmd)
+	}
 
-		// Get implementations via LSP
-		fileURI := "file://" + sym.File
-		pos := lsp.Position{
-			Line:      sym.Line - 1,
-			Character: sym.Column,
+	typeArgs, _ := dbManager.GetImplementationTypeArgs(interfaceName)
+	fmt.Printf("🔧 Implementations of %s (%s found):\n\n", Symbol(interfaceName), Info(len(dbImplementations)+len(unindexed)))
+	for _, impl := range dbImplementations {
+		fmt.Printf("  %s [%s]", Symbol(impl.Name), Keyword(impl.Kind))
+		if args, ok := typeArgs[impl.ID]; ok {
+			fmt.Printf(" %s", Type(fmt.Sprintf("%s<%s>", interfaceName, args)))
 		}
-
-		implementations, err := client.Implementation(ctx, fileURI, pos)
-		if err != nil {
-			continue
-		}
-
-		if len(implementations) > 0 {
-			if !found {
-				fmt.Printf("🔧 Implementations of %s (%s found via LSP):\n\n", Symbol(interfaceName), Info(len(implementations)))
-				found = true
-			}
-
-			for _, impl := range implementations {
-				implPath := strings.TrimPrefix(impl.URI, "file://")
-
-				relPath, _ := filepath.Rel(cwd, implPath)
-				fmt.Printf("  %s\n", Path(fmt.Sprintf("%s:%d", relPath, impl.Range.Start.Line+1)))
-			}
+		fmt.Println()
+		fmt.Printf("    %s\n", Path(fmt.Sprintf("%s:%d", relativePath(cwd, impl.File), impl.Line)))
+		if line := getSourceLine(impl.File, impl.Line); line != "" {
+			fmt.Printf("    %s\n", Dim(line))
 		}
+		fmt.Println()
 	}
-
-	if !found {
-		fmt.Printf("🔧 No implementations found for: %s\n", Warning(interfaceName))
+	for _, loc := range unindexed {
+		implPath := lsp.URIToPath(loc.URI)
+		fmt.Printf("  %s %s\n", Path(fmt.Sprintf("%s:%d", relativePath(cwd, implPath), loc.Range.Start.Line+1)), Dim("(via LSP, not indexed)"))
 	}
 
 	return nil
@@ -168,81 +139,154 @@ func runImplementationsJSON(cmd *cobra.Command, interfaceName string) error {
 		return err
 	}
 
+	opts := queryOptions(implementationsLangFlag, implementationsKindFlag)
+	if err := implementationsPageFlags.apply(&opts); err != nil {
+		return emitErr("invalid_flag", err)
+	}
+
 	cwd, cfg, dbManager, code, err := openProject(false)
 	if err != nil {
 		return emitErr(code, err)
 	}
 	defer dbManager.Close()
 
-	records := make([]implementationRecord, 0)
-
-	dbImpls, err := dbManager.GetImplementationsByName(interfaceName)
-	if err == nil {
-		for _, impl := range dbImpls {
-			relPath, rerr := filepath.Rel(cwd, impl.File)
-			if rerr != nil {
-				relPath = impl.File
-			}
-			records = append(records, implementationRecord{
-				Name: impl.Name,
-				Kind: impl.Kind,
-				File: relPath,
-				Line: impl.Line,
-			})
+	var unindexed []lsp.Location
+	if implementationsRefresh {
+		symbols, err := dbManager.GetSymbolByName(interfaceName, parseListFlag(implementationsLangFlag))
+		if err != nil {
+			return emitErr("implementations_lookup_failed", fmt.Errorf("failed to find symbol: %w", err))
+		}
+		if unindexed, err = refreshImplementations(cmd, cfg, cwd, dbManager, symbols); err != nil {
+			return emitErr("implementations_refresh_failed", err)
 		}
 	}
 
-	if len(records) > 0 {
-		return EmitJSON(out, "implementations", &interfaceName, records, nil)
+	dbImpls, err := dbManager.GetImplementationsByName(interfaceName, opts)
+	if err != nil {
+		return emitErr("implementations_lookup_failed", fmt.Errorf("failed to find implementations: %w", err))
+	}
+	typeArgs, _ := dbManager.GetImplementationTypeArgs(interfaceName)
+	records := make([]implementationRecord, 0, len(dbImpls)+len(unindexed))
+	for _, impl := range dbImpls {
+		records = append(records, implementationRecord{
+			Name:     impl.Name,
+			Kind:     impl.Kind,
+			File:     relativePath(cwd, impl.File),
+			Line:     impl.Line,
+			TypeArgs: typeArgs[impl.ID],
+		})
+	}
+	// Locations outside the index have no symbol to name them by
+	for _, loc := range unindexed {
+		records = append(records, implementationRecord{
+			File: relativePath(cwd, lsp.URIToPath(loc.URI)),
+			Line: loc.Range.Start.Line + 1,
+		})
+	}
+
+	return emitResults(cmd, "implementations", &interfaceName, records)
+}
+
+// refreshImplementations asks the language servers for the implementations
+// of the interface-like symbols and caches those declared by indexed
+// symbols in type_hierarchy, replacing what was cached for each interface
+// before. It returns the locations no indexed symbol declares, and every
+// location when the index is read-only. The servers get until the
+// command's --timeout, else search.timeout_seconds.
+func refreshImplementations(cmd *cobra.Command, cfg *config.Config, cwd string, dbManager *db.Manager, symbols []db.Symbol) ([]lsp.Location, error) {
+	ctx, cancel := withTimeout(context.Background(), commandTimeout(cmd, implementationsTimeout, cfg.Search.Timeout()))
+	defer cancel()
+	found := lspImplementations(ctx, cfg, cwd, symbols)
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("failed to ask the language servers: %w", timedOut(ctx, err))
 	}
+	var unindexed []lsp.Location
+	for _, sym := range symbols {
+		locations, ok := found[sym.ID]
+		if !ok {
+			continue // The server failed; keep what was cached
+		}
+		if cfg.Database.ReadOnly {
+			unindexed = append(unindexed, locations...)
+			continue
+		}
 
-	// LSP fallback
-	var languages []string
-	if implementationsLangFlag != "" {
-		languages = strings.Split(implementationsLangFlag, ",")
+		var childIDs []string
+		for _, loc := range locations {
+			child, err := symbolAtLocation(dbManager, loc)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve implementation: %w", err)
+			}
+			if child == nil || child.ID == sym.ID {
+				unindexed = append(unindexed, loc)
+				continue
+			}
+			childIDs = append(childIDs, child.ID)
+		}
+		if err := dbManager.CacheImplementations(sym.ID, childIDs); err != nil {
+			return nil, err
+		}
 	}
+	return unindexed, nil
+}
 
-	symbols, err := dbManager.GetSymbolByName(interfaceName, languages)
+// symbolAtLocation returns the indexed symbol declared on loc's line, or
+// else the innermost one whose body spans it; nil when there is none
+func symbolAtLocation(dbManager *db.Manager, loc lsp.Location) (*db.Symbol, error) {
+	symbols, err := dbManager.GetFileSymbols(lsp.URIToPath(loc.URI))
 	if err != nil {
-		return emitErr("implementations_lookup_failed", fmt.Errorf("failed to find symbol: %w", err))
+		return nil, err
 	}
-	if len(symbols) == 0 {
-		return EmitJSON(out, "implementations", &interfaceName, records, nil)
+	line := loc.Range.Start.Line + 1
+	var enclosing *db.Symbol
+	for i := range symbols {
+		sym := &symbols[i]
+		if sym.Line == line {
+			return sym, nil
+		}
+		// Symbols come in source order, so a later match is nested deeper
+		if sym.Line < line && symbolEndLine(*sym) >= line {
+			enclosing = sym
+		}
 	}
+	return enclosing, nil
+}
 
-	rootURI := "file://" + cwd
-	lspManager := lsp.NewManager(cfg, rootURI)
-	defer lspManager.ShutdownAll()
+// lspImplementations asks the language servers for the implementations of
+// the interface-like symbols, through the project's daemon when it is
+// running and otherwise through servers started for this command. Results
+// are keyed by symbol ID; symbols whose server failed are left out.
+func lspImplementations(ctx context.Context, cfg *config.Config, cwd string, symbols []db.Symbol) map[string][]lsp.Location {
+	var implementation func(sym db.Symbol, pos lsp.Position) ([]lsp.Location, error)
+	if client, err := daemon.Dial(cwd); err == nil {
+		defer client.Close()
+		implementation = func(sym db.Symbol, pos lsp.Position) ([]lsp.Location, error) {
+			return client.Implementation(ctx, sym.Language, sym.File, pos)
+		}
+	} else {
+		lspManager := lsp.NewManager(cfg, lsp.PathToURI(cwd))
+		defer lspManager.ShutdownAll()
+		implementation = func(sym db.Symbol, pos lsp.Position) ([]lsp.Location, error) {
+			client, err := lspManager.GetClientForFile(ctx, sym.Language, sym.File)
+			if err != nil {
+				return nil, err
+			}
+			return client.Implementation(ctx, lsp.PathToURI(sym.File), pos)
+		}
+	}
 
-	ctx := context.Background()
+	found := make(map[string][]lsp.Location)
 	for _, sym := range symbols {
+		// Only process interface-like symbols
 		if sym.Kind != "interface" && sym.Kind != "class" && sym.Kind != "struct" {
 			continue
 		}
-		client, cerr := lspManager.GetClient(ctx, sym.Language)
-		if cerr != nil {
-			continue
-		}
-		fileURI := "file://" + sym.File
 		pos := lsp.Position{Line: sym.Line - 1, Character: sym.Column}
-		impls, ierr := client.Implementation(ctx, fileURI, pos)
-		if ierr != nil {
+		impls, err := implementation(sym, pos)
+		if err != nil {
 			continue
 		}
-		for _, impl := range impls {
-			implPath := strings.TrimPrefix(impl.URI, "file://")
-			relPath, rerr := filepath.Rel(cwd, implPath)
-			if rerr != nil {
-				relPath = implPath
-			}
-			records = append(records, implementationRecord{
-				Name: "",
-				Kind: "",
-				File: relPath,
-				Line: impl.Range.Start.Line + 1,
-			})
-		}
+		found[sym.ID] = impls
 	}
-
-	return EmitJSON(out, "implementations", &interfaceName, records, nil)
+	return found
 }
@@ -5,14 +5,24 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/tk-425/Codegraph/internal/config"
 	"github.com/tk-425/Codegraph/internal/ignore"
+	"github.com/tk-425/Codegraph/internal/lsp/adapters"
 	"github.com/tk-425/Codegraph/internal/registry"
 )
 
+var (
+	initNonInteractiveFlag bool
+	initIndexFlag          bool
+	initSkipIndexFlag      bool
+	initLanguagesFlag      string
+	initNoGitignoreFlag    bool
+)
+
 var initCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Initialize codegraph in the current project",
@@ -22,16 +32,37 @@ var initCmd = &cobra.Command{
 3. Creating .cgignore seeded from .gitignore
 4. Adding .codegraph/ to .gitignore
 
+When .codegraph/config.toml already exists, init asks before replacing it.
+Scripts and CI pass --non-interactive, which never asks and replaces it.
+
 After initialization, edit .codegraph/.cgignore to customize what gets indexed,
-then run 'codegraph build' to index your project.`,
+then run 'codegraph build' to index your project, or pass --index to build
+right away.
+
+Examples:
+  codegraph init
+  codegraph init --languages=go,python --index
+  codegraph init --non-interactive --no-gitignore`,
+	Args: cobra.NoArgs,
 	RunE: runInit,
 }
 
 func init() {
+	initCmd.Flags().BoolVar(&initNonInteractiveFlag, "non-interactive", false, "Never prompt; replace an existing config.toml")
+	initCmd.Flags().BoolVar(&initIndexFlag, "index", false, "Index the project once initialized, like 'codegraph build'")
+	initCmd.Flags().BoolVar(&initSkipIndexFlag, "skip-index", false, "Do not index the project (the default; for scripts that state it)")
+	initCmd.MarkFlagsMutuallyExclusive("index", "skip-index")
+	initCmd.Flags().StringVar(&initLanguagesFlag, "languages", "", "Only index these languages, comma-separated (e.g., go,python); sets only_languages under [index]")
+	initCmd.Flags().BoolVar(&initNoGitignoreFlag, "no-gitignore", false, "Do not add .codegraph/ to .gitignore")
 	rootCmd.AddCommand(initCmd)
 }
 
 func runInit(cmd *cobra.Command, args []string) error {
+	languages, err := parseInitLanguages(initLanguagesFlag)
+	if err != nil {
+		return err
+	}
+
 	fmt.Printf("📁 %s\n", Bold("Initializing codegraph..."))
 
 	// Get current directory
@@ -47,12 +78,23 @@ func runInit(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create .codegraph directory: %w", err)
 	}
 
-	// 2. Create config.toml
-	cfg := config.DefaultConfig()
-	if err := config.Save(cwd, cfg); err != nil {
-		return fmt.Errorf("failed to create config: %w", err)
+	// 2. Create config.toml, unless the user keeps theirs
+	configPath := filepath.Join(codegraphDir, "config.toml")
+	_, statErr := os.Stat(configPath)
+	if statErr == nil && !initNonInteractiveFlag && stdinIsTerminal() &&
+		!confirm(bufio.NewReader(cmd.InOrStdin()), "Replace the existing .codegraph/config.toml?") {
+		fmt.Printf("⏭️  Kept %s\n", Path(".codegraph/config.toml"))
+	} else {
+		cfg := config.InitConfig()
+		cfg.Index.OnlyLanguages = languages
+		if err := config.Save(cwd, cfg); err != nil {
+			return fmt.Errorf("failed to create config: %w", err)
+		}
+		fmt.Printf("📁 Created %s\n", Path(".codegraph/config.toml"))
+		if len(languages) > 0 {
+			fmt.Printf("   %s\n", Dim("Indexing only "+strings.Join(languages, ", ")))
+		}
 	}
-	fmt.Printf("📁 Created %s\n", Path(".codegraph/config.toml"))
 
 	// 3. Create .cgignore
 	if err := ignore.CreateDefaultCGIgnore(codegraphDir, cwd); err != nil {
@@ -61,7 +103,9 @@ func runInit(cmd *cobra.Command, args []string) error {
 	fmt.Printf("📁 Created %s (seeded from %s when available)\n", Path(".codegraph/.cgignore"), Dim("\".gitignore\""))
 
 	// 4. Update .gitignore
-	if err := updateGitignore(cwd); err != nil {
+	if initNoGitignoreFlag {
+		fmt.Printf("⏭️  Left %s unchanged\n", Path(".gitignore"))
+	} else if err := updateGitignore(cwd); err != nil {
 		fmt.Printf("⚠️  %s: %v\n", Warning("Could not update .gitignore"), err)
 	} else {
 		fmt.Printf("📝 Added %s to .gitignore\n", Dim("\".codegraph/\""))
@@ -76,11 +120,51 @@ func runInit(cmd *cobra.Command, args []string) error {
 
 	reportAgentNoteResult(applyAgentNote(cwd))
 
-	fmt.Printf("✅ %s\n", Success("Done. Edit .codegraph/.cgignore to customize what gets indexed, then run 'codegraph build'."))
+	if !initIndexFlag {
+		fmt.Printf("✅ %s\n", Success("Done. Edit .codegraph/.cgignore to customize what gets indexed, then run 'codegraph build'."))
+		return nil
+	}
 
+	// 6. Index the project, when asked to
+	fmt.Println()
+	if err := runBuild(buildCmd, nil); err != nil {
+		return err
+	}
+	fmt.Printf("✅ %s\n", Success("Done. Edit .codegraph/.cgignore to customize what gets indexed, then rerun 'codegraph build'."))
 	return nil
 }
 
+// parseInitLanguages splits --languages, rejecting languages codegraph
+// cannot index
+func parseInitLanguages(flag string) ([]string, error) {
+	var supported []string
+	for _, ext := range adapters.SupportedExtensions() {
+		if lang := adapters.LanguageFromExtension(ext); !slices.Contains(supported, lang) {
+			supported = append(supported, lang)
+		}
+	}
+
+	var languages []string
+	for _, lang := range strings.Split(flag, ",") {
+		lang = strings.ToLower(strings.TrimSpace(lang))
+		switch {
+		case lang == "" || slices.Contains(languages, lang):
+			continue
+		case !slices.Contains(supported, lang):
+			return nil, fmt.Errorf("unsupported language %q in --languages (supported: %s)", lang, strings.Join(supported, ", "))
+		}
+		languages = append(languages, lang)
+	}
+	return languages, nil
+}
+
+// stdinIsTerminal reports whether someone can answer prompts on standard
+// input
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
 func reportAgentNoteResult(result agentNoteResult) {
 	for _, name := range result.Updated {
 		fmt.Printf("📝 Added %s to %s\n", Success("CodeGraph agent note"), Path(name))
@@ -97,7 +181,7 @@ func reportAgentNoteResult(result agentNoteResult) {
 }
 
 func registerProject(cwd string) error {
-	regPath, err := registry.DefaultRegistryPath()
+	regPath, err := registryPath()
 	if err != nil {
 		return err
 	}
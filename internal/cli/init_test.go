@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/tk-425/Codegraph/internal/config"
+)
+
+func TestInitWithLanguagesDoesNotIndex(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	registryFlag = filepath.Join(t.TempDir(), "registry.json")
+	initNoGitignoreFlag, initNonInteractiveFlag = true, true
+	initLanguagesFlag = "go, Python,go"
+	t.Cleanup(func() {
+		registryFlag = ""
+		initNoGitignoreFlag, initNonInteractiveFlag = false, false
+		initLanguagesFlag = ""
+	})
+
+	cmd, _ := freshCmdNoArgs(t, "init", runInit)
+	captureStdout(t, func() {
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("init: %v", err)
+		}
+	})
+
+	cfg, err := config.Load(dir)
+	if err != nil {
+		t.Fatalf("config.Load: %v", err)
+	}
+	if want := []string{"go", "python"}; !slices.Equal(cfg.Index.OnlyLanguages, want) {
+		t.Errorf("only_languages = %v, want %v", cfg.Index.OnlyLanguages, want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".codegraph", ".cgignore")); err != nil {
+		t.Errorf(".cgignore not created: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".gitignore")); !os.IsNotExist(err) {
+		t.Errorf("--no-gitignore created .gitignore (stat err %v)", err)
+	}
+	if _, err := os.Stat(cfg.GetDatabasePath(dir)); !os.IsNotExist(err) {
+		t.Errorf("init without --index created the database (stat err %v)", err)
+	}
+}
+
+func TestParseInitLanguages(t *testing.T) {
+	languages, err := parseInitLanguages("")
+	if err != nil || languages != nil {
+		t.Errorf("parseInitLanguages(\"\") = %v, %v; want nil", languages, err)
+	}
+	if _, err := parseInitLanguages("go,cobol"); err == nil {
+		t.Error("parseInitLanguages accepted cobol")
+	}
+}
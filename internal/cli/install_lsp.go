@@ -0,0 +1,181 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tk-425/Codegraph/internal/config"
+	"github.com/tk-425/Codegraph/internal/lsp"
+)
+
+var (
+	installLSPYesFlag    bool
+	installLSPForceFlag  bool
+	installLSPBinDirFlag string
+)
+
+var installLSPCmd = &cobra.Command{
+	Use:   "install-lsp [language...]",
+	Short: "Install missing language servers",
+	Long: `Install the language servers CodeGraph uses for precise call graphs.
+
+Without arguments, every configured server that is not on PATH is offered.
+gopls is installed with go install, pyright and typescript-language-server
+with npm, ocaml-lsp-server with opam, and rust-analyzer is downloaded from
+its GitHub releases into --bin-dir. jdtls and sourcekit-lsp are not
+installed automatically; install-lsp prints instructions for them.
+
+Each install is confirmed first unless --yes is given. Pin a version with
+` + "`version`" + ` under the language's [lsp.<lang>] entry in config.toml.
+
+Examples:
+  codegraph install-lsp
+  codegraph install-lsp go rust
+  codegraph install-lsp python --yes
+  codegraph install-lsp rust --bin-dir ~/bin --force`,
+	RunE: runInstallLSP,
+}
+
+func init() {
+	installLSPCmd.Flags().BoolVarP(&installLSPYesFlag, "yes", "y", false, "Install without asking for confirmation")
+	installLSPCmd.Flags().BoolVar(&installLSPForceFlag, "force", false, "Reinstall servers that are already on PATH")
+	installLSPCmd.Flags().StringVar(&installLSPBinDirFlag, "bin-dir", "", "Directory for downloaded binaries (default ~/.local/bin)")
+	rootCmd.AddCommand(installLSPCmd)
+}
+
+func runInstallLSP(cmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+	// Outside an initialized project, fall back to the default servers
+	cfg := config.DefaultConfig()
+	if _, err := os.Stat(filepath.Join(cwd, config.DefaultConfigDir)); err == nil {
+		if cfg, err = config.Load(cwd); err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+	}
+
+	binDir, err := installBinDir()
+	if err != nil {
+		return err
+	}
+
+	languages := args
+	if len(languages) == 0 {
+		for lang := range cfg.LSP {
+			languages = append(languages, lang)
+		}
+		sort.Strings(languages)
+	}
+
+	plans, err := installPlans(cfg, languages)
+	if err != nil {
+		return err
+	}
+	if len(plans) == 0 {
+		fmt.Printf("✅ %s\n", Success("All language servers are installed"))
+		return nil
+	}
+
+	ctx := context.Background()
+	in := bufio.NewReader(cmd.InOrStdin())
+	failed := 0
+	for _, plan := range plans {
+		if !plan.Automatic() {
+			fmt.Printf("📝 %s (%s): %s\n", Keyword(plan.Server), plan.Language, plan.Manual)
+			continue
+		}
+		if !installLSPYesFlag && !confirm(in, fmt.Sprintf("Install %s for %s? (%s)", plan.Server, plan.Language, plan.Description())) {
+			fmt.Printf("⏭️  Skipped %s\n", plan.Server)
+			continue
+		}
+
+		fmt.Printf("📦 Installing %s...\n", Keyword(plan.Server))
+		if err := plan.Run(ctx, binDir, os.Stdout); err != nil {
+			fmt.Printf("❌ %s\n", Error(err.Error()))
+			failed++
+			continue
+		}
+		fmt.Printf("✅ %s installed\n", Success(plan.Server))
+		if plan.URL != "" && !dirOnPath(binDir) {
+			fmt.Printf("⚠️  %s\n", Warning(fmt.Sprintf("%s is not on PATH; add it so CodeGraph can find %s", binDir, plan.Server)))
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d language server(s) failed to install", failed)
+	}
+	return nil
+}
+
+// installPlans resolves the install plan for each language, dropping servers
+// already on PATH (unless --force) and languages sharing a server
+func installPlans(cfg *config.Config, languages []string) ([]lsp.InstallPlan, error) {
+	var plans []lsp.InstallPlan
+	seen := make(map[string]bool)
+	for _, lang := range languages {
+		lspCfg := cfg.LSP[lang]
+		if lspCfg.IsSocket() || lspCfg.Container != nil {
+			continue // Connected to, or run in a container, not installed
+		}
+		plan, err := lsp.PlanInstall(lang, lspCfg)
+		if err != nil {
+			return nil, err
+		}
+		if seen[plan.Server] {
+			continue
+		}
+		seen[plan.Server] = true
+
+		command := lspCfg.Command
+		if command == "" {
+			command = plan.Server
+		}
+		if _, err := exec.LookPath(command); err == nil && !installLSPForceFlag {
+			continue
+		}
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
+
+// installBinDir returns --bin-dir, defaulting to ~/.local/bin
+func installBinDir() (string, error) {
+	if installLSPBinDirFlag != "" {
+		return installLSPBinDirFlag, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate home directory (use --bin-dir): %w", err)
+	}
+	return filepath.Join(home, ".local", "bin"), nil
+}
+
+// confirm asks a yes/no question, defaulting to no
+func confirm(in *bufio.Reader, question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, err := in.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+func dirOnPath(dir string) bool {
+	for _, entry := range filepath.SplitList(os.Getenv("PATH")) {
+		if filepath.Clean(entry) == filepath.Clean(dir) {
+			return true
+		}
+	}
+	return false
+}
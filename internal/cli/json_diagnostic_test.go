@@ -3,12 +3,15 @@ package cli
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/tk-425/Codegraph/internal/db"
 	"github.com/tk-425/Codegraph/internal/registry"
 )
 
@@ -129,3 +132,93 @@ func TestJSONDiagnostic_Stats(t *testing.T) {
 		t.Errorf("database_path should be populated, got empty; record=%+v", recs[0])
 	}
 }
+
+func TestJSONDiagnostic_StatsByDir(t *testing.T) {
+	root, m := setupCodegraphProject(t)
+	for _, s := range []db.Symbol{
+		{ID: "main.go#main", Name: "main", Kind: "function", File: "main.go", Language: "go"},
+		{ID: "pkg/a/x.go#X", Name: "X", Kind: "function", File: "pkg/a/x.go", Language: "go"},
+		{ID: "pkg/a/x.go#T", Name: "T", Kind: "struct", File: "pkg/a/x.go", Language: "go"},
+		{ID: "pkg/b/y.py#y", Name: "y", Kind: "function", File: "pkg/b/y.py", Language: "python"},
+	} {
+		s.File = filepath.Join(root, s.File)
+		seedSymbol(t, m, s)
+		if err := m.UpdateFileMeta(s.File, time.Now(), s.Language, "tree-sitter"); err != nil {
+			t.Fatalf("UpdateFileMeta: %v", err)
+		}
+	}
+	if err := m.UpdateFileMeta(filepath.Join(root, "pkg/b/empty.py"), time.Now(), "python", "tree-sitter"); err != nil {
+		t.Fatalf("UpdateFileMeta: %v", err)
+	}
+	if err := m.InsertCall(&db.Call{CallerID: "pkg/a/x.go#X", CalleeID: "main.go#main"}); err != nil {
+		t.Fatalf("InsertCall: %v", err)
+	}
+	t.Cleanup(func() { statsByDir, statsDepth = false, 0 })
+
+	for _, tc := range []struct {
+		depth int
+		want  string
+	}{
+		{0, "pkg/a files=1 symbols=2 funcs=1 calls=1 [go]; . files=1 symbols=1 funcs=1 calls=0 [go]; pkg/b files=2 symbols=1 funcs=1 calls=0 [python]"},
+		{1, "pkg files=3 symbols=3 funcs=2 calls=1 [go python]; . files=1 symbols=1 funcs=1 calls=0 [go]"},
+	} {
+		statsByDir, statsDepth = true, tc.depth
+		c, buf := freshCmdNoArgs(t, "stats", runStats)
+		if err := c.RunE(c, nil); err != nil {
+			t.Fatalf("runStats returned error: %v", err)
+		}
+		env, _ := decodeEnvelope(t, buf.Bytes())
+		var recs []statsRecord
+		_ = json.Unmarshal(env["results"], &recs)
+		var got []string
+		for _, p := range recs[0].Paths {
+			got = append(got, fmt.Sprintf("%s files=%d symbols=%d funcs=%d calls=%d %v", p.Path, p.Files, p.Symbols, p.Functions, p.CallEdges, p.Languages))
+		}
+		if strings.Join(got, "; ") != tc.want {
+			t.Errorf("depth %d: paths = %s\nwant %s", tc.depth, strings.Join(got, "; "), tc.want)
+		}
+	}
+}
+
+func TestJSONDiagnostic_Coverage(t *testing.T) {
+	root, m := setupCodegraphProject(t)
+	for _, f := range []struct{ file, lang, source string }{
+		{"ok.go", "go", "lsp"},
+		{"empty.go", "go", "tree-sitter"},
+		{"nocalls.py", "python", "tree-sitter"},
+		{"types.go", "go", "lsp"},
+	} {
+		if err := m.UpdateFileMeta(filepath.Join(root, f.file), time.Now(), f.lang, f.source); err != nil {
+			t.Fatalf("UpdateFileMeta: %v", err)
+		}
+	}
+	for _, s := range []db.Symbol{
+		{ID: "ok.go#a", Name: "a", Kind: "function", File: "ok.go", Language: "go"},
+		{ID: "ok.go#b", Name: "b", Kind: "function", File: "ok.go", Language: "go"},
+		{ID: "nocalls.py#f", Name: "f", Kind: "function", File: "nocalls.py", Language: "python"},
+		{ID: "types.go#T", Name: "T", Kind: "struct", File: "types.go", Language: "go"},
+	} {
+		s.File = filepath.Join(root, s.File)
+		seedSymbol(t, m, s)
+	}
+	if err := m.InsertCall(&db.Call{CallerID: "ok.go#a", CalleeID: "ok.go#b", File: filepath.Join(root, "ok.go"), Line: 2}); err != nil {
+		t.Fatalf("InsertCall: %v", err)
+	}
+
+	c, buf := freshCmdNoArgs(t, "coverage", runCoverage)
+	if err := c.RunE(c, nil); err != nil {
+		t.Fatalf("runCoverage returned error: %v", err)
+	}
+	env, _ := decodeEnvelope(t, buf.Bytes())
+	assertQueryNull(t, env)
+	var recs []coverageRecord
+	_ = json.Unmarshal(env["results"], &recs)
+	var got []string
+	for _, r := range recs {
+		got = append(got, fmt.Sprintf("%s:%s:%s", r.File, r.Source, r.Problem))
+	}
+	// A file of types alone legitimately has no calls
+	if want := "empty.go:tree-sitter:no_symbols,nocalls.py:tree-sitter:no_calls"; strings.Join(got, ",") != want {
+		t.Errorf("coverage = %s, want %s", strings.Join(got, ","), want)
+	}
+}
@@ -34,6 +34,8 @@ func negativePathCases() []cmdCase {
 		{"callers", runCallers, []string{"x"}},
 		{"callees", runCallees, []string{"x"}},
 		{"implementations", runImplementations, []string{"x"}},
+		{"context", runContext, []string{"x"}},
+		{"snippet", runSnippet, []string{"x"}},
 		{"stats", runStats, nil},
 	}
 }
@@ -192,6 +194,16 @@ func TestJSONErrors_NonJSONRegression(t *testing.T) {
 			setup: func(t *testing.T) { setupCodegraphProject(t); jsonOutputFlag = false },
 			run:   runImplementations, args: []string{"nope"},
 		},
+		{
+			name: "context", prefix: "🧭",
+			setup: func(t *testing.T) { setupCodegraphProject(t); jsonOutputFlag = false },
+			run:   runContext, args: []string{"nope"},
+		},
+		{
+			name: "snippet", prefix: "📄",
+			setup: func(t *testing.T) { setupCodegraphProject(t); jsonOutputFlag = false },
+			run:   runSnippet, args: []string{"nope"},
+		},
 		{
 			name: "types", prefix: "🔗",
 			setup: func(t *testing.T) {
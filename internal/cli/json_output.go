@@ -2,6 +2,7 @@ package cli
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -27,7 +28,7 @@ func openProject(requireExistingDB bool) (string, *config.Config, *db.Manager, s
 	}
 	codegraphDir := filepath.Join(cwd, ".codegraph")
 	if _, statErr := os.Stat(codegraphDir); os.IsNotExist(statErr) {
-		return cwd, nil, nil, "not_initialized", fmt.Errorf("codegraph not initialized. Run 'codegraph init' first")
+		return cwd, nil, nil, "not_initialized", errNotInitialized
 	}
 	cfg, err := config.Load(cwd)
 	if err != nil {
@@ -36,16 +37,29 @@ func openProject(requireExistingDB bool) (string, *config.Config, *db.Manager, s
 	dbPath := cfg.GetDatabasePath(cwd)
 	if requireExistingDB {
 		if _, statErr := os.Stat(dbPath); os.IsNotExist(statErr) {
-			return cwd, cfg, nil, "database_missing", fmt.Errorf("database not found. Run 'codegraph build' first")
+			return cwd, cfg, nil, "database_missing", errIndexMissing
 		}
 	}
-	dbm, err := db.NewManager(dbPath)
+	dbm, err := openDatabase(cfg, cwd)
+	if errors.Is(err, db.ErrOutdatedIndex) {
+		return cwd, cfg, nil, "index_outdated", fmt.Errorf("failed to open database: %w", err)
+	}
 	if err != nil {
 		return cwd, cfg, nil, "db_open_failed", fmt.Errorf("failed to open database: %w", err)
 	}
 	return cwd, cfg, dbm, "", nil
 }
 
+// openDatabase opens the project's database, read-only when config.toml
+// marks it as a shared index, rebased onto root when that index was built
+// in another checkout
+func openDatabase(cfg *config.Config, root string) (*db.Manager, error) {
+	if cfg.Database.ReadOnly {
+		return db.NewSharedManager(cfg.GetDatabasePath(root), root, cfg.GetSharedCachePath(root))
+	}
+	return db.NewManager(cfg.GetDatabasePath(root))
+}
+
 // jsonOutputFlag is set by the persistent --json root flag. When true,
 // in-scope read-only query commands emit a single JSON envelope to stdout
 // instead of their human-formatted output.
@@ -99,3 +113,68 @@ func EmitJSON(w io.Writer, command string, query *string, results any, errs []En
 	}
 	return json.NewEncoder(w).Encode(env)
 }
+
+// JSONStream writes an Envelope whose results are encoded one at a time as
+// they are read, so a large result set is never held in memory. The output
+// is the same as EmitJSON's. Count precedes results in the envelope, so it
+// must be known before the first result is written.
+type JSONStream struct {
+	w       io.Writer
+	written int
+	err     error
+}
+
+// StartJSON writes the head of an Envelope with count results
+func StartJSON(w io.Writer, command string, query *string, count int) *JSONStream {
+	s := &JSONStream{w: w}
+	head, err := json.Marshal(struct {
+		Command string  `json:"command"`
+		Query   *string `json:"query"`
+		Count   int     `json:"count"`
+	}{command, query, count})
+	if err != nil {
+		s.err = err
+		return s
+	}
+	// Reopen the object to append the results array
+	_, s.err = fmt.Fprintf(w, `%s,"results":[`, head[:len(head)-1])
+	return s
+}
+
+// Write appends one result. Writes block while the reader of w is behind,
+// which holds back the query feeding the stream.
+func (s *JSONStream) Write(result any) error {
+	if s.err != nil {
+		return s.err
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		s.err = err
+		return err
+	}
+	if s.written > 0 {
+		_, s.err = io.WriteString(s.w, ",")
+	}
+	if s.err == nil {
+		_, s.err = s.w.Write(data)
+	}
+	s.written++
+	return s.err
+}
+
+// Close ends the results and writes errs, e.g. the error that stopped a
+// query after some results were written; count then exceeds their number
+func (s *JSONStream) Close(errs []EnvelopeError) error {
+	if s.err != nil {
+		return s.err
+	}
+	if errs == nil {
+		errs = []EnvelopeError{}
+	}
+	data, err := json.Marshal(errs)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(s.w, "],\"errors\":%s}\n", data)
+	return err
+}
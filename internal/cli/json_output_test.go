@@ -143,3 +143,42 @@ func TestEmitJSON_CountMatchesLen(t *testing.T) {
 		t.Errorf("count=%d, want 5", env.Count)
 	}
 }
+
+func TestJSONStream_MatchesEmitJSON(t *testing.T) {
+	type rec struct {
+		Name string `json:"name"`
+	}
+	q := "a<b>"
+
+	for _, results := range [][]rec{{}, {{Name: "a"}}, {{Name: "a"}, {Name: "<b>"}, {Name: "c"}}} {
+		var want, got bytes.Buffer
+		if err := EmitJSON(&want, "callers", &q, results, nil); err != nil {
+			t.Fatalf("EmitJSON: %v", err)
+		}
+		stream := StartJSON(&got, "callers", &q, len(results))
+		for _, r := range results {
+			if err := stream.Write(r); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+		}
+		if err := stream.Close(nil); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+		if got.String() != want.String() {
+			t.Errorf("stream = %s, want %s", got.String(), want.String())
+		}
+	}
+
+	// An error after some results still closes a valid envelope
+	var buf bytes.Buffer
+	stream := StartJSON(&buf, "callers", nil, 2)
+	_ = stream.Write(rec{Name: "a"})
+	_ = stream.Close([]EnvelopeError{{Code: "callers_lookup_failed", Message: "boom"}})
+	var env Envelope
+	if err := json.Unmarshal(buf.Bytes(), &env); err != nil {
+		t.Fatalf("invalid JSON %s: %v", buf.String(), err)
+	}
+	if env.Count != 2 || len(env.Errors) != 1 || env.Errors[0].Code != "callers_lookup_failed" {
+		t.Errorf("envelope = %+v", env)
+	}
+}
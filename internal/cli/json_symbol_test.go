@@ -2,14 +2,27 @@ package cli
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/tk-425/Codegraph/internal/config"
 	"github.com/tk-425/Codegraph/internal/db"
+	"github.com/tk-425/Codegraph/internal/embed"
+	"github.com/tk-425/Codegraph/internal/indexer"
+	"github.com/tk-425/Codegraph/internal/lsp"
+	"github.com/tk-425/Codegraph/internal/registry"
+	"github.com/tk-425/Codegraph/internal/snapshot"
 )
 
 // setupCodegraphProject creates a temp project with .codegraph/ and an
@@ -119,6 +132,168 @@ func TestJSONSymbol_Search(t *testing.T) {
 	}
 }
 
+func TestJSONSymbol_SearchPatterns(t *testing.T) {
+	_, m := setupCodegraphProject(t)
+	for _, name := range []string{"HandleLoginRequest", "HandleLogout", "handleRequest"} {
+		seedSymbol(t, m, db.Symbol{
+			ID: "src/handler.go#" + name, Name: name, Kind: "function",
+			File: "src/handler.go", Line: 1, Language: "go",
+		})
+	}
+	t.Cleanup(func() { searchRegexFlag, searchGlobFlag = false, false })
+
+	for _, tc := range []struct {
+		query       string
+		regex, glob bool
+		want        []string
+	}{
+		{"Handle.*Request", true, false, []string{"HandleLoginRequest"}},
+		{"(?i)^handle.*request$", true, false, []string{"HandleLoginRequest", "handleRequest"}},
+		{"Handle*", false, true, []string{"HandleLoginRequest", "HandleLogout"}},
+		{"[hH]andle?equest", false, true, []string{"handleRequest"}},
+	} {
+		searchRegexFlag, searchGlobFlag = tc.regex, tc.glob
+		c, buf := freshCmd(t, "search", runSearch)
+		if err := c.RunE(c, []string{tc.query}); err != nil {
+			t.Fatalf("%s: runSearch returned error: %v", tc.query, err)
+		}
+		env, _ := decodeEnvelope(t, buf.Bytes())
+		var recs []searchRecord
+		_ = json.Unmarshal(env["results"], &recs)
+		var got []string
+		for _, r := range recs {
+			got = append(got, r.Name)
+		}
+		if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Errorf("%s: got %v, want %v", tc.query, got, tc.want)
+		}
+	}
+
+	searchRegexFlag, searchGlobFlag = true, false
+	c, buf := freshCmd(t, "search", runSearch)
+	if err := c.RunE(c, []string{"Handle("}); err == nil {
+		t.Fatal("expected error for invalid regex")
+	}
+	env, _ := decodeEnvelope(t, buf.Bytes())
+	if !strings.Contains(string(env["errors"]), "invalid_pattern") {
+		t.Errorf("errors = %s, want invalid_pattern", env["errors"])
+	}
+}
+
+func TestJSONSymbol_SearchSemantic(t *testing.T) {
+	dir, m := setupCodegraphProject(t)
+	t.Cleanup(func() { searchSemanticFlag = false })
+	searchSemanticFlag = true
+
+	// Without an [embeddings] section the tier is unavailable
+	c, buf := freshCmd(t, "search", runSearch)
+	if err := c.RunE(c, []string{"retry requests"}); err == nil {
+		t.Fatal("expected error when embeddings are not configured")
+	}
+	env, _ := decodeEnvelope(t, buf.Bytes())
+	if !strings.Contains(string(env["errors"]), "semantic_unavailable") {
+		t.Errorf("errors = %s, want semantic_unavailable", env["errors"])
+	}
+
+	cfg := "[embeddings]\nprovider = \"hash\"\n"
+	if err := os.WriteFile(filepath.Join(dir, ".codegraph", "config.toml"), []byte(cfg), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	seedSymbol(t, m, db.Symbol{
+		ID: "src/client.go#retryWithBackoff", Name: "retryWithBackoff", Kind: "function",
+		File: "src/client.go", Line: 10, Language: "go", Signature: "func retryWithBackoff(req *Request) error",
+	})
+	seedSymbol(t, m, db.Symbol{
+		ID: "src/config.go#parseConfig", Name: "parseConfig", Kind: "function",
+		File: "src/config.go", Line: 3, Language: "go", Signature: "func parseConfig(path string) (*Config, error)",
+	})
+	if _, err := indexer.NewEmbeddingIndexer(m, embed.NewHashProvider(), 0).IndexEmbeddings(context.Background()); err != nil {
+		t.Fatalf("IndexEmbeddings: %v", err)
+	}
+
+	c, buf = freshCmd(t, "search", runSearch)
+	if err := c.RunE(c, []string{"retry request"}); err != nil {
+		t.Fatalf("runSearch returned error: %v", err)
+	}
+	env, count := decodeEnvelope(t, buf.Bytes())
+	var recs []searchRecord
+	_ = json.Unmarshal(env["results"], &recs)
+	if count == 0 || recs[0].Name != "retryWithBackoff" || recs[0].Score <= 0 {
+		t.Errorf("results = %+v, want retryWithBackoff first with a score", recs)
+	}
+}
+
+func TestJSONSymbol_SearchTiers(t *testing.T) {
+	dir, m := setupCodegraphProject(t)
+	t.Cleanup(func() { searchTiersFlag = "" })
+	seedSymbol(t, m, db.Symbol{
+		ID: "src/old.go#handleStale", Name: "handleStale", Kind: "function",
+		File: filepath.Join(dir, "src", "old.go"), Line: 1, Language: "go",
+	})
+	src := "package main\n\nfunc handleFresh() {}\n\ntype handleConfig struct{}\n"
+	if err := os.MkdirAll(filepath.Join(dir, "src"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "src", "new.go"), []byte(src), 0o644); err != nil {
+		t.Fatalf("write source: %v", err)
+	}
+
+	search := func(query string) ([]searchRecord, map[string]json.RawMessage, error) {
+		c, buf := freshCmd(t, "search", runSearch)
+		err := c.RunE(c, []string{query})
+		env, _ := decodeEnvelope(t, buf.Bytes())
+		var recs []searchRecord
+		_ = json.Unmarshal(env["results"], &recs)
+		return recs, env, err
+	}
+
+	// The tree-sitter tier sees symbols that were never indexed
+	searchTiersFlag = "treesitter"
+	recs, _, err := search("handleFresh")
+	if err != nil {
+		t.Fatalf("runSearch returned error: %v", err)
+	}
+	if len(recs) != 1 || recs[0].Name != "handleFresh" || recs[0].File != "src/new.go" || recs[0].Line != 3 {
+		t.Errorf("treesitter results = %+v", recs)
+	}
+
+	// Tiers run in order: the database answers first when it has a match
+	searchTiersFlag = "db,treesitter"
+	if recs, _, _ = search("handle"); len(recs) != 1 || recs[0].Name != "handleStale" {
+		t.Errorf("db,treesitter results = %+v, want handleStale from the database", recs)
+	}
+
+	// config.toml sets the order when --tiers is absent
+	searchTiersFlag = ""
+	cfg := "[search]\ntiers = [\"treesitter\"]\n"
+	if err := os.WriteFile(filepath.Join(dir, ".codegraph", "config.toml"), []byte(cfg), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if recs, _, _ = search("handle"); len(recs) != 2 || recs[0].Name != "handleConfig" || recs[1].Name != "handleFresh" {
+		t.Errorf("config tiers results = %+v", recs)
+	}
+
+	// The built-in grep tier tags declarations and respects .cgignore
+	if err := os.WriteFile(filepath.Join(dir, ".codegraph", ".cgignore"), []byte("vendor/\n"), 0o644); err != nil {
+		t.Fatalf("write .cgignore: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "vendor"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "vendor", "dep.go"), []byte("package dep\n\nfunc handleFresh() {}\n"), 0o644); err != nil {
+		t.Fatalf("write vendored source: %v", err)
+	}
+	searchTiersFlag = "grep"
+	if recs, _, _ = search("handleFresh"); len(recs) != 1 || recs[0].Kind != "function" || recs[0].File != "src/new.go" {
+		t.Errorf("grep results = %+v", recs)
+	}
+
+	searchTiersFlag = "db,fuzzy"
+	if _, env, err := search("handle"); err == nil || !strings.Contains(string(env["errors"]), "invalid_flag") {
+		t.Errorf("unknown tier: err = %v, errors = %s", err, env["errors"])
+	}
+}
+
 func TestJSONSymbol_Signature(t *testing.T) {
 	_, m := setupCodegraphProject(t)
 	seedSymbol(t, m, db.Symbol{
@@ -177,7 +352,7 @@ func TestJSONSymbol_Callers(t *testing.T) {
 	}
 }
 
-func TestJSONSymbol_Callees(t *testing.T) {
+func TestJSONSymbol_CallersStdin(t *testing.T) {
 	_, m := setupCodegraphProject(t)
 	caller := db.Symbol{
 		ID: "src/handler.go#handleLogin", Name: "handleLogin", Kind: "function",
@@ -195,84 +370,2143 @@ func TestJSONSymbol_Callees(t *testing.T) {
 	}); err != nil {
 		t.Fatalf("InsertCall: %v", err)
 	}
+	callersStdinFlag = true
+	t.Cleanup(func() { callersStdinFlag = false })
 
-	c, buf := freshCmd(t, "callees", runCallees)
-	if err := c.RunE(c, []string{"handleLogin"}); err != nil {
-		t.Fatalf("runCallees returned error: %v", err)
+	c, buf := freshCmd(t, "callers", runCallers)
+	c.SetIn(strings.NewReader("authenticate\n\n# comment\nhandleLogin\nauthenticate\n"))
+	if err := c.RunE(c, nil); err != nil {
+		t.Fatalf("runCallers returned error: %v", err)
 	}
 
+	env, count := decodeEnvelope(t, buf.Bytes())
+	if count != 2 || string(env["query"]) != "null" {
+		t.Fatalf("count = %d, query = %s, want 2 symbols and no query; env=%s", count, env["query"], buf.String())
+	}
+	var results []struct {
+		Symbol  string         `json:"symbol"`
+		Count   int            `json:"count"`
+		Results []callerRecord `json:"results"`
+	}
+	if err := json.Unmarshal(env["results"], &results); err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Symbol != "authenticate" || results[0].Count != 1 || results[0].Results[0].Name != "handleLogin" {
+		t.Errorf("authenticate result = %+v", results[0])
+	}
+	if results[1].Symbol != "handleLogin" || results[1].Count != 0 || results[1].Results == nil {
+		t.Errorf("handleLogin result = %+v, want an empty list", results[1])
+	}
+}
+
+func TestJSONSymbol_CallersKindFilter(t *testing.T) {
+	_, m := setupCodegraphProject(t)
+	callee := db.Symbol{
+		ID: "src/auth.go#authenticate", Name: "authenticate", Kind: "function",
+		File: "src/auth.go", Line: 42, Language: "go",
+	}
+	fn := db.Symbol{
+		ID: "src/handler.go#handleLogin", Name: "handleLogin", Kind: "function",
+		File: "src/handler.go", Line: 10, Language: "go",
+	}
+	method := db.Symbol{
+		ID: "src/server.go#Server.login", Name: "login", Kind: "method",
+		File: "src/server.go", Line: 20, Language: "go",
+	}
+	for _, s := range []db.Symbol{callee, fn, method} {
+		seedSymbol(t, m, s)
+	}
+	for _, call := range []db.Call{
+		{CallerID: fn.ID, CalleeID: callee.ID, File: "src/handler.go", Line: 15, Column: 4},
+		{CallerID: method.ID, CalleeID: callee.ID, File: "src/server.go", Line: 25, Column: 4},
+	} {
+		if err := m.InsertCall(&call); err != nil {
+			t.Fatalf("InsertCall: %v", err)
+		}
+	}
+	t.Cleanup(func() { callersKindFlag = "" })
+
+	for _, tc := range []struct {
+		kind string
+		want string
+	}{
+		{"method", "login"},
+		{"!method", "handleLogin"},
+	} {
+		callersKindFlag = tc.kind
+		c, buf := freshCmd(t, "callers", runCallers)
+		if err := c.RunE(c, []string{"authenticate"}); err != nil {
+			t.Fatalf("--kind=%s: runCallers returned error: %v", tc.kind, err)
+		}
+		env, count := decodeEnvelope(t, buf.Bytes())
+		if count != 1 {
+			t.Fatalf("--kind=%s: count = %d, want 1, env=%s", tc.kind, count, buf.String())
+		}
+		var recs []callerRecord
+		_ = json.Unmarshal(env["results"], &recs)
+		if recs[0].Name != tc.want {
+			t.Errorf("--kind=%s: name = %q, want %q", tc.kind, recs[0].Name, tc.want)
+		}
+	}
+}
+
+func TestJSONSymbol_CallersArity(t *testing.T) {
+	_, m := setupCodegraphProject(t)
+	callee := db.Symbol{
+		ID: "src/server.go#NewServer", Name: "NewServer", Kind: "function",
+		File: "src/server.go", Line: 5, Language: "go",
+	}
+	caller := db.Symbol{
+		ID: "src/main.go#main", Name: "main", Kind: "function",
+		File: "src/main.go", Line: 3, Language: "go",
+	}
+	seedSymbol(t, m, callee)
+	seedSymbol(t, m, caller)
+	one, two := 1, 2
+	for _, call := range []db.Call{
+		{CallerID: caller.ID, CalleeID: callee.ID, File: "src/main.go", Line: 4, ArgCount: &one, Args: "(addr)"},
+		{CallerID: caller.ID, CalleeID: callee.ID, File: "src/main.go", Line: 5, ArgCount: &two, Args: `(addr, "tls")`},
+		{CallerID: caller.ID, CalleeID: callee.ID, File: "src/main.go", Line: 6},
+	} {
+		if err := m.InsertCall(&call); err != nil {
+			t.Fatalf("InsertCall: %v", err)
+		}
+	}
+	t.Cleanup(func() { callersArityFlag = -1 })
+
+	callersArityFlag = 2
+	c, buf := freshCmd(t, "callers", runCallers)
+	if err := c.RunE(c, []string{"NewServer"}); err != nil {
+		t.Fatalf("runCallers returned error: %v", err)
+	}
 	env, count := decodeEnvelope(t, buf.Bytes())
 	if count != 1 {
 		t.Fatalf("count = %d, want 1, env=%s", count, buf.String())
 	}
-	var recs []calleeRecord
+	var recs []callerRecord
 	_ = json.Unmarshal(env["results"], &recs)
-	if recs[0].Name != "authenticate" {
+	if recs[0].Line != 5 || recs[0].ArgCount == nil || *recs[0].ArgCount != 2 || recs[0].Args != `(addr, "tls")` {
 		t.Errorf("record = %+v", recs[0])
 	}
+
+	// Without --arity, unparsed call sites report a null count
+	callersArityFlag = -1
+	c, buf = freshCmd(t, "callers", runCallers)
+	if err := c.RunE(c, []string{"NewServer"}); err != nil {
+		t.Fatalf("runCallers returned error: %v", err)
+	}
+	env, count = decodeEnvelope(t, buf.Bytes())
+	_ = json.Unmarshal(env["results"], &recs)
+	if count != 3 || recs[2].ArgCount != nil {
+		t.Errorf("records = %+v", recs)
+	}
 }
 
-func TestJSONSymbol_Implementations(t *testing.T) {
+func TestJSONSymbol_CallersContext(t *testing.T) {
 	_, m := setupCodegraphProject(t)
-	iface := db.Symbol{
-		ID: "src/io.go#Reader", Name: "Reader", Kind: "interface",
-		File: "src/io.go", Line: 5, Language: "go",
+	callee := db.Symbol{
+		ID: "src/tx.go#rollback", Name: "rollback", Kind: "function",
+		File: "src/tx.go", Line: 5, Language: "go",
 	}
-	impl := db.Symbol{
-		ID: "src/file.go#FileReader", Name: "FileReader", Kind: "struct",
-		File: "src/file.go", Line: 10, Language: "go",
+	caller := db.Symbol{
+		ID: "src/main.go#main", Name: "main", Kind: "function",
+		File: "src/main.go", Line: 3, Language: "go",
 	}
-	seedSymbol(t, m, iface)
-	seedSymbol(t, m, impl)
-	if err := m.InsertTypeHierarchy(&db.TypeHierarchy{
-		ChildID: impl.ID, ParentID: iface.ID, Relationship: "implements",
-	}); err != nil {
-		t.Fatalf("InsertTypeHierarchy: %v", err)
+	seedSymbol(t, m, callee)
+	seedSymbol(t, m, caller)
+	for _, call := range []db.Call{
+		{CallerID: caller.ID, CalleeID: callee.ID, File: "src/main.go", Line: 4},
+		{CallerID: caller.ID, CalleeID: callee.ID, File: "src/main.go", Line: 6, Context: "loop,if"},
+		{CallerID: caller.ID, CalleeID: callee.ID, File: "src/main.go", Line: 8, Context: "defer"},
+	} {
+		if err := m.InsertCall(&call); err != nil {
+			t.Fatalf("InsertCall: %v", err)
+		}
 	}
+	t.Cleanup(func() { callersContext = "" })
 
-	c, buf := freshCmd(t, "implementations", runImplementations)
-	if err := c.RunE(c, []string{"Reader"}); err != nil {
-		t.Fatalf("runImplementations returned error: %v", err)
+	for _, tc := range []struct {
+		context string
+		want    []int
+	}{
+		{"", []int{4, 6, 8}},
+		{"none", []int{4}},
+		{"if", []int{6}},
+		{"defer", []int{8}},
+		{"catch", nil},
+	} {
+		callersContext = tc.context
+		c, buf := freshCmd(t, "callers", runCallers)
+		if err := c.RunE(c, []string{"rollback"}); err != nil && (tc.want != nil || ExitCode(err) != ExitNoResults) {
+			t.Fatalf("--context=%s: %v", tc.context, err)
+		}
+		env, _ := decodeEnvelope(t, buf.Bytes())
+		var recs []callerRecord
+		_ = json.Unmarshal(env["results"], &recs)
+		var lines []int
+		for _, r := range recs {
+			lines = append(lines, r.Line)
+		}
+		if fmt.Sprint(lines) != fmt.Sprint(tc.want) {
+			t.Errorf("--context=%s: lines = %v, want %v", tc.context, lines, tc.want)
+		}
+		if tc.context == "" && (len(recs[0].Context) != 0 || strings.Join(recs[1].Context, ",") != "loop,if") {
+			t.Errorf("contexts = %v, %v", recs[0].Context, recs[1].Context)
+		}
+	}
+
+	callersContext = "while"
+	c, _ := freshCmd(t, "callers", runCallers)
+	if err := c.RunE(c, []string{"rollback"}); err == nil {
+		t.Error("--context=while: expected an error")
+	}
+}
+
+func TestJSONSymbol_TestCoverage(t *testing.T) {
+	_, m := setupCodegraphProject(t)
+	syms := map[string]db.Symbol{}
+	for _, s := range []db.Symbol{
+		{ID: "src/config.go#parseConfig", Name: "parseConfig", File: "src/config.go", Line: 10},
+		{ID: "src/config.go#Load", Name: "Load", File: "src/config.go", Line: 30},
+		{ID: "src/main.go#main", Name: "main", File: "src/main.go", Line: 3},
+		{ID: "src/config_test.go#TestParseConfig", Name: "TestParseConfig", File: "src/config_test.go", Line: 8, IsTest: true},
+		{ID: "src/load_test.go#TestLoad", Name: "TestLoad", File: "src/load_test.go", Line: 5, IsTest: true},
+	} {
+		s.Kind, s.Language = "function", "go"
+		seedSymbol(t, m, s)
+		syms[s.Name] = s
+	}
+	for _, e := range [][2]string{
+		{"TestParseConfig", "parseConfig"},
+		{"Load", "parseConfig"},
+		{"main", "Load"},
+		{"TestLoad", "Load"},
+	} {
+		if err := m.InsertCall(&db.Call{CallerID: syms[e[0]].ID, CalleeID: syms[e[1]].ID, File: syms[e[0]].File, Line: syms[e[0]].Line + 1}); err != nil {
+			t.Fatalf("InsertCall: %v", err)
+		}
+	}
+
+	c, buf := freshCmd(t, "testcoverage", runTestCoverage)
+	if err := c.RunE(c, []string{"parseConfig"}); err != nil {
+		t.Fatalf("runTestCoverage returned error: %v", err)
+	}
+	env, count := decodeEnvelope(t, buf.Bytes())
+	var recs []testCoverageRecord
+	_ = json.Unmarshal(env["results"], &recs)
+	if count != 2 {
+		t.Fatalf("count = %d, want 2, env=%s", count, buf.String())
+	}
+	if recs[0].Name != "TestParseConfig" || recs[0].Depth != 1 || len(recs[0].Via) != 0 {
+		t.Errorf("direct test = %+v", recs[0])
+	}
+	if recs[1].Name != "TestLoad" || recs[1].Depth != 2 || strings.Join(recs[1].Via, ",") != "Load" {
+		t.Errorf("transitive test = %+v", recs[1])
+	}
+
+	// Callers leave test functions out unless asked for
+	t.Cleanup(func() { includeTestsFlag, onlyTestsFlag = false, false })
+	for _, tc := range []struct {
+		include, only bool
+		want          string
+	}{
+		{false, false, "Load"},
+		{true, false, "Load,TestParseConfig"},
+		{false, true, "TestParseConfig"},
+	} {
+		includeTestsFlag, onlyTestsFlag = tc.include, tc.only
+		c, buf := freshCmd(t, "callers", runCallers)
+		if err := c.RunE(c, []string{"parseConfig"}); err != nil {
+			t.Fatalf("runCallers returned error: %v", err)
+		}
+		env, _ := decodeEnvelope(t, buf.Bytes())
+		var callers []callerRecord
+		_ = json.Unmarshal(env["results"], &callers)
+		var names []string
+		for _, r := range callers {
+			names = append(names, r.Name)
+		}
+		if got := strings.Join(names, ","); got != tc.want {
+			t.Errorf("include=%v only=%v: callers = %s, want %s", tc.include, tc.only, got, tc.want)
+		}
+	}
+}
+
+func TestJSONSymbol_Owners(t *testing.T) {
+	_, m := setupCodegraphProject(t)
+	syms := map[string]db.Symbol{}
+	for _, s := range []db.Symbol{
+		{ID: "src/store.go#Save", Name: "Save", File: "src/store.go", Line: 10},
+		{ID: "src/api.go#handle", Name: "handle", File: "src/api.go", Line: 5},
+		{ID: "src/api.go#serve", Name: "serve", File: "src/api.go", Line: 20},
+		{ID: "src/cli.go#run", Name: "run", File: "src/cli.go", Line: 3},
+	} {
+		s.Kind, s.Language = "function", "go"
+		seedSymbol(t, m, s)
+		syms[s.Name] = s
+	}
+	for _, e := range [][2]string{{"handle", "Save"}, {"serve", "Save"}, {"run", "Save"}} {
+		if err := m.InsertCall(&db.Call{CallerID: syms[e[0]].ID, CalleeID: syms[e[1]].ID, File: syms[e[0]].File, Line: syms[e[0]].Line + 1}); err != nil {
+			t.Fatalf("InsertCall: %v", err)
+		}
+	}
+	if err := m.SetFileOwners("src/store.go", []string{"@acme/storage"}); err != nil {
+		t.Fatalf("SetFileOwners: %v", err)
+	}
+	if err := m.SetFileOwners("src/api.go", []string{"@acme/api", "@alice"}); err != nil {
+		t.Fatalf("SetFileOwners: %v", err)
+	}
+	if err := m.SetSymbolAuthor(syms["Save"].ID, "Bob"); err != nil {
+		t.Fatalf("SetSymbolAuthor: %v", err)
+	}
+	if err := m.SetSymbolAuthor(syms["run"].ID, "Carol"); err != nil {
+		t.Fatalf("SetSymbolAuthor: %v", err)
 	}
 
+	c, buf := freshCmd(t, "owners", runOwners)
+	if err := c.RunE(c, []string{"Save"}); err != nil {
+		t.Fatalf("runOwners returned error: %v", err)
+	}
 	env, count := decodeEnvelope(t, buf.Bytes())
+	var recs []ownersRecord
+	_ = json.Unmarshal(env["results"], &recs)
 	if count != 1 {
 		t.Fatalf("count = %d, want 1, env=%s", count, buf.String())
 	}
-	var recs []implementationRecord
-	_ = json.Unmarshal(env["results"], &recs)
-	if recs[0].Name != "FileReader" {
-		t.Errorf("record = %+v", recs[0])
+	if r := recs[0]; strings.Join(r.Owners, " ") != "@acme/storage" || r.Author != "Bob" {
+		t.Errorf("ownership = %+v", r)
+	}
+	var got []string
+	for _, oc := range recs[0].CallerOwners {
+		got = append(got, fmt.Sprintf("%s=%d", oc.Owner, oc.Callers))
+	}
+	if want := "@acme/api=2,@alice=2,Carol=1"; strings.Join(got, ",") != want {
+		t.Errorf("caller owners = %s, want %s", strings.Join(got, ","), want)
 	}
-}
 
-func TestJSONSymbol_Types(t *testing.T) {
-	setupCodegraphProject(t)
+	// --owner matches CODEOWNERS owners and authors, case-insensitively
+	t.Cleanup(func() { ownerFlag = "" })
+	for _, tc := range []struct{ owner, want string }{
+		{"@acme/api", "handle,serve"},
+		{"carol", "run"},
+		{"@acme", ""},
+	} {
+		ownerFlag = tc.owner
+		c, buf := freshCmd(t, "callers", runCallers)
+		if err := c.RunE(c, []string{"Save"}); err != nil && (tc.want != "" || ExitCode(err) != ExitNoResults) {
+			t.Fatalf("runCallers returned error: %v", err)
+		}
+		env, _ := decodeEnvelope(t, buf.Bytes())
+		var callers []callerRecord
+		_ = json.Unmarshal(env["results"], &callers)
+		var names []string
+		for _, r := range callers {
+			names = append(names, r.Name)
+		}
+		sort.Strings(names)
+		if got := strings.Join(names, ","); got != tc.want {
+			t.Errorf("--owner=%s: callers = %s, want %s", tc.owner, got, tc.want)
+		}
+	}
+}
 
-	c, buf := freshCmd(t, "types", runTypes)
-	err := c.RunE(c, []string{"Reader"})
-	if err == nil {
-		t.Fatalf("runTypes should return non-nil error while stubbed; envelope=%s", buf.String())
+func TestJSONSymbol_Diff(t *testing.T) {
+	root, m := setupCodegraphProject(t)
+	seedSymbol(t, m, db.Symbol{ID: "src/a.go#main", Name: "main", Kind: "function", File: "src/a.go", Line: 1, Language: "go"})
+	if _, err := snapshot.Create(m, root, "v1"); err != nil {
+		t.Fatalf("snapshot.Create: %v", err)
+	}
+	seedSymbol(t, m, db.Symbol{ID: "src/a.go#helper", Name: "helper", Kind: "function", File: "src/a.go", Line: 5, Language: "go"})
+	if err := m.InsertCall(&db.Call{CallerID: "src/a.go#main", CalleeID: "src/a.go#helper", File: "src/a.go", Line: 2}); err != nil {
+		t.Fatalf("InsertCall: %v", err)
 	}
 
+	c, buf := freshCmd(t, "diff", runDiff)
+	if err := c.RunE(c, []string{"v1"}); err != nil {
+		t.Fatalf("runDiff returned error: %v", err)
+	}
 	env, count := decodeEnvelope(t, buf.Bytes())
-	if count != 0 {
-		t.Errorf("count = %d, want 0 (types stub)", count)
+	if string(env["query"]) != `"v1..current"` {
+		t.Errorf("query = %s, want \"v1..current\"", env["query"])
 	}
-	if string(env["query"]) != `"Reader"` {
-		t.Errorf("query raw = %s", string(env["query"]))
+	var recs []diffRecord
+	_ = json.Unmarshal(env["results"], &recs)
+	if count != 2 || recs[0].Change != "added" || recs[0].Name != "helper" ||
+		recs[1].Change != "call_added" || recs[1].Caller != "main" || recs[1].Callee != "helper" {
+		t.Errorf("diff = %+v", recs)
 	}
-	var cmdName string
-	_ = json.Unmarshal(env["command"], &cmdName)
-	if cmdName != "types" {
-		t.Errorf("command = %q, want types", cmdName)
+
+	c, buf = freshCmd(t, "diff", runDiff)
+	if err := c.RunE(c, []string{"v0"}); err == nil {
+		t.Fatal("diff against a missing snapshot succeeded")
+	}
+	env, _ = decodeEnvelope(t, buf.Bytes())
+	var errs []EnvelopeError
+	_ = json.Unmarshal(env["errors"], &errs)
+	if len(errs) != 1 || errs[0].Code != "diff_failed" {
+		t.Errorf("errors = %+v, want diff_failed", errs)
+	}
+}
+
+func TestJSONSymbol_RenameCheck(t *testing.T) {
+	root, m := setupCodegraphProject(t)
+	src := "package main\n\nfunc store() {}\n\nfunc helper() { store() }\n\nvar name = \"store\"\n"
+	if err := os.WriteFile(filepath.Join(root, "main.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(root, "main.go")
+	seedSymbol(t, m, db.Symbol{ID: "main.go#store", Name: "store", Kind: "function", File: file, Line: 3, Language: "go"})
+	seedSymbol(t, m, db.Symbol{ID: "main.go#helper", Name: "helper", Kind: "function", File: file, Line: 5, Language: "go"})
+	if err := m.InsertCall(&db.Call{CallerID: "main.go#helper", CalleeID: "main.go#store", File: file, Line: 5, Column: 16}); err != nil {
+		t.Fatalf("InsertCall: %v", err)
+	}
+
+	c, buf := freshCmd(t, "rename-check", runRenameCheck)
+	if err := c.RunE(c, []string{"store", "helper"}); err != nil {
+		t.Fatalf("runRenameCheck returned error: %v", err)
+	}
+	env, _ := decodeEnvelope(t, buf.Bytes())
+	var locs []renameLocation
+	_ = json.Unmarshal(env["results"], &locs)
+	var got []string
+	for _, l := range locs {
+		got = append(got, fmt.Sprintf("%s:%d:%s", l.File, l.Line, l.Category))
+	}
+	want := "main.go:3:definition,main.go:5:call,main.go:5:conflict,main.go:7:string"
+	if strings.Join(got, ",") != want {
+		t.Errorf("locations = %s, want %s", strings.Join(got, ","), want)
+	}
+	if locs[1].Column != 17 || locs[1].Symbol != "helper" || locs[1].Text != "func helper() { store() }" {
+		t.Errorf("call location = %+v", locs[1])
 	}
 
+	c, buf = freshCmd(t, "rename-check", runRenameCheck)
+	if err := c.RunE(c, []string{"store", "not-valid"}); err == nil {
+		t.Fatal("rename to an invalid identifier succeeded")
+	}
+	env, _ = decodeEnvelope(t, buf.Bytes())
 	var errs []EnvelopeError
-	if uerr := json.Unmarshal(env["errors"], &errs); uerr != nil {
-		t.Fatalf("errors unmarshal: %v", uerr)
+	_ = json.Unmarshal(env["errors"], &errs)
+	if len(errs) != 1 || errs[0].Code != "rename_check_failed" {
+		t.Errorf("errors = %+v, want rename_check_failed", errs)
 	}
-	if len(errs) != 1 || errs[0].Code != "not_implemented" {
-		t.Errorf("errors = %+v, want one entry with code=not_implemented", errs)
+}
+
+func TestJSONSymbol_GrepCalls(t *testing.T) {
+	root, m := setupCodegraphProject(t)
+	src := "package main\n\nfunc MustParse() {}\nfunc MustLoad() {}\n\n// MustParse is not called here\nfunc main() {\n\tMustParse()\n\tMustLoad()\n}\n"
+	file := filepath.Join(root, "main.go")
+	if err := os.WriteFile(file, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []db.Symbol{
+		{ID: "main.go#MustParse", Name: "MustParse", Line: 3},
+		{ID: "main.go#MustLoad", Name: "MustLoad", Line: 4},
+		{ID: "main.go#main", Name: "main", Line: 7},
+	} {
+		s.Kind, s.File, s.Language = "function", file, "go"
+		seedSymbol(t, m, s)
+	}
+	for _, call := range []db.Call{
+		{CallerID: "main.go#main", CalleeID: "main.go#MustParse", File: file, Line: 8, Column: 1},
+		{CallerID: "main.go#main", CalleeID: "main.go#MustLoad", File: file, Line: 9, Column: 1},
+	} {
+		if err := m.InsertCall(&call); err != nil {
+			t.Fatalf("InsertCall: %v", err)
+		}
+	}
+	t.Cleanup(func() { grepCallsGlobFlag = false })
+
+	for _, tc := range []struct {
+		pattern string
+		glob    bool
+		want    string
+	}{
+		{"^Must", false, "main.go:8:2:MustParse,main.go:9:2:MustLoad"},
+		{"Load$", false, "main.go:9:2:MustLoad"},
+		{"*Parse", true, "main.go:8:2:MustParse"},
+		{"Parse", true, ""},
+	} {
+		grepCallsGlobFlag = tc.glob
+		c, buf := freshCmd(t, "grep-calls", runGrepCalls)
+		if err := c.RunE(c, []string{tc.pattern}); err != nil && (tc.want != "" || ExitCode(err) != ExitNoResults) {
+			t.Fatalf("%s: runGrepCalls returned error: %v", tc.pattern, err)
+		}
+		env, _ := decodeEnvelope(t, buf.Bytes())
+		var recs []grepCallRecord
+		_ = json.Unmarshal(env["results"], &recs)
+		var got []string
+		for _, r := range recs {
+			got = append(got, fmt.Sprintf("%s:%d:%d:%s", r.File, r.Line, r.Column, r.Callee))
+		}
+		if strings.Join(got, ",") != tc.want {
+			t.Errorf("%s: got %v, want %s", tc.pattern, got, tc.want)
+		}
+		if len(recs) > 0 && (recs[0].Caller != "main" || recs[0].Text == "") {
+			t.Errorf("%s: record = %+v", tc.pattern, recs[0])
+		}
+	}
+
+	grepCallsGlobFlag = false
+	c, buf := freshCmd(t, "grep-calls", runGrepCalls)
+	if err := c.RunE(c, []string{"Must("}); err == nil {
+		t.Fatal("expected error for an invalid pattern")
+	}
+	env, _ := decodeEnvelope(t, buf.Bytes())
+	if !strings.Contains(string(env["errors"]), "invalid_flag") {
+		t.Errorf("errors = %s, want invalid_flag", env["errors"])
+	}
+}
+
+func TestJSONSymbol_Entrypoints(t *testing.T) {
+	_, m := setupCodegraphProject(t)
+	for _, s := range []db.Symbol{
+		{ID: "cmd/main.go#main", Name: "main", File: "cmd/main.go", Line: 9},
+		{ID: "cmd/serve.go#runServe", Name: "runServe", File: "cmd/serve.go", Line: 3},
+		{ID: "api/api.go#Serve", Name: "Serve", File: "api/api.go", Line: 5},
+		{ID: "api/api.go#helper", Name: "helper", File: "api/api.go", Line: 7},
+	} {
+		s.Kind, s.Language = "function", "go"
+		seedSymbol(t, m, s)
+	}
+	for id, typ := range map[string]string{
+		"cmd/main.go#main":      indexer.EntrypointMain,
+		"cmd/serve.go#runServe": indexer.EntrypointCLI,
+		"api/api.go#Serve":      indexer.EntrypointAPI,
+	} {
+		if err := m.SetEntrypoint(id, typ); err != nil {
+			t.Fatalf("SetEntrypoint: %v", err)
+		}
+	}
+	t.Cleanup(func() { entrypointsTypeFlag = "" })
+
+	for _, tc := range []struct {
+		types string
+		want  string
+	}{
+		{"", "api:Serve,main:main,cli:runServe"},
+		{"main,cli", "main:main,cli:runServe"},
+	} {
+		entrypointsTypeFlag = tc.types
+		c, buf := freshCmd(t, "entrypoints", runEntrypoints)
+		if err := c.RunE(c, nil); err != nil {
+			t.Fatalf("%q: runEntrypoints returned error: %v", tc.types, err)
+		}
+		env, _ := decodeEnvelope(t, buf.Bytes())
+		var recs []entrypointRecord
+		_ = json.Unmarshal(env["results"], &recs)
+		var got []string
+		for _, r := range recs {
+			got = append(got, r.Type+":"+r.Name)
+		}
+		if strings.Join(got, ",") != tc.want {
+			t.Errorf("%q: got %v, want %s", tc.types, got, tc.want)
+		}
+	}
+
+	entrypointsTypeFlag = "test"
+	c, buf := freshCmd(t, "entrypoints", runEntrypoints)
+	if err := c.RunE(c, nil); err == nil {
+		t.Fatal("expected error for an invalid --type")
+	}
+	env, _ := decodeEnvelope(t, buf.Bytes())
+	if !strings.Contains(string(env["errors"]), "invalid_flag") {
+		t.Errorf("errors = %s, want invalid_flag", env["errors"])
+	}
+}
+
+func TestJSONSymbol_Reachable(t *testing.T) {
+	_, m := setupCodegraphProject(t)
+	for _, s := range []db.Symbol{
+		{ID: "cmd/main.go#main", Name: "main", File: "cmd/main.go", Line: 3},
+		{ID: "lib/lib.go#Load", Name: "Load", File: "lib/lib.go", Line: 3},
+		{ID: "lib/lib.go#parse", Name: "parse", File: "lib/lib.go", Line: 7},
+		{ID: "lib/lib.go#Dump", Name: "Dump", File: "lib/lib.go", Line: 11},
+		{ID: "web/web.go#Handle", Name: "Handle", File: "web/web.go", Line: 3},
+	} {
+		s.Kind, s.Language = "function", "go"
+		seedSymbol(t, m, s)
+	}
+	for _, call := range []db.Call{
+		{CallerID: "cmd/main.go#main", CalleeID: "lib/lib.go#Load", File: "cmd/main.go", Line: 4},
+		{CallerID: "lib/lib.go#Load", CalleeID: "lib/lib.go#parse", File: "lib/lib.go", Line: 4},
+		{CallerID: "web/web.go#Handle", CalleeID: "lib/lib.go#Dump", File: "web/web.go", Line: 4},
+	} {
+		if err := m.InsertCall(&call); err != nil {
+			t.Fatalf("InsertCall: %v", err)
+		}
+	}
+	if err := m.SetEntrypoint("web/web.go#Handle", indexer.EntrypointHTTP); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		reachableInvertFlag, reachableEntrypointsFlag, reachableDepthFlag = false, "", 0
+	})
+
+	for _, tc := range []struct {
+		args        []string
+		entrypoints string
+		invert      bool
+		depth       int
+		want        string
+	}{
+		{[]string{"main"}, "", false, 0, "main@0,Load@1,parse@2"},
+		{[]string{"main"}, "", false, 1, "main@0,Load@1"},
+		{[]string{"main"}, "", true, 0, "Dump,Handle"},
+		{[]string{"main"}, "http", true, 0, ""},
+		{nil, "http", false, 0, "Dump@1,Handle@0"},
+	} {
+		reachableEntrypointsFlag, reachableInvertFlag, reachableDepthFlag = tc.entrypoints, tc.invert, tc.depth
+		c, buf := freshCmd(t, "reachable", runReachable)
+		if err := c.RunE(c, tc.args); err != nil {
+			t.Fatalf("%+v: runReachable returned error: %v", tc, err)
+		}
+		env, _ := decodeEnvelope(t, buf.Bytes())
+		var recs []reachableRecord
+		_ = json.Unmarshal(env["results"], &recs)
+		var got []string
+		for _, r := range recs {
+			if r.Depth != nil {
+				got = append(got, fmt.Sprintf("%s@%d", r.Name, *r.Depth))
+			} else {
+				got = append(got, r.Name)
+			}
+		}
+		if strings.Join(got, ",") != tc.want {
+			t.Errorf("%+v: got %v, want %s", tc, got, tc.want)
+		}
+	}
+
+	reachableEntrypointsFlag, reachableInvertFlag, reachableDepthFlag = "", false, 0
+	c, buf := freshCmd(t, "reachable", runReachable)
+	if err := c.RunE(c, nil); err == nil {
+		t.Fatal("expected an error without roots")
+	}
+	env, _ := decodeEnvelope(t, buf.Bytes())
+	if !strings.Contains(string(env["errors"]), "invalid_root") {
+		t.Errorf("errors = %s, want invalid_root", env["errors"])
+	}
+}
+
+func TestJSONSymbol_Flows(t *testing.T) {
+	root, m := setupCodegraphProject(t)
+	src := "package web\n\nfunc handle(r *http.Request) {\n\tid := r.FormValue(\"id\")\n\tload(id)\n}\n\nfunc load(id string) {\n\tquery(id)\n}\n\nfunc query(id string) {\n\tdb.Exec(\"DELETE \" + id)\n}\n\nfunc audit() {\n\tquery(\"\")\n}\n"
+	file := filepath.Join(root, "web", "web.go")
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	end := func(line int) *int { return &line }
+	for _, s := range []db.Symbol{
+		{ID: "web/web.go#handle", Name: "handle", Line: 3, EndLine: end(6)},
+		{ID: "web/web.go#load", Name: "load", Line: 8, EndLine: end(10)},
+		{ID: "web/web.go#query", Name: "query", Line: 12, EndLine: end(14)},
+		{ID: "web/web.go#audit", Name: "audit", Line: 16, EndLine: end(18)},
+	} {
+		s.Kind, s.File, s.Language = "function", file, "go"
+		seedSymbol(t, m, s)
+	}
+	for _, call := range []db.Call{
+		{CallerID: "web/web.go#handle", CalleeID: "web/web.go#load", File: file, Line: 5},
+		{CallerID: "web/web.go#load", CalleeID: "web/web.go#query", File: file, Line: 9},
+		{CallerID: "web/web.go#audit", CalleeID: "web/web.go#query", File: file, Line: 17},
+	} {
+		if err := m.InsertCall(&call); err != nil {
+			t.Fatalf("InsertCall: %v", err)
+		}
+	}
+	writePatterns := func(name, content string) string {
+		path := filepath.Join(root, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	flowsSourcesFlag = writePatterns("sources.txt", "# untrusted input\nRequest.FormValue\n\naudit\n")
+	flowsSinksFlag = writePatterns("sinks.txt", "sql.Exec\n")
+	t.Cleanup(func() { flowsSourcesFlag, flowsSinksFlag, flowsDepthFlag = "", "", 0 })
+
+	for _, tc := range []struct {
+		depth int
+		want  string
+	}{
+		{0, "Request.FormValue@4:handle>load>query:sql.Exec@13,audit@16:audit>query:sql.Exec@13"},
+		{1, "audit@16:audit>query:sql.Exec@13"},
+	} {
+		flowsDepthFlag = tc.depth
+		c, buf := freshCmd(t, "flows", runFlows)
+		if err := c.RunE(c, nil); err != nil {
+			t.Fatalf("runFlows returned error: %v", err)
+		}
+		env, _ := decodeEnvelope(t, buf.Bytes())
+		var recs []flowRecord
+		_ = json.Unmarshal(env["results"], &recs)
+		var got []string
+		for _, r := range recs {
+			var path []string
+			for _, step := range r.Path {
+				path = append(path, step.Name)
+			}
+			got = append(got, fmt.Sprintf("%s@%d:%s:%s@%d", r.Source.Pattern, r.Source.Line, strings.Join(path, ">"), r.Sink.Pattern, r.Sink.Line))
+		}
+		if strings.Join(got, ",") != tc.want {
+			t.Errorf("depth %d: flows = %s, want %s", tc.depth, strings.Join(got, ","), tc.want)
+		}
+	}
+
+	flowsSinksFlag = writePatterns("sinks.txt", "# nothing\n")
+	c, buf := freshCmd(t, "flows", runFlows)
+	if err := c.RunE(c, nil); err == nil {
+		t.Fatal("expected an error for an empty sinks file")
+	}
+	env, _ := decodeEnvelope(t, buf.Bytes())
+	if !strings.Contains(string(env["errors"]), "invalid_flag") {
+		t.Errorf("errors = %s, want invalid_flag", env["errors"])
+	}
+}
+
+func TestJSONSymbol_Concurrency(t *testing.T) {
+	root, m := setupCodegraphProject(t)
+	src := "package main\n\nfunc handle(ch chan int) {\n\tgo worker(ch)\n\thelper(ch)\n}\n\nfunc helper(ch chan int) {\n\tch <- 1\n}\n\nfunc worker(ch chan int) {\n\t<-ch\n}\n"
+	file := filepath.Join(root, "main.go")
+	if err := os.WriteFile(file, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []db.Symbol{
+		{ID: "main.go#handle", Name: "handle", Line: 3},
+		{ID: "main.go#helper", Name: "helper", Line: 8},
+		{ID: "main.go#worker", Name: "worker", Line: 12},
+	} {
+		s.Kind, s.File, s.Language = "function", file, "go"
+		seedSymbol(t, m, s)
+	}
+	if err := m.InsertCall(&db.Call{CallerID: "main.go#handle", CalleeID: "main.go#helper", File: file, Line: 5, Column: 1}); err != nil {
+		t.Fatalf("InsertCall: %v", err)
+	}
+	worker := "main.go#worker"
+	for _, site := range []db.ConcurrencySite{
+		{SymbolID: "main.go#handle", Op: db.ConcurrencyGo, Target: "worker", TargetID: &worker, Line: 4, Column: 1},
+		{SymbolID: "main.go#helper", Op: db.ConcurrencySend, Target: "ch", Line: 9, Column: 1},
+		{SymbolID: "main.go#worker", Op: db.ConcurrencyReceive, Target: "ch", Line: 13, Column: 1},
+	} {
+		site.File = file
+		if err := m.InsertConcurrencySite(&site); err != nil {
+			t.Fatalf("InsertConcurrencySite: %v", err)
+		}
+	}
+	t.Cleanup(func() { concurrencyOpFlag, concurrencyTransitiveFlag = "", false })
+
+	for _, tc := range []struct {
+		args       []string
+		op         string
+		transitive bool
+		want       string
+	}{
+		{nil, "", false, "handle:go:worker:4,helper:send:ch:9,worker:receive:ch:13"},
+		{[]string{"handle"}, "", false, "handle:go:worker:4"},
+		{[]string{"handle"}, "", true, "handle:go:worker:4,helper:send:ch:9,worker:receive:ch:13"},
+		{[]string{"handle"}, "receive", true, "worker:receive:ch:13"},
+	} {
+		concurrencyOpFlag, concurrencyTransitiveFlag = tc.op, tc.transitive
+		c, buf := freshCmd(t, "concurrency", runConcurrency)
+		if err := c.RunE(c, tc.args); err != nil {
+			t.Fatalf("%v: runConcurrency returned error: %v", tc.args, err)
+		}
+		env, _ := decodeEnvelope(t, buf.Bytes())
+		var recs []concurrencyRecord
+		_ = json.Unmarshal(env["results"], &recs)
+		var got []string
+		for _, r := range recs {
+			got = append(got, fmt.Sprintf("%s:%s:%s:%d", r.Function, r.Op, r.Target, r.Line))
+		}
+		if strings.Join(got, ",") != tc.want {
+			t.Errorf("%v op=%q transitive=%v: got %v, want %s", tc.args, tc.op, tc.transitive, got, tc.want)
+		}
+	}
+
+	concurrencyOpFlag, concurrencyTransitiveFlag = "spawn", false
+	c, buf := freshCmd(t, "concurrency", runConcurrency)
+	if err := c.RunE(c, nil); err == nil {
+		t.Fatal("expected error for an invalid --op")
+	}
+	env, _ := decodeEnvelope(t, buf.Bytes())
+	var errs []EnvelopeError
+	_ = json.Unmarshal(env["errors"], &errs)
+	if len(errs) != 1 || errs[0].Code != "invalid_flag" {
+		t.Errorf("errors = %+v, want invalid_flag", errs)
+	}
+}
+
+func TestJSONSymbol_Routes(t *testing.T) {
+	root, m := setupCodegraphProject(t)
+	file := filepath.Join(root, "main.go")
+	src := "package main\n\nfunc main() {\n\thttp.HandleFunc(\"GET /users\", listUsers)\n\thttp.HandleFunc(\"/health\", health)\n}\n\nfunc listUsers() {}\nfunc health() {}\n"
+	if err := os.WriteFile(file, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []db.Symbol{
+		{ID: "main.go#listUsers", Name: "listUsers", Line: 8},
+		{ID: "main.go#health", Name: "health", Line: 9},
+	} {
+		s.Kind, s.File, s.Language = "function", file, "go"
+		seedSymbol(t, m, s)
+	}
+	listUsers, health := "main.go#listUsers", "main.go#health"
+	for _, r := range []db.Route{
+		{Method: "GET", Path: "/users", Handler: "listUsers", HandlerID: &listUsers, Line: 4},
+		{Method: db.RouteAnyMethod, Path: "/health", Handler: "health", HandlerID: &health, Line: 5},
+		{Method: "POST", Path: "/users", Handler: "createUser", Line: 6},
+	} {
+		r.Framework, r.Language, r.File = "net/http", "go", file
+		if err := m.InsertRoute(&r); err != nil {
+			t.Fatalf("InsertRoute: %v", err)
+		}
+	}
+	t.Cleanup(func() { routesMethodFlag = "" })
+
+	for _, tc := range []struct {
+		args   []string
+		method string
+		want   string
+	}{
+		{nil, "", "GET /users listUsers main.go:8,ANY /health health main.go:9,POST /users createUser :0"},
+		{[]string{"/users"}, "", "GET /users listUsers main.go:8,POST /users createUser :0"},
+		{nil, "get", "GET /users listUsers main.go:8,ANY /health health main.go:9"},
+	} {
+		routesMethodFlag = tc.method
+		c, buf := freshCmd(t, "routes", runRoutes)
+		if err := c.RunE(c, tc.args); err != nil {
+			t.Fatalf("%v: runRoutes returned error: %v", tc.args, err)
+		}
+		env, _ := decodeEnvelope(t, buf.Bytes())
+		var recs []routeRecord
+		_ = json.Unmarshal(env["results"], &recs)
+		var got []string
+		for _, r := range recs {
+			got = append(got, fmt.Sprintf("%s %s %s %s:%d", r.Method, r.Path, r.Handler, r.HandlerFile, r.HandlerLine))
+		}
+		if strings.Join(got, ",") != tc.want {
+			t.Errorf("%v method=%q: got %v, want %s", tc.args, tc.method, got, tc.want)
+		}
+	}
+
+	// A route is a caller of its handler
+	c, buf := freshCmd(t, "callers", runCallers)
+	if err := c.RunE(c, []string{"listUsers"}); err != nil {
+		t.Fatalf("runCallers returned error: %v", err)
+	}
+	env, _ := decodeEnvelope(t, buf.Bytes())
+	var callers []callerRecord
+	_ = json.Unmarshal(env["results"], &callers)
+	if len(callers) != 1 || callers[0].Name != "GET /users" || callers[0].Kind != "route" || callers[0].Line != 4 {
+		t.Errorf("callers = %+v, want the GET /users route", callers)
+	}
+}
+
+func TestJSONSymbol_CallersGRPC(t *testing.T) {
+	root, m := setupCodegraphProject(t)
+	for _, s := range []db.Symbol{
+		{ID: "users.proto#UserService.GetUser", Name: "GetUser", Kind: "method", Scope: "UserService", File: "users.proto", Line: 4, Language: "proto"},
+		{ID: "users.proto#AdminService.GetUser", Name: "GetUser", Kind: "method", Scope: "AdminService", File: "users.proto", Line: 8, Language: "proto"},
+		{ID: "server/server.go#GetUser", Name: "GetUser", Kind: "method", File: "server/server.go", Line: 7, Language: "go"},
+		{ID: "app.py#lookup", Name: "lookup", Kind: "function", File: "app.py", Line: 3, Language: "python"},
+	} {
+		s.File = filepath.Join(root, s.File)
+		seedSymbol(t, m, s)
+	}
+	if err := m.InsertTypeHierarchy(&db.TypeHierarchy{ChildID: "server/server.go#GetUser", ParentID: "users.proto#UserService.GetUser", Relationship: "implements"}); err != nil {
+		t.Fatalf("InsertTypeHierarchy: %v", err)
+	}
+	if err := m.InsertCall(&db.Call{CallerID: "app.py#lookup", CalleeID: "users.proto#UserService.GetUser", File: filepath.Join(root, "app.py"), Line: 5}); err != nil {
+		t.Fatalf("InsertCall: %v", err)
+	}
+	t.Cleanup(func() { callersKindFlag = "" })
+
+	for _, tc := range []struct {
+		symbol, kind, want string
+	}{
+		{"UserService.GetUser", "", "GetUser server server/server.go:7,lookup function app.py:5"},
+		{"AdminService.GetUser", "", ""},
+		{"UserService.GetUser", "function", "lookup function app.py:5"},
+	} {
+		callersKindFlag = tc.kind
+		c, buf := freshCmd(t, "callers", runCallers)
+		if err := c.RunE(c, []string{tc.symbol}); err != nil && (tc.want != "" || ExitCode(err) != ExitNoResults) {
+			t.Fatalf("%s: runCallers returned error: %v", tc.symbol, err)
+		}
+		env, _ := decodeEnvelope(t, buf.Bytes())
+		var recs []callerRecord
+		_ = json.Unmarshal(env["results"], &recs)
+		var got []string
+		for _, r := range recs {
+			got = append(got, fmt.Sprintf("%s %s %s:%d", r.Name, r.Kind, r.File, r.Line))
+		}
+		if strings.Join(got, ",") != tc.want {
+			t.Errorf("%s kind=%q: got %v, want %s", tc.symbol, tc.kind, got, tc.want)
+		}
+	}
+}
+
+func TestJSONSymbol_Components(t *testing.T) {
+	root, m := setupCodegraphProject(t)
+	for _, s := range []db.Symbol{
+		{ID: "web/App.tsx#App", Name: "App", Kind: "function", File: "web/App.tsx", Line: 3, Language: "typescriptreact"},
+		{ID: "web/Page.tsx#Page", Name: "Page", Kind: "function", File: "web/Page.tsx", Line: 1, Language: "typescriptreact"},
+		{ID: "web/Button.tsx#Button", Name: "Button", Kind: "function", File: "web/Button.tsx", Line: 1, Language: "typescriptreact"},
+	} {
+		s.File = filepath.Join(root, s.File)
+		seedSymbol(t, m, s)
+		if err := m.InsertComponent(s.ID, db.FrameworkReact); err != nil {
+			t.Fatalf("InsertComponent: %v", err)
+		}
+	}
+	page, button := "web/Page.tsx#Page", "web/Button.tsx#Button"
+	for _, r := range []db.Render{
+		{ParentID: "web/App.tsx#App", Child: "Page", ChildID: &page, File: "web/App.tsx", Line: 4},
+		{ParentID: "web/App.tsx#App", Child: "Button", ChildID: &button, File: "web/App.tsx", Line: 5},
+		{ParentID: "web/Page.tsx#Page", Child: "Button", ChildID: &button, File: "web/Page.tsx", Line: 2},
+		{ParentID: "web/Page.tsx#Page", Child: "Button", ChildID: &button, File: "web/Page.tsx", Line: 3},
+		{ParentID: "web/Page.tsx#Page", Child: "Mui.Grid", File: "web/Page.tsx", Line: 2},
+	} {
+		r.File = filepath.Join(root, r.File)
+		if err := m.InsertRender(&r); err != nil {
+			t.Fatalf("InsertRender: %v", err)
+		}
+	}
+	t.Cleanup(func() { componentsReverseFlag = false })
+
+	c, buf := freshCmdNoArgs(t, "components", runComponents)
+	if err := c.RunE(c, nil); err != nil {
+		t.Fatalf("runComponents returned error: %v", err)
+	}
+	env, _ := decodeEnvelope(t, buf.Bytes())
+	var comps []componentRecord
+	_ = json.Unmarshal(env["results"], &comps)
+	var got []string
+	for _, r := range comps {
+		got = append(got, fmt.Sprintf("%s [%s] by [%s]", r.Name, strings.Join(r.Renders, " "), strings.Join(r.RenderedBy, " ")))
+	}
+	want := "App [Page Button] by [],Button [] by [App Page],Page [Button Mui.Grid] by [App]"
+	if strings.Join(got, ",") != want {
+		t.Errorf("components: got %v, want %s", got, want)
+	}
+
+	for _, tc := range []struct {
+		component string
+		reverse   bool
+		want      string
+	}{
+		{"App", false, "1 App>Page,2 Page>Button,2 Page>Mui.Grid,1 App>Button"},
+		{"Button", true, "1 App>Button,1 Page>Button,2 App>Page"},
+	} {
+		componentsReverseFlag = tc.reverse
+		c, buf := freshCmd(t, "components", runComponents)
+		if err := c.RunE(c, []string{tc.component}); err != nil {
+			t.Fatalf("%s: runComponents returned error: %v", tc.component, err)
+		}
+		env, _ := decodeEnvelope(t, buf.Bytes())
+		var recs []renderRecord
+		_ = json.Unmarshal(env["results"], &recs)
+		got = nil
+		for _, r := range recs {
+			got = append(got, fmt.Sprintf("%d %s>%s", r.Depth, r.Parent, r.Child))
+		}
+		if strings.Join(got, ",") != tc.want {
+			t.Errorf("%s reverse=%v: got %v, want %s", tc.component, tc.reverse, got, tc.want)
+		}
+	}
+}
+
+func TestJSONSymbol_SearchTags(t *testing.T) {
+	_, m := setupCodegraphProject(t)
+	for _, name := range []string{"oldLogin", "login"} {
+		seedSymbol(t, m, db.Symbol{
+			ID: "src/auth.py#" + name, Name: name, Kind: "function",
+			File: "src/auth.py", Line: 1, Language: "python",
+		})
+	}
+	for _, tag := range []*db.Tag{
+		{SymbolID: "src/auth.py#oldLogin", Name: "deprecated", Tag: db.TagDeprecated, File: "src/auth.py", Line: 1},
+		{SymbolID: "src/auth.py#login", Name: "app.route", Tag: "route", Args: `"/login"`, File: "src/auth.py", Line: 5},
+	} {
+		if err := m.InsertTag(tag); err != nil {
+			t.Fatalf("InsertTag: %v", err)
+		}
+	}
+	t.Cleanup(func() { searchTagFlag = "" })
+
+	for _, tc := range []struct {
+		tag  string
+		args []string
+		want string
+	}{
+		{"deprecated", nil, "oldLogin"},
+		{"@app.route", nil, "login"},
+		{"Route", []string{"login"}, "login"},
+		{"deprecated", []string{"logout"}, ""},
+	} {
+		searchTagFlag = tc.tag
+		c, buf := freshCmdNoArgs(t, "search", runSearch)
+		// Finding nothing exits with ExitNoResults
+		if err := c.RunE(c, tc.args); err != nil && (tc.want != "" || ExitCode(err) != ExitNoResults) {
+			t.Fatalf("--tag %s: runSearch returned error: %v", tc.tag, err)
+		}
+		env, _ := decodeEnvelope(t, buf.Bytes())
+		var recs []searchRecord
+		_ = json.Unmarshal(env["results"], &recs)
+		var got []string
+		for _, r := range recs {
+			got = append(got, r.Name)
+		}
+		if strings.Join(got, ",") != tc.want {
+			t.Errorf("--tag %s %v: got %v, want %s", tc.tag, tc.args, got, tc.want)
+		}
+	}
+}
+
+func TestJSONSymbol_Deprecated(t *testing.T) {
+	_, m := setupCodegraphProject(t)
+	for _, s := range []db.Symbol{
+		{ID: "old/old.go#Login", Name: "Login", File: "old/old.go", Line: 5},
+		{ID: "old/old.go#Wrap", Name: "Wrap", File: "old/old.go", Line: 9},
+		{ID: "cmd/main.go#main", Name: "main", File: "cmd/main.go", Line: 3},
+		{ID: "api/api.go#Serve", Name: "Serve", File: "api/api.go", Line: 3},
+	} {
+		s.Kind, s.Language = "function", "go"
+		seedSymbol(t, m, s)
+	}
+	for _, tag := range []*db.Tag{
+		{SymbolID: "old/old.go#Login", Name: "Deprecated", Tag: db.TagDeprecated, Args: "use NewLogin.", File: "old/old.go", Line: 4},
+		{SymbolID: "old/old.go#Wrap", Name: "Deprecated", Tag: db.TagDeprecated, File: "old/old.go", Line: 8},
+	} {
+		if err := m.InsertTag(tag); err != nil {
+			t.Fatalf("InsertTag: %v", err)
+		}
+	}
+	for _, c := range []*db.Call{
+		{CallerID: "cmd/main.go#main", CalleeID: "old/old.go#Login", File: "cmd/main.go", Line: 4, Column: 1},
+		{CallerID: "api/api.go#Serve", CalleeID: "old/old.go#Wrap", File: "api/api.go", Line: 4, Column: 1},
+		{CallerID: "old/old.go#Wrap", CalleeID: "old/old.go#Login", File: "old/old.go", Line: 10, Column: 1},
+	} {
+		if err := m.InsertCall(c); err != nil {
+			t.Fatalf("InsertCall: %v", err)
+		}
+	}
+
+	c, buf := freshCmdNoArgs(t, "deprecated", runDeprecated)
+	if err := c.RunE(c, nil); err != nil {
+		t.Fatalf("runDeprecated returned error: %v", err)
+	}
+	env, count := decodeEnvelope(t, buf.Bytes())
+	if count != 2 {
+		t.Fatalf("count = %d, want 2 (calls from deprecated Wrap left out)", count)
+	}
+	var recs []deprecatedRecord
+	if err := json.Unmarshal(env["results"], &recs); err != nil {
+		t.Fatalf("results unmarshal: %v", err)
+	}
+	if recs[0].Package != "api" || recs[0].Symbol != "Wrap" || recs[0].Notice != "" {
+		t.Errorf("recs[0] = %+v", recs[0])
+	}
+	if recs[1].Package != "cmd" || recs[1].Caller != "main" || recs[1].Notice != "use NewLogin." || recs[1].Column != 2 {
+		t.Errorf("recs[1] = %+v", recs[1])
+	}
+
+	t.Cleanup(func() { deprecatedFailFlag = false })
+	deprecatedFailFlag = true
+	c, buf = freshCmdNoArgs(t, "deprecated", runDeprecated)
+	if err := c.RunE(c, nil); err == nil {
+		t.Fatal("expected --fail-on-use to fail")
+	}
+	if _, count := decodeEnvelope(t, buf.Bytes()); count != 2 {
+		t.Errorf("count with --fail-on-use = %d, want 2", count)
+	}
+}
+
+func TestJSONSymbol_CallersPaging(t *testing.T) {
+	_, m := setupCodegraphProject(t)
+	callee := db.Symbol{
+		ID: "src/log.go#Log", Name: "Log", Kind: "function",
+		File: "src/log.go", Line: 1, Language: "go",
+	}
+	seedSymbol(t, m, callee)
+	// Call sites are seeded in an order that differs from both name and file order
+	for i, name := range []string{"beta", "gamma", "alpha"} {
+		caller := db.Symbol{
+			ID: "src/z.go#" + name, Name: name, Kind: "function",
+			File: "src/z.go", Line: 10 * (i + 1), Language: "go",
+		}
+		seedSymbol(t, m, caller)
+		if err := m.InsertCall(&db.Call{
+			CallerID: caller.ID, CalleeID: callee.ID,
+			File: "src/z.go", Line: 10*(i+1) + 1, Column: 2,
+		}); err != nil {
+			t.Fatalf("InsertCall: %v", err)
+		}
+	}
+	t.Cleanup(func() { callersPageFlags = pageFlags{} })
+
+	for _, tc := range []struct {
+		page pageFlags
+		want string
+	}{
+		{pageFlags{sort: "name"}, "alpha,beta,gamma"},
+		{pageFlags{sort: "name", offset: 1, limit: 1}, "beta"},
+		{pageFlags{sort: "line", offset: 2}, "alpha"},
+		{pageFlags{limit: 2}, "beta,gamma"},
+	} {
+		callersPageFlags = tc.page
+		c, buf := freshCmd(t, "callers", runCallers)
+		if err := c.RunE(c, []string{"Log"}); err != nil {
+			t.Fatalf("%+v: runCallers returned error: %v", tc.page, err)
+		}
+		env, _ := decodeEnvelope(t, buf.Bytes())
+		var recs []callerRecord
+		_ = json.Unmarshal(env["results"], &recs)
+		var got []string
+		for _, r := range recs {
+			got = append(got, r.Name)
+		}
+		if strings.Join(got, ",") != tc.want {
+			t.Errorf("%+v: got %v, want %s", tc.page, got, tc.want)
+		}
+	}
+
+	callersPageFlags = pageFlags{sort: "size"}
+	c, buf := freshCmd(t, "callers", runCallers)
+	if err := c.RunE(c, []string{"Log"}); err == nil {
+		t.Fatal("expected error for invalid --sort")
+	}
+	env, _ := decodeEnvelope(t, buf.Bytes())
+	if !strings.Contains(string(env["errors"]), "invalid_flag") {
+		t.Errorf("errors = %s, want invalid_flag", env["errors"])
+	}
+}
+
+func TestJSONSymbol_Callees(t *testing.T) {
+	_, m := setupCodegraphProject(t)
+	caller := db.Symbol{
+		ID: "src/handler.go#handleLogin", Name: "handleLogin", Kind: "function",
+		File: "src/handler.go", Line: 10, Language: "go",
+	}
+	callee := db.Symbol{
+		ID: "src/auth.go#authenticate", Name: "authenticate", Kind: "function",
+		File: "src/auth.go", Line: 42, Language: "go",
+	}
+	seedSymbol(t, m, caller)
+	seedSymbol(t, m, callee)
+	if err := m.InsertCall(&db.Call{
+		CallerID: caller.ID, CalleeID: callee.ID,
+		File: "src/handler.go", Line: 15, Column: 4,
+	}); err != nil {
+		t.Fatalf("InsertCall: %v", err)
+	}
+
+	c, buf := freshCmd(t, "callees", runCallees)
+	if err := c.RunE(c, []string{"handleLogin"}); err != nil {
+		t.Fatalf("runCallees returned error: %v", err)
+	}
+
+	env, count := decodeEnvelope(t, buf.Bytes())
+	if count != 1 {
+		t.Fatalf("count = %d, want 1, env=%s", count, buf.String())
+	}
+	var recs []calleeRecord
+	_ = json.Unmarshal(env["results"], &recs)
+	if recs[0].Name != "authenticate" {
+		t.Errorf("record = %+v", recs[0])
+	}
+}
+
+func TestJSONSymbol_Context(t *testing.T) {
+	dir, m := setupCodegraphProject(t)
+	src := filepath.Join(dir, "auth.go")
+	content := "package auth\n\n// authenticate checks credentials.\nfunc authenticate(u User) bool {\n\treturn check(u)\n}\n"
+	if err := os.WriteFile(src, []byte(content), 0o644); err != nil {
+		t.Fatalf("write source: %v", err)
+	}
+	endLine := 6
+	target := db.Symbol{
+		ID: "auth.go#authenticate", Name: "authenticate", Kind: "function",
+		File: src, Line: 4, EndLine: &endLine, Language: "go",
+		Signature: "func authenticate(u User) bool", Documentation: "authenticate checks credentials.",
+	}
+	callee := db.Symbol{
+		ID: "auth.go#check", Name: "check", Kind: "function",
+		File: src, Line: 8, Language: "go", Signature: "func check(u User) bool",
+	}
+	caller := db.Symbol{
+		ID: "handler.go#handleLogin", Name: "handleLogin", Kind: "function",
+		File: filepath.Join(dir, "handler.go"), Line: 10, Language: "go",
+	}
+	// A same-named symbol elsewhere must not leak into the target's call graph
+	other := db.Symbol{
+		ID: "legacy.go#check", Name: "check", Kind: "function",
+		File: filepath.Join(dir, "legacy.go"), Line: 3, Language: "go",
+	}
+	for _, s := range []db.Symbol{target, callee, caller, other} {
+		seedSymbol(t, m, s)
+	}
+	for _, call := range []db.Call{
+		{CallerID: caller.ID, CalleeID: target.ID, File: caller.File, Line: 12, Column: 1},
+		{CallerID: target.ID, CalleeID: callee.ID, File: src, Line: 5, Column: 8},
+		{CallerID: other.ID, CalleeID: other.ID, File: other.File, Line: 4, Column: 1},
+	} {
+		if err := m.InsertCall(&call); err != nil {
+			t.Fatalf("InsertCall: %v", err)
+		}
+	}
+
+	c, buf := freshCmd(t, "context", runContext)
+	if err := c.RunE(c, []string{"authenticate"}); err != nil {
+		t.Fatalf("runContext returned error: %v", err)
+	}
+
+	env, count := decodeEnvelope(t, buf.Bytes())
+	if count != 1 {
+		t.Fatalf("count = %d, want 1, env=%s", count, buf.String())
+	}
+	var recs []contextRecord
+	if err := json.Unmarshal(env["results"], &recs); err != nil {
+		t.Fatalf("results unmarshal: %v", err)
+	}
+	rec := recs[0]
+	if rec.Definition.File != "auth.go" || rec.Definition.EndLine != 6 {
+		t.Errorf("definition = %+v", rec.Definition)
+	}
+	if !strings.HasPrefix(rec.Definition.Source, "func authenticate") || !strings.HasSuffix(rec.Definition.Source, "}") {
+		t.Errorf("source = %q", rec.Definition.Source)
+	}
+	if len(rec.Callers) != 1 || rec.Callers[0].Name != "handleLogin" || rec.Callers[0].Line != 12 {
+		t.Errorf("callers = %+v", rec.Callers)
+	}
+	if len(rec.Callees) != 1 || rec.Callees[0].Signature != "func check(u User) bool" {
+		t.Errorf("callees = %+v", rec.Callees)
+	}
+	if len(rec.Outline) != 2 || rec.Outline[0].Name != "authenticate" {
+		t.Errorf("outline = %+v", rec.Outline)
+	}
+}
+
+func TestJSONSymbol_ContextMaxTokens(t *testing.T) {
+	dir, m := setupCodegraphProject(t)
+	src := filepath.Join(dir, "log.go")
+	body := "func Log(msg string) {\n" + strings.Repeat("\tfmt.Println(msg)\n", 50) + "}\n"
+	if err := os.WriteFile(src, []byte(body), 0o644); err != nil {
+		t.Fatalf("write source: %v", err)
+	}
+	endLine := 52
+	target := db.Symbol{
+		ID: "log.go#Log", Name: "Log", Kind: "function",
+		File: src, Line: 1, EndLine: &endLine, Language: "go", Signature: "func Log(msg string)",
+	}
+	script := db.Symbol{ID: "tool.go#script", Name: "script", Kind: "function", File: "tool.go", Line: 1, Language: "go"}
+	hot := db.Symbol{ID: "server.go#serve", Name: "serve", Kind: "function", File: "server.go", Line: 1, Language: "go"}
+	for _, s := range []db.Symbol{target, script, hot} {
+		seedSymbol(t, m, s)
+	}
+	// script calls Log first in file order, but serve is itself called twice
+	for _, call := range []db.Call{
+		{CallerID: script.ID, CalleeID: target.ID, File: "a.go", Line: 1},
+		{CallerID: hot.ID, CalleeID: target.ID, File: "b.go", Line: 1},
+		{CallerID: script.ID, CalleeID: hot.ID, File: "a.go", Line: 2},
+		{CallerID: script.ID, CalleeID: hot.ID, File: "a.go", Line: 3},
+	} {
+		if err := m.InsertCall(&call); err != nil {
+			t.Fatalf("InsertCall: %v", err)
+		}
+	}
+	contextMaxTokensFlag = 100
+	t.Cleanup(func() { contextMaxTokensFlag = 0 })
+
+	c, buf := freshCmd(t, "context", runContext)
+	if err := c.RunE(c, []string{"Log"}); err != nil {
+		t.Fatalf("runContext returned error: %v", err)
+	}
+	env, _ := decodeEnvelope(t, buf.Bytes())
+	var recs []contextRecord
+	_ = json.Unmarshal(env["results"], &recs)
+	rec := recs[0]
+	if !rec.Truncated || rec.Omitted == 0 {
+		t.Errorf("truncated = %v, omitted = %d; want truncation", rec.Truncated, rec.Omitted)
+	}
+	if !strings.HasSuffix(rec.Definition.Source, "more lines)") {
+		t.Errorf("source not truncated: %q", rec.Definition.Source)
+	}
+	if len(rec.Callers) == 0 || rec.Callers[0].Name != "serve" {
+		t.Errorf("callers = %+v, want serve ranked first", rec.Callers)
+	}
+}
+
+func TestJSONSymbol_Snippet(t *testing.T) {
+	dir, m := setupCodegraphProject(t)
+	src := filepath.Join(dir, "math.go")
+	content := "package math\n\nfunc Add(a, b int) int {\n\treturn a + b\n}\n\nfunc Sub(a, b int) int { return a - b }\n"
+	if err := os.WriteFile(src, []byte(content), 0o644); err != nil {
+		t.Fatalf("write source: %v", err)
+	}
+	endLine, endColumn := 5, 1
+	seedSymbol(t, m, db.Symbol{
+		ID: "math.go#Add", Name: "Add", Kind: "function", File: src,
+		Line: 3, EndLine: &endLine, EndColumn: &endColumn, Language: "go",
+	})
+	t.Cleanup(func() { snippetContextFlag, snippetLineNumbersFlag = 0, false })
+
+	c, buf := freshCmd(t, "snippet", runSnippet)
+	if err := c.RunE(c, []string{"Add"}); err != nil {
+		t.Fatalf("runSnippet returned error: %v", err)
+	}
+	env, count := decodeEnvelope(t, buf.Bytes())
+	if count != 1 {
+		t.Fatalf("count = %d, want 1, env=%s", count, buf.String())
+	}
+	var recs []snippetRecord
+	_ = json.Unmarshal(env["results"], &recs)
+	if want := "func Add(a, b int) int {\n\treturn a + b\n}"; recs[0].Source != want {
+		t.Errorf("source = %q, want %q", recs[0].Source, want)
+	}
+	if recs[0].File != "math.go" || recs[0].StartLine != 3 || recs[0].LastLine != 5 {
+		t.Errorf("record = %+v", recs[0])
+	}
+
+	snippetContextFlag, snippetLineNumbersFlag = 1, true
+	c, buf = freshCmd(t, "snippet", runSnippet)
+	if err := c.RunE(c, []string{"Add"}); err != nil {
+		t.Fatalf("runSnippet returned error: %v", err)
+	}
+	env, _ = decodeEnvelope(t, buf.Bytes())
+	_ = json.Unmarshal(env["results"], &recs)
+	if want := "2\n3  func Add(a, b int) int {\n4  \treturn a + b\n5  }\n6"; recs[0].Source != want {
+		t.Errorf("source with context = %q, want %q", recs[0].Source, want)
+	}
+}
+
+func TestJSONSymbol_Implementations(t *testing.T) {
+	_, m := setupCodegraphProject(t)
+	iface := db.Symbol{
+		ID: "src/io.go#Reader", Name: "Reader", Kind: "interface",
+		File: "src/io.go", Line: 5, Language: "go",
+	}
+	impl := db.Symbol{
+		ID: "src/file.go#FileReader", Name: "FileReader", Kind: "struct",
+		File: "src/file.go", Line: 10, Language: "go",
+	}
+	seedSymbol(t, m, iface)
+	seedSymbol(t, m, impl)
+	if err := m.InsertTypeHierarchy(&db.TypeHierarchy{
+		ChildID: impl.ID, ParentID: iface.ID, Relationship: "implements",
+	}); err != nil {
+		t.Fatalf("InsertTypeHierarchy: %v", err)
+	}
+
+	c, buf := freshCmd(t, "implementations", runImplementations)
+	if err := c.RunE(c, []string{"Reader"}); err != nil {
+		t.Fatalf("runImplementations returned error: %v", err)
+	}
+
+	env, count := decodeEnvelope(t, buf.Bytes())
+	if count != 1 {
+		t.Fatalf("count = %d, want 1, env=%s", count, buf.String())
+	}
+	var recs []implementationRecord
+	_ = json.Unmarshal(env["results"], &recs)
+	if recs[0].Name != "FileReader" {
+		t.Errorf("record = %+v", recs[0])
+	}
+}
+
+func TestJSONSymbol_ImplementationsCached(t *testing.T) {
+	dir, m := setupCodegraphProject(t)
+	// Language servers answer with absolute paths, as the index stores them
+	file := filepath.Join(dir, "src", "file.go")
+	end := 20
+	iface := db.Symbol{ID: "src/io.go#Reader", Name: "Reader", Kind: "interface", File: "src/io.go", Line: 5, Language: "go"}
+	for _, s := range []db.Symbol{
+		iface,
+		{ID: "src/file.go#FileReader", Name: "FileReader", Kind: "struct", File: file, Line: 10, EndLine: &end, Language: "go"},
+		{ID: "src/file.go#FileReader.Read", Name: "Read", Kind: "method", File: file, Line: 14, Language: "go"},
+		{ID: "src/net.go#ConnReader", Name: "ConnReader", Kind: "struct", File: "src/net.go", Line: 3, Language: "go"},
+	} {
+		seedSymbol(t, m, s)
+	}
+	// Indexed by tree-sitter; a refresh must leave it alone
+	if err := m.InsertTypeHierarchy(&db.TypeHierarchy{ChildID: "src/net.go#ConnReader", ParentID: iface.ID, Relationship: "implements"}); err != nil {
+		t.Fatalf("InsertTypeHierarchy: %v", err)
+	}
+
+	at := func(file string, line int) lsp.Location {
+		return lsp.Location{URI: lsp.PathToURI(file), Range: lsp.Range{Start: lsp.Position{Line: line - 1}}}
+	}
+	for _, tc := range []struct {
+		loc  lsp.Location
+		want string
+	}{
+		{at(file, 10), "src/file.go#FileReader"},
+		{at(file, 12), "src/file.go#FileReader"},
+		{at(file, 14), "src/file.go#FileReader.Read"},
+		{at(file, 30), ""},
+	} {
+		sym, err := symbolAtLocation(m, tc.loc)
+		if err != nil {
+			t.Fatalf("symbolAtLocation: %v", err)
+		}
+		if got := ""; sym != nil {
+			got = sym.ID
+			if got != tc.want {
+				t.Errorf("symbolAtLocation(line %d) = %q, want %q", tc.loc.Range.Start.Line+1, got, tc.want)
+			}
+		} else if tc.want != "" {
+			t.Errorf("symbolAtLocation(line %d) = nil, want %q", tc.loc.Range.Start.Line+1, tc.want)
+		}
+	}
+
+	// Caching twice replaces the first answer and skips pairs already known
+	if err := m.CacheImplementations(iface.ID, []string{"src/file.go#FileReader.Read"}); err != nil {
+		t.Fatalf("CacheImplementations: %v", err)
+	}
+	if err := m.CacheImplementations(iface.ID, []string{"src/file.go#FileReader", "src/net.go#ConnReader"}); err != nil {
+		t.Fatalf("CacheImplementations: %v", err)
+	}
+
+	c, buf := freshCmd(t, "implementations", runImplementations)
+	if err := c.RunE(c, []string{"Reader"}); err != nil {
+		t.Fatalf("runImplementations returned error: %v", err)
+	}
+	env, count := decodeEnvelope(t, buf.Bytes())
+	var recs []implementationRecord
+	_ = json.Unmarshal(env["results"], &recs)
+	var names []string
+	for _, r := range recs {
+		names = append(names, r.Name)
+	}
+	sort.Strings(names)
+	if count != 2 || !slices.Equal(names, []string{"ConnReader", "FileReader"}) {
+		t.Errorf("implementations = %v, want ConnReader and FileReader once each", names)
+	}
+}
+
+func TestJSONSymbol_Fields(t *testing.T) {
+	_, m := setupCodegraphProject(t)
+	end := 14
+	seedSymbol(t, m, db.Symbol{
+		ID: "src/config.go#Config", Name: "Config", Kind: "struct",
+		File: "src/config.go", Line: 10, EndLine: &end, Language: "go",
+	})
+	for _, s := range []db.Symbol{
+		{ID: "src/config.go#Config.Name", Name: "Name", Kind: "field", File: "src/config.go", Line: 11, Scope: "Config", Signature: "string", Language: "go"},
+		{ID: "src/config.go#Config.Port", Name: "Port", Kind: "field", File: "src/config.go", Line: 12, Scope: "Config", Signature: "int", Language: "go"},
+		// Same scope name but outside the type's body, and not a data member
+		{ID: "src/config.go#Config.Load", Name: "Load", Kind: "method", File: "src/config.go", Line: 13, Scope: "Config", Language: "go"},
+		{ID: "src/other.go#Config.Name", Name: "Name", Kind: "field", File: "src/other.go", Line: 11, Scope: "Config", Language: "go"},
+	} {
+		seedSymbol(t, m, s)
+	}
+
+	c, buf := freshCmd(t, "fields", runFields)
+	if err := c.RunE(c, []string{"Config"}); err != nil {
+		t.Fatalf("runFields returned error: %v", err)
+	}
+
+	env, count := decodeEnvelope(t, buf.Bytes())
+	if count != 2 {
+		t.Fatalf("count = %d, want 2, env=%s", count, buf.String())
+	}
+	var recs []fieldRecord
+	_ = json.Unmarshal(env["results"], &recs)
+	if recs[0].Name != "Name" || recs[0].Type != "string" || recs[0].Owner != "Config" || recs[1].Name != "Port" || recs[1].Line != 12 {
+		t.Errorf("records = %+v", recs)
+	}
+}
+
+func TestJSONSymbol_MembersFollowContainment(t *testing.T) {
+	_, m := setupCodegraphProject(t)
+	// A Rust impl block sits outside the struct's range and its methods
+	// carry no scope match, so only the contains table links them
+	server := db.Symbol{ID: "src/server.rs#Server", Name: "Server", Kind: "struct", File: "src/server.rs", Line: 1, Language: "rust"}
+	start := db.Symbol{ID: "src/server.rs#Server::start", Name: "start", Kind: "method", File: "src/server.rs", Line: 8, Language: "rust"}
+	helper := db.Symbol{ID: "src/server.rs#helper", Name: "helper", Kind: "function", File: "src/server.rs", Line: 12, Language: "rust"}
+	for _, s := range []db.Symbol{server, start, helper} {
+		seedSymbol(t, m, s)
+	}
+	if err := m.InsertContainment(&db.Containment{ChildID: start.ID, ParentID: server.ID}); err != nil {
+		t.Fatalf("InsertContainment: %v", err)
+	}
+	fieldsKindFlag = "method"
+	t.Cleanup(func() { fieldsKindFlag = "" })
+
+	c, buf := freshCmd(t, "members", runFields)
+	if err := c.RunE(c, []string{"Server"}); err != nil {
+		t.Fatalf("runFields returned error: %v", err)
+	}
+	env, count := decodeEnvelope(t, buf.Bytes())
+	var recs []fieldRecord
+	_ = json.Unmarshal(env["results"], &recs)
+	if count != 1 || recs[0].Name != "start" {
+		t.Fatalf("records = %+v", recs)
+	}
+
+	parents, err := m.GetFileContainment("src/server.rs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := contextOutlineLine(contextOutlineEntry{Name: "start", Kind: "method", Line: 8, Depth: containmentDepth(parents, start.ID)}); got != "  - 8: method `start`" {
+		t.Errorf("outline line = %q", got)
+	}
+}
+
+func TestJSONSymbol_Types(t *testing.T) {
+	setupCodegraphProject(t)
+
+	c, buf := freshCmd(t, "types", runTypes)
+	err := c.RunE(c, []string{"Reader"})
+	if err == nil {
+		t.Fatalf("runTypes should return non-nil error while stubbed; envelope=%s", buf.String())
+	}
+
+	env, count := decodeEnvelope(t, buf.Bytes())
+	if count != 0 {
+		t.Errorf("count = %d, want 0 (types stub)", count)
+	}
+	if string(env["query"]) != `"Reader"` {
+		t.Errorf("query raw = %s", string(env["query"]))
+	}
+	var cmdName string
+	_ = json.Unmarshal(env["command"], &cmdName)
+	if cmdName != "types" {
+		t.Errorf("command = %q, want types", cmdName)
+	}
+
+	var errs []EnvelopeError
+	if uerr := json.Unmarshal(env["errors"], &errs); uerr != nil {
+		t.Fatalf("errors unmarshal: %v", uerr)
+	}
+	if len(errs) != 1 || errs[0].Code != "not_implemented" {
+		t.Errorf("errors = %+v, want one entry with code=not_implemented", errs)
+	}
+}
+
+func TestBuildProgress_JSONStream(t *testing.T) {
+	var events bytes.Buffer
+	progress, err := newProgress(progressJSON, &bytes.Buffer{}, &events)
+	if err != nil {
+		t.Fatalf("newProgress: %v", err)
+	}
+	progress.LanguageStarted("go", 2)
+	progress.FileDone("go", 1, 2, 3)
+	progress.FileDone("go", 2, 2, 5)
+	progress.LanguageFinished("go")
+
+	var got []progressEvent
+	for _, line := range strings.Split(strings.TrimSpace(events.String()), "\n") {
+		var e progressEvent
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("unmarshal %q: %v", line, err)
+		}
+		got = append(got, e)
+	}
+	if len(got) != 4 {
+		t.Fatalf("got %d events, want 4: %s", len(got), events.String())
+	}
+	if got[0].Event != "language_started" || got[0].Total != 2 {
+		t.Errorf("first event = %+v, want language_started of 2 files", got[0])
+	}
+	if last := got[3]; last.Event != "language_finished" || last.Done != 2 || last.Symbols != 5 {
+		t.Errorf("last event = %+v, want language_finished with 2 files, 5 symbols", last)
+	}
+
+	if p, err := newProgress(progressNone, nil, nil); err != nil || p != nil {
+		t.Errorf("newProgress(none) = %v, %v; want nil, nil", p, err)
+	}
+	if _, err := newProgress("fancy", nil, nil); err == nil {
+		t.Error("newProgress(fancy) should fail")
+	}
+}
+
+func TestJSONVerify_FixDropsRemovedFiles(t *testing.T) {
+	dir, m := setupCodegraphProject(t)
+	kept := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(kept, []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gone := filepath.Join(dir, "gone.go")
+	seedSymbol(t, m, db.Symbol{ID: "main.go#main", Name: "main", Kind: "function", File: kept, Line: 3, Language: "go"})
+	seedSymbol(t, m, db.Symbol{ID: "gone.go#helper", Name: "helper", Kind: "function", File: gone, Line: 1, Language: "go"})
+	if err := m.InsertCall(&db.Call{CallerID: "main.go#main", CalleeID: "gone.go#helper", File: kept, Line: 4}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { verifyFixFlag = false })
+
+	verify := func(fix bool) (verifyRecord, error) {
+		verifyFixFlag = fix
+		buf := &bytes.Buffer{}
+		c := &cobra.Command{Use: "verify", RunE: runVerify}
+		c.SetOut(buf)
+		err := c.RunE(c, nil)
+		_, count := decodeEnvelope(t, buf.Bytes())
+		var env struct{ Results []verifyRecord }
+		if jerr := json.Unmarshal(buf.Bytes(), &env); jerr != nil || count != 1 {
+			t.Fatalf("verify output: %s", buf.String())
+		}
+		return env.Results[0], err
+	}
+
+	rec, err := verify(false)
+	if err == nil {
+		t.Error("verify should fail while the index has problems")
+	}
+	if rec.Problems != 1 || len(rec.RemovedFiles) != 1 || rec.RemovedFiles[0] != "gone.go" {
+		t.Errorf("record = %+v, want gone.go as the one problem", rec)
+	}
+
+	if rec, err = verify(true); err != nil || !rec.Fixed {
+		t.Fatalf("verify --fix = %+v, %v", rec, err)
+	}
+	if rec, err = verify(false); err != nil || rec.Problems != 0 {
+		t.Errorf("after fix: %+v, %v; want a consistent index", rec, err)
+	}
+	if calls, _ := m.ListCalls(); len(calls) != 0 {
+		t.Errorf("calls into the removed file remain: %+v", calls)
+	}
+}
+
+func TestRegistryPathResolution(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("CODEGRAPH_HOME", "")
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Cleanup(func() { registryFlag = "" })
+
+	check := func(want string) {
+		t.Helper()
+		got, err := registryPath()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("registryPath() = %s, want %s", got, want)
+		}
+	}
+
+	legacy := filepath.Join(home, ".codegraph", "registry.json")
+	check(legacy)
+
+	xdg := filepath.Join(home, "data")
+	t.Setenv("XDG_DATA_HOME", xdg)
+	check(filepath.Join(xdg, "codegraph", "registry.json"))
+
+	// An existing legacy registry keeps being used
+	if err := os.MkdirAll(filepath.Dir(legacy), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(legacy, []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	check(legacy)
+
+	t.Setenv("CODEGRAPH_HOME", filepath.Join(home, "cg"))
+	check(filepath.Join(home, "cg", "registry.json"))
+
+	registryFlag = filepath.Join(home, "elsewhere.json")
+	check(registryFlag)
+}
+
+func TestResolveProjectByPathOrName(t *testing.T) {
+	home := t.TempDir()
+	registryFlag = filepath.Join(home, "registry.json")
+	t.Cleanup(func() { registryFlag = "" })
+
+	api, web, other := filepath.Join(home, "api"), filepath.Join(home, "web"), filepath.Join(home, "x", "web")
+	reg := registry.New()
+	reg.Add(api, "api")
+	reg.Add(web, "web")
+	reg.Add(other, "web")
+	if err := reg.Save(registryFlag); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(api, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := resolveProject("api"); err != nil || got != api {
+		t.Errorf("resolveProject(api) = %s, %v; want %s", got, err, api)
+	}
+	if got, err := resolveProject(api); err != nil || got != api {
+		t.Errorf("resolveProject(path) = %s, %v; want %s", got, err, api)
+	}
+	if _, err := resolveProject("web"); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("resolveProject(web) error = %v, want ambiguous", err)
+	}
+	if _, err := resolveProject("nope"); err == nil {
+		t.Error("resolveProject(nope) should fail")
+	}
+}
+
+func TestRegistrySubcommands(t *testing.T) {
+	dir, m := setupCodegraphProject(t)
+	registryFlag = filepath.Join(t.TempDir(), "registry.json")
+	t.Cleanup(func() { registryFlag = ""; registryAddNameFlag = "" })
+	seedSymbol(t, m, db.Symbol{ID: "a.go#run", Name: "run", Kind: "function", File: filepath.Join(dir, "a.go"), Line: 1, Language: "go"})
+	if err := m.UpdateFileMeta(filepath.Join(dir, "a.go"), time.Now(), "go", "lsp"); err != nil {
+		t.Fatal(err)
+	}
+
+	registryAddNameFlag = "demo"
+	if err := runRegistryAdd(registryAddCmd, nil); err != nil {
+		t.Fatalf("registry add: %v", err)
+	}
+	if err := runRegistryRename(registryRenameCmd, []string{"demo", "renamed"}); err != nil {
+		t.Fatalf("registry rename: %v", err)
+	}
+
+	c, buf := freshCmd(t, "info", runRegistryInfo)
+	if err := c.RunE(c, []string{"renamed"}); err != nil {
+		t.Fatalf("registry info: %v", err)
+	}
+	var env struct{ Results []projectRecord }
+	if err := json.Unmarshal(buf.Bytes(), &env); err != nil || len(env.Results) != 1 {
+		t.Fatalf("registry info output: %s", buf.String())
+	}
+	rec := env.Results[0]
+	if rec.Name != "renamed" || rec.Stats == nil || rec.Stats.Symbols != 1 || rec.Stats.Files != 1 || rec.Stats.LastBuild == nil {
+		t.Errorf("record = %+v, stats = %+v; want renamed with 1 symbol in 1 file", rec, rec.Stats)
+	}
+
+	if err := runRegistryRemove(registryRemoveCmd, []string{dir}); err != nil {
+		t.Fatalf("registry remove: %v", err)
+	}
+	if reg, _, _ := loadRegistry(); len(reg.Projects) != 0 {
+		t.Errorf("projects after remove = %v", reg.Projects)
+	}
+}
+
+func TestCompleteSymbolNames(t *testing.T) {
+	_, m := setupCodegraphProject(t)
+	for _, s := range []struct{ name, kind string }{
+		{"parseConfig", "function"}, {"parseArgs", "function"}, {"Parser", "struct"}, {"render", "function"},
+	} {
+		seedSymbol(t, m, db.Symbol{ID: "a.go#" + s.name, Name: s.name, Kind: s.kind, File: "a.go", Line: 1, Language: "go"})
+	}
+
+	got, directive := completeSymbols(nil)(callersCmd, nil, "pars")
+	if strings.Join(got, ",") != "parseArgs,parseConfig" || directive != cobra.ShellCompDirectiveNoFileComp {
+		t.Errorf("callers pars<TAB> = %v (%v), want parseArgs,parseConfig", got, directive)
+	}
+	if got, _ := fieldsCmd.ValidArgsFunction(fieldsCmd, nil, "P"); strings.Join(got, ",") != "Parser" {
+		t.Errorf("fields P<TAB> = %v, want only the Parser type", got)
+	}
+	if got, _ := callersCmd.ValidArgsFunction(callersCmd, []string{"parseArgs"}, ""); len(got) != 0 {
+		t.Errorf("second argument completions = %v, want none", got)
+	}
+}
+
+func TestCallersVimgrepFormat(t *testing.T) {
+	dir, m := setupCodegraphProject(t)
+	jsonOutputFlag = false
+	t.Cleanup(func() { callersFormat.value = formatText })
+
+	file := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(file, []byte("package main\n\nfunc main() {\n\tparseConfig(\"a.toml\")\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	seedSymbol(t, m, db.Symbol{ID: "main.go#main", Name: "main", Kind: "function", File: file, Line: 3, Language: "go"})
+	seedSymbol(t, m, db.Symbol{ID: "main.go#parseConfig", Name: "parseConfig", Kind: "function", File: file, Line: 7, Language: "go"})
+	if err := m.InsertCall(&db.Call{CallerID: "main.go#main", CalleeID: "main.go#parseConfig", File: file, Line: 4, Column: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	callersFormat.value = formatVimgrep
+	c, buf := freshCmd(t, "callers", runCallers)
+	if err := c.RunE(c, []string{"parseConfig"}); err != nil {
+		t.Fatalf("runCallers: %v", err)
+	}
+	if got, want := buf.String(), "main.go:4:2: main calls parseConfig: parseConfig(\"a.toml\")\n"; got != want {
+		t.Errorf("vimgrep output = %q, want %q", got, want)
+	}
+
+	callersFormat.value = "xml"
+	if err := c.RunE(c, []string{"parseConfig"}); err == nil {
+		t.Error("--format=xml should be rejected")
+	}
+}
+
+func TestJSONSymbol_Hotspots(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir, m := setupCodegraphProject(t)
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=Dev", "-c", "user.email=dev@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	commit := func(file, src string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		git("add", file)
+		git("commit", "-q", "-m", "change "+file)
+	}
+	git("init", "-q")
+	commit("store.go", "package main\n\nfunc Save() int {\n\treturn 1\n}\n")
+	commit("api.go", "package main\n\nfunc handle() { Save() }\n\nfunc serve() { Save() }\n")
+	commit("store.go", "package main\n\nfunc Save() int {\n\treturn 2\n}\n")
+	commit("store.go", "package main\n\nfunc Save() int {\n\treturn 3\n}\n")
+
+	end := func(n int) *int { return &n }
+	syms := map[string]db.Symbol{}
+	for _, s := range []db.Symbol{
+		{ID: "store.go#Save", Name: "Save", File: filepath.Join(dir, "store.go"), Line: 3, EndLine: end(5)},
+		{ID: "api.go#handle", Name: "handle", File: filepath.Join(dir, "api.go"), Line: 3, EndLine: end(3)},
+		{ID: "api.go#serve", Name: "serve", File: filepath.Join(dir, "api.go"), Line: 5, EndLine: end(5)},
+	} {
+		s.Kind, s.Language = "function", "go"
+		seedSymbol(t, m, s)
+		syms[s.Name] = s
+	}
+	for _, e := range [][2]string{{"handle", "Save"}, {"serve", "Save"}} {
+		if err := m.InsertCall(&db.Call{CallerID: syms[e[0]].ID, CalleeID: syms[e[1]].ID, File: syms[e[0]].File, Line: syms[e[0]].Line}); err != nil {
+			t.Fatalf("InsertCall: %v", err)
+		}
+	}
+
+	c, buf := freshCmd(t, "hotspots", runHotspots)
+	if err := c.RunE(c, nil); err != nil {
+		t.Fatalf("runHotspots returned error: %v", err)
+	}
+	env, count := decodeEnvelope(t, buf.Bytes())
+	var recs []hotspotRecord
+	_ = json.Unmarshal(env["results"], &recs)
+	if count != 3 {
+		t.Fatalf("count = %d, want 3, env=%s", count, buf.String())
+	}
+	// Save: 3 commits × 2 callers; handle and serve: 1 commit × 1 callee
+	if r := recs[0]; r.Name != "Save" || r.File != "store.go" || r.Churn != 3 || r.FanIn != 2 || r.Score != 6 {
+		t.Errorf("top hotspot = %+v, want Save with churn 3, fan-in 2, score 6", r)
+	}
+	if r := recs[2]; r.Rank != 3 || r.Name != "serve" || r.Score != 1 {
+		t.Errorf("last hotspot = %+v, want serve ranked 3rd with score 1", r)
+	}
+	if h, err := m.GetSymbolHistory(syms["Save"].ID); err != nil || h == nil || h.Commits != 3 {
+		t.Errorf("stored history = %+v, %v, want 3 commits", h, err)
+	}
+
+	// --by=file ranks files by their commits and their functions' calls
+	t.Cleanup(func() { hotspotsByFlag, hotspotsFormatFlag, jsonOutputFlag = "symbol", formatText, true })
+	hotspotsByFlag = "file"
+	c, buf = freshCmd(t, "hotspots", runHotspots)
+	if err := c.RunE(c, nil); err != nil {
+		t.Fatalf("runHotspots --by=file returned error: %v", err)
+	}
+	env, _ = decodeEnvelope(t, buf.Bytes())
+	recs = nil
+	_ = json.Unmarshal(env["results"], &recs)
+	var got []string
+	for _, r := range recs {
+		got = append(got, fmt.Sprintf("%s=%d", r.File, r.Score))
+	}
+	if want := "store.go=6,api.go=2"; strings.Join(got, ",") != want {
+		t.Errorf("file hotspots = %s, want %s", strings.Join(got, ","), want)
+	}
+
+	hotspotsFormatFlag = formatCSV
+	c, buf = freshCmd(t, "hotspots", runHotspots)
+	if err := c.RunE(c, nil); err == nil {
+		t.Error("--format=csv with --json succeeded")
+	}
+	jsonOutputFlag = false
+	c, buf = freshCmd(t, "hotspots", runHotspots)
+	if err := c.RunE(c, nil); err != nil {
+		t.Fatalf("runHotspots --format=csv returned error: %v", err)
+	}
+	if want := "rank,file,functions,churn,fan_in,fan_out,score\n1,store.go,1,3,2,0,6\n2,api.go,2,1,0,2,2\n"; buf.String() != want {
+		t.Errorf("csv = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestWriteTableCSV(t *testing.T) {
+	dir, m := setupCodegraphProject(t)
+	for _, s := range []db.Symbol{
+		{ID: "shapes.py#Shape", Name: "Shape", Kind: "class", File: filepath.Join(dir, "shapes.py"), Line: 1},
+		{ID: "shapes.py#Circle", Name: "Circle", Kind: "class", File: filepath.Join(dir, "shapes.py"), Line: 5, Signature: "class Circle(Shape):"},
+		{ID: "shapes.py#area", Name: "area", Kind: "function", File: filepath.Join(dir, "shapes.py"), Line: 9},
+	} {
+		s.Language = "python"
+		seedSymbol(t, m, s)
+	}
+	if err := m.InsertCall(&db.Call{CallerID: "shapes.py#area", CalleeID: "shapes.py#Circle", File: filepath.Join(dir, "shapes.py"), Line: 10, Column: 4, Args: "(r, 2)"}); err != nil {
+		t.Fatalf("InsertCall: %v", err)
+	}
+	if err := m.InsertTypeHierarchy(&db.TypeHierarchy{ChildID: "shapes.py#Circle", ParentID: "shapes.py#Shape", Relationship: "extends"}); err != nil {
+		t.Fatalf("InsertTypeHierarchy: %v", err)
+	}
+
+	for _, tc := range []struct {
+		table string
+		want  string
+	}{
+		{"symbols", "id,name,kind,file,line,column,end_line,end_column,scope,signature,language,source,package,is_test\n" +
+			"shapes.py#Shape,Shape,class,shapes.py,1,0,,,,,python,,,0\n" +
+			"shapes.py#Circle,Circle,class,shapes.py,5,0,,,,class Circle(Shape):,python,,,0\n" +
+			"shapes.py#area,area,function,shapes.py,9,0,,,,,python,,,0\n"},
+		{"calls", "caller_id,caller,callee_id,callee,file,line,column,arg_count,args\n" +
+			"shapes.py#area,area,shapes.py#Circle,Circle,shapes.py,10,4,,\"(r, 2)\"\n"},
+		{"hierarchy", "child_id,child,parent_id,parent,relationship,type_args\n" +
+			"shapes.py#Circle,Circle,shapes.py#Shape,Shape,extends,\n"},
+	} {
+		var buf bytes.Buffer
+		count, err := writeTableCSV(&buf, m, tc.table, dir)
+		if err != nil {
+			t.Fatalf("writeTableCSV(%s): %v", tc.table, err)
+		}
+		if want := strings.Count(tc.want, "\n") - 1; count != want {
+			t.Errorf("%s: count = %d, want %d", tc.table, count, want)
+		}
+		if buf.String() != tc.want {
+			t.Errorf("%s csv =\n%s\nwant\n%s", tc.table, buf.String(), tc.want)
+		}
+	}
+	if _, err := writeTableCSV(io.Discard, m, "tags", dir); err == nil {
+		t.Error("writeTableCSV(tags) succeeded")
+	}
+}
+
+func TestJSONSymbol_SQLQuery(t *testing.T) {
+	_, m := setupCodegraphProject(t)
+	syms := map[string]db.Symbol{}
+	for _, s := range []db.Symbol{
+		{ID: "a.go#main", Name: "main", File: "a.go", Line: 1},
+		{ID: "a.go#parse", Name: "parse", File: "a.go", Line: 5},
+	} {
+		s.Kind, s.Language = "function", "go"
+		seedSymbol(t, m, s)
+		syms[s.Name] = s
+	}
+	if err := m.InsertCall(&db.Call{CallerID: syms["main"].ID, CalleeID: syms["parse"].ID, File: "a.go", Line: 2}); err != nil {
+		t.Fatalf("InsertCall: %v", err)
+	}
+
+	run := func(query string) (map[string]json.RawMessage, []map[string]interface{}, error) {
+		t.Helper()
+		c, buf := freshCmd(t, "query", runSQLQuery)
+		err := c.RunE(c, []string{query})
+		env, _ := decodeEnvelope(t, buf.Bytes())
+		var rows []map[string]interface{}
+		_ = json.Unmarshal(env["results"], &rows)
+		return env, rows, err
+	}
+	_, rows, err := run("SELECT caller_name, callee_name, line FROM v_calls_named")
+	if err != nil {
+		t.Fatalf("runSQLQuery returned error: %v", err)
+	}
+	if len(rows) != 1 || rows[0]["caller_name"] != "main" || rows[0]["callee_name"] != "parse" || rows[0]["line"] != float64(2) {
+		t.Errorf("rows = %v, want main calling parse on line 2", rows)
+	}
+
+	for _, query := range []string{
+		"DELETE FROM symbols",
+		"PRAGMA query_only = OFF; DELETE FROM symbols; SELECT 1",
+		"ATTACH DATABASE 'other.db' AS other",
+		"SELECT * FROM missing",
+	} {
+		env, _, err := run(query)
+		if err == nil || !strings.Contains(string(env["errors"]), "query_failed") {
+			t.Errorf("%q: err = %v, errors = %s, want query_failed", query, err, env["errors"])
+		}
+	}
+	if _, err := os.Stat("other.db"); err == nil {
+		t.Error("ATTACH created other.db")
+	}
+	// The connection went back to the pool writable
+	seedSymbol(t, m, db.Symbol{ID: "a.go#helper", Name: "helper", Kind: "function", Language: "go", File: "a.go", Line: 9})
+	t.Cleanup(func() { sqlLimitFlag = 1000 })
+	sqlLimitFlag = 2
+	if _, rows, err := run("SELECT name FROM symbols ORDER BY line"); err != nil || len(rows) != 2 {
+		t.Errorf("rows = %v, %v, want the first 2 of 3", rows, err)
+	}
+}
+
+func TestJSONSymbol_RunSavedQuery(t *testing.T) {
+	dir, m := setupCodegraphProject(t)
+	syms := map[string]db.Symbol{}
+	for _, s := range []db.Symbol{
+		{ID: "a.go#main", Name: "main", File: "a.go", Line: 1},
+		{ID: "a.go#parse", Name: "parse", File: "a.go", Line: 5},
+		{ID: "a.go#Config", Name: "Config", Kind: "struct", File: "a.go", Line: 9},
+	} {
+		if s.Kind == "" {
+			s.Kind = "function"
+		}
+		s.Language = "go"
+		seedSymbol(t, m, s)
+		syms[s.Name] = s
+	}
+	if err := m.InsertCall(&db.Call{CallerID: syms["main"].ID, CalleeID: syms["parse"].ID, File: "a.go", Line: 2}); err != nil {
+		t.Fatalf("InsertCall: %v", err)
+	}
+	queries := `[queries.by-kind]
+description = "Symbols of a kind"
+params = ["kind"]
+sql = "SELECT name FROM symbols WHERE kind = :kind ORDER BY line"
+
+[queries.impact]
+params = ["symbol"]
+commands = [["callers", "{{symbol}}"], ["callees", "{{symbol}}"]]
+`
+	if err := os.WriteFile(filepath.Join(dir, ".codegraph", "queries.toml"), []byte(queries), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) []string {
+		t.Helper()
+		c, buf := freshCmd(t, "run", runSaved)
+		if err := c.RunE(c, args); err != nil {
+			t.Fatalf("run %v: %v", args, err)
+		}
+		return strings.Split(strings.TrimSpace(buf.String()), "\n")
+	}
+	env, _ := decodeEnvelope(t, []byte(run()[0]))
+	var list []savedQueryRecord
+	_ = json.Unmarshal(env["results"], &list)
+	if len(list) != 2 || list[0].Name != "by-kind" || list[0].Type != "sql" || list[1].Type != "commands" {
+		t.Errorf("saved queries = %+v", list)
+	}
+
+	env, _ = decodeEnvelope(t, []byte(run("by-kind", "function")[0]))
+	var rows []map[string]interface{}
+	_ = json.Unmarshal(env["results"], &rows)
+	if len(rows) != 2 || rows[0]["name"] != "main" || rows[1]["name"] != "parse" {
+		t.Errorf("by-kind function = %v, want main and parse", rows)
+	}
+
+	// Each command writes its own envelope; callers print to stdout
+	// through the command they were found as, so capture it
+	var out bytes.Buffer
+	for _, name := range []string{"callers", "callees"} {
+		c, _, err := rootCmd.Find([]string{name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.SetOut(&out)
+		t.Cleanup(func() { c.SetOut(nil) })
+	}
+	run("impact", "parse")
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("impact wrote %d envelopes, want 2: %s", len(lines), out.String())
+	}
+	env, count := decodeEnvelope(t, []byte(lines[0]))
+	if count != 1 || !strings.Contains(string(env["command"]), "callers") || !strings.Contains(string(env["results"]), `"main"`) {
+		t.Errorf("callers envelope = %s, want main", lines[0])
+	}
+	if _, count := decodeEnvelope(t, []byte(lines[1])); count != 0 {
+		t.Errorf("callees envelope = %s, want none", lines[1])
+	}
+
+	c, _ := freshCmd(t, "run", runSaved)
+	if err := c.RunE(c, []string{"by-kind"}); err == nil {
+		t.Error("by-kind without its argument succeeded")
+	}
+}
+
+func TestSplitCommandLine(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want []string
+	}{
+		{"callers SaveUser", []string{"callers", "SaveUser"}},
+		{`  query "SELECT * FROM symbols WHERE kind = 'class'"  `, []string{"query", "SELECT * FROM symbols WHERE kind = 'class'"}},
+		{`grep-calls 'a b' c\ d "x\"y\n"`, []string{"grep-calls", "a b", "c d", `x"y\n`}},
+		{`search ""`, []string{"search", ""}},
+	} {
+		got, err := splitCommandLine(tc.in)
+		if err != nil || !slices.Equal(got, tc.want) {
+			t.Errorf("splitCommandLine(%q) = %q, %v, want %q", tc.in, got, err, tc.want)
+		}
+	}
+	if _, err := splitCommandLine(`callers "open`); err == nil {
+		t.Error("unterminated quote accepted")
+	}
+}
+
+func TestWatcherRerunsChangedSubscriptions(t *testing.T) {
+	dir, m := setupCodegraphProject(t)
+	jsonOutputFlag = false
+	path := filepath.Join(dir, "app.py")
+	write := func(src string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("def save():\n    pass\n\ndef main():\n    save()\n")
+
+	cfg := config.DefaultConfig()
+	cfg.LSP["python"] = config.LSPConfig{Command: "missing-python-lsp"}
+	idx := indexer.NewIndexer(cfg, m, dir)
+	defer idx.Close()
+	idx.SetFast(true)
+
+	var out bytes.Buffer
+	w := &watcher{
+		root: dir,
+		db:   m,
+		idx:  idx,
+		scan: func() ([]indexer.FileInfo, error) {
+			return []indexer.FileInfo{{Path: path, RelPath: "app.py", Language: "python"}}, nil
+		},
+		subs: []*subscription{{command: "callers save", args: []string{"callers", "save"}}},
+		exec: `{ echo "$CODEGRAPH_QUERY|$CODEGRAPH_CHANGED_FILES"; cat; } >> notified.txt`,
+		out:  &out,
+		// The callers of save, as the subscription would print them
+		query: func(ctx context.Context, args []string) string {
+			callers, err := m.GetCallers(args[1], db.QueryOptions{})
+			if err != nil {
+				return err.Error()
+			}
+			var names []string
+			for _, c := range callers {
+				names = append(names, c.Name)
+			}
+			sort.Strings(names)
+			return strings.Join(names, ",") + "\n"
+		},
+	}
+	ctx := context.Background()
+	if changed, err := w.poll(ctx); err != nil || !slices.Equal(changed, []string{"app.py"}) {
+		t.Fatalf("first poll = %q, %v, want app.py indexed", changed, err)
+	}
+	w.subs[0].output = w.query(ctx, w.subs[0].args)
+	if changed, err := w.poll(ctx); err != nil || len(changed) != 0 {
+		t.Fatalf("poll without changes = %q, %v, want none", changed, err)
+	}
+
+	// A comment changes nothing the subscription shows; a new caller does
+	for _, src := range []string{
+		"# app\ndef save():\n    pass\n\ndef main():\n    save()\n",
+		"# app\ndef save():\n    pass\n\ndef main():\n    save()\n\ndef retry():\n    save()\n",
+	} {
+		write(src)
+		changed, err := w.poll(ctx)
+		if err != nil || len(changed) != 1 {
+			t.Fatalf("poll = %q, %v, want app.py re-indexed", changed, err)
+		}
+		w.rerun(ctx, changed)
+	}
+	notified, err := os.ReadFile(filepath.Join(dir, "notified.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "callers save|app.py\nmain,retry\n"; string(notified) != want {
+		t.Errorf("--exec saw %q, want %q", notified, want)
+	}
+	if !strings.Contains(out.String(), "main,retry") {
+		t.Errorf("output does not show the new callers:\n%s", out.String())
 	}
 }
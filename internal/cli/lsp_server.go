@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.com/tk-425/Codegraph/internal/lspserver"
+)
+
+var lspStdioFlag bool
+
+var lspServerCmd = &cobra.Command{
+	Use:   "lsp",
+	Short: "Serve the index to editors as a language server",
+	Long: `Run a language server on standard input and output that answers from the
+index instead of analyzing the code, so navigation works across every
+indexed language as soon as the editor connects, while the languages'
+own servers are still starting up.
+
+Supported requests:
+  workspace/symbol                 symbols whose name contains the query
+  textDocument/references          call sites of the symbol under the cursor
+  textDocument/implementation      types implementing or extending it
+  textDocument/prepareCallHierarchy, callHierarchy/incomingCalls,
+  callHierarchy/outgoingCalls      callers and callees, by symbol
+
+Answers are as current as the last 'codegraph build'; run 'codegraph
+watch' alongside to keep them up to date. Configure the editor to start
+'codegraph lsp' in the project root, or pass --project.
+
+Examples:
+  codegraph lsp
+  codegraph lsp --project ~/src/monorepo`,
+	Args: cobra.NoArgs,
+	RunE: runLSPServer,
+}
+
+func init() {
+	lspServerCmd.Flags().BoolVar(&lspStdioFlag, "stdio", true, "Communicate over standard input and output (the only transport; accepted for editor clients that pass it)")
+	rootCmd.AddCommand(lspServerCmd)
+}
+
+func runLSPServer(cmd *cobra.Command, args []string) error {
+	// Standard output carries the protocol, so nothing else may be printed
+	cmd.SilenceUsage = true
+	_, _, dbManager, _, err := openProject(true)
+	if err != nil {
+		return err
+	}
+	defer dbManager.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server := lspserver.NewServer(dbManager)
+	server.Version = Version
+	return server.Serve(ctx, os.Stdin, os.Stdout)
+}
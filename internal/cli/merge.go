@@ -0,0 +1,166 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.com/tk-425/Codegraph/internal/config"
+	"github.com/tk-425/Codegraph/internal/db"
+	"github.com/tk-425/Codegraph/internal/indexer"
+)
+
+var mergeCmd = &cobra.Command{
+	Use:   "merge <shard.db>...",
+	Short: "Merge shard databases into the project index and link them",
+	Long: `Replace the project's index with the union of databases built by
+'codegraph build --shard i/N', then extract the call graph and the other
+links between files (type hierarchy, gRPC, entry points, routes and
+components) across all of them, as a full build would.
+
+Shards may come from checkouts at other paths: their file paths are moved
+under this project. Linking reads the source files, so run merge in a
+checkout of the same commit. Every shard must split the files into the
+same number of shards; a shard given twice is an error, and missing shards
+are reported since their files will be absent from the index.
+
+Examples:
+  codegraph build --shard 1/2 && cp .codegraph/graphs/codegraph.db shard1.db
+  codegraph build --shard 2/2 && cp .codegraph/graphs/codegraph.db shard2.db
+  codegraph merge shard1.db shard2.db`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runMerge,
+}
+
+func init() {
+	rootCmd.AddCommand(mergeCmd)
+}
+
+// checkShards reads the shard of each database and reports an error when
+// they disagree on the shard count or repeat a shard, and the shards that
+// are missing otherwise
+func checkShards(paths []string) ([]string, error) {
+	seen := make(map[int]string)
+	count := 0
+	for _, path := range paths {
+		label, err := db.IndexMetaAt(path, db.MetaShard)
+		if err != nil {
+			return nil, err
+		}
+		if label == "" {
+			return nil, fmt.Errorf("%s is not a shard; build it with 'codegraph build --shard i/N'", path)
+		}
+		shard, err := indexer.ParseShard(label)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if count != 0 && shard.Count != count {
+			return nil, fmt.Errorf("%s holds shard %s, but the other shards are out of %d", path, shard, count)
+		}
+		count = shard.Count
+		if other, ok := seen[shard.Index]; ok {
+			return nil, fmt.Errorf("shard %s is given twice (%s and %s)", shard, other, path)
+		}
+		seen[shard.Index] = path
+	}
+
+	var missing []string
+	for i := 1; i <= count; i++ {
+		if _, ok := seen[i]; !ok {
+			missing = append(missing, indexer.Shard{Index: i, Count: count}.String())
+		}
+	}
+	return missing, nil
+}
+
+func runMerge(cmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+	codegraphDir := filepath.Join(cwd, ".codegraph")
+	if _, err := os.Stat(codegraphDir); os.IsNotExist(err) {
+		return errNotInitialized
+	}
+	cfg, err := config.Load(cwd)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if cfg.Database.ReadOnly {
+		return fmt.Errorf("the index is a read-only shared index (database.read_only in config.toml); unset read_only to merge into it")
+	}
+
+	paths := make([]string, len(args))
+	for i, arg := range args {
+		if paths[i], err = filepath.Abs(arg); err != nil {
+			return err
+		}
+	}
+	dbPath := cfg.GetDatabasePath(cwd)
+	if slices.Contains(paths, dbPath) {
+		return fmt.Errorf("cannot merge the project's own database; copy each shard out of .codegraph first")
+	}
+	missing, err := checkShards(paths)
+	if err != nil {
+		return err
+	}
+	cmd.SilenceUsage = true
+
+	fmt.Printf("🔀 %s\n", Bold(fmt.Sprintf("Merging %d shards...", len(paths))))
+	if len(missing) > 0 {
+		fmt.Printf("⚠️  %s\n", Warning(fmt.Sprintf("Missing shards %s: their files will not be indexed", strings.Join(missing, ", "))))
+	}
+
+	dbManager, err := db.NewBuildManager(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer dbManager.Close()
+	if err := dbManager.Initialize(); err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	if err := dbManager.ClearAll(); err != nil {
+		return fmt.Errorf("failed to clear database: %w", err)
+	}
+	for i, path := range paths {
+		if err := dbManager.MergeIndex(path, cwd); err != nil {
+			return fmt.Errorf("failed to merge %s: %w", args[i], err)
+		}
+		fmt.Printf("   %s %s\n", Success("✓"), Path(args[i]))
+	}
+
+	// Link the merged symbols across the files of every shard
+	scanner, err := indexer.NewScannerWithConfig(cwd, filepath.Join(codegraphDir, ".cgignore"), cfg.Index)
+	if err != nil {
+		return fmt.Errorf("failed to prepare scanner: %w", err)
+	}
+	scanner.SetWorkspaces(cfg.Workspaces)
+	files, err := scanner.Scan()
+	if err != nil {
+		return fmt.Errorf("failed to scan files: %w", err)
+	}
+	idx := indexer.NewIndexer(cfg, dbManager, cwd)
+	defer idx.Close()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := idx.LinkProject(ctx, files); err != nil {
+		var interrupted *indexer.InterruptedError
+		if errors.As(err, &interrupted) {
+			fmt.Printf("\n⏸️  %s\n", Warning("Merge interrupted while linking; run the merge again to complete the index"))
+			return staleIndex(errors.New("merge interrupted"))
+		}
+		return fmt.Errorf("linking failed: %w", err)
+	}
+
+	if err := recordBuildStats(cwd, dbManager); err != nil {
+		fmt.Printf("⚠️  %s: %v\n", Warning("Could not update project registry"), err)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	openLangFlag   string
+	openKindFlag   string
+	openPickFlag   int
+	openOpenerFlag string
+	openPrintFlag  bool
+)
+
+var openCmd = &cobra.Command{
+	Use:   "open <symbol>",
+	Short: "Open a symbol's definition in your editor",
+	Long: `Find a symbol and open its definition in an editor, at its line and column.
+
+The editor command comes from --opener, else opener under [editor] in
+config.toml, else $VISUAL or $EDITOR (vim, emacs, VS Code, Sublime Text,
+JetBrains IDEs and others get their jump-to-line arguments), else
+'code -g'. A template is split on spaces, and {file}, {line} and {column}
+are replaced in each argument:
+
+  [editor]
+  opener = "code -g {file}:{line}:{column}"
+
+When several symbols match, they are listed; choose one with --pick.
+
+Examples:
+  codegraph open handleRequest
+  codegraph open Config --kind=struct
+  codegraph open parse --lang=go --pick=2
+  codegraph open Save --opener="nvim +{line} {file}"
+  codegraph open Save --print`,
+	Args: cobra.ExactArgs(1),
+	RunE: runOpen,
+}
+
+func init() {
+	openCmd.Flags().StringVar(&openLangFlag, "lang", "", "Filter by language(s), comma-separated")
+	openCmd.Flags().StringVar(&openKindFlag, "kind", "", kindFlagUsage)
+	openCmd.Flags().IntVar(&openPickFlag, "pick", 0, "Open the Nth of several matching symbols (1-based)")
+	openCmd.Flags().StringVar(&openOpenerFlag, "opener", "", "Editor command template, overriding [editor] opener (e.g. \"subl {file}:{line}\")")
+	openCmd.Flags().BoolVar(&openPrintFlag, "print", false, "Print the editor command instead of running it")
+	rootCmd.AddCommand(openCmd)
+}
+
+func runOpen(cmd *cobra.Command, args []string) error {
+	symbol := args[0]
+	cmd.SilenceUsage = true
+	cwd, cfg, dbManager, _, err := openProject(false)
+	if err != nil {
+		return err
+	}
+	defer dbManager.Close()
+
+	symbols, err := dbManager.FindSymbolsByName(symbol, queryOptions(openLangFlag, openKindFlag))
+	if err != nil {
+		return fmt.Errorf("failed to find symbol: %w", err)
+	}
+	if len(symbols) == 0 {
+		return fmt.Errorf("no symbol named '%s' found", symbol)
+	}
+
+	pick := openPickFlag
+	switch {
+	case pick < 0 || pick > len(symbols):
+		return fmt.Errorf("--pick must be between 1 and %d", len(symbols))
+	case pick == 0 && len(symbols) > 1:
+		fmt.Printf("📂 %s symbols named %s:\n\n", Info(len(symbols)), Symbol(symbol))
+		for i, sym := range symbols {
+			fmt.Printf("  %s %s [%s] %s\n", Info(fmt.Sprintf("%d.", i+1)), Symbol(sym.Name), Keyword(sym.Kind),
+				Path(fmt.Sprintf("%s:%d", relativePath(cwd, sym.File), sym.Line)))
+		}
+		fmt.Println()
+		return fmt.Errorf("choose one with --pick=<n>")
+	case pick == 0:
+		pick = 1
+	}
+	sym := symbols[pick-1]
+
+	editor := cfg.Editor
+	if openOpenerFlag != "" {
+		editor.Opener = openOpenerFlag
+	}
+	command, err := editor.OpenerCommand(sym.File, sym.Line, sym.Column+1)
+	if err != nil {
+		return err
+	}
+	if openPrintFlag {
+		fmt.Println(strings.Join(command, " "))
+		return nil
+	}
+
+	// Attach the terminal so editors such as vim can take it over
+	editorCmd := exec.Command(command[0], command[1:]...)
+	editorCmd.Stdin = os.Stdin
+	editorCmd.Stdout = os.Stdout
+	editorCmd.Stderr = os.Stderr
+	if err := editorCmd.Run(); err != nil {
+		return fmt.Errorf("failed to run %s: %w", command[0], err)
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+var ownersLangFlag string
+
+var ownersCmd = &cobra.Command{
+	Use:   "owners <symbol>",
+	Short: "Show who owns a symbol and the code that calls it",
+	Long: `Show the owners of the specified symbol: the CODEOWNERS owners of its
+file and the git author of most of its lines, as recorded by the last
+build. The owners of its direct callers are summarized too, so a change
+to the symbol can be routed to every team it affects.
+
+CODEOWNERS is read from .github/CODEOWNERS, CODEOWNERS or docs/CODEOWNERS.
+Git blame can be turned off with blame = false under [owners] in
+.codegraph/config.toml.
+
+Examples:
+  codegraph owners parseConfig
+  codegraph owners Save --lang=go
+  codegraph owners handleRequest --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runOwners,
+}
+
+func init() {
+	ownersCmd.Flags().StringVar(&ownersLangFlag, "lang", "", "Filter by language(s), comma-separated")
+	rootCmd.AddCommand(ownersCmd)
+}
+
+// ownedSymbol is a symbol with its ownership and that of its callers
+type ownedSymbol struct {
+	db.Symbol
+	db.Ownership
+	CallerOwners []ownerCount
+}
+
+// ownerCount is how many direct callers an owner has
+type ownerCount struct {
+	Owner   string `json:"owner"`
+	Callers int    `json:"callers"`
+}
+
+// ownersRecord is the JSON form of an ownedSymbol
+type ownersRecord struct {
+	Name         string       `json:"name"`
+	Kind         string       `json:"kind"`
+	File         string       `json:"file"`
+	Line         int          `json:"line"`
+	Owners       []string     `json:"owners"`
+	Author       string       `json:"author"`
+	CallerOwners []ownerCount `json:"caller_owners"`
+}
+
+// unownedLabel stands for callers with neither CODEOWNERS owners nor author
+const unownedLabel = "(unowned)"
+
+// findOwners returns the symbols named symbol with their ownership
+func findOwners(dbManager *db.Manager, symbol string, opts db.QueryOptions) ([]ownedSymbol, error) {
+	symbols, err := dbManager.FindSymbolsByName(symbol, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find symbol: %w", err)
+	}
+
+	result := make([]ownedSymbol, 0, len(symbols))
+	for _, sym := range symbols {
+		ownership, err := dbManager.GetOwnership(sym.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read owners: %w", err)
+		}
+		callers, err := dbManager.GetCallersByID(sym.ID, db.QueryOptions{Tests: testFilter()})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find callers: %w", err)
+		}
+
+		// A caller counts once for each of its owners; its author stands
+		// in when CODEOWNERS assigns it none
+		counts := make(map[string]int)
+		seen := make(map[string]bool)
+		for _, c := range callers {
+			if seen[c.ID] {
+				continue
+			}
+			seen[c.ID] = true
+			callerOwnership, err := dbManager.GetOwnership(c.ID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read owners: %w", err)
+			}
+			owners := callerOwnership.Owners
+			if len(owners) == 0 && callerOwnership.Author != "" {
+				owners = []string{callerOwnership.Author}
+			}
+			if len(owners) == 0 {
+				owners = []string{unownedLabel}
+			}
+			for _, owner := range owners {
+				counts[owner]++
+			}
+		}
+		callerOwners := make([]ownerCount, 0, len(counts))
+		for owner, n := range counts {
+			callerOwners = append(callerOwners, ownerCount{Owner: owner, Callers: n})
+		}
+		sort.Slice(callerOwners, func(i, j int) bool {
+			if callerOwners[i].Callers != callerOwners[j].Callers {
+				return callerOwners[i].Callers > callerOwners[j].Callers
+			}
+			return callerOwners[i].Owner < callerOwners[j].Owner
+		})
+
+		result = append(result, ownedSymbol{Symbol: sym, Ownership: *ownership, CallerOwners: callerOwners})
+	}
+	return result, nil
+}
+
+func runOwners(cmd *cobra.Command, args []string) error {
+	symbol := args[0]
+	if jsonOutputFlag {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		return runOwnersJSON(cmd, symbol)
+	}
+
+	cwd, _, dbManager, _, err := openProject(true)
+	if err != nil {
+		return err
+	}
+	defer dbManager.Close()
+
+	symbols, err := findOwners(dbManager, symbol, queryOptions(ownersLangFlag, ""))
+	if err != nil {
+		return err
+	}
+	if len(symbols) == 0 {
+		fmt.Printf("👥 No symbol named '%s' found in database\n", symbol)
+		return noResults(cmd)
+	}
+
+	for i, s := range symbols {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("👥 %s [%s] %s\n", Symbol(s.Name), Keyword(s.Kind), Path(fmt.Sprintf("%s:%d", relativePath(cwd, s.File), s.Line)))
+		owners := Dim("none")
+		if len(s.Owners) > 0 {
+			owners = Info(strings.Join(s.Owners, " "))
+		}
+		fmt.Printf("  Owners: %s\n", owners)
+		author := Dim("unknown")
+		if s.Author != "" {
+			author = Info(s.Author)
+		}
+		fmt.Printf("  Author: %s\n", author)
+
+		if len(s.CallerOwners) == 0 {
+			fmt.Printf("  %s\n", Dim("No callers"))
+			continue
+		}
+		fmt.Printf("  Impacted owners (direct callers):\n")
+		for _, oc := range s.CallerOwners {
+			fmt.Printf("    %s %s\n", Info(oc.Owner), Dim(fmt.Sprintf("(%d)", oc.Callers)))
+		}
+	}
+	return nil
+}
+
+func runOwnersJSON(cmd *cobra.Command, symbol string) error {
+	out := cmd.OutOrStdout()
+	emitErr := func(code string, err error) error {
+		_ = EmitJSON(out, "owners", &symbol, []ownersRecord{}, []EnvelopeError{{Code: code, Message: err.Error()}})
+		return err
+	}
+
+	cwd, _, dbManager, code, err := openProject(true)
+	if err != nil {
+		return emitErr(code, err)
+	}
+	defer dbManager.Close()
+
+	symbols, err := findOwners(dbManager, symbol, queryOptions(ownersLangFlag, ""))
+	if err != nil {
+		return emitErr("owners_failed", err)
+	}
+	records := make([]ownersRecord, 0, len(symbols))
+	for _, s := range symbols {
+		owners := s.Owners
+		if owners == nil {
+			owners = []string{}
+		}
+		records = append(records, ownersRecord{
+			Name:         s.Name,
+			Kind:         s.Kind,
+			File:         relativePath(cwd, s.File),
+			Line:         s.Line,
+			Owners:       owners,
+			Author:       s.Author,
+			CallerOwners: s.CallerOwners,
+		})
+	}
+	return emitResults(cmd, "owners", &symbol, records)
+}
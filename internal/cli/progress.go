@@ -0,0 +1,167 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/tk-425/Codegraph/internal/indexer"
+)
+
+// Build progress modes accepted by --progress
+const (
+	progressBar  = "bar"
+	progressJSON = "json"
+	progressNone = "none"
+)
+
+// newProgress returns the build progress renderer for mode, or nil when
+// progress is disabled. Bars go to out; JSON events go to events so they
+// can be consumed apart from the build log.
+func newProgress(mode string, out, events io.Writer) (indexer.Progress, error) {
+	switch mode {
+	case progressBar:
+		return &barProgress{out: out}, nil
+	case progressJSON:
+		return &jsonProgress{enc: json.NewEncoder(events)}, nil
+	case progressNone:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("invalid --progress %q (expected bar, json, or none)", mode)
+	}
+}
+
+// rate tracks the throughput of one language's files
+type rate struct {
+	start time.Time
+}
+
+// perSecond returns the files handled per second since the language started
+func (r rate) perSecond(done int) float64 {
+	elapsed := time.Since(r.start).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(done) / elapsed
+}
+
+// eta estimates the seconds left for the remaining files, or -1 while
+// there is no throughput to estimate from
+func (r rate) eta(done, total int) float64 {
+	perSec := r.perSecond(done)
+	if perSec <= 0 {
+		return -1
+	}
+	return float64(total-done) / perSec
+}
+
+// barProgress redraws a single progress line per language
+type barProgress struct {
+	out      io.Writer
+	rate     rate
+	drawn    time.Time
+	lastLine int
+}
+
+const (
+	barWidth     = 24
+	barRedrawGap = 100 * time.Millisecond
+)
+
+func (p *barProgress) LanguageStarted(language string, total int) {
+	p.rate = rate{start: time.Now()}
+	p.drawn = time.Time{}
+	p.lastLine = 0
+}
+
+func (p *barProgress) FileDone(language string, done, total, symbols int) {
+	// Redraw at most every barRedrawGap, but always show the final file
+	if done < total && time.Since(p.drawn) < barRedrawGap {
+		return
+	}
+	p.drawn = time.Now()
+
+	filled := 0
+	if total > 0 {
+		filled = done * barWidth / total
+	}
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", barWidth-filled)
+	line := fmt.Sprintf("   [%s] %s %d/%d files (%.0f%%) · %.1f files/s",
+		language, bar, done, total, float64(done)/float64(total)*100, p.rate.perSecond(done))
+	if eta := p.rate.eta(done, total); eta >= 0 {
+		line += fmt.Sprintf(" · ETA %s", time.Duration(eta*float64(time.Second)).Round(time.Second))
+	}
+	line += fmt.Sprintf(" · %d symbols", symbols)
+	p.draw(line)
+}
+
+func (p *barProgress) LanguageFinished(language string) {
+	// Clear the bar; the indexer prints the language summary in its place
+	p.draw("")
+}
+
+// draw replaces the current line with line, padding over any leftovers
+func (p *barProgress) draw(line string) {
+	width := len([]rune(line))
+	pad := ""
+	if width < p.lastLine {
+		pad = strings.Repeat(" ", p.lastLine-width)
+	}
+	fmt.Fprintf(p.out, "\r%s%s", line, pad)
+	if line == "" {
+		fmt.Fprint(p.out, "\r")
+	}
+	p.lastLine = width
+}
+
+// progressEvent is one line of the --progress=json stream
+type progressEvent struct {
+	Event       string   `json:"event"`
+	Language    string   `json:"language"`
+	Done        int      `json:"done"`
+	Total       int      `json:"total"`
+	Symbols     int      `json:"symbols"`
+	FilesPerSec float64  `json:"files_per_sec"`
+	ETASeconds  *float64 `json:"eta_seconds,omitempty"`
+}
+
+// jsonProgress writes one JSON object per progress event
+type jsonProgress struct {
+	enc     *json.Encoder
+	rate    rate
+	total   int
+	done    int
+	symbols int
+}
+
+func (p *jsonProgress) LanguageStarted(language string, total int) {
+	p.rate = rate{start: time.Now()}
+	p.total, p.done, p.symbols = total, 0, 0
+	p.emit("language_started", language)
+}
+
+func (p *jsonProgress) FileDone(language string, done, total, symbols int) {
+	p.total, p.done, p.symbols = total, done, symbols
+	p.emit("file", language)
+}
+
+func (p *jsonProgress) LanguageFinished(language string) {
+	p.emit("language_finished", language)
+}
+
+func (p *jsonProgress) emit(event, language string) {
+	e := progressEvent{
+		Event:       event,
+		Language:    language,
+		Done:        p.done,
+		Total:       p.total,
+		Symbols:     p.symbols,
+		FilesPerSec: p.rate.perSecond(p.done),
+	}
+	if eta := p.rate.eta(p.done, p.total); eta >= 0 {
+		e.ETASeconds = &eta
+	}
+	_ = p.enc.Encode(e)
+}
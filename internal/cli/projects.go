@@ -22,11 +22,23 @@ func init() {
 }
 
 type projectRecord struct {
-	Name          string    `json:"name"`
-	Path          string    `json:"path"`
-	Status        string    `json:"status"`
-	LastSeen      time.Time `json:"last_seen"`
-	InitializedAt time.Time `json:"initialized_at"`
+	Name          string          `json:"name"`
+	Path          string          `json:"path"`
+	Status        string          `json:"status"`
+	LastSeen      time.Time       `json:"last_seen"`
+	InitializedAt time.Time       `json:"initialized_at"`
+	Stats         *registry.Stats `json:"stats"`
+}
+
+func newProjectRecord(proj *registry.Project) projectRecord {
+	return projectRecord{
+		Name:          proj.Name,
+		Path:          proj.Path,
+		Status:        getProjectStatusCode(proj.Path),
+		LastSeen:      proj.LastSeen,
+		InitializedAt: proj.InitializedAt,
+		Stats:         proj.Stats,
+	}
 }
 
 func runProjects(cmd *cobra.Command, args []string) error {
@@ -36,7 +48,7 @@ func runProjects(cmd *cobra.Command, args []string) error {
 		return runProjectsJSON(cmd)
 	}
 
-	regPath, err := registry.DefaultRegistryPath()
+	regPath, err := registryPath()
 	if err != nil {
 		return err
 	}
@@ -54,14 +66,25 @@ func runProjects(cmd *cobra.Command, args []string) error {
 	fmt.Printf("📁 Projects (%s found):\n\n", Info(len(reg.Projects)))
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
-	fmt.Fprintln(w, Bold("NAME")+"\t"+Bold("PATH")+"\t"+Bold("STATUS")+"\t"+Bold("LAST SEEN"))
+	fmt.Fprintln(w, Bold("NAME")+"\t"+Bold("PATH")+"\t"+Bold("STATUS")+"\t"+Bold("SYMBOLS")+"\t"+Bold("SIZE")+"\t"+Bold("LAST BUILD")+"\t"+Bold("LAST SEEN"))
 
 	for path, proj := range reg.Projects {
 		status := getProjectStatus(path)
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+		symbols, size, lastBuild := Dim("-"), Dim("-"), Dim("never")
+		if proj.Stats != nil {
+			symbols = Info(formatNumber(proj.Stats.Symbols))
+			size = Info(formatBytes(proj.Stats.DatabaseSize))
+			if proj.Stats.LastBuild != nil {
+				lastBuild = Info(formatAge(*proj.Stats.LastBuild))
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
 			Symbol(proj.Name),
 			Path(path),
 			status,
+			symbols,
+			size,
+			lastBuild,
 			Dim(proj.LastSeen.Format(time.RFC822)),
 		)
 	}
@@ -76,7 +99,7 @@ func runProjectsJSON(cmd *cobra.Command) error {
 		return err
 	}
 
-	regPath, err := registry.DefaultRegistryPath()
+	regPath, err := registryPath()
 	if err != nil {
 		return emitErr("registry_path_failed", err)
 	}
@@ -86,19 +109,28 @@ func runProjectsJSON(cmd *cobra.Command) error {
 	}
 
 	records := make([]projectRecord, 0, len(reg.Projects))
-	for path, proj := range reg.Projects {
-		records = append(records, projectRecord{
-			Name:          proj.Name,
-			Path:          path,
-			Status:        getProjectStatusCode(path),
-			LastSeen:      proj.LastSeen,
-			InitializedAt: proj.InitializedAt,
-		})
+	for _, proj := range reg.Projects {
+		records = append(records, newProjectRecord(proj))
 	}
 
 	return EmitJSON(out, "projects", nil, records, nil)
 }
 
+// formatAge describes how long ago t was, to show index freshness
+func formatAge(t time.Time) string {
+	age := time.Since(t)
+	switch {
+	case age < time.Minute:
+		return "just now"
+	case age < time.Hour:
+		return fmt.Sprintf("%dm ago", int(age.Minutes()))
+	case age < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(age.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(age.Hours()/24))
+	}
+}
+
 func getProjectStatusCode(path string) string {
 	info, err := os.Stat(path)
 	if os.IsNotExist(err) {
@@ -24,7 +24,7 @@ func init() {
 }
 
 func runPrune(cmd *cobra.Command, args []string) error {
-	regPath, err := registry.DefaultRegistryPath()
+	regPath, err := registryPath()
 	if err != nil {
 		return err
 	}
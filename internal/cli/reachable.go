@@ -0,0 +1,329 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tk-425/Codegraph/internal/db"
+	"github.com/tk-425/Codegraph/internal/indexer"
+)
+
+var (
+	reachableEntrypointsFlag string
+	reachableInvertFlag      bool
+	reachableSummaryFlag     bool
+	reachableDepthFlag       int
+	reachableLangFlag        string
+	reachableKindFlag        string
+)
+
+var reachableCmd = &cobra.Command{
+	Use:   "reachable [entrypoint]",
+	Short: "List the functions reachable from an entry point through the call graph",
+	Long: `Walk the call graph from a root set and list every function it reaches
+transitively, grouped by package (directory) with a per-package summary.
+With --invert, list the functions it never reaches instead: candidates for
+trimming a binary, or proof that risky code is not exposed.
+
+The roots are the symbols named [entrypoint], and/or the entry points of
+the --entrypoints types tagged at build time (see 'codegraph entrypoints').
+Only recorded call edges are followed, so functions called through
+reflection, function values or interfaces the index did not resolve show
+up as unreachable.
+
+Examples:
+  codegraph reachable main
+  codegraph reachable handleUpload --lang=go --summary
+  codegraph reachable --entrypoints=main,http,cli --invert
+  codegraph reachable main --invert --kind=method --json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runReachable,
+}
+
+func init() {
+	reachableCmd.Flags().StringVar(&reachableEntrypointsFlag, "entrypoints", "", "Also start from the entry points of these type(s): "+strings.Join(indexer.EntrypointTypes, ", "))
+	reachableCmd.Flags().BoolVar(&reachableInvertFlag, "invert", false, "List the functions that are not reachable")
+	reachableCmd.Flags().BoolVar(&reachableSummaryFlag, "summary", false, "Show only the per-package summary")
+	reachableCmd.Flags().IntVar(&reachableDepthFlag, "depth", 0, "Max call-chain length to follow (0 = unlimited)")
+	reachableCmd.Flags().StringVar(&reachableLangFlag, "lang", "", "Filter the listed functions by language(s), comma-separated")
+	reachableCmd.Flags().StringVar(&reachableKindFlag, "kind", "", kindFlagUsage)
+	rootCmd.AddCommand(reachableCmd)
+}
+
+// callableKinds are the function symbols, listed by reachable when --kind
+// is not given
+var callableKinds = []string{"function", "method", "constructor"}
+
+// reachableFunction is a function with whether the roots reach it
+type reachableFunction struct {
+	db.Symbol
+	Package   string // Directory relative to the project root
+	Reachable bool
+	Depth     int // Calls from the nearest root; 0 for a root
+}
+
+// packageReach summarizes the functions of one package
+type packageReach struct {
+	Package   string `json:"package"`
+	Functions int    `json:"functions"`
+	Reachable int    `json:"reachable"`
+}
+
+type reachableRecord struct {
+	Name      string `json:"name"`
+	Kind      string `json:"kind"`
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	Package   string `json:"package"`
+	Reachable bool   `json:"reachable"`
+	Depth     *int   `json:"depth"` // null when unreachable
+}
+
+// reachableRoots returns the symbols named root and the entry points of
+// the --entrypoints types
+func reachableRoots(dbManager *db.Manager, root string) ([]db.Symbol, error) {
+	types := parseListFlag(reachableEntrypointsFlag)
+	if root == "" && len(types) == 0 {
+		return nil, fmt.Errorf("specify an entry point symbol or --entrypoints")
+	}
+	for _, t := range types {
+		if !slices.Contains(indexer.EntrypointTypes, t) {
+			return nil, fmt.Errorf("invalid --entrypoints %q (expected %s)", t, strings.Join(indexer.EntrypointTypes, ", "))
+		}
+	}
+
+	var roots []db.Symbol
+	if root != "" {
+		named, err := dbManager.FindSymbolsByName(root, db.QueryOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find symbol: %w", err)
+		}
+		if len(named) == 0 {
+			return nil, fmt.Errorf("no symbol named '%s' found in database", root)
+		}
+		roots = append(roots, named...)
+	}
+	if len(types) > 0 {
+		entrypoints, err := dbManager.ListEntrypoints(types, db.QueryOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list entry points: %w", err)
+		}
+		for _, e := range entrypoints {
+			roots = append(roots, e.Symbol)
+		}
+	}
+	return roots, nil
+}
+
+// findReachable walks the call graph breadth-first from roots, up to depth
+// calls (0 = unlimited), and returns the functions matching opts with
+// whether they were reached, in file order
+func findReachable(dbManager *db.Manager, cwd string, roots []db.Symbol, opts db.QueryOptions, depth int) ([]reachableFunction, error) {
+	calls, err := dbManager.ListCalls()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list calls: %w", err)
+	}
+	callees := make(map[string][]string)
+	for _, c := range calls {
+		callees[c.CallerID] = append(callees[c.CallerID], c.CalleeID)
+	}
+
+	reached := make(map[string]int)
+	var frontier []string
+	for _, r := range roots {
+		if _, seen := reached[r.ID]; !seen {
+			reached[r.ID] = 0
+			frontier = append(frontier, r.ID)
+		}
+	}
+	for level := 1; len(frontier) > 0 && (depth <= 0 || level <= depth); level++ {
+		var next []string
+		for _, id := range frontier {
+			for _, callee := range callees[id] {
+				if _, seen := reached[callee]; !seen {
+					reached[callee] = level
+					next = append(next, callee)
+				}
+			}
+		}
+		frontier = next
+	}
+
+	if len(opts.Kinds) == 0 && len(opts.ExcludeKinds) == 0 {
+		opts.Kinds = callableKinds
+	}
+	symbols, err := dbManager.ListSymbols(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list symbols: %w", err)
+	}
+	functions := make([]reachableFunction, 0, len(symbols))
+	for _, s := range symbols {
+		level, ok := reached[s.ID]
+		functions = append(functions, reachableFunction{
+			Symbol:    s,
+			Package:   filepath.ToSlash(filepath.Dir(relativePath(cwd, s.File))),
+			Reachable: ok,
+			Depth:     level,
+		})
+	}
+	return functions, nil
+}
+
+// summarizeReach counts the reachable functions of each package, in
+// package order
+func summarizeReach(functions []reachableFunction) []packageReach {
+	byPackage := make(map[string]*packageReach)
+	for _, f := range functions {
+		p := byPackage[f.Package]
+		if p == nil {
+			p = &packageReach{Package: f.Package}
+			byPackage[f.Package] = p
+		}
+		p.Functions++
+		if f.Reachable {
+			p.Reachable++
+		}
+	}
+	summary := make([]packageReach, 0, len(byPackage))
+	for _, p := range byPackage {
+		summary = append(summary, *p)
+	}
+	sort.Slice(summary, func(i, j int) bool { return summary[i].Package < summary[j].Package })
+	return summary
+}
+
+// reachableQuery names the roots in output
+func reachableQuery(args []string) string {
+	parts := slices.Clone(args)
+	if reachableEntrypointsFlag != "" {
+		parts = append(parts, "entrypoints:"+reachableEntrypointsFlag)
+	}
+	return strings.Join(parts, " ")
+}
+
+func runReachable(cmd *cobra.Command, args []string) error {
+	root := ""
+	if len(args) > 0 {
+		root = args[0]
+	}
+	if jsonOutputFlag {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		return runReachableJSON(cmd, args, root)
+	}
+
+	cwd, _, dbManager, _, err := openProject(true)
+	if err != nil {
+		return err
+	}
+	defer dbManager.Close()
+
+	roots, err := reachableRoots(dbManager, root)
+	if err != nil {
+		return err
+	}
+	functions, err := findReachable(dbManager, cwd, roots, queryOptions(reachableLangFlag, reachableKindFlag), reachableDepthFlag)
+	if err != nil {
+		return err
+	}
+
+	summary := summarizeReach(functions)
+	total, reachable := 0, 0
+	for _, p := range summary {
+		total += p.Functions
+		reachable += p.Reachable
+	}
+	verb := "Reachable"
+	shown := reachable
+	if reachableInvertFlag {
+		verb, shown = "Unreachable", total-reachable
+	}
+	fmt.Printf("🎯 %s from %s (%s roots): %s of %s functions (%s)\n\n",
+		verb, Symbol(reachableQuery(args)), Info(len(roots)), Info(formatNumber(shown)), Info(formatNumber(total)), percent(shown, total))
+
+	width := len("Package")
+	for _, p := range summary {
+		width = max(width, len(p.Package))
+	}
+	fmt.Printf("  %s  %9s  %5s\n", Bold(fmt.Sprintf("%-*s", width, "Package")), Bold("Reachable"), Bold("Share"))
+	for _, p := range summary {
+		fmt.Printf("  %-*s  %9s  %5s\n", width, p.Package, fmt.Sprintf("%d/%d", p.Reachable, p.Functions), percent(p.Reachable, p.Functions))
+	}
+	if reachableSummaryFlag {
+		return nil
+	}
+
+	currentPackage := ""
+	for _, f := range functions {
+		if f.Reachable == reachableInvertFlag {
+			continue
+		}
+		if f.Package != currentPackage {
+			currentPackage = f.Package
+			fmt.Printf("\n%s\n", Path(f.Package))
+		}
+		location := Path(fmt.Sprintf("%s:%d", relativePath(cwd, f.File), f.Line))
+		if f.Reachable {
+			fmt.Printf("  %s [%s] %s %s\n", Symbol(f.Name), Keyword(f.Kind), location, Dim(fmt.Sprintf("depth %d", f.Depth)))
+		} else {
+			fmt.Printf("  %s [%s] %s\n", Symbol(f.Name), Keyword(f.Kind), location)
+		}
+	}
+	return nil
+}
+
+// percent formats part/total as a whole percentage
+func percent(part, total int) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d%%", part*100/total)
+}
+
+func runReachableJSON(cmd *cobra.Command, args []string, root string) error {
+	out := cmd.OutOrStdout()
+	query := reachableQuery(args)
+	emitErr := func(code string, err error) error {
+		_ = EmitJSON(out, "reachable", &query, []reachableRecord{}, []EnvelopeError{{Code: code, Message: err.Error()}})
+		return err
+	}
+
+	cwd, _, dbManager, code, err := openProject(true)
+	if err != nil {
+		return emitErr(code, err)
+	}
+	defer dbManager.Close()
+
+	roots, err := reachableRoots(dbManager, root)
+	if err != nil {
+		return emitErr("invalid_root", err)
+	}
+	functions, err := findReachable(dbManager, cwd, roots, queryOptions(reachableLangFlag, reachableKindFlag), reachableDepthFlag)
+	if err != nil {
+		return emitErr("reachable_failed", err)
+	}
+
+	records := make([]reachableRecord, 0, len(functions))
+	for _, f := range functions {
+		if f.Reachable == reachableInvertFlag {
+			continue
+		}
+		record := reachableRecord{
+			Name:      f.Name,
+			Kind:      f.Kind,
+			File:      relativePath(cwd, f.File),
+			Line:      f.Line,
+			Package:   f.Package,
+			Reachable: f.Reachable,
+		}
+		if f.Reachable {
+			record.Depth = &f.Depth
+		}
+		records = append(records, record)
+	}
+	return EmitJSON(out, "reachable", &query, records, nil)
+}
@@ -0,0 +1,248 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tk-425/Codegraph/internal/config"
+	"github.com/tk-425/Codegraph/internal/db"
+	"github.com/tk-425/Codegraph/internal/registry"
+)
+
+var registryAddNameFlag string
+
+var registryCmd = &cobra.Command{
+	Use:   "registry",
+	Short: "Manage the global project registry",
+	Long: `Add, remove, rename, and inspect projects in the global registry.
+
+Projects are referred to by path or by registered name. Each build stores
+a snapshot of the project's index (symbols, files, call edges, database
+size, last build) in the registry, which 'codegraph projects' shows.
+
+Examples:
+  codegraph registry add ~/src/api --name api
+  codegraph registry rename api backend
+  codegraph registry info backend
+  codegraph registry remove ~/src/old`,
+}
+
+var registryAddCmd = &cobra.Command{
+	Use:   "add [path]",
+	Short: "Register a project (default: current directory)",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runRegistryAdd,
+}
+
+var registryRemoveCmd = &cobra.Command{
+	Use:   "remove <project>",
+	Short: "Remove a project from the registry",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runRegistryRemove,
+}
+
+var registryRenameCmd = &cobra.Command{
+	Use:   "rename <project> <name>",
+	Short: "Rename a registered project",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runRegistryRename,
+}
+
+var registryInfoCmd = &cobra.Command{
+	Use:   "info <project>",
+	Short: "Show a registered project and its index snapshot",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runRegistryInfo,
+}
+
+func init() {
+	registryAddCmd.Flags().StringVar(&registryAddNameFlag, "name", "", "Project name (default: directory name)")
+	registryCmd.AddCommand(registryAddCmd, registryRemoveCmd, registryRenameCmd, registryInfoCmd)
+	rootCmd.AddCommand(registryCmd)
+}
+
+// loadRegistry loads the registry and returns it with its path
+func loadRegistry() (*registry.Registry, string, error) {
+	regPath, err := registryPath()
+	if err != nil {
+		return nil, "", err
+	}
+	reg, err := registry.Load(regPath)
+	if err != nil {
+		return nil, "", err
+	}
+	return reg, regPath, nil
+}
+
+// snapshotStats summarizes an open index for the registry
+func snapshotStats(dbManager *db.Manager) (*registry.Stats, error) {
+	stats, err := dbManager.GetDetailedStats()
+	if err != nil {
+		return nil, err
+	}
+	return &registry.Stats{
+		Symbols:      stats.TotalSymbols,
+		Files:        stats.FilesIndexed,
+		CallEdges:    stats.CallEdges,
+		DatabaseSize: stats.DatabaseSize,
+		LastBuild:    stats.LastBuildTime,
+		RecordedAt:   time.Now(),
+	}, nil
+}
+
+// snapshotProject reads the index snapshot of the project at root, or nil
+// when it has not been built
+func snapshotProject(root string) *registry.Stats {
+	cfg, err := config.Load(root)
+	if err != nil {
+		return nil
+	}
+	dbPath := cfg.GetDatabasePath(root)
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil
+	}
+	dbManager, err := openDatabase(cfg, root)
+	if err != nil {
+		return nil
+	}
+	defer dbManager.Close()
+	stats, err := snapshotStats(dbManager)
+	if err != nil {
+		return nil
+	}
+	return stats
+}
+
+// recordBuildStats stores the snapshot of a freshly built project,
+// registering the project if needed
+func recordBuildStats(root string, dbManager *db.Manager) error {
+	stats, err := snapshotStats(dbManager)
+	if err != nil {
+		return err
+	}
+	reg, regPath, err := loadRegistry()
+	if err != nil {
+		return err
+	}
+	root = filepath.Clean(root)
+	if _, ok := reg.Projects[root]; !ok {
+		reg.Add(root, filepath.Base(root))
+	}
+	proj := reg.Projects[root]
+	proj.Stats = stats
+	proj.LastSeen = time.Now()
+	return reg.Save(regPath)
+}
+
+func runRegistryAdd(cmd *cobra.Command, args []string) error {
+	path := "."
+	if len(args) == 1 {
+		path = args[0]
+	}
+	root, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", root)
+	}
+	name := registryAddNameFlag
+	if name == "" {
+		name = filepath.Base(root)
+	}
+
+	reg, regPath, err := loadRegistry()
+	if err != nil {
+		return err
+	}
+	reg.Add(root, name)
+	reg.Projects[filepath.Clean(root)].Stats = snapshotProject(root)
+	if err := reg.Save(regPath); err != nil {
+		return err
+	}
+	fmt.Printf("📋 Registered %s at %s\n", Symbol(name), Path(root))
+	if _, err := os.Stat(filepath.Join(root, ".codegraph")); os.IsNotExist(err) {
+		fmt.Printf("⚠️  %s\n", Warning("Not initialized yet; run 'codegraph init' there"))
+	}
+	return nil
+}
+
+func runRegistryRemove(cmd *cobra.Command, args []string) error {
+	reg, regPath, err := loadRegistry()
+	if err != nil {
+		return err
+	}
+	proj, err := reg.Find(args[0])
+	if err != nil {
+		return err
+	}
+	reg.Remove(proj.Path)
+	if err := reg.Save(regPath); err != nil {
+		return err
+	}
+	fmt.Printf("🗑️  Removed %s (%s) from registry\n", Symbol(proj.Name), Path(proj.Path))
+	return nil
+}
+
+func runRegistryRename(cmd *cobra.Command, args []string) error {
+	reg, regPath, err := loadRegistry()
+	if err != nil {
+		return err
+	}
+	proj, err := reg.Find(args[0])
+	if err != nil {
+		return err
+	}
+	old := proj.Name
+	proj.Name = args[1]
+	if err := reg.Save(regPath); err != nil {
+		return err
+	}
+	fmt.Printf("✏️  Renamed %s to %s\n", Symbol(old), Symbol(proj.Name))
+	return nil
+}
+
+func runRegistryInfo(cmd *cobra.Command, args []string) error {
+	if jsonOutputFlag {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+	}
+	out := cmd.OutOrStdout()
+	query := args[0]
+
+	reg, _, err := loadRegistry()
+	if err == nil {
+		var proj *registry.Project
+		if proj, err = reg.Find(query); err == nil {
+			if jsonOutputFlag {
+				return EmitJSON(out, "registry info", &query, []projectRecord{newProjectRecord(proj)}, nil)
+			}
+			printProjectInfo(proj)
+			return nil
+		}
+	}
+	if jsonOutputFlag {
+		_ = EmitJSON(out, "registry info", &query, []projectRecord{}, []EnvelopeError{{Code: "project_not_found", Message: err.Error()}})
+	}
+	return err
+}
+
+func printProjectInfo(proj *registry.Project) {
+	fmt.Printf("📁 %s\n", Symbol(proj.Name))
+	fmt.Printf("   Path:         %s\n", Path(proj.Path))
+	fmt.Printf("   Status:       %s\n", getProjectStatus(proj.Path))
+	fmt.Printf("   Initialized:  %s\n", Info(formatTime(&proj.InitializedAt)))
+	fmt.Printf("   Last seen:    %s\n", Info(formatTime(&proj.LastSeen)))
+	if proj.Stats == nil {
+		fmt.Printf("   %s\n", Dim("No index snapshot; run 'codegraph build' in the project"))
+		return
+	}
+	fmt.Printf("   Last build:   %s\n", Info(formatTime(proj.Stats.LastBuild)))
+	fmt.Printf("   Symbols:      %s\n", Info(formatNumber(proj.Stats.Symbols)))
+	fmt.Printf("   Files:        %s\n", Info(formatNumber(proj.Stats.Files)))
+	fmt.Printf("   Call edges:   %s\n", Info(formatNumber(proj.Stats.CallEdges)))
+	fmt.Printf("   Database:     %s\n", Info(formatBytes(proj.Stats.DatabaseSize)))
+}
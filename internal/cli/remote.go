@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/tk-425/Codegraph/internal/config"
+	"github.com/tk-425/Codegraph/internal/remote"
+)
+
+var remoteFlag string
+
+var pullCmd = &cobra.Command{
+	Use:   "pull",
+	Short: "Download the shared index from the remote",
+	Long: `Replace the local index with the one at the project's remote, so an index
+built once (e.g. in CI) does not have to be rebuilt by everyone.
+
+The remote is database.remote in .codegraph/config.toml, or --remote:
+s3://bucket/key (needs the aws CLI), gs://bucket/key (needs gcloud), or a
+file path such as a network mount. The download must have been built by a
+codegraph with the same schema version. Set database.read_only = true to
+keep 'codegraph build' from overwriting a pulled index.
+
+Examples:
+  codegraph pull
+  codegraph pull --remote=s3://ci-artifacts/monorepo/index.db
+  codegraph pull --remote=/mnt/shared/monorepo/index.db`,
+	Args: cobra.NoArgs,
+	RunE: runPull,
+}
+
+var pushCmd = &cobra.Command{
+	Use:   "push",
+	Short: "Upload the local index to the remote",
+	Long: `Upload a consistent copy of the local index to the project's remote, for
+teammates to fetch with 'codegraph pull'. Typically run in CI after
+'codegraph build'.
+
+The remote is database.remote in .codegraph/config.toml, or --remote:
+s3://bucket/key (needs the aws CLI), gs://bucket/key (needs gcloud), or a
+file path such as a network mount.
+
+Examples:
+  codegraph build && codegraph push
+  codegraph push --remote=gs://ci-artifacts/monorepo/index.db`,
+	Args: cobra.NoArgs,
+	RunE: runPush,
+}
+
+func init() {
+	pullCmd.Flags().StringVar(&remoteFlag, "remote", "", "Remote location (defaults to database.remote)")
+	pushCmd.Flags().StringVar(&remoteFlag, "remote", "", "Remote location (defaults to database.remote)")
+	rootCmd.AddCommand(pullCmd, pushCmd)
+}
+
+// remoteLocation returns --remote, or database.remote from config.toml
+func remoteLocation(cfg *config.Config) string {
+	if remoteFlag != "" {
+		return remoteFlag
+	}
+	return cfg.Database.Remote
+}
+
+func runPull(cmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+	if _, err := os.Stat(filepath.Join(cwd, config.DefaultConfigDir)); os.IsNotExist(err) {
+		return errNotInitialized
+	}
+	cfg, err := config.Load(cwd)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	location := remoteLocation(cfg)
+	if _, err := remote.Scheme(location); err != nil {
+		return err
+	}
+	fmt.Printf("⬇️  Pulling index from %s...\n", Path(location))
+	size, err := remote.Pull(context.Background(), location, cfg.GetDatabasePath(cwd), cwd)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✅ Pulled index (%s)\n", Info(formatBytes(size)))
+	return nil
+}
+
+func runPush(cmd *cobra.Command, args []string) error {
+	_, cfg, dbManager, _, err := openProject(true)
+	if err != nil {
+		return err
+	}
+	defer dbManager.Close()
+
+	location := remoteLocation(cfg)
+	if _, err := remote.Scheme(location); err != nil {
+		return err
+	}
+	fmt.Printf("⬆️  Pushing index to %s...\n", Path(location))
+	size, err := remote.Push(context.Background(), dbManager, location)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✅ Pushed index (%s)\n", Info(formatBytes(size)))
+	return nil
+}
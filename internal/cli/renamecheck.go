@@ -0,0 +1,394 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tk-425/Codegraph/internal/config"
+	"github.com/tk-425/Codegraph/internal/daemon"
+	"github.com/tk-425/Codegraph/internal/db"
+	"github.com/tk-425/Codegraph/internal/lsp"
+	"github.com/tk-425/Codegraph/internal/search"
+)
+
+var (
+	renameCheckLangFlag   string
+	renameCheckNoTextFlag bool
+	renameCheckTimeout    time.Duration
+)
+
+var renameCheckCmd = &cobra.Command{
+	Use:   "rename-check <old> <new>",
+	Short: "List every location a rename would have to change",
+	Long: `Preflight a rename: list, grouped by file, every location that has to
+change when the symbol <old> becomes <new>:
+
+  definition      the declaration of <old>
+  call            a call site of <old>
+  implementation  a type implementing or extending <old>, or a method
+                  overriding it in such a type
+  reference       another mention of <old> in code (imports, type uses, ...)
+  string          a mention inside a string literal, e.g. reflection or
+                  configuration keys; review these by hand
+
+References and strings come from a whole-word text search (ripgrep, or the
+built-in scanner without it). While 'codegraph daemon' runs, its language
+servers' references are added too. Existing symbols already named <new> are
+reported as conflicts. Test files are always included. The text search
+gives up after search.timeout_seconds from config.toml, or --timeout.
+
+Examples:
+  codegraph rename-check parseConfig loadConfig
+  codegraph rename-check Save Store --lang=go
+  codegraph rename-check handleRequest serve --no-text --json`,
+	Args: cobra.ExactArgs(2),
+	RunE: runRenameCheck,
+}
+
+func init() {
+	renameCheckCmd.Flags().StringVar(&renameCheckLangFlag, "lang", "", "Filter by language(s), comma-separated")
+	renameCheckCmd.Flags().BoolVar(&renameCheckNoTextFlag, "no-text", false, "Skip the text search for references and string mentions")
+	renameCheckCmd.Flags().DurationVar(&renameCheckTimeout, "timeout", 0, timeoutFlagUsage)
+	rootCmd.AddCommand(renameCheckCmd)
+}
+
+// Categories of rename locations, from the most to the least certain. A
+// line found by several lookups keeps the first category.
+const (
+	renameDefinition     = "definition"
+	renameCall           = "call"
+	renameImplementation = "implementation"
+	renameReference      = "reference"
+	renameString         = "string"
+	renameConflict       = "conflict"
+)
+
+var renameCategoryOrder = []string{renameDefinition, renameCall, renameImplementation, renameReference, renameString, renameConflict}
+
+// renameLocation is one place a rename has to change
+type renameLocation struct {
+	Category string `json:"category"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"` // 1-indexed
+	Symbol   string `json:"symbol"` // The symbol at or containing the location
+	Text     string `json:"text"`   // Source line
+}
+
+// identifierPattern is what a new name must look like
+var identifierPattern = regexp.MustCompile(`^[\p{L}_$][\p{L}\p{N}_$]*$`)
+
+// findRenameLocations collects the locations to change when old becomes
+// new, sorted by file and line
+func findRenameLocations(ctx context.Context, cfg *config.Config, dbManager *db.Manager, cwd, old, new string, text bool) ([]renameLocation, error) {
+	if !identifierPattern.MatchString(new) {
+		return nil, fmt.Errorf("%q is not a valid identifier", new)
+	}
+	if old == new {
+		return nil, fmt.Errorf("old and new names are the same")
+	}
+	opts := db.QueryOptions{Languages: parseListFlag(renameCheckLangFlag)}
+
+	targets, err := dbManager.FindSymbolsByName(old, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find symbol: %w", err)
+	}
+
+	var locs []renameLocation
+	seen := make(map[string]bool)
+	add := func(category, file string, line, column int, symbol string) {
+		rel := relativePath(cwd, file)
+		key := fmt.Sprintf("%s:%d", rel, line)
+		if category != renameConflict {
+			if seen[key] {
+				return
+			}
+			seen[key] = true
+		}
+		abs := file
+		if !filepath.IsAbs(abs) {
+			abs = filepath.Join(cwd, file)
+		}
+		locs = append(locs, renameLocation{
+			Category: category,
+			File:     rel,
+			Line:     line,
+			Column:   column,
+			Symbol:   symbol,
+			Text:     getSourceLine(abs, line),
+		})
+	}
+
+	for _, t := range targets {
+		add(renameDefinition, t.File, t.Line, t.Column+1, t.Name)
+	}
+	for _, t := range targets {
+		callers, err := dbManager.GetCallersByID(t.ID, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find callers: %w", err)
+		}
+		for _, c := range callers {
+			add(renameCall, c.CallFile, c.CallLine, c.CallColumn+1, c.Name)
+		}
+	}
+	for _, t := range targets {
+		impls, err := renameImplementations(dbManager, t, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find implementations: %w", err)
+		}
+		for _, impl := range impls {
+			add(renameImplementation, impl.File, impl.Line, impl.Column+1, impl.Name)
+		}
+	}
+
+	for _, ref := range daemonReferences(ctx, cwd, targets) {
+		add(renameReference, lsp.URIToPath(ref.URI), ref.Range.Start.Line+1, ref.Range.Start.Character+1, old)
+	}
+	if text {
+		mentions, err := textMentions(ctx, cfg, cwd, old, opts.Languages)
+		if err != nil {
+			return nil, fmt.Errorf("text search failed: %w", err)
+		}
+		for _, m := range mentions {
+			category := renameReference
+			if onlyInStrings(m.Context, old) {
+				category = renameString
+			}
+			add(category, m.File, m.Line, m.Column, old)
+		}
+	}
+
+	conflicts, err := dbManager.FindSymbolsByName(new, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find symbol: %w", err)
+	}
+	for _, c := range conflicts {
+		add(renameConflict, c.File, c.Line, c.Column+1, c.Name)
+	}
+
+	sort.SliceStable(locs, func(i, j int) bool {
+		if locs[i].File != locs[j].File {
+			return locs[i].File < locs[j].File
+		}
+		return locs[i].Line < locs[j].Line
+	})
+	return locs, nil
+}
+
+// renameImplementations returns the types implementing or extending sym
+// and, when sym is a method, the methods of the same name in the types
+// implementing its owner
+func renameImplementations(dbManager *db.Manager, sym db.Symbol, opts db.QueryOptions) ([]db.Symbol, error) {
+	impls, err := dbManager.GetImplementations(sym.ID)
+	if err != nil {
+		return nil, err
+	}
+	if sym.Scope == "" {
+		return impls, nil
+	}
+
+	ownerName := sym.Scope
+	if i := strings.LastIndexAny(ownerName, ".:"); i >= 0 {
+		ownerName = ownerName[i+1:]
+	}
+	owners, err := dbManager.FindSymbolsByName(ownerName, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, owner := range owners {
+		if owner.File != sym.File {
+			continue
+		}
+		implementers, err := dbManager.GetImplementations(owner.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, impl := range implementers {
+			members, err := dbManager.GetMembers(impl, opts)
+			if err != nil {
+				return nil, err
+			}
+			for _, m := range members {
+				if m.Name == sym.Name {
+					impls = append(impls, m)
+				}
+			}
+		}
+	}
+	return impls, nil
+}
+
+// daemonReferences asks the project's daemon, when it is running, for the
+// references to each symbol. Without a daemon it returns nothing rather
+// than start language servers; the text search covers references then.
+func daemonReferences(ctx context.Context, cwd string, symbols []db.Symbol) []lsp.Location {
+	client, err := daemon.Dial(cwd)
+	if err != nil {
+		return nil
+	}
+	defer client.Close()
+
+	var refs []lsp.Location
+	for _, sym := range symbols {
+		locations, err := client.References(ctx, sym.Language, sym.File, lsp.Position{Line: sym.Line - 1, Character: sym.Column})
+		if err != nil {
+			continue // No server for the language, or it failed
+		}
+		refs = append(refs, locations...)
+	}
+	return refs
+}
+
+// textMentions finds whole-word occurrences of name with ripgrep, or the
+// built-in scanner when ripgrep is not installed
+func textMentions(ctx context.Context, cfg *config.Config, cwd, name string, languages []string) ([]search.SearchResult, error) {
+	var tier search.Tier
+	if _, err := exec.LookPath("rg"); err == nil {
+		tier = search.NewRipgrepTier(cwd)
+	} else {
+		ignorePath := filepath.Join(cwd, ".codegraph", ".cgignore")
+		if _, err := os.Stat(ignorePath); err != nil {
+			ignorePath = ""
+		}
+		tier = search.NewGrepTier(cwd, ignorePath, cfg.Index)
+	}
+	return tier.Search(ctx, search.SearchOptions{
+		Query:      name,
+		Languages:  languages,
+		ExactMatch: true,
+		Tests:      db.TestsInclude,
+	})
+}
+
+// onlyInStrings reports whether every whole-word occurrence of name in
+// line sits inside a string literal ("...", '...' or `...`)
+func onlyInStrings(line, name string) bool {
+	found := false
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0 && c == '\\':
+			i++
+			continue
+		case quote != 0 && c == quote:
+			quote = 0
+			continue
+		case quote == 0 && (c == '"' || c == '\'' || c == '`'):
+			quote = c
+			continue
+		}
+		if !strings.HasPrefix(line[i:], name) || !wordBoundary(line, i, i+len(name)) {
+			continue
+		}
+		if quote == 0 {
+			return false
+		}
+		found = true
+		i += len(name) - 1
+	}
+	return found
+}
+
+// wordBoundary reports whether line[start:end] is a whole word
+func wordBoundary(line string, start, end int) bool {
+	isWord := func(c byte) bool {
+		return c == '_' || c == '$' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+	}
+	return (start == 0 || !isWord(line[start-1])) && (end == len(line) || !isWord(line[end]))
+}
+
+func runRenameCheck(cmd *cobra.Command, args []string) error {
+	old, new := args[0], args[1]
+	if jsonOutputFlag {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		return runRenameCheckJSON(cmd, old, new)
+	}
+
+	cwd, cfg, dbManager, _, err := openProject(true)
+	if err != nil {
+		return err
+	}
+	defer dbManager.Close()
+
+	ctx, cancel := withTimeout(context.Background(), commandTimeout(cmd, renameCheckTimeout, cfg.Search.Timeout()))
+	defer cancel()
+	locs, err := findRenameLocations(ctx, cfg, dbManager, cwd, old, new, !renameCheckNoTextFlag)
+	if err != nil {
+		return timedOut(ctx, err)
+	}
+	if len(locs) == 0 {
+		fmt.Printf("✏️  No occurrences of '%s' found\n", old)
+		return noResults(cmd)
+	}
+
+	counts := make(map[string]int)
+	files := make(map[string]bool)
+	var conflicts []renameLocation
+	fmt.Printf("✏️  Renaming %s → %s:\n", Symbol(old), Symbol(new))
+	currentFile := ""
+	for _, l := range locs {
+		counts[l.Category]++
+		if l.Category == renameConflict {
+			conflicts = append(conflicts, l)
+			continue
+		}
+		files[l.File] = true
+		if l.File != currentFile {
+			currentFile = l.File
+			fmt.Printf("\n%s\n", Path(l.File))
+		}
+		category := Keyword(fmt.Sprintf("%-14s", l.Category))
+		if l.Category == renameString {
+			category = Warning(fmt.Sprintf("%-14s", l.Category))
+		}
+		fmt.Printf("  %s %s %s\n", Dim(fmt.Sprintf("%5d:%-3d", l.Line, l.Column)), category, l.Text)
+	}
+
+	var parts []string
+	for _, c := range renameCategoryOrder {
+		if c != renameConflict && counts[c] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[c], c))
+		}
+	}
+	fmt.Printf("\n📊 %s locations in %s files (%s)\n", Info(len(locs)-len(conflicts)), Info(len(files)), strings.Join(parts, ", "))
+	for _, c := range conflicts {
+		fmt.Printf("⚠️  %s\n", Warning(fmt.Sprintf("'%s' already exists at %s:%d", new, c.File, c.Line)))
+	}
+	return nil
+}
+
+func runRenameCheckJSON(cmd *cobra.Command, old, new string) error {
+	out := cmd.OutOrStdout()
+	query := old + " " + new
+	emitErr := func(code string, err error) error {
+		_ = EmitJSON(out, "rename-check", &query, []renameLocation{}, []EnvelopeError{{Code: code, Message: err.Error()}})
+		return err
+	}
+
+	cwd, cfg, dbManager, code, err := openProject(true)
+	if err != nil {
+		return emitErr(code, err)
+	}
+	defer dbManager.Close()
+
+	ctx, cancel := withTimeout(context.Background(), commandTimeout(cmd, renameCheckTimeout, cfg.Search.Timeout()))
+	defer cancel()
+	locs, err := findRenameLocations(ctx, cfg, dbManager, cwd, old, new, !renameCheckNoTextFlag)
+	if err != nil {
+		return emitErr("rename_check_failed", timedOut(ctx, err))
+	}
+	if locs == nil {
+		locs = []renameLocation{}
+	}
+	return emitResults(cmd, "rename-check", &query, locs)
+}
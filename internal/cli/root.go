@@ -2,22 +2,75 @@ package cli
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 
 	"github.com/spf13/cobra"
+	"github.com/tk-425/Codegraph/internal/config"
+	"github.com/tk-425/Codegraph/internal/registry"
 )
 
+var (
+	// registryFlag overrides where the global project registry is kept
+	registryFlag string
+	// projectFlag runs the command in another project, by path or name
+	projectFlag string
+	// profileFlag applies a [profiles.<name>] section of config.toml
+	profileFlag string
+	// includeTestsFlag and onlyTestsFlag widen or narrow query results,
+	// which leave out symbols from test files by default
+	includeTestsFlag bool
+	onlyTestsFlag    bool
+	// ownerFlag narrows query results to one CODEOWNERS owner or author
+	ownerFlag string
+	// packageFlag narrows query results to one package or module
+	packageFlag string
+)
+
+// projectEnv sets the project like --project when the flag is absent
+const projectEnv = "CODEGRAPH_PROJECT"
+
 var rootCmd = &cobra.Command{
 	Use:   "codegraph",
 	Short: "Code indexing and call graph analysis tool",
-	Long:  "CodeGraph indexes your codebase using LSP servers and provides fast symbol search, call graph analysis, and code navigation.",
+	Long:  "CodeGraph indexes your codebase using LSP servers and provides fast symbol search, call graph analysis, and code navigation.\n\n" + exitCodesHelp,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := enterProject(); err != nil {
+			return err
+		}
+		// Through the environment, so builds started by --auto-build use it too
+		if profileFlag != "" {
+			if err := os.Setenv(config.ProfileEnv, profileFlag); err != nil {
+				return err
+			}
+		}
+		checkStaleIndex(cmd)
+		return nil
+	},
 }
 
+// Execute runs the command line. Pass its error to ExitCode for the
+// process exit code.
 func Execute() error {
+	registerIndexCompletion(rootCmd)
 	return rootCmd.Execute()
 }
 
 func init() {
 	rootCmd.PersistentFlags().BoolVar(&jsonOutputFlag, "json", false, "Emit machine-readable JSON output (read-only query commands only)")
+	rootCmd.PersistentFlags().StringVar(&projectFlag, "project", "", "Run against another project, by path or registered name (env: "+projectEnv+")")
+	rootCmd.PersistentFlags().StringVar(&profileFlag, "profile", "", "Apply this [profiles.<name>] section of config.toml, e.g. ci (env: "+config.ProfileEnv+")")
+	rootCmd.PersistentFlags().StringVar(&registryFlag, "registry", "", "Path of the project registry file (default: $CODEGRAPH_HOME, $XDG_DATA_HOME/codegraph, or ~/.codegraph)")
+	rootCmd.PersistentFlags().BoolVar(&includeTestsFlag, "include-tests", false, "Include symbols from test files in query results")
+	rootCmd.PersistentFlags().BoolVar(&onlyTestsFlag, "only-tests", false, "Only show symbols from test files in query results")
+	rootCmd.MarkFlagsMutuallyExclusive("include-tests", "only-tests")
+	rootCmd.PersistentFlags().StringVar(&ownerFlag, "owner", "", "Only show symbols owned by this CODEOWNERS owner (@team) or git author")
+	rootCmd.PersistentFlags().StringVar(&packageFlag, "package", "", "Only show symbols in this package or module, matched by its last segments (e.g., db, com.app.db, src/db/manager)")
+	_ = rootCmd.RegisterFlagCompletionFunc("package", completePackages)
+	_ = rootCmd.RegisterFlagCompletionFunc("project", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		names, _ := completeProjects(cmd, nil, toComplete)
+		return names, cobra.ShellCompDirectiveDefault // paths are accepted too
+	})
 
 	defaultHelp := rootCmd.HelpFunc()
 	rootCmd.SetHelpFunc(func(cmd *cobra.Command, args []string) {
@@ -29,3 +82,54 @@ func init() {
 		defaultHelp(cmd, args)
 	})
 }
+
+// registryPath returns the registry file to use: --registry, or the
+// default location for the environment
+func registryPath() (string, error) {
+	if registryFlag != "" {
+		return registryFlag, nil
+	}
+	return registry.DefaultRegistryPath()
+}
+
+// enterProject switches to the project named by --project or
+// CODEGRAPH_PROJECT, so commands run as if started in its root
+func enterProject() error {
+	project := projectFlag
+	if project == "" {
+		project = os.Getenv(projectEnv)
+	}
+	if project == "" {
+		return nil
+	}
+	root, err := resolveProject(project)
+	if err != nil {
+		return err
+	}
+	if err := os.Chdir(root); err != nil {
+		return fmt.Errorf("failed to enter project %s: %w", root, err)
+	}
+	return nil
+}
+
+// resolveProject returns the root of project: a directory path, or the
+// name of a project in the registry
+func resolveProject(project string) (string, error) {
+	if info, err := os.Stat(project); err == nil && info.IsDir() {
+		return filepath.Abs(project)
+	}
+
+	regPath, err := registryPath()
+	if err != nil {
+		return "", err
+	}
+	reg, err := registry.Load(regPath)
+	if err != nil {
+		return "", err
+	}
+	proj, err := reg.Find(project)
+	if err != nil {
+		return "", err
+	}
+	return proj.Path, nil
+}
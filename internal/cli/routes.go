@@ -0,0 +1,202 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+var (
+	routesMethodFlag string
+	routesLangFlag   string
+	routesPageFlags  pageFlags
+	routesFormat     formatFlag
+)
+
+var routesCmd = &cobra.Command{
+	Use:   "routes [path-prefix]",
+	Short: "List the HTTP routes and the functions that handle them",
+	Long: `List the HTTP routes 'codegraph build' found, with the method, URL path
+and handler function of each. Registrations are recognized for:
+
+  Go       net/http (HandleFunc, Handle, "GET /path" patterns), gin,
+           echo, chi, gorilla/mux (.Methods(...))
+  JS/TS    Express: app.get('/path', handler), router.post(...)
+  Python   FastAPI @app.get("/path"), Flask @app.route("/path", methods=[...])
+  Java     Spring @GetMapping, @RequestMapping (with the class-level prefix)
+
+ANY marks routes that accept every method; --method matches them too.
+Handlers registered by name are resolved like entry points: in the same
+file, then the same package, then anywhere. Routes also show up in
+'codegraph callers <handler>', so a caller chain ends at its routes.
+
+Examples:
+  codegraph routes
+  codegraph routes /api/users
+  codegraph routes --method=POST,PUT --lang=go
+  codegraph routes --sort=name --format=vimgrep`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRoutes,
+}
+
+func init() {
+	routesCmd.Flags().StringVar(&routesMethodFlag, "method", "", "Filter by HTTP method(s), comma-separated")
+	routesCmd.Flags().StringVar(&routesLangFlag, "lang", "", "Filter by language(s), comma-separated")
+	routesPageFlags.register(routesCmd, 0)
+	routesFormat.register(routesCmd)
+	rootCmd.AddCommand(routesCmd)
+}
+
+type routeRecord struct {
+	Method      string  `json:"method"`
+	Path        string  `json:"path"`
+	Handler     string  `json:"handler"`
+	HandlerID   *string `json:"handler_id"` // null when the handler did not resolve
+	HandlerFile string  `json:"handler_file,omitempty"`
+	HandlerLine int     `json:"handler_line,omitempty"`
+	Framework   string  `json:"framework"`
+	File        string  `json:"file"` // Where the route is registered
+	Line        int     `json:"line"`
+}
+
+// routesQuery validates the flags and builds the route filter
+func routesQuery() ([]string, db.QueryOptions, error) {
+	var methods []string
+	for _, m := range parseListFlag(routesMethodFlag) {
+		methods = append(methods, strings.ToUpper(m))
+	}
+	opts := queryOptions(routesLangFlag, "")
+	err := routesPageFlags.apply(&opts)
+	return methods, opts, err
+}
+
+// routeLabel names a route in output, e.g. "GET /users/{id}"
+func routeLabel(r db.Route) string {
+	return r.Method + " " + r.Path
+}
+
+func runRoutes(cmd *cobra.Command, args []string) error {
+	prefix := ""
+	if len(args) > 0 {
+		prefix = args[0]
+	}
+	vimgrep, err := routesFormat.vimgrep()
+	if err != nil {
+		return err
+	}
+	if jsonOutputFlag {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		return runRoutesJSON(cmd, args, prefix)
+	}
+
+	methods, opts, err := routesQuery()
+	if err != nil {
+		return err
+	}
+	cwd, _, dbManager, _, err := openProject(true)
+	if err != nil {
+		return err
+	}
+	defer dbManager.Close()
+
+	routes, err := dbManager.ListRoutes(methods, prefix, opts)
+	if err != nil {
+		return fmt.Errorf("failed to list routes: %w", err)
+	}
+
+	if vimgrep {
+		for _, r := range routes {
+			writeVimgrep(cmd.OutOrStdout(), relativePath(cwd, r.File), r.Line, r.Column+1,
+				fmt.Sprintf("%s → %s [%s]", routeLabel(r), r.Handler, r.Framework))
+		}
+		return nil
+	}
+
+	if len(routes) == 0 {
+		fmt.Println("🛣️  No routes found (run 'codegraph build' to extract them)")
+		return noResults(cmd)
+	}
+
+	width := 0
+	for _, r := range routes {
+		width = max(width, len(r.Path))
+	}
+	fmt.Printf("🛣️  Routes (%s found):\n\n", Info(len(routes)))
+	for _, r := range routes {
+		handler := Symbol(r.Handler)
+		if r.HandlerFile != "" {
+			handler += " " + Path(fmt.Sprintf("%s:%d", relativePath(cwd, r.HandlerFile), r.HandlerLine))
+		} else {
+			handler += " " + Dim("(unresolved)")
+		}
+		fmt.Printf("  %s %s → %s %s\n", Keyword(fmt.Sprintf("%-7s", r.Method)), fmt.Sprintf("%-*s", width, r.Path), handler,
+			Dim(fmt.Sprintf("[%s, %s:%d]", r.Framework, relativePath(cwd, r.File), r.Line)))
+	}
+	return nil
+}
+
+func runRoutesJSON(cmd *cobra.Command, args []string, prefix string) error {
+	out := cmd.OutOrStdout()
+	var query *string
+	if len(args) > 0 {
+		query = &prefix
+	}
+	emitErr := func(code string, err error) error {
+		_ = EmitJSON(out, "routes", query, []routeRecord{}, []EnvelopeError{{Code: code, Message: err.Error()}})
+		return err
+	}
+
+	methods, opts, err := routesQuery()
+	if err != nil {
+		return emitErr("invalid_flag", err)
+	}
+	cwd, _, dbManager, code, err := openProject(true)
+	if err != nil {
+		return emitErr(code, err)
+	}
+	defer dbManager.Close()
+
+	routes, err := dbManager.ListRoutes(methods, prefix, opts)
+	if err != nil {
+		return emitErr("routes_failed", fmt.Errorf("failed to list routes: %w", err))
+	}
+
+	records := make([]routeRecord, 0, len(routes))
+	for _, r := range routes {
+		record := routeRecord{
+			Method:    r.Method,
+			Path:      r.Path,
+			Handler:   r.Handler,
+			HandlerID: r.HandlerID,
+			Framework: r.Framework,
+			File:      relativePath(cwd, r.File),
+			Line:      r.Line,
+		}
+		if r.HandlerFile != "" {
+			record.HandlerFile, record.HandlerLine = relativePath(cwd, r.HandlerFile), r.HandlerLine
+		}
+		records = append(records, record)
+	}
+	return emitResults(cmd, "routes", query, records)
+}
+
+// handlerRoutes returns the routes handled by the symbols named symbol,
+// which 'codegraph callers' lists as their callers. Routes have no
+// arguments or control flow, so call-site filters and later pages omit them.
+func handlerRoutes(dbManager *db.Manager, symbol string, opts db.QueryOptions) ([]db.Route, error) {
+	if !listsLinkedCallers(opts, "route") {
+		return nil, nil
+	}
+	handlers, err := dbManager.FindSymbolsByName(symbol, db.QueryOptions{Languages: opts.Languages})
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(handlers))
+	for i, h := range handlers {
+		ids[i] = h.ID
+	}
+	return dbManager.GetHandlerRoutes(ids)
+}
@@ -0,0 +1,213 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/tk-425/Codegraph/internal/config"
+)
+
+var runCmd = &cobra.Command{
+	Use:   "run [name] [args...]",
+	Short: "Run a saved query from .codegraph/queries.toml",
+	Long: `Run a named query saved in .codegraph/queries.toml, so a team can commit
+its own analyses next to the code. Without a name, list the saved queries.
+
+A saved query is either read-only SQL, as for 'codegraph query', which
+refers to its parameters as :name, or codegraph commands run in order,
+whose arguments refer to them as {{name}}. The arguments after the name
+fill the params in order:
+
+  [queries.untested-handlers]
+  description = "Route handlers no test calls"
+  sql = """
+  SELECT s.name, s.file FROM symbols s
+  JOIN tags t ON t.symbol_id = s.id AND t.tag = 'route'
+  WHERE NOT EXISTS (SELECT 1 FROM v_calls_named c
+                    WHERE c.callee_id = s.id AND c.caller_file LIKE '%_test.%')
+  """
+
+  [queries.impact]
+  description = "Who calls a symbol, and what it calls"
+  params = ["symbol"]
+  commands = [["callers", "{{symbol}}", "--depth=2"], ["callees", "{{symbol}}"]]
+
+--limit and --format apply to SQL queries; --json applies to both, with
+one envelope per command.
+
+Examples:
+  codegraph run
+  codegraph run untested-handlers --format=csv
+  codegraph run impact parseConfig
+  codegraph run impact -- --weird-name`,
+	Args: cobra.ArbitraryArgs,
+	RunE: runSaved,
+}
+
+func init() {
+	runCmd.Flags().IntVar(&sqlLimitFlag, "limit", 1000, "Max rows of SQL queries to show (0 = unlimited)")
+	runCmd.Flags().StringVar(&sqlFormatFlag, "format", formatText, "Output format of SQL queries: text or csv")
+	rootCmd.AddCommand(runCmd)
+}
+
+func runSaved(cmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+	queries, err := config.LoadQueries(cwd)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return listSavedQueries(cmd, queries)
+	}
+
+	cmd.SilenceUsage = true
+	name := args[0]
+	q, ok := queries[name]
+	if !ok {
+		return fmt.Errorf("no saved query named %q in %s (see 'codegraph run')", name, config.QueriesFile)
+	}
+	values, err := q.Bind(args[1:])
+	if err != nil {
+		return fmt.Errorf("%s %w", name, err)
+	}
+	if q.SQL != "" {
+		return runSavedSQL(cmd, q, values)
+	}
+	// A command finding nothing does not stop the rest; the query only
+	// exits with ExitNoResults when none found anything
+	found := false
+	for _, argv := range q.Expand(values) {
+		err := runStep(argv)
+		if errors.Is(err, errNoResults) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("%s: codegraph %s: %w", name, strings.Join(argv, " "), err)
+		}
+		found = true
+	}
+	if !found {
+		return noResults(cmd)
+	}
+	return nil
+}
+
+// runSavedSQL runs an SQL saved query with its params bound by name
+func runSavedSQL(cmd *cobra.Command, q config.SavedQuery, values map[string]string) error {
+	text := q.SQL
+	if jsonOutputFlag {
+		cmd.SilenceErrors = true
+		return runSQLQueryJSON(cmd, []string{text}, namedArgs(values)...)
+	}
+	if _, err := sqlQueryText([]string{text}); err != nil {
+		return err
+	}
+	_, _, dbManager, _, err := openProject(true)
+	if err != nil {
+		return err
+	}
+	defer dbManager.Close()
+
+	result, err := dbManager.RunQuery(context.Background(), text, sqlLimitFlag, namedArgs(values)...)
+	if err != nil {
+		return fmt.Errorf("query failed: %w", err)
+	}
+	return printQueryResult(cmd.OutOrStdout(), result)
+}
+
+// namedArgs binds values as :name parameters
+func namedArgs(values map[string]string) []interface{} {
+	args := make([]interface{}, 0, len(values))
+	for name, value := range values {
+		args = append(args, sql.Named(name, value))
+	}
+	return args
+}
+
+// runStep runs one command of a saved query like the command line argv
+// would, starting from its flags' defaults. Global flags such as --json
+// carry over from the run command.
+func runStep(argv []string) error {
+	c, rest, err := rootCmd.Find(argv)
+	if err != nil {
+		return err
+	}
+	if !c.HasParent() || c.Name() == "run" {
+		return fmt.Errorf("unknown command %q", argv[0])
+	}
+	c.LocalNonPersistentFlags().VisitAll(func(f *pflag.Flag) {
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			_ = sv.Replace(nil)
+		} else {
+			_ = f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	})
+	if err := c.ParseFlags(rest); err != nil {
+		return err
+	}
+	args := c.Flags().Args()
+	if err := c.ValidateArgs(args); err != nil {
+		return err
+	}
+	switch {
+	case c.RunE != nil:
+		return c.RunE(c, args)
+	case c.Run != nil:
+		c.Run(c, args)
+		return nil
+	}
+	return fmt.Errorf("%q is not a runnable command", c.CommandPath())
+}
+
+// savedQueryRecord is the JSON form of a saved query in the list
+type savedQueryRecord struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Params      []string `json:"params"`
+	Type        string   `json:"type"` // sql or commands
+}
+
+func listSavedQueries(cmd *cobra.Command, queries map[string]config.SavedQuery) error {
+	records := make([]savedQueryRecord, 0, len(queries))
+	for _, name := range config.QueryNames(queries) {
+		q := queries[name]
+		rec := savedQueryRecord{Name: name, Description: q.Description, Params: q.Params, Type: "commands"}
+		if rec.Params == nil {
+			rec.Params = []string{}
+		}
+		if q.SQL != "" {
+			rec.Type = "sql"
+		}
+		records = append(records, rec)
+	}
+	if jsonOutputFlag {
+		return EmitJSON(cmd.OutOrStdout(), "run", nil, records, nil)
+	}
+
+	if len(records) == 0 {
+		fmt.Printf("📋 No saved queries; define them under [queries.<name>] in %s\n", Path(".codegraph/"+config.QueriesFile))
+		return nil
+	}
+	fmt.Printf("📋 Saved queries (%s):\n\n", Info(len(records)))
+	for _, r := range records {
+		usage := r.Name
+		for _, p := range r.Params {
+			usage += " <" + p + ">"
+		}
+		fmt.Printf("  %s %s\n", Symbol(usage), Dim("["+r.Type+"]"))
+		if r.Description != "" {
+			fmt.Printf("      %s\n", r.Description)
+		}
+	}
+	return nil
+}
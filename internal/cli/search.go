@@ -4,57 +4,127 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/tk-425/Codegraph/internal/config"
 	"github.com/tk-425/Codegraph/internal/db"
+	"github.com/tk-425/Codegraph/internal/embed"
 	"github.com/tk-425/Codegraph/internal/search"
 )
 
 var (
-	searchKindFlag  string
-	searchLangFlag  string
-	searchLimitFlag int
-	searchExactFlag bool
+	searchKindFlag     string
+	searchLangFlag     string
+	searchExactFlag    bool
+	searchRegexFlag    bool
+	searchGlobFlag     bool
+	searchSemanticFlag bool
+	searchTiersFlag    string
+	searchTagFlag      string
+	searchPageFlags    pageFlags
+	searchFormat       formatFlag
+	searchTimeoutFlag  time.Duration
 )
 
 var searchCmd = &cobra.Command{
-	Use:   "search <symbol>",
+	Use:   "search [symbol]",
 	Short: "Search for symbols by name",
 	Long: `Search for symbols (functions, variables, classes, etc.) by name.
 
-Uses multi-tier search: database first, then ripgrep fallback.
+Uses multi-tier search: each tier runs in order until one finds results.
+The order comes from [search] tiers in config.toml (default: db, ripgrep)
+or --tiers. Tiers: db (the index), treesitter (parses files on demand),
+ripgrep (text search; falls back to grep when rg is not installed), grep
+(built-in text search) and semantic (embeddings). With --kind, ripgrep only
+matches declarations of those kinds (func X(, def X(, class X, ...) and
+guesses each result's kind. Custom kinds from [kinds] in config.toml (e.g.
+a "component" rule for React components) filter like built-in ones; shell
+completion of --kind offers every kind in the index.
+With --regex or --glob the query is a pattern matched against symbol
+names (and source text in the ripgrep tier); globs must match the whole name.
+With --semantic the query is a description, matched against symbol
+embeddings computed at build time (requires [embeddings] in config.toml).
+--tag keeps symbols carrying an annotation, decorator or attribute, matched
+by its last segment (route for @app.route, test for #[tokio::test]) or its
+full name; Java @Deprecated, Rust #[deprecated], C# [Obsolete], Swift
+@available(*, deprecated) and "Deprecated:" or @deprecated doc comments are
+all tagged deprecated. Tags are only known to the index, so the other
+tiers find nothing, and the symbol may be omitted.
+A package-qualified query, db.Manager, finds Manager in the db package
+(Go and Java packages, Python modules, TypeScript module paths) when no
+symbol is named db.Manager itself; --package filters by package the same
+way. Packages match by their last segments: db matches internal/db and
+com.app.db.
+A search gives up after search.timeout_seconds from config.toml (default
+30), or --timeout, so a hung tier cannot stall it.
 
 Examples:
   codegraph search parseConfig
   codegraph search parse --kind=function
   codegraph search Config --lang=go,python
-  codegraph search main --exact`,
-	Args: cobra.ExactArgs(1),
+  codegraph search main --exact
+  codegraph search 'Handle.*Request' --regex
+  codegraph search 'New*' --glob --kind=function
+  codegraph search Handler --sort=score --limit=50 --offset=50
+  codegraph search db.Manager
+  codegraph search Manager --package=db
+  codegraph search --semantic "retry http requests"
+  codegraph search newHandler --tiers=db,treesitter
+  codegraph search --tag deprecated
+  codegraph search Test --tag test --lang=rust
+  codegraph search parse --format=vimgrep
+  codegraph search Handler --tiers=treesitter --timeout=2m`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if searchTagFlag != "" {
+			return cobra.MaximumNArgs(1)(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
 	RunE: runSearch,
 }
 
 func init() {
-	searchCmd.Flags().StringVar(&searchKindFlag, "kind", "", "Filter by symbol kind (function, variable, class, interface, type, module)")
+	searchCmd.Flags().StringVar(&searchKindFlag, "kind", "", "Filter by symbol kind(s) (function, variable, class, interface, type, module, or a custom kind from config.toml); prefix with ! to exclude")
 	searchCmd.Flags().StringVar(&searchLangFlag, "lang", "", "Filter by language(s), comma-separated (e.g., go,python)")
-	searchCmd.Flags().IntVar(&searchLimitFlag, "limit", 20, "Max results to show")
 	searchCmd.Flags().BoolVar(&searchExactFlag, "exact", false, "Require exact name match")
+	searchCmd.Flags().BoolVar(&searchRegexFlag, "regex", false, "Treat the query as a regular expression")
+	searchCmd.Flags().BoolVar(&searchGlobFlag, "glob", false, "Treat the query as a glob pattern (*, ?, [...])")
+	searchCmd.Flags().BoolVar(&searchSemanticFlag, "semantic", false, "Match the query by meaning using symbol embeddings")
+	searchCmd.Flags().StringVar(&searchTagFlag, "tag", "", tagFlagUsage)
+	searchCmd.Flags().StringVar(&searchTiersFlag, "tiers", "", "Search tiers to run, in order (db, treesitter, ripgrep, grep, semantic)")
+	searchCmd.MarkFlagsMutuallyExclusive("exact", "regex", "glob", "semantic")
+	searchCmd.MarkFlagsMutuallyExclusive("tiers", "semantic")
+	searchPageFlags.register(searchCmd, 20)
+	searchFormat.register(searchCmd)
+	searchCmd.Flags().DurationVar(&searchTimeoutFlag, "timeout", 0, timeoutFlagUsage)
 	rootCmd.AddCommand(searchCmd)
 }
 
 type searchRecord struct {
-	Name      string `json:"name"`
-	Kind      string `json:"kind"`
-	File      string `json:"file"`
-	Line      int    `json:"line"`
-	Language  string `json:"language"`
-	Signature string `json:"signature"`
+	Name      string  `json:"name"`
+	Kind      string  `json:"kind"`
+	File      string  `json:"file"`
+	Line      int     `json:"line"`
+	Language  string  `json:"language"`
+	Signature string  `json:"signature"`
+	Score     float64 `json:"score,omitempty"` // Similarity, for --semantic results
+	Package   string  `json:"package,omitempty"`
 }
 
 func runSearch(cmd *cobra.Command, args []string) error {
-	symbol := args[0]
+	symbol := ""
+	if len(args) > 0 {
+		symbol = args[0]
+	}
+	vimgrep, err := searchFormat.vimgrep()
+	if err != nil {
+		return err
+	}
 	if jsonOutputFlag {
 		cmd.SilenceUsage = true
 		cmd.SilenceErrors = true
@@ -70,7 +140,7 @@ func runSearch(cmd *cobra.Command, args []string) error {
 	// Check if codegraph is initialized
 	codegraphDir := filepath.Join(cwd, ".codegraph")
 	if _, err := os.Stat(codegraphDir); os.IsNotExist(err) {
-		return fmt.Errorf("codegraph not initialized. Run 'codegraph init' first")
+		return errNotInitialized
 	}
 
 	// Load config
@@ -87,47 +157,72 @@ func runSearch(cmd *cobra.Command, args []string) error {
 	}
 	defer dbManager.Close()
 
-	// Parse languages filter
-	var languages []string
-	if searchLangFlag != "" {
-		languages = strings.Split(searchLangFlag, ",")
+	// Search options
+	opts, _, err := searchOptions(symbol)
+	if err != nil {
+		return err
 	}
 
-	// Create search tiers
-	dbTier := search.NewDatabaseTier(dbManager)
-	rgTier := search.NewRipgrepTier(cwd)
-
 	// Create orchestrator with fallback chain
-	orchestrator := search.NewOrchestrator(dbTier, rgTier)
-
-	// Search options
-	opts := search.SearchOptions{
-		Query:      symbol,
-		Kind:       searchKindFlag,
-		Languages:  languages,
-		Limit:      searchLimitFlag,
-		ExactMatch: searchExactFlag,
+	orchestrator, _, err := newSearchOrchestrator(cfg, dbManager, cwd)
+	if err != nil {
+		return err
 	}
 
 	// Execute search
-	ctx := context.Background()
+	ctx, cancel := withTimeout(context.Background(), commandTimeout(cmd, searchTimeoutFlag, cfg.Search.Timeout()))
+	defer cancel()
 	results, err := orchestrator.Search(ctx, opts)
 	if err != nil {
-		return fmt.Errorf("search failed: %w", err)
+		return fmt.Errorf("search failed: %w", timedOut(ctx, err))
 	}
 
-	if len(results) == 0 {
-		fmt.Printf("🔍 No results found for: %s\n", Warning(symbol))
+	if vimgrep {
+		for _, r := range results {
+			relPath, err := filepath.Rel(cwd, r.File)
+			if err != nil {
+				relPath = r.File
+			}
+			// Text tiers report 1-indexed columns, the index 0-indexed ones
+			column := r.Column
+			if r.Source != "ripgrep" && r.Source != "grep" {
+				column++
+			}
+			detail := r.Signature
+			if detail == "" {
+				detail = r.Context
+			}
+			writeVimgrep(cmd.OutOrStdout(), relPath, r.Line, column, fmt.Sprintf("%s [%s] %s", r.Name, r.Kind, detail))
+		}
+		if len(results) == 0 {
+			return noResults(cmd)
+		}
 		return nil
 	}
 
-	fmt.Printf("🔍 Found %s results for '%s':\n\n", Info(len(results)), Symbol(symbol))
+	label := symbol
+	if label == "" {
+		label = "--tag " + searchTagFlag
+	}
+	if len(results) == 0 {
+		fmt.Printf("🔍 No results found for: %s\n", Warning(label))
+		return noResults(cmd)
+	}
+
+	fmt.Printf("🔍 Found %s results for '%s':\n\n", Info(len(results)), Symbol(label))
 	for _, r := range results {
 		relPath, err := filepath.Rel(cwd, r.File)
 		if err != nil {
 			relPath = r.File
 		}
-		fmt.Printf("  %s [%s]\n", Symbol(r.Name), Keyword(r.Kind))
+		fmt.Printf("  %s [%s]", Symbol(r.Name), Keyword(r.Kind))
+		if r.Package != "" {
+			fmt.Printf(" %s", Dim(r.Package))
+		}
+		if r.Source == "semantic" {
+			fmt.Printf(" %s", Dim(fmt.Sprintf("%.2f", r.Score)))
+		}
+		fmt.Println()
 		fmt.Printf("    %s\n", Path(fmt.Sprintf("%s:%d", relPath, r.Line)))
 
 		// Show signature if available, otherwise show source line
@@ -147,37 +242,36 @@ func runSearch(cmd *cobra.Command, args []string) error {
 
 func runSearchJSON(cmd *cobra.Command, symbol string) error {
 	out := cmd.OutOrStdout()
+	var query *string
+	if symbol != "" {
+		query = &symbol
+	}
 	emitErr := func(code string, err error) error {
-		_ = EmitJSON(out, "search", &symbol, []searchRecord{}, []EnvelopeError{{Code: code, Message: err.Error()}})
+		_ = EmitJSON(out, "search", query, []searchRecord{}, []EnvelopeError{{Code: code, Message: err.Error()}})
 		return err
 	}
 
-	cwd, _, dbManager, code, err := openProject(false)
+	cwd, cfg, dbManager, code, err := openProject(false)
 	if err != nil {
 		return emitErr(code, err)
 	}
 	defer dbManager.Close()
 
-	var languages []string
-	if searchLangFlag != "" {
-		languages = strings.Split(searchLangFlag, ",")
+	opts, code, err := searchOptions(symbol)
+	if err != nil {
+		return emitErr(code, err)
 	}
 
-	dbTier := search.NewDatabaseTier(dbManager)
-	rgTier := search.NewRipgrepTier(cwd)
-	orchestrator := search.NewOrchestrator(dbTier, rgTier)
-
-	opts := search.SearchOptions{
-		Query:      symbol,
-		Kind:       searchKindFlag,
-		Languages:  languages,
-		Limit:      searchLimitFlag,
-		ExactMatch: searchExactFlag,
+	orchestrator, code, err := newSearchOrchestrator(cfg, dbManager, cwd)
+	if err != nil {
+		return emitErr(code, err)
 	}
 
-	results, err := orchestrator.Search(context.Background(), opts)
+	ctx, cancel := withTimeout(context.Background(), commandTimeout(cmd, searchTimeoutFlag, cfg.Search.Timeout()))
+	defer cancel()
+	results, err := orchestrator.Search(ctx, opts)
 	if err != nil {
-		return emitErr("search_failed", fmt.Errorf("search failed: %w", err))
+		return emitErr("search_failed", fmt.Errorf("search failed: %w", timedOut(ctx, err)))
 	}
 
 	records := make([]searchRecord, 0, len(results))
@@ -186,15 +280,113 @@ func runSearchJSON(cmd *cobra.Command, symbol string) error {
 		if rerr != nil {
 			relPath = r.File
 		}
-		records = append(records, searchRecord{
+		rec := searchRecord{
 			Name:      r.Name,
 			Kind:      r.Kind,
 			File:      relPath,
 			Line:      r.Line,
 			Language:  r.Language,
 			Signature: r.Signature,
-		})
+			Package:   r.Package,
+		}
+		if r.Source == "semantic" {
+			rec.Score = r.Score
+		}
+		records = append(records, rec)
+	}
+
+	return emitResults(cmd, "search", query, records)
+}
+
+// searchOptions builds the search options from the command flags, rejecting
+// regular expressions that would otherwise fail inside every tier. On failure
+// it also returns the JSON error code.
+func searchOptions(query string) (search.SearchOptions, string, error) {
+	if searchRegexFlag {
+		if _, err := regexp.Compile(query); err != nil {
+			return search.SearchOptions{}, "invalid_pattern", fmt.Errorf("invalid regex %q: %w", query, err)
+		}
+	}
+
+	var page db.QueryOptions
+	if err := searchPageFlags.apply(&page); err != nil {
+		return search.SearchOptions{}, "invalid_flag", err
+	}
+
+	// Parse languages and kinds filters
+	var languages []string
+	if searchLangFlag != "" {
+		languages = strings.Split(searchLangFlag, ",")
 	}
+	kinds, excludeKinds := parseKindFilter(searchKindFlag)
 
-	return EmitJSON(out, "search", &symbol, records, nil)
+	return search.SearchOptions{
+		Query:        query,
+		Kinds:        kinds,
+		ExcludeKinds: excludeKinds,
+		Languages:    languages,
+		Offset:       page.Offset,
+		Limit:        page.Limit,
+		Sort:         page.Sort,
+		ExactMatch:   searchExactFlag,
+		Regex:        searchRegexFlag,
+		Glob:         searchGlobFlag,
+		Tests:        testFilter(),
+		Owner:        ownerFlag,
+		Package:      packageFlag,
+		Tags:         parseListFlag(searchTagFlag),
+	}, "", nil
+}
+
+// searchTierNames lists the tiers accepted by --tiers and [search] tiers
+var searchTierNames = []string{"db", "treesitter", "ripgrep", "grep", "semantic"}
+
+// newSearchOrchestrator builds the tier chain for the search flags: the
+// semantic tier alone for --semantic, the --tiers list when given, and the
+// [search] tiers from config.toml otherwise. On failure it also returns the
+// JSON error code.
+func newSearchOrchestrator(cfg *config.Config, dbManager *db.Manager, cwd string) (*search.Orchestrator, string, error) {
+	names := cfg.Search.TierOrder()
+	if searchTiersFlag != "" {
+		names = parseListFlag(searchTiersFlag)
+	}
+	if searchSemanticFlag {
+		names = []string{"semantic"}
+	}
+	if len(names) == 0 {
+		return nil, "invalid_flag", fmt.Errorf("no search tiers given (valid: %s)", strings.Join(searchTierNames, ", "))
+	}
+
+	ignorePath := filepath.Join(cwd, ".codegraph", ".cgignore")
+	if _, err := os.Stat(ignorePath); err != nil {
+		ignorePath = ""
+	}
+
+	tiers := make([]search.Tier, 0, len(names))
+	for _, name := range names {
+		switch name {
+		case "db":
+			tiers = append(tiers, search.NewDatabaseTier(dbManager))
+		case "treesitter":
+			tiers = append(tiers, search.NewTreeSitterTier(cwd, ignorePath, cfg.Index, cfg.Kinds))
+		case "ripgrep":
+			if _, err := exec.LookPath("rg"); err != nil {
+				// Degrade to the built-in scanner instead of failing every search
+				tiers = append(tiers, search.NewGrepTier(cwd, ignorePath, cfg.Index))
+				continue
+			}
+			tiers = append(tiers, search.NewRipgrepTier(cwd))
+		case "grep":
+			tiers = append(tiers, search.NewGrepTier(cwd, ignorePath, cfg.Index))
+		case "semantic":
+			provider, err := embed.NewProvider(cfg.Embeddings)
+			if err != nil {
+				return nil, "semantic_unavailable", err
+			}
+			tiers = append(tiers, search.NewSemanticTier(dbManager, provider))
+		default:
+			return nil, "invalid_flag", fmt.Errorf("unknown search tier %q (valid: %s)", name, strings.Join(searchTierNames, ", "))
+		}
+	}
+	return search.NewOrchestrator(tiers...), "", nil
 }
@@ -11,7 +11,15 @@ import (
 	"github.com/tk-425/Codegraph/internal/db"
 )
 
-var signatureLangFlag string
+var (
+	signatureLangFlag  string
+	signatureKindFlag  string
+	signaturePageFlags pageFlags
+)
+
+// defaultSignatureKinds are the kinds shown when --kind is not given
+// (variables are included for OCaml, where functions are let-bindings).
+var defaultSignatureKinds = []string{"function", "method", "variable"}
 
 var signatureCmd = &cobra.Command{
 	Use:   "signature <symbol>",
@@ -20,13 +28,16 @@ var signatureCmd = &cobra.Command{
 
 Examples:
   codegraph signature parseConfig
-  codegraph signature handleRequest --lang=go`,
+  codegraph signature handleRequest --lang=go
+  codegraph signature New --kind=constructor`,
 	Args: cobra.ExactArgs(1),
 	RunE: runSignature,
 }
 
 func init() {
 	signatureCmd.Flags().StringVar(&signatureLangFlag, "lang", "", "Filter by language(s), comma-separated")
+	signatureCmd.Flags().StringVar(&signatureKindFlag, "kind", "", kindFlagUsage)
+	signaturePageFlags.register(signatureCmd, 0)
 	rootCmd.AddCommand(signatureCmd)
 }
 
@@ -56,7 +67,7 @@ func runSignature(cmd *cobra.Command, args []string) error {
 	// Check if codegraph is initialized
 	codegraphDir := filepath.Join(cwd, ".codegraph")
 	if _, err := os.Stat(codegraphDir); os.IsNotExist(err) {
-		return fmt.Errorf("codegraph not initialized. Run 'codegraph init' first")
+		return errNotInitialized
 	}
 
 	// Load config
@@ -73,29 +84,20 @@ func runSignature(cmd *cobra.Command, args []string) error {
 	}
 	defer dbManager.Close()
 
-	// Parse languages filter
-	var languages []string
-	if signatureLangFlag != "" {
-		languages = strings.Split(signatureLangFlag, ",")
+	opts, err := signatureQueryOptions()
+	if err != nil {
+		return err
 	}
 
-	// Find symbols in database
-	symbols, err := dbManager.GetSymbolByName(symbol, languages)
+	// Find functions/methods in database (narrowed by --lang/--kind)
+	filtered, err := dbManager.FindSymbolsByName(symbol, opts)
 	if err != nil {
 		return fmt.Errorf("failed to find symbol: %w", err)
 	}
 
-	// Filter to only functions/methods (and variables for OCaml where functions are let-bindings)
-	var filtered []db.Symbol
-	for _, sym := range symbols {
-		if sym.Kind == "function" || sym.Kind == "method" || sym.Kind == "variable" {
-			filtered = append(filtered, sym)
-		}
-	}
-
 	if len(filtered) == 0 {
 		fmt.Printf("📝 No function/method named '%s' found\n", Warning(symbol))
-		return nil
+		return noResults(cmd)
 	}
 
 	fmt.Printf("📝 Signature for '%s' (%s found):\n\n", Symbol(symbol), Info(len(filtered)))
@@ -132,27 +134,24 @@ func runSignatureJSON(cmd *cobra.Command, symbol string) error {
 		return err
 	}
 
+	opts, err := signatureQueryOptions()
+	if err != nil {
+		return emitErr("invalid_flag", err)
+	}
+
 	cwd, _, dbManager, code, err := openProject(false)
 	if err != nil {
 		return emitErr(code, err)
 	}
 	defer dbManager.Close()
 
-	var languages []string
-	if signatureLangFlag != "" {
-		languages = strings.Split(signatureLangFlag, ",")
-	}
-
-	symbols, err := dbManager.GetSymbolByName(symbol, languages)
+	symbols, err := dbManager.FindSymbolsByName(symbol, opts)
 	if err != nil {
 		return emitErr("signature_lookup_failed", fmt.Errorf("failed to find symbol: %w", err))
 	}
 
 	records := make([]signatureRecord, 0, len(symbols))
 	for _, sym := range symbols {
-		if sym.Kind != "function" && sym.Kind != "method" && sym.Kind != "variable" {
-			continue
-		}
 		relPath, rerr := filepath.Rel(cwd, sym.File)
 		if rerr != nil {
 			relPath = sym.File
@@ -167,7 +166,18 @@ func runSignatureJSON(cmd *cobra.Command, symbol string) error {
 		})
 	}
 
-	return EmitJSON(out, "signature", &symbol, records, nil)
+	return emitResults(cmd, "signature", &symbol, records)
+}
+
+// signatureQueryOptions builds the filter for signature lookups, falling back
+// to function-like kinds when --kind does not name any kinds to keep.
+func signatureQueryOptions() (db.QueryOptions, error) {
+	opts := queryOptions(signatureLangFlag, signatureKindFlag)
+	if len(opts.Kinds) == 0 {
+		opts.Kinds = defaultSignatureKinds
+	}
+	err := signaturePageFlags.apply(&opts)
+	return opts, err
 }
 
 // colorizeSignature adds colors to a function signature
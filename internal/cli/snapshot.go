@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tk-425/Codegraph/internal/snapshot"
+)
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Save labelled copies of the index to diff later",
+	Long: `Create, list, and delete labelled snapshots of the project's index.
+
+A snapshot is a copy of the database as of the last build, kept in
+.codegraph/snapshots/. Compare two of them, or one with the live index,
+using 'codegraph diff'.
+
+Examples:
+  codegraph snapshot create before-upgrade
+  codegraph snapshot list
+  codegraph snapshot delete before-upgrade`,
+}
+
+var snapshotCreateCmd = &cobra.Command{
+	Use:   "create <label>",
+	Short: "Snapshot the current index under a label",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runSnapshotCreate,
+}
+
+var snapshotListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the project's snapshots",
+	Args:  cobra.NoArgs,
+	RunE:  runSnapshotList,
+}
+
+var snapshotDeleteCmd = &cobra.Command{
+	Use:   "delete <label>",
+	Short: "Delete a snapshot",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runSnapshotDelete,
+}
+
+func init() {
+	snapshotCmd.AddCommand(snapshotCreateCmd, snapshotListCmd, snapshotDeleteCmd)
+	rootCmd.AddCommand(snapshotCmd)
+}
+
+// snapshotRecord is the JSON form of a snapshot.Info
+type snapshotRecord struct {
+	Label   string    `json:"label"`
+	Created time.Time `json:"created"`
+	Size    int64     `json:"size"`
+}
+
+func runSnapshotCreate(cmd *cobra.Command, args []string) error {
+	cwd, _, dbManager, _, err := openProject(true)
+	if err != nil {
+		return err
+	}
+	defer dbManager.Close()
+
+	info, err := snapshot.Create(dbManager, cwd, args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Printf("📸 Saved snapshot %s (%s)\n", Symbol(info.Label), Info(formatBytes(info.Size)))
+	return nil
+}
+
+func runSnapshotList(cmd *cobra.Command, args []string) error {
+	if jsonOutputFlag {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		return runSnapshotListJSON(cmd)
+	}
+
+	cwd, _, dbManager, _, err := openProject(true)
+	if err != nil {
+		return err
+	}
+	defer dbManager.Close()
+
+	infos, err := snapshot.List(cwd)
+	if err != nil {
+		return err
+	}
+	if len(infos) == 0 {
+		fmt.Println("📸 No snapshots yet. Create one with 'codegraph snapshot create <label>'")
+		return nil
+	}
+	fmt.Printf("📸 Snapshots (%s):\n\n", Info(len(infos)))
+	for _, info := range infos {
+		fmt.Printf("  %s  %s  %s\n", Symbol(info.Label), Dim(info.Created.Format("2006-01-02 15:04")), Info(formatBytes(info.Size)))
+	}
+	return nil
+}
+
+func runSnapshotListJSON(cmd *cobra.Command) error {
+	out := cmd.OutOrStdout()
+	emitErr := func(code string, err error) error {
+		_ = EmitJSON(out, "snapshot list", nil, []snapshotRecord{}, []EnvelopeError{{Code: code, Message: err.Error()}})
+		return err
+	}
+
+	cwd, _, dbManager, code, err := openProject(true)
+	if err != nil {
+		return emitErr(code, err)
+	}
+	defer dbManager.Close()
+
+	infos, err := snapshot.List(cwd)
+	if err != nil {
+		return emitErr("snapshot_list_failed", err)
+	}
+	records := make([]snapshotRecord, 0, len(infos))
+	for _, info := range infos {
+		records = append(records, snapshotRecord{Label: info.Label, Created: info.Created, Size: info.Size})
+	}
+	return EmitJSON(out, "snapshot list", nil, records, nil)
+}
+
+func runSnapshotDelete(cmd *cobra.Command, args []string) error {
+	cwd, _, dbManager, _, err := openProject(true)
+	if err != nil {
+		return err
+	}
+	defer dbManager.Close()
+
+	if err := snapshot.Delete(cwd, args[0]); err != nil {
+		return err
+	}
+	fmt.Printf("🗑️  Deleted snapshot %s\n", Symbol(args[0]))
+	return nil
+}
@@ -0,0 +1,171 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/tk-425/Codegraph/internal/bundle"
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+var (
+	snippetLangFlag        string
+	snippetKindFlag        string
+	snippetContextFlag     int
+	snippetLineNumbersFlag bool
+)
+
+var snippetCmd = &cobra.Command{
+	Use:   "snippet <symbol>",
+	Short: "Print the full source of a symbol",
+	Long: `Print the source text of a symbol's body, from its first line to its
+recorded end position.
+
+Examples:
+  codegraph snippet handleRequest
+  codegraph snippet Config --kind=struct --context=3
+  codegraph snippet parse --lang=go -n`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSnippet,
+}
+
+func init() {
+	snippetCmd.Flags().StringVar(&snippetLangFlag, "lang", "", "Filter by language(s), comma-separated")
+	snippetCmd.Flags().StringVar(&snippetKindFlag, "kind", "", kindFlagUsage)
+	snippetCmd.Flags().IntVarP(&snippetContextFlag, "context", "C", 0, "Lines of surrounding context to include")
+	snippetCmd.Flags().BoolVarP(&snippetLineNumbersFlag, "line-numbers", "n", false, "Prefix each line with its line number")
+	rootCmd.AddCommand(snippetCmd)
+}
+
+type snippetRecord struct {
+	Name      string `json:"name"`
+	Kind      string `json:"kind"`
+	File      string `json:"file"`
+	Language  string `json:"language"`
+	Line      int    `json:"line"`       // Symbol start
+	EndLine   int    `json:"end_line"`   // Symbol end
+	StartLine int    `json:"start_line"` // First line of source (with context)
+	LastLine  int    `json:"last_line"`  // Last line of source (with context)
+	Source    string `json:"source"`
+}
+
+func runSnippet(cmd *cobra.Command, args []string) error {
+	symbol := args[0]
+	if jsonOutputFlag {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		return runSnippetJSON(cmd, symbol)
+	}
+
+	cwd, _, dbManager, _, err := openProject(false)
+	if err != nil {
+		return err
+	}
+	defer dbManager.Close()
+
+	symbols, err := dbManager.FindSymbolsByName(symbol, queryOptions(snippetLangFlag, snippetKindFlag))
+	if err != nil {
+		return fmt.Errorf("failed to find symbol: %w", err)
+	}
+
+	if len(symbols) == 0 {
+		fmt.Printf("📄 No symbol named '%s' found\n", Warning(symbol))
+		return noResults(cmd)
+	}
+
+	fmt.Printf("📄 Source of %s (%s found):\n\n", Symbol(symbol), Info(len(symbols)))
+	for _, sym := range symbols {
+		snippet, err := readSymbolSnippet(sym)
+		relPath := relativePath(cwd, sym.File)
+		if err != nil {
+			fmt.Printf("  %s [%s] %s\n", Symbol(sym.Name), Keyword(sym.Kind), Warning(fmt.Sprintf("(%v)", err)))
+			fmt.Println()
+			continue
+		}
+
+		fmt.Printf("  %s [%s]\n", Symbol(sym.Name), Keyword(sym.Kind))
+		fmt.Printf("    %s\n\n", Path(fmt.Sprintf("%s:%d-%d", relPath, snippet.StartLine, snippet.EndLine())))
+		if snippetLineNumbersFlag {
+			fmt.Println(snippet.Numbered())
+		} else {
+			fmt.Println(snippet.Text())
+		}
+		fmt.Println()
+	}
+
+	return nil
+}
+
+func runSnippetJSON(cmd *cobra.Command, symbol string) error {
+	out := cmd.OutOrStdout()
+	emitErr := func(code string, err error) error {
+		_ = EmitJSON(out, "snippet", &symbol, []snippetRecord{}, []EnvelopeError{{Code: code, Message: err.Error()}})
+		return err
+	}
+
+	cwd, _, dbManager, code, err := openProject(false)
+	if err != nil {
+		return emitErr(code, err)
+	}
+	defer dbManager.Close()
+
+	symbols, err := dbManager.FindSymbolsByName(symbol, queryOptions(snippetLangFlag, snippetKindFlag))
+	if err != nil {
+		return emitErr("snippet_lookup_failed", fmt.Errorf("failed to find symbol: %w", err))
+	}
+
+	records := make([]snippetRecord, 0, len(symbols))
+	var errs []EnvelopeError
+	for _, sym := range symbols {
+		snippet, err := readSymbolSnippet(sym)
+		if err != nil {
+			// Report unreadable files without dropping the other matches
+			errs = append(errs, EnvelopeError{Code: "source_unreadable", Message: err.Error()})
+			continue
+		}
+		source := snippet.Text()
+		if snippetLineNumbersFlag {
+			source = snippet.Numbered()
+		}
+		records = append(records, snippetRecord{
+			Name:      sym.Name,
+			Kind:      sym.Kind,
+			File:      relativePath(cwd, sym.File),
+			Language:  sym.Language,
+			Line:      sym.Line,
+			EndLine:   symbolEndLine(sym),
+			StartLine: snippet.StartLine,
+			LastLine:  snippet.EndLine(),
+			Source:    source,
+		})
+	}
+
+	if err := EmitJSON(out, "snippet", &symbol, records, errs); err != nil {
+		return err
+	}
+	if len(symbols) == 0 {
+		return noResults(cmd)
+	}
+	return nil
+}
+
+// readSymbolSnippet reads a symbol's body plus --context lines around it.
+func readSymbolSnippet(sym db.Symbol) (*bundle.Snippet, error) {
+	endColumn := 0
+	if sym.EndColumn != nil {
+		endColumn = *sym.EndColumn
+	}
+	if sym.Source == "lsp" {
+		return bundle.ReadLSPSnippet(sym.File, sym.Line, symbolEndLine(sym), endColumn, snippetContextFlag)
+	}
+	return bundle.ReadSnippet(sym.File, sym.Line, symbolEndLine(sym), endColumn, snippetContextFlag)
+}
+
+// symbolEndLine returns the symbol's last line, falling back to its first
+// line when no end position was recorded.
+func symbolEndLine(sym db.Symbol) int {
+	if sym.EndLine != nil && *sym.EndLine >= sym.Line {
+		return *sym.EndLine
+	}
+	return sym.Line
+}
@@ -0,0 +1,237 @@
+package cli
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+var (
+	sqlLimitFlag  int
+	sqlFormatFlag string
+	sqlViewsFlag  bool
+)
+
+var sqlQueryCmd = &cobra.Command{
+	Use:   "query <sql>",
+	Short: "Run a read-only SQL query against the index",
+	Long: `Run an SQL query against the index, for analyses the other commands
+do not cover, without finding the database or installing sqlite3. Pass -
+to read the query from standard input.
+
+The index is opened read-only (PRAGMA query_only): statements that would
+change it, ATTACH, and pragmas that set values fail. Besides the tables
+(symbols, calls, type_hierarchy, contains, external_calls, tags, ...;
+see them with "SELECT name FROM sqlite_master WHERE type = 'table'"),
+these views name both ends of each edge:
+
+` + queryViewsHelp() + `
+Examples:
+  codegraph query "SELECT kind, COUNT(*) FROM symbols GROUP BY kind"
+  codegraph query "SELECT callee_name, COUNT(*) n FROM v_calls_named GROUP BY callee_name ORDER BY n DESC LIMIT 10"
+  codegraph query "SELECT child_name, parent_name FROM v_hierarchy_named WHERE relationship = 'implements'"
+  codegraph query --format=csv "SELECT * FROM symbols WHERE kind = 'class'" > classes.csv
+  codegraph query - < report.sql
+  codegraph query --views`,
+	Args: cobra.RangeArgs(0, 1),
+	RunE: runSQLQuery,
+}
+
+func init() {
+	sqlQueryCmd.Flags().IntVar(&sqlLimitFlag, "limit", 1000, "Max rows to show (0 = unlimited)")
+	sqlQueryCmd.Flags().StringVar(&sqlFormatFlag, "format", formatText, "Output format: text or csv")
+	sqlQueryCmd.Flags().BoolVar(&sqlViewsFlag, "views", false, "List the views available to queries")
+	rootCmd.AddCommand(sqlQueryCmd)
+}
+
+// queryViewsHelp lists db.QueryViews for the command's help
+func queryViewsHelp() string {
+	var b strings.Builder
+	for _, v := range db.QueryViews {
+		fmt.Fprintf(&b, "  %-18s %s\n", v.Name, v.Description)
+	}
+	return b.String()
+}
+
+// sqlQueryText returns the query given as args, reading standard input
+// for -
+func sqlQueryText(args []string) (string, error) {
+	if sqlLimitFlag < 0 {
+		return "", fmt.Errorf("--limit must not be negative")
+	}
+	switch sqlFormatFlag {
+	case formatText, "":
+	case formatCSV:
+		if jsonOutputFlag {
+			return "", fmt.Errorf("--format=csv cannot be combined with --json")
+		}
+	default:
+		return "", fmt.Errorf("invalid --format %q (expected text or csv)", sqlFormatFlag)
+	}
+	if len(args) == 0 {
+		return "", fmt.Errorf("missing query (or --views to list the views)")
+	}
+	query := args[0]
+	if query == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("failed to read query: %w", err)
+		}
+		query = string(data)
+	}
+	if strings.TrimSpace(query) == "" {
+		return "", fmt.Errorf("empty query")
+	}
+	return query, nil
+}
+
+// sqlValue renders a column value as text; NULL is ""
+func sqlValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func runSQLQuery(cmd *cobra.Command, args []string) error {
+	if jsonOutputFlag {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		return runSQLQueryJSON(cmd, args)
+	}
+	if sqlViewsFlag {
+		fmt.Print(queryViewsHelp())
+		return nil
+	}
+	query, err := sqlQueryText(args)
+	if err != nil {
+		return err
+	}
+
+	_, _, dbManager, _, err := openProject(true)
+	if err != nil {
+		return err
+	}
+	defer dbManager.Close()
+
+	result, err := dbManager.RunQuery(context.Background(), query, sqlLimitFlag)
+	if err != nil {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("query failed: %w", err)
+	}
+	return printQueryResult(cmd.OutOrStdout(), result)
+}
+
+// printQueryResult writes result as a table or, with --format=csv, as CSV
+func printQueryResult(out io.Writer, result *db.QueryResult) error {
+	if sqlFormatFlag == formatCSV {
+		w := csv.NewWriter(out)
+		if err := w.Write(result.Columns); err != nil {
+			return err
+		}
+		for _, row := range result.Rows {
+			record := make([]string, len(row))
+			for i, v := range row {
+				record[i] = sqlValue(v)
+			}
+			if err := w.Write(record); err != nil {
+				return err
+			}
+		}
+		w.Flush()
+		return w.Error()
+	}
+
+	if len(result.Columns) == 0 {
+		fmt.Fprintln(out, "✅ Done")
+		return nil
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.ToUpper(strings.Join(result.Columns, "\t")))
+	for _, row := range result.Rows {
+		cells := make([]string, len(row))
+		for i, v := range row {
+			// One line per row, however long or multi-line the value
+			cells[i] = strings.Join(strings.Fields(sqlValue(v)), " ")
+			if v == nil {
+				cells[i] = "NULL"
+			}
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+	w.Flush()
+
+	fmt.Fprintf(out, "\n%s\n", Dim(fmt.Sprintf("(%d rows)", len(result.Rows))))
+	if result.Truncated {
+		fmt.Fprintf(out, "%s\n", Warning(fmt.Sprintf("Showing the first %d rows; use --limit to see more", sqlLimitFlag)))
+	}
+	return nil
+}
+
+// runSQLQueryJSON runs the query in args, binding params to its
+// parameters
+func runSQLQueryJSON(cmd *cobra.Command, args []string, params ...interface{}) error {
+	out := cmd.OutOrStdout()
+	var query *string
+	emitErr := func(code string, err error) error {
+		_ = EmitJSON(out, "query", query, []map[string]interface{}{}, []EnvelopeError{{Code: code, Message: err.Error()}})
+		return err
+	}
+
+	if sqlViewsFlag {
+		views := make([]map[string]interface{}, 0, len(db.QueryViews))
+		for _, v := range db.QueryViews {
+			views = append(views, map[string]interface{}{"name": v.Name, "description": v.Description})
+		}
+		return EmitJSON(out, "query", nil, views, nil)
+	}
+	text, err := sqlQueryText(args)
+	if err != nil {
+		return emitErr("invalid_flag", err)
+	}
+	query = &text
+
+	_, _, dbManager, code, err := openProject(true)
+	if err != nil {
+		return emitErr(code, err)
+	}
+	defer dbManager.Close()
+
+	result, err := dbManager.RunQuery(context.Background(), text, sqlLimitFlag, params...)
+	if err != nil {
+		return emitErr("query_failed", err)
+	}
+	return EmitJSON(out, "query", query, queryRecords(result), nil)
+}
+
+// queryRecords returns the rows of result as objects keyed by column
+func queryRecords(result *db.QueryResult) []map[string]interface{} {
+	records := make([]map[string]interface{}, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		record := make(map[string]interface{}, len(row))
+		for i, v := range row {
+			switch v := v.(type) {
+			case []byte:
+				record[result.Columns[i]] = string(v)
+			default:
+				record[result.Columns[i]] = v
+			}
+		}
+		records = append(records, record)
+	}
+	return records
+}
@@ -0,0 +1,170 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+
+	"github.com/spf13/cobra"
+	"github.com/tk-425/Codegraph/internal/config"
+	"github.com/tk-425/Codegraph/internal/db"
+	"github.com/tk-425/Codegraph/internal/history"
+	"github.com/tk-425/Codegraph/internal/indexer"
+)
+
+// autoBuildFlag makes query commands update a stale index before answering
+var autoBuildFlag bool
+
+// staleCheckedCommands are the query commands that check the index against
+// the working tree before they run
+var staleCheckedCommands = []*cobra.Command{
+	searchCmd, callersCmd, calleesCmd, implementationsCmd, signatureCmd,
+	snippetCmd, contextCmd, fieldsCmd, typesCmd, grepCallsCmd, renameCheckCmd,
+	blameCmd, ownersCmd, testCoverageCmd, reachableCmd, flowsCmd, hotspotsCmd,
+	deprecatedCmd, routesCmd, componentsCmd, entrypointsCmd, concurrencyCmd,
+	openCmd,
+}
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&autoBuildFlag, "auto-build", false, "Query commands: run an incremental build first when files changed since the last one (config: auto_build under [index])")
+}
+
+// staleness is how the working tree moved on since the last build
+type staleness struct {
+	changed  int    // Files an incremental build would re-index or drop
+	builtAt  string // Commit the index was built from, if any
+	headNow  string // Commit checked out now, when files changed and it differs from builtAt
+	complete bool   // Whether the last build finished
+}
+
+// fresh reports whether the index matches the working tree. A commit
+// alone does not make it stale: the index only holds file contents.
+func (s *staleness) fresh() bool {
+	return s.changed == 0 && s.complete
+}
+
+// findStaleness compares the files of the project at cwd with the index.
+// It returns nil when the index's paths are not under cwd, e.g. for a
+// shared index built elsewhere, as there is nothing to compare them with.
+func findStaleness(cwd string, cfg *config.Config, dbManager *db.Manager) (*staleness, error) {
+	root, err := dbManager.GetMeta(db.MetaRoot)
+	if err != nil {
+		return nil, err
+	}
+	shard, err := dbManager.GetMeta(db.MetaShard)
+	if err != nil {
+		return nil, err
+	}
+	if root != cwd || shard != "" {
+		return nil, nil
+	}
+
+	scanner, err := indexer.NewScannerWithConfig(cwd, filepath.Join(cwd, config.DefaultConfigDir, ".cgignore"), cfg.Index)
+	if err != nil {
+		return nil, err
+	}
+	scanner.SetWorkspaces(cfg.Workspaces)
+	files, err := scanner.Scan()
+	if err != nil {
+		return nil, err
+	}
+	changes, err := indexer.FindChanges(dbManager, files)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &staleness{changed: len(changes.Modified) + len(changes.Removed)}
+	interrupted, err := dbManager.GetMeta(db.MetaInterrupted)
+	if err != nil {
+		return nil, err
+	}
+	s.complete = interrupted == ""
+	if s.builtAt, err = dbManager.GetMeta(db.MetaGitHead); err != nil {
+		return nil, err
+	}
+	if s.changed > 0 && s.builtAt != "" {
+		if head, _ := history.Head(context.Background(), cwd); head != "" && head != s.builtAt {
+			s.headNow = head
+		}
+	}
+	return s, nil
+}
+
+// describe says how the index is behind, e.g. "12 files changed since the
+// last build (HEAD moved from 1a2b3c4 to 5d6e7f8)"
+func (s *staleness) describe() string {
+	var text string
+	switch {
+	case !s.complete:
+		return "the last build did not finish"
+	case s.changed == 1:
+		text = "1 file changed since the last build"
+	default:
+		text = fmt.Sprintf("%d files changed since the last build", s.changed)
+	}
+	if s.headNow != "" {
+		text += fmt.Sprintf(" (HEAD moved from %s to %s)", shortHash(s.builtAt), shortHash(s.headNow))
+	}
+	return text
+}
+
+// checkStaleIndex warns on stderr when cmd is a query command and the index
+// is behind the working tree, or runs an incremental build first with
+// --auto-build or auto_build under [index]. Problems opening the project
+// are left for the command to report.
+func checkStaleIndex(cmd *cobra.Command) {
+	if !slices.Contains(staleCheckedCommands, cmd) {
+		return
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return
+	}
+	if _, err := os.Stat(filepath.Join(cwd, config.DefaultConfigDir)); err != nil {
+		return
+	}
+	cfg, err := config.Load(cwd)
+	if err != nil {
+		return
+	}
+	autoBuild := (autoBuildFlag || cfg.Index.AutoBuild) && !cfg.Database.ReadOnly
+	if !cfg.Index.StaleCheck && !autoBuild {
+		return
+	}
+	// A shared index is read through its copy rebased onto cwd
+	var dbManager *db.Manager
+	if cfg.Database.ReadOnly {
+		dbManager, err = openDatabase(cfg, cwd)
+	} else {
+		dbManager, err = db.NewReadOnlyManager(cfg.GetDatabasePath(cwd))
+	}
+	if err != nil {
+		return
+	}
+	s, err := findStaleness(cwd, cfg, dbManager)
+	dbManager.Close()
+	if err != nil || s == nil || s.fresh() {
+		return
+	}
+
+	// Standard output is the command's; --json needs it to hold the envelope
+	stderr := cmd.ErrOrStderr()
+	if !autoBuild {
+		fmt.Fprintf(stderr, "⚠️  %s; run %s or pass --auto-build\n", Warning("Index is stale: "+s.describe()), Keyword("codegraph build"))
+		return
+	}
+	fmt.Fprintf(stderr, "🔁 %s; updating the index...\n", Info(s.describe()))
+	self, err := os.Executable()
+	if err == nil {
+		build := exec.Command(self, "build", "--no-progress")
+		build.Dir = cwd
+		build.Stdout, build.Stderr = stderr, stderr
+		err = build.Run()
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "⚠️  %s\n", Warning(fmt.Sprintf("Auto-build failed, answering from the stale index: %v", err)))
+	}
+}
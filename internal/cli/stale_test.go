@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tk-425/Codegraph/internal/config"
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+func TestFindStaleness(t *testing.T) {
+	_, m := setupCodegraphProject(t)
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Getwd: %v", err)
+	}
+	cfg := config.DefaultConfig()
+	if err := os.WriteFile(filepath.Join(cwd, ".codegraph", ".cgignore"), nil, 0o644); err != nil {
+		t.Fatalf("write .cgignore: %v", err)
+	}
+
+	indexed := filepath.Join(cwd, "main.go")
+	if err := os.WriteFile(indexed, []byte("package main\n"), 0o644); err != nil {
+		t.Fatalf("write main.go: %v", err)
+	}
+	if err := m.UpdateFileMeta(indexed, time.Now().Add(time.Hour), "go", ""); err != nil {
+		t.Fatalf("UpdateFileMeta: %v", err)
+	}
+
+	// An index built elsewhere has nothing to compare with
+	if err := m.SetMeta(db.MetaRoot, "/elsewhere"); err != nil {
+		t.Fatalf("SetMeta: %v", err)
+	}
+	if s, err := findStaleness(cwd, cfg, m); err != nil || s != nil {
+		t.Fatalf("findStaleness for another root = %+v, %v; want nil", s, err)
+	}
+
+	if err := m.SetMeta(db.MetaRoot, cwd); err != nil {
+		t.Fatalf("SetMeta: %v", err)
+	}
+	s, err := findStaleness(cwd, cfg, m)
+	if err != nil {
+		t.Fatalf("findStaleness: %v", err)
+	}
+	if !s.fresh() {
+		t.Fatalf("findStaleness = %+v; want fresh", s)
+	}
+
+	// A new file and a deleted one are both changes
+	if err := os.WriteFile(filepath.Join(cwd, "util.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatalf("write util.go: %v", err)
+	}
+	if err := m.UpdateFileMeta(filepath.Join(cwd, "gone.go"), time.Now(), "go", ""); err != nil {
+		t.Fatalf("UpdateFileMeta: %v", err)
+	}
+	s, err = findStaleness(cwd, cfg, m)
+	if err != nil {
+		t.Fatalf("findStaleness: %v", err)
+	}
+	if s.fresh() || s.changed != 2 {
+		t.Fatalf("findStaleness = %+v; want 2 changed files", s)
+	}
+	if got, want := s.describe(), "2 files changed since the last build"; got != want {
+		t.Errorf("describe() = %q; want %q", got, want)
+	}
+}
+
+func TestStalenessDescribe(t *testing.T) {
+	tests := []struct {
+		s    staleness
+		want string
+	}{
+		{staleness{changed: 1, complete: true}, "1 file changed since the last build"},
+		{staleness{changed: 3}, "the last build did not finish"},
+		{
+			staleness{changed: 4, builtAt: "1a2b3c4d5e", headNow: "5d6e7f8a9b", complete: true},
+			"4 files changed since the last build (HEAD moved from 1a2b3c4 to 5d6e7f8)",
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.s.describe(); got != tt.want {
+			t.Errorf("describe(%+v) = %q; want %q", tt.s, got, tt.want)
+		}
+	}
+
+	// A commit that changed no indexed file leaves the index fresh
+	if s := (staleness{builtAt: "1a2b3c4d5e", headNow: "5d6e7f8a9b", complete: true}); !s.fresh() {
+		t.Errorf("%+v is stale; want fresh", s)
+	}
+}
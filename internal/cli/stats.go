@@ -4,14 +4,22 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/tk-425/Codegraph/internal/config"
 	"github.com/tk-425/Codegraph/internal/db"
+	"github.com/tk-425/Codegraph/internal/indexer"
 )
 
-var statsCompact bool
+var (
+	statsCompact   bool
+	statsLastBuild bool
+	statsByDir     bool
+	statsByFile    bool
+	statsDepth     int
+)
 
 type statsLangRecord struct {
 	Language string  `json:"language"`
@@ -19,6 +27,15 @@ type statsLangRecord struct {
 	Percent  float64 `json:"percent"`
 }
 
+type statsPathRecord struct {
+	Path      string   `json:"path"`
+	Files     int      `json:"files"`
+	Symbols   int      `json:"symbols"`
+	Functions int      `json:"functions"`
+	CallEdges int      `json:"call_edges"`
+	Languages []string `json:"languages"`
+}
+
 type statsRecord struct {
 	TotalSymbols  int               `json:"total_symbols"`
 	Functions     int               `json:"functions"`
@@ -37,6 +54,7 @@ type statsRecord struct {
 	FilesIndexed  int               `json:"files_indexed"`
 	DatabasePath  string            `json:"database_path"`
 	DatabaseSize  int64             `json:"database_size"`
+	Paths         []statsPathRecord `json:"paths,omitempty"`
 }
 
 var statsCmd = &cobra.Command{
@@ -45,16 +63,40 @@ var statsCmd = &cobra.Command{
 	Long: `Display comprehensive statistics about the indexed codebase.
 
 Shows symbol counts by kind, call graph edges, language breakdown,
-last build time, and database information.`,
+last build time, and database information.
+
+Use --by-dir or --by-file to break the index down by directory or file
+(files, symbols, functions, call edges and languages, largest first) and
+see which parts of the codebase dominate it or are thinly covered.
+--depth rolls directories up, e.g. --depth=1 for top-level directories.
+
+Use --last-build to inspect the report of the most recent build instead:
+failed files and their errors, warnings, and the slowest files. With
+--json it emits the full report, including every file's status.
+
+Examples:
+  codegraph stats
+  codegraph stats --by-dir --depth=2
+  codegraph stats --by-file --json
+  codegraph stats --last-build
+  codegraph stats --last-build --json`,
 	RunE: runStats,
 }
 
 func init() {
 	rootCmd.AddCommand(statsCmd)
 	statsCmd.Flags().BoolVar(&statsCompact, "compact", false, "Compact output format")
+	statsCmd.Flags().BoolVar(&statsLastBuild, "last-build", false, "Show the report of the last build")
+	statsCmd.Flags().BoolVar(&statsByDir, "by-dir", false, "Break the statistics down by directory")
+	statsCmd.Flags().BoolVar(&statsByFile, "by-file", false, "Break the statistics down by file")
+	statsCmd.Flags().IntVar(&statsDepth, "depth", 0, "With --by-dir, group directories by their first N path components (0 = full path)")
+	statsCmd.MarkFlagsMutuallyExclusive("by-dir", "by-file")
 }
 
 func runStats(cmd *cobra.Command, args []string) error {
+	if statsLastBuild {
+		return runStatsLastBuild(cmd)
+	}
 	if jsonOutputFlag {
 		cmd.SilenceUsage = true
 		cmd.SilenceErrors = true
@@ -70,7 +112,7 @@ func runStats(cmd *cobra.Command, args []string) error {
 	// Check if codegraph is initialized
 	codegraphDir := filepath.Join(cwd, ".codegraph")
 	if _, err := os.Stat(codegraphDir); os.IsNotExist(err) {
-		return fmt.Errorf("not initialized. Run 'codegraph init' first")
+		return errNotInitialized
 	}
 
 	// Load config
@@ -82,7 +124,7 @@ func runStats(cmd *cobra.Command, args []string) error {
 	// Get database path
 	dbPath := cfg.GetDatabasePath(cwd)
 	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
-		return fmt.Errorf("database not found. Run 'codegraph build' first")
+		return errIndexMissing
 	}
 
 	// Open database
@@ -97,6 +139,11 @@ func runStats(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to get stats: %w", err)
 	}
+	if statsByDir || statsByFile {
+		if stats.Paths, err = dbManager.GetPathStats(cwd, statsByDir, statsDepth); err != nil {
+			return fmt.Errorf("failed to get stats: %w", err)
+		}
+	}
 
 	// Output based on flags
 	if statsCompact {
@@ -115,7 +162,7 @@ func runStatsJSON(cmd *cobra.Command) error {
 		return err
 	}
 
-	_, _, dbManager, code, err := openProject(true)
+	cwd, _, dbManager, code, err := openProject(true)
 	if err != nil {
 		return emitErr(code, err)
 	}
@@ -125,6 +172,11 @@ func runStatsJSON(cmd *cobra.Command) error {
 	if err != nil {
 		return emitErr("stats_failed", fmt.Errorf("failed to get stats: %w", err))
 	}
+	if statsByDir || statsByFile {
+		if stats.Paths, err = dbManager.GetPathStats(cwd, statsByDir, statsDepth); err != nil {
+			return emitErr("stats_failed", fmt.Errorf("failed to get stats: %w", err))
+		}
+	}
 
 	langs := make([]statsLangRecord, 0, len(stats.Languages))
 	for _, l := range stats.Languages {
@@ -150,10 +202,50 @@ func runStatsJSON(cmd *cobra.Command) error {
 		DatabasePath:  stats.DatabasePath,
 		DatabaseSize:  stats.DatabaseSize,
 	}
+	for _, p := range stats.Paths {
+		if p.Languages == nil {
+			p.Languages = []string{}
+		}
+		rec.Paths = append(rec.Paths, statsPathRecord{
+			Path:      p.Path,
+			Files:     p.Files,
+			Symbols:   p.Symbols,
+			Functions: p.Functions,
+			CallEdges: p.CallEdges,
+			Languages: p.Languages,
+		})
+	}
 
 	return EmitJSON(out, "stats", nil, []statsRecord{rec}, nil)
 }
 
+// runStatsLastBuild shows .codegraph/last-build.json
+func runStatsLastBuild(cmd *cobra.Command) error {
+	out := cmd.OutOrStdout()
+	if jsonOutputFlag {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+	report, err := indexer.LoadReport(cwd)
+	if err != nil {
+		if jsonOutputFlag {
+			_ = EmitJSON(out, "stats", nil, []indexer.BuildReport{}, []EnvelopeError{{Code: "no_build_report", Message: err.Error()}})
+		}
+		return err
+	}
+
+	if jsonOutputFlag {
+		return EmitJSON(out, "stats", nil, []indexer.BuildReport{*report}, nil)
+	}
+	printBuildReport(out, report)
+	return nil
+}
+
 func printStats(stats *db.DetailedStats, projectPath string) {
 	// Header
 	fmt.Printf("CodeGraph Status for: %s\n\n", Path(projectPath))
@@ -198,6 +290,39 @@ func printStats(stats *db.DetailedStats, projectPath string) {
 	fmt.Printf("💾 %s\n", Bold("Database"))
 	fmt.Printf("   Path:    %s\n", Path(stats.DatabasePath))
 	fmt.Printf("   Size:    %s\n", Info(formatBytes(stats.DatabaseSize)))
+
+	if len(stats.Paths) > 0 {
+		fmt.Println()
+		printPathStats(stats.Paths)
+	}
+}
+
+// printPathStats prints the per-directory or per-file breakdown as a table
+func printPathStats(paths []db.PathStats) {
+	fmt.Printf("📁 %s\n", Bold("Breakdown"))
+	width := len("Path")
+	for _, p := range paths {
+		width = max(width, min(len(p.Path), 60))
+	}
+	fmt.Printf("   %-*s %7s %9s %9s %9s  %s\n", width, "Path", "Files", "Symbols", "Funcs", "Calls", "Languages")
+	for _, p := range paths {
+		path := p.Path
+		if len(path) > width {
+			path = "…" + path[len(path)-width+1:]
+		}
+		langs := strings.Join(p.Languages, ",")
+		if langs == "" {
+			langs = "-"
+		}
+		// Pad before coloring: escape codes would throw off the widths
+		fmt.Printf("   %s %7s %s %9s %9s  %s\n",
+			Path(fmt.Sprintf("%-*s", width, path)),
+			formatNumber(p.Files),
+			Info(fmt.Sprintf("%9s", formatNumber(p.Symbols))),
+			formatNumber(p.Functions),
+			formatNumber(p.CallEdges),
+			Dim(langs))
+	}
 }
 
 func outputStatsCompact(stats *db.DetailedStats) error {
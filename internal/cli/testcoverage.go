@@ -0,0 +1,179 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+var (
+	testCoverageLangFlag  string
+	testCoverageDepthFlag int
+)
+
+var testCoverageCmd = &cobra.Command{
+	Use:   "testcoverage <symbol>",
+	Short: "List the tests that call a function, directly or transitively",
+	Long: `List the symbols in test files that reach the specified function
+through the call graph, either by calling it directly or by calling a
+function that (eventually) does. Each test is shown with the call chain
+that leads to the function.
+
+Test files are recognized by their language's naming conventions
+(_test.go, test_*.py, *.spec.ts, *Test.java, ...).
+
+Examples:
+  codegraph testcoverage parseConfig
+  codegraph testcoverage Save --depth=2
+  codegraph testcoverage handleRequest --lang=go --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTestCoverage,
+}
+
+func init() {
+	testCoverageCmd.Flags().StringVar(&testCoverageLangFlag, "lang", "", "Filter by language(s), comma-separated")
+	testCoverageCmd.Flags().IntVar(&testCoverageDepthFlag, "depth", 0, "Max call-chain length to follow (0 = unlimited)")
+	rootCmd.AddCommand(testCoverageCmd)
+}
+
+// coveringTest is a test symbol that reaches the target; Via lists the
+// functions between the test and the target, nearest the test first
+type coveringTest struct {
+	db.Symbol
+	Depth int
+	Via   []string
+}
+
+// testCoverageRecord is the JSON form of a coveringTest
+type testCoverageRecord struct {
+	Name  string   `json:"name"`
+	Kind  string   `json:"kind"`
+	File  string   `json:"file"`
+	Line  int      `json:"line"`
+	Depth int      `json:"depth"` // 1 when the test calls the function itself
+	Via   []string `json:"via"`
+}
+
+// findCoveringTests walks the callers of the symbols named symbol
+// breadth-first, up to depth levels (0 = unlimited), and returns the test
+// symbols found, nearest first. It reports whether the symbol exists.
+func findCoveringTests(dbManager *db.Manager, symbol string, opts db.QueryOptions, depth int) ([]coveringTest, bool, error) {
+	opts.Tests = db.TestsInclude
+	targets, err := dbManager.FindSymbolsByName(symbol, opts)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to find symbol: %w", err)
+	}
+	if len(targets) == 0 {
+		return nil, false, nil
+	}
+
+	// via maps each visited symbol to the chain leading from it to a target
+	via := make(map[string][]string)
+	names := make(map[string]string)
+	frontier := make([]string, 0, len(targets))
+	for _, t := range targets {
+		via[t.ID] = []string{}
+		names[t.ID] = t.Name
+		frontier = append(frontier, t.ID)
+	}
+
+	var tests []coveringTest
+	for level := 1; len(frontier) > 0 && (depth <= 0 || level <= depth); level++ {
+		var next []string
+		for _, id := range frontier {
+			callers, err := dbManager.GetCallersByID(id, db.QueryOptions{})
+			if err != nil {
+				return nil, true, fmt.Errorf("failed to find callers: %w", err)
+			}
+			for _, c := range callers {
+				if _, seen := via[c.ID]; seen {
+					continue
+				}
+				chain := via[id]
+				if level > 1 {
+					chain = append([]string{names[id]}, chain...)
+				}
+				via[c.ID] = chain
+				names[c.ID] = c.Name
+				next = append(next, c.ID)
+				if c.IsTest {
+					tests = append(tests, coveringTest{Symbol: c.Symbol, Depth: level, Via: chain})
+				}
+			}
+		}
+		frontier = next
+	}
+	return tests, true, nil
+}
+
+func runTestCoverage(cmd *cobra.Command, args []string) error {
+	symbol := args[0]
+	if jsonOutputFlag {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		return runTestCoverageJSON(cmd, symbol)
+	}
+
+	cwd, _, dbManager, _, err := openProject(true)
+	if err != nil {
+		return err
+	}
+	defer dbManager.Close()
+
+	tests, found, err := findCoveringTests(dbManager, symbol, queryOptions(testCoverageLangFlag, ""), testCoverageDepthFlag)
+	if err != nil {
+		return err
+	}
+	if !found {
+		fmt.Printf("🧪 No symbol named '%s' found in database\n", symbol)
+		return noResults(cmd)
+	}
+	if len(tests) == 0 {
+		fmt.Printf("🧪 No tests reach %s\n", Warning(symbol))
+		return noResults(cmd)
+	}
+
+	fmt.Printf("🧪 Tests reaching %s (%s found):\n\n", Symbol(symbol), Info(len(tests)))
+	for _, t := range tests {
+		how := Success("direct")
+		if len(t.Via) > 0 {
+			how = Dim("via " + strings.Join(t.Via, " → ") + " → " + symbol)
+		}
+		fmt.Printf("  %s [%s] %s\n", Symbol(t.Name), Keyword(t.Kind), how)
+		fmt.Printf("    %s\n", Path(fmt.Sprintf("%s:%d", relativePath(cwd, t.File), t.Line)))
+	}
+	return nil
+}
+
+func runTestCoverageJSON(cmd *cobra.Command, symbol string) error {
+	out := cmd.OutOrStdout()
+	emitErr := func(code string, err error) error {
+		_ = EmitJSON(out, "testcoverage", &symbol, []testCoverageRecord{}, []EnvelopeError{{Code: code, Message: err.Error()}})
+		return err
+	}
+
+	cwd, _, dbManager, code, err := openProject(true)
+	if err != nil {
+		return emitErr(code, err)
+	}
+	defer dbManager.Close()
+
+	tests, _, err := findCoveringTests(dbManager, symbol, queryOptions(testCoverageLangFlag, ""), testCoverageDepthFlag)
+	if err != nil {
+		return emitErr("testcoverage_failed", err)
+	}
+	records := make([]testCoverageRecord, 0, len(tests))
+	for _, t := range tests {
+		records = append(records, testCoverageRecord{
+			Name:  t.Name,
+			Kind:  t.Kind,
+			File:  relativePath(cwd, t.File),
+			Line:  t.Line,
+			Depth: t.Depth,
+			Via:   t.Via,
+		})
+	}
+	return emitResults(cmd, "testcoverage", &symbol, records)
+}
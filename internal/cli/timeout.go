@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// timeoutFlagUsage describes the --timeout flag of query commands, which
+// defaults to search.timeout_seconds
+const timeoutFlagUsage = "Give up after this long, e.g. 10s or 2m (default: search.timeout_seconds from config.toml; 0 = no limit)"
+
+// timeoutError is the cause of a context stopped by --timeout or
+// search.timeout_seconds
+type timeoutError struct {
+	after time.Duration
+}
+
+func (e timeoutError) Error() string {
+	return fmt.Sprintf("timed out after %s", e.after)
+}
+
+// commandTimeout returns the command's --timeout when it was set, and
+// fallback otherwise
+func commandTimeout(cmd *cobra.Command, flag, fallback time.Duration) time.Duration {
+	if cmd.Flags().Changed("timeout") {
+		return flag
+	}
+	return fallback
+}
+
+// withTimeout bounds ctx by d, with a timeoutError as its cause once the
+// deadline passes. A zero d leaves ctx unbounded.
+func withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeoutCause(ctx, d, timeoutError{after: d})
+}
+
+// cancelAfter cancels ctx after d, with a timeoutError as its cause. Builds
+// use it instead of withTimeout because they only stop when canceled: a
+// deadline just fails their language server requests.
+func cancelAfter(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	if d <= 0 {
+		return ctx, func() { cancel(nil) }
+	}
+	timer := time.AfterFunc(d, func() { cancel(timeoutError{after: d}) })
+	return ctx, func() {
+		timer.Stop()
+		cancel(nil)
+	}
+}
+
+// timedOut returns the timeoutError that stopped ctx in place of err, whose
+// message would otherwise just be "context deadline exceeded"
+func timedOut(ctx context.Context, err error) error {
+	var timeout timeoutError
+	if err != nil && errors.As(context.Cause(ctx), &timeout) {
+		return timeout
+	}
+	return err
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func TestCommandTimeout(t *testing.T) {
+	var flag time.Duration
+	cmd := &cobra.Command{Use: "search"}
+	cmd.Flags().DurationVar(&flag, "timeout", 0, timeoutFlagUsage)
+	if got := commandTimeout(cmd, flag, 30*time.Second); got != 30*time.Second {
+		t.Errorf("unset --timeout = %v, want the config's 30s", got)
+	}
+	if err := cmd.Flags().Set("timeout", "0"); err != nil {
+		t.Fatal(err)
+	}
+	if got := commandTimeout(cmd, flag, 30*time.Second); got != 0 {
+		t.Errorf("--timeout=0 = %v, want no limit", got)
+	}
+
+	ctx, cancel := withTimeout(context.Background(), 0)
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("a zero timeout should leave the context unbounded")
+	}
+
+	ctx, cancel = withTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	<-ctx.Done()
+	if err := timedOut(ctx, ctx.Err()); err.Error() != "timed out after 1ms" {
+		t.Errorf("timedOut = %v", err)
+	}
+}
+
+func TestCancelAfterCancelsWithTimeoutCause(t *testing.T) {
+	ctx, cancel := cancelAfter(context.Background(), time.Millisecond)
+	defer cancel()
+	<-ctx.Done()
+	// Builds only stop when canceled, not on a deadline
+	if !errors.Is(ctx.Err(), context.Canceled) {
+		t.Errorf("ctx.Err() = %v, want canceled", ctx.Err())
+	}
+	var timeout timeoutError
+	if !errors.As(context.Cause(ctx), &timeout) || timeout.after != time.Millisecond {
+		t.Errorf("cause = %v, want the timeout", context.Cause(ctx))
+	}
+
+	ctx, cancel = cancelAfter(context.Background(), 0)
+	cancel()
+	if err := timedOut(ctx, ctx.Err()); !errors.Is(err, context.Canceled) {
+		t.Errorf("timedOut = %v, want plain cancellation", err)
+	}
+}
@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/tk-425/Codegraph/internal/tui"
+)
+
+var tuiCmd = &cobra.Command{
+	Use:   "tui",
+	Short: "Browse the index interactively",
+	Long: `Open an interactive terminal UI over the project's index.
+
+Type to search symbols; the selected result's definition, callers, and
+callees are shown alongside. Press tab to move between panes, enter on a
+caller or callee to jump to it, and esc to go back.
+
+Examples:
+  codegraph tui
+  codegraph tui --project api`,
+	Args: cobra.NoArgs,
+	RunE: runTUI,
+}
+
+func init() {
+	rootCmd.AddCommand(tuiCmd)
+}
+
+func runTUI(cmd *cobra.Command, args []string) error {
+	cwd, _, dbManager, _, err := openProject(true)
+	if err != nil {
+		return err
+	}
+	defer dbManager.Close()
+	return tui.Run(dbManager, cwd)
+}
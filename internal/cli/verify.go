@@ -0,0 +1,247 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+var (
+	verifyFixFlag       bool
+	verifyConflictsFlag bool
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check the index for dangling references and removed files",
+	Long: `Validate the referential integrity of the index:
+
+  - calls whose caller or callee symbol is missing
+  - type relations whose child or parent symbol is missing
+  - containment rows and embeddings of missing symbols
+  - files that were indexed but no longer exist on disk
+
+Each check reports how many rows fail it. Exits with an error when
+problems are found, so it can gate CI. Use --fix to delete dangling rows
+and drop removed files (with their symbols and edges) from the index.
+
+When a file indexed by its language server is re-indexed by tree-sitter
+(or the other way round), the build keeps the new extractor's symbols and
+records where the two disagreed: symbols with another kind or line, and
+symbols only one of them found. Use --conflicts to list them. Conflicts are
+not problems: they do not make verify fail.
+
+Examples:
+  codegraph verify
+  codegraph verify --fix
+  codegraph verify --conflicts
+  codegraph verify --json`,
+	Args: cobra.NoArgs,
+	RunE: runVerify,
+}
+
+func init() {
+	verifyCmd.Flags().BoolVar(&verifyFixFlag, "fix", false, "Repair the problems found")
+	verifyCmd.Flags().BoolVar(&verifyConflictsFlag, "conflicts", false, "List where the language server and tree-sitter disagreed about symbols")
+	rootCmd.AddCommand(verifyCmd)
+}
+
+// removedFilesCheck names the check for indexed files missing on disk
+const removedFilesCheck = "removed_files"
+
+// verifyIndex runs every check; removed lists the indexed files that no
+// longer exist
+func verifyIndex(dbManager *db.Manager, root string) ([]db.IntegrityCheck, []string, error) {
+	checks, err := dbManager.CheckIntegrity()
+	if err != nil {
+		return nil, nil, err
+	}
+	files, err := dbManager.ListIndexedFiles()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list indexed files: %w", err)
+	}
+	var removed []string
+	for _, f := range files {
+		path := f
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(root, path)
+		}
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			removed = append(removed, f)
+		}
+	}
+	checks = append(checks, db.IntegrityCheck{
+		Name:        removedFilesCheck,
+		Description: "indexed files that no longer exist on disk",
+		Count:       len(removed),
+	})
+	return checks, removed, nil
+}
+
+// repairIndex drops removed files, then deletes the remaining dangling rows
+func repairIndex(dbManager *db.Manager, removed []string) error {
+	if err := dbManager.DeleteFiles(removed); err != nil {
+		return err
+	}
+	_, err := dbManager.RepairIntegrity()
+	return err
+}
+
+func countProblems(checks []db.IntegrityCheck) int {
+	total := 0
+	for _, c := range checks {
+		total += c.Count
+	}
+	return total
+}
+
+func runVerify(cmd *cobra.Command, args []string) error {
+	if jsonOutputFlag {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		return runVerifyJSON(cmd)
+	}
+
+	cwd, _, dbManager, _, err := openProject(true)
+	if err != nil {
+		return err
+	}
+	defer dbManager.Close()
+
+	checks, removed, err := verifyIndex(dbManager, cwd)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("🔎 %s\n", Bold("Verifying index..."))
+	for _, c := range checks {
+		if c.Count == 0 {
+			fmt.Printf("   %s %s\n", Success("✓"), Dim(c.Description))
+			continue
+		}
+		fmt.Printf("   %s %s %s\n", Error("✗"), Info(formatNumber(c.Count)), c.Description)
+	}
+	for _, f := range removed {
+		fmt.Printf("      %s\n", Path(relativePath(cwd, f)))
+	}
+	if err := printConflicts(dbManager, cwd); err != nil {
+		return err
+	}
+
+	problems := countProblems(checks)
+	if problems == 0 {
+		fmt.Printf("✅ %s\n", Success("Index is consistent"))
+		return nil
+	}
+	if !verifyFixFlag {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("index has %d problems; run 'codegraph verify --fix' to repair", problems)
+	}
+
+	if err := repairIndex(dbManager, removed); err != nil {
+		return fmt.Errorf("repair failed: %w", err)
+	}
+	fmt.Printf("✅ %s\n", Success(fmt.Sprintf("Repaired %d problems", problems)))
+	return nil
+}
+
+// printConflicts lists the recorded extractor conflicts with
+// --conflicts, and otherwise mentions how many there are
+func printConflicts(dbManager *db.Manager, cwd string) error {
+	if !verifyConflictsFlag {
+		count, err := dbManager.CountConflicts()
+		if err != nil {
+			return fmt.Errorf("failed to count conflicts: %w", err)
+		}
+		if count > 0 {
+			fmt.Printf("   %s %s extractor conflicts recorded %s\n", Dim("ℹ"), Info(formatNumber(count)), Dim("(list them with --conflicts)"))
+		}
+		return nil
+	}
+
+	conflicts, err := dbManager.ListConflicts()
+	if err != nil {
+		return fmt.Errorf("failed to list conflicts: %w", err)
+	}
+	fmt.Printf("\n⚖️  %s\n", Bold(fmt.Sprintf("Extractor conflicts (%d)", len(conflicts))))
+	file := ""
+	for _, c := range conflicts {
+		if c.File != file {
+			file = c.File
+			fmt.Printf("   %s\n", Path(relativePath(cwd, file)))
+		}
+		fmt.Printf("      %s %-8s lsp: %-12s tree-sitter: %-12s %s\n",
+			Symbol(fmt.Sprintf("%-24s", c.Name)), c.Field, orDash(c.LSP), orDash(c.TreeSitter), Dim("kept "+c.Kept))
+	}
+	return nil
+}
+
+// orDash shows an extractor's missing value as a dash
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// verifyRecord is the JSON result of verify: the checks as found, and
+// whether they were repaired
+type verifyRecord struct {
+	Checks       []db.IntegrityCheck `json:"checks"`
+	RemovedFiles []string            `json:"removed_files"`
+	Problems     int                 `json:"problems"`
+	Fixed        bool                `json:"fixed"`
+	// Conflicts are listed with --conflicts
+	Conflicts []db.SymbolConflict `json:"conflicts,omitempty"`
+}
+
+func runVerifyJSON(cmd *cobra.Command) error {
+	out := cmd.OutOrStdout()
+	emitErr := func(code string, err error) error {
+		_ = EmitJSON(out, "verify", nil, []verifyRecord{}, []EnvelopeError{{Code: code, Message: err.Error()}})
+		return err
+	}
+
+	cwd, _, dbManager, code, err := openProject(true)
+	if err != nil {
+		return emitErr(code, err)
+	}
+	defer dbManager.Close()
+
+	checks, removed, err := verifyIndex(dbManager, cwd)
+	if err != nil {
+		return emitErr("verify_failed", err)
+	}
+	rec := verifyRecord{Checks: checks, RemovedFiles: make([]string, 0, len(removed)), Problems: countProblems(checks)}
+	for _, f := range removed {
+		rec.RemovedFiles = append(rec.RemovedFiles, relativePath(cwd, f))
+	}
+	if verifyConflictsFlag {
+		conflicts, err := dbManager.ListConflicts()
+		if err != nil {
+			return emitErr("conflicts_failed", fmt.Errorf("failed to list conflicts: %w", err))
+		}
+		rec.Conflicts = make([]db.SymbolConflict, 0, len(conflicts))
+		for _, c := range conflicts {
+			c.File = relativePath(cwd, c.File)
+			rec.Conflicts = append(rec.Conflicts, c)
+		}
+	}
+
+	if rec.Problems > 0 && verifyFixFlag {
+		if err := repairIndex(dbManager, removed); err != nil {
+			return emitErr("repair_failed", fmt.Errorf("repair failed: %w", err))
+		}
+		rec.Fixed = true
+	}
+	if err := EmitJSON(out, "verify", nil, []verifyRecord{rec}, nil); err != nil {
+		return err
+	}
+	if rec.Problems > 0 && !rec.Fixed {
+		return fmt.Errorf("index has %d problems", rec.Problems)
+	}
+	return nil
+}
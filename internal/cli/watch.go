@@ -0,0 +1,320 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+	"unicode"
+
+	"github.com/spf13/cobra"
+	"github.com/tk-425/Codegraph/internal/config"
+	"github.com/tk-425/Codegraph/internal/db"
+	"github.com/tk-425/Codegraph/internal/indexer"
+)
+
+var (
+	watchOnChangeFlag []string
+	watchExecFlag     string
+	watchIntervalFlag time.Duration
+	watchFastFlag     bool
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Keep the index up to date and re-run queries as files change",
+	Long: `Watch the project's files and re-index the ones that change, keeping the
+index current while you work. Changes are found by polling every
+--interval, the same way an incremental build finds them; language servers
+stay running between builds.
+
+--on-change subscribes a codegraph command (without "codegraph") to the
+changes: it runs once at start, and again after every re-index, and when
+its output differs from the last run the new output is printed and the
+--exec command, if any, is run through sh with the output on its standard
+input. The command's environment has CODEGRAPH_QUERY (the subscription)
+and CODEGRAPH_CHANGED_FILES (the re-indexed files, one per line, relative
+to the project root). Repeat --on-change to subscribe several commands.
+
+Examples:
+  codegraph watch
+  codegraph watch --fast --interval=500ms
+  codegraph watch --on-change "callers SaveUser"
+  codegraph watch --on-change "callers SaveUser" --exec ./notify.sh
+  codegraph watch --on-change "run untested-routes" --on-change "hotspots --limit=5" --exec 'notify-send codegraph "$CODEGRAPH_QUERY changed"'`,
+	Args: cobra.NoArgs,
+	RunE: runWatch,
+}
+
+func init() {
+	watchCmd.Flags().StringArrayVar(&watchOnChangeFlag, "on-change", nil, "codegraph command to re-run after each re-index, e.g. \"callers SaveUser\" (repeatable)")
+	watchCmd.Flags().StringVar(&watchExecFlag, "exec", "", "Shell command to run when an --on-change command's output changes")
+	watchCmd.Flags().DurationVar(&watchIntervalFlag, "interval", 2*time.Second, "How often to look for changed files")
+	watchCmd.Flags().BoolVar(&watchFastFlag, "fast", false, "Re-index with tree-sitter only, skipping the language servers")
+	rootCmd.AddCommand(watchCmd)
+}
+
+// subscription is an --on-change command and its last output
+type subscription struct {
+	command string
+	args    []string
+	output  string
+}
+
+// watcher re-indexes changed files and re-runs subscriptions
+type watcher struct {
+	root  string
+	db    *db.Manager
+	idx   *indexer.Indexer
+	scan  func() ([]indexer.FileInfo, error)
+	subs  []*subscription
+	exec  string
+	out   io.Writer
+	query func(ctx context.Context, args []string) string // Runs a subscription
+}
+
+func runWatch(cmd *cobra.Command, args []string) error {
+	if watchIntervalFlag <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+	if watchExecFlag != "" && len(watchOnChangeFlag) == 0 {
+		return fmt.Errorf("--exec needs an --on-change command")
+	}
+	var subs []*subscription
+	for _, command := range watchOnChangeFlag {
+		args, err := splitCommandLine(command)
+		if err != nil {
+			return fmt.Errorf("invalid --on-change %q: %w", command, err)
+		}
+		if len(args) > 0 && args[0] == "codegraph" {
+			args = args[1:]
+		}
+		if len(args) == 0 {
+			return fmt.Errorf("invalid --on-change %q: no command", command)
+		}
+		if c, _, err := rootCmd.Find(args); err != nil || !c.HasParent() || c.Name() == "watch" {
+			return fmt.Errorf("invalid --on-change %q: not a codegraph command", command)
+		}
+		subs = append(subs, &subscription{command: command, args: args})
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find the codegraph executable: %w", err)
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+	codegraphDir := filepath.Join(cwd, config.DefaultConfigDir)
+	if _, err := os.Stat(codegraphDir); os.IsNotExist(err) {
+		return errNotInitialized
+	}
+	cfg, err := config.Load(cwd)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if cfg.Database.ReadOnly {
+		return fmt.Errorf("the index is a read-only shared index (database.read_only in config.toml); unset read_only to watch it")
+	}
+	dbManager, err := db.NewManager(cfg.GetDatabasePath(cwd))
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer dbManager.Close()
+	if err := dbManager.Initialize(); err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+
+	idx := indexer.NewIndexer(cfg, dbManager, cwd)
+	defer idx.Close()
+	idx.SetFast(watchFastFlag)
+
+	cgignorePath := filepath.Join(codegraphDir, ".cgignore")
+	w := &watcher{
+		root: cwd,
+		db:   dbManager,
+		idx:  idx,
+		scan: func() ([]indexer.FileInfo, error) {
+			scanner, err := indexer.NewScannerWithConfig(cwd, cgignorePath, cfg.Index)
+			if err != nil {
+				return nil, err
+			}
+			scanner.SetWorkspaces(cfg.Workspaces)
+			return scanner.Scan()
+		},
+		subs: subs,
+		exec: watchExecFlag,
+		out:  cmd.OutOrStdout(),
+		query: func(ctx context.Context, args []string) string {
+			c := exec.CommandContext(ctx, self, args...)
+			c.Dir = cwd
+			out, _ := c.CombinedOutput() // A failing command's error is its output
+			return string(out)
+		},
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Fprintf(w.out, "👀 Watching %s (every %s, Ctrl-C to stop)\n", Path(cwd), watchIntervalFlag)
+	if _, err := w.poll(ctx); err != nil {
+		return w.stopped(ctx, err)
+	}
+	for _, s := range w.subs {
+		s.output = w.query(ctx, s.args)
+		fmt.Fprintf(w.out, "\n📌 %s\n%s", Keyword(s.command), s.output)
+	}
+
+	ticker := time.NewTicker(watchIntervalFlag)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return w.stopped(ctx, nil)
+		case <-ticker.C:
+		}
+		changed, err := w.poll(ctx)
+		if err != nil {
+			return w.stopped(ctx, err)
+		}
+		if len(changed) > 0 {
+			w.rerun(ctx, changed)
+		}
+	}
+}
+
+// stopped reports the end of the watch, which err caused unless it was
+// interrupted
+func (w *watcher) stopped(ctx context.Context, err error) error {
+	var interrupted *indexer.InterruptedError
+	if ctx.Err() != nil && (err == nil || errors.As(err, &interrupted)) {
+		fmt.Fprintf(w.out, "\n👀 %s\n", Dim("Stopped watching"))
+		return nil
+	}
+	return err
+}
+
+// poll re-indexes the files changed since the last build and returns
+// their paths
+func (w *watcher) poll(ctx context.Context) ([]string, error) {
+	files, err := w.scan()
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan files: %w", err)
+	}
+	changes, err := indexer.FindChanges(w.db, files)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare files with the index: %w", err)
+	}
+	if changes.Empty() {
+		return nil, nil
+	}
+
+	paths := changes.Paths()
+	shown := make([]string, 0, len(paths))
+	for _, p := range paths {
+		shown = append(shown, relativePath(w.root, p))
+	}
+	fmt.Fprintf(w.out, "\n🔁 %s changed: %s\n", Info(fmt.Sprintf("%d files", len(paths))), Dim(strings.Join(shown, ", ")))
+	if len(changes.Removed) > 0 {
+		if err := w.db.DeleteFiles(changes.Removed); err != nil {
+			return nil, fmt.Errorf("failed to remove deleted files: %w", err)
+		}
+	}
+	if err := w.idx.IndexProject(ctx, files, false); err != nil {
+		return nil, err
+	}
+	return shown, nil
+}
+
+// rerun runs the subscriptions again after changed files were re-indexed,
+// reporting the ones whose output changed
+func (w *watcher) rerun(ctx context.Context, changed []string) {
+	for _, s := range w.subs {
+		output := w.query(ctx, s.args)
+		if output == s.output || ctx.Err() != nil {
+			continue
+		}
+		s.output = output
+		fmt.Fprintf(w.out, "\n📣 %s changed:\n%s", Keyword(s.command), output)
+		if w.exec == "" {
+			continue
+		}
+		c := exec.CommandContext(ctx, "sh", "-c", w.exec)
+		c.Dir = w.root
+		c.Stdin = strings.NewReader(output)
+		var stderr bytes.Buffer
+		c.Stdout, c.Stderr = w.out, &stderr
+		c.Env = append(os.Environ(),
+			"CODEGRAPH_QUERY="+s.command,
+			"CODEGRAPH_CHANGED_FILES="+strings.Join(changed, "\n"))
+		if err := c.Run(); err != nil {
+			fmt.Fprintf(w.out, "⚠️  %s\n", Warning(fmt.Sprintf("--exec failed: %v %s", err, strings.TrimSpace(stderr.String()))))
+		}
+	}
+}
+
+// splitCommandLine splits s into arguments at unquoted whitespace, like a
+// shell without expansions: single quotes keep their contents as is,
+// double quotes allow \" and \\, and a backslash outside quotes escapes
+// the next character
+func splitCommandLine(s string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			if quote == '"' && r != '"' && r != '\\' {
+				current.WriteRune('\\')
+			}
+			current.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case quote == '"':
+			switch r {
+			case '"':
+				quote = 0
+			case '\\':
+				escaped = true
+			default:
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case r == '\\':
+			escaped, inArg = true, true
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote or escape")
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
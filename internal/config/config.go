@@ -3,7 +3,11 @@ package config
 import (
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/pelletier/go-toml/v2"
 )
@@ -13,25 +17,439 @@ const DefaultConfigDir = ".codegraph"
 
 // Config represents the codegraph configuration
 type Config struct {
-	LSP      map[string]LSPConfig `toml:"lsp"`
-	Search   SearchConfig         `toml:"search"`
-	Database DatabaseConfig       `toml:"database"`
+	LSP        map[string]LSPConfig `toml:"lsp"`
+	Search     SearchConfig         `toml:"search"`
+	Database   DatabaseConfig       `toml:"database"`
+	Index      IndexConfig          `toml:"index"`
+	Embeddings EmbeddingsConfig     `toml:"embeddings"`
+	Owners     OwnersConfig         `toml:"owners"`
+	Editor     EditorConfig         `toml:"editor,omitempty"`
+	Calls      CallsConfig          `toml:"calls,omitempty"`
+	Kinds      KindsConfig          `toml:"kinds,omitempty"`
+	// Dependencies opts in to indexing the declarations of the project's
+	// dependencies, which external calls then resolve to
+	Dependencies DependenciesConfig `toml:"dependencies,omitempty"`
+	// Workspaces splits a monorepo into roots (backend/, frontend/, ...) that
+	// share one database. When set, only files inside a workspace are indexed.
+	Workspaces []WorkspaceConfig `toml:"workspaces,omitempty"`
 }
 
 // LSPConfig represents an LSP server configuration
 type LSPConfig struct {
 	Command string   `toml:"command"`
 	Args    []string `toml:"args"`
+	// Root starts the server in this directory (relative to the project
+	// root, or absolute) instead of the project root, for servers that need
+	// their build metadata (pom.xml, Cargo.toml) at the workspace root
+	Root string `toml:"root,omitempty"`
+	// Version pins the release `codegraph install-lsp` installs (e.g.
+	// "v0.16.2" for gopls); empty installs the latest
+	Version string `toml:"version,omitempty"`
+	// Transport is how codegraph reaches the server: "stdio" (the default)
+	// starts Command, while "tcp" and "unix" connect to an already-running
+	// server listening on Address and leave it running afterwards
+	Transport string `toml:"transport,omitempty"`
+	// Address is the host:port ("tcp") or socket path ("unix") to connect to
+	Address string `toml:"address,omitempty"`
+	// Container runs Command inside a container, so the server's toolchain
+	// does not need to be installed locally
+	Container *ContainerConfig `toml:"container,omitempty"`
+}
+
+// ContainerConfig runs a language server in a container. The project root is
+// mounted at Mount, and file URIs are translated between host and container
+// paths in both directions.
+type ContainerConfig struct {
+	Image   string   `toml:"image"`             // e.g. rust:1.80 with rust-analyzer installed
+	Runtime string   `toml:"runtime,omitempty"` // docker (default) or podman
+	Mount   string   `toml:"mount,omitempty"`   // Container path of the project root (default /workspace)
+	Args    []string `toml:"args,omitempty"`    // Extra run arguments, e.g. ["--network=none"]
+}
+
+// DefaultContainerMount is where the project root is mounted by default
+const DefaultContainerMount = "/workspace"
+
+// RuntimeCommand returns Runtime, defaulting to docker
+func (c ContainerConfig) RuntimeCommand() string {
+	if c.Runtime == "" {
+		return "docker"
+	}
+	return c.Runtime
+}
+
+// MountPath returns Mount, defaulting to DefaultContainerMount
+func (c ContainerConfig) MountPath() string {
+	if c.Mount == "" {
+		return DefaultContainerMount
+	}
+	return path.Clean(c.Mount)
+}
+
+// LSP transports
+const (
+	TransportStdio = "stdio"
+	TransportTCP   = "tcp"
+	TransportUnix  = "unix"
+)
+
+// TransportMode returns Transport, defaulting to stdio
+func (l LSPConfig) TransportMode() string {
+	if l.Transport == "" {
+		return TransportStdio
+	}
+	return l.Transport
+}
+
+// IsSocket reports whether the server is reached over a socket rather than
+// started by codegraph
+func (l LSPConfig) IsSocket() bool {
+	mode := l.TransportMode()
+	return mode == TransportTCP || mode == TransportUnix
 }
 
 // SearchConfig represents search configuration
 type SearchConfig struct {
+	// TimeoutSeconds bounds a query command's search tiers and language
+	// server requests, unless the command's --timeout overrides it (0 = no
+	// limit). Builds are only bounded by --timeout.
 	TimeoutSeconds int `toml:"timeout_seconds"`
+	// Tiers run in order until one returns results: "db", "treesitter",
+	// "ripgrep", "grep" or "semantic".
+	Tiers []string `toml:"tiers"`
+}
+
+// DefaultSearchTiers is the tier order used when config.toml sets none
+var DefaultSearchTiers = []string{"db", "ripgrep"}
+
+// TierOrder returns the configured tiers, or DefaultSearchTiers when unset
+func (s SearchConfig) TierOrder() []string {
+	if len(s.Tiers) == 0 {
+		return DefaultSearchTiers
+	}
+	return s.Tiers
+}
+
+// Timeout returns TimeoutSeconds as a duration, zero meaning no limit
+func (s SearchConfig) Timeout() time.Duration {
+	return time.Duration(s.TimeoutSeconds) * time.Second
+}
+
+// IndexConfig controls which files `codegraph build` scans
+type IndexConfig struct {
+	// UseGitignore also skips files ignored by the project's .gitignore files
+	// and .git/info/exclude, on top of .codegraph/.cgignore.
+	UseGitignore bool `toml:"use_gitignore"`
+	// MaxFileSize skips source files larger than this many bytes (0 = no limit)
+	MaxFileSize int64 `toml:"max_file_size"`
+	// ExcludeGenerated skips files marked as generated ("Code generated ...
+	// DO NOT EDIT.", "@generated", "<auto-generated>") and minified JavaScript.
+	ExcludeGenerated bool `toml:"exclude_generated"`
+	// FollowSymlinks walks into symlinked files and directories. Links that
+	// loop back into an already-scanned tree are skipped with a warning.
+	// When false, symlinks are not indexed at all.
+	FollowSymlinks bool `toml:"follow_symlinks"`
+	// StaleCheck makes query commands warn when files changed since the
+	// last build. It scans the project like a build does before every
+	// query, so it is off unless asked for.
+	StaleCheck bool `toml:"stale_check"`
+	// AutoBuild makes query commands run an incremental build first when
+	// files changed since the last build, as --auto-build does
+	AutoBuild bool `toml:"auto_build"`
+	// OnlyLanguages limits the index to these languages (go, python, ...);
+	// empty indexes every supported language. Listing "typescript" also
+	// covers typescriptreact (.tsx/.jsx) files.
+	OnlyLanguages []string `toml:"only_languages,omitempty"`
+	// Strategy picks the extractors symbols, calls and type hierarchy come
+	// from: "hybrid" (the default), "lsp" or "treesitter"
+	Strategy string `toml:"strategy,omitempty"`
+	// Languages holds include/exclude globs and strategy overrides per
+	// language, keyed by language name (go, python, typescript, ...)
+	Languages map[string]LanguageFilter `toml:"languages,omitempty"`
+}
+
+// IndexesLanguage reports whether OnlyLanguages lets language be indexed
+func (c IndexConfig) IndexesLanguage(language string) bool {
+	return len(c.OnlyLanguages) == 0 || listsLanguage(c.OnlyLanguages, language)
+}
+
+// listsLanguage reports whether languages includes language, counting
+// "typescript" as also covering typescriptreact
+func listsLanguage(languages []string, language string) bool {
+	for _, lang := range languages {
+		if lang == language || (lang == "typescript" && language == "typescriptreact") {
+			return true
+		}
+	}
+	return false
+}
+
+// Extraction strategies
+const (
+	StrategyHybrid     = "hybrid"     // Language server, falling back to tree-sitter
+	StrategyLSP        = "lsp"        // Language server only; files it cannot index fail
+	StrategyTreeSitter = "treesitter" // Tree-sitter only; no language server is started
+)
+
+// ValidStrategy reports whether s names an extraction strategy
+func ValidStrategy(s string) bool {
+	return s == StrategyHybrid || s == StrategyLSP || s == StrategyTreeSitter
+}
+
+// StrategyFor returns the strategy of language: its own, else the
+// project's, else hybrid
+func (c IndexConfig) StrategyFor(language string) string {
+	if s := c.Languages[language].Strategy; s != "" {
+		return s
+	}
+	if c.Strategy != "" {
+		return c.Strategy
+	}
+	return StrategyHybrid
+}
+
+// LanguageFilter narrows the files indexed for one language using
+// gitignore-style globs relative to the project root
+type LanguageFilter struct {
+	Include  []string `toml:"include,omitempty"`  // When set, only matching files are indexed
+	Exclude  []string `toml:"exclude,omitempty"`  // Matching files are skipped
+	Strategy string   `toml:"strategy,omitempty"` // Overrides [index] strategy for the language
+}
+
+// EmbeddingsConfig configures the optional semantic search tier. Leaving
+// Provider empty disables embeddings.
+type EmbeddingsConfig struct {
+	Provider  string `toml:"provider,omitempty"`    // "openai" (any OpenAI-compatible endpoint) or "hash" (built-in, offline)
+	Endpoint  string `toml:"endpoint,omitempty"`    // e.g. http://localhost:11434/v1 for Ollama; defaults to OpenAI
+	Model     string `toml:"model,omitempty"`       // e.g. text-embedding-3-small, nomic-embed-text
+	APIKeyEnv string `toml:"api_key_env,omitempty"` // Environment variable holding the API key
+	BatchSize int    `toml:"batch_size,omitempty"`  // Texts per request (default 64)
+}
+
+// Enabled reports whether an embeddings provider is configured
+func (e EmbeddingsConfig) Enabled() bool {
+	return e.Provider != ""
+}
+
+// OwnersConfig controls how `codegraph build` records symbol ownership.
+// CODEOWNERS owners are always recorded when the project has the file.
+type OwnersConfig struct {
+	// Blame records the author of most of each symbol's lines, from git
+	// blame over the files that changed
+	Blame bool `toml:"blame"`
+}
+
+// EditorConfig controls how `codegraph open` starts an editor at a symbol
+type EditorConfig struct {
+	// Opener is the command run to open a file, split on spaces, with
+	// {file}, {line} and {column} (1-indexed) replaced in each argument,
+	// e.g. "code -g {file}:{line}:{column}". When empty, it is derived from
+	// $VISUAL or $EDITOR.
+	Opener string `toml:"opener,omitempty"`
+}
+
+// OpenerTemplate returns Opener, or a template for the editor named by
+// $VISUAL or $EDITOR, falling back to VS Code when neither is set
+func (e EditorConfig) OpenerTemplate() string {
+	if e.Opener != "" {
+		return e.Opener
+	}
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		return "code -g {file}:{line}:{column}"
+	}
+
+	fields := strings.Fields(editor)
+	switch strings.TrimSuffix(filepath.Base(fields[0]), ".exe") {
+	case "vi", "vim", "nvim", "nano", "micro", "kak", "hx":
+		return editor + " +{line} {file}"
+	case "emacs", "emacsclient":
+		return editor + " +{line}:{column} {file}"
+	case "code", "code-insiders", "codium", "cursor", "windsurf":
+		return editor + " -g {file}:{line}:{column}"
+	case "subl", "zed", "mate":
+		return editor + " {file}:{line}:{column}"
+	case "idea", "goland", "pycharm", "webstorm", "clion", "rider":
+		return editor + " --line {line} --column {column} {file}"
+	default:
+		return editor + " {file}"
+	}
+}
+
+// OpenerCommand returns the command and arguments that open file at line
+// and column (both 1-indexed)
+func (e EditorConfig) OpenerCommand(file string, line, column int) ([]string, error) {
+	template := e.OpenerTemplate()
+	replacer := strings.NewReplacer("{file}", file, "{line}", fmt.Sprint(line), "{column}", fmt.Sprint(column))
+	var args []string
+	for _, field := range strings.Fields(template) {
+		args = append(args, replacer.Replace(field))
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("invalid config: editor.opener is empty")
+	}
+	if !strings.Contains(template, "{file}") {
+		return nil, fmt.Errorf("invalid config: editor.opener %q must contain {file}", template)
+	}
+	return args, nil
+}
+
+// CallsConfig controls which symbols tree-sitter call extraction links a
+// callee name to. Calls resolved by a language server are not affected.
+type CallsConfig struct {
+	// Resolve narrows the symbols a callee name may resolve to: "any" (the
+	// default) tries the caller's language, then any language; "language"
+	// keeps to the caller's language; "package" keeps to the caller's
+	// package or the one the call is qualified with (db in Go's db.Open)
+	Resolve string `toml:"resolve,omitempty"`
+	// Ignore lists more callee names never to link, by language, e.g.
+	// python = ["run", "execute"]
+	Ignore map[string][]string `toml:"ignore,omitempty"`
+	// KeepBuiltins also links calls named like each language's builtins and
+	// common standard library functions (append, len, print, map, get, ...),
+	// which are otherwise only linked to a symbol of the calling file
+	KeepBuiltins bool `toml:"keep_builtins,omitempty"`
+}
+
+// Call resolution modes
+const (
+	ResolveAny      = "any"      // Caller's language first, then any language
+	ResolveLanguage = "language" // Caller's language only
+	ResolvePackage  = "package"  // Caller's package or the call's qualifier only
+)
+
+// ValidResolve reports whether s names a call resolution mode
+func ValidResolve(s string) bool {
+	return s == ResolveAny || s == ResolveLanguage || s == ResolvePackage
+}
+
+// DependenciesConfig controls the shallow indexing of the project's
+// dependencies: the Go modules go.mod requires (from vendor/ or the module
+// cache), the type definitions of package.json dependencies in
+// node_modules and the .pyi stubs in a virtualenv's site-packages. Their
+// declarations are kept apart from the project's symbols.
+type DependenciesConfig struct {
+	Enabled bool `toml:"enabled"`
+	// MaxFiles bounds the files indexed per dependency (default 200)
+	MaxFiles int `toml:"max_files,omitempty"`
+}
+
+// DefaultDependencyFiles is the default DependenciesConfig.MaxFiles
+const DefaultDependencyFiles = 200
+
+// FileLimit returns MaxFiles, or the default when unset
+func (d DependenciesConfig) FileLimit() int {
+	if d.MaxFiles > 0 {
+		return d.MaxFiles
+	}
+	return DefaultDependencyFiles
+}
+
+// KindsConfig relabels the kinds symbols are indexed with. It applies when
+// a file is indexed, so a rebuild (codegraph build --force) relabels
+// symbols in files that have not changed.
+type KindsConfig struct {
+	// LSP maps LSP SymbolKind names to kinds, replacing the built-in mapping,
+	// e.g. Property = "property" where properties are otherwise "field"
+	LSP map[string]string `toml:"lsp,omitempty"`
+	// TreeSitter maps the tree-sitter node types symbols are declared by to
+	// kinds, e.g. struct_item = "class" where Rust structs are otherwise
+	// "struct"
+	TreeSitter map[string]string `toml:"tree_sitter,omitempty"`
+	// Rules relabel the symbols matching a heuristic, after the mappings
+	Rules []KindRule `toml:"rules,omitempty"`
+}
+
+// KindRule relabels symbols as Kind, e.g. React components: functions in
+// typescriptreact files whose name starts with an upper-case letter. Every
+// condition that is set must hold; the first matching rule wins.
+type KindRule struct {
+	Kind      string   `toml:"kind"`
+	Languages []string `toml:"languages,omitempty"`
+	From      []string `toml:"from,omitempty"`      // Kinds the rule relabels; empty = any
+	Name      string   `toml:"name,omitempty"`      // Regexp the symbol name must match
+	Signature string   `toml:"signature,omitempty"` // Regexp the signature must match
+}
+
+// WorkspaceConfig declares one root of a multi-root project. Each language
+// server is started once, with every workspace indexing its language as a
+// workspace folder.
+type WorkspaceConfig struct {
+	Name      string   `toml:"name,omitempty"`      // Defaults to the root's base name
+	Root      string   `toml:"root"`                // Directory relative to the project root
+	Languages []string `toml:"languages,omitempty"` // Languages indexed under Root; empty = all
+}
+
+// RelRoot returns the workspace root as a clean, slash-separated path
+// relative to the project root ("." for the project root itself)
+func (w WorkspaceConfig) RelRoot() string {
+	root := path.Clean(filepath.ToSlash(w.Root))
+	return strings.TrimPrefix(root, "./")
+}
+
+// DisplayName returns Name, or the root's base name when unset
+func (w WorkspaceConfig) DisplayName() string {
+	if w.Name != "" {
+		return w.Name
+	}
+	return path.Base(w.RelRoot())
+}
+
+// Contains reports whether the project-relative relPath lies inside the
+// workspace root
+func (w WorkspaceConfig) Contains(relPath string) bool {
+	root := w.RelRoot()
+	relPath = path.Clean(filepath.ToSlash(relPath))
+	return root == "." || relPath == root || strings.HasPrefix(relPath, root+"/")
+}
+
+// HasLanguage reports whether the workspace indexes language. Listing
+// "typescript" also covers typescriptreact (.tsx/.jsx) files.
+func (w WorkspaceConfig) HasLanguage(language string) bool {
+	return len(w.Languages) == 0 || listsLanguage(w.Languages, language)
+}
+
+// WorkspaceFor returns the innermost workspace that contains relPath and
+// indexes language
+func (c *Config) WorkspaceFor(relPath, language string) (WorkspaceConfig, bool) {
+	var best WorkspaceConfig
+	found := false
+	for _, ws := range c.Workspaces {
+		if !ws.Contains(relPath) || !ws.HasLanguage(language) {
+			continue
+		}
+		if !found || len(ws.RelRoot()) > len(best.RelRoot()) {
+			best, found = ws, true
+		}
+	}
+	return best, found
+}
+
+// WorkspacesForLanguage returns the workspaces that index language, in
+// config order
+func (c *Config) WorkspacesForLanguage(language string) []WorkspaceConfig {
+	var matches []WorkspaceConfig
+	for _, ws := range c.Workspaces {
+		if ws.HasLanguage(language) {
+			matches = append(matches, ws)
+		}
+	}
+	return matches
 }
 
 // DatabaseConfig represents database configuration
 type DatabaseConfig struct {
 	Path string `toml:"path"`
+	// ReadOnly opens the database without ever writing to it, for a shared
+	// index built in CI (a downloaded artifact or a network mount); build
+	// refuses to run against it
+	ReadOnly bool `toml:"read_only,omitempty"`
+	// Remote is where `codegraph pull` and `codegraph push` sync the
+	// database: s3://bucket/key, gs://bucket/key, or a file path or file://
+	// URL (e.g. on a network mount)
+	Remote string `toml:"remote,omitempty"`
 }
 
 // DefaultConfig returns the default configuration
@@ -70,22 +488,45 @@ func DefaultConfig() *Config {
 				Command: "ocamllsp",
 				Args:    []string{},
 			},
+			"csharp": {
+				Command: "csharp-ls",
+				Args:    []string{},
+			},
 		},
 		Search: SearchConfig{
 			TimeoutSeconds: 30,
+			Tiers:          append([]string(nil), DefaultSearchTiers...),
 		},
 		Database: DatabaseConfig{
 			Path: ".codegraph/graphs/codegraph.db",
 		},
+		Owners: OwnersConfig{
+			Blame: true,
+		},
 	}
 }
 
-// Load loads the configuration from the config file
+// InitConfig returns the configuration `codegraph init` writes for a new
+// project: DefaultConfig with the file filters on. They are not defaults,
+// so that configs written before they existed keep indexing every file.
+func InitConfig() *Config {
+	cfg := DefaultConfig()
+	cfg.Index.MaxFileSize = 1 << 20 // 1 MiB
+	cfg.Index.ExcludeGenerated = true
+	return cfg
+}
+
+// Load loads the configuration from the config file, with the profile
+// named by $CODEGRAPH_PROFILE applied
 func Load(projectRoot string) (*Config, error) {
 	configPath := filepath.Join(projectRoot, DefaultConfigDir, "config.toml")
+	profile := os.Getenv(ProfileEnv)
 
 	// If config doesn't exist, return default config
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		if profile != "" {
+			return nil, fmt.Errorf("invalid config: profile %q is not defined; there is no config.toml", profile)
+		}
 		return DefaultConfig(), nil
 	}
 
@@ -93,11 +534,64 @@ func Load(projectRoot string) (*Config, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config: %w", err)
 	}
+	if profile != "" {
+		if data, err = applyProfile(data, profile); err != nil {
+			return nil, err
+		}
+	}
 
 	cfg := DefaultConfig()
 	if err := toml.Unmarshal(data, cfg); err != nil {
 		return nil, fmt.Errorf("failed to parse config: %w", err)
 	}
+	cfg.expandEnv()
+	for i, ws := range cfg.Workspaces {
+		root := ws.RelRoot()
+		if ws.Root == "" || filepath.IsAbs(ws.Root) || root == ".." || strings.HasPrefix(root, "../") {
+			return nil, fmt.Errorf("invalid config: workspaces[%d].root %q must be a directory inside the project", i, ws.Root)
+		}
+	}
+	for i, rule := range cfg.Kinds.Rules {
+		if rule.Kind == "" {
+			return nil, fmt.Errorf("invalid config: kinds.rules[%d] needs a kind", i)
+		}
+		for field, pattern := range map[string]string{"name": rule.Name, "signature": rule.Signature} {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return nil, fmt.Errorf("invalid config: kinds.rules[%d].%s: %w", i, field, err)
+			}
+		}
+	}
+	if cfg.Database.Path == "" {
+		return nil, fmt.Errorf("invalid config: database.path is empty (an unset ${VAR} expands to \"\"; use ${VAR:-default})")
+	}
+	if cfg.Search.TimeoutSeconds < 0 {
+		return nil, fmt.Errorf("invalid config: search.timeout_seconds %d must not be negative", cfg.Search.TimeoutSeconds)
+	}
+	if cfg.Index.Strategy != "" && !ValidStrategy(cfg.Index.Strategy) {
+		return nil, fmt.Errorf("invalid config: index.strategy %q must be hybrid, lsp or treesitter", cfg.Index.Strategy)
+	}
+	for lang, filter := range cfg.Index.Languages {
+		if filter.Strategy != "" && !ValidStrategy(filter.Strategy) {
+			return nil, fmt.Errorf("invalid config: index.languages.%s.strategy %q must be hybrid, lsp or treesitter", lang, filter.Strategy)
+		}
+	}
+	if cfg.Calls.Resolve != "" && !ValidResolve(cfg.Calls.Resolve) {
+		return nil, fmt.Errorf("invalid config: calls.resolve %q must be any, language or package", cfg.Calls.Resolve)
+	}
+	for lang, l := range cfg.LSP {
+		switch {
+		case !l.IsSocket() && l.TransportMode() != TransportStdio:
+			return nil, fmt.Errorf("invalid config: lsp.%s.transport %q must be stdio, tcp or unix", lang, l.Transport)
+		case l.IsSocket() && l.Address == "":
+			return nil, fmt.Errorf("invalid config: lsp.%s.transport %q needs an address", lang, l.Transport)
+		case l.Container != nil && l.IsSocket():
+			return nil, fmt.Errorf("invalid config: lsp.%s.container cannot be used with transport %q", lang, l.Transport)
+		case l.Container != nil && l.Container.Image == "":
+			return nil, fmt.Errorf("invalid config: lsp.%s.container needs an image", lang)
+		case l.Container != nil && !path.IsAbs(l.Container.MountPath()):
+			return nil, fmt.Errorf("invalid config: lsp.%s.container.mount %q must be an absolute path", lang, l.Container.Mount)
+		}
+	}
 
 	return cfg, nil
 }
@@ -133,3 +627,9 @@ func (c *Config) GetDatabasePath(projectRoot string) string {
 	}
 	return filepath.Join(projectRoot, c.Database.Path)
 }
+
+// GetSharedCachePath returns where a read-only shared index built in
+// another checkout is copied with its file paths moved under projectRoot
+func (c *Config) GetSharedCachePath(projectRoot string) string {
+	return filepath.Join(projectRoot, DefaultConfigDir, "graphs", "shared.db")
+}
@@ -1,6 +1,13 @@
 package config
 
-import "testing"
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
 
 func TestDefaultConfigUsesAutomaticTypeScriptServer(t *testing.T) {
 	cfg := DefaultConfig()
@@ -14,3 +21,422 @@ func TestDefaultConfigUsesAutomaticTypeScriptServer(t *testing.T) {
 		}
 	}
 }
+
+func TestFileFiltersAreOnlyOnForNewProjects(t *testing.T) {
+	if index := DefaultConfig().Index; index.MaxFileSize != 0 || index.ExcludeGenerated {
+		t.Errorf("default index = %+v, want no file filters", index)
+	}
+	if index := InitConfig().Index; index.MaxFileSize != 1<<20 || !index.ExcludeGenerated {
+		t.Errorf("init index = %+v, want the 1 MiB limit and generated files excluded", index)
+	}
+
+	// A config written before the filters existed keeps indexing everything
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, DefaultConfigDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, DefaultConfigDir, "config.toml"), []byte("[index]\nuse_gitignore = true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Index.MaxFileSize != 0 || cfg.Index.ExcludeGenerated {
+		t.Errorf("loaded index = %+v, want no file filters", cfg.Index)
+	}
+}
+
+func TestSearchTierOrderDefaultsWhenUnset(t *testing.T) {
+	if got := (SearchConfig{}).TierOrder(); len(got) != 2 || got[0] != "db" || got[1] != "ripgrep" {
+		t.Fatalf("default tiers = %#v", got)
+	}
+	custom := SearchConfig{Tiers: []string{"treesitter", "db"}}
+	if got := custom.TierOrder(); len(got) != 2 || got[0] != "treesitter" {
+		t.Fatalf("custom tiers = %#v", got)
+	}
+}
+
+func TestWorkspaceForPicksInnermostRootIndexingLanguage(t *testing.T) {
+	cfg := &Config{Workspaces: []WorkspaceConfig{
+		{Root: "."},
+		{Root: "services/api/", Languages: []string{"go"}},
+		{Root: "services/api/web", Languages: []string{"typescript"}},
+	}}
+	cases := []struct{ path, language, want string }{
+		{"services/api/main.go", "go", "services/api"},
+		{"services/api/web/app.tsx", "typescriptreact", "services/api/web"},
+		{"services/api/web/tool.go", "go", "services/api"},
+		{"services/apiserver/main.go", "go", "."},
+	}
+	for _, tc := range cases {
+		ws, ok := cfg.WorkspaceFor(tc.path, tc.language)
+		if !ok || ws.RelRoot() != tc.want {
+			t.Errorf("WorkspaceFor(%s, %s) = %q, %v; want %q", tc.path, tc.language, ws.RelRoot(), ok, tc.want)
+		}
+	}
+	if got := (WorkspaceConfig{Root: "services/api/"}).DisplayName(); got != "api" {
+		t.Errorf("DisplayName = %q", got)
+	}
+}
+
+func TestLoadRejectsWorkspaceOutsideProject(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, DefaultConfigDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(root, DefaultConfigDir, "config.toml")
+	if err := os.WriteFile(configPath, []byte("[[workspaces]]\nroot = \"../shared\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(root); err == nil {
+		t.Fatal("expected an error for a workspace root outside the project")
+	}
+
+	if err := os.WriteFile(configPath, []byte("[[workspaces]]\nroot = \"backend\"\nlanguages = [\"go\"]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Workspaces) != 1 || !cfg.Workspaces[0].HasLanguage("go") || cfg.Workspaces[0].HasLanguage("python") {
+		t.Fatalf("workspaces = %#v", cfg.Workspaces)
+	}
+}
+
+func TestLoadValidatesLSPTransportAndContainer(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, DefaultConfigDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(root, DefaultConfigDir, "config.toml")
+	for _, bad := range []string{
+		"[lsp.go]\ncommand = \"gopls\"\ntransport = \"pipe\"\n",
+		"[lsp.go]\ntransport = \"tcp\"\n",
+		"[lsp.go.container]\nmount = \"/src\"\n",
+		"[lsp.go.container]\nimage = \"golang:1.25\"\nmount = \"src\"\n",
+		"[lsp.go]\ntransport = \"tcp\"\naddress = \"localhost:37374\"\n[lsp.go.container]\nimage = \"golang:1.25\"\n",
+	} {
+		if err := os.WriteFile(configPath, []byte(bad), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(root); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+
+	if err := os.WriteFile(configPath, []byte("[lsp.go]\ntransport = \"tcp\"\naddress = \"localhost:37374\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.LSP["go"]; !got.IsSocket() || got.Address != "localhost:37374" {
+		t.Fatalf("lsp.go = %#v", got)
+	}
+	if cfg.LSP["python"].IsSocket() || cfg.LSP["python"].TransportMode() != TransportStdio {
+		t.Fatalf("lsp.python = %#v", cfg.LSP["python"])
+	}
+}
+
+func TestLoadValidatesKindRules(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, DefaultConfigDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(root, DefaultConfigDir, "config.toml")
+	for _, bad := range []string{
+		"[[kinds.rules]]\nname = \"^[A-Z]\"\n",
+		"[[kinds.rules]]\nkind = \"component\"\nname = \"([A-Z]\"\n",
+		"[[kinds.rules]]\nkind = \"component\"\nsignature = \"*\"\n",
+	} {
+		if err := os.WriteFile(configPath, []byte(bad), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(root); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+
+	good := "[kinds.lsp]\nProperty = \"property\"\n\n[kinds.tree_sitter]\nstruct_item = \"struct\"\n\n" +
+		"[[kinds.rules]]\nkind = \"component\"\nlanguages = [\"typescriptreact\"]\nfrom = [\"function\"]\nname = \"^[A-Z]\"\n"
+	if err := os.WriteFile(configPath, []byte(good), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Kinds.LSP["Property"] != "property" || cfg.Kinds.TreeSitter["struct_item"] != "struct" ||
+		len(cfg.Kinds.Rules) != 1 || cfg.Kinds.Rules[0].Kind != "component" {
+		t.Fatalf("kinds = %#v", cfg.Kinds)
+	}
+}
+
+func TestLoadResolvesStrategyOverrides(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, DefaultConfigDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(root, DefaultConfigDir, "config.toml")
+	for _, bad := range []string{
+		"[index]\nstrategy = \"fast\"\n",
+		"[index.languages.rust]\nstrategy = \"tree-sitter\"\n",
+	} {
+		if err := os.WriteFile(configPath, []byte(bad), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(root); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+
+	good := "[index]\nstrategy = \"lsp\"\n\n[index.languages.rust]\nstrategy = \"treesitter\"\n"
+	if err := os.WriteFile(configPath, []byte(good), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Index.StrategyFor("rust"); got != StrategyTreeSitter {
+		t.Errorf("rust strategy = %q, want treesitter", got)
+	}
+	if got := cfg.Index.StrategyFor("go"); got != StrategyLSP {
+		t.Errorf("go strategy = %q, want lsp", got)
+	}
+	if got := DefaultConfig().Index.StrategyFor("go"); got != StrategyHybrid {
+		t.Errorf("default strategy = %q, want hybrid", got)
+	}
+}
+
+func TestLoadValidatesCallResolution(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, DefaultConfigDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(root, DefaultConfigDir, "config.toml")
+	if err := os.WriteFile(configPath, []byte("[calls]\nresolve = \"module\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(root); err == nil {
+		t.Error("expected an error for calls.resolve = \"module\"")
+	}
+
+	good := "[calls]\nresolve = \"package\"\nkeep_builtins = true\n\n[calls.ignore]\npython = [\"run\"]\n"
+	if err := os.WriteFile(configPath, []byte(good), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Calls.Resolve != ResolvePackage || !cfg.Calls.KeepBuiltins || len(cfg.Calls.Ignore["python"]) != 1 {
+		t.Errorf("calls = %+v, want package resolution keeping builtins and ignoring run", cfg.Calls)
+	}
+}
+
+func TestLoadValidatesSearchTimeout(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, DefaultConfigDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(root, DefaultConfigDir, "config.toml")
+	if err := os.WriteFile(configPath, []byte("[search]\ntimeout_seconds = -1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(root); err == nil {
+		t.Error("expected an error for a negative search.timeout_seconds")
+	}
+
+	if err := os.WriteFile(configPath, []byte("[search]\ntimeout_seconds = 5\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Search.Timeout(); got != 5*time.Second {
+		t.Errorf("Timeout() = %v, want 5s", got)
+	}
+	if got := (SearchConfig{}).Timeout(); got != 0 {
+		t.Errorf("unset Timeout() = %v, want no limit", got)
+	}
+}
+
+func TestLoadAppliesProfile(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, DefaultConfigDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(root, DefaultConfigDir, "config.toml")
+	data := `[index]
+strategy = "lsp"
+max_file_size = 1000
+
+[lsp.go]
+command = "gopls"
+args = ["serve", "-rpc.trace"]
+
+[profiles.ci.index]
+strategy = "treesitter"
+
+[profiles.ci.lsp.go]
+args = ["serve"]
+
+[profiles.local.search]
+timeout_seconds = 90
+`
+	if err := os.WriteFile(configPath, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Index.Strategy != StrategyLSP || len(cfg.LSP["go"].Args) != 2 {
+		t.Errorf("without a profile: strategy = %q, go args = %v", cfg.Index.Strategy, cfg.LSP["go"].Args)
+	}
+
+	t.Setenv(ProfileEnv, "ci")
+	cfg, err = Load(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Index.Strategy != StrategyTreeSitter || cfg.Index.MaxFileSize != 1000 {
+		t.Errorf("ci index = %+v, want treesitter keeping max_file_size", cfg.Index)
+	}
+	if got := cfg.LSP["go"]; got.Command != "gopls" || len(got.Args) != 1 {
+		t.Errorf("ci go server = %+v, want gopls serve", got)
+	}
+	if cfg.Search.TimeoutSeconds != 30 {
+		t.Errorf("ci timeout = %d, want the default", cfg.Search.TimeoutSeconds)
+	}
+
+	t.Setenv(ProfileEnv, "fast")
+	if _, err := Load(root); err == nil || !strings.Contains(err.Error(), "profiles: ci, local") {
+		t.Errorf("Load with an undefined profile: err = %v", err)
+	}
+}
+
+func TestLoadExpandsEnvironmentVariables(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, DefaultConfigDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(root, DefaultConfigDir, "config.toml")
+	data := `[database]
+path = "${CODEGRAPH_TEST_DB_DIR}/codegraph.db"
+
+[lsp.java]
+command = "${CODEGRAPH_TEST_JAVA_HOME}/bin/jdtls"
+args = ["-data", "${CODEGRAPH_TEST_UNSET:-/tmp/jdtls}", "$HOME"]
+`
+	if err := os.WriteFile(configPath, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CODEGRAPH_TEST_DB_DIR", "/var/cache/cg")
+	t.Setenv("CODEGRAPH_TEST_JAVA_HOME", "/opt/java")
+
+	cfg, err := Load(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Database.Path != "/var/cache/cg/codegraph.db" {
+		t.Errorf("database path = %q", cfg.Database.Path)
+	}
+	java := cfg.LSP["java"]
+	if java.Command != "/opt/java/bin/jdtls" {
+		t.Errorf("java command = %q", java.Command)
+	}
+	if want := []string{"-data", "/tmp/jdtls", "$HOME"}; strings.Join(java.Args, " ") != strings.Join(want, " ") {
+		t.Errorf("java args = %q, want %q", java.Args, want)
+	}
+
+	if err := os.WriteFile(configPath, []byte("[database]\npath = \"${CODEGRAPH_TEST_UNSET}\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(root); err == nil {
+		t.Error("expected an error for a database path that expands to nothing")
+	}
+}
+
+func TestLoadQueries(t *testing.T) {
+	root := t.TempDir()
+	if queries, err := LoadQueries(root); err != nil || len(queries) != 0 {
+		t.Fatalf("LoadQueries without %s = %v, %v, want none", QueriesFile, queries, err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, DefaultConfigDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(root, DefaultConfigDir, QueriesFile)
+	for _, bad := range []string{
+		"[queries.both]\nsql = \"SELECT 1\"\ncommands = [[\"stats\"]]\n",
+		"[queries.neither]\ndescription = \"nothing to run\"\n",
+		"[queries.loop]\ncommands = [[\"run\", \"loop\"]]\n",
+		"[queries.unknown]\ncommands = [[\"callers\", \"{{symbol}}\"]]\n",
+		"[queries.dup]\nparams = [\"a\", \"a\"]\nsql = \"SELECT :a\"\n",
+		"[queries.\"bad name\"]\nsql = \"SELECT 1\"\n",
+	} {
+		if err := os.WriteFile(path, []byte(bad), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadQueries(root); err == nil {
+			t.Errorf("LoadQueries accepted %q", bad)
+		}
+	}
+
+	good := "[queries.impact]\ndescription = \"Callers and callees\"\nparams = [\"symbol\", \"depth\"]\n" +
+		"commands = [[\"callers\", \"{{symbol}}\", \"--depth={{ depth }}\"], [\"callees\", \"{{symbol}}\"]]\n\n" +
+		"[queries.classes]\nsql = \"SELECT name FROM symbols WHERE kind = 'class'\"\n"
+	if err := os.WriteFile(path, []byte(good), 0644); err != nil {
+		t.Fatal(err)
+	}
+	queries, err := LoadQueries(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := QueryNames(queries); strings.Join(names, ",") != "classes,impact" {
+		t.Errorf("names = %v, want classes and impact", names)
+	}
+	impact := queries["impact"]
+	if _, err := impact.Bind([]string{"parse"}); err == nil {
+		t.Error("Bind accepted too few arguments")
+	}
+	values, err := impact.Bind([]string{"parse", "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"callers", "parse", "--depth=2"}, {"callees", "parse"}}
+	if got := impact.Expand(values); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Expand = %q, want %q", got, want)
+	}
+}
+
+func TestEditorOpenerCommand(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "nvim")
+	got, err := (EditorConfig{}).OpenerCommand("/src/my file.go", 12, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"nvim", "+12", "/src/my file.go"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("$EDITOR=nvim command = %q, want %q", got, want)
+	}
+
+	t.Setenv("EDITOR", "")
+	if got, _ := (EditorConfig{}).OpenerCommand("a.go", 3, 1); strings.Join(got, " ") != "code -g a.go:3:1" {
+		t.Errorf("default command = %q, want VS Code", got)
+	}
+
+	custom := EditorConfig{Opener: "subl {file}:{line}:{column}"}
+	if got, _ := custom.OpenerCommand("a.go", 3, 7); strings.Join(got, " ") != "subl a.go:3:7" {
+		t.Errorf("opener command = %q", got)
+	}
+	if _, err := (EditorConfig{Opener: "code -g"}).OpenerCommand("a.go", 1, 1); err == nil {
+		t.Error("OpenerCommand accepted an opener without {file}")
+	}
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"os"
+	"regexp"
+)
+
+// envReference matches ${NAME} and ${NAME:-default} in config values
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// expandEnv replaces each ${NAME} in s with the environment variable's
+// value, and each ${NAME:-default} with default when NAME is unset or
+// empty. Like a shell, an unset NAME without a default expands to "". A
+// bare $NAME is left alone, as arguments may need a literal $.
+func expandEnv(s string) string {
+	return envReference.ReplaceAllStringFunc(s, func(ref string) string {
+		match := envReference.FindStringSubmatch(ref)
+		if value := os.Getenv(match[1]); value != "" {
+			return value
+		}
+		return match[2]
+	})
+}
+
+// expandEnv expands environment variables in the settings that name
+// machine-specific paths: language server commands, arguments, roots and
+// addresses, and the database path and remote. Configs can then be
+// committed and still work on every developer's machine.
+func (c *Config) expandEnv() {
+	for lang, l := range c.LSP {
+		l.Command = expandEnv(l.Command)
+		if l.Args != nil {
+			args := make([]string, len(l.Args))
+			for i, arg := range l.Args {
+				args[i] = expandEnv(arg)
+			}
+			l.Args = args
+		}
+		l.Root = expandEnv(l.Root)
+		l.Address = expandEnv(l.Address)
+		c.LSP[lang] = l
+	}
+	c.Database.Path = expandEnv(c.Database.Path)
+	c.Database.Remote = expandEnv(c.Database.Remote)
+}
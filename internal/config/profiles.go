@@ -0,0 +1,52 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// ProfileEnv names the profile Load applies, as the --profile flag does
+const ProfileEnv = "CODEGRAPH_PROFILE"
+
+// applyProfile returns the config.toml data with [profiles.<name>] merged
+// over the top-level settings: its tables are merged key by key, and any
+// other value, arrays included, replaces the top-level one. For example,
+//
+//	[profiles.ci.index]
+//	strategy = "treesitter"
+//
+// only changes index.strategy.
+func applyProfile(data []byte, name string) ([]byte, error) {
+	var doc map[string]any
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	profiles, _ := doc["profiles"].(map[string]any)
+	profile, ok := profiles[name].(map[string]any)
+	if !ok {
+		if len(profiles) == 0 {
+			return nil, fmt.Errorf("invalid config: profile %q is not defined; config.toml has no [profiles.*] sections", name)
+		}
+		return nil, fmt.Errorf("invalid config: profile %q is not defined (profiles: %s)", name, strings.Join(slices.Sorted(maps.Keys(profiles)), ", "))
+	}
+	delete(doc, "profiles")
+	mergeTables(doc, profile)
+	return toml.Marshal(doc)
+}
+
+// mergeTables merges src into dst, recursing into tables both define
+func mergeTables(dst, src map[string]any) {
+	for key, value := range src {
+		srcTable, srcIsTable := value.(map[string]any)
+		dstTable, dstIsTable := dst[key].(map[string]any)
+		if srcIsTable && dstIsTable {
+			mergeTables(dstTable, srcTable)
+			continue
+		}
+		dst[key] = value
+	}
+}
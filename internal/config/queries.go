@@ -0,0 +1,136 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// QueriesFile holds the project's saved queries, next to config.toml so it
+// can be committed and shared
+const QueriesFile = "queries.toml"
+
+// SavedQuery is a named analysis run by `codegraph run`: either SQL, which
+// refers to its parameters as :name bind parameters, or codegraph commands
+// run in order, whose arguments refer to them as {{name}}
+type SavedQuery struct {
+	Description string     `toml:"description"`
+	Params      []string   `toml:"params,omitempty"`   // Names of the positional arguments
+	SQL         string     `toml:"sql,omitempty"`      // Read-only SQL, as for `codegraph query`
+	Commands    [][]string `toml:"commands,omitempty"` // e.g. [["callers", "{{symbol}}", "--depth=2"]]
+}
+
+// queriesFile is the layout of QueriesFile
+type queriesFile struct {
+	Queries map[string]SavedQuery `toml:"queries"`
+}
+
+var (
+	queryNamePattern  = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+	queryParamPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	placeholder       = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+)
+
+// LoadQueries reads the saved queries of the project at projectRoot; a
+// project without QueriesFile has none
+func LoadQueries(projectRoot string) (map[string]SavedQuery, error) {
+	path := filepath.Join(projectRoot, DefaultConfigDir, QueriesFile)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return map[string]SavedQuery{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", QueriesFile, err)
+	}
+
+	var file queriesFile
+	if err := toml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", QueriesFile, err)
+	}
+	if file.Queries == nil {
+		file.Queries = map[string]SavedQuery{}
+	}
+	for name, q := range file.Queries {
+		if err := q.validate(name); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", QueriesFile, err)
+		}
+	}
+	return file.Queries, nil
+}
+
+func (q SavedQuery) validate(name string) error {
+	if !queryNamePattern.MatchString(name) {
+		return fmt.Errorf("query name %q must be letters, digits, '-', '_' and '.'", name)
+	}
+	if (q.SQL == "") == (len(q.Commands) == 0) {
+		return fmt.Errorf("queries.%s needs either sql or commands", name)
+	}
+	params := make(map[string]bool, len(q.Params))
+	for _, p := range q.Params {
+		if !queryParamPattern.MatchString(p) || params[p] {
+			return fmt.Errorf("queries.%s.params: %q must be a unique identifier", name, p)
+		}
+		params[p] = true
+	}
+	for i, command := range q.Commands {
+		if len(command) == 0 {
+			return fmt.Errorf("queries.%s.commands[%d] is empty", name, i)
+		}
+		if command[0] == "run" {
+			return fmt.Errorf("queries.%s.commands[%d] cannot run saved queries", name, i)
+		}
+		for _, arg := range command {
+			for _, m := range placeholder.FindAllStringSubmatch(arg, -1) {
+				if !params[m[1]] {
+					return fmt.Errorf("queries.%s.commands[%d] uses {{%s}}, which is not in params", name, i, m[1])
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Bind pairs args with the query's params, which must match in number
+func (q SavedQuery) Bind(args []string) (map[string]string, error) {
+	if len(args) != len(q.Params) {
+		if len(q.Params) == 0 {
+			return nil, fmt.Errorf("takes no arguments, got %d", len(args))
+		}
+		return nil, fmt.Errorf("takes %d argument(s) (%s), got %d", len(q.Params), strings.Join(q.Params, ", "), len(args))
+	}
+	values := make(map[string]string, len(args))
+	for i, p := range q.Params {
+		values[p] = args[i]
+	}
+	return values, nil
+}
+
+// Expand returns the query's commands with {{name}} replaced by the values
+// of the params
+func (q SavedQuery) Expand(values map[string]string) [][]string {
+	commands := make([][]string, len(q.Commands))
+	for i, command := range q.Commands {
+		commands[i] = make([]string, len(command))
+		for j, arg := range command {
+			commands[i][j] = placeholder.ReplaceAllStringFunc(arg, func(m string) string {
+				return values[placeholder.FindStringSubmatch(m)[1]]
+			})
+		}
+	}
+	return commands
+}
+
+// QueryNames returns the names of queries, sorted
+func QueryNames(queries map[string]SavedQuery) []string {
+	names := make([]string, 0, len(queries))
+	for name := range queries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,369 @@
+// Package daemon keeps a project's language servers and index open between
+// CLI invocations. `codegraph daemon` serves requests on a unix socket in
+// the project's .codegraph directory; query commands that need a language
+// server send their requests there when the daemon is running, instead of
+// paying for server startup and project analysis on every run.
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/tk-425/Codegraph/internal/config"
+	"github.com/tk-425/Codegraph/internal/db"
+	"github.com/tk-425/Codegraph/internal/lsp"
+)
+
+// SocketName is the daemon's socket file inside .codegraph
+const SocketName = "daemon.sock"
+
+// ErrNotRunning is returned by Dial when no daemon serves the project
+var ErrNotRunning = errors.New("codegraph daemon is not running")
+
+// Request methods
+const (
+	MethodPing           = "ping"
+	MethodImplementation = "implementation"
+	MethodReferences     = "references"
+	MethodShutdown       = "shutdown"
+)
+
+// Request is one call to the daemon, sent as a JSON line
+type Request struct {
+	Method   string       `json:"method"`
+	Language string       `json:"language,omitempty"`
+	File     string       `json:"file,omitempty"` // Absolute path
+	Position lsp.Position `json:"position"`
+	// Deadline is the caller's; the daemon gives up on the request then
+	Deadline time.Time `json:"deadline,omitzero"`
+}
+
+// Response answers a Request
+type Response struct {
+	Locations []lsp.Location `json:"locations,omitempty"`
+	Languages []string       `json:"languages,omitempty"` // ping: servers running
+	Error     string         `json:"error,omitempty"`
+}
+
+// SocketPath returns the daemon socket of the project at root
+func SocketPath(root string) string {
+	return filepath.Join(root, config.DefaultConfigDir, SocketName)
+}
+
+// Server serves a project's language servers over its daemon socket
+type Server struct {
+	root   string
+	lsp    *lsp.Manager
+	db     *db.Manager // Held open for the daemon's lifetime; may be nil
+	dbPath string
+	stop   context.CancelFunc
+
+	mu sync.Mutex // Guards the fields below
+	// docs are the files opened on the servers, by URI
+	docs map[string]*document
+	// indexed is file_meta as of the last request, and indexedAt the
+	// database's modification time then
+	indexed   map[string]time.Time
+	indexedAt time.Time
+}
+
+// document is a file opened on a language server
+type document struct {
+	client  *lsp.Client
+	path    string
+	version int
+	modTime time.Time
+}
+
+// NewServer creates a daemon for the project at root. It keeps dbManager,
+// the project's index, open while it runs, and tells the language servers
+// about the files each rebuild of the index reindexed.
+func NewServer(cfg *config.Config, root string, dbManager *db.Manager) *Server {
+	return &Server{
+		root:   root,
+		lsp:    lsp.NewManager(cfg, lsp.PathToURI(root)),
+		db:     dbManager,
+		dbPath: cfg.GetDatabasePath(root),
+		docs:   make(map[string]*document),
+	}
+}
+
+// Serve listens on the project's socket and answers requests until ctx is
+// done or a client asks it to shut down. Language servers are started on
+// first use and shut down when Serve returns.
+func (s *Server) Serve(ctx context.Context) error {
+	path := SocketPath(s.root)
+	if _, err := os.Stat(path); err == nil {
+		if client, err := Dial(s.root); err == nil {
+			client.Close()
+			return fmt.Errorf("a daemon is already running for this project (%s)", path)
+		}
+		// Left behind by a daemon that did not exit cleanly
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	ctx, s.stop = context.WithCancel(ctx)
+	defer s.lsp.ShutdownAll()
+
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("accept failed: %w", err)
+		}
+		go s.handle(ctx, conn)
+	}
+}
+
+// handle answers the single request sent on conn. The request is canceled
+// at its deadline, or when the client hangs up before the answer.
+func (s *Server) handle(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	var req Request
+	if err := json.NewDecoder(reader).Decode(&req); err != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if !req.Deadline.IsZero() {
+		ctx, cancel = context.WithDeadline(ctx, req.Deadline)
+		defer cancel()
+	}
+	go func() {
+		// Clients send nothing after the request; EOF means they left
+		_, _ = io.Copy(io.Discard, reader)
+		cancel()
+	}()
+
+	resp := s.dispatch(ctx, req)
+	_ = json.NewEncoder(conn).Encode(resp)
+}
+
+// dispatch answers req. Requests run concurrently: starting a language
+// server only blocks other requests that need the same server.
+func (s *Server) dispatch(ctx context.Context, req Request) Response {
+	switch req.Method {
+	case MethodPing:
+		return Response{Languages: s.lsp.ActiveLanguages()}
+	case MethodShutdown:
+		s.stop()
+		return Response{}
+	case MethodImplementation, MethodReferences:
+		client, err := s.lsp.GetClientForFile(ctx, req.Language, req.File)
+		if err != nil {
+			return Response{Error: err.Error()}
+		}
+		if err := s.sync(client, req.Language, req.File); err != nil {
+			return Response{Error: err.Error()}
+		}
+		uri := lsp.PathToURI(req.File)
+		var locations []lsp.Location
+		if req.Method == MethodImplementation {
+			locations, err = client.Implementation(ctx, uri, req.Position)
+		} else {
+			locations, err = client.References(ctx, uri, req.Position, false)
+		}
+		if err != nil {
+			return Response{Error: err.Error()}
+		}
+		return Response{Locations: locations}
+	default:
+		return Response{Error: fmt.Sprintf("unknown method %q", req.Method)}
+	}
+}
+
+// sync brings the servers up to date before a request about file: it
+// reports the files reindexed since the last request, sends the current
+// text of opened files that changed on disk, and opens file on client
+func (s *Server) sync(client *lsp.Client, language, file string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.notifyReindexed()
+	for uri, doc := range s.docs {
+		info, err := os.Stat(doc.path)
+		if err != nil {
+			doc.client.DidCloseTextDocument(uri)
+			delete(s.docs, uri)
+			continue
+		}
+		if info.ModTime().Equal(doc.modTime) {
+			continue
+		}
+		content, err := os.ReadFile(doc.path)
+		if err != nil {
+			continue
+		}
+		doc.version++
+		doc.modTime = info.ModTime()
+		if err := doc.client.DidChangeTextDocument(uri, doc.version, string(content)); err != nil {
+			return fmt.Errorf("failed to update %s: %w", doc.path, err)
+		}
+	}
+
+	uri := lsp.PathToURI(file)
+	if doc, ok := s.docs[uri]; ok && doc.client == client {
+		return nil
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	if err := client.DidOpenTextDocument(uri, language, string(content)); err != nil {
+		return fmt.Errorf("failed to open %s: %w", file, err)
+	}
+	s.docs[uri] = &document{client: client, path: file, version: 1, modTime: info.ModTime()}
+	return nil
+}
+
+// notifyReindexed tells every running server about the files added,
+// changed or removed by builds since the last request, found by comparing
+// file_meta with what it held then. The database file's modification time
+// saves reading file_meta when nothing was rebuilt. s.mu must be held.
+func (s *Server) notifyReindexed() {
+	if s.db == nil {
+		return
+	}
+	info, err := os.Stat(s.dbPath)
+	if err != nil || info.ModTime().Equal(s.indexedAt) {
+		return
+	}
+	indexed, err := s.db.FileModTimes()
+	if err != nil {
+		return
+	}
+	previous := s.indexed
+	s.indexed, s.indexedAt = indexed, info.ModTime()
+	if previous == nil {
+		return // The servers read the files themselves at startup
+	}
+
+	var changes []lsp.FileEvent
+	for path, modTime := range indexed {
+		if before, ok := previous[path]; !ok {
+			changes = append(changes, lsp.FileEvent{URI: lsp.PathToURI(path), Type: lsp.FileCreated})
+		} else if !before.Equal(modTime) {
+			changes = append(changes, lsp.FileEvent{URI: lsp.PathToURI(path), Type: lsp.FileChanged})
+		}
+	}
+	for path := range previous {
+		if _, ok := indexed[path]; !ok {
+			changes = append(changes, lsp.FileEvent{URI: lsp.PathToURI(path), Type: lsp.FileDeleted})
+		}
+	}
+	if len(changes) == 0 {
+		return
+	}
+	for _, client := range s.lsp.Clients() {
+		client.DidChangeWatchedFiles(changes)
+	}
+}
+
+// Client talks to a running daemon
+type Client struct {
+	path string
+}
+
+// dialTimeout bounds connecting to the daemon socket
+const dialTimeout = 500 * time.Millisecond
+
+// Dial connects to the daemon of the project at root, returning
+// ErrNotRunning when there is none
+func Dial(root string) (*Client, error) {
+	c := &Client{path: SocketPath(root)}
+	// Connecting is enough: a ping could wait on a server starting up
+	conn, err := net.DialTimeout("unix", c.path, dialTimeout)
+	if err != nil {
+		return nil, ErrNotRunning
+	}
+	conn.Close()
+	return c, nil
+}
+
+// Close releases the client. Each request uses its own connection, so
+// there is nothing to release yet.
+func (c *Client) Close() error {
+	return nil
+}
+
+// Ping returns the languages whose servers the daemon is running
+func (c *Client) Ping(ctx context.Context) ([]string, error) {
+	resp, err := c.call(ctx, Request{Method: MethodPing})
+	return resp.Languages, err
+}
+
+// Implementation asks the language's server for the implementations of the
+// symbol at pos in file
+func (c *Client) Implementation(ctx context.Context, language, file string, pos lsp.Position) ([]lsp.Location, error) {
+	resp, err := c.call(ctx, Request{Method: MethodImplementation, Language: language, File: file, Position: pos})
+	return resp.Locations, err
+}
+
+// References asks the language's server for the references to the symbol
+// at pos in file, excluding its declaration
+func (c *Client) References(ctx context.Context, language, file string, pos lsp.Position) ([]lsp.Location, error) {
+	resp, err := c.call(ctx, Request{Method: MethodReferences, Language: language, File: file, Position: pos})
+	return resp.Locations, err
+}
+
+// Shutdown stops the daemon and its language servers
+func (c *Client) Shutdown(ctx context.Context) error {
+	_, err := c.call(ctx, Request{Method: MethodShutdown})
+	return err
+}
+
+func (c *Client) call(ctx context.Context, req Request) (Response, error) {
+	dialer := net.Dialer{Timeout: dialTimeout}
+	conn, err := dialer.DialContext(ctx, "unix", c.path)
+	if err != nil {
+		return Response{}, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		req.Deadline = deadline
+	}
+	// Hanging up cancels the request on the daemon's side
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return Response{}, fmt.Errorf("failed to send request: %w", err)
+	}
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return Response{}, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.Error != "" {
+		return resp, errors.New(resp.Error)
+	}
+	return resp, nil
+}
@@ -0,0 +1,231 @@
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tk-425/Codegraph/internal/config"
+	"github.com/tk-425/Codegraph/internal/lsp"
+)
+
+// newProjectRoot returns a project directory with a .codegraph directory
+func newProjectRoot(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, config.DefaultConfigDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+// startServer serves root in the background until the test ends
+func startServer(t *testing.T, cfg *config.Config, root string) *Client {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		NewServer(cfg, root, nil).Serve(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	for range 50 {
+		if client, err := Dial(root); err == nil {
+			return client
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("daemon did not start")
+	return nil
+}
+
+// serveFakeLSP runs a language server on a unix socket that answers
+// initialize and implementation requests, never answers references, and
+// sends the methods of the notifications it gets on notified
+func serveFakeLSP(t *testing.T) (string, <-chan string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "lsp.sock")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	notified := make(chan string, 100)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		for {
+			var length int
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line = strings.TrimSpace(line); line == "" {
+					break
+				}
+				fmt.Sscanf(line, "Content-Length: %d", &length)
+			}
+			body := make([]byte, length)
+			if _, err := io.ReadFull(reader, body); err != nil {
+				return
+			}
+			var msg struct {
+				ID     int64  `json:"id"`
+				Method string `json:"method"`
+			}
+			json.Unmarshal(body, &msg)
+			var result string
+			switch {
+			case msg.ID == 0:
+				notified <- msg.Method
+				continue
+			case msg.Method == "initialize":
+				result = `{"capabilities":{}}`
+			case msg.Method == "textDocument/implementation":
+				result = `[]`
+			default:
+				continue
+			}
+			reply := fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"result":%s}`, msg.ID, result)
+			fmt.Fprintf(conn, "Content-Length: %d\r\n\r\n%s", len(reply), reply)
+		}
+	}()
+	return path, notified
+}
+
+// waitForNotification returns once the fake server got method
+func waitForNotification(t *testing.T, notified <-chan string, method string) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case got := <-notified:
+			if got == method {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("server never got %s", method)
+		}
+	}
+}
+
+func TestServerSyncsDocumentsAndHonorsDeadlines(t *testing.T) {
+	root := newProjectRoot(t)
+	address, notified := serveFakeLSP(t)
+	cfg := config.DefaultConfig()
+	cfg.LSP = map[string]config.LSPConfig{"go": {Transport: config.TransportUnix, Address: address}}
+	client := startServer(t, cfg, root)
+
+	file := filepath.Join(root, "a.go")
+	if err := os.WriteFile(file, []byte("package a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := client.Implementation(ctx, "go", file, lsp.Position{}); err != nil {
+		t.Fatalf("Implementation: %v", err)
+	}
+	waitForNotification(t, notified, "textDocument/didOpen")
+
+	// An edit reaches the server before the next request
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(file, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Implementation(ctx, "go", file, lsp.Position{}); err != nil {
+		t.Fatalf("Implementation: %v", err)
+	}
+	waitForNotification(t, notified, "textDocument/didChange")
+
+	// A request the server never answers ends at the caller's deadline,
+	// and does not hold up other requests meanwhile
+	hung := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(ctx, 300*time.Millisecond)
+		defer cancel()
+		_, err := client.References(ctx, "go", file, lsp.Position{})
+		hung <- err
+	}()
+	if _, err := client.Ping(ctx); err != nil {
+		t.Fatalf("Ping during a hung request: %v", err)
+	}
+	select {
+	case err := <-hung:
+		if err == nil {
+			t.Fatal("References to a hung server succeeded")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("References did not stop at its deadline")
+	}
+}
+
+func TestServeAnswersClients(t *testing.T) {
+	root := newProjectRoot(t)
+	if _, err := Dial(root); !errors.Is(err, ErrNotRunning) {
+		t.Fatalf("Dial before serve = %v, want ErrNotRunning", err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.LSP["python"] = config.LSPConfig{Command: "missing-python-lsp"}
+	done := make(chan error, 1)
+	go func() { done <- NewServer(cfg, root, nil).Serve(context.Background()) }()
+
+	var client *Client
+	for i := 0; i < 50; i++ {
+		var err error
+		if client, err = Dial(root); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if client == nil {
+		t.Fatal("daemon did not start")
+	}
+	defer client.Close()
+
+	if err := NewServer(cfg, root, nil).Serve(context.Background()); err == nil || !strings.Contains(err.Error(), "already running") {
+		t.Fatalf("second Serve = %v, want already running", err)
+	}
+
+	ctx := context.Background()
+	if languages, err := client.Ping(ctx); err != nil || len(languages) != 0 {
+		t.Fatalf("Ping = %v, %v", languages, err)
+	}
+	// Server errors reach the client
+	_, err := client.Implementation(ctx, "python", filepath.Join(root, "a.py"), lsp.Position{})
+	if err == nil || !strings.Contains(err.Error(), "missing-python-lsp") {
+		t.Fatalf("Implementation error = %v", err)
+	}
+
+	if err := client.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("daemon did not stop")
+	}
+	if _, err := os.Stat(SocketPath(root)); !os.IsNotExist(err) {
+		t.Fatalf("socket left behind: %v", err)
+	}
+}
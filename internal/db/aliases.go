@@ -0,0 +1,75 @@
+package db
+
+import "fmt"
+
+// Kinds of Alias
+const (
+	AliasImport = "import" // import x as y: y names x in the importing file
+	AliasExport = "export" // export { a as b }: b names a for the importers
+)
+
+// Alias is a name a file imports or exports under another name
+type Alias struct {
+	ID     int64
+	File   string
+	Alias  string // The name used: y in import x as y
+	Name   string // The name it stands for: x; a package name for Go
+	Module string // Where it comes from, as written: "./x", a.b, "example.com/mathutil"
+	Kind   string // AliasImport or AliasExport
+	Line   int
+}
+
+// ClearAliases deletes the aliases of files before they are re-extracted
+func (m *Manager) ClearAliases(files []string) error {
+	if len(files) == 0 {
+		return nil
+	}
+	in, list := inList(files)
+	if _, err := m.exec("DELETE FROM aliases WHERE file IN "+in, list); err != nil {
+		return fmt.Errorf("failed to clear aliases: %w", err)
+	}
+	return nil
+}
+
+// InsertAlias records an aliased import or export
+func (m *Manager) InsertAlias(a *Alias) error {
+	_, err := m.exec(`
+		INSERT INTO aliases (file, alias, name, module, kind, line)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		a.File, a.Alias, a.Name, a.Module, a.Kind, a.Line,
+	)
+	return err
+}
+
+// GetFileAliases returns the aliases a file imports, in source order
+func (m *Manager) GetFileAliases(file string) ([]Alias, error) {
+	return m.queryAliases("file = ? AND kind = ?", file, AliasImport)
+}
+
+// GetExportedAliases returns the exports of any file under the name alias,
+// in file order
+func (m *Manager) GetExportedAliases(alias string) ([]Alias, error) {
+	return m.queryAliases("alias = ? AND kind = ?", alias, AliasExport)
+}
+
+func (m *Manager) queryAliases(cond string, args ...interface{}) ([]Alias, error) {
+	rows, err := m.query(`
+		SELECT id, file, alias, name, module, kind, line
+		FROM aliases
+		WHERE `+cond+`
+		ORDER BY file, line, id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var aliases []Alias
+	for rows.Next() {
+		var a Alias
+		if err := rows.Scan(&a.ID, &a.File, &a.Alias, &a.Name, &a.Module, &a.Kind, &a.Line); err != nil {
+			return nil, err
+		}
+		aliases = append(aliases, a)
+	}
+	return aliases, rows.Err()
+}
@@ -0,0 +1,100 @@
+package db
+
+// CallSite is one call edge with both of its ends
+type CallSite struct {
+	Caller   Symbol
+	Callee   Symbol
+	File     string // File where the call occurs
+	Line     int
+	Column   int
+	ArgCount *int
+	Args     string
+	Context  string
+}
+
+// GrepCalls finds the call sites of every callee whose name matches pattern,
+// a Go regular expression or, with glob, a shell glob over the whole name.
+// opts filter on the calling symbol, like GetCallers.
+func (m *Manager) GrepCalls(pattern string, glob bool, opts QueryOptions) ([]CallSite, error) {
+	match := "e.name REGEXP ?"
+	if glob {
+		match = "e.name GLOB ?"
+	}
+	query := `
+		SELECT s.id, s.name, s.kind, s.file, s.line, s.column, s.end_line, s.end_column,
+		       s.scope, s.signature, s.documentation, s.language, s.source, s.created_at, s.is_test, s.package,
+		       e.id, e.name, e.kind, e.file, e.line, e.column, e.end_line, e.end_column,
+		       e.scope, e.signature, e.documentation, e.language, e.source, e.created_at, e.is_test, e.package,
+		       c.file, c.line, c.column, c.arg_count, COALESCE(c.args, ''), COALESCE(c.context, '')
+		FROM calls c
+		JOIN symbols s ON s.id = c.caller_id
+		JOIN symbols e ON e.id = c.callee_id
+		WHERE ` + match
+	args := []interface{}{pattern}
+
+	query, args = applyQueryOptions(query, args, "s.", opts)
+	query, args = applyCallSiteOptions(query, args, opts)
+
+	// One row per call site when it resolved to several callees
+	query += " GROUP BY c.file, c.line, c.column"
+	query, args = orderAndPage(query, args, callSiteSortColumns, opts, SortFile)
+
+	rows, err := m.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sites []CallSite
+	for rows.Next() {
+		var cs CallSite
+		s, e := &cs.Caller, &cs.Callee
+		err := rows.Scan(
+			&s.ID, &s.Name, &s.Kind, &s.File, &s.Line, &s.Column,
+			&s.EndLine, &s.EndColumn, &s.Scope, &s.Signature,
+			&s.Documentation, &s.Language, &s.Source, &s.CreatedAt, &s.IsTest, &s.Package,
+			&e.ID, &e.Name, &e.Kind, &e.File, &e.Line, &e.Column,
+			&e.EndLine, &e.EndColumn, &e.Scope, &e.Signature,
+			&e.Documentation, &e.Language, &e.Source, &e.CreatedAt, &e.IsTest, &e.Package,
+			&cs.File, &cs.Line, &cs.Column, &cs.ArgCount, &cs.Args, &cs.Context,
+		)
+		if err != nil {
+			return nil, err
+		}
+		sites = append(sites, cs)
+	}
+	return sites, rows.Err()
+}
+
+// CallDegree is how connected a symbol is in the call graph
+type CallDegree struct {
+	FanIn  int `json:"fan_in"`  // Distinct callers
+	FanOut int `json:"fan_out"` // Distinct callees
+}
+
+// GetCallDegrees returns the fan-in and fan-out of every symbol with
+// calls, by ID
+func (m *Manager) GetCallDegrees() (map[string]CallDegree, error) {
+	rows, err := m.query(`
+		SELECT id, SUM(fan_in), SUM(fan_out) FROM (
+			SELECT callee_id AS id, COUNT(DISTINCT caller_id) AS fan_in, 0 AS fan_out FROM calls GROUP BY callee_id
+			UNION ALL
+			SELECT caller_id, 0, COUNT(DISTINCT callee_id) FROM calls GROUP BY caller_id
+		)
+		GROUP BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	degrees := make(map[string]CallDegree)
+	for rows.Next() {
+		var id string
+		var d CallDegree
+		if err := rows.Scan(&id, &d.FanIn, &d.FanOut); err != nil {
+			return nil, err
+		}
+		degrees[id] = d
+	}
+	return degrees, rows.Err()
+}
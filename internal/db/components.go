@@ -0,0 +1,124 @@
+package db
+
+import "fmt"
+
+// UI frameworks of the components the indexer recognizes
+const (
+	FrameworkReact = "react"
+	FrameworkVue   = "vue"
+)
+
+// Component is a symbol the indexer recognized as a UI component
+type Component struct {
+	Symbol
+	Framework string `json:"framework"`
+}
+
+// Render is a JSX element a component renders: <Child /> inside Parent
+type Render struct {
+	ID       int64
+	ParentID string
+	Child    string  // Element name as written, e.g. Card or UI.Card
+	ChildID  *string // Child component, when it resolved to one
+	File     string
+	Line     int
+	Column   int
+	Parent   Symbol // Rendering component; filled by ListRenders
+}
+
+// ClearComponents removes every component and render edge before they are
+// re-extracted
+func (m *Manager) ClearComponents() error {
+	for _, table := range []string{"renders", "components"} {
+		if _, err := m.db.Exec("DELETE FROM " + table); err != nil {
+			return fmt.Errorf("failed to clear %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// InsertComponent records a symbol as a component of framework
+func (m *Manager) InsertComponent(symbolID, framework string) error {
+	_, err := m.db.Exec("INSERT OR REPLACE INTO components (symbol_id, framework) VALUES (?, ?)", symbolID, framework)
+	return err
+}
+
+// InsertRender records a JSX element rendered by a component
+func (m *Manager) InsertRender(r *Render) error {
+	_, err := m.db.Exec(`
+		INSERT INTO renders (parent_id, child, child_id, file, line, column)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		r.ParentID, r.Child, r.ChildID, r.File, r.Line, r.Column,
+	)
+	return err
+}
+
+// ListComponents returns the components matching opts, ordered by file by
+// default
+func (m *Manager) ListComponents(opts QueryOptions) ([]Component, error) {
+	query := `
+		SELECT s.id, s.name, s.kind, s.file, s.line, s.column, s.end_line, s.end_column,
+		       s.scope, s.signature, s.documentation, s.language, s.source, s.created_at, s.is_test, s.package,
+		       c.framework
+		FROM components c
+		JOIN symbols s ON s.id = c.symbol_id
+		WHERE 1 = 1`
+	var args []interface{}
+	query, args = applyQueryOptions(query, args, "s.", opts)
+	query, args = orderAndPage(query, args, symbolSortColumns("s."), opts, SortFile)
+
+	rows, err := m.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var components []Component
+	for rows.Next() {
+		var c Component
+		s := &c.Symbol
+		err := rows.Scan(
+			&s.ID, &s.Name, &s.Kind, &s.File, &s.Line, &s.Column,
+			&s.EndLine, &s.EndColumn, &s.Scope, &s.Signature,
+			&s.Documentation, &s.Language, &s.Source, &s.CreatedAt, &s.IsTest, &s.Package,
+			&c.Framework,
+		)
+		if err != nil {
+			return nil, err
+		}
+		components = append(components, c)
+	}
+	return components, rows.Err()
+}
+
+// ListRenders returns every render edge, ordered by where the element is
+func (m *Manager) ListRenders() ([]Render, error) {
+	rows, err := m.db.Query(`
+		SELECT r.id, r.parent_id, r.child, r.child_id, r.file, r.line, r.column,
+		       s.id, s.name, s.kind, s.file, s.line, s.column, s.end_line, s.end_column,
+		       s.scope, s.signature, s.documentation, s.language, s.source, s.created_at, s.is_test, s.package
+		FROM renders r
+		JOIN symbols s ON s.id = r.parent_id
+		ORDER BY r.file, r.line, r.column, r.id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var renders []Render
+	for rows.Next() {
+		var r Render
+		s := &r.Parent
+		err := rows.Scan(
+			&r.ID, &r.ParentID, &r.Child, &r.ChildID, &r.File, &r.Line, &r.Column,
+			&s.ID, &s.Name, &s.Kind, &s.File, &s.Line, &s.Column,
+			&s.EndLine, &s.EndColumn, &s.Scope, &s.Signature,
+			&s.Documentation, &s.Language, &s.Source, &s.CreatedAt, &s.IsTest, &s.Package,
+		)
+		if err != nil {
+			return nil, err
+		}
+		renders = append(renders, r)
+	}
+	return renders, rows.Err()
+}
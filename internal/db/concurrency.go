@@ -0,0 +1,122 @@
+package db
+
+import "fmt"
+
+// Concurrency operations recorded by the indexer
+const (
+	ConcurrencyGo      = "go"      // Goroutine launch
+	ConcurrencySend    = "send"    // Channel send
+	ConcurrencyReceive = "receive" // Channel receive
+	ConcurrencyClose   = "close"   // Channel close
+	ConcurrencyLock    = "lock"
+	ConcurrencyUnlock  = "unlock"
+	ConcurrencyRLock   = "rlock"
+	ConcurrencyRUnlock = "runlock"
+)
+
+// ConcurrencyOps lists the operations in display order
+var ConcurrencyOps = []string{
+	ConcurrencyGo, ConcurrencySend, ConcurrencyReceive, ConcurrencyClose,
+	ConcurrencyLock, ConcurrencyUnlock, ConcurrencyRLock, ConcurrencyRUnlock,
+}
+
+// ConcurrencySite is a goroutine launch, channel operation or mutex
+// operation inside a function
+type ConcurrencySite struct {
+	ID       int64
+	SymbolID string // Enclosing function
+	Op       string
+	Target   string  // Launched function, channel or mutex expression
+	TargetID *string // Launched function, when indexed
+	File     string
+	Line     int
+	Column   int
+	Function Symbol // Enclosing function; filled by ListConcurrency
+}
+
+// InsertConcurrencySite records a concurrency site
+func (m *Manager) InsertConcurrencySite(c *ConcurrencySite) error {
+	_, err := m.db.Exec(`
+		INSERT INTO concurrency (symbol_id, op, target, target_id, file, line, column)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		c.SymbolID, c.Op, c.Target, c.TargetID, c.File, c.Line, c.Column,
+	)
+	return err
+}
+
+// ClearConcurrency deletes the concurrency sites in files before they are
+// re-extracted
+func (m *Manager) ClearConcurrency(files []string) error {
+	if len(files) == 0 {
+		return nil
+	}
+	in := placeholders(len(files))
+	query := `
+		DELETE FROM concurrency
+		WHERE file IN ` + in + `
+		   OR symbol_id IN (SELECT id FROM symbols WHERE file IN ` + in + `)`
+
+	if _, err := m.db.Exec(query, repeatArgs(files, 2)...); err != nil {
+		return fmt.Errorf("failed to clear concurrency sites: %w", err)
+	}
+	return nil
+}
+
+// ListConcurrency returns the concurrency sites inside the functions
+// symbolIDs (every function when empty) with one of ops (any when empty).
+// opts filter on the enclosing function; sites are ordered by location.
+func (m *Manager) ListConcurrency(symbolIDs, ops []string, opts QueryOptions) ([]ConcurrencySite, error) {
+	query := `
+		SELECT k.id, k.symbol_id, k.op, k.target, k.target_id, k.file, k.line, k.column,
+		       s.id, s.name, s.kind, s.file, s.line, s.column, s.end_line, s.end_column,
+		       s.scope, s.signature, s.documentation, s.language, s.source, s.created_at, s.is_test, s.package
+		FROM concurrency k
+		JOIN symbols s ON s.id = k.symbol_id
+		WHERE 1 = 1`
+	var args []interface{}
+	if len(symbolIDs) > 0 {
+		query += " AND k.symbol_id IN " + placeholders(len(symbolIDs))
+		for _, id := range symbolIDs {
+			args = append(args, id)
+		}
+	}
+	if len(ops) > 0 {
+		query += " AND k.op IN " + placeholders(len(ops))
+		for _, op := range ops {
+			args = append(args, op)
+		}
+	}
+	query, args = applyQueryOptions(query, args, "s.", opts)
+	query, args = orderAndPage(query, args, concurrencySortColumns, opts, SortFile)
+
+	rows, err := m.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sites []ConcurrencySite
+	for rows.Next() {
+		var c ConcurrencySite
+		s := &c.Function
+		err := rows.Scan(
+			&c.ID, &c.SymbolID, &c.Op, &c.Target, &c.TargetID, &c.File, &c.Line, &c.Column,
+			&s.ID, &s.Name, &s.Kind, &s.File, &s.Line, &s.Column,
+			&s.EndLine, &s.EndColumn, &s.Scope, &s.Signature,
+			&s.Documentation, &s.Language, &s.Source, &s.CreatedAt, &s.IsTest, &s.Package,
+		)
+		if err != nil {
+			return nil, err
+		}
+		sites = append(sites, c)
+	}
+	return sites, rows.Err()
+}
+
+// concurrencySortColumns sorts concurrency sites by their location
+var concurrencySortColumns = sortColumns{
+	Name:   "s.name",
+	File:   "k.file",
+	Line:   "k.line",
+	Column: "k.column",
+}
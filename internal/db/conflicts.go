@@ -0,0 +1,122 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+// Fields a SymbolConflict can disagree on
+const (
+	ConflictKind    = "kind"    // Both found the symbol with different kinds
+	ConflictLine    = "line"    // Both found the symbol on different lines
+	ConflictMissing = "missing" // Only one extractor found the symbol
+)
+
+// SymbolConflict is a disagreement between the language server and
+// tree-sitter about a symbol of a file that was indexed by one and then
+// re-indexed by the other
+type SymbolConflict struct {
+	ID         int64     `json:"-"`
+	File       string    `json:"file"`
+	SymbolID   string    `json:"symbol_id"`
+	Name       string    `json:"name"`
+	Field      string    `json:"field"`       // ConflictKind, ConflictLine or ConflictMissing
+	LSP        string    `json:"lsp"`         // The server's value; empty when it did not find the symbol
+	TreeSitter string    `json:"tree_sitter"` // Tree-sitter's value; empty when it did not find the symbol
+	Kept       string    `json:"kept"`        // Extractor whose symbols the index keeps
+	FoundAt    time.Time `json:"found_at"`
+}
+
+// ReplaceFileConflicts records the conflicts found when file was last
+// reconciled, replacing the ones found before
+func (m *Manager) ReplaceFileConflicts(file string, conflicts []SymbolConflict) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("DELETE FROM symbol_conflicts WHERE file = ?", file); err != nil {
+		return fmt.Errorf("failed to clear conflicts: %w", err)
+	}
+	for _, c := range conflicts {
+		_, err := tx.Exec(`
+			INSERT INTO symbol_conflicts (file, symbol_id, name, field, lsp, tree_sitter, kept, found_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			file, c.SymbolID, c.Name, c.Field, c.LSP, c.TreeSitter, c.Kept, c.FoundAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to record conflict: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// ListConflicts returns every recorded conflict, by file and symbol
+func (m *Manager) ListConflicts() ([]SymbolConflict, error) {
+	rows, err := m.db.Query(`
+		SELECT id, file, symbol_id, name, field, lsp, tree_sitter, kept, found_at
+		FROM symbol_conflicts
+		ORDER BY file, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var conflicts []SymbolConflict
+	for rows.Next() {
+		var c SymbolConflict
+		if err := rows.Scan(&c.ID, &c.File, &c.SymbolID, &c.Name, &c.Field, &c.LSP, &c.TreeSitter, &c.Kept, &c.FoundAt); err != nil {
+			return nil, err
+		}
+		conflicts = append(conflicts, c)
+	}
+	return conflicts, rows.Err()
+}
+
+// CountConflicts returns how many conflicts are recorded
+func (m *Manager) CountConflicts() (int, error) {
+	var count int
+	err := m.db.QueryRow("SELECT COUNT(*) FROM symbol_conflicts").Scan(&count)
+	return count, err
+}
+
+// DeleteSymbols removes symbols from the index with every call, type
+// relation, containment row, embedding and tag touching them
+func (m *Manager) DeleteSymbols(ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	in, list := inList(ids)
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	statements := []struct {
+		query string
+		sets  int // how many times the ID list is bound
+	}{
+		{"DELETE FROM calls WHERE caller_id IN " + in + " OR callee_id IN " + in, 2},
+		{"DELETE FROM type_hierarchy WHERE child_id IN " + in + " OR parent_id IN " + in, 2},
+		{"DELETE FROM contains WHERE child_id IN " + in + " OR parent_id IN " + in, 2},
+		{"DELETE FROM embeddings WHERE symbol_id IN " + in, 1},
+		{"DELETE FROM concurrency WHERE symbol_id IN " + in, 1},
+		{"DELETE FROM components WHERE symbol_id IN " + in, 1},
+		{"DELETE FROM renders WHERE parent_id IN " + in + " OR child_id IN " + in, 2},
+		{"DELETE FROM tags WHERE symbol_id IN " + in, 1},
+		{"DELETE FROM symbol_history WHERE symbol_id IN " + in, 1},
+		{"DELETE FROM symbols WHERE id IN " + in, 1},
+	}
+	for _, s := range statements {
+		args := make([]interface{}, s.sets)
+		for i := range args {
+			args[i] = list
+		}
+		if _, err := tx.Exec(s.query, args...); err != nil {
+			return fmt.Errorf("failed to delete symbols: %w", err)
+		}
+	}
+	return tx.Commit()
+}
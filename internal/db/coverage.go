@@ -0,0 +1,45 @@
+package db
+
+// FileCoverage is what the index holds for one scanned file
+type FileCoverage struct {
+	Path      string
+	Language  string
+	Source    string // Extractor: lsp or tree-sitter; empty for files indexed before it was recorded
+	Symbols   int
+	Functions int // Functions and methods
+	Calls     int // Call sites in the file
+}
+
+// GetFileCoverage returns the symbol and call counts of every scanned
+// file, by path
+func (m *Manager) GetFileCoverage() ([]FileCoverage, error) {
+	rows, err := m.db.Query(`
+		SELECT f.path, f.language, COALESCE(f.source, ''),
+			COALESCE(s.symbols, 0), COALESCE(s.functions, 0), COALESCE(c.calls, 0)
+		FROM file_meta f
+		LEFT JOIN (
+			SELECT file, COUNT(*) AS symbols, SUM(kind IN ('function', 'method')) AS functions
+			FROM symbols
+			GROUP BY file
+		) s ON s.file = f.path
+		LEFT JOIN (
+			SELECT file, COUNT(*) AS calls
+			FROM calls
+			GROUP BY file
+		) c ON c.file = f.path
+		ORDER BY f.path`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var files []FileCoverage
+	for rows.Next() {
+		var f FileCoverage
+		if err := rows.Scan(&f.Path, &f.Language, &f.Source, &f.Symbols, &f.Functions, &f.Calls); err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return files, rows.Err()
+}
@@ -0,0 +1,110 @@
+package db
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// SymbolVector pairs a symbol with its embedding
+type SymbolVector struct {
+	Symbol
+	Vector []float32
+}
+
+// UpsertEmbedding stores the embedding of a symbol
+func (m *Manager) UpsertEmbedding(symbolID, model, textHash string, vector []float32) error {
+	query := `
+		INSERT OR REPLACE INTO embeddings (symbol_id, model, text_hash, vector)
+		VALUES (?, ?, ?, ?)`
+	_, err := m.db.Exec(query, symbolID, model, textHash, encodeVector(vector))
+	return err
+}
+
+// GetEmbeddingHashes returns the text hash of every symbol embedded with model
+func (m *Manager) GetEmbeddingHashes(model string) (map[string]string, error) {
+	rows, err := m.db.Query("SELECT symbol_id, text_hash FROM embeddings WHERE model = ?", model)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hashes := make(map[string]string)
+	for rows.Next() {
+		var id, hash string
+		if err := rows.Scan(&id, &hash); err != nil {
+			return nil, err
+		}
+		hashes[id] = hash
+	}
+	return hashes, rows.Err()
+}
+
+// GetSymbolVectors returns the symbols embedded with model, with their vectors
+func (m *Manager) GetSymbolVectors(model string, opts QueryOptions) ([]SymbolVector, error) {
+	query := `
+		SELECT s.id, s.name, s.kind, s.file, s.line, s.column, s.end_line, s.end_column,
+		       s.scope, s.signature, s.documentation, s.language, s.source, s.created_at, s.is_test, s.package,
+		       e.vector
+		FROM symbols s
+		JOIN embeddings e ON s.id = e.symbol_id
+		WHERE e.model = ?`
+	args := []interface{}{model}
+	query, args = applyQueryOptions(query, args, "s.", opts)
+
+	rows, err := m.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var vectors []SymbolVector
+	for rows.Next() {
+		var v SymbolVector
+		var blob []byte
+		err := rows.Scan(
+			&v.ID, &v.Name, &v.Kind, &v.File, &v.Line, &v.Column,
+			&v.EndLine, &v.EndColumn, &v.Scope, &v.Signature,
+			&v.Documentation, &v.Language, &v.Source, &v.CreatedAt, &v.IsTest, &v.Package,
+			&blob,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if v.Vector, err = decodeVector(blob); err != nil {
+			return nil, fmt.Errorf("embedding of %s: %w", v.ID, err)
+		}
+		vectors = append(vectors, v)
+	}
+	return vectors, rows.Err()
+}
+
+// PruneEmbeddings deletes embeddings of removed symbols and of other models
+func (m *Manager) PruneEmbeddings(model string) (int64, error) {
+	result, err := m.db.Exec(`
+		DELETE FROM embeddings
+		WHERE model != ? OR symbol_id NOT IN (SELECT id FROM symbols)`, model)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func encodeVector(vector []float32) []byte {
+	buf := make([]byte, 4*len(vector))
+	for i, v := range vector {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(v))
+	}
+	return buf
+}
+
+func decodeVector(buf []byte) ([]float32, error) {
+	if len(buf)%4 != 0 {
+		return nil, fmt.Errorf("invalid vector length %d", len(buf))
+	}
+	vector := make([]float32, len(buf)/4)
+	for i := range vector {
+		vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:]))
+	}
+	return vector, nil
+}
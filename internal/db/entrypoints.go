@@ -0,0 +1,61 @@
+package db
+
+// Entrypoint is a symbol the indexer tagged as an entry point
+type Entrypoint struct {
+	Symbol
+	Type string `json:"entrypoint"` // main, http, cli or api
+}
+
+// ClearEntrypoints removes every entry point tag
+func (m *Manager) ClearEntrypoints() error {
+	_, err := m.db.Exec("UPDATE symbols SET entrypoint = NULL WHERE entrypoint IS NOT NULL")
+	return err
+}
+
+// SetEntrypoint tags a symbol as an entry point of the given type
+func (m *Manager) SetEntrypoint(symbolID, entrypointType string) error {
+	_, err := m.db.Exec("UPDATE symbols SET entrypoint = ? WHERE id = ?", entrypointType, symbolID)
+	return err
+}
+
+// ListEntrypoints returns the symbols tagged as entry points of one of
+// types (any type when empty) that match opts, ordered by file by default
+func (m *Manager) ListEntrypoints(types []string, opts QueryOptions) ([]Entrypoint, error) {
+	query := `
+		SELECT id, name, kind, file, line, column, end_line, end_column, scope, signature, documentation, language, source, created_at, is_test, package,
+		       entrypoint
+		FROM symbols
+		WHERE entrypoint IS NOT NULL`
+	var args []interface{}
+	if len(types) > 0 {
+		query += " AND entrypoint IN " + placeholders(len(types))
+		for _, t := range types {
+			args = append(args, t)
+		}
+	}
+	query, args = applyQueryOptions(query, args, "", opts)
+	query, args = orderAndPage(query, args, symbolSortColumns(""), opts, SortFile)
+
+	rows, err := m.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entrypoints []Entrypoint
+	for rows.Next() {
+		var e Entrypoint
+		s := &e.Symbol
+		err := rows.Scan(
+			&s.ID, &s.Name, &s.Kind, &s.File, &s.Line, &s.Column,
+			&s.EndLine, &s.EndColumn, &s.Scope, &s.Signature,
+			&s.Documentation, &s.Language, &s.Source, &s.CreatedAt, &s.IsTest, &s.Package,
+			&e.Type,
+		)
+		if err != nil {
+			return nil, err
+		}
+		entrypoints = append(entrypoints, e)
+	}
+	return entrypoints, rows.Err()
+}
@@ -0,0 +1,81 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// exportQueries select the rows of each table ExportTable writes. Edges
+// carry the names of both ends so they read without a join.
+var exportQueries = map[string]string{
+	"symbols": `
+		SELECT id, name, kind, file, line, column, end_line, end_column,
+		       scope, signature, language, source, package, is_test
+		FROM symbols
+		ORDER BY file, line, column, id`,
+	"calls": `
+		SELECT c.caller_id, s.name, c.callee_id, e.name, c.file, c.line, c.column, c.arg_count, c.args
+		FROM calls c
+		LEFT JOIN symbols s ON s.id = c.caller_id
+		LEFT JOIN symbols e ON e.id = c.callee_id
+		ORDER BY c.file, c.line, c.column, c.callee_id`,
+	"hierarchy": `
+		SELECT h.child_id, c.name, h.parent_id, p.name, h.relationship, h.type_args
+		FROM type_hierarchy h
+		LEFT JOIN symbols c ON c.id = h.child_id
+		LEFT JOIN symbols p ON p.id = h.parent_id
+		ORDER BY h.child_id, h.parent_id, h.relationship`,
+}
+
+// exportColumns name the columns of exportQueries
+var exportColumns = map[string][]string{
+	"symbols": {"id", "name", "kind", "file", "line", "column", "end_line", "end_column",
+		"scope", "signature", "language", "source", "package", "is_test"},
+	"calls":     {"caller_id", "caller", "callee_id", "callee", "file", "line", "column", "arg_count", "args"},
+	"hierarchy": {"child_id", "child", "parent_id", "parent", "relationship", "type_args"},
+}
+
+// ExportTables are the tables ExportTable can write
+var ExportTables = []string{"symbols", "calls", "hierarchy"}
+
+// ExportTable calls fn with the column names of table, one of
+// ExportTables, and then with each of its rows as text, NULL as "". It
+// returns the number of rows.
+func (m *Manager) ExportTable(table string, fn func(row []string) error) (int, error) {
+	query, ok := exportQueries[table]
+	if !ok {
+		return 0, fmt.Errorf("unknown table %q (expected %s)", table, strings.Join(ExportTables, ", "))
+	}
+	columns := exportColumns[table]
+	if err := fn(columns); err != nil {
+		return 0, err
+	}
+
+	rows, err := m.query(query)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	values := make([]sql.NullString, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	row := make([]string, len(columns))
+	count := 0
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return count, err
+		}
+		for i, v := range values {
+			row[i] = v.String
+		}
+		if err := fn(row); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, rows.Err()
+}
@@ -0,0 +1,185 @@
+package db
+
+import "fmt"
+
+// ExternalSymbol is a declaration of one of the project's dependencies
+type ExternalSymbol struct {
+	ID            string `json:"id"` // module/path#Name, e.g. github.com/spf13/cobra@v1.8.0/command.go#Command
+	Name          string `json:"name"`
+	Kind          string `json:"kind"`
+	File          string `json:"file"` // Absolute path in the module cache, node_modules or site-packages
+	Line          int    `json:"line"`
+	Signature     string `json:"signature"`
+	Documentation string `json:"documentation"`
+	Language      string `json:"language"`
+	Package       string `json:"package"`
+	Module        string `json:"module"` // Dependency, with its version when known
+}
+
+// externalCallSortColumns orders external calls like the call sites of
+// callees, by the name called in place of the callee's
+var externalCallSortColumns = sortColumns{
+	Name:   "c.callee_name",
+	File:   "c.file",
+	Line:   "c.line",
+	Column: "c.column",
+}
+
+// InsertExternalCall records a call whose callee is not indexed
+func (m *Manager) InsertExternalCall(c *ExternalCall) error {
+	_, err := m.exec(`
+		INSERT INTO external_calls (caller_id, callee_name, package, file, line, column, arg_count, args, context, resolved)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		c.CallerID, c.CalleeName, c.Package, c.File, c.Line, c.Column, c.ArgCount, c.Args, c.Context, c.Resolved,
+	)
+	return err
+}
+
+// EachExternalCall streams the external calls made by the symbols callees
+// match to fn, ordered and paged like callees. Languages narrow the
+// callers; the callee kinds and tags of opts match no external call, so
+// none is returned when they are set.
+func (m *Manager) EachExternalCall(symbolName string, opts QueryOptions, fn func(ExternalCall) error) error {
+	cond, args := calleesCond(symbolName)
+	return m.eachExternalCall(cond, args, opts, fn)
+}
+
+// CountExternalCalls returns how many calls EachExternalCall would return
+func (m *Manager) CountExternalCalls(symbolName string, opts QueryOptions) (int, error) {
+	cond, args := calleesCond(symbolName)
+	query, args := externalCallsQuery(cond, args, opts)
+	return m.countRows(query, args)
+}
+
+// GetExternalCallsByID returns the external calls of one specific symbol
+func (m *Manager) GetExternalCallsByID(symbolID string, opts QueryOptions) ([]ExternalCall, error) {
+	var calls []ExternalCall
+	err := m.eachExternalCall("caller.id = ?", []interface{}{symbolID}, opts, func(c ExternalCall) error {
+		calls = append(calls, c)
+		return nil
+	})
+	return calls, err
+}
+
+func externalCallsQuery(cond string, args []interface{}, opts QueryOptions) (string, []interface{}) {
+	query := `
+		SELECT c.id, c.caller_id, c.callee_name, c.package, c.file, c.line, c.column,
+		       c.arg_count, COALESCE(c.args, ''), COALESCE(c.context, ''), c.resolved,
+		       COALESCE(c.external_id, ''), COALESCE(x.signature, ''), COALESCE(x.module, '')
+		FROM external_calls c
+		JOIN symbols caller ON c.caller_id = caller.id
+		LEFT JOIN external_symbols x ON x.id = c.external_id
+		WHERE ` + cond
+
+	if len(opts.Kinds) > 0 || len(opts.Tags) > 0 {
+		query += " AND 0"
+	}
+	if len(opts.Languages) > 0 {
+		in, list := inList(opts.Languages)
+		query += " AND caller.language IN " + in
+		args = append(args, list)
+	}
+	query, args = applyCallSiteOptions(query, args, opts)
+	return orderAndPage(query, args, externalCallSortColumns, opts, SortFile)
+}
+
+func (m *Manager) eachExternalCall(cond string, args []interface{}, opts QueryOptions, fn func(ExternalCall) error) error {
+	query, args := externalCallsQuery(cond, args, opts)
+	rows, err := m.query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c ExternalCall
+		err := rows.Scan(&c.ID, &c.CallerID, &c.CalleeName, &c.Package, &c.File, &c.Line, &c.Column,
+			&c.ArgCount, &c.Args, &c.Context, &c.Resolved,
+			&c.ExternalID, &c.Signature, &c.Module)
+		if err != nil {
+			return err
+		}
+		if err := fn(c); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ListExternalModules returns the dependencies with indexed symbols
+func (m *Manager) ListExternalModules() ([]string, error) {
+	rows, err := m.query("SELECT DISTINCT module FROM external_symbols ORDER BY module")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var modules []string
+	for rows.Next() {
+		var module string
+		if err := rows.Scan(&module); err != nil {
+			return nil, err
+		}
+		modules = append(modules, module)
+	}
+	return modules, rows.Err()
+}
+
+// ClearExternalModules deletes the symbols of dependencies the project no
+// longer uses, or uses at another version
+func (m *Manager) ClearExternalModules(modules []string) error {
+	if len(modules) == 0 {
+		return nil
+	}
+	in, list := inList(modules)
+	if _, err := m.exec("DELETE FROM external_symbols WHERE module IN "+in, list); err != nil {
+		return fmt.Errorf("failed to clear dependency symbols: %w", err)
+	}
+	_, err := m.exec(`
+		UPDATE external_calls SET resolved = 0, external_id = NULL
+		WHERE external_id IS NOT NULL AND external_id NOT IN (SELECT id FROM external_symbols)`)
+	return err
+}
+
+// InsertExternalSymbols stores the symbols of a dependency in one
+// transaction
+func (m *Manager) InsertExternalSymbols(symbols []*ExternalSymbol) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, s := range symbols {
+		err := m.txExec(tx, `
+			INSERT OR IGNORE INTO external_symbols (id, name, kind, file, line, signature, documentation, language, package, module)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			s.ID, s.Name, s.Kind, s.File, s.Line, s.Signature, s.Documentation, s.Language, s.Package, s.Module)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ResolveExternalCalls links the unresolved external calls to a dependency
+// symbol of the name called, in the caller's language (TypeScript type
+// definitions serve JavaScript too) and the package the call is qualified
+// with, and returns how many were linked
+func (m *Manager) ResolveExternalCalls() (int64, error) {
+	target := `
+		SELECT x.id FROM external_symbols x, symbols caller
+		WHERE caller.id = external_calls.caller_id
+		  AND x.name = external_calls.callee_name
+		  AND (external_calls.package = '' OR x.package = external_calls.package)
+		  AND (x.language = caller.language
+		       OR (x.language = 'typescript' AND caller.language IN ('typescriptreact', 'javascript')))
+		ORDER BY x.module, x.id
+		LIMIT 1`
+	result, err := m.exec(`
+		UPDATE external_calls SET resolved = 1, external_id = (` + target + `)
+		WHERE resolved = 0 AND EXISTS (` + target + `)`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve external calls: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// SymbolHistory is what git says about the lines of a symbol: who
+// introduced it, who changed it last and how often it changed (its churn).
+// It holds for the commit Head and the lines StartLine to EndLine.
+type SymbolHistory struct {
+	SymbolID         string    `json:"symbol_id"`
+	File             string    `json:"file"`
+	StartLine        int       `json:"start_line"`
+	EndLine          int       `json:"end_line"`
+	Head             string    `json:"head"`
+	IntroducedCommit string    `json:"introduced_commit"`
+	IntroducedAuthor string    `json:"introduced_author"`
+	IntroducedAt     time.Time `json:"introduced_at"`
+	ModifiedCommit   string    `json:"modified_commit"`
+	ModifiedAuthor   string    `json:"modified_author"`
+	ModifiedAt       time.Time `json:"modified_at"`
+	Commits          int       `json:"commits"`
+	Authors          []string  `json:"authors"` // Most commits first
+}
+
+// GetSymbolHistory returns the stored history of a symbol, or nil when
+// none is stored
+func (m *Manager) GetSymbolHistory(symbolID string) (*SymbolHistory, error) {
+	var h SymbolHistory
+	var authors string
+	err := m.queryRow(`
+		SELECT symbol_id, file, start_line, end_line, head,
+		       introduced_commit, introduced_author, introduced_at,
+		       modified_commit, modified_author, modified_at, commits, authors
+		FROM symbol_history
+		WHERE symbol_id = ?`, symbolID,
+	).Scan(&h.SymbolID, &h.File, &h.StartLine, &h.EndLine, &h.Head,
+		&h.IntroducedCommit, &h.IntroducedAuthor, &h.IntroducedAt,
+		&h.ModifiedCommit, &h.ModifiedAuthor, &h.ModifiedAt, &h.Commits, &authors)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if authors != "" {
+		h.Authors = strings.Split(authors, "\n")
+	}
+	return &h, nil
+}
+
+// SaveSymbolHistory stores the history of a symbol, replacing an older one
+func (m *Manager) SaveSymbolHistory(h *SymbolHistory) error {
+	_, err := m.exec(`
+		INSERT OR REPLACE INTO symbol_history
+		(symbol_id, file, start_line, end_line, head, introduced_commit, introduced_author, introduced_at,
+		 modified_commit, modified_author, modified_at, commits, authors)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		h.SymbolID, h.File, h.StartLine, h.EndLine, h.Head, h.IntroducedCommit, h.IntroducedAuthor, h.IntroducedAt,
+		h.ModifiedCommit, h.ModifiedAuthor, h.ModifiedAt, h.Commits, strings.Join(h.Authors, "\n"),
+	)
+	return err
+}
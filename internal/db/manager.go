@@ -2,29 +2,61 @@ package db
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
+	"unicode/utf8"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 )
 
 // Manager handles database operations
 type Manager struct {
 	db     *sql.DB
 	dbPath string
+	stmts  stmtCache // Prepared statements of the frequent queries
+	// clearOutdated lets migrate clear an index built with an older
+	// SchemaVersion (NewBuildManager)
+	clearOutdated bool
 }
 
-// NewManager creates a new database manager
+// ErrOutdatedIndex is returned when opening an index built with an older
+// SchemaVersion, whose rows the current queries cannot read. Only a build
+// clears it; see NewBuildManager.
+var ErrOutdatedIndex = errors.New("index built by an older codegraph; run 'codegraph build'")
+
+// busyTimeoutMillis is how long a statement waits for another connection's
+// write to finish before failing with "database is locked". A build writes
+// through a Writer and the connection pool at once, and queries may run
+// while watch or the daemon writes, so this is set explicitly rather than
+// left to the driver's default.
+const busyTimeoutMillis = 30000
+
+// NewManager creates a new database manager. An index built with an older
+// SchemaVersion is refused with ErrOutdatedIndex rather than changed.
 func NewManager(dbPath string) (*Manager, error) {
+	return open(dbPath, false)
+}
+
+// NewBuildManager opens the database for a command that re-creates its
+// index, clearing an index built with an older SchemaVersion instead of
+// refusing it
+func NewBuildManager(dbPath string) (*Manager, error) {
+	return open(dbPath, true)
+}
+
+func open(dbPath string, clearOutdated bool) (*Manager, error) {
 	// Ensure directory exists
 	dir := filepath.Dir(dbPath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create database directory: %w", err)
 	}
 
-	db, err := sql.Open("sqlite3", dbPath)
+	db, err := sql.Open(driverName, fmt.Sprintf("%s?_busy_timeout=%d", dbPath, busyTimeoutMillis))
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -35,6 +67,40 @@ func NewManager(dbPath string) (*Manager, error) {
 		return nil, fmt.Errorf("failed to enable foreign keys: %w", err)
 	}
 
+	m := &Manager{db: db, dbPath: dbPath, clearOutdated: clearOutdated}
+	// Upgrade existing databases so queries see the current columns
+	if err := m.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return m, nil
+}
+
+// NewReadOnlyManager opens an existing database without writing to it. The
+// index must have been built with the current SchemaVersion, since an
+// older one cannot be migrated in place.
+func NewReadOnlyManager(dbPath string) (*Manager, error) {
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	db, err := sql.Open(driverName, fmt.Sprintf("file:%s?mode=ro&_busy_timeout=%d", dbPath, busyTimeoutMillis))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if version < SchemaVersion {
+		db.Close()
+		return nil, fmt.Errorf("read-only index has schema version %d, this codegraph needs %d: %w", version, SchemaVersion, ErrOutdatedIndex)
+	}
+	if version != SchemaVersion {
+		db.Close()
+		return nil, fmt.Errorf("read-only index has schema version %d, this codegraph needs %d; rebuild it with a matching version", version, SchemaVersion)
+	}
 	return &Manager{db: db, dbPath: dbPath}, nil
 }
 
@@ -45,18 +111,101 @@ func (m *Manager) Initialize() error {
 			return fmt.Errorf("failed to execute schema statement: %w", err)
 		}
 	}
+	return m.migrate()
+}
+
+// migrate adds the ColumnMigrations missing from existing tables and
+// records the SchemaVersion of a new database. An index built with an
+// older SchemaVersion is refused with ErrOutdatedIndex, or cleared when the
+// Manager came from NewBuildManager. Tables that do not exist yet are left
+// to Initialize.
+func (m *Manager) migrate() error {
+	for _, mig := range ColumnMigrations {
+		columns, err := m.tableColumns(mig.Table)
+		if err != nil {
+			return fmt.Errorf("failed to inspect %s: %w", mig.Table, err)
+		}
+		if len(columns) == 0 || columns[mig.Column] {
+			continue
+		}
+		stmt := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", mig.Table, mig.Column, mig.Definition)
+		if _, err := m.db.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to add %s.%s: %w", mig.Table, mig.Column, err)
+		}
+	}
+
+	var version int
+	if err := m.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if version >= SchemaVersion {
+		return nil
+	}
+	// Symbol IDs from older versions can collide and no longer match the
+	// IDs the indexers build, so only a rebuild can bring the rows back
+	for _, table := range IndexTables {
+		columns, err := m.tableColumns(table)
+		if err != nil {
+			return fmt.Errorf("failed to inspect %s: %w", table, err)
+		}
+		if len(columns) == 0 {
+			continue
+		}
+		if !m.clearOutdated {
+			var rows bool
+			if err := m.db.QueryRow(fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s)", table)).Scan(&rows); err != nil {
+				return fmt.Errorf("failed to inspect %s: %w", table, err)
+			}
+			if rows {
+				return fmt.Errorf("%s has schema version %d, this codegraph needs %d: %w", m.dbPath, version, SchemaVersion, ErrOutdatedIndex)
+			}
+			continue
+		}
+		if _, err := m.db.Exec(fmt.Sprintf("DELETE FROM %s", table)); err != nil {
+			return fmt.Errorf("failed to clear %s: %w", table, err)
+		}
+	}
+	if _, err := m.db.Exec(fmt.Sprintf("PRAGMA user_version = %d", SchemaVersion)); err != nil {
+		return fmt.Errorf("failed to record schema version: %w", err)
+	}
 	return nil
 }
 
+// tableColumns returns the set of column names of table, empty when the
+// table does not exist
+func (m *Manager) tableColumns(table string) (map[string]bool, error) {
+	rows, err := m.db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var (
+			cid        int
+			name, typ  string
+			notNull    int
+			defaultVal sql.NullString
+			pk         int
+		)
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &defaultVal, &pk); err != nil {
+			return nil, err
+		}
+		columns[name] = true
+	}
+	return columns, rows.Err()
+}
+
 // Close closes the database connection
 func (m *Manager) Close() error {
+	m.closeStatements()
 	return m.db.Close()
 }
 
 // ClearAll deletes all data (for full rebuild)
 func (m *Manager) ClearAll() error {
-	tables := []string{"calls", "type_hierarchy", "symbols", "file_meta"}
-	for _, table := range tables {
+	for _, table := range IndexTables {
 		if _, err := m.db.Exec(fmt.Sprintf("DELETE FROM %s", table)); err != nil {
 			return fmt.Errorf("failed to clear %s: %w", table, err)
 		}
@@ -75,9 +224,115 @@ func (m *Manager) ClearCalls(language string) error {
 	if _, err := m.db.Exec(query, language); err != nil {
 		return fmt.Errorf("failed to clear calls for %s: %w", language, err)
 	}
+	query = `
+		DELETE FROM external_calls
+		WHERE caller_id IN (
+			SELECT id FROM symbols WHERE language = ?
+		)`
+	if _, err := m.db.Exec(query, language); err != nil {
+		return fmt.Errorf("failed to clear external calls for %s: %w", language, err)
+	}
 	return nil
 }
 
+// ClearCallsFrom deletes the calls made from files, by call site or by
+// caller, before their calls are re-extracted
+func (m *Manager) ClearCallsFrom(files []string) error {
+	if len(files) == 0 {
+		return nil
+	}
+	in := placeholders(len(files))
+	query := `
+		DELETE FROM calls
+		WHERE file IN ` + in + `
+		   OR caller_id IN (SELECT id FROM symbols WHERE file IN ` + in + `)`
+
+	if _, err := m.db.Exec(query, repeatArgs(files, 2)...); err != nil {
+		return fmt.Errorf("failed to clear calls from changed files: %w", err)
+	}
+	query = `
+		DELETE FROM external_calls
+		WHERE file IN ` + in + `
+		   OR caller_id IN (SELECT id FROM symbols WHERE file IN ` + in + `)`
+	if _, err := m.db.Exec(query, repeatArgs(files, 2)...); err != nil {
+		return fmt.Errorf("failed to clear external calls from changed files: %w", err)
+	}
+	return nil
+}
+
+// ClearCallsTo deletes the calls to symbols declared in files, before those
+// calls are re-resolved
+func (m *Manager) ClearCallsTo(files []string) error {
+	if len(files) == 0 {
+		return nil
+	}
+	query := `
+		DELETE FROM calls
+		WHERE callee_id IN (SELECT id FROM symbols WHERE file IN ` + placeholders(len(files)) + `)`
+
+	if _, err := m.db.Exec(query, repeatArgs(files, 1)...); err != nil {
+		return fmt.Errorf("failed to clear calls to changed files: %w", err)
+	}
+	return nil
+}
+
+// GetCallingFiles returns the call site files of the calls to symbols
+// declared in files, and of the unresolved external calls by the name of
+// one, which may resolve now, other than files themselves
+func (m *Manager) GetCallingFiles(files []string) ([]string, error) {
+	if len(files) == 0 {
+		return nil, nil
+	}
+	in := placeholders(len(files))
+	query := `
+		SELECT DISTINCT c.file
+		FROM calls c
+		JOIN symbols s ON s.id = c.callee_id
+		WHERE s.file IN ` + in + ` AND c.file NOT IN ` + in + `
+		UNION
+		SELECT DISTINCT e.file
+		FROM external_calls e
+		JOIN symbols s ON s.name = e.callee_name
+		WHERE s.file IN ` + in + ` AND e.file NOT IN ` + in + ` AND e.resolved = 0
+		ORDER BY 1`
+
+	rows, err := m.db.Query(query, repeatArgs(files, 4)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var callers []string
+	for rows.Next() {
+		var f string
+		if err := rows.Scan(&f); err != nil {
+			return nil, err
+		}
+		callers = append(callers, f)
+	}
+	return callers, rows.Err()
+}
+
+// CountCallsByFile returns the number of call edges made from each file
+func (m *Manager) CountCallsByFile() (map[string]int, error) {
+	rows, err := m.db.Query(`SELECT file, COUNT(*) FROM calls GROUP BY file`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var f string
+		var n int
+		if err := rows.Scan(&f, &n); err != nil {
+			return nil, err
+		}
+		counts[f] = n
+	}
+	return counts, rows.Err()
+}
+
 // ClearTypeHierarchy deletes all type hierarchy for a specific language
 func (m *Manager) ClearTypeHierarchy(language string) error {
 	query := `
@@ -92,46 +347,237 @@ func (m *Manager) ClearTypeHierarchy(language string) error {
 	return nil
 }
 
-// InsertSymbol inserts a symbol into the database
-func (m *Manager) InsertSymbol(s *Symbol) error {
-	_, err := m.db.Exec(`
+// ClearLinksTo deletes the calls to and implementations of the symbols of
+// a language, which other languages link to after their own indexing (gRPC
+// clients and servers to .proto RPCs)
+func (m *Manager) ClearLinksTo(language string) error {
+	queries := []string{
+		`DELETE FROM calls WHERE callee_id IN (SELECT id FROM symbols WHERE language = ?)`,
+		`DELETE FROM type_hierarchy WHERE parent_id IN (SELECT id FROM symbols WHERE language = ?)`,
+	}
+	for _, query := range queries {
+		if _, err := m.db.Exec(query, language); err != nil {
+			return fmt.Errorf("failed to clear links to %s: %w", language, err)
+		}
+	}
+	return nil
+}
+
+// Statements shared by the Manager methods and Writer
+const (
+	insertSymbolSQL = `
 		INSERT OR REPLACE INTO symbols 
-		(id, name, kind, file, line, column, end_line, end_column, scope, signature, documentation, language, source, created_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		(id, name, kind, file, line, column, end_line, end_column, scope, signature, documentation, language, source, created_at, is_test, package, body_hash)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	insertContainmentSQL = `
+		INSERT OR REPLACE INTO contains (child_id, parent_id)
+		VALUES (?, ?)`
+	clearFileContainmentSQL = `
+		DELETE FROM contains
+		WHERE child_id IN (
+			SELECT id FROM symbols WHERE file = ?
+		)`
+	updateFileMetaSQL = `
+		INSERT OR REPLACE INTO file_meta (path, mod_time, language, source)
+		VALUES (?, ?, ?, ?)`
+)
+
+// symbolArgs are the parameters of insertSymbolSQL
+func symbolArgs(s *Symbol) []interface{} {
+	return []interface{}{
 		s.ID, s.Name, s.Kind, s.File, s.Line, s.Column, s.EndLine, s.EndColumn,
-		s.Scope, s.Signature, s.Documentation, s.Language, s.Source, s.CreatedAt,
-	)
+		s.Scope, s.Signature, s.Documentation, s.Language, s.Source, s.CreatedAt, s.IsTest,
+		s.Package, s.BodyHash,
+	}
+}
+
+// InsertSymbol inserts a symbol into the database
+func (m *Manager) InsertSymbol(s *Symbol) error {
+	_, err := m.exec(insertSymbolSQL, symbolArgs(s)...)
 	return err
 }
 
 // InsertCall inserts a call relationship
 func (m *Manager) InsertCall(c *Call) error {
-	_, err := m.db.Exec(`
-		INSERT INTO calls (caller_id, callee_id, file, line, column)
-		VALUES (?, ?, ?, ?, ?)`,
-		c.CallerID, c.CalleeID, c.File, c.Line, c.Column,
+	_, err := m.exec(`
+		INSERT INTO calls (caller_id, callee_id, file, line, column, arg_count, args, context)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		c.CallerID, c.CalleeID, c.File, c.Line, c.Column, c.ArgCount, c.Args, c.Context,
 	)
 	return err
 }
 
 // InsertTypeHierarchy inserts a type relationship
 func (m *Manager) InsertTypeHierarchy(th *TypeHierarchy) error {
-	_, err := m.db.Exec(`
-		INSERT INTO type_hierarchy (child_id, parent_id, relationship)
-		VALUES (?, ?, ?)`,
-		th.ChildID, th.ParentID, th.Relationship,
+	_, err := m.exec(`
+		INSERT INTO type_hierarchy (child_id, parent_id, relationship, type_args, source)
+		VALUES (?, ?, ?, ?, ?)`,
+		th.ChildID, th.ParentID, th.Relationship, nullIfEmpty(th.TypeArgs), nullIfEmpty(th.Source),
 	)
 	return err
 }
 
+// CacheImplementations records childIDs as the implementations of parentID
+// a language server found, replacing the ones cached for it before.
+// Children already linked to parentID by build keep their extracted
+// relation.
+func (m *Manager) CacheImplementations(parentID string, childIDs []string) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("DELETE FROM type_hierarchy WHERE parent_id = ? AND source = ?", parentID, HierarchySourceLSP); err != nil {
+		return fmt.Errorf("failed to clear cached implementations: %w", err)
+	}
+	for _, childID := range childIDs {
+		_, err := tx.Exec(`
+			INSERT INTO type_hierarchy (child_id, parent_id, relationship, source)
+			SELECT ?, ?, 'implements', ?
+			WHERE NOT EXISTS (SELECT 1 FROM type_hierarchy WHERE child_id = ? AND parent_id = ?)`,
+			childID, parentID, HierarchySourceLSP, childID, parentID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to cache implementation: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// ClearFileContainment deletes the containment rows of a file's symbols, before
+// the file is re-indexed
+func (m *Manager) ClearFileContainment(file string) error {
+	if _, err := m.exec(clearFileContainmentSQL, file); err != nil {
+		return fmt.Errorf("failed to clear containment for %s: %w", file, err)
+	}
+	return nil
+}
+
+// InsertContainment records that c.ChildID is declared inside c.ParentID
+func (m *Manager) InsertContainment(c *Containment) error {
+	_, err := m.exec(insertContainmentSQL, c.ChildID, c.ParentID)
+	return err
+}
+
+// GetChildren returns the symbols declared directly inside parentID, in
+// source order unless opts.Sort says otherwise
+func (m *Manager) GetChildren(parentID string, opts QueryOptions) ([]Symbol, error) {
+	query := `
+		SELECT s.id, s.name, s.kind, s.file, s.line, s.column, s.end_line, s.end_column,
+			   s.scope, s.signature, s.documentation, s.language, s.source, s.created_at, s.is_test, s.package
+		FROM symbols s
+		JOIN contains c ON s.id = c.child_id
+		WHERE c.parent_id = ?`
+	args := []interface{}{parentID}
+	query, args = applyQueryOptions(query, args, "s.", opts)
+	query, args = orderAndPage(query, args, symbolSortColumns("s."), opts, SortLine)
+
+	rows, err := m.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanSymbols(rows)
+}
+
+// GetFileContainment maps each contained symbol of a file to its parent ID
+func (m *Manager) GetFileContainment(file string) (map[string]string, error) {
+	rows, err := m.db.Query(`
+		SELECT c.child_id, c.parent_id
+		FROM contains c
+		JOIN symbols s ON s.id = c.child_id
+		WHERE s.file = ?`, file)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	parents := make(map[string]string)
+	for rows.Next() {
+		var child, parent string
+		if err := rows.Scan(&child, &parent); err != nil {
+			return nil, err
+		}
+		parents[child] = parent
+	}
+	return parents, rows.Err()
+}
+
+// ListCalls returns every call edge, ordered by call site
+func (m *Manager) ListCalls() ([]Call, error) {
+	rows, err := m.db.Query(`
+		SELECT id, caller_id, callee_id, file, line, column, arg_count, COALESCE(args, ''), COALESCE(context, '')
+		FROM calls
+		ORDER BY file, line, column`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var calls []Call
+	for rows.Next() {
+		var c Call
+		if err := rows.Scan(&c.ID, &c.CallerID, &c.CalleeID, &c.File, &c.Line, &c.Column, &c.ArgCount, &c.Args, &c.Context); err != nil {
+			return nil, err
+		}
+		calls = append(calls, c)
+	}
+	return calls, rows.Err()
+}
+
+// ListTypeHierarchy returns every extends/implements relationship
+func (m *Manager) ListTypeHierarchy() ([]TypeHierarchy, error) {
+	rows, err := m.db.Query(`
+		SELECT id, child_id, parent_id, relationship, COALESCE(type_args, '')
+		FROM type_hierarchy
+		ORDER BY child_id, parent_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rels []TypeHierarchy
+	for rows.Next() {
+		var th TypeHierarchy
+		if err := rows.Scan(&th.ID, &th.ChildID, &th.ParentID, &th.Relationship, &th.TypeArgs); err != nil {
+			return nil, err
+		}
+		rels = append(rels, th)
+	}
+	return rels, rows.Err()
+}
+
+// ListContainment returns every containment link
+func (m *Manager) ListContainment() ([]Containment, error) {
+	rows, err := m.db.Query(`
+		SELECT child_id, parent_id
+		FROM contains
+		ORDER BY parent_id, child_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var links []Containment
+	for rows.Next() {
+		var c Containment
+		if err := rows.Scan(&c.ChildID, &c.ParentID); err != nil {
+			return nil, err
+		}
+		links = append(links, c)
+	}
+	return links, rows.Err()
+}
+
 // GetImplementations returns symbols that implement/extend the given parent symbol
 func (m *Manager) GetImplementations(parentID string) ([]Symbol, error) {
 	query := `
 		SELECT s.id, s.name, s.kind, s.file, s.line, s.column, s.end_line, s.end_column, 
-			   s.scope, s.signature, s.documentation, s.language, s.source, s.created_at
+			   s.scope, s.signature, s.documentation, s.language, s.source, s.created_at, s.is_test, s.package
 		FROM symbols s
 		INNER JOIN type_hierarchy th ON s.id = th.child_id
-		WHERE th.parent_id = ?
+		WHERE th.parent_id = ? AND th.relationship != 'includes'
 		ORDER BY s.file, s.line`
 
 	rows, err := m.db.Query(query, parentID)
@@ -143,18 +589,21 @@ func (m *Manager) GetImplementations(parentID string) ([]Symbol, error) {
 	return scanSymbols(rows)
 }
 
-// GetImplementationsByName returns symbols that implement/extend a type by its name
-func (m *Manager) GetImplementationsByName(typeName string) ([]Symbol, error) {
+// GetImplementationsByName returns symbols that implement/extend a type by its name.
+// Unions including the type (type Shape = Circle | Square) do not implement it.
+func (m *Manager) GetImplementationsByName(typeName string, opts QueryOptions) ([]Symbol, error) {
 	query := `
 		SELECT s.id, s.name, s.kind, s.file, s.line, s.column, s.end_line, s.end_column, 
-			   s.scope, s.signature, s.documentation, s.language, s.source, s.created_at
+			   s.scope, s.signature, s.documentation, s.language, s.source, s.created_at, s.is_test, s.package
 		FROM symbols s
 		INNER JOIN type_hierarchy th ON s.id = th.child_id
 		INNER JOIN symbols parent ON th.parent_id = parent.id
-		WHERE parent.name = ?
-		ORDER BY s.file, s.line`
+		WHERE parent.name = ? AND th.relationship != 'includes'`
+	args := []interface{}{typeName}
+	query, args = applyQueryOptions(query, args, "s.", opts)
+	query, args = orderAndPage(query, args, symbolSortColumns("s."), opts, SortFile)
 
-	rows, err := m.db.Query(query, typeName)
+	rows, err := m.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -163,27 +612,81 @@ func (m *Manager) GetImplementationsByName(typeName string) ([]Symbol, error) {
 	return scanSymbols(rows)
 }
 
+// GetImplementationTypeArgs returns the type arguments the implementations
+// of a type by its name instantiate it with (User in extends Base<User>),
+// by implementing symbol ID. Implementations without any are left out.
+func (m *Manager) GetImplementationTypeArgs(typeName string) (map[string]string, error) {
+	rows, err := m.query(`
+		SELECT th.child_id, th.type_args
+		FROM type_hierarchy th
+		INNER JOIN symbols parent ON th.parent_id = parent.id
+		WHERE parent.name = ? AND th.type_args IS NOT NULL`, typeName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	args := make(map[string]string)
+	for rows.Next() {
+		var childID, typeArgs string
+		if err := rows.Scan(&childID, &typeArgs); err != nil {
+			return nil, err
+		}
+		args[childID] = typeArgs
+	}
+	return args, rows.Err()
+}
+
 // SearchSymbols searches for symbols by name with optional filters
-func (m *Manager) SearchSymbols(name string, kind string, languages []string) ([]Symbol, error) {
-	query := "SELECT id, name, kind, file, line, column, end_line, end_column, scope, signature, documentation, language, source, created_at FROM symbols WHERE name LIKE ?"
+func (m *Manager) SearchSymbols(name string, opts QueryOptions) ([]Symbol, error) {
+	query := "SELECT id, name, kind, file, line, column, end_line, end_column, scope, signature, documentation, language, source, created_at, is_test, package FROM symbols WHERE name LIKE ?"
 	args := []interface{}{"%" + name + "%"}
 
-	if kind != "" {
-		query += " AND kind = ?"
-		args = append(args, kind)
-	} else {
+	if len(opts.Kinds) == 0 {
 		// By default, exclude module/package declarations from search results
 		query += " AND kind != 'module'"
 	}
+	query, args = applyQueryOptions(query, args, "", opts)
 
-	if len(languages) > 0 {
-		query += " AND language IN (?" + repeatString(",?", len(languages)-1) + ")"
-		for _, lang := range languages {
-			args = append(args, lang)
-		}
+	// Score: exact name, then prefix, then substring matches
+	cols := symbolSortColumns("")
+	cols.Score = "CASE WHEN name = ? THEN 0 WHEN name LIKE ? THEN 1 ELSE 2 END"
+	cols.ScoreArgs = []interface{}{name, name + "%"}
+	query, args = orderAndPage(query, args, cols, opts, SortName)
+
+	rows, err := m.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanSymbols(rows)
+}
+
+// SearchSymbolsRegex finds symbols whose name matches a Go regular expression
+func (m *Manager) SearchSymbolsRegex(pattern string, opts QueryOptions) ([]Symbol, error) {
+	return m.searchSymbolsWhere("name REGEXP ?", pattern, opts)
+}
+
+// SearchSymbolsGlob finds symbols whose whole name matches a shell glob
+// (*, ?, [...]); matching is case-sensitive like SQLite GLOB
+func (m *Manager) SearchSymbolsGlob(pattern string, opts QueryOptions) ([]Symbol, error) {
+	return m.searchSymbolsWhere("name GLOB ?", pattern, opts)
+}
+
+func (m *Manager) searchSymbolsWhere(cond string, pattern string, opts QueryOptions) ([]Symbol, error) {
+	query := "SELECT id, name, kind, file, line, column, end_line, end_column, scope, signature, documentation, language, source, created_at, is_test, package FROM symbols WHERE " + cond
+	args := []interface{}{pattern}
+
+	if len(opts.Kinds) == 0 {
+		query += " AND kind != 'module'"
 	}
+	query, args = applyQueryOptions(query, args, "", opts)
 
-	query += " ORDER BY name, file, line"
+	// Score: shorter names leave less unmatched around the pattern
+	cols := symbolSortColumns("")
+	cols.Score = "length(name)"
+	query, args = orderAndPage(query, args, cols, opts, SortName)
 
 	rows, err := m.db.Query(query, args...)
 	if err != nil {
@@ -194,118 +697,422 @@ func (m *Manager) SearchSymbols(name string, kind string, languages []string) ([
 	return scanSymbols(rows)
 }
 
+// callSiteSortColumns sorts call graph queries by the call site location
+var callSiteSortColumns = sortColumns{
+	Name:   "s.name",
+	File:   "c.file",
+	Line:   "c.line",
+	Column: "c.column",
+	Churn:  churnColumn("s.id"),
+}
+
 // GetCallers finds all callers of a symbol with call site info
-func (m *Manager) GetCallers(symbolName string, languages []string) ([]CallerInfo, error) {
+func (m *Manager) GetCallers(symbolName string, opts QueryOptions) ([]CallerInfo, error) {
+	var callers []CallerInfo
+	err := m.EachCaller(symbolName, opts, func(c CallerInfo) error {
+		callers = append(callers, c)
+		return nil
+	})
+	return callers, err
+}
+
+// GetCallersByID finds the callers of one specific symbol
+func (m *Manager) GetCallersByID(symbolID string, opts QueryOptions) ([]CallerInfo, error) {
+	var callers []CallerInfo
+	err := m.eachCaller("c.callee_id = ?", []interface{}{symbolID}, opts, func(c CallerInfo) error {
+		callers = append(callers, c)
+		return nil
+	})
+	return callers, err
+}
+
+// EachCaller streams the callers GetCallers finds to fn one row at a time,
+// in the same order, so a popular symbol's call sites are never all held
+// in memory. The next row is read only once fn returns; an error from fn
+// stops the query and is returned.
+func (m *Manager) EachCaller(symbolName string, opts QueryOptions, fn func(CallerInfo) error) error {
+	cond, args := callersCond(symbolName)
+	return m.eachCaller(cond, args, opts, fn)
+}
+
+// CountCallers returns how many call sites GetCallers would return
+func (m *Manager) CountCallers(symbolName string, opts QueryOptions) (int, error) {
+	cond, args := callersCond(symbolName)
+	query, args := callersQuery(cond, args, opts)
+	return m.countRows(query, args)
+}
+
+// callersCond matches the callee names flexibly, like callees match
+// callers, or a scope-qualified name against the end of the ID
+// (Class.method matches path#Class.method and its clash-suffixed
+// path#Class.method@42)
+func callersCond(symbolName string) (string, []interface{}) {
+	cond := `c.callee_id IN (
+		SELECT id FROM symbols
+		WHERE name = ? OR name LIKE ? OR name LIKE ? OR id LIKE ? OR id LIKE ?)`
+	args := []interface{}{
+		symbolName,               // Exact match
+		symbolName + "(%",        // Method with params: method(
+		"%." + symbolName + "(%", // Qualified with params: Class.method(
+		"%#" + symbolName,        // Scope chain: path#Class.method
+		"%#" + symbolName + "@%", // Clashing name: path#Class.method@42
+	}
+	return cond, args
+}
+
+func callersQuery(cond string, args []interface{}, opts QueryOptions) (string, []interface{}) {
 	// Join calls table to find caller symbols
-	// callee_id format varies:
-	// - Go: path#FunctionName
-	// - Java: path#Class.methodName(params)
-	// - C#: path#ClassName.MethodName
-	// We need to match when symbolName appears after # or after . (for method names)
 	query := `
 		SELECT s.id, s.name, s.kind, s.file, s.line, s.column, s.end_line, s.end_column, 
-		       s.scope, s.signature, s.documentation, s.language, s.source, s.created_at,
-		       c.file as call_file, c.line as call_line, c.column as call_column
+		       s.scope, s.signature, s.documentation, s.language, s.source, s.created_at, s.is_test, s.package,
+		       c.file as call_file, c.line as call_line, c.column as call_column,
+		       c.arg_count, COALESCE(c.args, ''), COALESCE(c.context, '')
 		FROM symbols s
 		JOIN calls c ON s.id = c.caller_id
-		WHERE (c.callee_id LIKE ? OR c.callee_id LIKE ? OR c.callee_id LIKE ?)`
-	// Match: #symbolName, #Class.symbolName, or .symbolName(
-	args := []interface{}{
-		"%#" + symbolName,          // Exact function: path#FunctionName
-		"%#%." + symbolName + "(%", // Method with params: path#Class.method(
-		"%." + symbolName,          // Method without params: path#Class.method
-	}
+		WHERE ` + cond
 
-	if len(languages) > 0 {
-		query += " AND s.language IN (?" + repeatString(",?", len(languages)-1) + ")"
-		for _, lang := range languages {
-			args = append(args, lang)
-		}
-	}
+	query, args = applyQueryOptions(query, args, "s.", opts)
+	query, args = applyCallSiteOptions(query, args, opts)
 
 	// Group by call site to avoid duplicates when multiple callees match (e.g., interface + impl)
-	query += " GROUP BY c.file, c.line, c.column ORDER BY c.file, c.line"
+	query += " GROUP BY c.file, c.line, c.column"
+	return orderAndPage(query, args, callSiteSortColumns, opts, SortFile)
+}
 
-	rows, err := m.db.Query(query, args...)
+func (m *Manager) eachCaller(cond string, args []interface{}, opts QueryOptions, fn func(CallerInfo) error) error {
+	query, args := callersQuery(cond, args, opts)
+	rows, err := m.query(query, args...)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
 
-	var callers []CallerInfo
 	for rows.Next() {
 		var c CallerInfo
 		var endLine, endColumn *int
 		err := rows.Scan(
 			&c.ID, &c.Name, &c.Kind, &c.File, &c.Line, &c.Column,
 			&endLine, &endColumn, &c.Scope, &c.Signature, &c.Documentation,
-			&c.Language, &c.Source, &c.CreatedAt,
+			&c.Language, &c.Source, &c.CreatedAt, &c.IsTest, &c.Package,
 			&c.CallFile, &c.CallLine, &c.CallColumn,
+			&c.CallArgCount, &c.CallArgs, &c.CallContext,
 		)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		c.EndLine = endLine
 		c.EndColumn = endColumn
-		callers = append(callers, c)
+		if err := fn(c); err != nil {
+			return err
+		}
 	}
-	return callers, rows.Err()
+	return rows.Err()
 }
 
 // GetCallees finds all callees of a symbol with call site info
-func (m *Manager) GetCallees(symbolName string, languages []string) ([]CalleeInfo, error) {
-	// Match caller names flexibly:
-	// - Exact match: main
-	// - Method with params: main(String[])
-	// - Qualified: Class.main
-	query := `
-		SELECT s.id, s.name, s.kind, s.file, s.line, s.column, s.end_line, s.end_column, 
-		       s.scope, s.signature, s.documentation, s.language, s.source, s.created_at,
-		       c.file as call_file, c.line as call_line, c.column as call_column
-		FROM symbols s
-		JOIN calls c ON s.id = c.callee_id
-		JOIN symbols caller ON c.caller_id = caller.id
-		WHERE (caller.name = ? OR caller.name LIKE ? OR caller.name LIKE ?)`
+func (m *Manager) GetCallees(symbolName string, opts QueryOptions) ([]CalleeInfo, error) {
+	var callees []CalleeInfo
+	err := m.EachCallee(symbolName, opts, func(c CalleeInfo) error {
+		callees = append(callees, c)
+		return nil
+	})
+	return callees, err
+}
+
+// GetCalleesByID finds the callees of one specific symbol
+func (m *Manager) GetCalleesByID(symbolID string, opts QueryOptions) ([]CalleeInfo, error) {
+	var callees []CalleeInfo
+	err := m.eachCallee("c.caller_id = ?", []interface{}{symbolID}, opts, func(c CalleeInfo) error {
+		callees = append(callees, c)
+		return nil
+	})
+	return callees, err
+}
+
+// EachCallee streams the callees GetCallees finds to fn one row at a time,
+// like EachCaller
+func (m *Manager) EachCallee(symbolName string, opts QueryOptions, fn func(CalleeInfo) error) error {
+	cond, args := calleesCond(symbolName)
+	return m.eachCallee(cond, args, opts, fn)
+}
+
+// CountCallees returns how many call sites GetCallees would return
+func (m *Manager) CountCallees(symbolName string, opts QueryOptions) (int, error) {
+	cond, args := calleesCond(symbolName)
+	query, args := calleesQuery(cond, args, opts)
+	return m.countRows(query, args)
+}
+
+// calleesCond matches the caller names flexibly:
+// - Exact match: main
+// - Method with params: main(String[])
+// - Qualified: Class.main
+func calleesCond(symbolName string) (string, []interface{}) {
+	cond := "(caller.name = ? OR caller.name LIKE ? OR caller.name LIKE ?)"
 	args := []interface{}{
 		symbolName,               // Exact match
 		symbolName + "(%",        // Method with params: main(
 		"%." + symbolName + "(%", // Qualified with params: Class.main(
 	}
+	return cond, args
+}
 
-	if len(languages) > 0 {
-		query += " AND s.language IN (?" + repeatString(",?", len(languages)-1) + ")"
-		for _, lang := range languages {
-			args = append(args, lang)
-		}
-	}
+func calleesQuery(cond string, args []interface{}, opts QueryOptions) (string, []interface{}) {
+	query := `
+		SELECT s.id, s.name, s.kind, s.file, s.line, s.column, s.end_line, s.end_column, 
+		       s.scope, s.signature, s.documentation, s.language, s.source, s.created_at, s.is_test, s.package,
+		       c.file as call_file, c.line as call_line, c.column as call_column,
+		       c.arg_count, COALESCE(c.args, ''), COALESCE(c.context, '')
+		FROM symbols s
+		JOIN calls c ON s.id = c.callee_id
+		JOIN symbols caller ON c.caller_id = caller.id
+		WHERE ` + cond
+
+	query, args = applyQueryOptions(query, args, "s.", opts)
+	query, args = applyCallSiteOptions(query, args, opts)
 
 	// Group by call site to deduplicate (interface + impl at same line)
-	query += " GROUP BY c.file, c.line, c.column ORDER BY c.file, c.line"
+	query += " GROUP BY c.file, c.line, c.column"
+	return orderAndPage(query, args, callSiteSortColumns, opts, SortFile)
+}
 
-	rows, err := m.db.Query(query, args...)
+func (m *Manager) eachCallee(cond string, args []interface{}, opts QueryOptions, fn func(CalleeInfo) error) error {
+	query, args := calleesQuery(cond, args, opts)
+	rows, err := m.query(query, args...)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
 
-	var callees []CalleeInfo
 	for rows.Next() {
 		var c CalleeInfo
 		var endLine, endColumn *int
 		err := rows.Scan(
 			&c.ID, &c.Name, &c.Kind, &c.File, &c.Line, &c.Column,
 			&endLine, &endColumn, &c.Scope, &c.Signature, &c.Documentation,
-			&c.Language, &c.Source, &c.CreatedAt,
+			&c.Language, &c.Source, &c.CreatedAt, &c.IsTest, &c.Package,
 			&c.CallFile, &c.CallLine, &c.CallColumn,
+			&c.CallArgCount, &c.CallArgs, &c.CallContext,
 		)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		c.EndLine = endLine
 		c.EndColumn = endColumn
-		callees = append(callees, c)
+		if err := fn(c); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// countRows returns how many rows query returns, without reading them
+func (m *Manager) countRows(query string, args []interface{}) (int, error) {
+	var count int
+	err := m.queryRow("SELECT COUNT(*) FROM ("+query+")", args...).Scan(&count)
+	return count, err
+}
+
+// GetIncomingCallCounts returns how many call sites target each of the given
+// symbols; symbols that are never called are absent from the map
+func (m *Manager) GetIncomingCallCounts(symbolIDs []string) (map[string]int, error) {
+	counts := make(map[string]int)
+	if len(symbolIDs) == 0 {
+		return counts, nil
+	}
+
+	query := "SELECT callee_id, COUNT(*) FROM calls WHERE callee_id IN " + placeholders(len(symbolIDs)) + " GROUP BY callee_id"
+	args := make([]interface{}, len(symbolIDs))
+	for i, id := range symbolIDs {
+		args[i] = id
+	}
+
+	rows, err := m.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		var count int
+		if err := rows.Scan(&id, &count); err != nil {
+			return nil, err
+		}
+		counts[id] = count
+	}
+	return counts, rows.Err()
+}
+
+// ListSymbols returns all symbols matching opts, ordered by file by default
+func (m *Manager) ListSymbols(opts QueryOptions) ([]Symbol, error) {
+	var symbols []Symbol
+	err := m.EachSymbol(opts, func(s Symbol) error {
+		symbols = append(symbols, s)
+		return nil
+	})
+	return symbols, err
+}
+
+// EachSymbol streams the symbols ListSymbols returns to fn one row at a
+// time, like EachCaller
+func (m *Manager) EachSymbol(opts QueryOptions, fn func(Symbol) error) error {
+	query := `
+		SELECT id, name, kind, file, line, column, end_line, end_column, scope, signature, documentation, language, source, created_at, is_test, package
+		FROM symbols
+		WHERE 1 = 1`
+	query, args := applyQueryOptions(query, nil, "", opts)
+	query, args = orderAndPage(query, args, symbolSortColumns(""), opts, SortFile)
+
+	rows, err := m.query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	return eachSymbol(rows, fn)
+}
+
+// ListKinds returns the distinct kinds of the indexed symbols, in name
+// order, including custom kinds from config.toml's [kinds] section
+func (m *Manager) ListKinds() ([]string, error) {
+	rows, err := m.db.Query("SELECT DISTINCT kind FROM symbols ORDER BY kind")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var kinds []string
+	for rows.Next() {
+		var kind string
+		if err := rows.Scan(&kind); err != nil {
+			return nil, err
+		}
+		kinds = append(kinds, kind)
+	}
+	return kinds, rows.Err()
+}
+
+// ListPackages returns the distinct packages and modules of the indexed
+// symbols, in order
+func (m *Manager) ListPackages() ([]string, error) {
+	rows, err := m.db.Query("SELECT DISTINCT package FROM symbols WHERE package != '' ORDER BY package")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var packages []string
+	for rows.Next() {
+		var pkg string
+		if err := rows.Scan(&pkg); err != nil {
+			return nil, err
+		}
+		packages = append(packages, pkg)
+	}
+	return packages, rows.Err()
+}
+
+// CompleteSymbolNames returns the distinct symbol names starting with
+// prefix (case-sensitive), in name order, for shell completion
+func (m *Manager) CompleteSymbolNames(prefix string, opts QueryOptions) ([]string, error) {
+	// substr counts characters, not bytes
+	query := "SELECT DISTINCT name FROM symbols WHERE substr(name, 1, ?) = ?"
+	args := []interface{}{utf8.RuneCountInString(prefix), prefix}
+	query, args = applyQueryOptions(query, args, "", opts)
+	query += " ORDER BY name"
+	if opts.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, opts.Limit)
+	}
+
+	rows, err := m.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// GetSymbolByID returns the symbol with the given ID, or nil if none exists
+func (m *Manager) GetSymbolByID(id string) (*Symbol, error) {
+	query := `
+		SELECT id, name, kind, file, line, column, end_line, end_column, scope, signature, documentation, language, source, created_at, is_test, package
+		FROM symbols
+		WHERE id = ?`
+
+	rows, err := m.query(query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	symbols, err := scanSymbols(rows)
+	if err != nil || len(symbols) == 0 {
+		return nil, err
+	}
+	return &symbols[0], nil
+}
+
+// GetFileSymbols returns every symbol declared in a file, in source order
+func (m *Manager) GetFileSymbols(file string) ([]Symbol, error) {
+	query := `
+		SELECT id, name, kind, file, line, column, end_line, end_column, scope, signature, documentation, language, source, created_at, is_test, package
+		FROM symbols
+		WHERE file = ?
+		ORDER BY line, column`
+
+	rows, err := m.query(query, file)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanSymbols(rows)
+}
+
+// MemberKinds are the data-member kinds returned by GetMembers by default
+var MemberKinds = []string{"field", "property", "enum_member"}
+
+// GetMembers returns the fields, properties and enum members declared inside
+// owner, in source order. Members come from the contains table, plus any
+// symbol in the owner's file and line range whose scope ends in the owner's
+// name ("Outer.Inner", "mod::Type"), which covers indexes built before
+// containment was recorded. opts.Kinds defaults to MemberKinds.
+func (m *Manager) GetMembers(owner Symbol, opts QueryOptions) ([]Symbol, error) {
+	scoped := "file = ? AND line >= ? AND (scope = ? OR scope LIKE ? OR scope LIKE ?)"
+	args := []interface{}{owner.ID, owner.ID, owner.File, owner.Line, owner.Name, "%." + owner.Name, "%::" + owner.Name}
+	if owner.EndLine != nil {
+		scoped += " AND line <= ?"
+		args = append(args, *owner.EndLine)
+	}
+	query := `
+		SELECT id, name, kind, file, line, column, end_line, end_column, scope, signature, documentation, language, source, created_at, is_test, package
+		FROM symbols
+		WHERE id != ?
+		  AND (id IN (SELECT child_id FROM contains WHERE parent_id = ?) OR (` + scoped + `))`
+	if len(opts.Kinds) == 0 {
+		opts.Kinds = MemberKinds
 	}
-	return callees, rows.Err()
+	query, args = applyQueryOptions(query, args, "", opts)
+	query, args = orderAndPage(query, args, symbolSortColumns(""), opts, SortLine)
+
+	rows, err := m.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanSymbols(rows)
 }
 
 // GetSignature finds the signature of a symbol
@@ -315,7 +1122,7 @@ func (m *Manager) GetSignature(symbolName string, languages []string) ([]Symbol,
 	// - Method with params: main(String[])
 	// - Qualified: Class.main
 	query := `
-		SELECT id, name, kind, file, line, column, end_line, end_column, scope, signature, documentation, language, source, created_at
+		SELECT id, name, kind, file, line, column, end_line, end_column, scope, signature, documentation, language, source, created_at, is_test, package
 		FROM symbols
 		WHERE (name = ? OR name LIKE ? OR name LIKE ?) AND signature IS NOT NULL AND signature != ''`
 	args := []interface{}{
@@ -325,13 +1132,12 @@ func (m *Manager) GetSignature(symbolName string, languages []string) ([]Symbol,
 	}
 
 	if len(languages) > 0 {
-		query += " AND language IN (?" + repeatString(",?", len(languages)-1) + ")"
-		for _, lang := range languages {
-			args = append(args, lang)
-		}
+		in, list := inList(languages)
+		query += " AND language IN " + in
+		args = append(args, list)
 	}
 
-	rows, err := m.db.Query(query, args...)
+	rows, err := m.query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -343,12 +1149,12 @@ func (m *Manager) GetSignature(symbolName string, languages []string) ([]Symbol,
 // GetFunctionSymbols returns all function symbols for a language
 func (m *Manager) GetFunctionSymbols(language string) ([]Symbol, error) {
 	query := `
-		SELECT id, name, kind, file, line, column, end_line, end_column, scope, signature, documentation, language, source, created_at
+		SELECT id, name, kind, file, line, column, end_line, end_column, scope, signature, documentation, language, source, created_at, is_test, package
 		FROM symbols
 		WHERE kind IN ('function', 'method') AND language = ?
 		ORDER BY file, line`
 
-	rows, err := m.db.Query(query, language)
+	rows, err := m.query(query, language)
 	if err != nil {
 		return nil, err
 	}
@@ -360,12 +1166,12 @@ func (m *Manager) GetFunctionSymbols(language string) ([]Symbol, error) {
 // GetTypeSymbols returns all class/interface/struct symbols for a language
 func (m *Manager) GetTypeSymbols(language string) ([]Symbol, error) {
 	query := `
-		SELECT id, name, kind, file, line, column, end_line, end_column, scope, signature, documentation, language, source, created_at
+		SELECT id, name, kind, file, line, column, end_line, end_column, scope, signature, documentation, language, source, created_at, is_test, package
 		FROM symbols
 		WHERE kind IN ('class', 'interface', 'struct', 'type', 'enum') AND language = ?
 		ORDER BY file, line`
 
-	rows, err := m.db.Query(query, language)
+	rows, err := m.query(query, language)
 	if err != nil {
 		return nil, err
 	}
@@ -376,12 +1182,17 @@ func (m *Manager) GetTypeSymbols(language string) ([]Symbol, error) {
 
 // GetSymbolByName returns symbol by name (flexible matching)
 func (m *Manager) GetSymbolByName(name string, languages []string) ([]Symbol, error) {
+	return m.FindSymbolsByName(name, QueryOptions{Languages: languages})
+}
+
+// FindSymbolsByName returns symbols by name (flexible matching) narrowed by opts
+func (m *Manager) FindSymbolsByName(name string, opts QueryOptions) ([]Symbol, error) {
 	// Match symbol names flexibly:
 	// - Exact match: main
 	// - Method with params: main(String[])
 	// - Qualified: Class.main
 	query := `
-		SELECT id, name, kind, file, line, column, end_line, end_column, scope, signature, documentation, language, source, created_at
+		SELECT id, name, kind, file, line, column, end_line, end_column, scope, signature, documentation, language, source, created_at, is_test, package
 		FROM symbols
 		WHERE (name = ? OR name LIKE ? OR name LIKE ?)`
 	args := []interface{}{
@@ -389,17 +1200,15 @@ func (m *Manager) GetSymbolByName(name string, languages []string) ([]Symbol, er
 		name + "(%",        // Method with params: main(
 		"%." + name + "(%", // Qualified with params: Class.main(
 	}
+	query, args = applyQueryOptions(query, args, "", opts)
 
-	if len(languages) > 0 {
-		query += " AND language IN (?" + repeatString(",?", len(languages)-1) + ")"
-		for _, lang := range languages {
-			args = append(args, lang)
-		}
-	}
-
-	query += " ORDER BY file, line"
+	// Score: exact name before parameterized/qualified forms
+	cols := symbolSortColumns("")
+	cols.Score = "CASE WHEN name = ? THEN 0 ELSE 1 END"
+	cols.ScoreArgs = []interface{}{name}
+	query, args = orderAndPage(query, args, cols, opts, SortFile)
 
-	rows, err := m.db.Query(query, args...)
+	rows, err := m.query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -410,23 +1219,20 @@ func (m *Manager) GetSymbolByName(name string, languages []string) ([]Symbol, er
 
 // GetStats is defined below with Stats struct
 
-// UpdateFileMeta updates file metadata for incremental builds
-func (m *Manager) UpdateFileMeta(path string, modTime time.Time, language string) error {
-	_, err := m.db.Exec(`
-		INSERT OR REPLACE INTO file_meta (path, mod_time, language)
-		VALUES (?, ?, ?)`,
-		path, modTime, language,
-	)
+// UpdateFileMeta updates file metadata for incremental builds; source is
+// the extractor that indexed the file
+func (m *Manager) UpdateFileMeta(path string, modTime time.Time, language, source string) error {
+	_, err := m.exec(updateFileMetaSQL, path, modTime, language, nullIfEmpty(source))
 	return err
 }
 
 // GetFileMeta gets file metadata
 func (m *Manager) GetFileMeta(path string) (*FileMeta, error) {
 	var fm FileMeta
-	err := m.db.QueryRow(
-		"SELECT path, mod_time, language FROM file_meta WHERE path = ?",
+	err := m.queryRow(
+		"SELECT path, mod_time, language, COALESCE(source, '') FROM file_meta WHERE path = ?",
 		path,
-	).Scan(&fm.Path, &fm.ModTime, &fm.Language)
+	).Scan(&fm.Path, &fm.ModTime, &fm.Language, &fm.Source)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -437,6 +1243,27 @@ func (m *Manager) GetFileMeta(path string) (*FileMeta, error) {
 	return &fm, nil
 }
 
+// FileModTimes returns the modification time of every indexed file, keyed
+// by path
+func (m *Manager) FileModTimes() (map[string]time.Time, error) {
+	rows, err := m.query("SELECT path, mod_time FROM file_meta")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	times := make(map[string]time.Time)
+	for rows.Next() {
+		var path string
+		var modTime time.Time
+		if err := rows.Scan(&path, &modTime); err != nil {
+			return nil, err
+		}
+		times[path] = modTime
+	}
+	return times, rows.Err()
+}
+
 // Stats holds database statistics
 type Stats struct {
 	SymbolCount int
@@ -520,6 +1347,19 @@ type DetailedStats struct {
 	// Database info
 	DatabasePath string
 	DatabaseSize int64
+
+	// Per-file or per-directory breakdown, filled by GetPathStats on request
+	Paths []PathStats
+}
+
+// PathStats holds the statistics of one file or directory
+type PathStats struct {
+	Path      string   // Relative to the project root; "." for the root directory
+	Files     int      // Indexed files, including those without symbols
+	Symbols   int      // All symbols
+	Functions int      // Functions and methods
+	CallEdges int      // Calls made from the path's symbols
+	Languages []string // Sorted
 }
 
 // GetDetailedStats returns comprehensive database statistics
@@ -614,10 +1454,14 @@ func (m *Manager) GetDetailedStats() (*DetailedStats, error) {
 		return nil, err
 	}
 	if lastBuildStr.Valid {
-		// Parse RFC3339 format
-		lastBuildTime, err := time.Parse(time.RFC3339, lastBuildStr.String)
-		if err == nil {
-			stats.LastBuildTime = &lastBuildTime
+		// MAX() returns the stored text, in one of the driver's timestamp
+		// formats (RFC3339 for rows written by older versions)
+		layouts := append([]string{time.RFC3339}, sqlite3.SQLiteTimestampFormats...)
+		for _, layout := range layouts {
+			if lastBuildTime, err := time.Parse(layout, lastBuildStr.String); err == nil {
+				stats.LastBuildTime = &lastBuildTime
+				break
+			}
 		}
 	}
 
@@ -635,23 +1479,160 @@ func (m *Manager) GetDetailedStats() (*DetailedStats, error) {
 	return stats, nil
 }
 
+// GetPathStats breaks the index down by file, or by directory when byDir
+// is set, with paths relative to root. depth > 0 rolls directories up to
+// their first depth components. Entries are sorted by symbol count,
+// largest first.
+func (m *Manager) GetPathStats(root string, byDir bool, depth int) ([]PathStats, error) {
+	byPath := make(map[string]*PathStats)
+	languages := make(map[string]map[string]bool)
+	entry := func(file string) *PathStats {
+		path := file
+		if rel, err := filepath.Rel(root, file); err == nil {
+			path = rel
+		}
+		path = filepath.ToSlash(path)
+		if byDir {
+			path = pathPrefix(filepath.ToSlash(filepath.Dir(path)), depth)
+		}
+		if byPath[path] == nil {
+			byPath[path] = &PathStats{Path: path}
+			languages[path] = make(map[string]bool)
+		}
+		return byPath[path]
+	}
+
+	fileRows, err := m.db.Query("SELECT path FROM file_meta")
+	if err != nil {
+		return nil, err
+	}
+	defer fileRows.Close()
+	for fileRows.Next() {
+		var file string
+		if err := fileRows.Scan(&file); err != nil {
+			return nil, err
+		}
+		entry(file).Files++
+	}
+	if err := fileRows.Err(); err != nil {
+		return nil, err
+	}
+
+	symbolRows, err := m.db.Query(`
+		SELECT file, language, COUNT(*), SUM(kind IN ('function', 'method'))
+		FROM symbols
+		GROUP BY file, language`)
+	if err != nil {
+		return nil, err
+	}
+	defer symbolRows.Close()
+	for symbolRows.Next() {
+		var file, lang string
+		var symbols, functions int
+		if err := symbolRows.Scan(&file, &lang, &symbols, &functions); err != nil {
+			return nil, err
+		}
+		e := entry(file)
+		e.Symbols += symbols
+		e.Functions += functions
+		languages[e.Path][lang] = true
+	}
+	if err := symbolRows.Err(); err != nil {
+		return nil, err
+	}
+
+	callRows, err := m.db.Query(`
+		SELECT s.file, COUNT(*)
+		FROM calls c
+		INNER JOIN symbols s ON c.caller_id = s.id
+		GROUP BY s.file`)
+	if err != nil {
+		return nil, err
+	}
+	defer callRows.Close()
+	for callRows.Next() {
+		var file string
+		var calls int
+		if err := callRows.Scan(&file, &calls); err != nil {
+			return nil, err
+		}
+		entry(file).CallEdges += calls
+	}
+	if err := callRows.Err(); err != nil {
+		return nil, err
+	}
+
+	paths := make([]PathStats, 0, len(byPath))
+	for path, e := range byPath {
+		for lang := range languages[path] {
+			e.Languages = append(e.Languages, lang)
+		}
+		sort.Strings(e.Languages)
+		paths = append(paths, *e)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		if paths[i].Symbols != paths[j].Symbols {
+			return paths[i].Symbols > paths[j].Symbols
+		}
+		return paths[i].Path < paths[j].Path
+	})
+	return paths, nil
+}
+
+// pathPrefix returns the first depth components of a slash-separated
+// path, or the whole path when depth is 0
+func pathPrefix(path string, depth int) string {
+	if depth <= 0 {
+		return path
+	}
+	parts := strings.Split(path, "/")
+	if len(parts) > depth {
+		parts = parts[:depth]
+	}
+	return strings.Join(parts, "/")
+}
+
 // Helper functions
 
 func scanSymbols(rows *sql.Rows) ([]Symbol, error) {
 	var symbols []Symbol
+	err := eachSymbol(rows, func(s Symbol) error {
+		symbols = append(symbols, s)
+		return nil
+	})
+	return symbols, err
+}
+
+// eachSymbol scans symbol rows into fn one at a time, stopping at the first
+// error fn returns
+func eachSymbol(rows *sql.Rows, fn func(Symbol) error) error {
 	for rows.Next() {
 		var s Symbol
 		err := rows.Scan(
 			&s.ID, &s.Name, &s.Kind, &s.File, &s.Line, &s.Column,
 			&s.EndLine, &s.EndColumn, &s.Scope, &s.Signature,
-			&s.Documentation, &s.Language, &s.Source, &s.CreatedAt,
+			&s.Documentation, &s.Language, &s.Source, &s.CreatedAt, &s.IsTest, &s.Package,
 		)
 		if err != nil {
-			return nil, err
+			return err
+		}
+		if err := fn(s); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// repeatArgs returns values n times over, for a query using the same IN
+// list n times
+func repeatArgs(values []string, n int) []interface{} {
+	args := make([]interface{}, 0, n*len(values))
+	for i := 0; i < n; i++ {
+		for _, v := range values {
+			args = append(args, v)
 		}
-		symbols = append(symbols, s)
 	}
-	return symbols, rows.Err()
+	return args
 }
 
 func repeatString(s string, n int) string {
@@ -0,0 +1,86 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestManager returns an initialized database in a temporary directory
+func newTestManager(t *testing.T) (*Manager, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "codegraph.db")
+	m, err := NewManager(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Initialize(); err != nil {
+		m.Close()
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { m.Close() })
+	return m, path
+}
+
+func TestOutdatedIndexIsRefusedNotCleared(t *testing.T) {
+	m, path := newTestManager(t)
+	if err := m.UpdateFileMeta("/project/main.go", time.Now(), "go", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.db.Exec("PRAGMA user_version = 0"); err != nil {
+		t.Fatal(err)
+	}
+	m.Close()
+
+	if _, err := NewManager(path); !errors.Is(err, ErrOutdatedIndex) {
+		t.Fatalf("NewManager on an outdated index: err = %v, want ErrOutdatedIndex", err)
+	}
+	if _, err := NewReadOnlyManager(path); !errors.Is(err, ErrOutdatedIndex) {
+		t.Fatalf("NewReadOnlyManager on an outdated index: err = %v, want ErrOutdatedIndex", err)
+	}
+	raw, err := sql.Open(driverName, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var files int
+	err = raw.QueryRow("SELECT COUNT(*) FROM file_meta").Scan(&files)
+	raw.Close()
+	if err != nil || files != 1 {
+		t.Fatalf("file_meta rows after a refused open = %d, %v; want 1", files, err)
+	}
+
+	built, err := NewBuildManager(path)
+	if err != nil {
+		t.Fatalf("NewBuildManager: %v", err)
+	}
+	defer built.Close()
+	if meta, err := built.GetFileMeta("/project/main.go"); err != nil || meta != nil {
+		t.Errorf("GetFileMeta after NewBuildManager = %+v, %v; want the index cleared", meta, err)
+	}
+	if version, err := FileSchemaVersion(path); err != nil || version != SchemaVersion {
+		t.Errorf("schema version = %d, %v; want %d", version, err, SchemaVersion)
+	}
+}
+
+func TestNewDatabaseRecordsSchemaVersion(t *testing.T) {
+	_, path := newTestManager(t)
+	if version, err := FileSchemaVersion(path); err != nil || version != SchemaVersion {
+		t.Errorf("schema version = %d, %v; want %d", version, err, SchemaVersion)
+	}
+}
+
+func TestCompleteSymbolNamesMatchesNonASCIIPrefixes(t *testing.T) {
+	m, _ := newTestManager(t)
+	for _, name := range []string{"ÜberParser", "überParser", "Ubiquitous"} {
+		sym := &Symbol{ID: "a.go#" + name, Name: name, Kind: "function", Language: "go", File: "/project/a.go", Line: 1, CreatedAt: time.Unix(0, 0)}
+		if err := m.InsertSymbol(sym); err != nil {
+			t.Fatal(err)
+		}
+	}
+	names, err := m.CompleteSymbolNames("Üb", QueryOptions{})
+	if err != nil || len(names) != 1 || names[0] != "ÜberParser" {
+		t.Errorf("CompleteSymbolNames(Üb) = %v, %v; want [ÜberParser]", names, err)
+	}
+}
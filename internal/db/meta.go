@@ -0,0 +1,164 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Keys of index_meta
+const (
+	MetaRoot  = "root"  // Absolute project root of the indexed file paths
+	MetaShard = "shard" // "i/N" for a build of one shard of the files
+	// Commit checked out when the last build completed, unless the project
+	// is not a git repository
+	MetaGitHead = "git_head"
+	// Start time of a build that has not completed (still running,
+	// interrupted or crashed)
+	MetaInterrupted = "interrupted"
+)
+
+// SetMeta records a fact about the index, or deletes it when value is empty
+func (m *Manager) SetMeta(key, value string) error {
+	var err error
+	if value == "" {
+		_, err = m.db.Exec("DELETE FROM index_meta WHERE key = ?", key)
+	} else {
+		_, err = m.db.Exec("INSERT OR REPLACE INTO index_meta (key, value) VALUES (?, ?)", key, value)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record %s: %w", key, err)
+	}
+	return nil
+}
+
+// GetMeta returns a fact about the index, or "" when it was not recorded
+func (m *Manager) GetMeta(key string) (string, error) {
+	var value string
+	err := m.db.QueryRow("SELECT value FROM index_meta WHERE key = ?", key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return value, err
+}
+
+// IndexMetaAt returns a fact recorded in the index at path without opening
+// it as a Manager, or "" when it was not recorded
+func IndexMetaAt(path, key string) (string, error) {
+	if _, err := os.Stat(path); err != nil {
+		return "", err
+	}
+	conn, err := sql.Open(driverName, "file:"+path+"?mode=ro")
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	var value string
+	err = conn.QueryRow("SELECT value FROM index_meta WHERE key = ?", key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s of %s: %w", key, path, err)
+	}
+	return value, nil
+}
+
+// MergeIndex adds the rows of the index at path, built in a checkout at
+// another root, to this one with their file paths moved under root. The
+// index at path is left unchanged; rows already present (the same symbol
+// or file) are kept. Edges between its symbols and the others are not
+// created: re-extract them once every index is merged.
+func (m *Manager) MergeIndex(path, root string) error {
+	if version, err := FileSchemaVersion(path); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	} else if version != SchemaVersion {
+		return fmt.Errorf("%s has schema version %d, this codegraph needs %d; rebuild it with a matching version", path, version, SchemaVersion)
+	}
+
+	// Rebase a copy, so the merged paths are rewritten in one place
+	tmp := m.dbPath + ".merge"
+	os.Remove(tmp)
+	defer os.Remove(tmp)
+	source, err := NewManager(path)
+	if err != nil {
+		return err
+	}
+	err = source.SnapshotTo(tmp)
+	if closeErr := source.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	copied, err := NewManager(tmp)
+	if err != nil {
+		return err
+	}
+	oldRoot, err := copied.GetMeta(MetaRoot)
+	if err == nil && oldRoot == "" {
+		err = fmt.Errorf("%s does not record its project root; rebuild it with this version of codegraph", path)
+	}
+	if err == nil {
+		err = copied.RebaseFiles(oldRoot, root)
+	}
+	if closeErr := copied.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	// ATTACH holds for one connection, so every statement runs on the same
+	ctx := context.Background()
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, "ATTACH DATABASE ? AS shard", tmp); err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer conn.ExecContext(ctx, "DETACH DATABASE shard")
+
+	// Parents first, so foreign keys hold; index_meta describes the shard
+	for _, table := range slices.Backward(IndexTables) {
+		if table == "index_meta" {
+			continue
+		}
+		columns, err := copiedColumns(ctx, conn, table)
+		if err != nil {
+			return fmt.Errorf("failed to inspect %s: %w", table, err)
+		}
+		stmt := fmt.Sprintf("INSERT OR IGNORE INTO main.%[1]s (%[2]s) SELECT %[2]s FROM shard.%[1]s", table, columns)
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to merge %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// copiedColumns lists the columns of table to copy between indexes: all
+// but an AUTOINCREMENT id, which is renumbered
+func copiedColumns(ctx context.Context, conn *sql.Conn, table string) (string, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT name FROM main.pragma_table_info(?) WHERE NOT (pk = 1 AND type = 'INTEGER')", table)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return "", err
+		}
+		columns = append(columns, `"`+name+`"`)
+	}
+	return strings.Join(columns, ", "), rows.Err()
+}
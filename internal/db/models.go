@@ -18,6 +18,9 @@ type Symbol struct {
 	Language      string    `json:"language"`       // Programming language
 	Source        string    `json:"source"`         // lsp, tree-sitter, ast-grep, ripgrep
 	CreatedAt     time.Time `json:"created_at"`     // When indexed
+	IsTest        bool      `json:"is_test"`        // Defined in a test file
+	Package       string    `json:"package,omitempty"` // Go/Java package, Python module or TS module path
+	BodyHash      string    `json:"-"` // Hash of the definition without its name, to recognize renames
 }
 
 // Call represents a call relationship between symbols
@@ -28,22 +31,52 @@ type Call struct {
 	File     string `json:"file"`      // File where call occurs
 	Line     int    `json:"line"`      // Line of call
 	Column   int    `json:"column"`    // Column of call
+	ArgCount *int   `json:"arg_count"` // Arguments passed (nil when unknown)
+	Args     string `json:"args"`      // Argument source text, e.g. "(ctx, \"id\")"
+	Context  string `json:"context"`   // Enclosing control flow, outermost first, e.g. "loop,if"; empty when unconditional
+}
+
+// ExternalCall is a call whose callee is not an indexed symbol: a standard
+// library or third-party function, or a name that did not resolve
+type ExternalCall struct {
+	ID         int64  `json:"id"`
+	CallerID   string `json:"caller_id"`   // Symbol that makes the call
+	CalleeName string `json:"callee_name"` // Name called, e.g. Println for fmt.Println()
+	Package    string `json:"package"`     // Package the call is qualified with, e.g. fmt; Go only
+	File       string `json:"file"`        // File where call occurs
+	Line       int    `json:"line"`        // Line of call
+	Column     int    `json:"column"`      // Column of call
+	ArgCount   *int   `json:"arg_count"`   // Arguments passed (nil when unknown)
+	Args       string `json:"args"`        // Argument source text
+	Context    string `json:"context"`     // Enclosing control flow, as for Call
+	Resolved   bool   `json:"resolved"`    // Linked to a symbol by a later pass
+	// ExternalID is the dependency symbol the call was linked to, whose
+	// Signature and Module listings fill in
+	ExternalID string `json:"external_id,omitempty"`
+	Signature  string `json:"signature,omitempty"`
+	Module     string `json:"module,omitempty"`
 }
 
 // CallerInfo combines caller symbol info with call site location
 type CallerInfo struct {
-	Symbol       // Embedded caller symbol
-	CallFile   string `json:"call_file"`   // File where call occurs
-	CallLine   int    `json:"call_line"`   // Line of call site
-	CallColumn int    `json:"call_column"` // Column of call site
+	Symbol              // Embedded caller symbol
+	CallFile     string `json:"call_file"`      // File where call occurs
+	CallLine     int    `json:"call_line"`      // Line of call site
+	CallColumn   int    `json:"call_column"`    // Column of call site
+	CallArgCount *int   `json:"call_arg_count"` // Arguments passed at the call site
+	CallArgs     string `json:"call_args"`      // Argument source text at the call site
+	CallContext  string `json:"call_context"`   // Enclosing control flow at the call site
 }
 
 // CalleeInfo combines callee symbol info with call site location
 type CalleeInfo struct {
-	Symbol       // Embedded callee symbol
-	CallFile   string `json:"call_file"`   // File where call occurs
-	CallLine   int    `json:"call_line"`   // Line of call site
-	CallColumn int    `json:"call_column"` // Column of call site
+	Symbol              // Embedded callee symbol
+	CallFile     string `json:"call_file"`      // File where call occurs
+	CallLine     int    `json:"call_line"`      // Line of call site
+	CallColumn   int    `json:"call_column"`    // Column of call site
+	CallArgCount *int   `json:"call_arg_count"` // Arguments passed at the call site
+	CallArgs     string `json:"call_args"`      // Argument source text at the call site
+	CallContext  string `json:"call_context"`   // Enclosing control flow at the call site
 }
 
 // TypeHierarchy represents a type relationship (extends, implements)
@@ -52,6 +85,18 @@ type TypeHierarchy struct {
 	ChildID      string `json:"child_id"`      // Subclass/implementor
 	ParentID     string `json:"parent_id"`     // Superclass/interface
 	Relationship string `json:"relationship"`  // "extends" or "implements"
+	TypeArgs     string `json:"type_args,omitempty"` // Type arguments of the parent, "User" in Base<User>
+	Source       string `json:"source,omitempty"`    // HierarchySourceLSP when cached from a language server's answer; empty when extracted
+}
+
+// HierarchySourceLSP marks type relations cached from a language server's
+// textDocument/implementation answer rather than extracted by build
+const HierarchySourceLSP = "lsp"
+
+// Containment links a symbol to the symbol declaring it (method -> class)
+type Containment struct {
+	ChildID  string `json:"child_id"`  // Contained symbol
+	ParentID string `json:"parent_id"` // Class, type or module containing it
 }
 
 // FileMeta stores file metadata for incremental builds
@@ -59,4 +104,5 @@ type FileMeta struct {
 	Path     string    `json:"path"`
 	ModTime  time.Time `json:"mod_time"`
 	Language string    `json:"language"`
+	Source   string    `json:"source"` // Extractor that indexed the file: lsp or tree-sitter
 }
@@ -0,0 +1,42 @@
+package db
+
+import (
+	"database/sql"
+	"strings"
+)
+
+// Ownership is who owns a symbol: the CODEOWNERS owners of its file and
+// the author of most of its lines according to git blame
+type Ownership struct {
+	Owners []string `json:"owners"`
+	Author string   `json:"author"`
+}
+
+// SetFileOwners records the CODEOWNERS owners of every symbol in file
+func (m *Manager) SetFileOwners(file string, owners []string) error {
+	_, err := m.db.Exec("UPDATE symbols SET owners = ? WHERE file = ?", nullIfEmpty(strings.Join(owners, " ")), file)
+	return err
+}
+
+// SetSymbolAuthor records the main git blame author of a symbol
+func (m *Manager) SetSymbolAuthor(symbolID, author string) error {
+	_, err := m.db.Exec("UPDATE symbols SET author = ? WHERE id = ?", nullIfEmpty(author), symbolID)
+	return err
+}
+
+// GetOwnership returns the ownership recorded for a symbol
+func (m *Manager) GetOwnership(symbolID string) (*Ownership, error) {
+	var owners, author sql.NullString
+	err := m.db.QueryRow("SELECT owners, author FROM symbols WHERE id = ?", symbolID).Scan(&owners, &author)
+	if err != nil {
+		return nil, err
+	}
+	return &Ownership{Owners: strings.Fields(owners.String), Author: author.String}, nil
+}
+
+func nullIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
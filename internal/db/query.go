@@ -0,0 +1,191 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Sort keys accepted by QueryOptions.Sort
+const (
+	SortName  = "name"
+	SortFile  = "file"
+	SortLine  = "line"
+	SortScore = "score"
+	SortChurn = "churn" // Most commits first, for symbols whose history is stored
+)
+
+// SortKeys lists the valid QueryOptions.Sort values
+var SortKeys = []string{SortName, SortFile, SortLine, SortScore, SortChurn}
+
+// QueryOptions narrows the symbols returned by query methods. Zero values
+// apply no filtering and keep each query's natural order.
+type QueryOptions struct {
+	Languages    []string // Only symbols in these languages
+	Kinds        []string // Only symbols of these kinds
+	ExcludeKinds []string // Drop symbols of these kinds
+	Sort         string   // One of SortKeys; empty keeps the query's default order
+	Offset       int      // Rows to skip before returning results
+	Limit        int      // Max rows (0 = unlimited)
+	Arity        *int     // Call queries: only call sites passing this many arguments
+	CallContext  string   // Call queries: only call sites in this control flow (e.g. "catch"); NoCallContext for unconditional ones
+	Tests        string   // Test symbols: TestsInclude (default), TestsExclude or TestsOnly
+	Owner        string   // Only symbols owned (CODEOWNERS) or mainly authored (git blame) by this owner
+	Tags         []string // Only symbols with one of these annotation tags (route) or names (app.route), ignoring case
+	Package      string   // Only symbols in this package or module, matched by its trailing segments (see Symbol.Package)
+}
+
+// Values of QueryOptions.Tests
+const (
+	TestsInclude = ""        // Test and production symbols
+	TestsExclude = "exclude" // Production symbols only
+	TestsOnly    = "only"    // Test symbols only
+)
+
+// NoCallContext selects call sites outside any conditional, loop, defer,
+// goroutine, or try/catch
+const NoCallContext = "none"
+
+// ValidateSort reports an error when key is not one of SortKeys.
+func ValidateSort(key string) error {
+	if key == "" {
+		return nil
+	}
+	for _, k := range SortKeys {
+		if k == key {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid sort %q (valid: %s)", key, strings.Join(SortKeys, ", "))
+}
+
+// applyCallSiteOptions appends the call-site conditions for opts (arity,
+// control-flow context) to a query over calls aliased "c"
+func applyCallSiteOptions(query string, args []interface{}, opts QueryOptions) (string, []interface{}) {
+	if opts.Arity != nil {
+		query += " AND c.arg_count = ?"
+		args = append(args, *opts.Arity)
+	}
+	switch opts.CallContext {
+	case "":
+	case NoCallContext:
+		query += " AND COALESCE(c.context, '') = ''"
+	default:
+		query += " AND ',' || c.context || ',' LIKE ?"
+		args = append(args, "%,"+opts.CallContext+",%")
+	}
+	return query, args
+}
+
+// applyQueryOptions appends the WHERE conditions for opts to query. prefix
+// qualifies the filtered columns (e.g. "s.") when the query joins tables.
+func applyQueryOptions(query string, args []interface{}, prefix string, opts QueryOptions) (string, []interface{}) {
+	if len(opts.Languages) > 0 {
+		in, list := inList(opts.Languages)
+		query += " AND " + prefix + "language IN " + in
+		args = append(args, list)
+	}
+	if len(opts.Kinds) > 0 {
+		in, list := inList(opts.Kinds)
+		query += " AND " + prefix + "kind IN " + in
+		args = append(args, list)
+	}
+	if len(opts.ExcludeKinds) > 0 {
+		in, list := inList(opts.ExcludeKinds)
+		query += " AND " + prefix + "kind NOT IN " + in
+		args = append(args, list)
+	}
+	if opts.Owner != "" {
+		query += " AND (instr(' ' || lower(COALESCE(" + prefix + "owners, '')) || ' ', ' ' || lower(?) || ' ') > 0 OR " + prefix + "author = ? COLLATE NOCASE)"
+		args = append(args, opts.Owner, opts.Owner)
+	}
+	if len(opts.Tags) > 0 {
+		tags := make([]string, len(opts.Tags))
+		for i, tag := range opts.Tags {
+			tags[i] = strings.ToLower(strings.TrimLeft(tag, "@#["))
+		}
+		in, list := inList(tags)
+		query += " AND " + prefix + "id IN (SELECT symbol_id FROM tags WHERE tag IN " + in + " OR lower(name) IN " + in + ")"
+		args = append(args, list, list)
+	}
+	if opts.Package != "" {
+		// db matches db, internal/db and com.app.db, but not rdb
+		query += " AND (" + prefix + "package = ? OR substr(" + prefix + "package, -length(?) - 1) IN ('.' || ?, '/' || ?))"
+		args = append(args, opts.Package, opts.Package, opts.Package, opts.Package)
+	}
+	switch opts.Tests {
+	case TestsExclude:
+		query += " AND " + prefix + "is_test = 0"
+	case TestsOnly:
+		query += " AND " + prefix + "is_test = 1"
+	}
+	return query, args
+}
+
+// sortColumns names the columns a query orders by for each sort key
+type sortColumns struct {
+	Name, File, Line, Column string
+	// Score ranks the best matches first (ascending). Queries without a
+	// meaningful ranking leave it empty and fall back to their default order.
+	Score     string
+	ScoreArgs []interface{}
+	// Churn ranks the symbols changed by the most commits first; empty
+	// when the rows are not symbols
+	Churn string
+}
+
+// churnColumn is the stored commit count of the symbol whose ID is idColumn
+func churnColumn(idColumn string) string {
+	return "-COALESCE((SELECT commits FROM symbol_history h WHERE h.symbol_id = " + idColumn + "), 0)"
+}
+
+// symbolSortColumns sorts plain symbol queries by the symbol's own location
+func symbolSortColumns(prefix string) sortColumns {
+	return sortColumns{
+		Name:   prefix + "name",
+		File:   prefix + "file",
+		Line:   prefix + "line",
+		Column: prefix + "column",
+		Churn:  churnColumn(prefix + "id"),
+	}
+}
+
+// orderAndPage appends ORDER BY, LIMIT and OFFSET for opts, using defaultSort
+// when opts.Sort is empty. Every ordering ends on file, line and column so
+// pages are deterministic across calls.
+func orderAndPage(query string, args []interface{}, cols sortColumns, opts QueryOptions, defaultSort string) (string, []interface{}) {
+	sort := opts.Sort
+	if sort == "" || (sort == SortScore && cols.Score == "") || (sort == SortChurn && cols.Churn == "") {
+		sort = defaultSort
+	}
+
+	var order []string
+	switch sort {
+	case SortName:
+		order = []string{cols.Name, cols.File, cols.Line, cols.Column}
+	case SortLine:
+		order = []string{cols.Line, cols.File, cols.Column, cols.Name}
+	case SortScore:
+		order = []string{cols.Score, cols.Name, cols.File, cols.Line, cols.Column}
+		args = append(args, cols.ScoreArgs...)
+	case SortChurn:
+		order = []string{cols.Churn, cols.Name, cols.File, cols.Line, cols.Column}
+	default:
+		order = []string{cols.File, cols.Line, cols.Column, cols.Name}
+	}
+	query += " ORDER BY " + strings.Join(order, ", ")
+
+	if opts.Limit > 0 || opts.Offset > 0 {
+		limit := opts.Limit
+		if limit <= 0 {
+			limit = -1 // SQLite requires LIMIT before OFFSET; -1 means no limit
+		}
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, limit, opts.Offset)
+	}
+	return query, args
+}
+
+// placeholders returns "(?,?,...)" with n parameters.
+func placeholders(n int) string {
+	return "(?" + repeatString(",?", n-1) + ")"
+}
@@ -0,0 +1,36 @@
+package db
+
+import (
+	"database/sql"
+	"regexp"
+	"sync"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// driverName is the sqlite3 driver with codegraph's SQL functions registered.
+const driverName = "sqlite3_codegraph"
+
+func init() {
+	sql.Register(driverName, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			// Enables "x REGEXP pattern", which SQLite rewrites to regexp(pattern, x)
+			return conn.RegisterFunc("regexp", sqlRegexp, true)
+		},
+	})
+}
+
+// regexpCache holds compiled patterns so REGEXP does not recompile per row.
+var regexpCache sync.Map // map[string]*regexp.Regexp
+
+func sqlRegexp(pattern, value string) (bool, error) {
+	if re, ok := regexpCache.Load(pattern); ok {
+		return re.(*regexp.Regexp).MatchString(value), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return false, err
+	}
+	regexpCache.Store(pattern, re)
+	return re.MatchString(value), nil
+}
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+// Rename is a symbol an incremental build saw disappear while one of the
+// same kind and body appeared under another name or in another file
+type Rename struct {
+	ID        int64     `json:"id"`
+	OldID     string    `json:"old_id"`
+	NewID     string    `json:"new_id"`
+	OldName   string    `json:"old_name"`
+	NewName   string    `json:"new_name"`
+	Kind      string    `json:"kind"`
+	OldFile   string    `json:"old_file"`
+	NewFile   string    `json:"new_file"`
+	RenamedAt time.Time `json:"renamed_at"`
+}
+
+// InsertRename records a rename
+func (m *Manager) InsertRename(r *Rename) error {
+	_, err := m.exec(`
+		INSERT INTO renames (old_id, new_id, old_name, new_name, kind, old_file, new_file, renamed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.OldID, r.NewID, r.OldName, r.NewName, r.Kind, r.OldFile, r.NewFile, r.RenamedAt,
+	)
+	return err
+}
+
+// ListRenames returns every recorded rename, oldest first. Indexes and
+// snapshots built before renames were recorded have none.
+func (m *Manager) ListRenames() ([]Rename, error) {
+	if columns, err := m.tableColumns("renames"); err != nil || len(columns) == 0 {
+		return nil, err
+	}
+	rows, err := m.query(`
+		SELECT id, old_id, new_id, old_name, new_name, kind, old_file, new_file, renamed_at
+		FROM renames
+		ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var renames []Rename
+	for rows.Next() {
+		var r Rename
+		if err := rows.Scan(&r.ID, &r.OldID, &r.NewID, &r.OldName, &r.NewName, &r.Kind, &r.OldFile, &r.NewFile, &r.RenamedAt); err != nil {
+			return nil, err
+		}
+		renames = append(renames, r)
+	}
+	return renames, rows.Err()
+}
+
+// GetFileBodyHashes returns the body hash of each symbol of a file, by ID
+func (m *Manager) GetFileBodyHashes(file string) (map[string]string, error) {
+	rows, err := m.query("SELECT id, body_hash FROM symbols WHERE file = ?", file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read body hashes of %s: %w", file, err)
+	}
+	defer rows.Close()
+
+	hashes := make(map[string]string)
+	for rows.Next() {
+		var id, hash string
+		if err := rows.Scan(&id, &hash); err != nil {
+			return nil, err
+		}
+		hashes[id] = hash
+	}
+	return hashes, rows.Err()
+}
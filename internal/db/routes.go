@@ -0,0 +1,106 @@
+package db
+
+import "fmt"
+
+// RouteAnyMethod is the method of routes that accept every HTTP method
+const RouteAnyMethod = "ANY"
+
+// Route is an HTTP route registration
+type Route struct {
+	ID        int64
+	Method    string  // Upper-case HTTP method, or RouteAnyMethod
+	Path      string  // URL path pattern as written, e.g. /users/{id}
+	Handler   string  // Handler name as registered
+	HandlerID *string // Handler function, when it resolved to a symbol
+	Framework string  // e.g. net/http, gin, express, fastapi, spring
+	Language  string
+	File      string // Where the route is registered
+	Line      int
+	Column    int
+	// Handler declaration; empty when the handler did not resolve
+	HandlerFile string
+	HandlerLine int
+}
+
+// ClearRoutes removes every route before they are re-extracted
+func (m *Manager) ClearRoutes() error {
+	if _, err := m.db.Exec("DELETE FROM routes"); err != nil {
+		return fmt.Errorf("failed to clear routes: %w", err)
+	}
+	return nil
+}
+
+// InsertRoute records a route registration
+func (m *Manager) InsertRoute(r *Route) error {
+	_, err := m.db.Exec(`
+		INSERT INTO routes (method, path, handler, handler_id, framework, language, file, line, column)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.Method, r.Path, r.Handler, r.HandlerID, r.Framework, r.Language, r.File, r.Line, r.Column,
+	)
+	return err
+}
+
+// ListRoutes returns the routes accepting one of methods (any when empty;
+// ANY routes always match) whose path starts with pathPrefix, in
+// opts.Languages, ordered by file by default. Only the language and paging
+// fields of opts apply.
+func (m *Manager) ListRoutes(methods []string, pathPrefix string, opts QueryOptions) ([]Route, error) {
+	query := routeSelect + " WHERE instr(r.path, ?) = 1"
+	args := []interface{}{pathPrefix}
+	if len(methods) > 0 {
+		query += " AND (r.method = ? OR r.method IN " + placeholders(len(methods)) + ")"
+		args = append(args, RouteAnyMethod)
+		for _, method := range methods {
+			args = append(args, method)
+		}
+	}
+	query, args = applyQueryOptions(query, args, "r.", QueryOptions{Languages: opts.Languages})
+	query, args = orderAndPage(query, args, routeSortColumns, opts, SortFile)
+	return m.queryRoutes(query, args...)
+}
+
+// GetHandlerRoutes returns the routes whose handler is one of handlerIDs
+func (m *Manager) GetHandlerRoutes(handlerIDs []string) ([]Route, error) {
+	if len(handlerIDs) == 0 {
+		return nil, nil
+	}
+	query := routeSelect + " WHERE r.handler_id IN " + placeholders(len(handlerIDs)) +
+		" ORDER BY r.file, r.line, r.column"
+	return m.queryRoutes(query, repeatArgs(handlerIDs, 1)...)
+}
+
+const routeSelect = `
+		SELECT r.id, r.method, r.path, r.handler, r.handler_id, r.framework, r.language,
+		       r.file, r.line, r.column, COALESCE(s.file, ''), COALESCE(s.line, 0)
+		FROM routes r
+		LEFT JOIN symbols s ON s.id = r.handler_id`
+
+// routeSortColumns sorts routes by path, or by where they are registered
+var routeSortColumns = sortColumns{
+	Name:   "r.path",
+	File:   "r.file",
+	Line:   "r.line",
+	Column: "r.column",
+}
+
+func (m *Manager) queryRoutes(query string, args ...interface{}) ([]Route, error) {
+	rows, err := m.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var routes []Route
+	for rows.Next() {
+		var r Route
+		err := rows.Scan(
+			&r.ID, &r.Method, &r.Path, &r.Handler, &r.HandlerID, &r.Framework, &r.Language,
+			&r.File, &r.Line, &r.Column, &r.HandlerFile, &r.HandlerLine,
+		)
+		if err != nil {
+			return nil, err
+		}
+		routes = append(routes, r)
+	}
+	return routes, rows.Err()
+}
@@ -17,7 +17,13 @@ CREATE TABLE IF NOT EXISTS symbols (
     documentation TEXT,
     language TEXT NOT NULL,
     source TEXT DEFAULT 'lsp',
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    is_test INTEGER NOT NULL DEFAULT 0,
+    owners TEXT,
+    author TEXT,
+    entrypoint TEXT,
+    package TEXT NOT NULL DEFAULT '',
+    body_hash TEXT NOT NULL DEFAULT ''
 );`
 
 	CreateCallsTable = `
@@ -28,16 +34,72 @@ CREATE TABLE IF NOT EXISTS calls (
     file TEXT NOT NULL,
     line INTEGER NOT NULL,
     column INTEGER NOT NULL,
+    arg_count INTEGER,
+    args TEXT,
+    context TEXT,
     FOREIGN KEY(caller_id) REFERENCES symbols(id),
     FOREIGN KEY(callee_id) REFERENCES symbols(id)
 );`
 
+	// Calls whose callee is not an indexed symbol (standard library and
+	// third-party functions, names that did not resolve), by the name
+	// called and, for Go, the package it is qualified with (fmt for
+	// fmt.Println). resolved is set once a later pass links the call, to the
+	// dependency symbol external_id.
+	CreateExternalCallsTable = `
+CREATE TABLE IF NOT EXISTS external_calls (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    caller_id TEXT NOT NULL,
+    callee_name TEXT NOT NULL,
+    package TEXT NOT NULL DEFAULT '',
+    file TEXT NOT NULL,
+    line INTEGER NOT NULL,
+    column INTEGER NOT NULL,
+    arg_count INTEGER,
+    args TEXT,
+    context TEXT,
+    resolved INTEGER NOT NULL DEFAULT 0,
+    external_id TEXT,
+    FOREIGN KEY(caller_id) REFERENCES symbols(id)
+);`
+
+	// Declarations of the project's dependencies (Go modules, npm type
+	// definitions, Python stubs), indexed apart from symbols so they only
+	// give external calls a target. module is the dependency, with its
+	// version when known: golang.org/x/text@v0.14.0, react@18.2.0.
+	CreateExternalSymbolsTable = `
+CREATE TABLE IF NOT EXISTS external_symbols (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    file TEXT NOT NULL,
+    line INTEGER NOT NULL,
+    signature TEXT NOT NULL DEFAULT '',
+    documentation TEXT NOT NULL DEFAULT '',
+    language TEXT NOT NULL,
+    package TEXT NOT NULL DEFAULT '',
+    module TEXT NOT NULL
+);`
+
 	CreateTypeHierarchyTable = `
 CREATE TABLE IF NOT EXISTS type_hierarchy (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     child_id TEXT NOT NULL,
     parent_id TEXT NOT NULL,
     relationship TEXT NOT NULL,
+    type_args TEXT,
+    source TEXT,
+    FOREIGN KEY(child_id) REFERENCES symbols(id),
+    FOREIGN KEY(parent_id) REFERENCES symbols(id)
+);`
+
+	// Containment: methods and fields in their class, nested types in their
+	// parent, declarations in their module. Top-level symbols have no row;
+	// their container is the file recorded on the symbol itself.
+	CreateContainsTable = `
+CREATE TABLE IF NOT EXISTS contains (
+    child_id TEXT PRIMARY KEY,
+    parent_id TEXT NOT NULL,
     FOREIGN KEY(child_id) REFERENCES symbols(id),
     FOREIGN KEY(parent_id) REFERENCES symbols(id)
 );`
@@ -46,7 +108,161 @@ CREATE TABLE IF NOT EXISTS type_hierarchy (
 CREATE TABLE IF NOT EXISTS file_meta (
     path TEXT PRIMARY KEY,
     mod_time TIMESTAMP NOT NULL,
-    language TEXT NOT NULL
+    language TEXT NOT NULL,
+    source TEXT
+);`
+
+	// Embeddings for semantic search; vector is little-endian float32.
+	// text_hash detects symbols whose embedded text changed since last build.
+	CreateEmbeddingsTable = `
+CREATE TABLE IF NOT EXISTS embeddings (
+    symbol_id TEXT PRIMARY KEY,
+    model TEXT NOT NULL,
+    text_hash TEXT NOT NULL,
+    vector BLOB NOT NULL
+);`
+
+	// Goroutine launches, channel operations and mutex operations, by the
+	// function they occur in. target_id is the function a goroutine runs,
+	// when it is indexed.
+	CreateConcurrencyTable = `
+CREATE TABLE IF NOT EXISTS concurrency (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    symbol_id TEXT NOT NULL,
+    op TEXT NOT NULL,
+    target TEXT NOT NULL,
+    target_id TEXT,
+    file TEXT NOT NULL,
+    line INTEGER NOT NULL,
+    column INTEGER NOT NULL,
+    FOREIGN KEY(symbol_id) REFERENCES symbols(id)
+);`
+
+	// HTTP routes: a method and URL path registered to a handler.
+	// handler_id is the handler function, when it resolved to a symbol.
+	CreateRoutesTable = `
+CREATE TABLE IF NOT EXISTS routes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    method TEXT NOT NULL,
+    path TEXT NOT NULL,
+    handler TEXT NOT NULL,
+    handler_id TEXT,
+    framework TEXT NOT NULL,
+    language TEXT NOT NULL,
+    file TEXT NOT NULL,
+    line INTEGER NOT NULL,
+    column INTEGER NOT NULL
+);`
+
+	// UI components (React, Vue) by their symbol
+	CreateComponentsTable = `
+CREATE TABLE IF NOT EXISTS components (
+    symbol_id TEXT PRIMARY KEY,
+    framework TEXT NOT NULL
+);`
+
+	// JSX elements a component renders: <Child /> inside parent_id.
+	// child_id is the child component, when it resolved to one.
+	CreateRendersTable = `
+CREATE TABLE IF NOT EXISTS renders (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    parent_id TEXT NOT NULL,
+    child TEXT NOT NULL,
+    child_id TEXT,
+    file TEXT NOT NULL,
+    line INTEGER NOT NULL,
+    column INTEGER NOT NULL
+);`
+
+	// Annotations, decorators and attributes on symbols (@Deprecated,
+	// @app.route, #[test]). name is the annotation as written, tag its
+	// lower-case last segment; args are the arguments' source text.
+	CreateTagsTable = `
+CREATE TABLE IF NOT EXISTS tags (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    symbol_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    tag TEXT NOT NULL,
+    args TEXT NOT NULL DEFAULT '',
+    file TEXT NOT NULL,
+    line INTEGER NOT NULL
+);`
+
+	// Disagreements between the language server and tree-sitter about the
+	// symbols of a file that was re-indexed by the other extractor. lsp and
+	// tree_sitter hold each one's value of field ('' when it did not find
+	// the symbol); kept is the extractor whose symbols the index keeps.
+	CreateSymbolConflictsTable = `
+CREATE TABLE IF NOT EXISTS symbol_conflicts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    file TEXT NOT NULL,
+    symbol_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    field TEXT NOT NULL,
+    lsp TEXT NOT NULL,
+    tree_sitter TEXT NOT NULL,
+    kept TEXT NOT NULL,
+    found_at TIMESTAMP NOT NULL
+);`
+
+	// Names a file imports or exports under another name: import x as y,
+	// export { a as b }, Go's import m "path/mathutil". alias is the name
+	// used, name the one it stands for and module where it comes from, as
+	// written; kind is import or export.
+	CreateAliasesTable = `
+CREATE TABLE IF NOT EXISTS aliases (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    file TEXT NOT NULL,
+    alias TEXT NOT NULL,
+    name TEXT NOT NULL,
+    module TEXT NOT NULL DEFAULT '',
+    kind TEXT NOT NULL,
+    line INTEGER NOT NULL
+);`
+
+	// Symbols an incremental build saw disappear while one of the same
+	// kind and body (apart from the name) appeared: old_* is the symbol
+	// before, new_* after. Renames of renames chain through the IDs.
+	CreateRenamesTable = `
+CREATE TABLE IF NOT EXISTS renames (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    old_id TEXT NOT NULL,
+    new_id TEXT NOT NULL,
+    old_name TEXT NOT NULL,
+    new_name TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    old_file TEXT NOT NULL,
+    new_file TEXT NOT NULL,
+    renamed_at TIMESTAMP NOT NULL
+);`
+
+	// What git says about the lines of a symbol, stored the first time it
+	// is asked for (codegraph blame) and valid while head and the lines
+	// are the same. commits is the symbol's churn; authors are separated
+	// by newlines, most commits first.
+	CreateSymbolHistoryTable = `
+CREATE TABLE IF NOT EXISTS symbol_history (
+    symbol_id TEXT PRIMARY KEY,
+    file TEXT NOT NULL,
+    start_line INTEGER NOT NULL,
+    end_line INTEGER NOT NULL,
+    head TEXT NOT NULL,
+    introduced_commit TEXT NOT NULL,
+    introduced_author TEXT NOT NULL,
+    introduced_at TIMESTAMP NOT NULL,
+    modified_commit TEXT NOT NULL,
+    modified_author TEXT NOT NULL,
+    modified_at TIMESTAMP NOT NULL,
+    commits INTEGER NOT NULL,
+    authors TEXT NOT NULL
+);`
+
+	// Facts about how the index was built: the project root its file paths
+	// are under and, for a partial build, its shard (MetaRoot, MetaShard)
+	CreateIndexMetaTable = `
+CREATE TABLE IF NOT EXISTS index_meta (
+    key TEXT PRIMARY KEY,
+    value TEXT NOT NULL
 );`
 
 	// Indexes for faster queries
@@ -59,6 +275,22 @@ CREATE INDEX IF NOT EXISTS idx_calls_caller ON calls(caller_id);
 CREATE INDEX IF NOT EXISTS idx_calls_callee ON calls(callee_id);
 CREATE INDEX IF NOT EXISTS idx_type_hierarchy_child ON type_hierarchy(child_id);
 CREATE INDEX IF NOT EXISTS idx_type_hierarchy_parent ON type_hierarchy(parent_id);
+CREATE INDEX IF NOT EXISTS idx_contains_parent ON contains(parent_id);
+CREATE INDEX IF NOT EXISTS idx_concurrency_symbol ON concurrency(symbol_id);
+CREATE INDEX IF NOT EXISTS idx_routes_handler ON routes(handler_id);
+CREATE INDEX IF NOT EXISTS idx_renders_parent ON renders(parent_id);
+CREATE INDEX IF NOT EXISTS idx_renders_child ON renders(child_id);
+CREATE INDEX IF NOT EXISTS idx_tags_symbol ON tags(symbol_id);
+CREATE INDEX IF NOT EXISTS idx_tags_tag ON tags(tag);
+CREATE INDEX IF NOT EXISTS idx_symbol_conflicts_file ON symbol_conflicts(file);
+CREATE INDEX IF NOT EXISTS idx_aliases_file ON aliases(file);
+CREATE INDEX IF NOT EXISTS idx_aliases_alias ON aliases(alias);
+CREATE INDEX IF NOT EXISTS idx_external_calls_caller ON external_calls(caller_id);
+CREATE INDEX IF NOT EXISTS idx_external_calls_file ON external_calls(file);
+CREATE INDEX IF NOT EXISTS idx_external_calls_name ON external_calls(callee_name);
+CREATE INDEX IF NOT EXISTS idx_external_symbols_name ON external_symbols(name);
+CREATE INDEX IF NOT EXISTS idx_external_symbols_module ON external_symbols(module);
+CREATE INDEX IF NOT EXISTS idx_renames_new ON renames(new_id);
 `
 )
 
@@ -67,8 +299,55 @@ func AllSchemaStatements() []string {
 	return []string{
 		CreateSymbolsTable,
 		CreateCallsTable,
+		CreateExternalCallsTable,
+		CreateExternalSymbolsTable,
 		CreateTypeHierarchyTable,
+		CreateContainsTable,
 		CreateFileMetaTable,
+		CreateEmbeddingsTable,
+		CreateConcurrencyTable,
+		CreateRoutesTable,
+		CreateComponentsTable,
+		CreateRendersTable,
+		CreateTagsTable,
+		CreateSymbolConflictsTable,
+		CreateAliasesTable,
+		CreateRenamesTable,
+		CreateSymbolHistoryTable,
+		CreateIndexMetaTable,
 		CreateIndexes,
 	}
 }
+
+// SchemaVersion is recorded in PRAGMA user_version. Version 1 changed the
+// symbol ID format to the full scope chain plus a line suffix on clashes
+// ("a.py#Service.fetch.parse", "a.py#parse@42"); older indexes are cleared
+// so the next build re-indexes every file with the new IDs.
+const SchemaVersion = 1
+
+// IndexTables hold the indexed data, in an order that respects foreign keys
+var IndexTables = []string{"calls", "external_calls", "external_symbols", "type_hierarchy", "contains", "embeddings", "concurrency", "routes", "components", "renders", "tags", "symbol_conflicts", "aliases", "renames", "symbol_history", "symbols", "file_meta", "index_meta"}
+
+// columnMigration adds a column introduced after a table was first created
+type columnMigration struct {
+	Table, Column, Definition string
+}
+
+// ColumnMigrations bring databases built by older versions up to date;
+// new databases get these columns from the CREATE TABLE statements
+var ColumnMigrations = []columnMigration{
+	{"calls", "arg_count", "INTEGER"},
+	{"calls", "args", "TEXT"},
+	{"calls", "context", "TEXT"},
+	{"symbols", "is_test", "INTEGER NOT NULL DEFAULT 0"},
+	{"symbols", "owners", "TEXT"},
+	{"symbols", "author", "TEXT"},
+	{"symbols", "entrypoint", "TEXT"},
+	{"file_meta", "source", "TEXT"},
+	{"type_hierarchy", "type_args", "TEXT"},
+	{"symbols", "package", "TEXT NOT NULL DEFAULT ''"},
+	{"external_calls", "package", "TEXT NOT NULL DEFAULT ''"},
+	{"external_calls", "external_id", "TEXT"},
+	{"symbols", "body_hash", "TEXT NOT NULL DEFAULT ''"},
+	{"type_hierarchy", "source", "TEXT"},
+}
@@ -0,0 +1,129 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// SnapshotTo writes a consistent copy of the database to path, which must
+// not exist yet
+func (m *Manager) SnapshotTo(path string) error {
+	if _, err := m.db.Exec("VACUUM INTO ?", path); err != nil {
+		return fmt.Errorf("failed to snapshot database: %w", err)
+	}
+	return nil
+}
+
+// FileSchemaVersion returns the SchemaVersion recorded in the database at
+// path without opening it as a Manager, which would refuse an older index
+func FileSchemaVersion(path string) (int, error) {
+	conn, err := sql.Open(driverName, "file:"+path+"?mode=ro")
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	var version int
+	if err := conn.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// NewSharedManager opens the read-only index at path for the project at
+// root. An index built in another checkout records that checkout's file
+// paths, so it is opened through a copy at cachePath rebased onto root,
+// which is made again whenever the index at path changes.
+func NewSharedManager(path, root, cachePath string) (*Manager, error) {
+	shared, err := NewReadOnlyManager(path)
+	if err != nil {
+		return nil, err
+	}
+	oldRoot, err := shared.GetMeta(MetaRoot)
+	if err != nil || oldRoot == "" || oldRoot == root {
+		return shared, err
+	}
+
+	if cached, ok := openRebasedCopy(path, root, cachePath); ok {
+		shared.Close()
+		return cached, nil
+	}
+	tmp := cachePath + ".rebase"
+	os.Remove(tmp)
+	defer os.Remove(tmp)
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err != nil {
+		shared.Close()
+		return nil, err
+	}
+	err = shared.SnapshotTo(tmp)
+	shared.Close()
+	if err != nil {
+		return nil, err
+	}
+	copied, err := NewManager(tmp)
+	if err != nil {
+		return nil, err
+	}
+	err = copied.RebaseFiles(oldRoot, root)
+	if closeErr := copied.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp, cachePath); err != nil {
+		return nil, err
+	}
+	return NewReadOnlyManager(cachePath)
+}
+
+// openRebasedCopy opens the copy of the index at path that NewSharedManager
+// left at cachePath, if it is rebased onto root and newer than the index
+func openRebasedCopy(path, root, cachePath string) (*Manager, bool) {
+	source, err := os.Stat(path)
+	if err != nil {
+		return nil, false
+	}
+	cache, err := os.Stat(cachePath)
+	if err != nil || cache.ModTime().Before(source.ModTime()) {
+		return nil, false
+	}
+	cached, err := NewReadOnlyManager(cachePath)
+	if err != nil {
+		return nil, false
+	}
+	if cachedRoot, err := cached.GetMeta(MetaRoot); err != nil || cachedRoot != root {
+		cached.Close()
+		return nil, false
+	}
+	return cached, true
+}
+
+// RebaseFiles rewrites the absolute file paths recorded under oldRoot to
+// the same paths under newRoot, for an index built in another checkout
+func (m *Manager) RebaseFiles(oldRoot, newRoot string) error {
+	if oldRoot == newRoot {
+		return nil
+	}
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, col := range [][2]string{{"symbols", "file"}, {"calls", "file"}, {"external_calls", "file"}, {"concurrency", "file"}, {"routes", "file"}, {"renders", "file"}, {"tags", "file"}, {"aliases", "file"}, {"renames", "old_file"}, {"renames", "new_file"}, {"symbol_history", "file"}, {"file_meta", "path"}} {
+		// Offsets are in bytes, so compare and cut the paths as blobs
+		stmt := fmt.Sprintf(`UPDATE %[1]s SET %[2]s = ? || CAST(substr(CAST(%[2]s AS BLOB), ?) AS TEXT)
+			WHERE %[2]s = ? OR substr(CAST(%[2]s AS BLOB), 1, ?) = CAST(? AS BLOB)`, col[0], col[1])
+		prefix := oldRoot + string(filepath.Separator)
+		if _, err := tx.Exec(stmt, newRoot, len(oldRoot)+1, oldRoot, len(prefix), prefix); err != nil {
+			return fmt.Errorf("failed to rebase %s.%s: %w", col[0], col[1], err)
+		}
+	}
+	if _, err := tx.Exec("UPDATE index_meta SET value = ? WHERE key = ? AND value = ?", newRoot, MetaRoot, oldRoot); err != nil {
+		return fmt.Errorf("failed to rebase the index root: %w", err)
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,47 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewSharedManagerRebasesACopy(t *testing.T) {
+	m, path := newTestManager(t)
+	ciRoot := "/ci/checkout"
+	sym := &Symbol{ID: "a.go#f", Name: "f", Kind: "function", Language: "go", File: ciRoot + "/a.go", Line: 1, CreatedAt: time.Unix(0, 0)}
+	if err := m.InsertSymbol(sym); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetMeta(MetaRoot, ciRoot); err != nil {
+		t.Fatal(err)
+	}
+	m.Close()
+
+	root := filepath.Join(t.TempDir(), "project")
+	cache := filepath.Join(root, ".codegraph", "graphs", "shared.db")
+	for range 2 { // Made, then reused
+		shared, err := NewSharedManager(path, root, cache)
+		if err != nil {
+			t.Fatalf("NewSharedManager: %v", err)
+		}
+		syms, err := shared.ListSymbols(QueryOptions{})
+		shared.Close()
+		if err != nil || len(syms) != 1 || syms[0].File != filepath.Join(root, "a.go") {
+			t.Fatalf("shared symbols = %+v, %v; want a.go under %s", syms, err, root)
+		}
+	}
+	if _, err := os.Stat(cache); err != nil {
+		t.Errorf("rebased copy: %v", err)
+	}
+
+	source, err := NewReadOnlyManager(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer source.Close()
+	if got, _ := source.GetMeta(MetaRoot); got != ciRoot {
+		t.Errorf("shared index root = %q, want it left at %q", got, ciRoot)
+	}
+}
@@ -0,0 +1,153 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// QueryView is a view offered to ad-hoc SQL
+type QueryView struct {
+	Name        string
+	Description string
+	SQL         string
+}
+
+// QueryViews join the edge tables with the names of both ends, so ad-hoc
+// queries read like the CLI's output. They are temporary views of the
+// query's connection, which read-only and older indexes support alike.
+var QueryViews = []QueryView{
+	{
+		Name:        "v_calls_named",
+		Description: "Call sites with caller and callee names, kinds and files",
+		SQL: `
+			SELECT c.caller_id, s.name AS caller_name, s.kind AS caller_kind, s.file AS caller_file,
+			       c.callee_id, e.name AS callee_name, e.kind AS callee_kind, e.file AS callee_file,
+			       c.file, c.line, c.column, c.arg_count, c.args
+			FROM main.calls c
+			JOIN main.symbols s ON s.id = c.caller_id
+			JOIN main.symbols e ON e.id = c.callee_id`,
+	},
+	{
+		Name:        "v_hierarchy_named",
+		Description: "Type hierarchy edges with child and parent names, kinds and files",
+		SQL: `
+			SELECT h.child_id, c.name AS child_name, c.kind AS child_kind, c.file AS child_file,
+			       h.parent_id, p.name AS parent_name, p.kind AS parent_kind, p.file AS parent_file,
+			       h.relationship, h.type_args
+			FROM main.type_hierarchy h
+			JOIN main.symbols c ON c.id = h.child_id
+			JOIN main.symbols p ON p.id = h.parent_id`,
+	},
+	{
+		Name:        "v_contains_named",
+		Description: "Members with the names and kinds of their containers",
+		SQL: `
+			SELECT n.child_id, c.name AS child_name, c.kind AS child_kind,
+			       n.parent_id, p.name AS parent_name, p.kind AS parent_kind, c.file
+			FROM main.contains n
+			JOIN main.symbols c ON c.id = n.child_id
+			JOIN main.symbols p ON p.id = n.parent_id`,
+	},
+}
+
+// QueryResult is the outcome of RunQuery
+type QueryResult struct {
+	Columns   []string
+	Rows      [][]interface{} // Text as string, blobs as []byte, NULL as nil
+	Truncated bool            // More rows than the limit
+}
+
+// readOnlyPragmas may take an argument in ad-hoc queries; they only
+// describe the schema
+var readOnlyPragmas = map[string]bool{
+	"table_info": true, "table_xinfo": true, "table_list": true, "index_list": true,
+	"index_info": true, "index_xinfo": true, "foreign_key_list": true,
+}
+
+// RunQuery runs query, SQL written by the user, without letting it change
+// the index: its connection has PRAGMA query_only set, and ATTACH and
+// pragmas that set values are refused. The QueryViews are available to
+// it, and args bind its parameters. It returns up to limit rows (0 for
+// all).
+func (m *Manager) RunQuery(ctx context.Context, query string, limit int, args ...interface{}) (*QueryResult, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	for _, v := range QueryViews {
+		stmt := fmt.Sprintf("CREATE TEMP VIEW IF NOT EXISTS %s AS %s", v.Name, v.SQL)
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return nil, fmt.Errorf("failed to create view %s: %w", v.Name, err)
+		}
+	}
+	if _, err := conn.ExecContext(ctx, "PRAGMA query_only = ON"); err != nil {
+		return nil, err
+	}
+	setAuthorizer := func(auth func(int, string, string, string) int) error {
+		return conn.Raw(func(c interface{}) error {
+			sc, ok := c.(*sqlite3.SQLiteConn)
+			if !ok {
+				return driver.ErrBadConn
+			}
+			sc.RegisterAuthorizer(auth)
+			return nil
+		})
+	}
+	if err := setAuthorizer(queryAuthorizer); err != nil {
+		return nil, err
+	}
+	// The connection goes back to the pool writable
+	defer func() {
+		_ = setAuthorizer(nil)
+		_, _ = conn.ExecContext(context.Background(), "PRAGMA query_only = OFF")
+	}()
+
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	result := &QueryResult{Columns: columns, Rows: [][]interface{}{}}
+	for rows.Next() {
+		if limit > 0 && len(result.Rows) == limit {
+			result.Truncated = true
+			break
+		}
+		values := make([]interface{}, len(columns))
+		dest := make([]interface{}, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		result.Rows = append(result.Rows, values)
+	}
+	return result, rows.Err()
+}
+
+// queryAuthorizer refuses what PRAGMA query_only does not: attaching
+// databases, which creates missing files, and pragmas that set values,
+// which could turn query_only off
+func queryAuthorizer(action int, arg1, arg2, _ string) int {
+	switch action {
+	case sqlite3.SQLITE_ATTACH, sqlite3.SQLITE_DETACH:
+		return sqlite3.SQLITE_DENY
+	case sqlite3.SQLITE_PRAGMA:
+		if arg2 != "" && !readOnlyPragmas[strings.ToLower(arg1)] {
+			return sqlite3.SQLITE_DENY
+		}
+	}
+	return sqlite3.SQLITE_OK
+}
@@ -0,0 +1,108 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"sync"
+)
+
+// maxCachedStatements bounds the statement cache; queries beyond it are
+// run unprepared, so one-off shapes cannot grow it without limit
+const maxCachedStatements = 256
+
+// stmtCache holds the prepared statements of a Manager keyed by their SQL,
+// which is the shape of a query: values are always bound as parameters.
+// database/sql re-prepares a statement on each pooled connection it runs on.
+type stmtCache struct {
+	mu    sync.Mutex
+	stmts map[string]*sql.Stmt
+}
+
+// prepare returns the cached statement for query, preparing it on first
+// use. It returns nil once the cache is full.
+func (m *Manager) prepare(query string) (*sql.Stmt, error) {
+	c := &m.stmts
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if stmt, ok := c.stmts[query]; ok {
+		return stmt, nil
+	}
+	if len(c.stmts) >= maxCachedStatements {
+		return nil, nil
+	}
+	stmt, err := m.db.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	if c.stmts == nil {
+		c.stmts = make(map[string]*sql.Stmt)
+	}
+	c.stmts[query] = stmt
+	return stmt, nil
+}
+
+// query runs a SELECT through the statement cache
+func (m *Manager) query(query string, args ...interface{}) (*sql.Rows, error) {
+	stmt, err := m.prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	if stmt == nil {
+		return m.db.Query(query, args...)
+	}
+	return stmt.Query(args...)
+}
+
+// queryRow runs a single-row SELECT through the statement cache
+func (m *Manager) queryRow(query string, args ...interface{}) *sql.Row {
+	stmt, err := m.prepare(query)
+	if err != nil || stmt == nil {
+		return m.db.QueryRow(query, args...) // Reports the same error on Scan
+	}
+	return stmt.QueryRow(args...)
+}
+
+// exec runs a write through the statement cache
+func (m *Manager) exec(query string, args ...interface{}) (sql.Result, error) {
+	stmt, err := m.prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	if stmt == nil {
+		return m.db.Exec(query, args...)
+	}
+	return stmt.Exec(args...)
+}
+
+// txExec runs a write in tx through the statement cache
+func (m *Manager) txExec(tx *sql.Tx, query string, args ...interface{}) error {
+	stmt, err := m.prepare(query)
+	if err != nil {
+		return err
+	}
+	if stmt == nil {
+		_, err = tx.Exec(query, args...)
+	} else {
+		_, err = tx.Stmt(stmt).Exec(args...)
+	}
+	return err
+}
+
+// closeStatements closes every cached statement
+func (m *Manager) closeStatements() {
+	c := &m.stmts
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, stmt := range c.stmts {
+		stmt.Close()
+	}
+	c.stmts = nil
+}
+
+// inList returns an IN operand matching any of values, bound as a single
+// JSON array parameter, so the query has the same shape (and cached
+// statement) whatever the number of values
+func inList(values []string) (string, interface{}) {
+	data, _ := json.Marshal(values) // Strings always marshal
+	return "(SELECT value FROM json_each(?))", string(data)
+}
@@ -0,0 +1,90 @@
+package db
+
+import "fmt"
+
+// TagDeprecated is the tag of every deprecation marker: @Deprecated,
+// #[deprecated], [Obsolete], @available(*, deprecated)
+const TagDeprecated = "deprecated"
+
+// Tag is an annotation, decorator or attribute on a symbol
+type Tag struct {
+	ID       int64
+	SymbolID string
+	Name     string // As written, without @ or brackets: app.route, tokio::test
+	Tag      string // Lower-case last segment of Name: route, test
+	Args     string // Source text of the arguments, without parentheses
+	File     string
+	Line     int
+}
+
+// ClearTags deletes the tags in files before they are re-extracted
+func (m *Manager) ClearTags(files []string) error {
+	if len(files) == 0 {
+		return nil
+	}
+	in := placeholders(len(files))
+	query := `
+		DELETE FROM tags
+		WHERE file IN ` + in + `
+		   OR symbol_id IN (SELECT id FROM symbols WHERE file IN ` + in + `)`
+
+	if _, err := m.db.Exec(query, repeatArgs(files, 2)...); err != nil {
+		return fmt.Errorf("failed to clear tags: %w", err)
+	}
+	return nil
+}
+
+// InsertTag records an annotation on a symbol
+func (m *Manager) InsertTag(t *Tag) error {
+	_, err := m.exec(`
+		INSERT INTO tags (symbol_id, name, tag, args, file, line)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		t.SymbolID, t.Name, t.Tag, t.Args, t.File, t.Line,
+	)
+	return err
+}
+
+// GetSymbolTags returns the tags of symbolIDs by symbol, in source order
+func (m *Manager) GetSymbolTags(symbolIDs []string) (map[string][]Tag, error) {
+	tags := make(map[string][]Tag)
+	if len(symbolIDs) == 0 {
+		return tags, nil
+	}
+	rows, err := m.db.Query(`
+		SELECT id, symbol_id, name, tag, args, file, line
+		FROM tags
+		WHERE symbol_id IN `+placeholders(len(symbolIDs))+`
+		ORDER BY file, line, id`, repeatArgs(symbolIDs, 1)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t Tag
+		if err := rows.Scan(&t.ID, &t.SymbolID, &t.Name, &t.Tag, &t.Args, &t.File, &t.Line); err != nil {
+			return nil, err
+		}
+		tags[t.SymbolID] = append(tags[t.SymbolID], t)
+	}
+	return tags, rows.Err()
+}
+
+// ListTags returns the distinct tags in the index, for completion
+func (m *Manager) ListTags() ([]string, error) {
+	rows, err := m.db.Query("SELECT DISTINCT tag FROM tags ORDER BY tag")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []string
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
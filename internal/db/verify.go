@@ -0,0 +1,139 @@
+package db
+
+import "fmt"
+
+// IntegrityCheck is one referential check of the index and the number of
+// rows that fail it
+type IntegrityCheck struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Count       int    `json:"count"`
+}
+
+// danglingCheck finds rows of table that reference symbols which no
+// longer exist; where selects them
+type danglingCheck struct {
+	name, description, table, where string
+}
+
+var danglingChecks = []danglingCheck{
+	{"calls_missing_symbol", "calls whose caller or callee symbol is missing", "calls",
+		"caller_id NOT IN (SELECT id FROM symbols) OR callee_id NOT IN (SELECT id FROM symbols)"},
+	{"external_calls_missing_symbol", "external calls whose caller symbol is missing", "external_calls",
+		"caller_id NOT IN (SELECT id FROM symbols)"},
+	{"hierarchy_missing_symbol", "type relations whose child or parent symbol is missing", "type_hierarchy",
+		"child_id NOT IN (SELECT id FROM symbols) OR parent_id NOT IN (SELECT id FROM symbols)"},
+	{"contains_missing_symbol", "containment rows whose member or container symbol is missing", "contains",
+		"child_id NOT IN (SELECT id FROM symbols) OR parent_id NOT IN (SELECT id FROM symbols)"},
+	{"embeddings_missing_symbol", "embeddings of missing symbols", "embeddings",
+		"symbol_id NOT IN (SELECT id FROM symbols)"},
+	{"concurrency_missing_symbol", "concurrency sites in missing functions", "concurrency",
+		"symbol_id NOT IN (SELECT id FROM symbols)"},
+	{"routes_missing_handler", "routes whose handler symbol is missing", "routes",
+		"handler_id IS NOT NULL AND handler_id NOT IN (SELECT id FROM symbols)"},
+	{"components_missing_symbol", "components whose symbol is missing", "components",
+		"symbol_id NOT IN (SELECT id FROM symbols)"},
+	{"renders_missing_symbol", "render edges whose parent or child component is missing", "renders",
+		"parent_id NOT IN (SELECT id FROM symbols) OR (child_id IS NOT NULL AND child_id NOT IN (SELECT id FROM symbols))"},
+	{"tags_missing_symbol", "annotation tags of missing symbols", "tags",
+		"symbol_id NOT IN (SELECT id FROM symbols)"},
+	{"history_missing_symbol", "git histories of missing symbols", "symbol_history",
+		"symbol_id NOT IN (SELECT id FROM symbols)"},
+}
+
+// CheckIntegrity counts the rows that reference missing symbols
+func (m *Manager) CheckIntegrity() ([]IntegrityCheck, error) {
+	checks := make([]IntegrityCheck, 0, len(danglingChecks))
+	for _, c := range danglingChecks {
+		var count int
+		query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", c.table, c.where)
+		if err := m.db.QueryRow(query).Scan(&count); err != nil {
+			return nil, fmt.Errorf("failed to check %s: %w", c.name, err)
+		}
+		checks = append(checks, IntegrityCheck{Name: c.name, Description: c.description, Count: count})
+	}
+	return checks, nil
+}
+
+// RepairIntegrity deletes the rows that reference missing symbols and
+// returns how many were deleted
+func (m *Manager) RepairIntegrity() (int64, error) {
+	var deleted int64
+	for _, c := range danglingChecks {
+		result, err := m.db.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s", c.table, c.where))
+		if err != nil {
+			return deleted, fmt.Errorf("failed to repair %s: %w", c.name, err)
+		}
+		n, _ := result.RowsAffected()
+		deleted += n
+	}
+	return deleted, nil
+}
+
+// ListIndexedFiles returns every file the index knows about, from build
+// metadata and from stored symbols
+func (m *Manager) ListIndexedFiles() ([]string, error) {
+	rows, err := m.db.Query(`
+		SELECT path FROM file_meta
+		UNION
+		SELECT DISTINCT file FROM symbols
+		ORDER BY 1`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var files []string
+	for rows.Next() {
+		var f string
+		if err := rows.Scan(&f); err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return files, rows.Err()
+}
+
+// DeleteFiles removes files from the index: their symbols, every call,
+// type relation, containment row, and embedding touching those symbols,
+// and their build metadata
+func (m *Manager) DeleteFiles(files []string) error {
+	if len(files) == 0 {
+		return nil
+	}
+	in := placeholders(len(files))
+	fileSymbols := "(SELECT id FROM symbols WHERE file IN " + in + ")"
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	statements := []struct {
+		query string
+		sets  int // how many times the file list is bound
+	}{
+		{"DELETE FROM calls WHERE file IN " + in + " OR caller_id IN " + fileSymbols + " OR callee_id IN " + fileSymbols, 3},
+		{"DELETE FROM external_calls WHERE file IN " + in + " OR caller_id IN " + fileSymbols, 2},
+		{"DELETE FROM type_hierarchy WHERE child_id IN " + fileSymbols + " OR parent_id IN " + fileSymbols, 2},
+		{"DELETE FROM contains WHERE child_id IN " + fileSymbols + " OR parent_id IN " + fileSymbols, 2},
+		{"DELETE FROM embeddings WHERE symbol_id IN " + fileSymbols, 1},
+		{"DELETE FROM concurrency WHERE file IN " + in + " OR symbol_id IN " + fileSymbols, 2},
+		{"DELETE FROM routes WHERE file IN " + in + " OR handler_id IN " + fileSymbols, 2},
+		{"DELETE FROM components WHERE symbol_id IN " + fileSymbols, 1},
+		{"DELETE FROM renders WHERE file IN " + in + " OR parent_id IN " + fileSymbols + " OR child_id IN " + fileSymbols, 3},
+		{"DELETE FROM tags WHERE file IN " + in + " OR symbol_id IN " + fileSymbols, 2},
+		{"DELETE FROM symbol_conflicts WHERE file IN " + in, 1},
+		{"DELETE FROM aliases WHERE file IN " + in, 1},
+		{"DELETE FROM symbol_history WHERE file IN " + in, 1},
+		{"DELETE FROM symbols WHERE file IN " + in, 1},
+		{"DELETE FROM file_meta WHERE path IN " + in, 1},
+	}
+	for _, s := range statements {
+		if _, err := tx.Exec(s.query, repeatArgs(files, s.sets)...); err != nil {
+			return fmt.Errorf("failed to delete files: %w", err)
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,141 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// maxBatchFiles bounds how many files a Writer commits in one transaction
+const maxBatchFiles = 128
+
+// commitBatch commits a batch of files; tests replace it
+var commitBatch = (*Writer).commit
+
+// FileWrite is everything an extractor stores for one file
+type FileWrite struct {
+	Path     string
+	Language string
+	Source   string // Extractor, recorded in file_meta
+	Symbols  []*Symbol
+	Contains []*Containment
+	ModTime  time.Time
+}
+
+// Writer stores files on a single goroutine, so the indexer can parse the
+// next file while the previous ones are written. Writes queued while one
+// is committed are batched into the next transaction. Queued writes are
+// not visible to queries until Flush returns.
+type Writer struct {
+	m     *Manager
+	queue chan writerJob
+	done  chan struct{}
+	err   error // First write error; read only after done or a flush
+}
+
+// writerJob is a file to write, or a flush request when flushed is set
+type writerJob struct {
+	file    *FileWrite
+	flushed chan struct{}
+}
+
+// NewWriter starts a writer with room for buffer queued files. It must be
+// closed to write the last files.
+func (m *Manager) NewWriter(buffer int) *Writer {
+	w := &Writer{m: m, queue: make(chan writerJob, buffer), done: make(chan struct{})}
+	go w.run()
+	return w
+}
+
+// Write queues a file. Writing stops at the first error, which Flush and
+// Close return.
+func (w *Writer) Write(f *FileWrite) {
+	w.queue <- writerJob{file: f}
+}
+
+// Flush waits until every queued file is committed and returns the first
+// write error
+func (w *Writer) Flush() error {
+	flushed := make(chan struct{})
+	w.queue <- writerJob{flushed: flushed}
+	<-flushed
+	return w.err
+}
+
+// Close writes the queued files, stops the writer and returns the first
+// write error
+func (w *Writer) Close() error {
+	close(w.queue)
+	<-w.done
+	return w.err
+}
+
+func (w *Writer) run() {
+	defer close(w.done)
+	for job := range w.queue {
+		var batch []*FileWrite
+		for {
+			if job.file != nil {
+				batch = append(batch, job.file)
+			}
+			if job.flushed != nil || len(batch) == maxBatchFiles {
+				break
+			}
+			next, ok := w.tryReceive()
+			if !ok {
+				break
+			}
+			job = next
+		}
+		if w.err == nil && len(batch) > 0 {
+			w.err = commitBatch(w, batch)
+		}
+		if job.flushed != nil {
+			close(job.flushed)
+		}
+	}
+}
+
+// tryReceive returns a job already queued, without waiting for one
+func (w *Writer) tryReceive() (writerJob, bool) {
+	select {
+	case job, ok := <-w.queue:
+		return job, ok
+	default:
+		return writerJob{}, false
+	}
+}
+
+// commit writes a batch of files in one transaction
+func (w *Writer) commit(batch []*FileWrite) error {
+	tx, err := w.m.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin write: %w", err)
+	}
+	defer tx.Rollback()
+	for _, f := range batch {
+		if err := w.writeFile(tx, f); err != nil {
+			return fmt.Errorf("failed to write %s: %w", f.Path, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// writeFile replaces the containment of a file's symbols and stores the
+// file, as the extractors do with single statements
+func (w *Writer) writeFile(tx *sql.Tx, f *FileWrite) error {
+	if err := w.m.txExec(tx, clearFileContainmentSQL, f.Path); err != nil {
+		return err
+	}
+	for _, s := range f.Symbols {
+		if err := w.m.txExec(tx, insertSymbolSQL, symbolArgs(s)...); err != nil {
+			return err
+		}
+	}
+	for _, c := range f.Contains {
+		if err := w.m.txExec(tx, insertContainmentSQL, c.ChildID, c.ParentID); err != nil {
+			return err
+		}
+	}
+	return w.m.txExec(tx, updateFileMetaSQL, f.Path, f.ModTime, f.Language, nullIfEmpty(f.Source))
+}
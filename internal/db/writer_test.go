@@ -0,0 +1,125 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+// testFileWrite returns a write of a file declaring one function
+func testFileWrite(path string) *FileWrite {
+	return &FileWrite{
+		Path:     path,
+		Language: "go",
+		Source:   "treesitter",
+		Symbols:  []*Symbol{{ID: path + "#f", Name: "f", Kind: "function", Language: "go", File: path, Line: 1, CreatedAt: time.Unix(0, 0)}},
+		ModTime:  time.Unix(1700000000, 0),
+	}
+}
+
+// recordBatches makes commitBatch record the size of each batch
+func recordBatches(t *testing.T) *[]int {
+	t.Helper()
+	var sizes []int
+	commit := commitBatch
+	commitBatch = func(w *Writer, batch []*FileWrite) error {
+		sizes = append(sizes, len(batch))
+		return commit(w, batch)
+	}
+	t.Cleanup(func() { commitBatch = commit })
+	return &sizes
+}
+
+func TestWriterBatchesQueuedFiles(t *testing.T) {
+	m, _ := newTestManager(t)
+	sizes := recordBatches(t)
+
+	// Queue every file before the writer runs, so they are all waiting
+	files := 2*maxBatchFiles + 1
+	w := &Writer{m: m, queue: make(chan writerJob, files), done: make(chan struct{})}
+	for n := range files {
+		w.Write(testFileWrite(fmt.Sprintf("/project/f%d.go", n)))
+	}
+	go w.run()
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if want := []int{maxBatchFiles, maxBatchFiles, 1}; !slices.Equal(*sizes, want) {
+		t.Errorf("batches = %v, want %v", *sizes, want)
+	}
+	times, err := m.FileModTimes()
+	if err != nil || len(times) != files {
+		t.Errorf("files written = %d, %v; want %d", len(times), err, files)
+	}
+}
+
+func TestWriterFlushMakesWritesVisible(t *testing.T) {
+	m, _ := newTestManager(t)
+	w := m.NewWriter(4)
+	defer w.Close()
+
+	w.Write(testFileWrite("/project/a.go"))
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if meta, err := m.GetFileMeta("/project/a.go"); err != nil || meta == nil || meta.Source != "treesitter" {
+		t.Fatalf("GetFileMeta after Flush = %+v, %v", meta, err)
+	}
+	if syms, err := m.GetFileSymbols("/project/a.go"); err != nil || len(syms) != 1 {
+		t.Fatalf("GetFileSymbols after Flush = %+v, %v; want f", syms, err)
+	}
+}
+
+func TestWriterStopsAtFirstError(t *testing.T) {
+	m, _ := newTestManager(t)
+	failed := errors.New("disk full")
+	commit := commitBatch
+	commitBatch = func(w *Writer, batch []*FileWrite) error {
+		if batch[0].Path == "/project/bad.go" {
+			return failed
+		}
+		return commit(w, batch)
+	}
+	t.Cleanup(func() { commitBatch = commit })
+
+	w := m.NewWriter(4)
+	w.Write(testFileWrite("/project/bad.go"))
+	if err := w.Flush(); !errors.Is(err, failed) {
+		t.Fatalf("Flush = %v, want the write error", err)
+	}
+	w.Write(testFileWrite("/project/good.go"))
+	if err := w.Flush(); !errors.Is(err, failed) {
+		t.Fatalf("second Flush = %v, want the first error again", err)
+	}
+	if err := w.Close(); !errors.Is(err, failed) {
+		t.Fatalf("Close = %v, want the first error", err)
+	}
+	if meta, err := m.GetFileMeta("/project/good.go"); err != nil || meta != nil {
+		t.Errorf("a write after the error was stored: %+v, %v", meta, err)
+	}
+}
+
+func TestPoolWritesWaitForTheWriter(t *testing.T) {
+	m, _ := newTestManager(t)
+	// Hold a write transaction, as the Writer does while committing
+	tx, err := m.db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec(updateFileMetaSQL, "/project/a.go", time.Now(), "go", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	written := make(chan error, 1)
+	go func() { written <- m.UpdateFileMeta("/project/b.go", time.Now(), "go", "") }()
+	time.Sleep(200 * time.Millisecond)
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-written; err != nil {
+		t.Fatalf("UpdateFileMeta during a write transaction: %v", err)
+	}
+}
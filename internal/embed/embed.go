@@ -0,0 +1,100 @@
+// Package embed computes vector embeddings of symbols for semantic search.
+package embed
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/tk-425/Codegraph/internal/config"
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+// Provider turns texts into embedding vectors.
+type Provider interface {
+	// Model identifies the provider and model. Vectors from different models
+	// are not comparable, so it is stored alongside each vector.
+	Model() string
+	// Embed returns one vector per text, in order.
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// NewProvider creates the provider configured in the [embeddings] section.
+func NewProvider(cfg config.EmbeddingsConfig) (Provider, error) {
+	switch cfg.Provider {
+	case "openai":
+		return newOpenAIProvider(cfg)
+	case "hash":
+		return NewHashProvider(), nil
+	case "":
+		return nil, fmt.Errorf("embeddings are not configured (set [embeddings] provider in .codegraph/config.toml)")
+	default:
+		return nil, fmt.Errorf("unknown embeddings provider %q (want openai or hash)", cfg.Provider)
+	}
+}
+
+// SymbolText builds the text embedded for a symbol: its name split into
+// words, kind, signature, and documentation.
+func SymbolText(sym db.Symbol) string {
+	parts := []string{strings.Join(SplitIdentifier(sym.Name), " "), sym.Kind}
+	if sym.Signature != "" {
+		parts = append(parts, sym.Signature)
+	}
+	if sym.Documentation != "" {
+		parts = append(parts, sym.Documentation)
+	}
+	return strings.Join(parts, "\n")
+}
+
+// SplitIdentifier splits camelCase, PascalCase, and snake_case identifiers
+// into lowercase words: "parseHTTPRequest" -> [parse http request].
+func SplitIdentifier(name string) []string {
+	var words []string
+	var current []rune
+	runes := []rune(name)
+	flush := func() {
+		if len(current) > 0 {
+			words = append(words, strings.ToLower(string(current)))
+			current = current[:0]
+		}
+	}
+
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		if unicode.IsUpper(r) && len(current) > 0 {
+			prevLower := unicode.IsLower(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			// Break before an upper after a lower (parseHTTP) and before the
+			// last upper of an acronym followed by lower (HTTPRequest)
+			if prevLower || (unicode.IsUpper(runes[i-1]) && nextLower) {
+				flush()
+			}
+		}
+		current = append(current, r)
+	}
+	flush()
+	return words
+}
+
+// Cosine returns the cosine similarity of a and b, or 0 when their lengths
+// differ or either is zero.
+func Cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -0,0 +1,98 @@
+package embed
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/tk-425/Codegraph/internal/config"
+)
+
+func TestSplitIdentifier(t *testing.T) {
+	cases := map[string][]string{
+		"parseHTTPRequest":   {"parse", "http", "request"},
+		"retry_with_backoff": {"retry", "with", "backoff"},
+		"NewClient":          {"new", "client"},
+		"Class.method(int)":  {"class", "method", "int"},
+	}
+	for in, want := range cases {
+		if got := SplitIdentifier(in); !reflect.DeepEqual(got, want) {
+			t.Errorf("SplitIdentifier(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestHashProviderRanksOverlappingWords(t *testing.T) {
+	p := NewHashProvider()
+	vecs, err := p.Embed(context.Background(), []string{
+		"retry http requests",
+		"retryRequest\nfunction\nfunc retryRequest(req *http.Request) error",
+		"parseConfig\nfunction\nfunc parseConfig(path string) (*Config, error)",
+	})
+	if err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+	related := Cosine(vecs[0], vecs[1])
+	unrelated := Cosine(vecs[0], vecs[2])
+	if related <= unrelated {
+		t.Errorf("related score %.3f should beat unrelated %.3f", related, unrelated)
+	}
+}
+
+func TestOpenAIProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q", got)
+		}
+		var req embeddingRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		if req.Model != "test-model" || len(req.Input) != 2 {
+			t.Errorf("request = %+v", req)
+		}
+		// Answer out of order: the provider must reorder by index
+		w.Write([]byte(`{"data":[{"index":1,"embedding":[0,1]},{"index":0,"embedding":[1,0]}]}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("TEST_EMBED_KEY", "secret")
+	p, err := NewProvider(config.EmbeddingsConfig{
+		Provider: "openai", Endpoint: server.URL + "/v1/", Model: "test-model", APIKeyEnv: "TEST_EMBED_KEY",
+	})
+	if err != nil {
+		t.Fatalf("NewProvider: %v", err)
+	}
+	if p.Model() != "openai:test-model" {
+		t.Errorf("Model = %q", p.Model())
+	}
+	vecs, err := p.Embed(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+	if !reflect.DeepEqual(vecs, [][]float32{{1, 0}, {0, 1}}) {
+		t.Errorf("vectors = %v", vecs)
+	}
+}
+
+func TestOpenAIProviderError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":{"message":"bad key"}}`))
+	}))
+	defer server.Close()
+
+	p, err := NewProvider(config.EmbeddingsConfig{Provider: "openai", Endpoint: server.URL, Model: "m"})
+	if err != nil {
+		t.Fatalf("NewProvider: %v", err)
+	}
+	if _, err := p.Embed(context.Background(), []string{"a"}); err == nil || err.Error() != "embeddings endpoint returned an error: bad key" {
+		t.Errorf("err = %v", err)
+	}
+}
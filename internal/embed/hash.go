@@ -0,0 +1,81 @@
+package embed
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// hashDimensions is the vector size of the hash provider
+const hashDimensions = 512
+
+// HashProvider is a built-in, offline provider that embeds texts as hashed
+// bags of words (identifiers split into words). It captures vocabulary
+// overlap rather than meaning, so it suits small projects and machines
+// without a model; configure the openai provider for real semantic matches.
+type HashProvider struct{}
+
+// NewHashProvider creates the built-in hash provider
+func NewHashProvider() *HashProvider {
+	return &HashProvider{}
+}
+
+// Model returns "hash:<dimensions>"
+func (p *HashProvider) Model() string {
+	return "hash:" + strconv.Itoa(hashDimensions)
+}
+
+// Embed hashes each word of each text into a fixed-size, L2-normalized vector
+func (p *HashProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vec := make([]float32, hashDimensions)
+		for _, field := range strings.FieldsFunc(text, isSeparator) {
+			for _, word := range SplitIdentifier(field) {
+				word = stem(word)
+				if len(word) < 2 {
+					continue
+				}
+				h := fnv.New32a()
+				h.Write([]byte(word))
+				vec[h.Sum32()%hashDimensions]++
+			}
+		}
+		normalize(vec)
+		vectors[i] = vec
+	}
+	return vectors, nil
+}
+
+func isSeparator(r rune) bool {
+	return !(r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+}
+
+// stem strips common English suffixes so "retries" and "retry" share a bucket
+func stem(word string) string {
+	for _, suffix := range []string{"ies", "ing", "ed", "es", "s"} {
+		if len(word) > len(suffix)+2 && strings.HasSuffix(word, suffix) {
+			if suffix == "ies" {
+				return strings.TrimSuffix(word, suffix) + "y"
+			}
+			return strings.TrimSuffix(word, suffix)
+		}
+	}
+	return word
+}
+
+func normalize(vec []float32) {
+	var sum float64
+	for _, v := range vec {
+		sum += float64(v) * float64(v)
+	}
+	if sum == 0 {
+		return
+	}
+	norm := float32(math.Sqrt(sum))
+	for i := range vec {
+		vec[i] /= norm
+	}
+}
@@ -0,0 +1,161 @@
+// Package history ties indexed symbols to the git commits that touched
+// their lines. Results are stored in the index the first time a symbol is
+// asked about and reused until HEAD or the symbol's lines change.
+package history
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+// Commit is one commit that changed a range of lines
+type Commit struct {
+	Hash   string
+	Author string
+	Date   time.Time
+}
+
+// Head returns the commit checked out in the git repository at root
+func Head(ctx context.Context, root string) (string, error) {
+	out, err := exec.CommandContext(ctx, "git", "-C", root, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return "", fmt.Errorf("not a git repository with commits: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// LineLog returns the commits that changed lines start through end
+// (1-indexed, inclusive) of the file at path as of HEAD, newest first.
+// Changes not committed yet are not part of it.
+func LineLog(ctx context.Context, root, path string, start, end int) ([]Commit, error) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		rel = path
+	}
+	lineRange := fmt.Sprintf("-L%d,%d:%s", start, end, filepath.ToSlash(rel))
+	cmd := exec.CommandContext(ctx, "git", "-C", root, "log", "--no-patch", "--format=%x1e%H%x1f%an%x1f%aI", lineRange)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git log: %s", strings.TrimPrefix(msg, "fatal: "))
+		}
+		return nil, err
+	}
+	return parseLog(out)
+}
+
+// FileChurn returns how many commits changed each file under root, by
+// absolute path. Renamed files count under their current name from the
+// rename on.
+func FileChurn(ctx context.Context, root string) (map[string]int, error) {
+	cmd := exec.CommandContext(ctx, "git", "-C", root, "log", "--format=", "--name-only", "--relative", "--no-renames")
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("not a git repository with commits: %w", err)
+	}
+	churn := make(map[string]int)
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			churn[filepath.Join(root, filepath.FromSlash(line))]++
+		}
+	}
+	return churn, nil
+}
+
+// parseLog reads the records of LineLog's format: hash, author and date
+// separated by \x1f, each record starting with \x1e
+func parseLog(out []byte) ([]Commit, error) {
+	var commits []Commit
+	for _, record := range strings.Split(string(out), "\x1e") {
+		record = strings.TrimSpace(record)
+		if record == "" {
+			continue
+		}
+		fields := strings.Split(record, "\x1f")
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("unexpected git log record %q", record)
+		}
+		date, err := time.Parse(time.RFC3339, fields[2])
+		if err != nil {
+			return nil, err
+		}
+		commits = append(commits, Commit{Hash: fields[0], Author: fields[1], Date: date})
+	}
+	return commits, nil
+}
+
+// Summarize condenses the commits of a symbol, newest first, into its
+// history: who introduced it, who changed it last, how many commits
+// touched it and its authors by number of commits
+func Summarize(commits []Commit) *db.SymbolHistory {
+	h := &db.SymbolHistory{Commits: len(commits)}
+	if len(commits) == 0 {
+		return h
+	}
+	first, last := commits[len(commits)-1], commits[0]
+	h.IntroducedCommit, h.IntroducedAuthor, h.IntroducedAt = first.Hash, first.Author, first.Date
+	h.ModifiedCommit, h.ModifiedAuthor, h.ModifiedAt = last.Hash, last.Author, last.Date
+
+	counts := make(map[string]int)
+	for _, c := range commits {
+		if counts[c.Author] == 0 {
+			h.Authors = append(h.Authors, c.Author)
+		}
+		counts[c.Author]++
+	}
+	sort.SliceStable(h.Authors, func(i, j int) bool { return counts[h.Authors[i]] > counts[h.Authors[j]] })
+	return h
+}
+
+// Resolver returns the history of symbols of the project at root, from the
+// index when it was stored for the same HEAD and lines, and from git
+// otherwise. Histories read from git are stored unless the index is
+// read-only.
+type Resolver struct {
+	db   *db.Manager
+	root string
+	head string
+}
+
+// NewResolver creates a resolver for the git checkout at root
+func NewResolver(ctx context.Context, dbManager *db.Manager, root string) (*Resolver, error) {
+	head, err := Head(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+	return &Resolver{db: dbManager, root: root, head: head}, nil
+}
+
+// Symbol returns the history of sym
+func (r *Resolver) Symbol(ctx context.Context, sym db.Symbol) (*db.SymbolHistory, error) {
+	end := sym.Line
+	if sym.EndLine != nil && *sym.EndLine > end {
+		end = *sym.EndLine
+	}
+	stored, err := r.db.GetSymbolHistory(sym.ID)
+	if err != nil {
+		return nil, err
+	}
+	if stored != nil && stored.Head == r.head && stored.File == sym.File && stored.StartLine == sym.Line && stored.EndLine == end {
+		return stored, nil
+	}
+
+	commits, err := LineLog(ctx, r.root, sym.File, sym.Line, end)
+	if err != nil {
+		return nil, err
+	}
+	h := Summarize(commits)
+	h.SymbolID, h.File, h.StartLine, h.EndLine, h.Head = sym.ID, sym.File, sym.Line, end, r.head
+	_ = r.db.SaveSymbolHistory(h) // A read-only index still answers, without storing
+	return h, nil
+}
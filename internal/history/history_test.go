@@ -0,0 +1,105 @@
+package history
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+func TestSummarize(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	h := Summarize([]Commit{
+		{Hash: "c3", Author: "Bob", Date: day(3)},
+		{Hash: "c2", Author: "Alice", Date: day(2)},
+		{Hash: "c1b", Author: "Bob", Date: day(1)},
+		{Hash: "c1", Author: "Carol", Date: day(1)},
+	})
+	if h.IntroducedCommit != "c1" || h.IntroducedAuthor != "Carol" || h.ModifiedCommit != "c3" || h.ModifiedAuthor != "Bob" {
+		t.Errorf("introduced %s by %s, modified %s by %s, want c1 by Carol and c3 by Bob",
+			h.IntroducedCommit, h.IntroducedAuthor, h.ModifiedCommit, h.ModifiedAuthor)
+	}
+	if h.Commits != 4 || !slices.Equal(h.Authors, []string{"Bob", "Alice", "Carol"}) {
+		t.Errorf("commits = %d, authors = %q, want 4 by Bob, Alice, Carol", h.Commits, h.Authors)
+	}
+	if h := Summarize(nil); h.Commits != 0 || h.IntroducedCommit != "" {
+		t.Errorf("Summarize(nil) = %+v, want an empty history", h)
+	}
+}
+
+func TestResolver(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := t.TempDir()
+	git := func(author string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", root, "-c", "user.name=" + author, "-c", "user.email=dev@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	path := filepath.Join(root, "main.go")
+	commit := func(author, src string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		git(author, "add", "main.go")
+		git(author, "commit", "-q", "-m", "change")
+	}
+	git("Alice", "init", "-q")
+	commit("Alice", "package main\n\nfunc main() {}\n")
+	commit("Bob", "package main\n\nfunc main() {}\n\nfunc helper() int {\n\treturn 1\n}\n")
+	commit("Carol", "package main\n\nfunc main() {}\n\nfunc helper() int {\n\treturn 2\n}\n")
+
+	database, err := db.NewManager(filepath.Join(t.TempDir(), "graph.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	if err := database.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	end := 7
+	helper := db.Symbol{ID: "main.go#helper", Name: "helper", File: path, Line: 5, EndLine: &end}
+
+	ctx := context.Background()
+	resolver, err := NewResolver(ctx, database, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := resolver.Symbol(ctx, helper)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.IntroducedAuthor != "Bob" || h.ModifiedAuthor != "Carol" || h.Commits != 2 {
+		t.Errorf("history = %+v, want introduced by Bob, modified by Carol, 2 commits", h)
+	}
+
+	// Stored for the same HEAD and lines, and read again once they change
+	stored, err := database.GetSymbolHistory(helper.ID)
+	if err != nil || stored == nil || stored.Commits != 2 || stored.Head != resolver.head {
+		t.Fatalf("stored history = %+v, %v", stored, err)
+	}
+	stored.Commits = 99
+	if err := database.SaveSymbolHistory(stored); err != nil {
+		t.Fatal(err)
+	}
+	if h, err := resolver.Symbol(ctx, helper); err != nil || h.Commits != 99 {
+		t.Errorf("history = %+v, %v, want the stored one", h, err)
+	}
+	helper.Line, end = 3, 3 // Now on main's line
+	if h, err := resolver.Symbol(ctx, helper); err != nil || h.Commits != 1 || h.ModifiedAuthor != "Alice" {
+		t.Errorf("history of other lines = %+v, %v, want 1 commit by Alice", h, err)
+	}
+
+	if _, err := resolver.Symbol(ctx, db.Symbol{ID: "new.go#f", File: filepath.Join(root, "new.go"), Line: 1}); err == nil {
+		t.Error("history of an untracked file succeeded")
+	}
+}
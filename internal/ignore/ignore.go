@@ -84,6 +84,11 @@ type Matcher struct {
 	matcher        *goignore.Matcher
 	patterns       []string
 	noPruneParents map[string]bool
+
+	// git holds the project's own .gitignore rules when EnableGitignore was
+	// called. They apply only to paths .cgignore and the defaults leave alone.
+	git         *goignore.Matcher
+	projectRoot string
 }
 
 // NewMatcher creates a matcher that evaluates .cgignore using gitignore-style semantics.
@@ -117,13 +122,59 @@ func NewMatcher(cgignorePath string) (*Matcher, error) {
 	return m, nil
 }
 
+// EnableGitignore makes the matcher also honour the project's .gitignore
+// files and .git/info/exclude, so indexing skips what git skips. The walker
+// must call LoadGitignore for each directory it enters, starting with the
+// root. Rules in .cgignore (including negations) take precedence.
+func (m *Matcher) EnableGitignore(projectRoot string) error {
+	m.git = goignore.New()
+	m.projectRoot = projectRoot
+	return m.git.AddExcludePatterns(filepath.Join(projectRoot, ".git"))
+}
+
+// LoadGitignore adds the rules of the .gitignore in dir (relative to the
+// project root), scoped to that directory. It does nothing unless
+// EnableGitignore was called or when dir has no .gitignore.
+func (m *Matcher) LoadGitignore(dir string) error {
+	if m.git == nil {
+		return nil
+	}
+	base := normalizePath(dir)
+	path := filepath.Join(m.projectRoot, filepath.FromSlash(base), ".gitignore")
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("read .gitignore: %w", err)
+	}
+
+	if warnings := m.git.AddPatterns(base, content); len(warnings) > 0 {
+		return formatWarnings(path, warnings)
+	}
+	for parent := range extractNoPruneParents(content) {
+		if base != "" {
+			parent = base + "/" + parent
+		}
+		if m.noPruneParents == nil {
+			m.noPruneParents = make(map[string]bool)
+		}
+		m.noPruneParents[parent] = true
+	}
+	return nil
+}
+
 // ShouldIgnore checks if a path should be ignored.
 func (m *Matcher) ShouldIgnore(path string, isDir bool) bool {
 	normalized := normalizePath(path)
 	if normalized == "" {
 		return false
 	}
-	return m.matcher.Match(normalized, isDir)
+	result := m.matcher.MatchWithReason(normalized, isDir)
+	if result.Matched || m.git == nil {
+		return result.Ignored
+	}
+	return m.git.Match(normalized, isDir)
 }
 
 // ShouldSkipDir reports whether the walker can prune an ignored directory safely.
@@ -135,6 +186,31 @@ func (m *Matcher) ShouldSkipDir(path string) bool {
 	return m.ShouldIgnore(path, true)
 }
 
+// PatternList matches paths against a list of gitignore-style patterns,
+// such as the per-language include and exclude globs in config.toml.
+type PatternList struct {
+	matcher *goignore.Matcher
+}
+
+// NewPatternList compiles patterns; source names them in error messages.
+func NewPatternList(source string, patterns []string) (*PatternList, error) {
+	matcher := goignore.New()
+	if warnings := matcher.AddPatterns("", []byte(strings.Join(patterns, "\n")+"\n")); len(warnings) > 0 {
+		return nil, formatWarnings(source, warnings)
+	}
+	return &PatternList{matcher: matcher}, nil
+}
+
+// Match reports whether the file at path (relative to the project root)
+// matches the list.
+func (p *PatternList) Match(path string) bool {
+	normalized := normalizePath(path)
+	if normalized == "" {
+		return false
+	}
+	return p.matcher.Match(normalized, false)
+}
+
 // GetPatterns returns all active patterns.
 func (m *Matcher) GetPatterns() []string {
 	return append([]string{}, m.patterns...)
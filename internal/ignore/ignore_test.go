@@ -3,9 +3,11 @@ package ignore_test
 import (
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"testing"
 
+	"github.com/tk-425/Codegraph/internal/config"
 	"github.com/tk-425/Codegraph/internal/ignore"
 	"github.com/tk-425/Codegraph/internal/indexer"
 )
@@ -166,3 +168,232 @@ func TestScannerSupportsNegationWithoutSkippingDir(t *testing.T) {
 		t.Fatalf("unexpected files: %#v", files)
 	}
 }
+
+func TestScannerHonoursGitignoreWhenEnabled(t *testing.T) {
+	projectRoot := t.TempDir()
+	codegraphDir := filepath.Join(projectRoot, ".codegraph")
+	files := map[string]string{
+		".codegraph/.cgignore": "!keep.gen.go\n",
+		".gitignore":           "*.gen.go\n/out/\n",
+		".git/info/exclude":    "scratch.go\n",
+		"src/.gitignore":       "fixtures/\n!important.gen.go\n",
+		"src/main.go":          "package main\n",
+		"src/a.gen.go":         "package main\n",
+		"src/important.gen.go": "package main\n",
+		"src/keep.gen.go":      "package main\n",
+		"src/fixtures/f.go":    "package fixtures\n",
+		"lib/fixtures/f.go":    "package fixtures\n",
+		"out/o.go":             "package out\n",
+		"scratch.go":           "package main\n",
+	}
+	for rel, content := range files {
+		path := filepath.Join(projectRoot, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir %s: %v", rel, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", rel, err)
+		}
+	}
+
+	scan := func(cfg config.IndexConfig) []string {
+		scanner, err := indexer.NewScannerWithConfig(projectRoot, filepath.Join(codegraphDir, ".cgignore"), cfg)
+		if err != nil {
+			t.Fatalf("NewScannerWithConfig: %v", err)
+		}
+		found, err := scanner.Scan()
+		if err != nil {
+			t.Fatalf("Scan: %v", err)
+		}
+		var paths []string
+		for _, f := range found {
+			paths = append(paths, f.RelPath)
+		}
+		return paths
+	}
+
+	// .gitignore is only consulted when enabled
+	if got := scan(config.IndexConfig{}); len(got) != 8 {
+		t.Fatalf("without use_gitignore got %v", got)
+	}
+
+	// Nested .gitignore files are scoped to their directory, and .cgignore
+	// negations win over .gitignore
+	got := strings.Join(scan(config.IndexConfig{UseGitignore: true}), ",")
+	want := "lib/fixtures/f.go,src/important.gen.go,src/keep.gen.go,src/main.go"
+	if got != want {
+		t.Fatalf("with use_gitignore got %s, want %s", got, want)
+	}
+}
+
+func TestScannerAppliesIndexFilters(t *testing.T) {
+	projectRoot := t.TempDir()
+	minified := "var a=" + strings.Repeat("function(){return 1},", 120) + "0;\n"
+	files := map[string]string{
+		"main.go":           "package main\n",
+		"main_test.go":      "package main\n",
+		"api.pb.go":         "// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage api\n",
+		"big.go":            "package main\n" + strings.Repeat("// padding\n", 300),
+		"app.js":            "export function run() {}\n",
+		"bundle.js":         minified,
+		"lib.min.js":        "var a=1;\n",
+		"scripts/tool.py":   "def main(): pass\n",
+		"src/service.py":    "# @generated by codegen\nclass Service: pass\n",
+		"src/handler.py":    "def handle(): pass\n",
+		"src/legacy/old.py": "def old(): pass\n",
+	}
+	for rel, content := range files {
+		path := filepath.Join(projectRoot, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir %s: %v", rel, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", rel, err)
+		}
+	}
+
+	scanner, err := indexer.NewScannerWithConfig(projectRoot, "", config.IndexConfig{
+		MaxFileSize:      3000,
+		ExcludeGenerated: true,
+		Languages: map[string]config.LanguageFilter{
+			"go":     {Exclude: []string{"*_test.go"}},
+			"python": {Include: []string{"/src/**"}, Exclude: []string{"legacy/"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewScannerWithConfig: %v", err)
+	}
+	found, err := scanner.Scan()
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	var paths []string
+	for _, f := range found {
+		paths = append(paths, f.RelPath)
+	}
+	if got, want := strings.Join(paths, ","), "app.js,main.go,src/handler.py"; got != want {
+		t.Fatalf("files = %s, want %s", got, want)
+	}
+
+	skipped := scanner.Skipped()
+	if skipped[indexer.SkipTooLarge] != 1 || skipped[indexer.SkipGenerated] != 4 || skipped[indexer.SkipLanguage] != 3 {
+		t.Fatalf("skipped = %v", skipped)
+	}
+}
+
+func TestScannerSymlinks(t *testing.T) {
+	projectRoot := t.TempDir()
+	external := t.TempDir()
+	second := t.TempDir()
+	for _, dir := range []string{"src", "loop"} {
+		if err := os.MkdirAll(filepath.Join(projectRoot, dir), 0o755); err != nil {
+			t.Fatalf("mkdir %s: %v", dir, err)
+		}
+	}
+	for path, content := range map[string]string{
+		filepath.Join(projectRoot, "src", "main.go"): "package main\n",
+		filepath.Join(external, "lib.go"):            "package lib\n",
+		filepath.Join(second, "util.go"):             "package util\n",
+	} {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+	}
+	links := map[string]string{
+		filepath.Join(projectRoot, "shared"):          external,                                     // Followed
+		filepath.Join(external, "back"):               external,                                     // Loops into itself
+		filepath.Join(external, "next"):               second,                                       // Followed
+		filepath.Join(second, "prev"):                 external,                                     // Loops through a second link
+		filepath.Join(projectRoot, "srclink"):         filepath.Join(projectRoot, "src"),            // Duplicate of a real directory
+		filepath.Join(projectRoot, "loop", "up"):      projectRoot,                                  // Loops to the root
+		filepath.Join(projectRoot, "src", "alias.go"): filepath.Join(projectRoot, "src", "main.go"), // File link
+		filepath.Join(projectRoot, "dangling.go"):     filepath.Join(projectRoot, "missing.go"),     // Broken
+	}
+	for link, target := range links {
+		if err := os.Symlink(target, link); err != nil {
+			t.Skipf("symlinks unsupported: %v", err)
+		}
+	}
+
+	scan := func(follow bool) ([]string, *indexer.Scanner) {
+		scanner, err := indexer.NewScannerWithConfig(projectRoot, "", config.IndexConfig{FollowSymlinks: follow})
+		if err != nil {
+			t.Fatalf("NewScannerWithConfig: %v", err)
+		}
+		found, err := scanner.Scan()
+		if err != nil {
+			t.Fatalf("Scan: %v", err)
+		}
+		var paths []string
+		for _, f := range found {
+			paths = append(paths, f.RelPath)
+		}
+		sort.Strings(paths)
+		return paths, scanner
+	}
+
+	// Symlinked files are indexed either way; symlinked directories are not
+	paths, scanner := scan(false)
+	if got, want := strings.Join(paths, ","), "src/alias.go,src/main.go"; got != want {
+		t.Fatalf("without follow_symlinks got %s, want %s", got, want)
+	}
+	if n := scanner.Skipped()[indexer.SkipSymlink]; n != 3 {
+		t.Fatalf("skipped symlinks = %d, want 3", n)
+	}
+
+	paths, scanner = scan(true)
+	if got, want := strings.Join(paths, ","), "shared/lib.go,shared/next/util.go,src/alias.go,src/main.go"; got != want {
+		t.Fatalf("with follow_symlinks got %s, want %s", got, want)
+	}
+	warnings := strings.Join(scanner.Warnings(), "\n")
+	for _, want := range []string{"dangling.go: broken symlink", "loop/up: symlink loop", "shared/back: symlink loop", "shared/next/prev: symlink loop", "srclink: symlink loop"} {
+		if !strings.Contains(warnings, want) {
+			t.Errorf("warnings missing %q:\n%s", want, warnings)
+		}
+	}
+}
+
+func TestScannerLimitsToWorkspaces(t *testing.T) {
+	projectRoot := t.TempDir()
+	files := map[string]string{
+		"backend/main.go":          "package main\n",
+		"backend/scripts/seed.py":  "def seed(): pass\n",
+		"frontend/src/app.ts":      "export function app() {}\n",
+		"frontend/src/view.tsx":    "export function View() {}\n",
+		"docs/examples/example.go": "package examples\n",
+		"tool.py":                  "def tool(): pass\n",
+	}
+	for rel, content := range files {
+		path := filepath.Join(projectRoot, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir %s: %v", rel, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", rel, err)
+		}
+	}
+
+	scanner, err := indexer.NewScanner(projectRoot, "")
+	if err != nil {
+		t.Fatalf("NewScanner: %v", err)
+	}
+	scanner.SetWorkspaces([]config.WorkspaceConfig{
+		{Root: "backend", Languages: []string{"go"}},
+		{Root: "./frontend/", Languages: []string{"typescript"}},
+	})
+	found, err := scanner.Scan()
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	var paths []string
+	for _, f := range found {
+		paths = append(paths, f.RelPath)
+	}
+	if got, want := strings.Join(paths, ","), "backend/main.go,frontend/src/app.ts,frontend/src/view.tsx"; got != want {
+		t.Fatalf("files = %s, want %s", got, want)
+	}
+	// seed.py and tool.py are counted; docs/ is pruned without being walked
+	if skipped := scanner.Skipped(); skipped[indexer.SkipWorkspace] != 2 {
+		t.Fatalf("skipped = %#v", skipped)
+	}
+}
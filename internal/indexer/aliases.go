@@ -0,0 +1,181 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+	"github.com/smacker/go-tree-sitter/golang"
+	"github.com/smacker/go-tree-sitter/javascript"
+	"github.com/smacker/go-tree-sitter/python"
+	"github.com/smacker/go-tree-sitter/typescript/tsx"
+	"github.com/smacker/go-tree-sitter/typescript/typescript"
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+// AliasIndexer records the names files import or export under another
+// name (import x as y, export { a as b }, Go's import m "path/mathutil"),
+// so call extraction can map an aliased callee back to its symbol
+type AliasIndexer struct {
+	db *db.Manager
+}
+
+// NewAliasIndexer creates an alias indexer
+func NewAliasIndexer(dbManager *db.Manager) *AliasIndexer {
+	return &AliasIndexer{db: dbManager}
+}
+
+// IndexAliases re-extracts the aliases of files and returns how many were
+// found
+func (a *AliasIndexer) IndexAliases(ctx context.Context, files []FileInfo) (int, error) {
+	paths := make([]string, len(files))
+	for i, file := range files {
+		paths[i] = file.Path
+	}
+	if err := a.db.ClearAliases(paths); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	parser := sitter.NewParser()
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return count, err
+		}
+		lang := aliasLanguage(grammarFor(file))
+		if lang == nil {
+			continue
+		}
+		content, err := os.ReadFile(file.Path)
+		if err != nil {
+			continue // Removed since it was scanned
+		}
+		parser.SetLanguage(lang)
+		tree, err := parser.ParseCtx(ctx, nil, content)
+		if err != nil {
+			continue
+		}
+		aliases := fileAliases(tree.RootNode(), content, file)
+		tree.Close()
+		for _, alias := range aliases {
+			if err := a.db.InsertAlias(alias); err != nil {
+				return count, fmt.Errorf("failed to insert alias: %w", err)
+			}
+			count++
+		}
+	}
+	return count, nil
+}
+
+// aliasLanguage returns the grammar to read a file's imports with, or nil
+// for languages whose aliases are not indexed
+func aliasLanguage(grammar string) *sitter.Language {
+	switch grammar {
+	case "python":
+		return python.GetLanguage()
+	case "typescript":
+		return typescript.GetLanguage()
+	case "typescriptreact":
+		return tsx.GetLanguage()
+	case "javascript":
+		return javascript.GetLanguage()
+	case "go":
+		return golang.GetLanguage()
+	}
+	return nil
+}
+
+// fileAliases returns the aliased imports and exports of a file. Imports
+// under their own name are left out: their callees resolve by name already.
+func fileAliases(root *sitter.Node, content []byte, file FileInfo) []*db.Alias {
+	var aliases []*db.Alias
+	add := func(node *sitter.Node, alias, name, module, kind string) {
+		if alias == "" || name == "" || alias == name {
+			return
+		}
+		aliases = append(aliases, &db.Alias{
+			File:   file.Path,
+			Alias:  alias,
+			Name:   name,
+			Module: module,
+			Kind:   kind,
+			Line:   int(node.StartPoint().Row) + 1,
+		})
+	}
+	text := func(n *sitter.Node) string {
+		if n == nil {
+			return ""
+		}
+		return n.Content(content)
+	}
+
+	walkNodes(root, func(n *sitter.Node) {
+		switch grammarFor(file) {
+		case "python":
+			// import numpy as np, from a.b import foo as bar
+			if n.Type() != "aliased_import" {
+				return
+			}
+			module := ""
+			if stmt := n.Parent(); stmt != nil && stmt.Type() == "import_from_statement" {
+				module = text(stmt.ChildByFieldName("module_name"))
+			}
+			add(n, text(n.ChildByFieldName("alias")), text(n.ChildByFieldName("name")), module, db.AliasImport)
+		case "go":
+			// import m "example.com/mathutil"; dot and blank imports bind
+			// no name
+			if n.Type() != "import_spec" {
+				return
+			}
+			nameNode := n.ChildByFieldName("name")
+			if nameNode == nil || nameNode.Type() != "package_identifier" {
+				return
+			}
+			importPath, err := strconv.Unquote(text(n.ChildByFieldName("path")))
+			if err != nil {
+				return
+			}
+			add(n, nameNode.Content(content), goImportName(importPath), importPath, db.AliasImport)
+		default:
+			// import { a as b } from './x' and export { a as b } [from './x']
+			kind := ""
+			switch n.Type() {
+			case "import_specifier":
+				kind = db.AliasImport
+			case "export_specifier":
+				kind = db.AliasExport
+			default:
+				return
+			}
+			add(n, text(n.ChildByFieldName("alias")), text(n.ChildByFieldName("name")), aliasSource(n, content), kind)
+		}
+	})
+	return aliases
+}
+
+// aliasSource returns the module a TypeScript or JavaScript import or
+// export specifier names, without quotes: ./x for from './x'. Local
+// exports have none.
+func aliasSource(n *sitter.Node, content []byte) string {
+	for p := n.Parent(); p != nil; p = p.Parent() {
+		if p.Type() != "import_statement" && p.Type() != "export_statement" {
+			continue
+		}
+		if source := p.ChildByFieldName("source"); source != nil {
+			return strings.Trim(source.Content(content), `"'`+"`")
+		}
+		return ""
+	}
+	return ""
+}
+
+// walkNodes calls fn on node and each of its named descendants
+func walkNodes(node *sitter.Node, fn func(*sitter.Node)) {
+	fn(node)
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		walkNodes(node.NamedChild(i), fn)
+	}
+}
@@ -0,0 +1,149 @@
+package indexer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/tk-425/Codegraph/internal/config"
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+// Phases timed by Bench
+const (
+	BenchScan   = "scan"   // Walking the project for source files
+	BenchParse  = "parse"  // Reading and parsing files, extracting symbols
+	BenchInsert = "insert" // Storing the symbols
+	BenchCalls  = "calls"  // Extracting and storing call edges
+)
+
+// BenchPhase is the timing of one phase of a benchmark
+type BenchPhase struct {
+	Name     string
+	Duration time.Duration
+	Files    int // Files handled
+	Items    int // Files found, symbols or calls, by phase
+}
+
+// Bench times the phases of a build of the project at root with tree-sitter
+// alone, so the results do not depend on the language servers installed.
+// It indexes into a scratch database that is removed afterwards, leaving
+// the project's index untouched.
+func Bench(ctx context.Context, cfg *config.Config, root, cgignorePath string) ([]BenchPhase, error) {
+	var phases []BenchPhase
+	timed := func(name string, run func() (files, items int, err error)) error {
+		started := time.Now()
+		files, items, err := run()
+		phases = append(phases, BenchPhase{Name: name, Duration: time.Since(started), Files: files, Items: items})
+		return err
+	}
+
+	var files []FileInfo
+	err := timed(BenchScan, func() (int, int, error) {
+		scanner, err := NewScannerWithConfig(root, cgignorePath, cfg.Index)
+		if err != nil {
+			return 0, 0, err
+		}
+		scanner.SetWorkspaces(cfg.Workspaces)
+		files, err = scanner.Scan()
+		return len(files), len(files), err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "codegraph-bench-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	scratch, err := db.NewManager(filepath.Join(dir, "bench.db"))
+	if err != nil {
+		return nil, err
+	}
+	defer scratch.Close()
+	if err := scratch.Initialize(); err != nil {
+		return nil, err
+	}
+	kinds, err := NewKindMap(cfg.Kinds)
+	if err != nil {
+		return nil, err
+	}
+	ts := NewTreeSitterIndexer(scratch, root)
+	ts.SetKinds(kinds)
+
+	// Files tree-sitter cannot parse are left out of the later phases
+	type parsed struct {
+		file     FileInfo
+		symbols  []*db.Symbol
+		contains []*db.Containment
+	}
+	var results []parsed
+	err = timed(BenchParse, func() (int, int, error) {
+		count := 0
+		for _, file := range files {
+			if err := ctx.Err(); err != nil {
+				return len(results), count, err
+			}
+			content, err := os.ReadFile(file.Path)
+			if err != nil {
+				continue
+			}
+			symbols, contains, err := ts.parse(ctx, file, content)
+			if err != nil {
+				continue
+			}
+			results = append(results, parsed{file, symbols, contains})
+			count += len(symbols)
+		}
+		return len(results), count, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Symbols are stored the way builds store them, batched by a writer
+	err = timed(BenchInsert, func() (int, int, error) {
+		writer := scratch.NewWriter(writeQueueSize)
+		count := 0
+		for _, r := range results {
+			isTest := IsTestFile(r.file.RelPath)
+			for _, sym := range r.symbols {
+				sym.IsTest = isTest
+			}
+			writer.Write(&db.FileWrite{
+				Path:     r.file.Path,
+				Language: r.file.Language,
+				Source:   sourceTreeSitter,
+				Symbols:  r.symbols,
+				Contains: r.contains,
+				ModTime:  time.Now(),
+			})
+			count += len(r.symbols)
+		}
+		return len(results), count, writer.Close()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = timed(BenchCalls, func() (int, int, error) {
+		extractor := NewCallExtractor(scratch, root)
+		extractor.SetFilter(NewCallFilter(cfg.Calls))
+		count := 0
+		for _, r := range results {
+			if err := ctx.Err(); err != nil {
+				return len(results), count, err
+			}
+			if n, err := extractor.ExtractCalls(ctx, r.file); err == nil {
+				count += n
+			}
+		}
+		return len(results), count, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return phases, nil
+}
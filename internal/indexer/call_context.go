@@ -0,0 +1,236 @@
+package indexer
+
+import (
+	"context"
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// Control-flow contexts recorded on call edges. A call with no context runs
+// whenever its caller does.
+const (
+	ctxIf        = "if"        // if/else branch, ternary, guard
+	ctxSwitch    = "switch"    // switch/match/select case
+	ctxLoop      = "loop"      // loop body or comprehension
+	ctxDefer     = "defer"     // deferred until the caller returns
+	ctxGoroutine = "goroutine" // started on a new goroutine
+	ctxTry       = "try"       // try block
+	ctxCatch     = "catch"     // exception handler
+	ctxFinally   = "finally"   // finally block
+)
+
+// CallContexts lists the control-flow contexts a call edge can carry
+var CallContexts = []string{ctxIf, ctxSwitch, ctxLoop, ctxDefer, ctxGoroutine, ctxTry, ctxCatch, ctxFinally}
+
+// controlFlowNodes maps the tree-sitter node types that make their body
+// conditional, repeated, or deferred to the context they give a call
+var controlFlowNodes = map[string]string{
+	"if_statement":           ctxIf,
+	"if_expression":          ctxIf,
+	"if_let_expression":      ctxIf,
+	"ternary_expression":     ctxIf,
+	"conditional_expression": ctxIf,
+	"guard_statement":        ctxIf,
+
+	"switch_statement":            ctxSwitch,
+	"switch_expression":           ctxSwitch,
+	"expression_switch_statement": ctxSwitch,
+	"type_switch_statement":       ctxSwitch,
+	"select_statement":            ctxSwitch,
+	"match_statement":             ctxSwitch,
+	"match_expression":            ctxSwitch,
+
+	"for_statement":            ctxLoop,
+	"for_in_statement":         ctxLoop,
+	"enhanced_for_statement":   ctxLoop,
+	"for_each_statement":       ctxLoop,
+	"foreach_statement":        ctxLoop,
+	"while_statement":          ctxLoop,
+	"do_statement":             ctxLoop,
+	"repeat_while_statement":   ctxLoop,
+	"for_expression":           ctxLoop,
+	"while_expression":         ctxLoop,
+	"while_let_expression":     ctxLoop,
+	"loop_expression":          ctxLoop,
+	"list_comprehension":       ctxLoop,
+	"set_comprehension":        ctxLoop,
+	"dictionary_comprehension": ctxLoop,
+	"generator_expression":     ctxLoop,
+
+	"defer_statement": ctxDefer,
+	"go_statement":    ctxGoroutine,
+
+	"try_statement":                ctxTry,
+	"try_with_resources_statement": ctxTry,
+	"catch_clause":                 ctxCatch,
+	"except_clause":                ctxCatch,
+	"catch_block":                  ctxCatch,
+	"finally_clause":               ctxFinally,
+}
+
+// languageControlFlowNodes override controlFlowNodes where a grammar
+// reuses a node type for something else
+var languageControlFlowNodes = map[string]map[string]string{
+	"swift": {"do_statement": ctxTry},   // do { try f() } catch { }
+	"ocaml": {"try_expression": ctxTry}, // try e with ...
+}
+
+// unconditionalFields are the parts of a control-flow node evaluated
+// every time the node is reached, such as an if condition or the
+// collection a loop ranges over; calls there take no context from it
+var unconditionalFields = map[string]bool{
+	"condition":   true,
+	"initializer": true,
+	"value":       true,
+	"subject":     true,
+	"right":       true,
+	"collection":  true,
+}
+
+// unconditionalNodes are the loop headers whose collection is evaluated
+// once: Go's range clause and a comprehension's for clause
+var unconditionalNodes = map[string]bool{
+	"range_clause":  true,
+	"for_in_clause": true,
+}
+
+// functionBoundaries end the walk: control flow outside the enclosing
+// function (or closure) does not govern calls inside it
+var functionBoundaries = map[string]bool{
+	"function_declaration":           true,
+	"generator_function_declaration": true,
+	"function_definition":            true,
+	"function_item":                  true,
+	"function_expression":            true,
+	"function":                       true,
+	"method_declaration":             true,
+	"method_definition":              true,
+	"constructor_declaration":        true,
+	"local_function_statement":       true,
+	"init_declaration":               true,
+	"func_literal":                   true,
+	"arrow_function":                 true,
+	"lambda":                         true,
+	"lambda_expression":              true,
+	"lambda_literal":                 true,
+	"closure_expression":             true,
+	"fun_expression":                 true,
+}
+
+// callContext returns the control flow enclosing the call at n within its
+// function, outermost first and comma-separated (e.g. "loop,if"), or ""
+// when the call is unconditional
+func callContext(n *sitter.Node, language string) string {
+	var contexts []string
+	prev := n
+	for node := n.Parent(); node != nil; prev, node = node, node.Parent() {
+		if functionBoundaries[node.Type()] || isOCamlFunctionBinding(node) {
+			// go func() { ... }() and defer func() { ... }() run their
+			// body in the statement's context
+			if call := node.Parent(); call != nil && call.Type() == "call_expression" {
+				if stmt := call.Parent(); stmt != nil && (stmt.Type() == "go_statement" || stmt.Type() == "defer_statement") {
+					continue
+				}
+			}
+			break
+		}
+
+		label, ok := languageControlFlowNodes[language][node.Type()]
+		if !ok {
+			label = controlFlowNodes[node.Type()]
+		}
+		if label == "" || unconditionalFields[childFieldName(node, prev)] || unconditionalNodes[prev.Type()] {
+			continue
+		}
+		// OCaml handlers are the match cases of try ... with
+		if label == ctxTry && prev.Type() == "match_case" {
+			label = ctxCatch
+		}
+		// A handler's own context replaces the try block's
+		if label == ctxTry && len(contexts) > 0 {
+			if last := contexts[len(contexts)-1]; last == ctxCatch || last == ctxFinally {
+				continue
+			}
+		}
+		contexts = append(contexts, label)
+	}
+
+	// Outermost first, collapsing else-if chains and nested loops
+	var out []string
+	for i := len(contexts) - 1; i >= 0; i-- {
+		if len(out) == 0 || out[len(out)-1] != contexts[i] {
+			out = append(out, contexts[i])
+		}
+	}
+	return strings.Join(out, ",")
+}
+
+// isOCamlFunctionBinding reports whether node is a let binding that
+// defines a function (let f x = ..., let f = fun x -> ...) rather than a
+// value
+func isOCamlFunctionBinding(node *sitter.Node) bool {
+	if node.Type() != "let_binding" {
+		return false
+	}
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		if node.NamedChild(i).Type() == "parameter" {
+			return true
+		}
+	}
+	body := node.ChildByFieldName("body")
+	return body != nil && (body.Type() == "fun_expression" || body.Type() == "function_expression")
+}
+
+// childFieldName returns the field name under which child hangs off
+// parent, or "" when it is not in a field
+func childFieldName(parent, child *sitter.Node) string {
+	for i := 0; i < int(parent.ChildCount()); i++ {
+		if parent.Child(i).Equal(child) {
+			return parent.FieldNameForChild(i)
+		}
+	}
+	return ""
+}
+
+// callSiteTrees parses the files LSP references point into, so their call
+// sites can be given a context as tree-sitter extraction does
+type callSiteTrees struct {
+	trees map[string]*sitter.Tree
+}
+
+func newCallSiteTrees() *callSiteTrees {
+	return &callSiteTrees{trees: make(map[string]*sitter.Tree)}
+}
+
+// contextAt returns the call context of the reference at the 0-indexed
+// line and character of path, whose content is source
+func (t *callSiteTrees) contextAt(ctx context.Context, path, language, source string, line, character int) string {
+	tree, ok := t.trees[path]
+	if !ok {
+		if lang := new(CallExtractor).getLanguage(grammarFor(FileInfo{Path: path, Language: language})); lang != nil {
+			parser := sitter.NewParser()
+			parser.SetLanguage(lang)
+			tree, _ = parser.ParseCtx(ctx, nil, []byte(source))
+		}
+		t.trees[path] = tree
+	}
+	if tree == nil {
+		return ""
+	}
+	point := sitter.Point{Row: uint32(line), Column: uint32(character)}
+	node := tree.RootNode().NamedDescendantForPointRange(point, point)
+	if node == nil {
+		return ""
+	}
+	return callContext(node, language)
+}
+
+// close releases the parsed trees
+func (t *callSiteTrees) close() {
+	for _, tree := range t.trees {
+		if tree != nil {
+			tree.Close()
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package indexer
+
+import (
+	"github.com/tk-425/Codegraph/internal/config"
+)
+
+// builtinCallees are the builtins and common standard library functions
+// and methods of each language. Tree-sitter only sees a call's name, so a
+// call to one of them would otherwise be linked to whatever indexed symbol
+// shares it.
+var builtinCallees = map[string][]string{
+	"go": {
+		"append", "cap", "clear", "close", "complex", "copy", "delete", "imag", "len", "make",
+		"max", "min", "new", "panic", "print", "println", "real", "recover",
+		"Errorf", "Fprint", "Fprintf", "Fprintln", "Print", "Printf", "Println", "Sprint", "Sprintf", "Sprintln",
+	},
+	"python": {
+		"abs", "all", "any", "bool", "callable", "dict", "dir", "enumerate", "filter", "float",
+		"format", "getattr", "hasattr", "hash", "id", "int", "isinstance", "issubclass", "iter", "len",
+		"list", "map", "max", "min", "next", "open", "print", "range", "repr", "reversed",
+		"round", "set", "setattr", "sorted", "str", "sum", "super", "tuple", "type", "zip",
+		"add", "append", "copy", "endswith", "extend", "get", "insert", "items", "join", "keys",
+		"lower", "pop", "remove", "replace", "split", "startswith", "strip", "update", "upper", "values",
+	},
+	"typescript":      jsBuiltinCallees,
+	"typescriptreact": jsBuiltinCallees,
+	"javascript":      jsBuiltinCallees,
+	"java": {
+		"add", "append", "asList", "charAt", "collect", "contains", "equals", "filter", "forEach", "format",
+		"get", "hashCode", "isEmpty", "length", "map", "of", "print", "printf", "println", "put",
+		"remove", "size", "stream", "substring", "toString", "valueOf",
+	},
+	"csharp": {
+		"Add", "Any", "Contains", "Equals", "First", "FirstOrDefault", "Format", "GetHashCode", "Remove", "Select",
+		"ToList", "ToString", "Where", "Write", "WriteLine",
+	},
+	"rust": {
+		"as_ref", "clone", "collect", "expect", "from", "get", "insert", "into", "is_empty", "iter",
+		"len", "map", "ok", "push", "to_owned", "to_string", "unwrap",
+	},
+	"swift": {
+		"append", "contains", "filter", "forEach", "map", "print", "reduce", "remove",
+	},
+	"ocaml": {
+		"failwith", "fold_left", "ignore", "iter", "length", "map", "print_endline", "printf", "raise",
+	},
+}
+
+var jsBuiltinCallees = []string{
+	"assign", "catch", "concat", "delete", "entries", "error", "every", "filter", "finally", "find",
+	"forEach", "from", "get", "has", "includes", "indexOf", "isArray", "join", "keys", "log",
+	"map", "parseFloat", "parseInt", "pop", "push", "reduce", "reject", "replace", "require", "resolve",
+	"set", "setInterval", "setTimeout", "shift", "slice", "some", "sort", "splice", "split", "stringify",
+	"then", "toLowerCase", "toString", "toUpperCase", "trim", "values", "warn",
+}
+
+// CallFilter applies config.toml's [calls] section to tree-sitter call
+// extraction. A nil CallFilter skips the built-in names and resolves
+// callees in any language.
+type CallFilter struct {
+	ignored map[string]map[string]bool // Names never linked, by language
+	resolve string
+}
+
+// NewCallFilter builds the filter cfg describes
+func NewCallFilter(cfg config.CallsConfig) *CallFilter {
+	f := &CallFilter{ignored: make(map[string]map[string]bool), resolve: cfg.Resolve}
+	add := func(language string, names []string) {
+		if f.ignored[language] == nil {
+			f.ignored[language] = make(map[string]bool, len(names))
+		}
+		for _, name := range names {
+			f.ignored[language][name] = true
+		}
+	}
+	if !cfg.KeepBuiltins {
+		for language, names := range builtinCallees {
+			add(language, names)
+		}
+	}
+	for language, names := range cfg.Ignore {
+		add(language, names)
+	}
+	return f
+}
+
+// defaultCallFilter is the filter of an empty [calls] section
+var defaultCallFilter = NewCallFilter(config.CallsConfig{})
+
+// ignores reports whether calls named name in language are left unlinked
+func (f *CallFilter) ignores(language, name string) bool {
+	if f == nil {
+		f = defaultCallFilter
+	}
+	return f.ignored[language][name]
+}
+
+// mode returns the call resolution mode, config.ResolveAny by default
+func (f *CallFilter) mode() string {
+	if f == nil || f.resolve == "" {
+		return config.ResolveAny
+	}
+	return f.resolve
+}
@@ -4,8 +4,8 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
+	"unicode"
 
 	"github.com/tk-425/Codegraph/internal/db"
 	"github.com/tk-425/Codegraph/internal/lsp"
@@ -47,22 +47,85 @@ func (c *CallGraphIndexer) IndexCallGraph(ctx context.Context, language string)
 		return 0, fmt.Errorf("failed to get function symbols: %w", err)
 	}
 
-	callCount := 0
-	openedFiles := make(map[string]bool)
+	return c.indexReferences(ctx, client, language, symbols, newFunctionIndex(symbols), nil), nil
+}
+
+// IndexCallGraphFiles updates the call graph of a language after files (by
+// path) changed, instead of reprocessing every symbol: the edges made from
+// and to the changed files are dropped, then references are looked up only
+// for the functions declared in them (callers anywhere) and for the
+// functions whose names the changed files mention (call sites in the
+// changed files only).
+func (c *CallGraphIndexer) IndexCallGraphFiles(ctx context.Context, language string, files []string) (int, error) {
+	client, err := c.mgr.GetClient(ctx, language)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get LSP client: %w", err)
+	}
+
+	if err := c.db.ClearCallsFrom(files); err != nil {
+		return 0, err
+	}
+	if err := c.db.ClearCallsTo(files); err != nil {
+		return 0, err
+	}
+
+	symbols, err := c.db.GetFunctionSymbols(language)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get function symbols: %w", err)
+	}
 
+	changed := make(map[string]bool, len(files))
+	mentioned := make(map[string]bool)
+	for _, f := range files {
+		changed[f] = true
+		content, err := readFileContent(f)
+		if err != nil {
+			continue
+		}
+		for _, word := range strings.FieldsFunc(content, func(r rune) bool {
+			return r != '_' && r != '$' && !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			mentioned[word] = true
+		}
+	}
+
+	var targets []db.Symbol
 	for _, sym := range symbols {
-		fileURI := "file://" + sym.File
+		name := sym.Name
+		if idx := strings.IndexByte(name, '('); idx >= 0 {
+			name = name[:idx] // Java: method(String)
+		}
+		if changed[sym.File] || mentioned[name] {
+			targets = append(targets, sym)
+		}
+	}
 
-		// Open file if not already opened
-		if !openedFiles[fileURI] {
-			content, err := readFileContent(sym.File)
-			if err != nil {
-				continue
-			}
-			if err := client.DidOpenTextDocument(fileURI, language, content); err != nil {
-				continue
-			}
-			openedFiles[fileURI] = true
+	// Edges from unchanged files to unchanged functions were kept
+	keep := func(callee db.Symbol, refPath string) bool {
+		return changed[callee.File] || changed[refPath]
+	}
+	return c.indexReferences(ctx, client, language, targets, newFunctionIndex(symbols), keep), nil
+}
+
+// indexReferences stores a call edge for every reference to symbols made
+// inside a function of functions, skipping references keep rejects (nil
+// keeps all), and returns the number of edges stored
+func (c *CallGraphIndexer) indexReferences(ctx context.Context, client *lsp.Client, language string, symbols []db.Symbol, functions *functionIndex, keep func(callee db.Symbol, refPath string) bool) int {
+	callCount := 0
+	docs := newOpenDocuments(c.mgr, client, language)
+	defer docs.closeAll()
+	sources := make(map[string]string) // Call-site files, for argument text
+	trees := newCallSiteTrees()        // Call-site syntax trees, for call context
+	defer trees.close()
+
+	for _, sym := range symbols {
+		if canceled(ctx) {
+			break // Interrupted: the next build links again
+		}
+		// Open file on its server if not already opened
+		client, fileURI := docs.open(ctx, sym.File)
+		if client == nil {
+			continue
 		}
 
 		// Get position of the function name
@@ -79,15 +142,18 @@ func (c *CallGraphIndexer) IndexCallGraph(ctx context.Context, language string)
 
 		// Each reference is a potential call site
 		for _, ref := range refs {
-			refPath := uriToPath(ref.URI)
+			refPath := lsp.URIToPath(ref.URI)
 			
 			// Skip if same location as declaration
 			if refPath == sym.File && ref.Range.Start.Line+1 == sym.Line {
 				continue
 			}
+			if keep != nil && !keep(sym, refPath) {
+				continue
+			}
 
 			// Find which function contains this reference
-			callerID := c.findContainingFunction(refPath, ref.Range.Start.Line+1, language)
+			callerID := functions.containing(refPath, ref.Range.Start.Line+1)
 			if callerID == "" {
 				continue
 			}
@@ -100,6 +166,11 @@ func (c *CallGraphIndexer) IndexCallGraph(ctx context.Context, language string)
 				Line:     ref.Range.Start.Line + 1,
 				Column:   ref.Range.Start.Character,
 			}
+			if _, ok := sources[refPath]; !ok {
+				sources[refPath], _ = readFileContent(refPath)
+			}
+			dbCall.ArgCount, dbCall.Args = callArgumentsAt(sources[refPath], ref.Range.Start.Line, ref.Range.Start.Character)
+			dbCall.Context = trees.contextAt(ctx, refPath, language, sources[refPath], ref.Range.Start.Line, ref.Range.Start.Character)
 
 			if err := c.db.InsertCall(dbCall); err != nil {
 				// Skip duplicate calls
@@ -109,54 +180,83 @@ func (c *CallGraphIndexer) IndexCallGraph(ctx context.Context, language string)
 		}
 	}
 
-	// Close opened files
-	for fileURI := range openedFiles {
-		client.DidCloseTextDocument(fileURI)
-	}
-
-	return callCount, nil
+	return callCount
 }
 
-// findContainingFunction finds which function contains a given line
-func (c *CallGraphIndexer) findContainingFunction(file string, line int, language string) string {
-	// Query database for function that spans this line
-	symbols, err := c.db.GetFunctionSymbols(language)
-	if err != nil {
-		return ""
+// callArgumentsAt reads the argument list following the callee name that
+// starts at line/character (0-indexed, character in UTF-16 code units as
+// LSP counts it) in source: "parse(ctx, \"a,b\")" yields 2 and
+// "(ctx, \"a,b\")". References that are not followed by an argument list
+// (function values, imports) return nil.
+func callArgumentsAt(source string, line, character int) (*int, string) {
+	offset := 0
+	for i := 0; i < line; i++ {
+		next := strings.IndexByte(source[offset:], '\n')
+		if next < 0 {
+			return nil, ""
+		}
+		offset += next + 1
 	}
+	lineEnd := len(source)
+	if next := strings.IndexByte(source[offset:], '\n'); next >= 0 {
+		lineEnd = offset + next
+	}
+	offset += lsp.ByteOffset(source[offset:lineEnd], character)
 
-	// Normalize file path for comparison
-	absFile, _ := filepath.Abs(file)
+	// Skip the callee name, then any whitespace before the list
+	rest := source[offset:]
+	rest = strings.TrimLeftFunc(rest, func(r rune) bool {
+		return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+	})
+	rest = strings.TrimLeft(rest, " \t")
+	if !strings.HasPrefix(rest, "(") {
+		return nil, ""
+	}
 
-	for _, sym := range symbols {
-		absSymFile, _ := filepath.Abs(sym.File)
-		if absSymFile != absFile {
+	// Count top-level arguments that have content, so "f()" is 0 and a
+	// trailing comma does not add one
+	depth, count, pending := 0, 0, false
+	var quote byte
+	for i := 0; i < len(rest); i++ {
+		ch := rest[i]
+		if quote != 0 {
+			if ch == '\\' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
 			continue
 		}
-
-		// Check if line is within function range
-		if sym.EndLine != nil {
-			if line >= sym.Line && line <= *sym.EndLine {
-				return sym.ID
+		switch ch {
+		case '(', '[', '{':
+			depth++
+			if depth == 1 {
+				continue
+			}
+		case ')', ']', '}':
+			depth--
+			if depth == 0 {
+				if pending {
+					count++
+				}
+				return &count, compactArgs(rest[:i+1])
 			}
-		} else {
-			// If no end line, just check if after start
-			if line >= sym.Line {
-				return sym.ID
+		case ',':
+			if depth == 1 {
+				if pending {
+					count++
+				}
+				pending = false
+				continue
 			}
+		case '"', '\'', '`':
+			quote = ch
+		case ' ', '\t', '\r', '\n':
+			continue
 		}
+		pending = true
 	}
-
-	return ""
-}
-
-// Helper functions
-
-func uriToPath(uri string) string {
-	if strings.HasPrefix(uri, "file://") {
-		return uri[7:]
-	}
-	return uri
+	return nil, ""
 }
 
 func readFileContent(path string) (string, error) {
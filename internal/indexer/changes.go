@@ -0,0 +1,53 @@
+package indexer
+
+import (
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+// Changes are the differences between the scanned files and the index
+type Changes struct {
+	Modified []FileInfo // New files, or changed since they were indexed
+	Removed  []string   // Indexed files that no longer exist or are no longer scanned
+}
+
+// Empty reports whether the index is up to date with the files
+func (c *Changes) Empty() bool {
+	return len(c.Modified) == 0 && len(c.Removed) == 0
+}
+
+// Paths returns the paths of the modified and removed files
+func (c *Changes) Paths() []string {
+	paths := make([]string, 0, len(c.Modified)+len(c.Removed))
+	for _, f := range c.Modified {
+		paths = append(paths, f.Path)
+	}
+	return append(paths, c.Removed...)
+}
+
+// FindChanges compares files, as scanned, with what dbManager indexed. A
+// file counts as modified when an incremental build would re-index it.
+func FindChanges(dbManager *db.Manager, files []FileInfo) (*Changes, error) {
+	changes := &Changes{}
+	scanned := make(map[string]bool, len(files))
+	for _, file := range files {
+		scanned[file.Path] = true
+		current, err := upToDate(dbManager, file)
+		if err != nil {
+			return nil, err
+		}
+		if !current {
+			changes.Modified = append(changes.Modified, file)
+		}
+	}
+
+	indexed, err := dbManager.ListIndexedFiles()
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range indexed {
+		if !scanned[path] {
+			changes.Removed = append(changes.Removed, path)
+		}
+	}
+	return changes, nil
+}
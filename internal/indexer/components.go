@@ -0,0 +1,278 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	sitter "github.com/smacker/go-tree-sitter"
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+// componentWrappers are the functions whose result is the component they
+// wrap: const Card = memo((props) => <div />)
+var componentWrappers = map[string]bool{
+	"memo":            true,
+	"forwardRef":      true,
+	"observer":        true,
+	"defineComponent": true,
+}
+
+// importsVue matches files written against Vue, whose components render JSX
+// from a setup or render function
+var importsVue = regexp.MustCompile(`(?m)^\s*import\b.*\bfrom\s+['"]vue['"]`)
+
+// ComponentIndexer recognizes React and Vue components written with JSX
+// and records which components each one renders
+type ComponentIndexer struct {
+	db  *db.Manager
+	tsi *TreeSitterIndexer // Grammar lookup
+}
+
+// NewComponentIndexer creates a component indexer
+func NewComponentIndexer(dbManager *db.Manager) *ComponentIndexer {
+	return &ComponentIndexer{
+		db:  dbManager,
+		tsi: &TreeSitterIndexer{},
+	}
+}
+
+// fileComponents are the components of a file and the elements they render
+type fileComponents struct {
+	file       FileInfo
+	framework  string
+	components map[string]*db.Symbol // By ID
+	renders    []*db.Render
+}
+
+// IndexComponents re-extracts the components of every JSX file, since a
+// component can render one declared in another file, and returns how many
+// components and render edges were found
+func (c *ComponentIndexer) IndexComponents(ctx context.Context, files []FileInfo) (int, int, error) {
+	if err := c.db.ClearComponents(); err != nil {
+		return 0, 0, err
+	}
+
+	var extracted []*fileComponents
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return 0, 0, err
+		}
+		if IsTestFile(file.RelPath) {
+			continue
+		}
+		if file.Language != "typescriptreact" && file.Language != "javascript" {
+			continue // Plain TypeScript cannot contain JSX
+		}
+		fc, err := c.extractFile(ctx, file)
+		if err != nil {
+			continue // Unreadable files have no components, as in call extraction
+		}
+		extracted = append(extracted, fc)
+	}
+
+	// Children resolve among the components of every file
+	byName := make(map[string][]db.Symbol)
+	components := 0
+	for _, fc := range extracted {
+		for id, sym := range fc.components {
+			if err := c.db.InsertComponent(id, fc.framework); err != nil {
+				return components, 0, fmt.Errorf("failed to insert component: %w", err)
+			}
+			components++
+			byName[sym.Name] = append(byName[sym.Name], *sym)
+		}
+	}
+
+	renders := 0
+	for _, fc := range extracted {
+		for _, r := range fc.renders {
+			r.ChildID = resolveComponent(byName[r.Child[strings.LastIndex(r.Child, ".")+1:]], fc.file)
+			if err := c.db.InsertRender(r); err != nil {
+				return components, renders, fmt.Errorf("failed to insert render: %w", err)
+			}
+			renders++
+		}
+	}
+	return components, renders, nil
+}
+
+// resolveComponent picks the component an element names among candidates
+// as handlers are resolved: in the same file, then the same directory, then
+// the only one in the project
+func resolveComponent(candidates []db.Symbol, file FileInfo) *string {
+	for _, same := range []func(db.Symbol) bool{
+		func(s db.Symbol) bool { return s.File == file.Path },
+		func(s db.Symbol) bool { return filepath.Dir(s.File) == filepath.Dir(file.Path) },
+	} {
+		for _, s := range candidates {
+			if same(s) {
+				return &s.ID
+			}
+		}
+	}
+	if len(candidates) == 1 {
+		return &candidates[0].ID
+	}
+	return nil
+}
+
+// extractFile parses a JSX file and returns its components: capitalized
+// functions, bound function values and classes that contain JSX. Elements
+// are attributed to the innermost component around them, so lower-case
+// render helpers inside a component count as part of it.
+func (c *ComponentIndexer) extractFile(ctx context.Context, file FileInfo) (*fileComponents, error) {
+	content, err := os.ReadFile(file.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	parser := sitter.NewParser()
+	parser.SetLanguage(c.tsi.getLanguage(grammarFor(file)))
+	tree, err := parser.ParseCtx(ctx, nil, content)
+	if err != nil {
+		return nil, fmt.Errorf("tree-sitter parse error: %w", err)
+	}
+	defer tree.Close()
+
+	symbols, err := c.db.GetFileSymbols(file.Path)
+	if err != nil {
+		return nil, err
+	}
+	fc := &fileComponents{file: file, framework: db.FrameworkReact, components: make(map[string]*db.Symbol)}
+	if importsVue.Match(content) {
+		fc.framework = db.FrameworkVue
+	}
+
+	var walk func(n *sitter.Node, component *db.Symbol)
+	walk = func(n *sitter.Node, component *db.Symbol) {
+		if name := componentName(n, content); name != "" {
+			component = symbolAt(symbols, name, int(n.StartPoint().Row)+1)
+		}
+		if component != nil {
+			switch n.Type() {
+			case "jsx_element", "jsx_self_closing_element":
+				fc.components[component.ID] = component
+				if child := jsxComponentName(n, content); child != "" {
+					fc.renders = append(fc.renders, &db.Render{
+						ParentID: component.ID,
+						Child:    child,
+						File:     file.Path,
+						Line:     int(n.StartPoint().Row) + 1,
+						Column:   int(n.StartPoint().Column),
+					})
+				}
+			}
+		}
+		for i := 0; i < int(n.NamedChildCount()); i++ {
+			walk(n.NamedChild(i), component)
+		}
+	}
+	walk(tree.RootNode(), nil)
+	return fc, nil
+}
+
+// componentName returns the name n declares if it can be a component: a
+// capitalized function, function value or class
+func componentName(n *sitter.Node, content []byte) string {
+	name := ""
+	switch n.Type() {
+	case "function_declaration", "class_declaration", "class":
+		if nameNode := n.ChildByFieldName("name"); nameNode != nil {
+			name = nameNode.Content(content)
+		}
+	case "arrow_function", "function_expression", "function":
+		if bound, method := boundFunctionName(n, content); !method {
+			name = bound
+		}
+	}
+	if !isCapitalized(name) {
+		return ""
+	}
+	return name
+}
+
+// wrappedComponentName returns the variable a function passed to component
+// wrappers (memo, forwardRef, ...) is bound to, given the wrapper call's
+// arguments: const Card = memo(forwardRef((props, ref) => ...)) has Card
+func wrappedComponentName(args *sitter.Node, content []byte) string {
+	for {
+		call := args.Parent()
+		if call == nil || call.Type() != "call_expression" {
+			return ""
+		}
+		fn := call.ChildByFieldName("function")
+		if fn == nil {
+			return ""
+		}
+		wrapper := fn.Content(content)
+		if !componentWrappers[wrapper[strings.LastIndex(wrapper, ".")+1:]] {
+			return ""
+		}
+		parent := call.Parent()
+		if parent == nil {
+			return ""
+		}
+		switch parent.Type() {
+		case "arguments":
+			args = parent
+			continue
+		case "variable_declarator":
+			nameNode := parent.ChildByFieldName("name")
+			if nameNode != nil && nameNode.Type() == "identifier" {
+				return nameNode.Content(content)
+			}
+		}
+		return ""
+	}
+}
+
+// jsxComponentName returns the component a JSX element renders: a
+// capitalized or dotted tag name. Intrinsic elements (div, span) and
+// fragments have none.
+func jsxComponentName(n *sitter.Node, content []byte) string {
+	tag := n
+	if n.Type() == "jsx_element" {
+		if tag = n.ChildByFieldName("open_tag"); tag == nil {
+			return ""
+		}
+	}
+	nameNode := tag.ChildByFieldName("name")
+	if nameNode == nil {
+		return "" // <>...</>
+	}
+	name := nameNode.Content(content)
+	if name == "Fragment" || strings.HasSuffix(name, ".Fragment") {
+		return ""
+	}
+	if nameNode.Type() == "member_expression" || nameNode.Type() == "nested_identifier" || isCapitalized(name) {
+		return name
+	}
+	return ""
+}
+
+// symbolAt returns the innermost symbol named name whose range contains
+// line, or nil
+func symbolAt(symbols []db.Symbol, name string, line int) *db.Symbol {
+	var best *db.Symbol
+	for i := range symbols {
+		s := &symbols[i]
+		if s.Name != name || s.Line > line || (s.EndLine != nil && *s.EndLine < line) {
+			continue
+		}
+		if best == nil || s.Line > best.Line {
+			best = s
+		}
+	}
+	return best
+}
+
+func isCapitalized(name string) bool {
+	r, _ := utf8.DecodeRuneInString(name)
+	return unicode.IsUpper(r)
+}
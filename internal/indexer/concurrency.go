@@ -0,0 +1,170 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	sitter "github.com/smacker/go-tree-sitter"
+	"github.com/smacker/go-tree-sitter/golang"
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+// mutexOps maps sync.Mutex and sync.RWMutex methods to their operations
+var mutexOps = map[string]string{
+	"Lock":    db.ConcurrencyLock,
+	"Unlock":  db.ConcurrencyUnlock,
+	"RLock":   db.ConcurrencyRLock,
+	"RUnlock": db.ConcurrencyRUnlock,
+}
+
+// ConcurrencyIndexer records goroutine launches, channel operations and
+// mutex operations with the function they occur in. Only Go is supported.
+type ConcurrencyIndexer struct {
+	db        *db.Manager
+	extractor *CallExtractor // Tracks the enclosing function while walking
+}
+
+// NewConcurrencyIndexer creates a new concurrency indexer
+func NewConcurrencyIndexer(dbManager *db.Manager, rootPath string) *ConcurrencyIndexer {
+	return &ConcurrencyIndexer{
+		db:        dbManager,
+		extractor: NewCallExtractor(dbManager, rootPath),
+	}
+}
+
+// IndexConcurrency re-extracts the concurrency sites of the Go files among
+// files and returns how many were recorded
+func (c *ConcurrencyIndexer) IndexConcurrency(ctx context.Context, files []FileInfo) (int, error) {
+	var goFiles []FileInfo
+	var paths []string
+	for _, file := range files {
+		if file.Language == "go" {
+			goFiles = append(goFiles, file)
+			paths = append(paths, file.Path)
+		}
+	}
+	if err := c.db.ClearConcurrency(paths); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, file := range goFiles {
+		if err := ctx.Err(); err != nil {
+			return count, err
+		}
+		sites, err := c.extractFile(ctx, file)
+		if err != nil {
+			continue // Unreadable files have no sites, as in call extraction
+		}
+		for _, site := range sites {
+			if err := c.db.InsertConcurrencySite(site); err != nil {
+				return count, fmt.Errorf("failed to insert concurrency site: %w", err)
+			}
+			count++
+		}
+	}
+	return count, nil
+}
+
+// extractFile parses a Go file and returns its concurrency sites
+func (c *ConcurrencyIndexer) extractFile(ctx context.Context, file FileInfo) ([]*db.ConcurrencySite, error) {
+	content, err := os.ReadFile(file.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	parser := sitter.NewParser()
+	parser.SetLanguage(golang.GetLanguage())
+	tree, err := parser.ParseCtx(ctx, nil, content)
+	if err != nil {
+		return nil, fmt.Errorf("tree-sitter parse error: %w", err)
+	}
+	defer tree.Close()
+
+	c.extractor.fileSymbols, _ = c.db.GetFileSymbols(file.Path)
+	defer func() { c.extractor.fileSymbols = nil }()
+
+	var sites []*db.ConcurrencySite
+	c.extractor.walkTreeWithContext(tree.RootNode(), content, file, func(n *sitter.Node, _ string, enclosingFuncID string) {
+		if enclosingFuncID == "" {
+			return // Package-level initializers have no function to attach to
+		}
+		op, target, targetID := c.goConcurrencyOp(n, content, file.Language)
+		if op == "" {
+			return
+		}
+		sites = append(sites, &db.ConcurrencySite{
+			SymbolID: enclosingFuncID,
+			Op:       op,
+			Target:   target,
+			TargetID: targetID,
+			File:     file.Path,
+			Line:     int(n.StartPoint().Row) + 1,
+			Column:   int(n.StartPoint().Column),
+		})
+	})
+	return sites, nil
+}
+
+// goConcurrencyOp returns the operation n performs, if any, with its target
+// and, for a goroutine running an indexed function, that function's ID
+func (c *ConcurrencyIndexer) goConcurrencyOp(n *sitter.Node, content []byte, language string) (string, string, *string) {
+	switch n.Type() {
+	case "go_statement":
+		call := n.NamedChild(0)
+		if call == nil || call.Type() != "call_expression" {
+			return "", "", nil
+		}
+		fn := call.ChildByFieldName("function")
+		if fn == nil {
+			return "", "", nil
+		}
+		if fn.Type() == "func_literal" {
+			return db.ConcurrencyGo, "func literal", nil
+		}
+		var targetID *string
+		if name := c.extractor.getGoCalleeName(call, content); name != "" {
+			if id := c.extractor.resolveSymbolID(name, language); id != "" {
+				targetID = &id
+			}
+		}
+		return db.ConcurrencyGo, fn.Content(content), targetID
+	case "send_statement":
+		if channel := n.ChildByFieldName("channel"); channel != nil {
+			return db.ConcurrencySend, channel.Content(content), nil
+		}
+	case "unary_expression":
+		operator := n.ChildByFieldName("operator")
+		operand := n.ChildByFieldName("operand")
+		if operator != nil && operand != nil && operator.Type() == "<-" {
+			return db.ConcurrencyReceive, operand.Content(content), nil
+		}
+	case "call_expression":
+		fn := n.ChildByFieldName("function")
+		args := n.ChildByFieldName("arguments")
+		if fn == nil || args == nil {
+			return "", "", nil
+		}
+		// A goroutine's own call is reported by its go_statement
+		if parent := n.Parent(); parent != nil && parent.Type() == "go_statement" {
+			return "", "", nil
+		}
+		switch fn.Type() {
+		case "identifier":
+			if fn.Content(content) == "close" && args.NamedChildCount() == 1 {
+				return db.ConcurrencyClose, args.NamedChild(0).Content(content), nil
+			}
+		case "selector_expression":
+			field := fn.ChildByFieldName("field")
+			operand := fn.ChildByFieldName("operand")
+			if field == nil || operand == nil || args.NamedChildCount() != 0 {
+				return "", "", nil
+			}
+			if op, ok := mutexOps[field.Content(content)]; ok {
+				return op, operand.Content(content), nil
+			}
+		}
+	}
+	return "", "", nil
+}
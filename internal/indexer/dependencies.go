@@ -0,0 +1,309 @@
+package indexer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/tk-425/Codegraph/internal/config"
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+// dependency is the source of one dependency of the project
+type dependency struct {
+	Module   string // Name, with the version when known: golang.org/x/text@v0.14.0
+	Language string
+	Dir      string
+	Ext      string // Suffix of the files to index: .go, .d.ts, .pyi
+}
+
+// DependencyIndexer shallowly indexes the declarations of the project's
+// dependencies into external_symbols, where only external calls see them
+type DependencyIndexer struct {
+	db       *db.Manager
+	rootPath string
+	maxFiles int
+}
+
+// NewDependencyIndexer creates a dependency indexer
+func NewDependencyIndexer(dbManager *db.Manager, rootPath string, cfg config.DependenciesConfig) *DependencyIndexer {
+	return &DependencyIndexer{db: dbManager, rootPath: rootPath, maxFiles: cfg.FileLimit()}
+}
+
+// IndexDependencies indexes the dependencies not indexed yet, drops the
+// ones the project no longer uses and links the external calls they
+// declare. It returns how many dependencies were indexed and how many
+// calls were linked.
+func (d *DependencyIndexer) IndexDependencies(ctx context.Context) (int, int64, error) {
+	deps := findDependencies(d.rootPath)
+	indexed, err := d.db.ListExternalModules()
+	if err != nil {
+		return 0, 0, err
+	}
+	wanted := make(map[string]bool, len(deps))
+	for _, dep := range deps {
+		wanted[dep.Module] = true
+	}
+	var stale []string
+	for _, module := range indexed {
+		if !wanted[module] {
+			stale = append(stale, module)
+		}
+	}
+	if err := d.db.ClearExternalModules(stale); err != nil {
+		return 0, 0, err
+	}
+
+	count := 0
+	ts := NewTreeSitterIndexer(nil, d.rootPath)
+	for _, dep := range deps {
+		if err := ctx.Err(); err != nil {
+			return count, 0, err
+		}
+		if slices.Contains(indexed, dep.Module) {
+			continue
+		}
+		symbols := d.parseDependency(ctx, ts, dep)
+		if err := d.db.InsertExternalSymbols(symbols); err != nil {
+			return count, 0, fmt.Errorf("failed to store %s: %w", dep.Module, err)
+		}
+		count++
+	}
+	linked, err := d.db.ResolveExternalCalls()
+	return count, linked, err
+}
+
+// parseDependency extracts the symbols of up to maxFiles files of dep
+func (d *DependencyIndexer) parseDependency(ctx context.Context, ts *TreeSitterIndexer, dep dependency) []*db.ExternalSymbol {
+	var symbols []*db.ExternalSymbol
+	files := 0
+	filepath.WalkDir(dep.Dir, func(path string, entry fs.DirEntry, err error) error {
+		if files >= d.maxFiles {
+			return filepath.SkipAll
+		}
+		if err != nil {
+			return nil
+		}
+		if entry.IsDir() {
+			name := entry.Name()
+			if path != dep.Dir && (name == "node_modules" || name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, dep.Ext) || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(dep.Dir, path)
+		file := FileInfo{Path: path, RelPath: dep.Module + "/" + filepath.ToSlash(rel), Language: dep.Language}
+		parsed, _, err := ts.parse(ctx, file, content)
+		if err != nil {
+			return nil
+		}
+		files++
+		for _, s := range parsed {
+			symbols = append(symbols, &db.ExternalSymbol{
+				ID:            s.ID,
+				Name:          s.Name,
+				Kind:          s.Kind,
+				File:          path,
+				Line:          s.Line,
+				Signature:     s.Signature,
+				Documentation: s.Documentation,
+				Language:      dep.Language,
+				Package:       s.Package,
+				Module:        dep.Module,
+			})
+		}
+		return nil
+	})
+	return symbols
+}
+
+// findDependencies returns the dependencies of the project at root whose
+// source is on disk, sorted by module
+func findDependencies(root string) []dependency {
+	var deps []dependency
+	deps = append(deps, goDependencies(root)...)
+	deps = append(deps, npmDependencies(root)...)
+	deps = append(deps, pythonDependencies(root)...)
+	sort.Slice(deps, func(i, j int) bool { return deps[i].Module < deps[j].Module })
+	return deps
+}
+
+// goRequirement matches a requirement of go.mod: path and version
+var goRequirement = regexp.MustCompile(`^([^\s()]+)\s+(v[^\s]+)`)
+
+// goRequirements returns the path and version of each module go.mod
+// requires, alone or in a require block
+func goRequirements(gomod string) [][2]string {
+	var reqs [][2]string
+	inBlock := false
+	for _, line := range strings.Split(gomod, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "require (":
+			inBlock = true
+			continue
+		case inBlock && line == ")":
+			inBlock = false
+			continue
+		case strings.HasPrefix(line, "require "):
+			line = strings.TrimSpace(strings.TrimPrefix(line, "require "))
+		case !inBlock:
+			continue
+		}
+		if m := goRequirement.FindStringSubmatch(line); m != nil {
+			reqs = append(reqs, [2]string{m[1], m[2]})
+		}
+	}
+	return reqs
+}
+
+// goDependencies returns the modules go.mod requires, from vendor/ when
+// the project vendors them and from the module cache otherwise
+func goDependencies(root string) []dependency {
+	data, err := os.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return nil
+	}
+	cache := goModCache()
+	var deps []dependency
+	for _, req := range goRequirements(string(data)) {
+		path, version := req[0], req[1]
+		dir := filepath.Join(root, "vendor", filepath.FromSlash(path))
+		if !isDir(dir) {
+			dir = filepath.Join(cache, filepath.FromSlash(escapeModulePath(path))+"@"+version)
+		}
+		if isDir(dir) {
+			deps = append(deps, dependency{Module: path + "@" + version, Language: "go", Dir: dir, Ext: ".go"})
+		}
+	}
+	return deps
+}
+
+// goModCache returns the directory of the Go module cache
+func goModCache() string {
+	if dir := os.Getenv("GOMODCACHE"); dir != "" {
+		return dir
+	}
+	if gopath := os.Getenv("GOPATH"); gopath != "" {
+		return filepath.Join(filepath.SplitList(gopath)[0], "pkg", "mod")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, "go", "pkg", "mod")
+}
+
+// escapeModulePath escapes a module path the way the module cache stores
+// it: each upper-case letter becomes ! and its lower-case form
+func escapeModulePath(path string) string {
+	var b strings.Builder
+	for _, r := range path {
+		if unicode.IsUpper(r) {
+			b.WriteByte('!')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// npmDependencies returns the type definitions in node_modules of the
+// dependencies of package.json, @types packages included
+func npmDependencies(root string) []dependency {
+	data, err := os.ReadFile(filepath.Join(root, "package.json"))
+	if err != nil {
+		return nil
+	}
+	var manifest struct {
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	if json.Unmarshal(data, &manifest) != nil {
+		return nil
+	}
+	var deps []dependency
+	for _, required := range []map[string]string{manifest.Dependencies, manifest.DevDependencies} {
+		for name := range required {
+			dir := filepath.Join(root, "node_modules", filepath.FromSlash(name))
+			if !isDir(dir) {
+				continue
+			}
+			module := name
+			var pkg struct {
+				Version string `json:"version"`
+			}
+			if data, err := os.ReadFile(filepath.Join(dir, "package.json")); err == nil && json.Unmarshal(data, &pkg) == nil && pkg.Version != "" {
+				module += "@" + pkg.Version
+			}
+			deps = append(deps, dependency{Module: module, Language: "typescript", Dir: dir, Ext: ".d.ts"})
+		}
+	}
+	return deps
+}
+
+// pythonDependencies returns the packages with .pyi stubs in the
+// site-packages of the project's virtualenv (.venv, venv or env)
+func pythonDependencies(root string) []dependency {
+	var sites []string
+	for _, venv := range []string{".venv", "venv", "env"} {
+		found, _ := filepath.Glob(filepath.Join(root, venv, "lib", "python*", "site-packages"))
+		sites = append(sites, found...)
+		if dir := filepath.Join(root, venv, "Lib", "site-packages"); isDir(dir) {
+			sites = append(sites, dir) // Windows
+		}
+	}
+	var deps []dependency
+	seen := make(map[string]bool)
+	for _, site := range sites {
+		entries, err := os.ReadDir(site)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if !entry.IsDir() || strings.Contains(name, ".") || strings.HasPrefix(name, "_") {
+				continue // .dist-info and .egg-info metadata, __pycache__
+			}
+			module := strings.TrimSuffix(name, "-stubs")
+			dir := filepath.Join(site, name)
+			if seen[module] || !hasFileWithSuffix(dir, ".pyi") {
+				continue
+			}
+			seen[module] = true
+			deps = append(deps, dependency{Module: module, Language: "python", Dir: dir, Ext: ".pyi"})
+		}
+	}
+	return deps
+}
+
+// hasFileWithSuffix reports whether dir holds a file ending in suffix, at
+// any depth
+func hasFileWithSuffix(dir, suffix string) bool {
+	found := false
+	filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() && strings.HasSuffix(path, suffix) {
+			found = true
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return found
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,22 @@
+//go:build !unix
+
+package indexer
+
+import "path/filepath"
+
+// dirID identifies a directory however it is reached. Without inodes, the
+// fully resolved path stands in for them.
+type dirID struct {
+	path string
+}
+
+// statDirID returns the identity of the directory at path, following
+// symlinks
+func statDirID(path string) (dirID, error) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return dirID{}, err
+	}
+	abs, err := filepath.Abs(resolved)
+	return dirID{path: abs}, err
+}
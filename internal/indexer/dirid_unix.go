@@ -0,0 +1,28 @@
+//go:build unix
+
+package indexer
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// dirID identifies a directory however it is reached: by device and inode
+type dirID struct {
+	dev, ino uint64
+}
+
+// statDirID returns the identity of the directory at path, following
+// symlinks
+func statDirID(path string) (dirID, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return dirID{}, err
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return dirID{}, fmt.Errorf("%s: no device and inode", path)
+	}
+	return dirID{dev: uint64(stat.Dev), ino: uint64(stat.Ino)}, nil
+}
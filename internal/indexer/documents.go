@@ -0,0 +1,62 @@
+package indexer
+
+import (
+	"context"
+
+	"github.com/tk-425/Codegraph/internal/lsp"
+)
+
+// openDocuments tracks the files opened on the servers of one language. A
+// language may run several servers (one per tsconfig project), so each file
+// is opened on, and queried through, the server that owns it.
+type openDocuments struct {
+	mgr      *lsp.Manager
+	root     *lsp.Client
+	language string
+	opened   map[*lsp.Client]map[string]bool // client -> opened file URIs
+}
+
+func newOpenDocuments(mgr *lsp.Manager, root *lsp.Client, language string) *openDocuments {
+	return &openDocuments{
+		mgr:      mgr,
+		root:     root,
+		language: language,
+		opened:   make(map[*lsp.Client]map[string]bool),
+	}
+}
+
+// open returns the server owning path, with the file opened on it, and the
+// file's URI; a nil client means the file could not be read or opened
+func (d *openDocuments) open(ctx context.Context, path string) (*lsp.Client, string) {
+	client := d.root
+	if c, err := d.mgr.GetClientForFile(ctx, d.language, path); err == nil {
+		client = c
+	}
+	fileURI := lsp.PathToURI(path)
+	if d.opened[client][fileURI] {
+		return client, fileURI
+	}
+
+	content, err := readFileContent(path)
+	if err != nil {
+		return nil, fileURI
+	}
+	if err := client.DidOpenTextDocument(fileURI, d.language, content); err != nil {
+		return nil, fileURI
+	}
+	if d.opened[client] == nil {
+		d.opened[client] = make(map[string]bool)
+	}
+	d.opened[client][fileURI] = true
+	return client, fileURI
+}
+
+// closeAll closes every opened file
+func (d *openDocuments) closeAll() {
+	for client, files := range d.opened {
+		for fileURI := range files {
+			client.DidCloseTextDocument(fileURI)
+		}
+	}
+	d.opened = make(map[*lsp.Client]map[string]bool)
+}
@@ -0,0 +1,93 @@
+package indexer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/tk-425/Codegraph/internal/db"
+	"github.com/tk-425/Codegraph/internal/embed"
+)
+
+// defaultEmbeddingBatchSize is the number of texts sent per provider call
+const defaultEmbeddingBatchSize = 64
+
+// EmbeddingIndexer computes embeddings for semantic search
+type EmbeddingIndexer struct {
+	db        *db.Manager
+	provider  embed.Provider
+	batchSize int
+	out       io.Writer // Receives the embedding progress line
+}
+
+// NewEmbeddingIndexer creates a new embedding indexer
+func NewEmbeddingIndexer(dbManager *db.Manager, provider embed.Provider, batchSize int) *EmbeddingIndexer {
+	if batchSize <= 0 {
+		batchSize = defaultEmbeddingBatchSize
+	}
+	return &EmbeddingIndexer{
+		db:        dbManager,
+		provider:  provider,
+		batchSize: batchSize,
+		out:       os.Stdout,
+	}
+}
+
+// IndexEmbeddings embeds every symbol whose name, signature, or docs changed
+// since the last build and drops embeddings of removed symbols. Returns the
+// number of symbols embedded.
+func (e *EmbeddingIndexer) IndexEmbeddings(ctx context.Context) (int, error) {
+	model := e.provider.Model()
+	if _, err := e.db.PruneEmbeddings(model); err != nil {
+		return 0, fmt.Errorf("failed to prune embeddings: %w", err)
+	}
+
+	existing, err := e.db.GetEmbeddingHashes(model)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load embeddings: %w", err)
+	}
+
+	// Module declarations carry no behaviour worth matching
+	symbols, err := e.db.ListSymbols(db.QueryOptions{ExcludeKinds: []string{"module"}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list symbols: %w", err)
+	}
+
+	var pending []db.Symbol
+	var texts, hashes []string
+	for _, sym := range symbols {
+		text := embed.SymbolText(sym)
+		sum := sha256.Sum256([]byte(text))
+		hash := hex.EncodeToString(sum[:16])
+		if existing[sym.ID] == hash {
+			continue
+		}
+		pending = append(pending, sym)
+		texts = append(texts, text)
+		hashes = append(hashes, hash)
+	}
+
+	embedded := 0
+	for start := 0; start < len(pending); start += e.batchSize {
+		end := min(start+e.batchSize, len(pending))
+		vectors, err := e.provider.Embed(ctx, texts[start:end])
+		if err != nil {
+			return embedded, err
+		}
+		for j, vec := range vectors {
+			sym := pending[start+j]
+			if err := e.db.UpsertEmbedding(sym.ID, model, hashes[start+j], vec); err != nil {
+				return embedded, fmt.Errorf("failed to store embedding for %s: %w", sym.ID, err)
+			}
+			embedded++
+		}
+		fmt.Fprintf(e.out, "\r   %d/%d symbols embedded ", embedded, len(pending))
+	}
+	if len(pending) > 0 {
+		fmt.Fprintln(e.out)
+	}
+	return embedded, nil
+}
@@ -0,0 +1,494 @@
+package indexer
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+// Entry point types, in priority order: a symbol matching several is
+// tagged with the first
+const (
+	EntrypointMain = "main" // Program entry: func main, static void main, if __name__ == "__main__"
+	EntrypointHTTP = "http" // Function registered as an HTTP route handler
+	EntrypointCLI  = "cli"  // Function run by a CLI command (cobra Run, click command, ...)
+	EntrypointAPI  = "api"  // Exported library API
+)
+
+// EntrypointTypes lists the entry point types in priority order
+var EntrypointTypes = []string{EntrypointMain, EntrypointHTTP, EntrypointCLI, EntrypointAPI}
+
+// apiKinds are the symbol kinds that can be part of a library's API
+var apiKinds = map[string]bool{
+	"function": true, "method": true, "constructor": true, "class": true, "struct": true,
+	"interface": true, "enum": true, "type": true, "trait": true, "constant": true, "variable": true,
+}
+
+// Registrations that name a handler: a route with its handler as the last
+// argument, or a CLI command field or callback. The last group holds the
+// handler expression.
+var (
+	goRouteCall     = regexp.MustCompile(`\.(?:HandleFunc|Handle|Get|Post|Put|Patch|Delete|Head|Options|Connect|Trace|Any|GET|POST|PUT|PATCH|DELETE|HEAD|OPTIONS|CONNECT|TRACE|Match)\(\s*"[^"]*"\s*,(.*)\)`)
+	jsRouteCall     = regexp.MustCompile("\\.(?:get|post|put|patch|delete|all|head|options)\\(\\s*['\"`][^'\"`]*['\"`]\\s*,(.*)\\)")
+	djangoRoute     = regexp.MustCompile(`\b(?:path|re_path|url)\(\s*r?['"][^'"]*['"]\s*,(.*)\)`)
+	rustRouteCall   = regexp.MustCompile(`\.route\(\s*"[^"]*"\s*,(.*)\)`)
+	goCommandField  = regexp.MustCompile(`^\s*(?:Run|RunE|PreRun|PreRunE|PersistentPreRun|PersistentPreRunE|Action)\s*:\s*([\w.]+)\s*,?\s*$`)
+	pyCommandFunc   = regexp.MustCompile(`\bset_defaults\(\s*func\s*=\s*([\w.]+)`)
+	jsCommandAction = regexp.MustCompile(`\.action\(\s*([\w.]+)\s*\)`)
+)
+
+// Decorators, annotations and attributes that make the declaration below
+// them an HTTP handler or a CLI command
+var (
+	httpDecorator = regexp.MustCompile(`^(?:@[\w.]*\.(?:route|get|post|put|patch|delete|head|options|api_route|websocket)\(|@(?:Get|Post|Put|Patch|Delete|Request)Mapping\b|@(?:GET|POST|PUT|PATCH|DELETE|Path)\b|#\[(?:actix_web::)?(?:get|post|put|patch|delete|route)\(|\[(?:Http(?:Get|Post|Put|Patch|Delete)|Route)\b)`)
+	cliDecorator  = regexp.MustCompile(`^@(?:[\w]+\.)*(?:command|group)\(`)
+)
+
+var (
+	goPackageMain = regexp.MustCompile(`(?m)^package\s+main\b`)
+	goReceiver    = regexp.MustCompile(`^\s*func\s*\(\s*(?:\w+\s+)?\*?\s*(\w+)`)
+	pyMainGuard   = regexp.MustCompile(`^if\s+__name__\s*==\s*['"]__main__['"]\s*:`)
+	pyCall        = regexp.MustCompile(`\b(\w+)\(`)
+	pyAll         = regexp.MustCompile(`(?s)__all__\s*\+?=\s*[\[(](.*?)[\])]`)
+	pyQuoted      = regexp.MustCompile(`['"](\w+)['"]`)
+	identifier    = regexp.MustCompile(`^[\p{L}_$][\p{L}\p{N}_$]*$`)
+)
+
+// EntrypointIndexer tags the symbols analyses start from: program mains,
+// HTTP handlers, CLI command functions and exported library API
+type EntrypointIndexer struct {
+	db       *db.Manager
+	rootPath string
+}
+
+// NewEntrypointIndexer creates an entry point indexer
+func NewEntrypointIndexer(dbManager *db.Manager, rootPath string) *EntrypointIndexer {
+	return &EntrypointIndexer{
+		db:       dbManager,
+		rootPath: rootPath,
+	}
+}
+
+// entrypointRef is a handler registered by name, resolved once every file
+// has been read since it may be declared in another one
+type entrypointRef struct {
+	name, entrypointType string
+	file                 FileInfo
+}
+
+// IndexEntrypoints re-tags the entry points of every file, since a handler
+// can be registered in a file other than the one declaring it. It returns
+// the number of symbols tagged, by type.
+func (e *EntrypointIndexer) IndexEntrypoints(ctx context.Context, files []FileInfo) (map[string]int, error) {
+	if err := e.db.ClearEntrypoints(); err != nil {
+		return nil, err
+	}
+
+	tags := make(map[string]string)
+	tag := func(id, entrypointType string) {
+		if current, ok := tags[id]; !ok || entrypointPriority(entrypointType) < entrypointPriority(current) {
+			tags[id] = entrypointType
+		}
+	}
+	var refs []entrypointRef
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if IsTestFile(file.RelPath) {
+			continue
+		}
+		symbols, err := e.db.GetFileSymbols(file.Path)
+		if err != nil {
+			return nil, err
+		}
+		if len(symbols) == 0 {
+			continue
+		}
+		content, err := os.ReadFile(file.Path)
+		if err != nil {
+			continue // Removed since it was scanned
+		}
+		local, fileRefs := fileEntrypoints(file, string(content), symbols)
+		for id, t := range local {
+			tag(id, t)
+		}
+		refs = append(refs, fileRefs...)
+	}
+
+	for _, ref := range refs {
+		ids, err := resolveHandler(e.db, ref.name, ref.file)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			tag(id, ref.entrypointType)
+		}
+	}
+
+	counts := make(map[string]int)
+	for id, t := range tags {
+		if err := e.db.SetEntrypoint(id, t); err != nil {
+			return counts, err
+		}
+		counts[t]++
+	}
+	return counts, nil
+}
+
+// resolveHandler finds the functions a registration in file names: those
+// in the registering file, else in its directory (package), else the only
+// one with that name in the project
+func resolveHandler(dbManager *db.Manager, name string, file FileInfo) ([]string, error) {
+	candidates, err := dbManager.FindSymbolsByName(name, db.QueryOptions{Tests: db.TestsExclude})
+	if err != nil {
+		return nil, err
+	}
+	var callable []db.Symbol
+	for _, c := range candidates {
+		if c.Kind == "function" || c.Kind == "method" || c.Kind == "class" {
+			callable = append(callable, c)
+		}
+	}
+	for _, same := range []func(db.Symbol) bool{
+		func(s db.Symbol) bool { return s.File == file.Path },
+		func(s db.Symbol) bool { return filepath.Dir(s.File) == filepath.Dir(file.Path) },
+	} {
+		var ids []string
+		for _, c := range callable {
+			if same(c) {
+				ids = append(ids, c.ID)
+			}
+		}
+		if len(ids) > 0 {
+			return ids, nil
+		}
+	}
+	if len(callable) == 1 {
+		return []string{callable[0].ID}, nil
+	}
+	return nil, nil
+}
+
+func entrypointPriority(entrypointType string) int {
+	for i, t := range EntrypointTypes {
+		if t == entrypointType {
+			return i
+		}
+	}
+	return len(EntrypointTypes)
+}
+
+// fileEntrypoints returns the entry point type of the symbols of a file
+// that it alone determines, and the handlers it registers by name
+func fileEntrypoints(file FileInfo, content string, symbols []db.Symbol) (map[string]string, []entrypointRef) {
+	lines := strings.Split(content, "\n")
+	declLine := func(s db.Symbol) string {
+		if s.Line < 1 || s.Line > len(lines) {
+			return ""
+		}
+		return lines[s.Line-1]
+	}
+	tags := make(map[string]string)
+	set := func(id, t string) {
+		if _, ok := tags[id]; !ok {
+			tags[id] = t
+		}
+	}
+
+	// Mains first, so they win over the other types
+	switch file.Language {
+	case "python":
+		for _, name := range pyMainCalls(lines) {
+			for _, s := range symbols {
+				if s.Name == name && s.Kind == "function" && s.Scope == "" {
+					set(s.ID, EntrypointMain)
+				}
+			}
+		}
+	default:
+		goMain := file.Language != "go" || goPackageMain.MatchString(content)
+		for _, s := range symbols {
+			if isMain(file.Language, s, declLine(s)) && goMain {
+				set(s.ID, EntrypointMain)
+			}
+		}
+	}
+
+	for _, s := range symbols {
+		if s.Kind != "function" && s.Kind != "method" {
+			continue
+		}
+		for _, d := range decorators(lines, s.Line) {
+			if httpDecorator.MatchString(d) {
+				set(s.ID, EntrypointHTTP)
+			} else if cliDecorator.MatchString(d) {
+				set(s.ID, EntrypointCLI)
+			}
+		}
+	}
+
+	var refs []entrypointRef
+	add := func(expr, t string) {
+		if name := handlerName(expr); name != "" {
+			refs = append(refs, entrypointRef{name: name, entrypointType: t, file: file})
+		}
+	}
+	for _, line := range lines {
+		switch file.Language {
+		case "go":
+			if m := goRouteCall.FindStringSubmatch(line); m != nil {
+				add(lastArgument(m[1]), EntrypointHTTP)
+			}
+			if m := goCommandField.FindStringSubmatch(line); m != nil {
+				add(m[1], EntrypointCLI)
+			}
+		case "typescript", "typescriptreact":
+			if m := jsRouteCall.FindStringSubmatch(line); m != nil {
+				add(lastArgument(m[1]), EntrypointHTTP)
+			}
+			if m := jsCommandAction.FindStringSubmatch(line); m != nil {
+				add(m[1], EntrypointCLI)
+			}
+		case "python":
+			if m := djangoRoute.FindStringSubmatch(line); m != nil {
+				add(firstArgument(m[1]), EntrypointHTTP)
+			}
+			if m := pyCommandFunc.FindStringSubmatch(line); m != nil {
+				add(m[1], EntrypointCLI)
+			}
+		case "rust":
+			if m := rustRouteCall.FindStringSubmatch(line); m != nil {
+				add(lastArgument(m[1]), EntrypointHTTP)
+			}
+		}
+	}
+
+	for _, id := range exportedAPI(file, content, symbols, declLine) {
+		set(id, EntrypointAPI)
+	}
+	return tags, refs
+}
+
+// isMain reports whether s is the program entry of a language with a
+// main function
+func isMain(language string, s db.Symbol, decl string) bool {
+	switch language {
+	case "go", "c", "cpp", "rust":
+		return s.Name == "main" && s.Kind == "function" && s.Scope == ""
+	case "java":
+		return s.Name == "main" && s.Kind == "method" && strings.Contains(decl, "static")
+	case "csharp":
+		return s.Name == "Main" && s.Kind == "method" && strings.Contains(decl, "static")
+	}
+	return false
+}
+
+// pyMainCalls returns the functions called in a module's
+// if __name__ == "__main__": block
+func pyMainCalls(lines []string) []string {
+	var names []string
+	for i, line := range lines {
+		if !pyMainGuard.MatchString(line) {
+			continue
+		}
+		for _, body := range lines[i+1:] {
+			if strings.TrimSpace(body) == "" {
+				continue
+			}
+			if !strings.HasPrefix(body, " ") && !strings.HasPrefix(body, "\t") {
+				break
+			}
+			for _, m := range pyCall.FindAllStringSubmatch(body, -1) {
+				names = append(names, m[1])
+			}
+		}
+	}
+	return names
+}
+
+// decorators returns the decorator, annotation and attribute lines right
+// above the declaration at line (1-indexed), trimmed
+func decorators(lines []string, line int) []string {
+	var found []string
+	for _, i := range decoratorLines(lines, line) {
+		found = append(found, strings.TrimSpace(lines[i-1]))
+	}
+	return found
+}
+
+// decoratorLines returns the line numbers (1-indexed) of the decorators
+// right above the declaration at line, nearest first
+func decoratorLines(lines []string, line int) []int {
+	var found []int
+	for i := line - 2; i >= 0 && i < len(lines); i-- {
+		if !isDecoratorLine(lines[i]) {
+			break
+		}
+		found = append(found, i+1)
+	}
+	return found
+}
+
+// isDecoratorLine reports whether a line starts with a decorator or
+// annotation (@...) or an attribute (#[...], [...])
+func isDecoratorLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "@") || strings.HasPrefix(trimmed, "#[") || strings.HasPrefix(trimmed, "[")
+}
+
+// splitArguments splits an argument list at its top-level commas
+func splitArguments(args string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range args {
+		switch r {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, strings.TrimSpace(args[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, strings.TrimSpace(args[start:]))
+}
+
+// lastArgument returns the last non-empty argument of a list, which is the
+// handler in route registrations that take middleware first
+func lastArgument(args string) string {
+	parts := splitArguments(args)
+	for i := len(parts) - 1; i >= 0; i-- {
+		if parts[i] != "" {
+			return parts[i]
+		}
+	}
+	return ""
+}
+
+func firstArgument(args string) string {
+	return splitArguments(args)[0]
+}
+
+// handlerAdapters wrap a handler without being one: http.HandlerFunc(h),
+// axum's get(h), ...
+var handlerAdapters = map[string]bool{
+	"HandlerFunc": true, "StripPrefix": true, "get": true, "post": true, "put": true,
+	"patch": true, "delete": true, "head": true, "options": true, "any": true,
+}
+
+// handlerName returns the function an argument names, unwrapping handler
+// adapters. A factory call such as NewHandler(store) names the factory and
+// View.as_view() the view class; function literals name none.
+func handlerName(expr string) string {
+	expr = strings.TrimSpace(expr)
+	for {
+		if strings.HasPrefix(expr, "func") || strings.Contains(expr, "=>") || strings.HasPrefix(expr, "lambda") {
+			return ""
+		}
+		open := strings.Index(expr, "(")
+		if open < 0 || !strings.HasSuffix(expr, ")") {
+			break
+		}
+		callee := expr[:open]
+		if inner := strings.TrimSpace(expr[open+1 : len(expr)-1]); inner != "" && handlerAdapters[lastComponent(callee)] {
+			expr = lastArgument(inner)
+		} else if strings.HasSuffix(callee, ".as_view") {
+			expr = strings.TrimSuffix(callee, ".as_view")
+		} else {
+			expr = callee
+		}
+	}
+	expr = lastComponent(strings.TrimPrefix(expr, "&"))
+	if !identifier.MatchString(expr) {
+		return ""
+	}
+	return expr
+}
+
+// lastComponent strips the package, module or receiver qualifying a name
+func lastComponent(name string) string {
+	if i := strings.LastIndexAny(name, ".:"); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// exportedAPI returns the symbols of a file that other code can import, by
+// the visibility rules of its language. Go packages named main or under
+// internal/, Rust binaries and Python modules without __all__ export none.
+func exportedAPI(file FileInfo, content string, symbols []db.Symbol, declLine func(db.Symbol) string) []string {
+	rel := filepath.ToSlash(file.RelPath)
+	var ids []string
+	switch file.Language {
+	case "go":
+		if goPackageMain.MatchString(content) || rel == "internal" || strings.HasPrefix(rel, "internal/") || strings.Contains(rel, "/internal/") {
+			return nil
+		}
+		for _, s := range symbols {
+			if !apiKinds[s.Kind] || !isExported(s.Name) {
+				continue
+			}
+			owner := s.Scope
+			if m := goReceiver.FindStringSubmatch(declLine(s)); m != nil {
+				owner = m[1]
+			}
+			if owner == "" || isExported(owner) {
+				ids = append(ids, s.ID)
+			}
+		}
+	case "rust":
+		if path.Base(rel) == "main.rs" || strings.Contains("/"+rel, "/src/bin/") {
+			return nil
+		}
+		for _, s := range symbols {
+			if decl := strings.TrimSpace(declLine(s)); apiKinds[s.Kind] && strings.HasPrefix(decl, "pub ") {
+				ids = append(ids, s.ID)
+			}
+		}
+	case "typescript", "typescriptreact":
+		for _, s := range symbols {
+			if decl := strings.TrimSpace(declLine(s)); apiKinds[s.Kind] && s.Scope == "" && strings.HasPrefix(decl, "export ") {
+				ids = append(ids, s.ID)
+			}
+		}
+	case "python":
+		m := pyAll.FindStringSubmatch(content)
+		if m == nil {
+			return nil
+		}
+		exported := make(map[string]bool)
+		for _, q := range pyQuoted.FindAllStringSubmatch(m[1], -1) {
+			exported[q[1]] = true
+		}
+		for _, s := range symbols {
+			if s.Scope == "" && exported[s.Name] {
+				ids = append(ids, s.ID)
+			}
+		}
+	case "java", "csharp":
+		for _, s := range symbols {
+			if apiKinds[s.Kind] && strings.Contains(" "+declLine(s), " public ") {
+				ids = append(ids, s.ID)
+			}
+		}
+	}
+	return ids
+}
+
+// isExported reports whether a Go identifier is exported
+func isExported(name string) bool {
+	for _, r := range name {
+		return unicode.IsUpper(r)
+	}
+	return false
+}
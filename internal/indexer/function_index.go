@@ -0,0 +1,72 @@
+package indexer
+
+import (
+	"path/filepath"
+	"sort"
+
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+// functionIndex finds the function containing a line without querying the
+// database per call site: the function symbols of a language are loaded
+// once and kept per file, sorted by line
+type functionIndex struct {
+	files map[string]*fileFunctions // By absolute path
+}
+
+// fileFunctions are the functions of one file sorted by start line, with
+// the furthest line reached by any of the first i+1 of them in reach[i], so
+// containment is two binary searches
+type fileFunctions struct {
+	symbols []db.Symbol
+	reach   []int
+}
+
+// noEndLine is the reach of a function without an end line, which contains
+// every line after its start
+const noEndLine = int(^uint(0) >> 1)
+
+// newFunctionIndex indexes function symbols by file
+func newFunctionIndex(symbols []db.Symbol) *functionIndex {
+	idx := &functionIndex{files: make(map[string]*fileFunctions)}
+	for _, sym := range symbols {
+		absFile, _ := filepath.Abs(sym.File)
+		f := idx.files[absFile]
+		if f == nil {
+			f = &fileFunctions{}
+			idx.files[absFile] = f
+		}
+		f.symbols = append(f.symbols, sym)
+	}
+	for _, f := range idx.files {
+		sort.SliceStable(f.symbols, func(i, j int) bool { return f.symbols[i].Line < f.symbols[j].Line })
+		f.reach = make([]int, len(f.symbols))
+		furthest := 0
+		for i, sym := range f.symbols {
+			end := noEndLine
+			if sym.EndLine != nil {
+				end = *sym.EndLine
+			}
+			furthest = max(furthest, end)
+			f.reach[i] = furthest
+		}
+	}
+	return idx
+}
+
+// containing returns the ID of the first function in source order whose
+// range holds line, or "" when none does
+func (idx *functionIndex) containing(file string, line int) string {
+	absFile, _ := filepath.Abs(file)
+	f := idx.files[absFile]
+	if f == nil {
+		return ""
+	}
+	// Functions starting at or before line, then the first of them reaching it
+	started := sort.Search(len(f.symbols), func(i int) bool { return f.symbols[i].Line > line })
+	first := sort.Search(started, func(i int) bool { return f.reach[i] >= line })
+	if first == started {
+		return ""
+	}
+	return f.symbols[first].ID
+}
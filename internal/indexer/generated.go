@@ -0,0 +1,62 @@
+package indexer
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// generatedSampleSize is how much of each file is inspected for markers
+const generatedSampleSize = 8 * 1024
+
+// generatedMarkers recognise the common "this file is generated" headers:
+// Go's "// Code generated ... DO NOT EDIT.", Facebook-style @generated, and
+// the .NET <auto-generated> comment.
+var generatedMarkers = []*regexp.Regexp{
+	regexp.MustCompile(`(?m)^\s*(//|#|/\*|\*)\s*Code generated .* DO NOT EDIT\.?`),
+	regexp.MustCompile(`@generated\b`),
+	regexp.MustCompile(`<auto-generated`),
+}
+
+// isGeneratedFile reports whether the file looks machine-generated: it
+// carries a generated marker near the top, or is minified JavaScript.
+func isGeneratedFile(path, language string) (bool, error) {
+	if strings.HasSuffix(path, ".min.js") || strings.HasSuffix(path, ".min.mjs") {
+		return true, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	sample := make([]byte, generatedSampleSize)
+	n, err := io.ReadFull(f, sample)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return false, err
+	}
+	sample = sample[:n]
+
+	for _, marker := range generatedMarkers {
+		if marker.Match(sample) {
+			return true, nil
+		}
+	}
+	return isTypeScriptFamily(language) && isMinified(sample), nil
+}
+
+// isMinified flags samples dominated by very long lines, which hand-written
+// code never has: fewer than one line break per 500 bytes.
+func isMinified(sample []byte) bool {
+	if len(sample) < 2048 {
+		return false
+	}
+	return bytes.Count(sample, []byte{'\n'}) < len(sample)/500
+}
+
+func isTypeScriptFamily(language string) bool {
+	return language == "typescript" || language == "typescriptreact" || language == "javascript"
+}
@@ -0,0 +1,300 @@
+package indexer
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+// rpcInputType reads the request message of an RPC signature:
+// "rpc GetUser(GetUserRequest) returns (User);" has GetUserRequest
+var rpcInputType = regexp.MustCompile(`rpc\s+\w+\s*\(\s*(?:stream\s+)?\.?([\w.]+)\s*\)`)
+
+var (
+	// rpcWord matches the identifiers in source text, for containsWord
+	// and endsWord
+	rpcWord = regexp.MustCompile(`\w+`)
+	// rpcMethodCall matches a method call, "client.GetUser(", capturing
+	// the method name
+	rpcMethodCall = regexp.MustCompile(`\.(\w+)\s*\(`)
+)
+
+// serverBaseSuffixes follow the service name in the base class of a
+// generated server: UserServiceServicer, UserServiceImplBase,
+// UserServiceBase, UnimplementedUserServiceServer
+var serverBaseSuffixes = []string{"Servicer", "ImplBase", "Base", "Server"}
+
+// clientStubSuffixes follow the service name in a generated client:
+// NewUserServiceClient, UserServiceStub, UserServiceGrpc.newBlockingStub
+var clientStubSuffixes = []string{"Client", "Stub", "BlockingStub", "FutureStub", "Grpc"}
+
+// grpcStubSuffixes name the files protoc generates, which declare the stub
+// methods an RPC's servers and clients are linked through rather than
+// implementing or calling it
+var grpcStubSuffixes = []string{
+	".pb.go", "_pb2.py", "_pb2.pyi", "_pb2_grpc.py", "_pb.js", "_pb.d.ts", "_grpc_pb.js", "_grpc_pb.d.ts", "Grpc.java", "Grpc.cs",
+}
+
+// rpcInfo is an RPC declared in a .proto file
+type rpcInfo struct {
+	symbol  db.Symbol
+	service string
+	input   string // Request message, unqualified
+}
+
+// RPCIndexer links the RPCs declared in .proto files to the code generated
+// from them: server methods implementing an RPC are recorded as its
+// implementations and client stub calls as calls to it, so 'codegraph
+// callers Service.Method' spans every language of the repository
+type RPCIndexer struct {
+	db *db.Manager
+}
+
+// NewRPCIndexer creates an RPC indexer
+func NewRPCIndexer(dbManager *db.Manager) *RPCIndexer {
+	return &RPCIndexer{db: dbManager}
+}
+
+// IndexRPCs relinks every RPC, since a server or client in any file can use
+// a service declared in another, and returns how many server methods and
+// client call sites were linked
+func (r *RPCIndexer) IndexRPCs(ctx context.Context, files []FileInfo) (int, int, error) {
+	if err := r.db.ClearLinksTo("proto"); err != nil {
+		return 0, 0, err
+	}
+	symbols, err := r.db.ListSymbols(db.QueryOptions{Languages: []string{"proto"}, Kinds: []string{"method"}})
+	if err != nil {
+		return 0, 0, err
+	}
+	var rpcs []rpcInfo
+	for _, s := range symbols {
+		rpc := rpcInfo{symbol: s, service: s.Scope}
+		if m := rpcInputType.FindStringSubmatch(s.Signature); m != nil {
+			rpc.input = m[1][strings.LastIndex(m[1], ".")+1:]
+		}
+		rpcs = append(rpcs, rpc)
+	}
+	if len(rpcs) == 0 {
+		return 0, 0, nil
+	}
+
+	servers, clients := 0, 0
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return servers, clients, err
+		}
+		if file.Language == "proto" || isGRPCStub(file) {
+			continue
+		}
+		content, err := os.ReadFile(file.Path)
+		if err != nil {
+			continue // Removed since it was scanned
+		}
+		fileSymbols, err := r.db.GetFileSymbols(file.Path)
+		if err != nil {
+			return servers, clients, err
+		}
+		lines := strings.Split(string(content), "\n")
+
+		for _, th := range rpcServers(lines, fileSymbols, rpcs) {
+			if err := r.db.InsertTypeHierarchy(th); err != nil {
+				return servers, clients, err
+			}
+			servers++
+		}
+		for _, call := range rpcClientCalls(string(content), lines, fileSymbols, rpcs) {
+			call.File = file.Path
+			if err := r.db.InsertCall(call); err != nil {
+				return servers, clients, err
+			}
+			clients++
+		}
+	}
+	return servers, clients, nil
+}
+
+// isGRPCStub reports whether file was generated by protoc
+func isGRPCStub(file FileInfo) bool {
+	for _, suffix := range grpcStubSuffixes {
+		if strings.HasSuffix(file.Path, suffix) {
+			return true
+		}
+	}
+	generated, err := isGeneratedFile(file.Path, file.Language)
+	return err == nil && generated
+}
+
+// rpcServers returns the methods of a file that serve an RPC: named after it
+// (GetUser, or getUser in Java and JavaScript) and either taking its
+// request message or declared in a class extending the service's generated
+// base (UserServiceServicer, UserServiceImplBase, UserServiceBase,
+// UnimplementedUserServiceServer)
+func rpcServers(lines []string, symbols []db.Symbol, rpcs []rpcInfo) []*db.TypeHierarchy {
+	classes := make(map[string]db.Symbol)
+	for _, s := range symbols {
+		switch s.Kind {
+		case "class", "struct":
+			classes[qualifyName(s.Scope, scopeSeparator(s.Language), s.Name)] = s
+		}
+	}
+
+	var links []*db.TypeHierarchy
+	for _, s := range symbols {
+		if s.Kind != "method" && s.Kind != "function" {
+			continue
+		}
+		for _, rpc := range rpcs {
+			if s.Name != rpc.symbol.Name && s.Name != lowerFirst(rpc.symbol.Name) {
+				continue
+			}
+			serves := rpc.input != "" && containsWord(declarationText(lines, s.Line), rpc.input)
+			if class, ok := classes[s.Scope]; ok && !serves {
+				serves = endsWord(declarationText(lines, class.Line), rpc.service, serverBaseSuffixes)
+			}
+			if serves {
+				links = append(links, &db.TypeHierarchy{ChildID: s.ID, ParentID: rpc.symbol.ID, Relationship: "implements"})
+			}
+		}
+	}
+	return links
+}
+
+// rpcClientCalls returns the calls a file makes through a service's client
+// stub: "client.GetUser(ctx, req)", in files that create a client of the
+// service (NewUserServiceClient, UserServiceStub, UserServiceGrpc.new*Stub,
+// new UserServiceClient). Java and JavaScript stubs lower-case the method
+// name, and C# adds an Async variant. The caller is the innermost function
+// around the call; calls outside any function are not linked.
+func rpcClientCalls(content string, lines []string, symbols []db.Symbol, rpcs []rpcInfo) []*db.Call {
+	clientOf := make(map[string]bool)
+	byName := make(map[string][]rpcInfo)
+	for _, rpc := range rpcs {
+		used, ok := clientOf[rpc.service]
+		if !ok {
+			used = endsWord(content, rpc.service, clientStubSuffixes)
+			clientOf[rpc.service] = used
+		}
+		if !used {
+			continue
+		}
+		for _, name := range []string{rpc.symbol.Name, lowerFirst(rpc.symbol.Name), rpc.symbol.Name + "Async"} {
+			if !containsRPC(byName[name], rpc) {
+				byName[name] = append(byName[name], rpc)
+			}
+		}
+	}
+	if len(byName) == 0 {
+		return nil
+	}
+
+	declared := make(map[int]bool, len(symbols))
+	for _, s := range symbols {
+		declared[s.Line] = true
+	}
+	var calls []*db.Call
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if declared[i+1] || strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "*") {
+			continue
+		}
+		for _, m := range rpcMethodCall.FindAllStringSubmatchIndex(line, -1) {
+			called := byName[line[m[2]:m[3]]]
+			if len(called) == 0 {
+				continue
+			}
+			caller := enclosingFunction(symbols, i+1)
+			if caller == nil {
+				continue
+			}
+			column := m[2]
+			argCount, args := callArgumentsAt(content, i, column)
+			for _, rpc := range called {
+				calls = append(calls, &db.Call{
+					CallerID: caller.ID,
+					CalleeID: rpc.symbol.ID,
+					Line:     i + 1,
+					Column:   column,
+					ArgCount: argCount,
+					Args:     args,
+				})
+			}
+		}
+	}
+	return calls
+}
+
+func containsRPC(rpcs []rpcInfo, rpc rpcInfo) bool {
+	for _, r := range rpcs {
+		if r.symbol.ID == rpc.symbol.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// enclosingFunction returns the innermost function or method whose range
+// contains line, or nil
+func enclosingFunction(symbols []db.Symbol, line int) *db.Symbol {
+	var best *db.Symbol
+	for i := range symbols {
+		s := &symbols[i]
+		switch s.Kind {
+		case "function", "method", "constructor":
+		default:
+			continue
+		}
+		if s.EndLine == nil || s.Line > line || *s.EndLine < line {
+			continue
+		}
+		if best == nil || s.Line > best.Line {
+			best = s
+		}
+	}
+	return best
+}
+
+// declarationText returns the declaration starting at line, up to the line
+// opening its body (at most five lines), so parameters and base classes
+// wrapped onto later lines are included
+func declarationText(lines []string, line int) string {
+	var text []string
+	for i := line; i >= 1 && i <= len(lines) && len(text) < 5; i++ {
+		text = append(text, lines[i-1])
+		trimmed := strings.TrimSpace(lines[i-1])
+		if strings.Contains(trimmed, "{") || strings.HasSuffix(trimmed, ":") {
+			break
+		}
+	}
+	return strings.Join(text, "\n")
+}
+
+// containsWord reports whether word occurs in text as a whole identifier
+func containsWord(text, word string) bool {
+	return slices.Contains(rpcWord.FindAllString(text, -1), word)
+}
+
+// endsWord reports whether an identifier in text ends with prefix and one
+// of suffixes: UnimplementedUserServiceServer ends with UserService and
+// Server
+func endsWord(text, prefix string, suffixes []string) bool {
+	for _, word := range rpcWord.FindAllString(text, -1) {
+		for _, suffix := range suffixes {
+			if strings.HasSuffix(word, prefix+suffix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// lowerFirst lower-cases the first letter of name: GetUser becomes getUser
+func lowerFirst(name string) string {
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToLower(r)) + name[size:]
+}
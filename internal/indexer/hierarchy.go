@@ -4,12 +4,14 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	sitter "github.com/smacker/go-tree-sitter"
 	"github.com/smacker/go-tree-sitter/csharp"
 	"github.com/smacker/go-tree-sitter/golang"
 	"github.com/smacker/go-tree-sitter/java"
+	"github.com/smacker/go-tree-sitter/javascript"
 	"github.com/smacker/go-tree-sitter/ocaml"
 	"github.com/smacker/go-tree-sitter/python"
 	"github.com/smacker/go-tree-sitter/rust"
@@ -54,21 +56,14 @@ func (h *HierarchyIndexer) IndexHierarchyLSP(ctx context.Context, language strin
 	}
 
 	count := 0
-	openedFiles := make(map[string]bool)
+	docs := newOpenDocuments(h.lsp, client, language)
+	defer docs.closeAll()
 
 	for _, sym := range symbols {
-		fileURI := "file://" + sym.File
-
-		// Open file if not already opened
-		if !openedFiles[fileURI] {
-			content, err := os.ReadFile(sym.File)
-			if err != nil {
-				continue
-			}
-			if err := client.DidOpenTextDocument(fileURI, language, string(content)); err != nil {
-				continue
-			}
-			openedFiles[fileURI] = true
+		// Open file on its server if not already opened
+		client, fileURI := docs.open(ctx, sym.File)
+		if client == nil {
+			continue
 		}
 
 		// Get supertypes for this symbol
@@ -96,10 +91,12 @@ func (h *HierarchyIndexer) IndexHierarchyLSP(ctx context.Context, language strin
 				relationship = "implements"
 			}
 
+			parentName, typeArgs := splitTypeArgs(parent.Name)
 			th := &db.TypeHierarchy{
 				ChildID:      sym.ID,
-				ParentID:     parent.Name, // Will be resolved to ID later
+				ParentID:     parentName, // Will be resolved to ID later
 				Relationship: relationship,
+				TypeArgs:     typeArgs,
 			}
 
 			if err := h.db.InsertTypeHierarchy(th); err != nil {
@@ -109,17 +106,12 @@ func (h *HierarchyIndexer) IndexHierarchyLSP(ctx context.Context, language strin
 		}
 	}
 
-	// Close opened files
-	for fileURI := range openedFiles {
-		client.DidCloseTextDocument(fileURI)
-	}
-
 	return count, nil
 }
 
 // IndexHierarchyTreeSitter extracts type hierarchy using tree-sitter parsing
 func (h *HierarchyIndexer) IndexHierarchyTreeSitter(ctx context.Context, file FileInfo) (int, error) {
-	lang := h.getLanguage(file.Language)
+	lang := h.getLanguage(grammarFor(file))
 	if lang == nil {
 		return 0, nil // Language not supported
 	}
@@ -142,6 +134,28 @@ func (h *HierarchyIndexer) IndexHierarchyTreeSitter(ctx context.Context, file Fi
 
 	count := 0
 	for _, rel := range relationships {
+		// Base<User> resolves to Base; the arguments are kept for display
+		if rel.TypeArgs == "" {
+			rel.ParentID, rel.TypeArgs = splitTypeArgs(rel.ParentID)
+		}
+
+		// Rust trait methods: resolve the trait, then its Trait::method item
+		if owner, member, ok := strings.Cut(rel.ParentID, "::"); ok {
+			traits, err := h.db.GetSymbolByName(owner, []string{file.Language})
+			if err != nil || len(traits) == 0 {
+				continue
+			}
+			parent, err := h.db.GetSymbolByID(traits[0].ID + "::" + member)
+			if err != nil || parent == nil {
+				continue
+			}
+			rel.ParentID = parent.ID
+			if err := h.db.InsertTypeHierarchy(rel); err == nil {
+				count++
+			}
+			continue
+		}
+
 		// Look up the parent symbol ID by name
 		parentSymbols, err := h.db.GetSymbolByName(rel.ParentID, []string{file.Language})
 		if err != nil || len(parentSymbols) == 0 {
@@ -170,8 +184,10 @@ func (h *HierarchyIndexer) getLanguage(lang string) *sitter.Language {
 		return csharp.GetLanguage()
 	case "java":
 		return java.GetLanguage()
-	case "typescript", "typescriptreact", "javascript":
+	case "typescript", "typescriptreact":
 		return typescript.GetLanguage()
+	case "javascript":
+		return javascript.GetLanguage()
 	case "python":
 		return python.GetLanguage()
 	case "swift":
@@ -191,13 +207,15 @@ func (h *HierarchyIndexer) getLanguage(lang string) *sitter.Language {
 func (h *HierarchyIndexer) extractHierarchy(node *sitter.Node, content []byte, file FileInfo) []*db.TypeHierarchy {
 	var relationships []*db.TypeHierarchy
 
-	switch file.Language {
+	switch grammarFor(file) {
 	case "csharp":
 		relationships = h.extractCSharpHierarchy(node, content, file)
 	case "java":
 		relationships = h.extractJavaHierarchy(node, content, file)
-	case "typescript", "typescriptreact", "javascript":
+	case "typescript", "typescriptreact":
 		relationships = h.extractTypeScriptHierarchy(node, content, file)
+	case "javascript":
+		relationships = h.extractJavaScriptHierarchy(node, content, file)
 	case "python":
 		relationships = h.extractPythonHierarchy(node, content, file)
 	case "swift":
@@ -269,72 +287,53 @@ func (h *HierarchyIndexer) extractCSharpHierarchy(node *sitter.Node, content []b
 	return relationships
 }
 
-// Java hierarchy: class Foo extends Bar implements IBaz
+// javaTypeDeclarations are the Java declarations that can have supertypes
+var javaTypeDeclarations = map[string]bool{
+	"class_declaration":     true,
+	"interface_declaration": true,
+	"enum_declaration":      true,
+}
+
+// Java hierarchy: class Foo extends Bar implements IBaz, interface A
+// extends B, C, and enum E implements I, nested ones included
 func (h *HierarchyIndexer) extractJavaHierarchy(node *sitter.Node, content []byte, file FileInfo) []*db.TypeHierarchy {
 	var relationships []*db.TypeHierarchy
 
 	h.walkTree(node, func(n *sitter.Node) {
-		if n.Type() != "class_declaration" {
+		if !javaTypeDeclarations[n.Type()] {
 			return
 		}
-
-		// Find class name, superclass, and interfaces by iterating children
-		var className string
-		var superclass *sitter.Node
-		var interfaces *sitter.Node
-
-		for i := 0; i < int(n.NamedChildCount()); i++ {
-			child := n.NamedChild(i)
-			switch child.Type() {
-			case "identifier":
-				className = child.Content(content)
-			case "superclass":
-				superclass = child
-			case "super_interfaces":
-				interfaces = child
-			}
-		}
-
+		className := javaQualifiedName(n, content)
 		if className == "" {
 			return
 		}
-
 		childID := fmt.Sprintf("%s#%s", file.RelPath, className)
 
-		// Extract superclass
-		if superclass != nil {
-			// superclass contains type_identifier
-			for i := 0; i < int(superclass.NamedChildCount()); i++ {
-				typeNode := superclass.NamedChild(i)
-				parentName := h.getTypeName(typeNode, content)
-				if parentName != "" {
+		var add func(list *sitter.Node, relationship string)
+		add = func(list *sitter.Node, relationship string) {
+			for i := 0; i < int(list.NamedChildCount()); i++ {
+				typeNode := list.NamedChild(i)
+				if typeNode.Type() == "type_list" {
+					// super_interfaces and extends_interfaces wrap a type_list
+					add(typeNode, relationship)
+					continue
+				}
+				if parentName := h.getTypeName(typeNode, content); parentName != "" {
 					relationships = append(relationships, &db.TypeHierarchy{
 						ChildID:      childID,
 						ParentID:     parentName,
-						Relationship: "extends",
+						Relationship: relationship,
 					})
 				}
 			}
 		}
-
-		// Extract interfaces (super_interfaces -> type_list -> type_identifier)
-		if interfaces != nil {
-			// Look for type_list inside super_interfaces
-			for i := 0; i < int(interfaces.NamedChildCount()); i++ {
-				typeList := interfaces.NamedChild(i)
-				if typeList.Type() == "type_list" {
-					for j := 0; j < int(typeList.NamedChildCount()); j++ {
-						typeNode := typeList.NamedChild(j)
-						parentName := h.getTypeName(typeNode, content)
-						if parentName != "" {
-							relationships = append(relationships, &db.TypeHierarchy{
-								ChildID:      childID,
-								ParentID:     parentName,
-								Relationship: "implements",
-							})
-						}
-					}
-				}
+		for i := 0; i < int(n.NamedChildCount()); i++ {
+			child := n.NamedChild(i)
+			switch child.Type() {
+			case "superclass", "extends_interfaces":
+				add(child, "extends")
+			case "super_interfaces":
+				add(child, "implements")
 			}
 		}
 	})
@@ -342,12 +341,37 @@ func (h *HierarchyIndexer) extractJavaHierarchy(node *sitter.Node, content []byt
 	return relationships
 }
 
-// TypeScript hierarchy: class Foo extends Bar implements IBaz
+// javaQualifiedName returns the name of a Java type declaration qualified
+// by the types it is nested in (Outer.Inner), as its symbol ID has it
+func javaQualifiedName(n *sitter.Node, content []byte) string {
+	nameNode := n.ChildByFieldName("name")
+	if nameNode == nil {
+		return ""
+	}
+	name := nameNode.Content(content)
+	for p := n.Parent(); p != nil; p = p.Parent() {
+		if !javaTypeDeclarations[p.Type()] {
+			continue
+		}
+		if outer := p.ChildByFieldName("name"); outer != nil {
+			name = outer.Content(content) + "." + name
+		}
+	}
+	return name
+}
+
+// TypeScript hierarchy: class Foo extends Bar implements IBaz, interface
+// A extends B, C and the type aliases composing types (type X = Y & Z)
 func (h *HierarchyIndexer) extractTypeScriptHierarchy(node *sitter.Node, content []byte, file FileInfo) []*db.TypeHierarchy {
 	var relationships []*db.TypeHierarchy
 
 	h.walkTree(node, func(n *sitter.Node) {
-		if n.Type() != "class_declaration" {
+		switch n.Type() {
+		case "interface_declaration", "type_alias_declaration":
+			relationships = append(relationships, h.typeScriptTypeRelations(n, content, file)...)
+			return
+		case "class_declaration":
+		default:
 			return
 		}
 
@@ -368,6 +392,14 @@ func (h *HierarchyIndexer) extractTypeScriptHierarchy(node *sitter.Node, content
 						// extends
 						for k := 0; k < int(clause.NamedChildCount()); k++ {
 							typeNode := clause.NamedChild(k)
+							if typeNode.Type() == "type_arguments" {
+								// extends Base<User> holds the arguments
+								// beside the superclass, not in a type
+								if last := len(relationships) - 1; last >= 0 {
+									relationships[last].ParentID += typeNode.Content(content)
+								}
+								continue
+							}
 							parentName := h.getTypeName(typeNode, content)
 							relationships = append(relationships, &db.TypeHierarchy{
 								ChildID:      childID,
@@ -395,6 +427,141 @@ func (h *HierarchyIndexer) extractTypeScriptHierarchy(node *sitter.Node, content
 	return relationships
 }
 
+// typeScriptTypeRelations returns the supertypes of a TypeScript interface,
+// which extends each type of its extends clause, and the types a type alias
+// composes: an intersection (type X = Y & Z) extends each of its members
+// and a union (type Shape = Circle | Square) includes each of them. Only
+// named types are related; literals, object types and the alias's own type
+// parameters are skipped.
+func (h *HierarchyIndexer) typeScriptTypeRelations(n *sitter.Node, content []byte, file FileInfo) []*db.TypeHierarchy {
+	nameNode := n.ChildByFieldName("name")
+	if nameNode == nil {
+		return nil
+	}
+	childID := fmt.Sprintf("%s#%s", file.RelPath, nameNode.Content(content))
+
+	params := make(map[string]bool)
+	if typeParams := n.ChildByFieldName("type_parameters"); typeParams != nil {
+		for i := 0; i < int(typeParams.NamedChildCount()); i++ {
+			if param := typeParams.NamedChild(i).ChildByFieldName("name"); param != nil {
+				params[param.Content(content)] = true
+			}
+		}
+	}
+
+	var relationships []*db.TypeHierarchy
+	add := func(typeNode *sitter.Node, relationship string) {
+		switch typeNode.Type() {
+		case "type_identifier", "generic_type", "nested_type_identifier":
+		default:
+			return
+		}
+		parentName := h.getTypeName(typeNode, content)
+		if parentName == "" || params[parentName] {
+			return
+		}
+		relationships = append(relationships, &db.TypeHierarchy{
+			ChildID:      childID,
+			ParentID:     parentName,
+			Relationship: relationship,
+		})
+	}
+
+	if n.Type() == "interface_declaration" {
+		for i := 0; i < int(n.NamedChildCount()); i++ {
+			if clause := n.NamedChild(i); clause.Type() == "extends_type_clause" {
+				for j := 0; j < int(clause.NamedChildCount()); j++ {
+					add(clause.NamedChild(j), "extends")
+				}
+			}
+		}
+		return relationships
+	}
+
+	value := n.ChildByFieldName("value")
+	for value != nil && value.Type() == "parenthesized_type" && value.NamedChildCount() > 0 {
+		value = value.NamedChild(0)
+	}
+	if value == nil {
+		return nil
+	}
+	switch value.Type() {
+	case "intersection_type":
+		for _, member := range typeScriptMembers(value) {
+			add(member, "extends")
+		}
+	case "union_type":
+		for _, member := range typeScriptMembers(value) {
+			add(member, "includes")
+		}
+	}
+	return relationships
+}
+
+// typeScriptMembers flattens an intersection or union type: A | B | C
+// nests as ((A | B) | C). Parenthesized members are unwrapped; members
+// composed the other way, (P & Q) in a union, are returned whole.
+func typeScriptMembers(node *sitter.Node) []*sitter.Node {
+	var members []*sitter.Node
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		member := node.NamedChild(i)
+		for member.Type() == "parenthesized_type" && member.NamedChildCount() > 0 {
+			member = member.NamedChild(0)
+		}
+		if member.Type() == node.Type() {
+			members = append(members, typeScriptMembers(member)...)
+			continue
+		}
+		members = append(members, member)
+	}
+	return members
+}
+
+// JavaScript hierarchy: class Foo extends Bar. Unlike TypeScript, the
+// heritage holds the superclass expression directly, with no extends clause.
+func (h *HierarchyIndexer) extractJavaScriptHierarchy(node *sitter.Node, content []byte, file FileInfo) []*db.TypeHierarchy {
+	var relationships []*db.TypeHierarchy
+
+	h.walkTree(node, func(n *sitter.Node) {
+		if n.Type() != "class_declaration" {
+			return
+		}
+
+		nameNode := n.ChildByFieldName("name")
+		if nameNode == nil {
+			return
+		}
+		childID := fmt.Sprintf("%s#%s", file.RelPath, nameNode.Content(content))
+
+		for i := 0; i < int(n.NamedChildCount()); i++ {
+			heritage := n.NamedChild(i)
+			if heritage.Type() != "class_heritage" || heritage.NamedChildCount() == 0 {
+				continue
+			}
+			// Only plain names resolve to a class; mixin calls are skipped
+			superclass := heritage.NamedChild(0)
+			parentName := ""
+			switch superclass.Type() {
+			case "identifier":
+				parentName = superclass.Content(content)
+			case "member_expression":
+				if prop := superclass.ChildByFieldName("property"); prop != nil {
+					parentName = prop.Content(content)
+				}
+			}
+			if parentName != "" {
+				relationships = append(relationships, &db.TypeHierarchy{
+					ChildID:      childID,
+					ParentID:     parentName,
+					Relationship: "extends",
+				})
+			}
+		}
+	})
+
+	return relationships
+}
+
 // Python hierarchy: class Foo(Base, Mixin):
 func (h *HierarchyIndexer) extractPythonHierarchy(node *sitter.Node, content []byte, file FileInfo) []*db.TypeHierarchy {
 	var relationships []*db.TypeHierarchy
@@ -430,12 +597,14 @@ func (h *HierarchyIndexer) extractPythonHierarchy(node *sitter.Node, content []b
 	return relationships
 }
 
-// Swift hierarchy: class Foo: Base, Protocol
+// Swift hierarchy: class Foo: Base, Protocol and extension Foo: Protocol
 func (h *HierarchyIndexer) extractSwiftHierarchy(node *sitter.Node, content []byte, file FileInfo) []*db.TypeHierarchy {
 	var relationships []*db.TypeHierarchy
 
 	h.walkTree(node, func(n *sitter.Node) {
-		if n.Type() != "class_declaration" && n.Type() != "struct_declaration" {
+		switch n.Type() {
+		case "class_declaration", "struct_declaration", "protocol_declaration":
+		default:
 			return
 		}
 
@@ -445,32 +614,90 @@ func (h *HierarchyIndexer) extractSwiftHierarchy(node *sitter.Node, content []by
 		}
 		className := nameNode.Content(content)
 		childID := fmt.Sprintf("%s#%s", file.RelPath, className)
+		extension := n.Type() == "class_declaration" && swiftDeclarationKind(n) == "extension"
+		if extension {
+			// extension Foo: Codable adds conformances to Foo, which may
+			// be declared in another file
+			childID = h.swiftExtendedType(className, childID)
+		}
 
-		// Look for inheritance clause
+		// Supertypes are listed one per inheritance_specifier, or in a
+		// type_inheritance_clause in older grammars
+		var parents []*sitter.Node
 		for i := 0; i < int(n.NamedChildCount()); i++ {
 			child := n.NamedChild(i)
-			if child.Type() == "type_inheritance_clause" {
+			switch child.Type() {
+			case "inheritance_specifier":
+				if typeNode := child.ChildByFieldName("inherits_from"); typeNode != nil {
+					parents = append(parents, typeNode)
+				}
+			case "type_inheritance_clause":
 				for j := 0; j < int(child.NamedChildCount()); j++ {
-					typeNode := child.NamedChild(j)
-					parentName := h.getTypeName(typeNode, content)
-					// First is typically superclass, rest are protocols
-					relationship := "extends"
-					if j > 0 {
-						relationship = "implements"
-					}
-					relationships = append(relationships, &db.TypeHierarchy{
-						ChildID:      childID,
-						ParentID:     parentName,
-						Relationship: relationship,
-					})
+					parents = append(parents, child.NamedChild(j))
 				}
 			}
 		}
+		for j, typeNode := range parents {
+			parentName := swiftTypeName(typeNode, content)
+			// First is typically superclass, rest are protocols; protocols
+			// refine protocols, and extensions only add conformances
+			relationship := "extends"
+			if extension || (j > 0 && n.Type() != "protocol_declaration") {
+				relationship = "implements"
+			}
+			relationships = append(relationships, &db.TypeHierarchy{
+				ChildID:      childID,
+				ParentID:     parentName,
+				Relationship: relationship,
+			})
+		}
 	})
 
 	return relationships
 }
 
+// swiftTypeName returns the name of a Swift type without its module
+// qualifier, with its generic arguments: Inner<Int> for Outer.Inner<Int>
+func swiftTypeName(node *sitter.Node, content []byte) string {
+	if node.Type() == "user_type" {
+		for i := int(node.NamedChildCount()) - 1; i >= 0; i-- {
+			if child := node.NamedChild(i); child.Type() == "type_identifier" {
+				name := child.Content(content)
+				// Base<User>: the arguments follow the name
+				if next := child.NextNamedSibling(); next != nil && next.Type() == "type_arguments" {
+					name += next.Content(content)
+				}
+				return name
+			}
+		}
+	}
+	return node.Content(content)
+}
+
+// swiftExtendedType returns the ID of the declaration of the type a Swift
+// extension extends, skipping the extensions of it (indexed under the same
+// name, with an "extension" signature). It returns fallback when the type
+// is not indexed, e.g. a standard library type.
+func (h *HierarchyIndexer) swiftExtendedType(name, fallback string) string {
+	scope := ""
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		scope, name = name[:i], name[i+1:]
+	}
+	candidates, err := h.db.GetSymbolByName(name, []string{"swift"})
+	if err != nil {
+		return fallback
+	}
+	for _, sym := range candidates {
+		if strings.HasPrefix(sym.Signature, "extension ") {
+			continue
+		}
+		if scope == "" || sym.Scope == scope || strings.HasSuffix(sym.Scope, "."+scope) {
+			return sym.ID
+		}
+	}
+	return fallback
+}
+
 // Rust hierarchy: impl Trait for Struct
 func (h *HierarchyIndexer) extractRustHierarchy(node *sitter.Node, content []byte, file FileInfo) []*db.TypeHierarchy {
 	var relationships []*db.TypeHierarchy
@@ -485,15 +712,38 @@ func (h *HierarchyIndexer) extractRustHierarchy(node *sitter.Node, content []byt
 		typeNode := n.ChildByFieldName("type")
 
 		if traitNode != nil && typeNode != nil {
-			traitName := h.getTypeName(traitNode, content)
-			typeName := h.getTypeName(typeNode, content)
+			traitName := rustTypeName(traitNode, content)
+			typeName := rustTypeName(typeNode, content)
 			childID := fmt.Sprintf("%s#%s", file.RelPath, typeName)
 
-			relationships = append(relationships, &db.TypeHierarchy{
+			rel := &db.TypeHierarchy{
 				ChildID:      childID,
 				ParentID:     traitName,
 				Relationship: "implements",
-			})
+			}
+			if args := traitNode.ChildByFieldName("type_arguments"); args != nil {
+				_, rel.TypeArgs = splitTypeArgs(args.Content(content))
+			}
+			relationships = append(relationships, rel)
+
+			// Link each method to the trait item it implements. The parent
+			// is resolved as Trait::method once the trait is found.
+			if body := n.ChildByFieldName("body"); body != nil {
+				for i := 0; i < int(body.NamedChildCount()); i++ {
+					item := body.NamedChild(i)
+					if item.Type() != "function_item" {
+						continue
+					}
+					if nameNode := item.ChildByFieldName("name"); nameNode != nil {
+						method := nameNode.Content(content)
+						relationships = append(relationships, &db.TypeHierarchy{
+							ChildID:      fmt.Sprintf("%s#%s::%s", file.RelPath, typeName, method),
+							ParentID:     traitName + "::" + method,
+							Relationship: "implements",
+						})
+					}
+				}
+			}
 		}
 	})
 
@@ -613,17 +863,17 @@ func (h *HierarchyIndexer) getTypeName(node *sitter.Node, content []byte) string
 	case "identifier", "type_identifier":
 		return node.Content(content)
 	case "generic_name", "generic_type":
-		// Get just the base type name, not the generic params
-		if nameNode := node.ChildByFieldName("name"); nameNode != nil {
-			return nameNode.Content(content)
-		}
-		// Fallback: get first child
-		if node.NamedChildCount() > 0 {
-			return node.NamedChild(0).Content(content)
-		}
+		// Keep the type arguments: splitTypeArgs separates them from the
+		// base type name when the parent is resolved
+		return node.Content(content)
 	case "qualified_name", "scoped_type_identifier":
 		// Return full qualified name
 		return node.Content(content)
+	case "nested_type_identifier":
+		// TypeScript ns.Base resolves to the Base it names
+		if nameNode := node.ChildByFieldName("name"); nameNode != nil {
+			return nameNode.Content(content)
+		}
 	case "type":
 		// Recurse into type node
 		if node.NamedChildCount() > 0 {
@@ -635,6 +885,25 @@ func (h *HierarchyIndexer) getTypeName(node *sitter.Node, content []byte) string
 	return node.Content(content)
 }
 
+// splitTypeArgs splits an instantiated generic type into its raw name and
+// its type arguments: Base<User> and Base[User] (Python, Go) both give
+// Base and User. Names without type arguments are returned as they are.
+func splitTypeArgs(name string) (raw, args string) {
+	name = strings.TrimSpace(name)
+	open := strings.IndexAny(name, "<[")
+	if open <= 0 {
+		return name, ""
+	}
+	closing := byte('>')
+	if name[open] == '[' {
+		closing = ']'
+	}
+	if name[len(name)-1] != closing {
+		return name, ""
+	}
+	return strings.TrimSpace(name[:open]), strings.TrimSpace(name[open+1 : len(name)-1])
+}
+
 // Helper: check if name starts with 'I' (interface naming convention)
 func startsWithI(name string) bool {
 	return len(name) > 1 && name[0] == 'I' && name[1] >= 'A' && name[1] <= 'Z'
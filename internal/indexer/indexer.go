@@ -3,7 +3,7 @@ package indexer
 import (
 	"context"
 	"fmt"
-	"net/url"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -11,6 +11,8 @@ import (
 
 	"github.com/tk-425/Codegraph/internal/config"
 	"github.com/tk-425/Codegraph/internal/db"
+	"github.com/tk-425/Codegraph/internal/embed"
+	"github.com/tk-425/Codegraph/internal/history"
 	"github.com/tk-425/Codegraph/internal/lsp"
 )
 
@@ -21,12 +23,27 @@ type Indexer struct {
 	lsp      *lsp.Manager
 	rootPath string
 	rootURI  string
+	progress Progress
+	out      io.Writer // Receives the build's progress messages
+	report   *BuildReport
+	kinds    *KindMap
+	shard    *Shard     // Set for a build of one shard of the files
+	fast     bool       // Tree-sitter only, no language servers
+	strategy string     // Overrides the configured strategies when set
+	writer   *db.Writer // Stores the tree-sitter files of IndexProject
+	// snapshots hold the previous symbols of the files IndexProject
+	// re-indexes, for reconcile
+	snapshots map[string]fileSnapshot
 }
 
+// writeQueueSize is how many parsed files may wait for the writer before
+// parsing blocks
+const writeQueueSize = 64
+
 // NewIndexer creates a new indexer
 func NewIndexer(cfg *config.Config, dbManager *db.Manager, rootPath string) *Indexer {
 	absPath, _ := filepath.Abs(rootPath)
-	rootURI := "file://" + absPath
+	rootURI := lsp.PathToURI(absPath)
 
 	return &Indexer{
 		cfg:      cfg,
@@ -34,16 +51,101 @@ func NewIndexer(cfg *config.Config, dbManager *db.Manager, rootPath string) *Ind
 		lsp:      lsp.NewManager(cfg, rootURI),
 		rootPath: absPath,
 		rootURI:  rootURI,
+		out:      os.Stdout,
+	}
+}
+
+// SetProgress sets the receiver of IndexProject's per-file progress
+func (i *Indexer) SetProgress(p Progress) {
+	i.progress = p
+}
+
+// SetOutput sends the build's progress messages to w instead of standard
+// output; io.Discard silences them
+func (i *Indexer) SetOutput(w io.Writer) {
+	i.out = w
+	i.lsp.SetOutput(w)
+}
+
+// SetShard makes IndexProject build one shard of a project: the given files
+// are expected to be the shard's, and the links between files are left to
+// LinkProject once every shard is merged
+func (i *Indexer) SetShard(s Shard) {
+	i.shard = &s
+}
+
+// SetFast makes IndexProject skip the language servers: symbols, calls and
+// type hierarchy all come from tree-sitter, which is much faster on large
+// projects but resolves calls by name only
+func (i *Indexer) SetFast(fast bool) {
+	i.fast = fast
+}
+
+// SetStrategy makes IndexProject extract every language with strategy
+// (config.StrategyHybrid, StrategyLSP or StrategyTreeSitter) instead of the
+// strategies in config.toml; empty restores them
+func (i *Indexer) SetStrategy(strategy string) {
+	i.strategy = strategy
+}
+
+// strategyFor returns the extraction strategy of language
+func (i *Indexer) strategyFor(language string) string {
+	switch {
+	case i.fast:
+		return config.StrategyTreeSitter
+	case i.strategy != "":
+		return i.strategy
 	}
+	return i.cfg.Index.StrategyFor(language)
+}
+
+// Report returns the report of the last IndexProject run, or nil before
+// the first one
+func (i *Indexer) Report() *BuildReport {
+	return i.report
 }
 
-// IndexProject indexes all source files in the project
+// IndexProject indexes all source files in the project and records the
+// outcome in .codegraph/last-build.json
 func (i *Indexer) IndexProject(ctx context.Context, files []FileInfo, force bool) error {
+	report := &BuildReport{StartedAt: time.Now(), Force: force, Fast: i.fast, Strategies: make(map[string]string)}
+	if i.shard != nil {
+		report.Shard = i.shard.String()
+	}
+	i.report = report
+	kinds, err := NewKindMap(i.cfg.Kinds)
+	if err != nil {
+		return err
+	}
+	i.kinds = kinds
+	var reportPaths []string // absolute path of each report.Files entry
 	if force {
 		if err := i.db.ClearAll(); err != nil {
 			return fmt.Errorf("failed to clear database: %w", err)
 		}
 	}
+	// A build that did not finish stored the symbols of some files without
+	// linking them, so this one links every file. The mark is removed by
+	// recordMeta once the build completes.
+	resuming := false
+	if started, err := i.db.GetMeta(db.MetaInterrupted); err == nil && started != "" {
+		resuming = true
+		fmt.Fprintf(i.out, "↩️  Resuming the build started at %s: unchanged files are skipped, every file is linked again\n", started)
+	}
+	if err := i.db.SetMeta(db.MetaInterrupted, report.StartedAt.Format(time.RFC3339)); err != nil {
+		return err
+	}
+
+	i.snapshots = make(map[string]fileSnapshot)
+	defer func() { i.snapshots = nil }()
+
+	// Tree-sitter files are stored by a writer goroutine while the next
+	// files are parsed; it is flushed before the stores are read
+	i.writer = i.db.NewWriter(writeQueueSize)
+	defer func() {
+		i.writer.Close()
+		i.writer = nil
+	}()
 
 	// Group files by language
 	groups := GroupByLanguage(files)
@@ -51,6 +153,13 @@ func (i *Indexer) IndexProject(ctx context.Context, files []FileInfo, force bool
 	indexedFiles := 0
 	skippedFiles := 0
 	totalSymbols := 0
+	processedFiles := 0
+	// Files (re)indexed in this run, by language; only their call graph
+	// needs updating
+	changed := make(map[string][]FileInfo)
+	interrupted := func(phase string) error {
+		return &InterruptedError{Phase: phase, Indexed: indexedFiles, Pending: len(files) - processedFiles, Err: ctx.Err()}
+	}
 
 	for language, langFiles := range groups {
 		langTotal := len(langFiles)
@@ -59,98 +168,336 @@ func (i *Indexer) IndexProject(ctx context.Context, files []FileInfo, force bool
 		langLSP := 0
 		langTreeSitter := 0
 
-		// Get LSP client for this language
-		client, err := i.lsp.GetClient(ctx, language)
-		// If err != nil, client is nil. Proceed to fallback.
-		if err != nil {
-			fmt.Printf("   ⚠️  No LSP for %s (will use tree-sitter): %v\n", language, err)
-		}
+		strategy := i.strategyFor(language)
+		report.Strategies[language] = strategy
 
-		// Some LSP servers need time to analyze the project after initialization
-		switch language {
-		case "rust":
-			time.Sleep(10 * time.Second)
-		case "java":
-			time.Sleep(10 * time.Second)
-		case "swift":
-			time.Sleep(10 * time.Second)
-		case "ocaml":
-			time.Sleep(10 * time.Second)
+		// Get LSP client for this language; without one, files fall back
+		// to tree-sitter unless the strategy is LSP only
+		var client *lsp.Client
+		if strategy != config.StrategyTreeSitter {
+			var err error
+			client, err = i.lsp.GetClient(ctx, language)
+			if err != nil && strategy == config.StrategyLSP {
+				fmt.Fprintf(i.out, "   ⚠️  No LSP for %s (strategy lsp, files fail): %v\n", language, err)
+				report.Warnings = append(report.Warnings, fmt.Sprintf("no LSP for %s with strategy lsp: %v", language, err))
+			} else if err != nil {
+				fmt.Fprintf(i.out, "   ⚠️  No LSP for %s (will use tree-sitter): %v\n", language, err)
+			}
+
+			// Some LSP servers need time to analyze the project after initialization
+			switch language {
+			case "rust", "java", "swift", "ocaml":
+				sleepContext(ctx, 10*time.Second)
+			}
 		}
 
+		if i.progress != nil {
+			i.progress.LanguageStarted(language, langTotal)
+		}
+		langSymbols := 0
 		for idx, file := range langFiles {
-			// Check if file needs re-indexing (incremental build)
-			if !force {
-				if skip, _ := i.shouldSkipFile(file); skip {
-					langSkipped++
-					skippedFiles++
-					continue
-				}
+			if canceled(ctx) {
+				return interrupted("indexing files")
 			}
-
-			// Show progress
-			progress := float64(idx+1) / float64(langTotal) * 100
-			fmt.Printf("\r   [%s] %d/%d files (%.0f%%) ", language, idx+1, langTotal, progress)
-
-			symbols := 0
-			var err error
-
+			started := time.Now()
+			fileClient := client
 			if client != nil {
-				symbols, err = i.indexFile(ctx, client, file)
-			} else {
-				// No LSP client, force fallback
-				err = fmt.Errorf("no LSP client")
+				// Route the file to the server of its tsconfig project
+				if c, err := i.lsp.GetClientForFile(ctx, language, file.Path); err == nil {
+					fileClient = c
+				}
 			}
-
-			// Fallback if error OR if LSP returned 0 symbols (likely failed to process)
-			if err != nil || symbols == 0 {
-				if err != nil && client != nil {
-					// Log real LSP errors (but sparse errors like 'no LSP client' are expected)
+			source, symbols, err := i.indexOne(ctx, fileClient, file, force, strategy)
+			if canceled(ctx) && source != sourceSkipped {
+				// Roll back the file that was being written; the next
+				// build indexes it again
+				if err := i.writer.Flush(); err != nil {
+					return fmt.Errorf("failed to store symbols: %w", err)
 				}
-
-				// Try tree-sitter fallback
-				tsIndexer := NewTreeSitterIndexer(i.db, i.rootPath)
-				tsSymbols, tsErr := tsIndexer.IndexFile(ctx, file)
-				if tsErr != nil {
-					if err != nil {
-						fmt.Printf("\n   ⚠️  Error indexing %s: %v (tree-sitter: %v)\n", file.RelPath, err, tsErr)
-					}
-					// If LSP managed 0 and tree-sitter failed, we just continue (count as 0)
-					continue
+				if err := i.db.DeleteFiles([]string{file.Path}); err != nil {
+					return fmt.Errorf("failed to roll back %s: %w", file.RelPath, err)
 				}
-
-				// Tree-sitter succeeded
+				return interrupted("indexing files")
+			}
+			processedFiles++
+			fileReport := FileReport{
+				Path:       file.RelPath,
+				Language:   language,
+				Status:     StatusIndexed,
+				Source:     source,
+				Symbols:    symbols,
+				DurationMs: time.Since(started).Milliseconds(),
+			}
+			switch source {
+			case sourceSkipped:
+				fileReport.Status, fileReport.Source = StatusSkipped, ""
+				langSkipped++
+				skippedFiles++
+			case sourceFailed:
+				fileReport.Status, fileReport.Source = StatusFailed, ""
+				report.Failed++
+			case sourceLSP, sourceTreeSitter:
+				changed[language] = append(changed[language], file)
 				langIndexed++
-				langTreeSitter++
 				indexedFiles++
-				totalSymbols += tsSymbols
-				continue
+				totalSymbols += symbols
+				langSymbols += symbols
+				if source == sourceLSP {
+					langLSP++
+				} else {
+					langTreeSitter++
+				}
+			}
+			if err != nil {
+				fileReport.Error = err.Error()
+			}
+			report.Files = append(report.Files, fileReport)
+			reportPaths = append(reportPaths, file.Path)
+			if i.progress != nil {
+				i.progress.FileDone(language, idx+1, langTotal, langSymbols)
 			}
-
-			langIndexed++
-			langLSP++
-			indexedFiles++
-			totalSymbols += symbols
+		}
+		if i.progress != nil {
+			i.progress.LanguageFinished(language)
 		}
 
-		// Clear progress line and show summary with source counts
+		// Show summary with source counts
 		if langIndexed > 0 {
-			fmt.Printf("\r   [%s] %d indexed (%d LSP, %d tree-sitter), %d skipped         \n", language, langIndexed, langLSP, langTreeSitter, langSkipped)
+			fmt.Fprintf(i.out, "   [%s] %d indexed (%d LSP, %d tree-sitter), %d skipped\n", language, langIndexed, langLSP, langTreeSitter, langSkipped)
 		} else if langSkipped > 0 {
-			fmt.Printf("\r   [%s] 0 indexed, %d skipped (unchanged)         \n", language, langSkipped)
+			fmt.Fprintf(i.out, "   [%s] 0 indexed, %d skipped (unchanged)\n", language, langSkipped)
 		}
 	}
 
+	if err := i.writer.Flush(); err != nil {
+		return fmt.Errorf("failed to store symbols: %w", err)
+	}
+
+	// Files whose extractor changed since the last build keep only the new
+	// symbols; a failure should not fail the build
+	var reindexed []FileInfo
+	for _, langFiles := range changed {
+		reindexed = append(reindexed, langFiles...)
+	}
+	reconciled, conflicts, err := i.reconcile(reindexed)
+	if err != nil {
+		fmt.Fprintf(i.out, "   ⚠️  Reconciling extractors failed: %v\n", err)
+		report.Warnings = append(report.Warnings, fmt.Sprintf("reconciling extractors failed: %v", err))
+	}
+	if reconciled > 0 {
+		fmt.Fprintf(i.out, "⚖️  %d files changed extractor since the last build: %d conflicts recorded (codegraph verify --conflicts)\n", reconciled, conflicts)
+	}
+	report.Conflicts = conflicts
+
+	// Symbols the re-indexed files no longer declare are dropped, and the
+	// ones that reappeared under another name recorded as renames
+	if !force {
+		renamed, err := i.trackRenames(reindexed, report.StartedAt)
+		if err != nil {
+			fmt.Fprintf(i.out, "   ⚠️  Tracking renames failed: %v\n", err)
+			report.Warnings = append(report.Warnings, fmt.Sprintf("tracking renames failed: %v", err))
+		}
+		if renamed > 0 {
+			fmt.Fprintf(i.out, "✏️  %d symbols renamed since the last build\n", renamed)
+		}
+		report.Renames = renamed
+	}
+
+	if resuming {
+		changed = groups
+	}
+
+	// Concurrency sites are extracted for Go only; a failure should not
+	// fail the build
+	if len(groups["go"]) > 0 {
+		fmt.Fprintln(i.out, "🔀 Extracting concurrency sites...")
+		sites, err := NewConcurrencyIndexer(i.db, i.rootPath).IndexConcurrency(ctx, changed["go"])
+		if err != nil {
+			fmt.Fprintf(i.out, "   ⚠️  Concurrency sites skipped: %v\n", err)
+			report.Warnings = append(report.Warnings, fmt.Sprintf("concurrency sites skipped: %v", err))
+		} else {
+			fmt.Fprintf(i.out, "   Found %d goroutine, channel and mutex sites in changed files\n", sites)
+		}
+	}
+	if canceled(ctx) {
+		return interrupted("extracting concurrency sites")
+	}
+
+	// Ownership is informational; a failure should not fail the build
+	fmt.Fprintln(i.out, "👥 Assigning owners...")
+	var changedFiles []FileInfo
+	for _, langFiles := range changed {
+		changedFiles = append(changedFiles, langFiles...)
+	}
+	owned, authored, err := NewOwnershipIndexer(i.db, i.rootPath, i.cfg.Owners.Blame).IndexOwners(ctx, files, changedFiles)
+	if err != nil {
+		fmt.Fprintf(i.out, "   ⚠️  Owners skipped: %v\n", err)
+		report.Warnings = append(report.Warnings, fmt.Sprintf("owners skipped: %v", err))
+	} else {
+		fmt.Fprintf(i.out, "   %d files with CODEOWNERS owners, %d symbols with a blame author\n", owned, authored)
+	}
+	if canceled(ctx) {
+		return interrupted("assigning owners")
+	}
+
+	fmt.Fprintln(i.out, "🏷️  Tagging annotations...")
+	tags, err := NewTagIndexer(i.db).IndexTags(ctx, changedFiles)
+	if err != nil {
+		fmt.Fprintf(i.out, "   ⚠️  Tags skipped: %v\n", err)
+		report.Warnings = append(report.Warnings, fmt.Sprintf("tags skipped: %v", err))
+	} else {
+		fmt.Fprintf(i.out, "   Found %d annotations, decorators, attributes and deprecation notices in changed files\n", tags)
+	}
+	if canceled(ctx) {
+		return interrupted("tagging annotations")
+	}
+
+	// Calls through aliased imports resolve against these when linking
+	fmt.Fprintln(i.out, "🔀 Recording import aliases...")
+	aliases, err := NewAliasIndexer(i.db).IndexAliases(ctx, changedFiles)
+	if err != nil {
+		fmt.Fprintf(i.out, "   ⚠️  Aliases skipped: %v\n", err)
+		report.Warnings = append(report.Warnings, fmt.Sprintf("aliases skipped: %v", err))
+	} else {
+		fmt.Fprintf(i.out, "   Found %d aliased imports and exports in changed files\n", aliases)
+	}
+	if canceled(ctx) {
+		return interrupted("recording aliases")
+	}
+
+	// A shard cannot resolve the calls and other links into the files of
+	// the other shards; merge links them once their symbols are together
+	totalCalls, totalHierarchy := 0, 0
+	if i.shard != nil {
+		fmt.Fprintf(i.out, "⏭️  Shard %s: call graph and cross-file links are left to 'codegraph merge'\n", i.shard)
+	} else {
+		totalCalls, totalHierarchy = i.link(ctx, files, groups, changed, report)
+		if canceled(ctx) {
+			return interrupted("linking files")
+		}
+		if i.cfg.Dependencies.Enabled {
+			i.indexDependencies(ctx, report)
+			if canceled(ctx) {
+				return interrupted("indexing dependencies")
+			}
+		}
+	}
+
+	// Embeddings are optional; a failing provider should not fail the build
+	if i.cfg.Embeddings.Enabled() {
+		fmt.Fprintln(i.out, "🧠 Computing embeddings...")
+		provider, err := embed.NewProvider(i.cfg.Embeddings)
+		if err == nil {
+			embedder := NewEmbeddingIndexer(i.db, provider, i.cfg.Embeddings.BatchSize)
+			embedder.out = i.out
+			var count int
+			count, err = embedder.IndexEmbeddings(ctx)
+			fmt.Fprintf(i.out, "   Embedded %d symbols (%s)\n", count, provider.Model())
+		}
+		if err != nil {
+			fmt.Fprintf(i.out, "   ⚠️  Embeddings skipped: %v\n", err)
+			report.Warnings = append(report.Warnings, fmt.Sprintf("embeddings skipped: %v", err))
+		}
+		if canceled(ctx) {
+			return interrupted("computing embeddings")
+		}
+	}
+
+	// Shutdown LSP servers
+	i.lsp.ShutdownAll()
+
+	fmt.Fprintf(i.out, "✅ Indexed %d files, skipped %d unchanged, %d symbols, %d calls, %d type relations\n",
+		indexedFiles, skippedFiles, totalSymbols, totalCalls, totalHierarchy)
+
+	report.Indexed, report.Skipped = indexedFiles, skippedFiles
+	report.Symbols, report.Calls, report.TypeRelations = totalSymbols, totalCalls, totalHierarchy
+	if counts, err := i.db.CountCallsByFile(); err == nil {
+		for idx := range report.Files {
+			report.Files[idx].Calls = counts[reportPaths[idx]]
+		}
+	}
+	if err := i.recordMeta(); err != nil {
+		return err
+	}
+	report.DurationMs = time.Since(report.StartedAt).Milliseconds()
+	if err := writeReport(i.rootPath, report); err != nil {
+		fmt.Fprintf(i.out, "   ⚠️  Failed to write build report: %v\n", err)
+	}
+	return nil
+}
+
+// link extracts the edges between files: the call graph of the changed
+// files (every file of a language in changed redoes all of its calls),
+// type hierarchy, gRPC links, entry points, routes and components. It
+// returns the calls and type relations found.
+func (i *Indexer) link(ctx context.Context, files []FileInfo, groups, changed map[string][]FileInfo, report *BuildReport) (int, int) {
 	// Index call graph for each language
-	fmt.Println("📊 Extracting call graph (via references)...")
+	if i.fast {
+		fmt.Fprintln(i.out, "📊 Extracting call graph (tree-sitter)...")
+	} else {
+		fmt.Fprintln(i.out, "📊 Extracting call graph (via references)...")
+	}
 	callGraphIndexer := NewCallGraphIndexer(i.db, i.lsp, i.rootPath)
 	callExtractor := NewCallExtractor(i.db, i.rootPath)
+	callExtractor.SetFilter(NewCallFilter(i.cfg.Calls))
 	totalCalls := 0
 	for language := range groups {
-		// Try LSP-based call graph first
-		calls, err := callGraphIndexer.IndexCallGraph(ctx, language)
+		changedFiles := changed[language]
+		if len(changedFiles) == 0 {
+			continue // Nothing changed: the stored call graph is current
+		}
+		if language == "proto" {
+			continue // .proto files make no calls; RPCs are linked below
+		}
+		strategy := i.strategyFor(language)
+
+		// When only some files changed, update just the edges from and to
+		// them instead of reprocessing the whole language
+		if len(changedFiles) < len(groups[language]) {
+			paths := make([]string, len(changedFiles))
+			for idx, file := range changedFiles {
+				paths[idx] = file.Path
+			}
+			var calls int
+			var err error
+			switch strategy {
+			case config.StrategyTreeSitter:
+				calls, err = i.extractFileCalls(ctx, callExtractor, groups[language], paths)
+			case config.StrategyLSP:
+				calls, err = callGraphIndexer.IndexCallGraphFiles(ctx, language, paths)
+			default:
+				calls, err = callGraphIndexer.IndexCallGraphFiles(ctx, language, paths)
+				if err != nil {
+					calls, err = i.extractFileCalls(ctx, callExtractor, groups[language], paths)
+				}
+			}
+			if err != nil {
+				fmt.Fprintf(i.out, "   ⚠️  Call graph update failed for %s: %v\n", language, err)
+				report.Warnings = append(report.Warnings, fmt.Sprintf("call graph update failed for %s: %v", language, err))
+			}
+			totalCalls += calls
+			continue
+		}
+
+		// Try LSP-based call graph first, unless the strategy is tree-sitter
+		var calls int
+		var err error
+		if strategy != config.StrategyTreeSitter {
+			calls, err = callGraphIndexer.IndexCallGraph(ctx, language)
+		}
+		if strategy == config.StrategyLSP {
+			if err != nil {
+				fmt.Fprintf(i.out, "   ⚠️  Call graph LSP error for %s: %v\n", language, err)
+				report.Warnings = append(report.Warnings, fmt.Sprintf("call graph LSP error for %s: %v", language, err))
+			}
+			totalCalls += calls
+			continue
+		}
 		if err != nil || calls == 0 {
 			// LSP failed or returned nothing, try tree-sitter
+			if clearErr := i.db.ClearCalls(language); clearErr != nil {
+				fmt.Fprintf(i.out, "   ⚠️  %v\n", clearErr)
+			}
 			for _, file := range groups[language] {
 				tsCount, tsErr := callExtractor.ExtractCalls(ctx, file)
 				if tsErr == nil {
@@ -159,22 +506,39 @@ func (i *Indexer) IndexProject(ctx context.Context, files []FileInfo, force bool
 			}
 			if err != nil {
 				// Only show warning if there was an actual error (not just 0 results)
-				fmt.Printf("   ⚠️  Call graph LSP error for %s (using tree-sitter): %v\n", language, err)
+				fmt.Fprintf(i.out, "   ⚠️  Call graph LSP error for %s (using tree-sitter): %v\n", language, err)
+				report.Warnings = append(report.Warnings, fmt.Sprintf("call graph LSP error for %s (used tree-sitter): %v", language, err))
 			}
 			continue
 		}
 		totalCalls += calls
 	}
-	fmt.Printf("   Found %d call relationships\n", totalCalls)
+	fmt.Fprintf(i.out, "   Found %d call relationships\n", totalCalls)
+	if canceled(ctx) {
+		return totalCalls, 0
+	}
 
 	// Index type hierarchy for each language
-	fmt.Println("🔗 Extracting type hierarchy...")
+	fmt.Fprintln(i.out, "🔗 Extracting type hierarchy...")
 	hierarchyIndexer := NewHierarchyIndexer(i.db, i.lsp, i.rootPath)
 	totalHierarchy := 0
 
 	// Try LSP-based hierarchy first, fall back to tree-sitter if no results
 	for language := range groups {
-		count, err := hierarchyIndexer.IndexHierarchyLSP(ctx, language)
+		strategy := i.strategyFor(language)
+		var count int
+		var err error
+		if strategy != config.StrategyTreeSitter {
+			count, err = hierarchyIndexer.IndexHierarchyLSP(ctx, language)
+		}
+		if strategy == config.StrategyLSP {
+			if err != nil {
+				fmt.Fprintf(i.out, "   ⚠️  Type hierarchy LSP error for %s: %v\n", language, err)
+				report.Warnings = append(report.Warnings, fmt.Sprintf("type hierarchy LSP error for %s: %v", language, err))
+			}
+			totalHierarchy += count
+			continue
+		}
 		if err != nil || count == 0 {
 			// LSP hierarchy failed or returned nothing, try tree-sitter
 			for _, file := range groups[language] {
@@ -187,18 +551,216 @@ func (i *Indexer) IndexProject(ctx context.Context, files []FileInfo, force bool
 		}
 		totalHierarchy += count
 	}
-	fmt.Printf("   Found %d type relationships\n", totalHierarchy)
+	fmt.Fprintf(i.out, "   Found %d type relationships\n", totalHierarchy)
+	if canceled(ctx) {
+		return totalCalls, totalHierarchy
+	}
 
-	// Shutdown LSP servers
-	i.lsp.ShutdownAll()
+	// gRPC links cross languages, so they follow every language's call
+	// graph and hierarchy; a failure should not fail the build
+	if len(groups["proto"]) > 0 {
+		fmt.Fprintln(i.out, "🔌 Linking gRPC services...")
+		servers, clients, err := NewRPCIndexer(i.db).IndexRPCs(ctx, files)
+		if err != nil {
+			fmt.Fprintf(i.out, "   ⚠️  gRPC links skipped: %v\n", err)
+			report.Warnings = append(report.Warnings, fmt.Sprintf("gRPC links skipped: %v", err))
+		} else {
+			fmt.Fprintf(i.out, "   %d server methods, %d client call sites\n", servers, clients)
+		}
+	}
 
-	fmt.Printf("✅ Indexed %d files, skipped %d unchanged, %d symbols, %d calls, %d type relations\n",
-		indexedFiles, skippedFiles, totalSymbols, totalCalls, totalHierarchy)
+	// Entry points feed later analyses but are not needed to query the index
+	fmt.Fprintln(i.out, "🚪 Finding entry points...")
+	entrypoints, err := NewEntrypointIndexer(i.db, i.rootPath).IndexEntrypoints(ctx, files)
+	if err != nil {
+		fmt.Fprintf(i.out, "   ⚠️  Entry points skipped: %v\n", err)
+		report.Warnings = append(report.Warnings, fmt.Sprintf("entry points skipped: %v", err))
+	} else {
+		fmt.Fprintf(i.out, "   %d main, %d HTTP handlers, %d CLI commands, %d exported API\n",
+			entrypoints[EntrypointMain], entrypoints[EntrypointHTTP], entrypoints[EntrypointCLI], entrypoints[EntrypointAPI])
+	}
+
+	fmt.Fprintln(i.out, "🛣️  Extracting HTTP routes...")
+	routes, err := NewRouteIndexer(i.db, i.rootPath).IndexRoutes(ctx, files)
+	if err != nil {
+		fmt.Fprintf(i.out, "   ⚠️  Routes skipped: %v\n", err)
+		report.Warnings = append(report.Warnings, fmt.Sprintf("routes skipped: %v", err))
+	} else {
+		fmt.Fprintf(i.out, "   Found %d routes\n", routes)
+	}
+
+	fmt.Fprintln(i.out, "🧩 Extracting UI components...")
+	components, renders, err := NewComponentIndexer(i.db).IndexComponents(ctx, files)
+	if err != nil {
+		fmt.Fprintf(i.out, "   ⚠️  Components skipped: %v\n", err)
+		report.Warnings = append(report.Warnings, fmt.Sprintf("components skipped: %v", err))
+	} else {
+		fmt.Fprintf(i.out, "   Found %d components, %d render edges\n", components, renders)
+	}
+
+	return totalCalls, totalHierarchy
+}
+
+// indexDependencies indexes the declarations of the project's dependencies
+// and links the external calls to them. A failure only warns: the project's
+// own index is complete without them.
+func (i *Indexer) indexDependencies(ctx context.Context, report *BuildReport) {
+	fmt.Fprintln(i.out, "📦 Indexing dependencies...")
+	deps, linked, err := NewDependencyIndexer(i.db, i.rootPath, i.cfg.Dependencies).IndexDependencies(ctx)
+	if err != nil {
+		fmt.Fprintf(i.out, "   ⚠️  Dependencies skipped: %v\n", err)
+		report.Warnings = append(report.Warnings, fmt.Sprintf("dependencies skipped: %v", err))
+		return
+	}
+	fmt.Fprintf(i.out, "   Indexed %d new dependencies, linked %d external calls\n", deps, linked)
+}
+
+// LinkProject extracts the call graph and the other links between files
+// for an index whose symbols are already stored, such as one merged from
+// shards, and records the project root in it
+func (i *Indexer) LinkProject(ctx context.Context, files []FileInfo) error {
+	report := &BuildReport{StartedAt: time.Now(), Force: true}
+	i.report = report
+	// Until linking completes, a build links every file again
+	if err := i.db.SetMeta(db.MetaInterrupted, report.StartedAt.Format(time.RFC3339)); err != nil {
+		return err
+	}
+	groups := GroupByLanguage(files)
+	calls, hierarchy := i.link(ctx, files, groups, groups, report)
+	if i.cfg.Dependencies.Enabled && !canceled(ctx) {
+		i.indexDependencies(ctx, report)
+	}
+	i.lsp.ShutdownAll()
+	if canceled(ctx) {
+		return &InterruptedError{Phase: "linking files", Indexed: len(files), Err: ctx.Err()}
+	}
+	if err := i.recordMeta(); err != nil {
+		return err
+	}
+	fmt.Fprintf(i.out, "✅ Linked %d files: %d calls, %d type relations\n", len(files), calls, hierarchy)
+	report.Calls, report.TypeRelations = calls, hierarchy
+	report.DurationMs = time.Since(report.StartedAt).Milliseconds()
 	return nil
 }
 
+// recordMeta records the root the index's file paths are under, the
+// shard it holds, if any, the commit it was built from, and that the build
+// completed
+func (i *Indexer) recordMeta() error {
+	shard := ""
+	if i.shard != nil {
+		shard = i.shard.String()
+	}
+	if err := i.db.SetMeta(db.MetaRoot, i.rootPath); err != nil {
+		return err
+	}
+	if err := i.db.SetMeta(db.MetaShard, shard); err != nil {
+		return err
+	}
+	head, _ := history.Head(context.Background(), i.rootPath) // Empty outside git
+	if err := i.db.SetMeta(db.MetaGitHead, head); err != nil {
+		return err
+	}
+	return i.db.SetMeta(db.MetaInterrupted, "")
+}
+
+// How indexOne handled a file
+const (
+	sourceSkipped    = "skipped"
+	sourceLSP        = "lsp"
+	sourceTreeSitter = "tree-sitter"
+	sourceFailed     = "failed"
+)
+
+// indexOne indexes a file with the language server, falling back to
+// tree-sitter unless strategy is LSP only, and reports how it was handled, the symbols stored, and
+// the error that made it fail. Unless force is set, files unchanged
+// since the last build are skipped.
+func (i *Indexer) indexOne(ctx context.Context, client *lsp.Client, file FileInfo, force bool, strategy string) (string, int, error) {
+	// Check if file needs re-indexing (incremental build)
+	if !force {
+		if skip, _ := i.shouldSkipFile(file); skip {
+			return sourceSkipped, 0, nil
+		}
+		i.snapshot(file)
+	}
+
+	symbols := 0
+	var err error
+	if client != nil {
+		symbols, err = i.indexFile(ctx, client, file)
+	} else {
+		// No LSP client, force fallback
+		err = fmt.Errorf("no LSP client")
+	}
+	if err == nil && (symbols > 0 || strategy == config.StrategyLSP) {
+		return sourceLSP, symbols, nil
+	}
+	if strategy == config.StrategyLSP {
+		// No fallback: drop what the server stored of the file, so the
+		// next build retries it
+		if delErr := i.db.DeleteFiles([]string{file.Path}); delErr != nil {
+			return sourceFailed, 0, fmt.Errorf("%v (cleanup: %v)", err, delErr)
+		}
+		return sourceFailed, 0, err
+	}
+
+	// Fallback if error OR if LSP returned 0 symbols (likely failed to process)
+	tsIndexer := NewTreeSitterIndexer(i.db, i.rootPath)
+	tsIndexer.SetKinds(i.kinds)
+	tsIndexer.SetWriter(i.writer)
+	tsSymbols, tsErr := tsIndexer.IndexFile(ctx, file)
+	if tsErr != nil {
+		if err != nil {
+			fmt.Fprintf(i.out, "\n   ⚠️  Error indexing %s: %v (tree-sitter: %v)\n", file.RelPath, err, tsErr)
+			return sourceFailed, 0, fmt.Errorf("%v (tree-sitter: %v)", err, tsErr)
+		}
+		// If LSP managed 0 and tree-sitter failed, we just continue (count as 0)
+		return sourceFailed, 0, fmt.Errorf("tree-sitter: %v", tsErr)
+	}
+	return sourceTreeSitter, tsSymbols, nil
+}
+
+// extractFileCalls is the tree-sitter counterpart of IndexCallGraphFiles:
+// it drops the edges from and to the changed files (paths), then
+// re-extracts the calls of the changed files and of the files that called
+// into them
+func (i *Indexer) extractFileCalls(ctx context.Context, extractor *CallExtractor, files []FileInfo, paths []string) (int, error) {
+	callers, err := i.db.GetCallingFiles(paths)
+	if err != nil {
+		return 0, err
+	}
+	if err := i.db.ClearCallsTo(paths); err != nil {
+		return 0, err
+	}
+	affected := append(append([]string{}, paths...), callers...)
+	if err := i.db.ClearCallsFrom(affected); err != nil {
+		return 0, err
+	}
+
+	redo := make(map[string]bool, len(affected))
+	for _, path := range affected {
+		redo[path] = true
+	}
+	count := 0
+	for _, file := range files {
+		if !redo[file.Path] {
+			continue
+		}
+		if n, err := extractor.ExtractCalls(ctx, file); err == nil {
+			count += n
+		}
+	}
+	return count, nil
+}
+
 // shouldSkipFile checks if file is unchanged since last index
 func (i *Indexer) shouldSkipFile(file FileInfo) (bool, error) {
+	return upToDate(i.db, file)
+}
+
+// upToDate reports whether file is unchanged since dbManager indexed it
+func upToDate(dbManager *db.Manager, file FileInfo) (bool, error) {
 	// Get file's current modification time
 	stat, err := os.Stat(file.Path)
 	if err != nil {
@@ -207,7 +769,7 @@ func (i *Indexer) shouldSkipFile(file FileInfo) (bool, error) {
 	currentMtime := stat.ModTime()
 
 	// Get stored metadata
-	meta, err := i.db.GetFileMeta(file.Path)
+	meta, err := dbManager.GetFileMeta(file.Path)
 	if err != nil {
 		return false, err
 	}
@@ -224,7 +786,7 @@ func (i *Indexer) shouldSkipFile(file FileInfo) (bool, error) {
 // indexFile indexes a single file and returns number of symbols stored
 func (i *Indexer) indexFile(ctx context.Context, client *lsp.Client, file FileInfo) (int, error) {
 	// Convert path to URI
-	fileURI := pathToURI(file.Path)
+	fileURI := lsp.PathToURI(file.Path)
 
 	// Read file content
 	content, err := os.ReadFile(file.Path)
@@ -246,58 +808,142 @@ func (i *Indexer) indexFile(ctx context.Context, client *lsp.Client, file FileIn
 	}
 
 	// Store symbols in database
+	if err := i.db.ClearFileContainment(file.Path); err != nil {
+		return 0, err
+	}
 	count := 0
-	if err := i.storeSymbols(file, symbols, "", &count); err != nil {
+	tree := newSymbolTree()
+	tree.pkg = filePackage(file, content)
+	tree.lines = strings.Split(contentStr, "\n")
+	if err := i.storeSymbols(ctx, client, fileURI, file, symbols, "", "", tree, &count); err != nil {
 		return 0, err
 	}
+	// Parents are linked once every symbol of the file exists
+	for _, c := range tree.contains {
+		if err := i.db.InsertContainment(c); err != nil {
+			return 0, err
+		}
+	}
 
 	// Update file metadata
-	if err := i.db.UpdateFileMeta(file.Path, time.Now(), file.Language); err != nil {
+	if err := i.db.UpdateFileMeta(file.Path, time.Now(), file.Language, sourceLSP); err != nil {
 		return 0, err
 	}
 
 	return count, nil
 }
 
-// storeSymbols recursively stores symbols in the database
-func (i *Indexer) storeSymbols(file FileInfo, symbols []lsp.DocumentSymbol, scope string, count *int) error {
+// symbolTree collects the symbol IDs taken in one file and the containment
+// between its symbols while they are extracted
+type symbolTree struct {
+	ids      map[string]bool
+	contains []*db.Containment
+	// definitions are the declarations found by the grammar's extraction
+	// query; nil when the grammar is extracted by its AST walk
+	definitions map[nodeKey]definition
+	pkg         string   // Package of the file's symbols, see filePackage
+	lines       []string // Source of the file, for the LSP's symbol bodies
+}
+
+func newSymbolTree() *symbolTree {
+	return &symbolTree{ids: make(map[string]bool)}
+}
+
+// symbolID returns the ID of a symbol: the file's relative path and the
+// symbol's scope chain, "pkg/server.go#Server.Start". A qualified name that
+// is already taken in the file (overloads, shadowed or redefined names) gets
+// its line appended, "util.py#parse@42", so it never replaces the earlier
+// row.
+func (t *symbolTree) symbolID(file FileInfo, scope, sep, name string, line int) string {
+	id := fmt.Sprintf("%s#%s", file.RelPath, qualifyName(scope, sep, name))
+	if t.ids[id] {
+		id = fmt.Sprintf("%s@%d", id, line)
+	}
+	t.ids[id] = true
+	return id
+}
+
+// scopeSeparator joins the names of a scope chain: Outer.Inner, except for
+// Rust's own path separator (Foo::bar)
+func scopeSeparator(language string) string {
+	if language == "rust" {
+		return "::"
+	}
+	return "."
+}
+
+// qualifyName appends name to the scope chain scope
+func qualifyName(scope, sep, name string) string {
+	if scope == "" {
+		return name
+	}
+	return scope + sep + name
+}
+
+// link records that childID is declared inside parentID
+func (t *symbolTree) link(childID, parentID string) {
+	t.contains = append(t.contains, &db.Containment{ChildID: childID, ParentID: parentID})
+}
+
+// storeSymbols recursively stores symbols in the database, recording IDs and
+// containment in tree
+func (i *Indexer) storeSymbols(ctx context.Context, client *lsp.Client, fileURI string, file FileInfo, symbols []lsp.DocumentSymbol, scope, parentID string, tree *symbolTree, count *int) error {
+	sep := scopeSeparator(file.Language)
 	for _, sym := range symbols {
-		// Create symbol ID
-		id := fmt.Sprintf("%s#%s", file.RelPath, sym.Name)
-		if scope != "" {
-			id = fmt.Sprintf("%s#%s.%s", file.RelPath, scope, sym.Name)
+		id := tree.symbolID(file, scope, sep, sym.Name, sym.SelectionRange.Start.Line+1)
+
+		// Prefer the server-resolved hover signature over documentSymbol detail
+		signature := extractReturnType(sym.Detail, file.Language)
+		documentation := ""
+		if hoverSig, hoverDoc := i.hoverSignature(ctx, client, fileURI, sym); hoverSig != "" {
+			signature = hoverSig
+			documentation = hoverDoc
 		}
 
 		// Create database symbol
 		dbSym := &db.Symbol{
 			ID:            id,
 			Name:          sym.Name,
-			Kind:          lsp.SymbolKindToString(sym.Kind),
+			Kind:          i.kinds.LSPKind(sym.Kind),
 			File:          file.Path,
 			Line:          sym.SelectionRange.Start.Line + 1, // LSP is 0-indexed
 			Column:        sym.SelectionRange.Start.Character,
 			EndLine:       intPtr(sym.Range.End.Line + 1),
 			EndColumn:     intPtr(sym.Range.End.Character),
 			Scope:         scope,
-			Signature:     extractReturnType(sym.Detail, file.Language),
-			Documentation: "",
+			Signature:     signature,
+			Documentation: documentation,
 			Language:      file.Language,
 			Source:        "lsp",
 			CreatedAt:     time.Now(),
+			IsTest:        IsTestFile(file.RelPath),
+			Package:       tree.pkg,
+			BodyHash:      bodyHash(sourceLines(tree.lines, sym.Range.Start.Line, sym.Range.End.Line), sym.Name),
 		}
+		i.kinds.apply(dbSym)
 
 		if err := i.db.InsertSymbol(dbSym); err != nil {
 			return err
 		}
 		*count++
+		if parentID != "" {
+			tree.link(id, parentID)
+		}
 
 		// Recursively process children
 		if len(sym.Children) > 0 {
-			childScope := sym.Name
-			if scope != "" {
-				childScope = scope + "." + sym.Name
+			name := sym.Name
+			childParent := id
+			if file.Language == "rust" {
+				name = rustImplType(name)
+				// Methods of "impl Foo" belong to Foo when it is declared
+				// in this file, like the tree-sitter indexer links them
+				if name != sym.Name {
+					childParent = i.rustImplOwner(file, scope, sep, name, id)
+				}
 			}
-			if err := i.storeSymbols(file, sym.Children, childScope, count); err != nil {
+			childScope := qualifyName(scope, sep, name)
+			if err := i.storeSymbols(ctx, client, fileURI, file, sym.Children, childScope, childParent, tree, count); err != nil {
 				return err
 			}
 		}
@@ -306,6 +952,30 @@ func (i *Indexer) storeSymbols(file FileInfo, symbols []lsp.DocumentSymbol, scop
 	return nil
 }
 
+// hoverSignature asks the server for hover information on a function-like
+// symbol and returns the resolved signature and documentation. Empty strings
+// mean the server had nothing useful, or does not support hover, so the
+// caller keeps the documentSymbol detail.
+func (i *Indexer) hoverSignature(ctx context.Context, client *lsp.Client, fileURI string, sym lsp.DocumentSymbol) (string, string) {
+	if !client.Capabilities.SupportsHover() {
+		return "", ""
+	}
+	switch sym.Kind {
+	case lsp.SymbolKindFunction, lsp.SymbolKindMethod, lsp.SymbolKindConstructor:
+	default:
+		return "", ""
+	}
+
+	hoverCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	hover, err := client.Hover(hoverCtx, fileURI, sym.SelectionRange.Start)
+	if err != nil || hover == nil {
+		return "", ""
+	}
+	return hover.Signature(sym.Name), hover.Documentation()
+}
+
 // Close shuts down all LSP servers
 func (i *Indexer) Close() {
 	i.lsp.ShutdownAll()
@@ -313,15 +983,6 @@ func (i *Indexer) Close() {
 
 // Helper functions
 
-func pathToURI(path string) string {
-	absPath, _ := filepath.Abs(path)
-	u := url.URL{
-		Scheme: "file",
-		Path:   absPath,
-	}
-	return u.String()
-}
-
 func intPtr(i int) *int {
 	return &i
 }
@@ -354,3 +1015,67 @@ func extractReturnType(signature, language string) string {
 
 	return signature
 }
+
+// rustImplOwner returns the ID of the type an impl block implements when it
+// is already indexed, or implID (the impl block itself) otherwise
+func (i *Indexer) rustImplOwner(file FileInfo, scope, sep, typeName, implID string) string {
+	ownerID := fmt.Sprintf("%s#%s", file.RelPath, qualifyName(scope, sep, typeName))
+	if owner, err := i.db.GetSymbolByID(ownerID); err == nil && owner != nil {
+		return ownerID
+	}
+	return implID
+}
+
+// rustImplType maps a rust-analyzer impl block name ("impl Foo",
+// "impl<T> Display for Foo<T>") to the implementing type, so methods are
+// scoped as Foo::bar like the tree-sitter indexer does. Other names are
+// returned unchanged.
+func rustImplType(name string) string {
+	rest, ok := strings.CutPrefix(name, "impl")
+	if !ok || (rest != "" && rest[0] != ' ' && rest[0] != '<') {
+		return name
+	}
+	rest = strings.TrimSpace(skipGenerics(rest))
+	if _, typ, ok := strings.Cut(rest, " for "); ok {
+		rest = strings.TrimSpace(typ)
+	}
+	// Drop references and their lifetimes: &'a mut Foo
+	rest = strings.TrimLeft(rest, "&")
+	if strings.HasPrefix(rest, "'") {
+		if _, after, ok := strings.Cut(rest, " "); ok {
+			rest = after
+		}
+	}
+	rest = strings.TrimPrefix(rest, "mut ")
+	if i := strings.IndexAny(rest, "< "); i >= 0 {
+		rest = rest[:i]
+	}
+	if i := strings.LastIndex(rest, "::"); i >= 0 {
+		rest = rest[i+2:]
+	}
+	if rest == "" {
+		return name
+	}
+	return rest
+}
+
+// skipGenerics drops a leading <...> parameter list
+func skipGenerics(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "<") {
+		return s
+	}
+	depth := 0
+	for i, r := range s {
+		switch r {
+		case '<':
+			depth++
+		case '>':
+			depth--
+			if depth == 0 {
+				return s[i+1:]
+			}
+		}
+	}
+	return s
+}
@@ -2,13 +2,20 @@ package indexer
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
+	sitter "github.com/smacker/go-tree-sitter"
+	"github.com/smacker/go-tree-sitter/javascript"
 	"github.com/tk-425/Codegraph/internal/config"
 	"github.com/tk-425/Codegraph/internal/db"
+	"github.com/tk-425/Codegraph/internal/lsp"
 )
 
 func TestScannerRoutesTypeScriptReactJavaScriptAndOtherLanguages(t *testing.T) {
@@ -40,6 +47,34 @@ func TestScannerRoutesTypeScriptReactJavaScriptAndOtherLanguages(t *testing.T) {
 	}
 }
 
+func TestScannerOnlyLanguages(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"component.tsx", "script.js", "main.go", "app.py"} {
+		if err := os.WriteFile(filepath.Join(root, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ignorePath := filepath.Join(root, ".cgignore")
+	if err := os.WriteFile(ignorePath, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	scanner, err := NewScannerWithConfig(root, ignorePath, config.IndexConfig{OnlyLanguages: []string{"go", "typescript"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := scanner.Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	groups := GroupByLanguage(files)
+	if len(groups["typescriptreact"]) != 1 || len(groups["typescript"]) != 1 || len(groups["go"]) != 1 || len(groups["python"]) != 0 {
+		t.Fatalf("language groups = %#v", groups)
+	}
+	if skipped := scanner.Skipped()[SkipLanguage]; skipped != 1 {
+		t.Errorf("skipped by language = %d, want 1", skipped)
+	}
+}
+
 func TestIndexProjectRetriesNativeTypeScriptThenFallsBack(t *testing.T) {
 	root := t.TempDir()
 	filePath := filepath.Join(root, "example.ts")
@@ -109,3 +144,2461 @@ func TestIndexProjectFallsBackToTreeSitterWhenLSPFails(t *testing.T) {
 		t.Fatal("expected Tree-sitter fallback to record file metadata")
 	}
 }
+
+func TestTreeSitterExtractsCSharpSymbols(t *testing.T) {
+	src := []byte(`namespace Shop.Orders;
+
+public record Order(int Id);
+
+public interface IService { }
+
+public class Service : IService
+{
+    public Service(int retries) { }
+    public string Name { get; set; }
+    public async Task<int> Run(string input) => 1;
+}
+`)
+	file := FileInfo{Path: "/tmp/Service.cs", RelPath: "Service.cs", Language: "csharp"}
+	symbols, err := NewTreeSitterIndexer(nil, "/tmp").ParseContent(context.Background(), file, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, sym := range symbols {
+		got[sym.ID] = sym.Kind + " " + sym.Signature
+	}
+	want := map[string]string{
+		"Service.cs#Order":           "class ",
+		"Service.cs#IService":        "interface ",
+		"Service.cs#Service":         "class ",
+		"Service.cs#Service.Service": "constructor ",
+		"Service.cs#Service.Name":    "property string",
+		"Service.cs#Service.Run":     "method Task<int>",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("symbols = %#v", got)
+	}
+	for id, kind := range want {
+		if got[id] != kind {
+			t.Errorf("%s = %q, want %q", id, got[id], kind)
+		}
+	}
+}
+
+func TestJavaScriptFilesUseJavaScriptGrammar(t *testing.T) {
+	src := []byte(`class Store extends base.Model {
+  save() { persist(this) }
+}
+
+function* ids() { yield 1 }
+`)
+	file := FileInfo{Path: "/tmp/store.mjs", RelPath: "store.mjs", Language: "typescript"}
+	if got := grammarFor(file); got != "javascript" {
+		t.Fatalf("grammarFor = %q", got)
+	}
+	if got := grammarFor(FileInfo{Path: "/tmp/store.ts", Language: "typescript"}); got != "typescript" {
+		t.Fatalf("grammarFor(.ts) = %q", got)
+	}
+
+	symbols, err := NewTreeSitterIndexer(nil, "/tmp").ParseContent(context.Background(), file, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, sym := range symbols {
+		ids = append(ids, sym.ID+":"+sym.Kind)
+	}
+	if got, want := strings.Join(ids, ","), "store.mjs#Store:class,store.mjs#Store.save:method,store.mjs#ids:function"; got != want {
+		t.Fatalf("symbols = %s, want %s", got, want)
+	}
+
+	parser := sitter.NewParser()
+	parser.SetLanguage(javascript.GetLanguage())
+	tree, err := parser.ParseCtx(context.Background(), nil, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	rels := (&HierarchyIndexer{}).extractHierarchy(tree.RootNode(), src, file)
+	if len(rels) != 1 || rels[0].ChildID != "store.mjs#Store" || rels[0].ParentID != "Model" || rels[0].Relationship != "extends" {
+		t.Fatalf("hierarchy = %#v", rels)
+	}
+}
+
+func TestTreeSitterIndexesBoundFunctions(t *testing.T) {
+	src := []byte(`export const handler = async (req: Req) => { validate(req) };
+module.exports.load = function () { fetchAll() };
+Store.prototype.save = function () {};
+module.exports = function main() {};
+class Button { onClick = () => { render() } }
+const api = { list: () => [] };
+items.forEach((item) => log(item));
+`)
+	for _, file := range []FileInfo{
+		{Path: "/tmp/handlers.ts", RelPath: "handlers.ts", Language: "typescript"},
+		{Path: "/tmp/handlers.js", RelPath: "handlers.js", Language: "typescript"},
+	} {
+		content := src
+		if grammarFor(file) == "javascript" {
+			content = []byte(strings.Replace(string(src), "(req: Req)", "(req)", 1))
+		}
+		symbols, err := NewTreeSitterIndexer(nil, "/tmp").ParseContent(context.Background(), file, content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, sym := range symbols {
+			got = append(got, sym.Name+":"+sym.Kind)
+		}
+		want := "handler:function,load:function,save:method,main:function,Button:class,onClick:method,list:method"
+		if strings.Join(got, ",") != want {
+			t.Errorf("%s symbols = %s, want %s", file.RelPath, strings.Join(got, ","), want)
+		}
+
+		parser := sitter.NewParser()
+		parser.SetLanguage(NewTreeSitterIndexer(nil, "/tmp").getLanguage(grammarFor(file)))
+		tree, err := parser.ParseCtx(context.Background(), nil, content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		callers := map[string]string{}
+		(&CallExtractor{}).walkTreeWithContext(tree.RootNode(), content, file, func(n *sitter.Node, _ string, enclosingID string) {
+			if n.Type() == "call_expression" {
+				callers[n.ChildByFieldName("function").Content(content)] = enclosingID
+			}
+		})
+		tree.Close()
+		if callers["validate"] != file.RelPath+"#handler" || callers["fetchAll"] != file.RelPath+"#load" ||
+			callers["render"] != file.RelPath+"#onClick" || callers["log"] != "" {
+			t.Errorf("%s callers = %#v", file.RelPath, callers)
+		}
+	}
+}
+
+func TestTreeSitterQualifiesPythonDefinitions(t *testing.T) {
+	src := []byte(`class Service:
+    @property
+    def name(self):
+        return self._name
+
+    @staticmethod
+    async def fetch(url):
+        def parse(body):
+            return decode(body)
+        return parse(await get(url))
+
+    key = lambda self: self.id
+
+@app.get("/items")
+async def list_items():
+    return Service.fetch("/items")
+
+handler = lambda req: respond(req)
+`)
+	file := FileInfo{Path: "/tmp/service.py", RelPath: "service.py", Language: "python"}
+	symbols, err := NewTreeSitterIndexer(nil, "/tmp").ParseContent(context.Background(), file, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, sym := range symbols {
+		got[sym.ID] = sym.Kind + "|" + sym.Signature
+	}
+	want := map[string]string{
+		"service.py#Service":             "class|class Service:",
+		"service.py#Service.name":        "property|@property def name(self):",
+		"service.py#Service.fetch":       "method|@staticmethod async def fetch(url):",
+		"service.py#Service.fetch.parse": "function|def parse(body):",
+		"service.py#Service.key":         "method|key = lambda self: self.id",
+		"service.py#list_items":          `function|@app.get("/items") async def list_items():`,
+		"service.py#handler":             "function|handler = lambda req: respond(req)",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("symbols = %#v", got)
+	}
+	for id, w := range want {
+		if got[id] != w {
+			t.Errorf("%s = %q, want %q", id, got[id], w)
+		}
+	}
+
+	parser := sitter.NewParser()
+	parser.SetLanguage(NewTreeSitterIndexer(nil, "/tmp").getLanguage("python"))
+	tree, err := parser.ParseCtx(context.Background(), nil, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	callers := map[string]string{}
+	(&CallExtractor{}).walkTreeWithContext(tree.RootNode(), src, file, func(n *sitter.Node, _ string, enclosingID string) {
+		if n.Type() == "call" {
+			callers[n.ChildByFieldName("function").Content(src)] = enclosingID
+		}
+	})
+	for callee, caller := range map[string]string{
+		"decode":        "service.py#Service.fetch.parse",
+		"get":           "service.py#Service.fetch",
+		"Service.fetch": "service.py#list_items",
+		"respond":       "service.py#handler",
+	} {
+		if callers[callee] != caller {
+			t.Errorf("caller of %s = %q, want %q", callee, callers[callee], caller)
+		}
+	}
+}
+
+func TestTreeSitterQualifiesRustMethodsAndTraitImpls(t *testing.T) {
+	root := t.TempDir()
+	src := []byte(`trait Shape {
+    fn area(&self) -> f64;
+}
+
+struct Square<T> { side: T }
+
+impl<T> Square<T> {
+    fn new(side: T) -> Self { Square { side } }
+}
+
+impl Shape for Square<f64> {
+    fn area(&self) -> f64 { square(self.side) }
+}
+
+fn square(x: f64) -> f64 { x * x }
+`)
+	path := filepath.Join(root, "shape.rs")
+	if err := os.WriteFile(path, src, 0644); err != nil {
+		t.Fatal(err)
+	}
+	database, err := db.NewManager(filepath.Join(root, "graph.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	if err := database.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	file := FileInfo{Path: path, RelPath: "shape.rs", Language: "rust"}
+
+	if _, err := NewTreeSitterIndexer(database, root).IndexFile(context.Background(), file); err != nil {
+		t.Fatal(err)
+	}
+	for id, kind := range map[string]string{
+		"shape.rs#Shape::area":  "method",
+		"shape.rs#Square::new":  "method",
+		"shape.rs#Square::area": "method",
+		"shape.rs#square":       "function",
+	} {
+		sym, err := database.GetSymbolByID(id)
+		if err != nil || sym == nil || sym.Kind != kind {
+			t.Errorf("%s = %#v (%v), want kind %s", id, sym, err, kind)
+		}
+	}
+
+	if _, err := NewHierarchyIndexer(database, nil, root).IndexHierarchyTreeSitter(context.Background(), file); err != nil {
+		t.Fatal(err)
+	}
+	impls, err := database.GetImplementations("shape.rs#Shape::area")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(impls) != 1 || impls[0].ID != "shape.rs#Square::area" {
+		t.Fatalf("implementations of Shape::area = %#v", impls)
+	}
+
+	parser := sitter.NewParser()
+	parser.SetLanguage(NewTreeSitterIndexer(nil, root).getLanguage("rust"))
+	tree, err := parser.ParseCtx(context.Background(), nil, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	var caller string
+	(&CallExtractor{}).walkTreeWithContext(tree.RootNode(), src, file, func(n *sitter.Node, _ string, enclosingID string) {
+		if n.Type() == "call_expression" && n.ChildByFieldName("function").Content(src) == "square" {
+			caller = enclosingID
+		}
+	})
+	if caller != "shape.rs#Square::area" {
+		t.Fatalf("caller of square = %q", caller)
+	}
+}
+
+func TestTreeSitterNamesOCamlBindingsWithoutParameters(t *testing.T) {
+	root := t.TempDir()
+	src := []byte(`let add (x : int) ~y : int = x + y
+let limit = 10
+let ( +! ) a b = add a ~y:b
+let (a, b) = (1, 2)
+let total xs =
+  let step acc x = acc +! x in
+  List.fold_left step (add 0 ~y:limit) xs
+let () = print_int (total [1; 2])
+`)
+	path := filepath.Join(root, "calc.ml")
+	if err := os.WriteFile(path, src, 0644); err != nil {
+		t.Fatal(err)
+	}
+	database, err := db.NewManager(filepath.Join(root, "graph.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	if err := database.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	file := FileInfo{Path: path, RelPath: "calc.ml", Language: "ocaml"}
+	if _, err := NewTreeSitterIndexer(database, root).IndexFile(context.Background(), file); err != nil {
+		t.Fatal(err)
+	}
+
+	symbols, err := database.GetFileSymbols(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, s := range symbols {
+		got = append(got, fmt.Sprintf("%s %s (%s)", s.ID, s.Kind, s.Signature))
+	}
+	want := []string{
+		"calc.ml#add function ((x : int) ~y : int)",
+		"calc.ml#limit variable ()",
+		"calc.ml#+! function (a b)",
+		"calc.ml#total function (xs)",
+		"calc.ml#total.step function (acc x)",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("symbols = %q, want %q", got, want)
+	}
+
+	if _, err := NewCallExtractor(database, root).ExtractCalls(context.Background(), file); err != nil {
+		t.Fatal(err)
+	}
+	callers, err := database.GetCallersByID("calc.ml#add", db.QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var callerIDs []string
+	for _, c := range callers {
+		callerIDs = append(callerIDs, c.ID)
+	}
+	slices.Sort(callerIDs)
+	if !slices.Equal(callerIDs, []string{"calc.ml#+!", "calc.ml#total"}) {
+		t.Fatalf("callers of add = %q", callerIDs)
+	}
+}
+
+func TestSwiftExtensionsAddConformances(t *testing.T) {
+	root := t.TempDir()
+	database, err := db.NewManager(filepath.Join(root, "graph.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	if err := database.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	var files []FileInfo
+	for _, f := range []struct{ name, src string }{
+		{"Shape.swift", "protocol Shape: AnyObject {}\nclass Base {}\nclass Square: Base, Shape {}\n"},
+		{"Point.swift", "struct Point { var x: Int }\n"},
+		{"Point+Codable.swift", "extension Point: Codable, Shape {\n  func norm() -> Int { return x }\n}\nextension Square: Hashable {}\n"},
+	} {
+		path := filepath.Join(root, f.name)
+		if err := os.WriteFile(path, []byte(f.src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		file := FileInfo{Path: path, RelPath: f.name, Language: "swift"}
+		if _, err := NewTreeSitterIndexer(database, root).IndexFile(context.Background(), file); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, file)
+	}
+	for _, file := range files {
+		if _, err := NewHierarchyIndexer(database, nil, root).IndexHierarchyTreeSitter(context.Background(), file); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	relations, err := database.ListTypeHierarchy()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range relations {
+		got = append(got, fmt.Sprintf("%s %s %s", r.ChildID, r.Relationship, r.ParentID))
+	}
+	slices.Sort(got)
+	want := []string{
+		"Point.swift#Point implements Shape.swift#Shape",
+		"Shape.swift#Square extends Shape.swift#Base",
+		"Shape.swift#Square implements Shape.swift#Shape",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("type relations = %q, want %q", got, want)
+	}
+}
+
+func TestJavaHierarchyCoversInterfacesEnumsAndNestedTypes(t *testing.T) {
+	root := t.TempDir()
+	src := []byte(`interface Named {}
+interface Shape extends Named, Comparable<Shape> {}
+enum Color implements Named { RED }
+class Base {}
+class Outer extends Base {
+    static class Inner implements Shape {}
+    interface Nested extends Shape {}
+}
+`)
+	path := filepath.Join(root, "Shapes.java")
+	if err := os.WriteFile(path, src, 0644); err != nil {
+		t.Fatal(err)
+	}
+	database, err := db.NewManager(filepath.Join(root, "graph.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	if err := database.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	file := FileInfo{Path: path, RelPath: "Shapes.java", Language: "java"}
+	if _, err := NewTreeSitterIndexer(database, root).IndexFile(context.Background(), file); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewHierarchyIndexer(database, nil, root).IndexHierarchyTreeSitter(context.Background(), file); err != nil {
+		t.Fatal(err)
+	}
+
+	relations, err := database.ListTypeHierarchy()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range relations {
+		if sym, err := database.GetSymbolByID(r.ChildID); err != nil || sym == nil {
+			t.Errorf("child %s is not an indexed symbol", r.ChildID)
+		}
+		got = append(got, fmt.Sprintf("%s %s %s", r.ChildID, r.Relationship, r.ParentID))
+	}
+	want := []string{
+		"Shapes.java#Color implements Shapes.java#Named",
+		"Shapes.java#Outer extends Shapes.java#Base",
+		"Shapes.java#Outer.Inner implements Shapes.java#Shape",
+		"Shapes.java#Outer.Nested extends Shapes.java#Shape",
+		"Shapes.java#Shape extends Shapes.java#Named",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("type relations = %q, want %q", got, want)
+	}
+}
+
+func TestHierarchyNormalizesGenericParents(t *testing.T) {
+	for _, tc := range []struct {
+		file, lang, src string
+		want            []string
+	}{
+		{"Repo.java", "java", `class Base<T> {}
+interface Store<T> {}
+class Repo extends Base<User> implements Store<Map<String, User>> {}
+`, []string{"Repo.java#Repo extends Repo.java#Base <User>", "Repo.java#Repo implements Repo.java#Store <Map<String, User>>"}},
+		{"repo.ts", "typescript", `class Base<T> {}
+interface Store<T> {}
+class Repo extends Base<User> implements Store<User> {}
+`, []string{"repo.ts#Repo extends repo.ts#Base <User>", "repo.ts#Repo implements repo.ts#Store <User>"}},
+		{"repo.py", "python", `class Base:
+    pass
+
+class Repo(Base[User]):
+    pass
+`, []string{"repo.py#Repo extends repo.py#Base <User>"}},
+	} {
+		t.Run(tc.file, func(t *testing.T) {
+			root := t.TempDir()
+			path := filepath.Join(root, tc.file)
+			if err := os.WriteFile(path, []byte(tc.src), 0644); err != nil {
+				t.Fatal(err)
+			}
+			database, err := db.NewManager(filepath.Join(root, "graph.db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer database.Close()
+			if err := database.Initialize(); err != nil {
+				t.Fatal(err)
+			}
+			file := FileInfo{Path: path, RelPath: tc.file, Language: tc.lang}
+			if _, err := NewTreeSitterIndexer(database, root).IndexFile(context.Background(), file); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := NewHierarchyIndexer(database, nil, root).IndexHierarchyTreeSitter(context.Background(), file); err != nil {
+				t.Fatal(err)
+			}
+
+			relations, err := database.ListTypeHierarchy()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, r := range relations {
+				got = append(got, fmt.Sprintf("%s %s %s <%s>", r.ChildID, r.Relationship, r.ParentID, r.TypeArgs))
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Fatalf("type relations = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSplitTypeArgs(t *testing.T) {
+	for name, want := range map[string][2]string{
+		"Base":            {"Base", ""},
+		"Base<User>":      {"Base", "User"},
+		"Map<K, List<V>>": {"Map", "K, List<V>"},
+		"Generic[T]":      {"Generic", "T"},
+		"pkg.Other[int]":  {"pkg.Other", "int"},
+		"operator<":       {"operator<", ""},
+	} {
+		raw, args := splitTypeArgs(name)
+		if raw != want[0] || args != want[1] {
+			t.Errorf("splitTypeArgs(%q) = %q, %q, want %q, %q", name, raw, args, want[0], want[1])
+		}
+	}
+}
+
+func TestTypeScriptInterfacesAndTypeAliasesJoinHierarchy(t *testing.T) {
+	root := t.TempDir()
+	src := []byte(`namespace ns { export interface Named {} }
+interface Base {}
+interface Circle {}
+interface Square {}
+interface Shape extends Base, ns.Named {}
+type Entity<T> = Base & Partial<T> & { id: string };
+type Figure = Circle | Square | (Base & Circle) | "none";
+`)
+	path := filepath.Join(root, "shapes.ts")
+	if err := os.WriteFile(path, src, 0644); err != nil {
+		t.Fatal(err)
+	}
+	database, err := db.NewManager(filepath.Join(root, "graph.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	if err := database.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	file := FileInfo{Path: path, RelPath: "shapes.ts", Language: "typescript"}
+	if _, err := NewTreeSitterIndexer(database, root).IndexFile(context.Background(), file); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewHierarchyIndexer(database, nil, root).IndexHierarchyTreeSitter(context.Background(), file); err != nil {
+		t.Fatal(err)
+	}
+
+	relations, err := database.ListTypeHierarchy()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range relations {
+		got = append(got, fmt.Sprintf("%s %s %s", r.ChildID, r.Relationship, r.ParentID))
+	}
+	want := []string{
+		"shapes.ts#Entity extends shapes.ts#Base",
+		"shapes.ts#Figure includes shapes.ts#Circle",
+		"shapes.ts#Figure includes shapes.ts#Square",
+		"shapes.ts#Shape extends shapes.ts#Base",
+		"shapes.ts#Shape extends shapes.ts#Named",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("type relations = %q, want %q", got, want)
+	}
+
+	// A union does not implement its members
+	impls, err := database.GetImplementationsByName("Circle", db.QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(impls) != 0 {
+		t.Errorf("implementations of Circle = %v, want none", impls)
+	}
+}
+
+func TestFilePackage(t *testing.T) {
+	for _, tc := range []struct {
+		rel, language, src, want string
+	}{
+		{"internal/db/manager.go", "go", "// Package db stores the graph\npackage db\n", "db"},
+		{"src/main/java/com/app/db/Repo.java", "java", "/* x */\npackage com.app.db;\n\nclass Repo {}\n", "com.app.db"},
+		{"Main.java", "java", "class Main {}\n", ""},
+		{"pkg/sub/mod.py", "python", "", "pkg.sub.mod"},
+		{"pkg/sub/__init__.py", "python", "", "pkg.sub"},
+		{"src/db/manager.ts", "typescript", "", "src/db/manager"},
+		{"src/db/index.tsx", "typescriptreact", "", "src/db"},
+		{"index.js", "javascript", "", ""},
+		{"src/lib.rs", "rust", "", ""},
+	} {
+		file := FileInfo{RelPath: tc.rel, Language: tc.language}
+		if got := filePackage(file, []byte(tc.src)); got != tc.want {
+			t.Errorf("filePackage(%s) = %q, want %q", tc.rel, got, tc.want)
+		}
+	}
+}
+
+func TestSymbolsQueriedByPackage(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"internal/db/manager.go":  "package db\n\ntype Manager struct{}\n",
+		"internal/rdb/manager.go": "package rdb\n\ntype Manager struct{}\n",
+		"lsp/manager.go":          "package lsp\n\ntype Manager struct{}\n",
+	}
+	database, err := db.NewManager(filepath.Join(root, "graph.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	if err := database.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	for rel, src := range files {
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		file := FileInfo{Path: path, RelPath: rel, Language: "go"}
+		if _, err := NewTreeSitterIndexer(database, root).IndexFile(context.Background(), file); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	symbols, err := database.SearchSymbols("Manager", db.QueryOptions{Package: "db"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(symbols) != 1 || symbols[0].ID != "internal/db/manager.go#Manager" || symbols[0].Package != "db" {
+		t.Fatalf("symbols in package db = %+v, want internal/db's Manager only", symbols)
+	}
+	packages, err := database.ListPackages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"db", "lsp", "rdb"}; !slices.Equal(packages, want) {
+		t.Errorf("packages = %q, want %q", packages, want)
+	}
+}
+
+func TestCallsResolveThroughAliases(t *testing.T) {
+	root := t.TempDir()
+	files := []struct{ rel, lang, src string }{
+		{"lib.py", "python", "def fetch():\n    pass\n"},
+		{"app.py", "python", "from lib import fetch as get\n\ndef main():\n    get()\n"},
+		{"util.ts", "typescript", "export function parse() {}\n"},
+		{"index.ts", "typescript", "export { parse as read } from './util';\n"},
+		{"main.ts", "typescript", "import { read } from './index';\n\nfunction run() {\n  read();\n}\n"},
+		{"a/other/add.go", "go", "package other\n\nfunc Add() {}\n"},
+		{"z/mathutil/add.go", "go", "package mathutil\n\nfunc Add() {}\n"},
+		{"cmd/main.go", "go", "package main\n\nimport m \"example.com/z/mathutil\"\n\nfunc main() {\n\tm.Add()\n}\n"},
+	}
+	database, err := db.NewManager(filepath.Join(root, "graph.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	if err := database.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	var infos []FileInfo
+	for _, f := range files {
+		path := filepath.Join(root, f.rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(f.src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		file := FileInfo{Path: path, RelPath: f.rel, Language: f.lang}
+		if _, err := NewTreeSitterIndexer(database, root).IndexFile(ctx, file); err != nil {
+			t.Fatal(err)
+		}
+		infos = append(infos, file)
+	}
+	count, err := NewAliasIndexer(database).IndexAliases(ctx, infos)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 {
+		t.Errorf("aliases = %d, want 3 (get, read and m)", count)
+	}
+	extractor := NewCallExtractor(database, root)
+	for _, file := range infos {
+		if _, err := extractor.ExtractCalls(ctx, file); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for caller, want := range map[string]string{
+		"app.py#main":      "lib.py#fetch",          // from lib import fetch as get
+		"main.ts#run":      "util.ts#parse",         // export { parse as read }
+		"cmd/main.go#main": "z/mathutil/add.go#Add", // import m ".../mathutil"
+	} {
+		callees, err := database.GetCalleesByID(caller, db.QueryOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(callees) != 1 || callees[0].ID != want {
+			t.Errorf("callees of %s = %+v, want %s", caller, callees, want)
+		}
+	}
+}
+
+func TestCallFilterNarrowsCallResolution(t *testing.T) {
+	files := []struct{ rel, lang, src string }{
+		{"store.py", "python", "def get():\n    pass\n\ndef helper():\n    pass\n"},
+		{"app.py", "python", "def main():\n    cache.get()\n    helper()\n    run()\n\ndef run():\n    pass\n"},
+		{"web.ts", "typescript", "function render() {\n  helper();\n}\n"},
+		{"db/open.go", "go", "package db\n\nfunc Open() {}\n\nfunc Close() {}\n"},
+		{"rdb/open.go", "go", "package rdb\n\nfunc Open() {}\n"},
+		{"cmd/main.go", "go", "package main\n\nimport \"example.com/db\"\n\nfunc main() {\n\tdb.Open()\n\tClose()\n}\n"},
+	}
+	callees := func(t *testing.T, cfg config.CallsConfig) map[string][]string {
+		root := t.TempDir()
+		database, err := db.NewManager(filepath.Join(root, "graph.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer database.Close()
+		if err := database.Initialize(); err != nil {
+			t.Fatal(err)
+		}
+		ctx := context.Background()
+		var infos []FileInfo
+		for _, f := range files {
+			path := filepath.Join(root, f.rel)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(f.src), 0644); err != nil {
+				t.Fatal(err)
+			}
+			file := FileInfo{Path: path, RelPath: f.rel, Language: f.lang}
+			if _, err := NewTreeSitterIndexer(database, root).IndexFile(ctx, file); err != nil {
+				t.Fatal(err)
+			}
+			infos = append(infos, file)
+		}
+		extractor := NewCallExtractor(database, root)
+		extractor.SetFilter(NewCallFilter(cfg))
+		got := map[string][]string{}
+		for _, file := range infos {
+			if _, err := extractor.ExtractCalls(ctx, file); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, caller := range []string{"app.py#main", "web.ts#render", "cmd/main.go#main"} {
+			calls, err := database.GetCalleesByID(caller, db.QueryOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, call := range calls {
+				got[caller] = append(got[caller], call.ID)
+			}
+			slices.Sort(got[caller])
+		}
+		return got
+	}
+
+	for _, tc := range []struct {
+		name string
+		cfg  config.CallsConfig
+		want map[string][]string
+	}{
+		{"default", config.CallsConfig{}, map[string][]string{
+			"app.py#main":      {"app.py#run", "store.py#helper"}, // get is a builtin name
+			"web.ts#render":    {"store.py#helper"},
+			"cmd/main.go#main": {"db/open.go#Close", "db/open.go#Open"},
+		}},
+		{"keep builtins", config.CallsConfig{KeepBuiltins: true, Ignore: map[string][]string{"python": {"run"}}}, map[string][]string{
+			"app.py#main":      {"app.py#run", "store.py#get", "store.py#helper"}, // run is defined in app.py
+			"web.ts#render":    {"store.py#helper"},
+			"cmd/main.go#main": {"db/open.go#Close", "db/open.go#Open"},
+		}},
+		{"language", config.CallsConfig{Resolve: config.ResolveLanguage}, map[string][]string{
+			"app.py#main":      {"app.py#run", "store.py#helper"},
+			"cmd/main.go#main": {"db/open.go#Close", "db/open.go#Open"},
+		}},
+		{"package", config.CallsConfig{Resolve: config.ResolvePackage}, map[string][]string{
+			"app.py#main":      {"app.py#run"},
+			"cmd/main.go#main": {"db/open.go#Open"},
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := callees(t, tc.cfg)
+			for _, caller := range []string{"app.py#main", "web.ts#render", "cmd/main.go#main"} {
+				if !slices.Equal(got[caller], tc.want[caller]) {
+					t.Errorf("callees of %s = %q, want %q", caller, got[caller], tc.want[caller])
+				}
+			}
+		})
+	}
+}
+
+func TestRustImplType(t *testing.T) {
+	for name, want := range map[string]string{
+		"impl Foo":                           "Foo",
+		"impl<T> Display for Foo<T>":         "Foo",
+		"impl fmt::Debug for &'a Bar":        "Bar",
+		"impl<K: Ord> Map<K> for a::Tree<K>": "Tree",
+		"implode":                            "implode",
+	} {
+		if got := rustImplType(name); got != want {
+			t.Errorf("rustImplType(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestTreeSitterIndexesMembers(t *testing.T) {
+	cases := []struct {
+		file FileInfo
+		src  string
+		want string
+	}{
+		{
+			FileInfo{Path: "/tmp/a.go", RelPath: "a.go", Language: "go"},
+			"package a\ntype S struct {\n\tA, B int\n\t*io.Reader\n}\n",
+			"a.go#S:struct:,a.go#S.A:field:int,a.go#S.B:field:int,a.go#S.Reader:field:*io.Reader",
+		},
+		{
+			FileInfo{Path: "/tmp/a.ts", RelPath: "a.ts", Language: "typescript"},
+			"interface I { name: string }\nenum E { Red, Green = 2 }\ntype T = { skipped: number }\n",
+			"a.ts#I:interface:,a.ts#I.name:field:string,a.ts#E:enum:,a.ts#E.Red:enum_member:,a.ts#E.Green:enum_member:2,a.ts#T:type:{ skipped: number }",
+		},
+		{
+			FileInfo{Path: "/tmp/a.c", RelPath: "a.c", Language: "c"},
+			"struct S { int a, *b; };\ntypedef struct { int skipped; } T;\n",
+			"a.c#S:struct:,a.c#S.a:field:int,a.c#S.b:field:int",
+		},
+		{
+			FileInfo{Path: "/tmp/a.rs", RelPath: "a.rs", Language: "rust"},
+			"struct S { pub a: u8 }\nenum E { A, B(i32) }\n",
+			"a.rs#S:struct:,a.rs#S::a:field:u8,a.rs#E:enum:,a.rs#E::A:enum_member:A,a.rs#E::B:enum_member:B(i32)",
+		},
+		{
+			FileInfo{Path: "/tmp/a.py", RelPath: "a.py", Language: "python"},
+			"class Color(enum.Enum):\n    RED = 1\n\nclass User:\n    name: str = ''\n",
+			"a.py#Color:class:class Color(enum.Enum):,a.py#Color.RED:enum_member:,a.py#User:class:class User:,a.py#User.name:field:str",
+		},
+	}
+	for _, tc := range cases {
+		symbols, err := NewTreeSitterIndexer(nil, "/tmp").ParseContent(context.Background(), tc.file, []byte(tc.src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, sym := range symbols {
+			got = append(got, sym.ID+":"+sym.Kind+":"+sym.Signature)
+		}
+		if strings.Join(got, ",") != tc.want {
+			t.Errorf("%s symbols =\n%s\nwant\n%s", tc.file.RelPath, strings.Join(got, ","), tc.want)
+		}
+	}
+}
+
+func TestCallEdgesRecordArguments(t *testing.T) {
+	src := []byte("package main\n\nfunc main() {\n\tserve(addr, handler(\"a,b\"))\n\tready()\n}\n")
+	file := FileInfo{Path: "/tmp/main.go", RelPath: "main.go", Language: "go"}
+	parser := sitter.NewParser()
+	parser.SetLanguage(NewTreeSitterIndexer(nil, "/tmp").getLanguage("go"))
+	tree, err := parser.ParseCtx(context.Background(), nil, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	got := map[string]string{}
+	(&CallExtractor{}).walkTreeWithContext(tree.RootNode(), src, file, func(n *sitter.Node, _ string, _ string) {
+		if n.Type() == "call_expression" {
+			count, args := callArguments(n, src)
+			got[n.ChildByFieldName("function").Content(src)] = fmt.Sprintf("%d %s", *count, args)
+		}
+	})
+	for callee, want := range map[string]string{
+		"serve":   `2 (addr, handler("a,b"))`,
+		"handler": `1 ("a,b")`,
+		"ready":   "0 ()",
+	} {
+		if got[callee] != want {
+			t.Errorf("%s args = %q, want %q", callee, got[callee], want)
+		}
+	}
+
+	// The LSP path only knows where the callee name starts
+	for _, tc := range []struct {
+		line, col int
+		want      string
+	}{
+		{3, 1, `2 (addr, handler("a,b"))`},
+		{4, 1, "0 ()"},
+		{2, 5, "0 ()"},
+		{3, 7, "none"}, // addr is an argument, not a call
+	} {
+		got := "none"
+		if count, args := callArgumentsAt(string(src), tc.line, tc.col); count != nil {
+			got = fmt.Sprintf("%d %s", *count, args)
+		}
+		if got != tc.want {
+			t.Errorf("callArgumentsAt(%d, %d) = %q, want %q", tc.line, tc.col, got, tc.want)
+		}
+	}
+	// LSP counts characters in UTF-16 code units: 日本😀 is 4 of them
+	if count, args := callArgumentsAt("\tname := \"日本😀\"; ready(1)\n", 0, 17); count == nil || *count != 1 {
+		t.Errorf("callArgumentsAt after non-ASCII text = %v, %q; want 1 (1)", count, args)
+	}
+}
+
+func TestCallContext(t *testing.T) {
+	cases := []struct {
+		lang, src string
+		want      map[string]string
+	}{
+		{"go", "package main\n\nfunc main() {\n\tif err := load(); err != nil {\n\t\tfail(err)\n\t}\n\tfor _, x := range items() {\n\t\tif x {\n\t\t\tvisit(x)\n\t\t}\n\t}\n\tdefer cleanup()\n\tgo func() { work() }()\n\tdone()\n}\n",
+			map[string]string{"load": "", "fail": "if", "items": "", "visit": "loop,if", "cleanup": "defer", "work": "goroutine", "done": ""}},
+		{"python", "def main():\n    try:\n        load()\n    except Error:\n        rollback()\n    finally:\n        close()\n    [visit(x) for x in items()]\n",
+			map[string]string{"load": "try", "rollback": "catch", "close": "finally", "visit": "loop", "items": ""}},
+		{"java", "class A { void run() { try { load(); } catch (E e) { if (retry) { rollback(); } } } }",
+			map[string]string{"load": "try", "rollback": "catch,if"}},
+	}
+	for _, tc := range cases {
+		parser := sitter.NewParser()
+		parser.SetLanguage((&CallExtractor{}).getLanguage(tc.lang))
+		tree, err := parser.ParseCtx(context.Background(), nil, []byte(tc.src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := map[string]string{}
+		var walk func(n *sitter.Node)
+		walk = func(n *sitter.Node) {
+			switch n.Type() {
+			case "call_expression", "call":
+				got[n.ChildByFieldName("function").Content([]byte(tc.src))] = callContext(n, tc.lang)
+			case "method_invocation":
+				got[n.ChildByFieldName("name").Content([]byte(tc.src))] = callContext(n, tc.lang)
+			}
+			for i := 0; i < int(n.NamedChildCount()); i++ {
+				walk(n.NamedChild(i))
+			}
+		}
+		walk(tree.RootNode())
+		tree.Close()
+		for callee, want := range tc.want {
+			if ctx, ok := got[callee]; !ok || ctx != want {
+				t.Errorf("%s: context of %s = %q, want %q", tc.lang, callee, ctx, want)
+			}
+		}
+	}
+}
+
+func TestIsTestFile(t *testing.T) {
+	for path, want := range map[string]bool{
+		"pkg/server_test.go":              true,
+		"pkg/server.go":                   false,
+		"tests/test_api.py":               true,
+		"app/api_test.py":                 true,
+		"conftest.py":                     true,
+		"app/testing.py":                  false,
+		"src/app.spec.ts":                 true,
+		"src/app.test.jsx":                true,
+		"src/__tests__/app.ts":            true,
+		"src/app.ts":                      false,
+		"src/main/java/a/Parser.java":     false,
+		"src/test/java/a/ParserTest.java": true,
+		"src/main/java/a/Contest.java":    false,
+		"Tests/ParserTests.swift":         true,
+		"tests/integration.rs":            true,
+		"src/lib.rs":                      false,
+		"test/test_parser.ml":             true,
+	} {
+		if got := IsTestFile(path); got != want {
+			t.Errorf("IsTestFile(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestIndexFileRecordsContainment(t *testing.T) {
+	root := t.TempDir()
+	database, err := db.NewManager(filepath.Join(root, "graph.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	if err := database.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, src := range map[string]string{
+		"shop.py": "class Cart:\n    total: int = 0\n    def add(self, item):\n        def price():\n            return item.price\n",
+		"shop.rs": "struct Cart { total: u32 }\nimpl Cart {\n    fn add(&mut self) {}\n}\n",
+	} {
+		path := filepath.Join(root, name)
+		if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		lang := map[string]string{".py": "python", ".rs": "rust"}[filepath.Ext(name)]
+		if _, err := NewTreeSitterIndexer(database, root).IndexFile(context.Background(), FileInfo{Path: path, RelPath: name, Language: lang}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for parent, want := range map[string]string{
+		"shop.py#Cart":     "shop.py#Cart.total,shop.py#Cart.add",
+		"shop.py#Cart.add": "shop.py#Cart.add.price",
+		"shop.rs#Cart":     "shop.rs#Cart::total,shop.rs#Cart::add",
+	} {
+		children, err := database.GetChildren(parent, db.QueryOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, c := range children {
+			ids = append(ids, c.ID)
+		}
+		if got := strings.Join(ids, ","); got != want {
+			t.Errorf("children of %s = %s, want %s", parent, got, want)
+		}
+	}
+}
+
+func TestSymbolIDsDoNotCollide(t *testing.T) {
+	root := t.TempDir()
+	database, err := db.NewManager(filepath.Join(root, "graph.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	if err := database.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Same-named nested functions in different parents, and a redefinition
+	src := "def load():\n    def parse():\n        return 1\n\ndef save():\n    def parse():\n        return decode()\n\ndef decode():\n    pass\n\ndef decode():\n    return 2\n"
+	path := filepath.Join(root, "util.py")
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	file := FileInfo{Path: path, RelPath: "util.py", Language: "python"}
+	if _, err := NewTreeSitterIndexer(database, root).IndexFile(context.Background(), file); err != nil {
+		t.Fatal(err)
+	}
+
+	symbols, err := database.GetFileSymbols(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, sym := range symbols {
+		ids = append(ids, sym.ID)
+	}
+	want := "util.py#load,util.py#load.parse,util.py#save,util.py#save.parse,util.py#decode,util.py#decode@12"
+	if got := strings.Join(ids, ","); got != want {
+		t.Fatalf("ids = %s, want %s", got, want)
+	}
+
+	if _, err := NewCallExtractor(database, root).ExtractCalls(context.Background(), file); err != nil {
+		t.Fatal(err)
+	}
+	callers, err := database.GetCallers("decode", db.QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(callers) != 1 || callers[0].ID != "util.py#save.parse" {
+		t.Fatalf("callers of decode = %#v", callers)
+	}
+}
+
+func TestBuiltinQueriesCompile(t *testing.T) {
+	entries, err := builtinQueries.ReadDir("queries")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := NewTreeSitterIndexer(nil, "")
+	for _, entry := range entries {
+		grammar := strings.TrimSuffix(entry.Name(), ".scm")
+		source, _, _, err := ts.symbolQuery(grammar)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := sitter.NewQuery(source, ts.getLanguage(grammar)); err != nil {
+			t.Errorf("%s: %v", entry.Name(), err)
+		}
+	}
+}
+
+func TestTreeSitterQueriesExtractSymbols(t *testing.T) {
+	cases := []struct {
+		file FileInfo
+		src  string
+		want string
+	}{
+		{
+			FileInfo{Path: "/tmp/A.java", RelPath: "A.java", Language: "java"},
+			"class A {\n  int x, y;\n  enum E { ON }\n  void run() {}\n}\n",
+			"A.java#A:class:,A.java#A.x:field:int,A.java#A.y:field:int,A.java#A.E:enum:,A.java#A.E.ON:enum_member:,A.java#A.run:method:void run() {}",
+		},
+		{
+			FileInfo{Path: "/tmp/a.cpp", RelPath: "a.cpp", Language: "cpp"},
+			"class W { int* p; };\nint* W::get() { return p; }\n",
+			"a.cpp#W:class:,a.cpp#W.p:field:int,a.cpp#W::get:function:int* W::get() { return p; }",
+		},
+	}
+	for _, tc := range cases {
+		symbols, err := NewTreeSitterIndexer(nil, "").ParseContent(context.Background(), tc.file, []byte(tc.src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, sym := range symbols {
+			got = append(got, sym.ID+":"+sym.Kind+":"+sym.Signature)
+		}
+		if strings.Join(got, ",") != tc.want {
+			t.Errorf("%s symbols =\n%s\nwant\n%s", tc.file.RelPath, strings.Join(got, ","), tc.want)
+		}
+	}
+}
+
+func TestTreeSitterProjectQueryOverride(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, ".codegraph", "queries")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// Only functions, as commands
+	query := "(function_declaration name: (identifier) @name) @definition.command\n"
+	if err := os.WriteFile(filepath.Join(dir, "go.scm"), []byte(query), 0644); err != nil {
+		t.Fatal(err)
+	}
+	file := FileInfo{Path: filepath.Join(root, "a.go"), RelPath: "a.go", Language: "go"}
+	src := []byte("package a\n\ntype T struct{}\n\nfunc Run() {}\n")
+	symbols, err := NewTreeSitterIndexer(nil, root).ParseContent(context.Background(), file, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(symbols) != 1 || symbols[0].ID != "a.go#Run" || symbols[0].Kind != "command" {
+		t.Fatalf("symbols = %#v", symbols)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "go.scm"), []byte("(nonsense_node) @definition.x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewTreeSitterIndexer(nil, root).ParseContent(context.Background(), file, src); err == nil || !strings.Contains(err.Error(), "go.scm") {
+		t.Fatalf("invalid query error = %v", err)
+	}
+}
+
+func TestSymbolQueriesAreCompiledOnce(t *testing.T) {
+	root := t.TempDir()
+	ts := NewTreeSitterIndexer(nil, root)
+	lang := ts.getLanguage("java")
+	first, err := ts.compiledSymbolQuery("java", lang)
+	if err != nil || first == nil {
+		t.Fatalf("java query = %v, %v", first, err)
+	}
+	if again, _ := NewTreeSitterIndexer(nil, root).compiledSymbolQuery("java", lang); again != first {
+		t.Error("java query compiled again")
+	}
+	if query, err := ts.compiledSymbolQuery("go", ts.getLanguage("go")); query != nil || err != nil {
+		t.Errorf("go query = %v, %v; want the AST walk", query, err)
+	}
+
+	// An override replaces the cached built-in query
+	dir := filepath.Join(root, ".codegraph", "queries")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "java.scm"), []byte("(class_declaration name: (identifier) @name) @definition.class\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if override, err := ts.compiledSymbolQuery("java", lang); err != nil || override == first {
+		t.Errorf("override query = %v, %v; want a new query", override, err)
+	}
+}
+
+func TestKindMapRelabelsSymbols(t *testing.T) {
+	kinds, err := NewKindMap(config.KindsConfig{
+		LSP:        map[string]string{"property": "property"},
+		TreeSitter: map[string]string{"interface_declaration": "protocol"},
+		Rules: []config.KindRule{
+			{Kind: "component", Languages: []string{"typescriptreact"}, From: []string{"function"}, Name: "^[A-Z]"},
+			{Kind: "hook", From: []string{"function"}, Name: "^use[A-Z]"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := kinds.LSPKind(lsp.SymbolKindProperty); got != "property" {
+		t.Errorf("LSP Property = %q, want property", got)
+	}
+	if got := kinds.LSPKind(lsp.SymbolKindField); got != "field" {
+		t.Errorf("LSP Field = %q, want the built-in field", got)
+	}
+
+	root := t.TempDir()
+	file := FileInfo{Path: filepath.Join(root, "card.tsx"), RelPath: "card.tsx", Language: "typescriptreact"}
+	src := []byte("interface Props { name: string }\n\nfunction UserCard(props: Props) { return <div/> }\n\nfunction useUser() {}\n\nfunction format(n: string) {}\n")
+	parser := NewTreeSitterIndexer(nil, root)
+	parser.SetKinds(kinds)
+	symbols, err := parser.ParseContent(context.Background(), file, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]string)
+	for _, sym := range symbols {
+		got[sym.Name] = sym.Kind
+	}
+	want := map[string]string{"Props": "protocol", "name": "field", "UserCard": "component", "useUser": "hook", "format": "function"}
+	for name, kind := range want {
+		if got[name] != kind {
+			t.Errorf("%s kind = %q, want %q (all: %v)", name, got[name], kind, got)
+		}
+	}
+
+	if _, err := NewKindMap(config.KindsConfig{LSP: map[string]string{"Widget": "x"}}); err == nil {
+		t.Error("expected an error for an unknown LSP symbol kind")
+	}
+}
+
+func TestIndexProjectUpdatesCallGraphOfChangedFiles(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, src string) FileInfo {
+		path := filepath.Join(root, name)
+		if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return FileInfo{Path: path, RelPath: name, Language: "python"}
+	}
+	files := []FileInfo{
+		write("a.py", "from b import helper\n\ndef run():\n    return helper()\n"),
+		write("b.py", "def helper():\n    return 1\n"),
+		write("c.py", "def other():\n    return 2\n\ndef main():\n    return other()\n"),
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.LSP["python"] = config.LSPConfig{Command: "missing-python-lsp"}
+	database, err := db.NewManager(filepath.Join(root, "graph.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	if err := database.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	edges := func() string {
+		calls, err := database.ListCalls()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, c := range calls {
+			got = append(got, c.CallerID+">"+c.CalleeID)
+		}
+		return strings.Join(got, ",")
+	}
+
+	if err := NewIndexer(cfg, database, root).IndexProject(context.Background(), files, false); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := edges(), "a.py#run>b.py#helper,c.py#main>c.py#other"; got != want {
+		t.Fatalf("edges = %s, want %s", got, want)
+	}
+
+	// Only b.py changes; its callers are re-extracted, c.py is left alone
+	write("b.py", "def helper():\n    return twice()\n\ndef twice():\n    return 2\n")
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(files[1].Path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewIndexer(cfg, database, root).IndexProject(context.Background(), files, false); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := edges(), "a.py#run>b.py#helper,b.py#helper>b.py#twice,c.py#main>c.py#other"; got != want {
+		t.Fatalf("edges after change = %s, want %s", got, want)
+	}
+}
+
+func TestIndexProjectRecordsExternalCallsUntilResolved(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, src string) FileInfo {
+		path := filepath.Join(root, name)
+		if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return FileInfo{Path: path, RelPath: name, Language: "python"}
+	}
+	files := []FileInfo{
+		write("a.py", "def run():\n    print(1)\n    return helper()\n"),
+		write("b.py", "def other():\n    return 1\n"),
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.LSP["python"] = config.LSPConfig{Command: "missing-python-lsp"}
+	database, err := db.NewManager(filepath.Join(root, "graph.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	if err := database.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	external := func() string {
+		calls, err := database.GetExternalCallsByID("a.py#run", db.QueryOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, c := range calls {
+			got = append(got, fmt.Sprintf("%s@%d", c.CalleeName, c.Line))
+		}
+		return strings.Join(got, ",")
+	}
+
+	if err := NewIndexer(cfg, database, root).IndexProject(context.Background(), files, false); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := external(), "print@2,helper@3"; got != want {
+		t.Fatalf("external calls = %s, want %s", got, want)
+	}
+
+	// b.py now declares helper, so a.py is re-extracted and the call links
+	write("b.py", "def other():\n    return 1\n\ndef helper():\n    return 2\n")
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(files[1].Path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewIndexer(cfg, database, root).IndexProject(context.Background(), files, false); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := external(), "print@2"; got != want {
+		t.Errorf("external calls after change = %s, want %s", got, want)
+	}
+	callees, err := database.GetCalleesByID("a.py#run", db.QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(callees) != 1 || callees[0].ID != "b.py#helper" {
+		t.Errorf("callees after change = %+v, want b.py#helper", callees)
+	}
+}
+
+func TestFindChanges(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, src string) FileInfo {
+		path := filepath.Join(root, name)
+		if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return FileInfo{Path: path, RelPath: name, Language: "python"}
+	}
+	a := write("a.py", "def a():\n    return 1\n")
+	b := write("b.py", "def b():\n    return a()\n")
+
+	cfg := config.DefaultConfig()
+	cfg.LSP["python"] = config.LSPConfig{Command: "missing-python-lsp"}
+	database, err := db.NewManager(filepath.Join(root, "graph.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	if err := database.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	changes, err := FindChanges(database, []FileInfo{a, b})
+	if err != nil || len(changes.Modified) != 2 || len(changes.Removed) != 0 {
+		t.Fatalf("before the build: changes = %+v, %v, want both files modified", changes, err)
+	}
+	if err := NewIndexer(cfg, database, root).IndexProject(context.Background(), []FileInfo{a, b}, false); err != nil {
+		t.Fatal(err)
+	}
+	if changes, err := FindChanges(database, []FileInfo{a, b}); err != nil || !changes.Empty() {
+		t.Fatalf("after the build: changes = %+v, %v, want none", changes, err)
+	}
+
+	// a.py is edited, b.py deleted, c.py added
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(a.Path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	c := write("c.py", "def c():\n    pass\n")
+	changes, err = FindChanges(database, []FileInfo{a, c})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{a.Path, c.Path, b.Path}; !slices.Equal(changes.Paths(), want) {
+		t.Errorf("changed paths = %q, want %q", changes.Paths(), want)
+	}
+}
+
+func TestIndexProjectRecordsRenames(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, src string) FileInfo {
+		path := filepath.Join(root, name)
+		if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		later := time.Now().Add(time.Minute)
+		if err := os.Chtimes(path, later, later); err != nil {
+			t.Fatal(err)
+		}
+		return FileInfo{Path: path, RelPath: name, Language: "python"}
+	}
+	files := []FileInfo{write("a.py", "def helper(x):\n    return helper(x - 1) * 2\n\ndef old(n):\n    return n\n\ndef run():\n    return helper(1)\n")}
+
+	cfg := config.DefaultConfig()
+	cfg.LSP["python"] = config.LSPConfig{Command: "missing-python-lsp"}
+	database, err := db.NewManager(filepath.Join(root, "graph.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	if err := database.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewIndexer(cfg, database, root).IndexProject(context.Background(), files, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// helper is renamed, recursive call included; old is replaced by a
+	// function with another body
+	files = []FileInfo{write("a.py", "def double(x):\n    return double(x - 1) * 2\n\ndef new(n):\n    return n + 1\n\ndef run():\n    return double(1)\n")}
+	indexer := NewIndexer(cfg, database, root)
+	if err := indexer.IndexProject(context.Background(), files, false); err != nil {
+		t.Fatal(err)
+	}
+	if got := indexer.Report().Renames; got != 1 {
+		t.Errorf("report renames = %d, want 1", got)
+	}
+
+	renames, err := database.ListRenames()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(renames) != 1 || renames[0].OldID != "a.py#helper" || renames[0].NewID != "a.py#double" || renames[0].Kind != "function" {
+		t.Errorf("renames = %+v, want a.py#helper → a.py#double", renames)
+	}
+	symbols, err := database.GetFileSymbols(files[0].Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, s := range symbols {
+		ids = append(ids, s.ID)
+	}
+	if want := []string{"a.py#double", "a.py#new", "a.py#run"}; !slices.Equal(ids, want) {
+		t.Errorf("symbols = %q, want %q", ids, want)
+	}
+}
+
+func TestIndexProjectResolvesExternalCallsToDependencies(t *testing.T) {
+	root := t.TempDir()
+	cache := t.TempDir()
+	t.Setenv("GOMODCACHE", cache)
+	write := func(path, src string) {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(filepath.Join(cache, "example.com", "!acme", "greet@v1.2.0", "greet.go"),
+		"package greet\n\n// Hello greets name\nfunc Hello(name string) string { return name }\n")
+	write(filepath.Join(cache, "example.com", "!acme", "greet@v1.2.0", "greet_test.go"),
+		"package greet\n\nfunc TestHello() {}\n")
+	write(filepath.Join(root, "go.mod"), "module example.com/app\n\ngo 1.22\n\nrequire (\n\texample.com/Acme/greet v1.2.0\n)\n")
+	mainPath := filepath.Join(root, "main.go")
+	write(mainPath, "package main\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/Acme/greet\"\n)\n\nfunc main() {\n\tfmt.Println(greet.Hello(\"x\"))\n}\n")
+
+	cfg := config.DefaultConfig()
+	cfg.LSP["go"] = config.LSPConfig{Command: "missing-go-lsp"}
+	cfg.Dependencies.Enabled = true
+	database, err := db.NewManager(filepath.Join(root, "graph.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	if err := database.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	files := []FileInfo{{Path: mainPath, RelPath: "main.go", Language: "go"}}
+	if err := NewIndexer(cfg, database, root).IndexProject(context.Background(), files, false); err != nil {
+		t.Fatal(err)
+	}
+
+	modules, err := database.ListExternalModules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"example.com/Acme/greet@v1.2.0"}; !slices.Equal(modules, want) {
+		t.Fatalf("dependencies = %q, want %q", modules, want)
+	}
+	calls, err := database.GetExternalCallsByID("main.go#main", db.QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]db.ExternalCall{}
+	for _, c := range calls {
+		got[c.CalleeName] = c
+	}
+	if c := got["Hello"]; !c.Resolved || c.Package != "greet" || c.Module != "example.com/Acme/greet@v1.2.0" || c.Signature != "func Hello(name string) string { return name }" {
+		t.Errorf("greet.Hello = %+v, want it linked to the dependency", c)
+	}
+	if c, ok := got["Println"]; !ok || c.Resolved || c.Package != "fmt" {
+		t.Errorf("fmt.Println = %+v, want it external and unresolved", c)
+	}
+	// Dependency symbols stay out of the project's symbols
+	if symbols, err := database.GetSymbolByName("Hello", nil); err != nil || len(symbols) != 0 {
+		t.Errorf("project symbols named Hello = %+v, %v, want none", symbols, err)
+	}
+}
+
+func TestFindDependencies(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel, src string) {
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("package.json", `{"dependencies": {"left-pad": "^1.3.0", "missing": "1.0.0"}, "devDependencies": {"@types/node": "^20"}}`)
+	write("node_modules/left-pad/package.json", `{"version": "1.3.0"}`)
+	write("node_modules/left-pad/index.d.ts", "declare function leftPad(s: string): string;\n")
+	write("node_modules/@types/node/fs.d.ts", "declare function readFileSync(path: string): string;\n")
+	write(".venv/lib/python3.12/site-packages/requests-stubs/__init__.pyi", "def get(url: str) -> None: ...\n")
+	write(".venv/lib/python3.12/site-packages/flask/app.py", "def run(): pass\n")
+	write(".venv/lib/python3.12/site-packages/flask-3.0.dist-info/METADATA", "")
+
+	var got []string
+	for _, dep := range findDependencies(root) {
+		got = append(got, dep.Language+":"+dep.Module+":"+dep.Ext)
+	}
+	want := []string{"typescript:@types/node:.d.ts", "typescript:left-pad@1.3.0:.d.ts", "python:requests:.pyi"}
+	if !slices.Equal(got, want) {
+		t.Errorf("dependencies = %q, want %q", got, want)
+	}
+}
+
+func TestGoImportName(t *testing.T) {
+	for importPath, want := range map[string]string{
+		"fmt":                             "fmt",
+		"path/filepath":                   "filepath",
+		"github.com/pelletier/go-toml/v2": "toml",
+		"gopkg.in/yaml.v3":                "yaml",
+		"github.com/mattn/go-sqlite3":     "sqlite3",
+		"github.com/Sriram-PR/go-ignore":  "ignore",
+	} {
+		if got := goImportName(importPath); got != want {
+			t.Errorf("goImportName(%q) = %q, want %q", importPath, got, want)
+		}
+	}
+}
+
+func TestIndexProjectWritesBuildReport(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, src string) FileInfo {
+		path := filepath.Join(root, name)
+		if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return FileInfo{Path: path, RelPath: name, Language: "python"}
+	}
+	files := []FileInfo{
+		write("a.py", "def helper():\n    return 1\n\ndef run():\n    return helper()\n"),
+		write("b.py", "def other():\n    return 2\n"),
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.LSP["python"] = config.LSPConfig{Command: "missing-python-lsp"}
+	database, err := db.NewManager(filepath.Join(root, "graph.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	if err := database.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, run := range []struct {
+		status  string
+		indexed int
+	}{{StatusIndexed, 2}, {StatusSkipped, 0}} {
+		if err := NewIndexer(cfg, database, root).IndexProject(context.Background(), files, false); err != nil {
+			t.Fatal(err)
+		}
+		report, err := LoadReport(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.Indexed != run.indexed || len(report.Files) != 2 {
+			t.Fatalf("report indexed %d of %d files, want %d of 2", report.Indexed, len(report.Files), run.indexed)
+		}
+		for _, f := range report.Files {
+			if f.Status != run.status {
+				t.Errorf("%s status = %s, want %s", f.Path, f.Status, run.status)
+			}
+		}
+		if run.status != StatusIndexed {
+			continue
+		}
+		a := report.Files[0]
+		if a.Path != "a.py" || a.Source != "tree-sitter" || a.Symbols != 2 || a.Calls != 1 {
+			t.Errorf("a.py report = %+v, want tree-sitter, 2 symbols, 1 call", a)
+		}
+	}
+}
+
+func TestIndexEntrypoints(t *testing.T) {
+	root := t.TempDir()
+	database, err := db.NewManager(filepath.Join(root, "graph.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	if err := database.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	sources := map[string]string{
+		"cmd/main.go":           "package main\n\nvar rootCmd = &cobra.Command{\n\tUse:  \"serve\",\n\tRunE: runServe,\n}\n\nfunc runServe() error {\n\thttp.HandleFunc(\"/health\", health)\n\thttp.Handle(\"/api\", http.HandlerFunc(api.Serve))\n\treturn nil\n}\n\nfunc main() { rootCmd.Execute() }\n",
+		"cmd/health.go":         "package main\n\nfunc health() {}\n\nfunc Unused() {}\n",
+		"api/api.go":            "package api\n\nfunc Serve() {}\n\ntype Client struct{}\n\nfunc (c *Client) Do() {}\n\ntype store struct{}\n\nfunc (s *store) Get() {}\n\nfunc helper() {}\n",
+		"internal/util/util.go": "package util\n\nfunc Exported() {}\n",
+		"app.py":                "@app.route(\"/\")\ndef index():\n    pass\n\n@click.command()\ndef sync():\n    pass\n\ndef run():\n    pass\n\nif __name__ == \"__main__\":\n    run()\n",
+		"api/api_test.go":       "package api\n\nfunc TestServe() {}\n",
+	}
+	var files []FileInfo
+	for name, src := range sources {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		lang := map[string]string{".py": "python", ".go": "go"}[filepath.Ext(name)]
+		file := FileInfo{Path: path, RelPath: name, Language: lang}
+		if _, err := NewTreeSitterIndexer(database, root).IndexFile(context.Background(), file); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, file)
+	}
+
+	counts, err := NewEntrypointIndexer(database, root).IndexEntrypoints(context.Background(), files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entrypoints, err := database.ListEntrypoints(nil, db.QueryOptions{Sort: db.SortName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range entrypoints {
+		got = append(got, e.Name+"="+e.Type)
+	}
+	want := "Client=api,Do=api,Serve=http,health=http,index=http,main=main,run=main,runServe=cli,sync=cli"
+	if strings.Join(got, ",") != want {
+		t.Errorf("entry points = %s, want %s", strings.Join(got, ","), want)
+	}
+	if counts[EntrypointHTTP] != 3 || counts[EntrypointAPI] != 2 {
+		t.Errorf("counts = %v", counts)
+	}
+
+	// Serve is also exported API, but HTTP takes priority
+	mains, err := database.ListEntrypoints([]string{EntrypointMain}, db.QueryOptions{Languages: []string{"go"}})
+	if err != nil || len(mains) != 1 || mains[0].ID != "cmd/main.go#main" {
+		t.Errorf("go mains = %+v, %v", mains, err)
+	}
+}
+
+func TestIndexConcurrency(t *testing.T) {
+	root := t.TempDir()
+	database, err := db.NewManager(filepath.Join(root, "graph.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	if err := database.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	src := `package server
+
+type Server struct{ mu sync.RWMutex }
+
+func (s *Server) Handle(jobs chan int, done chan struct{}) {
+	go worker(jobs)
+	go func() { done <- struct{}{} }()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-done:
+	}
+	close(jobs)
+}
+
+func worker(jobs chan int) {
+	for {
+		j := <-jobs
+		_ = j
+	}
+}
+`
+	path := filepath.Join(root, "server.go")
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	file := FileInfo{Path: path, RelPath: "server.go", Language: "go"}
+	if _, err := NewTreeSitterIndexer(database, root).IndexFile(context.Background(), file); err != nil {
+		t.Fatal(err)
+	}
+
+	// Re-indexing replaces the file's sites instead of duplicating them
+	indexer := NewConcurrencyIndexer(database, root)
+	for range 2 {
+		if _, err := indexer.IndexConcurrency(context.Background(), []FileInfo{file}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sites, err := database.ListConcurrency(nil, nil, db.QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, s := range sites {
+		got = append(got, fmt.Sprintf("%s:%s %s", s.Function.Name, s.Op, s.Target))
+	}
+	want := "Handle:go worker,Handle:go func literal,Handle:send done,Handle:lock s.mu,Handle:unlock s.mu," +
+		"Handle:receive done,Handle:close jobs,worker:receive jobs"
+	if strings.Join(got, ",") != want {
+		t.Errorf("sites = %s, want %s", strings.Join(got, ","), want)
+	}
+	if sites[0].TargetID == nil || *sites[0].TargetID != "server.go#worker" {
+		t.Errorf("go worker target = %v, want server.go#worker", sites[0].TargetID)
+	}
+}
+
+func TestIndexRoutes(t *testing.T) {
+	root := t.TempDir()
+	database, err := db.NewManager(filepath.Join(root, "graph.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	if err := database.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	sources := map[string]string{
+		"server/main.go":           "package main\n\nfunc main() {\n\thttp.HandleFunc(\"GET /users/{id}\", getUser)\n\tr.HandleFunc(\"/items\", items).Methods(\"PUT\", \"PATCH\")\n\thttp.Handle(\"/health\", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))\n}\n",
+		"server/users.go":          "package main\n\nfunc getUser() {}\n\nfunc items() {}\n",
+		"gin/router.go":            "package api\n\nimport \"github.com/gin-gonic/gin\"\n\nfunc Routes(r *gin.Engine) {\n\tr.POST(\"/login\", auth.Middleware, login)\n}\n\nfunc login(c *gin.Context) {}\n",
+		"web/app.js":               "const express = require('express')\nconst app = express()\napp.get('/', home)\napp.all('/any', (req, res) => {})\naxios.get('/remote', cb)\nfunction home(req, res) {}\n",
+		"api/app.py":               "from fastapi import FastAPI\n\n@app.get(\"/items/{id}\")\ndef read_item(id):\n    pass\n\n@app.api_route(\"/x\", methods=[\"GET\", \"POST\"])\ndef both():\n    pass\n",
+		"java/UserController.java": "@RestController\n@RequestMapping(\"/api\")\npublic class UserController {\n    @GetMapping(\"/users\")\n    public List<User> list() { return null; }\n\n    @RequestMapping(value = \"/users\", method = RequestMethod.DELETE)\n    public void clear() {}\n}\n",
+	}
+	var files []FileInfo
+	for name, src := range sources {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		lang := map[string]string{".py": "python", ".go": "go", ".js": "javascript", ".java": "java"}[filepath.Ext(name)]
+		file := FileInfo{Path: path, RelPath: name, Language: lang}
+		if _, err := NewTreeSitterIndexer(database, root).IndexFile(context.Background(), file); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, file)
+	}
+
+	count, err := NewRouteIndexer(database, root).IndexRoutes(context.Background(), files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	routes, err := database.ListRoutes(nil, "", db.QueryOptions{Sort: db.SortName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range routes {
+		handler := r.Handler
+		if r.HandlerID != nil {
+			handler = *r.HandlerID
+		}
+		got = append(got, fmt.Sprintf("%s %s %s %s", r.Method, r.Path, handler, r.Framework))
+	}
+	want := []string{
+		"GET / web/app.js#home express",
+		"ANY /any anonymous express",
+		"GET /api/users java/UserController.java#UserController.list spring",
+		"DELETE /api/users java/UserController.java#UserController.clear spring",
+		"ANY /health anonymous net/http",
+		"PUT /items server/users.go#items net/http",
+		"PATCH /items server/users.go#items net/http",
+		"GET /items/{id} api/app.py#read_item fastapi",
+		"POST /login gin/router.go#login gin",
+		"GET /users/{id} server/users.go#getUser net/http",
+		"GET /x api/app.py#both fastapi",
+		"POST /x api/app.py#both fastapi",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") || count != len(want) {
+		t.Errorf("routes (%d) =\n%s\nwant\n%s", count, strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestIndexRPCs(t *testing.T) {
+	root := t.TempDir()
+	database, err := db.NewManager(filepath.Join(root, "graph.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	if err := database.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	sources := map[string]string{
+		"proto/users.proto":    "syntax = \"proto3\";\n\nservice UserService {\n  rpc GetUser(GetUserRequest) returns (User);\n}\n\nmessage GetUserRequest { string id = 1; }\nmessage User {\n  string id = 1;\n}\n",
+		"server/server.go":     "package server\n\ntype userServer struct {\n\tpb.UnimplementedUserServiceServer\n}\n\nfunc (s *userServer) GetUser(ctx context.Context, in *pb.GetUserRequest) (*pb.User, error) {\n\treturn nil, nil\n}",
+		"server/cache.go":      "package server\n\nfunc (c *cache) GetUser(id string) *User {\n\treturn nil\n}\n",
+		"gen/users_grpc.pb.go": "package users\n\nfunc (c *userServiceClient) GetUser(ctx context.Context, in *GetUserRequest) (*User, error) {\n\treturn nil, nil\n}\n",
+		"client/client.go":     "package client\n\nfunc Fetch(conn *grpc.ClientConn) {\n\tclient := pb.NewUserServiceClient(conn)\n\tclient.GetUser(ctx, &pb.GetUserRequest{})\n}\n",
+		"py/app.py":            "class Users(users_pb2_grpc.UserServiceServicer):\n    def GetUser(self, request, context):\n        return None\n\n\ndef lookup(channel):\n    stub = users_pb2_grpc.UserServiceStub(channel)\n    # stub.GetUser(old)\n    return stub.GetUser(request)\n",
+		"java/UserClient.java": "public class UserClient {\n    public User find(ManagedChannel channel) {\n        return UserServiceGrpc.newBlockingStub(channel).getUser(request);\n    }\n}\n",
+		"other/unrelated.go":   "package other\n\nfunc Load() {\n\trepo.GetUser(id)\n}\n",
+	}
+	var files []FileInfo
+	for name, src := range sources {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		lang := map[string]string{".proto": "proto", ".go": "go", ".py": "python", ".java": "java"}[filepath.Ext(name)]
+		file := FileInfo{Path: path, RelPath: name, Language: lang}
+		if _, err := NewTreeSitterIndexer(database, root).IndexFile(context.Background(), file); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, file)
+	}
+
+	rpc, err := database.GetSymbolByID("proto/users.proto#UserService.GetUser")
+	if err != nil || rpc == nil || rpc.Kind != "method" {
+		t.Fatalf("rpc symbol = %+v, %v", rpc, err)
+	}
+	if field, err := database.GetSymbolByID("proto/users.proto#User.id"); err != nil || field == nil || field.Kind != "field" {
+		t.Errorf("message field = %+v, %v", field, err)
+	}
+
+	servers, clients, err := NewRPCIndexer(database).IndexRPCs(context.Background(), files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	impls, err := database.GetImplementations(rpc.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, s := range impls {
+		got = append(got, s.ID)
+	}
+	want := []string{"py/app.py#Users.GetUser", "server/server.go#GetUser"}
+	if strings.Join(got, ",") != strings.Join(want, ",") || servers != len(want) {
+		t.Errorf("servers (%d) = %v, want %v", servers, got, want)
+	}
+
+	callers, err := database.GetCallers("UserService.GetUser", db.QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = nil
+	for _, c := range callers {
+		got = append(got, fmt.Sprintf("%s:%d", c.ID, c.CallLine))
+	}
+	slices.Sort(got)
+	want = []string{"client/client.go#Fetch:5", "java/UserClient.java#UserClient.find:3", "py/app.py#lookup:9"}
+	if strings.Join(got, ",") != strings.Join(want, ",") || clients != len(want) {
+		t.Errorf("clients (%d) = %v, want %v", clients, got, want)
+	}
+
+	// Relinking replaces the previous links
+	if _, _, err := NewRPCIndexer(database).IndexRPCs(context.Background(), files); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := database.GetCallers("UserService.GetUser", db.QueryOptions{}); len(again) != len(want) {
+		t.Errorf("relinked clients = %d, want %d", len(again), len(want))
+	}
+}
+
+func TestIndexComponents(t *testing.T) {
+	root := t.TempDir()
+	database, err := db.NewManager(filepath.Join(root, "graph.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	if err := database.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	sources := map[string]string{
+		"web/App.tsx": "import { Header } from './Header'\n\n" +
+			"export function App() {\n  const row = (x) => <Row key={x} />\n  return <main><Header /><UI.Panel>{[1].map(row)}</UI.Panel></main>\n}\n\n" +
+			"function Row() {\n  return <li />\n}\n\n" +
+			"function helper() {\n  return <Orphan />\n}\n",
+		"web/Header.tsx": "export const Header = memo(() => <header><Logo /></header>)\n\n" +
+			"class Logo extends React.Component {\n  render() {\n    return <><img /></>\n  }\n}\n",
+		"web/App.test.tsx": "it('renders', () => render(<App />))\n",
+		"vue/Counter.jsx":  "import { defineComponent } from 'vue'\n\nexport const Counter = defineComponent((props) => () => <Header />)\n",
+	}
+	var files []FileInfo
+	for name, src := range sources {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		file := FileInfo{Path: path, RelPath: name, Language: "typescriptreact"}
+		if _, err := NewTreeSitterIndexer(database, root).IndexFile(context.Background(), file); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, file)
+	}
+
+	components, renders, err := NewComponentIndexer(database).IndexComponents(context.Background(), files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := database.ListComponents(db.QueryOptions{Sort: db.SortName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, c := range list {
+		got = append(got, c.ID+" "+c.Framework)
+	}
+	want := []string{"web/App.tsx#App react", "vue/Counter.jsx#Counter vue", "web/Header.tsx#Header react", "web/Header.tsx#Logo react", "web/App.tsx#Row react"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") || components != len(want) {
+		t.Errorf("components (%d) =\n%s\nwant\n%s", components, strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	edges, err := database.ListRenders()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = nil
+	for _, r := range edges {
+		child := r.Child
+		if r.ChildID != nil {
+			child = *r.ChildID
+		}
+		got = append(got, fmt.Sprintf("%s -> %s:%d", r.ParentID, child, r.Line))
+	}
+	want = []string{
+		"web/App.tsx#App -> web/App.tsx#Row:4",
+		"web/App.tsx#App -> web/Header.tsx#Header:5",
+		"web/App.tsx#App -> UI.Panel:5",
+		"vue/Counter.jsx#Counter -> web/Header.tsx#Header:3",
+		"web/Header.tsx#Header -> web/Header.tsx#Logo:1",
+	}
+	slices.Sort(got)
+	slices.Sort(want)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") || renders != len(want) {
+		t.Errorf("renders (%d) =\n%s\nwant\n%s", renders, strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestIndexTags(t *testing.T) {
+	root := t.TempDir()
+	database, err := db.NewManager(filepath.Join(root, "graph.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	if err := database.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	sources := map[string]string{
+		"app.py":       "@app.route(\"/users\", methods=[\"GET\"])\n@login_required\ndef users():\n    pass\n\ndef legacy(\n    x,\n):\n    \"\"\"Deprecated: use users.\"\"\"\n",
+		"Service.java": "@Service\npublic class Service {\n    @Override @Deprecated\n    public void run() {}\n\n    @Transactional(readOnly = true)\n    public void save() {}\n}\n",
+		"lib.rs":       "/// Old API\n#[deprecated(note = \"use new\")]\n#[inline]\npub fn old() {}\n\n#[tokio::test]\nasync fn works() {}\n",
+		"Legacy.cs":    "public class Legacy\n{\n    [Obsolete(\"use New\"), EditorBrowsable]\n    public void Old() {}\n}\n",
+		"Widget.swift": "@available(*, deprecated, message: \"use View\")\nfunc widget() {}\n",
+		"component.ts": "@Component({ selector: 'app' })\nexport class AppComponent {}\n\n/**\n * Renders the old app.\n * @deprecated Use AppComponent.\n */\nexport function oldApp() {}\n",
+		"plain.go":     "package main\n\n// Old runs.\n//\n// Deprecated: use main.\nfunc Old() {}\n\n// Deprecated: not a doc comment\n\nfunc main() {}\n",
+	}
+	languages := map[string]string{".py": "python", ".java": "java", ".rs": "rust", ".cs": "csharp", ".swift": "swift", ".ts": "typescript", ".go": "go"}
+	var files []FileInfo
+	for name, src := range sources {
+		path := filepath.Join(root, name)
+		if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		file := FileInfo{Path: path, RelPath: name, Language: languages[filepath.Ext(name)]}
+		if _, err := NewTreeSitterIndexer(database, root).IndexFile(context.Background(), file); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, file)
+	}
+
+	count, err := NewTagIndexer(database).IndexTags(context.Background(), files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	symbols, err := database.ListSymbols(db.QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]string, len(symbols))
+	for i, s := range symbols {
+		ids[i] = s.ID
+	}
+	tags, err := database.GetSymbolTags(ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for id, symbolTags := range tags {
+		for _, tag := range symbolTags {
+			got = append(got, fmt.Sprintf("%s %s=%s(%s)", id, tag.Name, tag.Tag, tag.Args))
+		}
+	}
+	slices.Sort(got)
+	want := []string{
+		`Legacy.cs#Legacy.Old EditorBrowsable=editorbrowsable()`,
+		`Legacy.cs#Legacy.Old Obsolete=deprecated("use New")`,
+		`Service.java#Service Service=service()`,
+		`Service.java#Service.run Deprecated=deprecated()`,
+		`Service.java#Service.run Override=override()`,
+		`Service.java#Service.save Transactional=transactional(readOnly = true)`,
+		`Widget.swift#widget available=deprecated(*, deprecated, message: "use View")`,
+		`app.py#legacy Deprecated=deprecated(use users.)`,
+		`app.py#users app.route=route("/users", methods=["GET"])`,
+		`app.py#users login_required=login_required()`,
+		`component.ts#AppComponent Component=component({ selector: 'app' })`,
+		`component.ts#oldApp deprecated=deprecated(Use AppComponent.)`,
+		`lib.rs#old deprecated=deprecated(note = "use new")`,
+		`lib.rs#old inline=inline()`,
+		`lib.rs#works tokio::test=test()`,
+		`plain.go#Old Deprecated=deprecated(use main.)`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") || count != len(want) {
+		t.Errorf("tags (%d) =\n%s\nwant\n%s", count, strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	deprecated, err := database.ListSymbols(db.QueryOptions{Tags: []string{"Deprecated"}, Sort: db.SortName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, s := range deprecated {
+		names = append(names, s.Name)
+	}
+	if want := "Old,Old,legacy,old,oldApp,run,widget"; strings.Join(names, ",") != want {
+		t.Errorf("--tag deprecated = %v, want %s", names, want)
+	}
+	if routes, _ := database.ListSymbols(db.QueryOptions{Tags: []string{"app.route"}}); len(routes) != 1 {
+		t.Errorf("--tag app.route = %d symbols, want 1", len(routes))
+	}
+}
+
+func TestShardMerge(t *testing.T) {
+	for _, bad := range []string{"", "2", "0/2", "3/2", "a/b", "1/0"} {
+		if _, err := ParseShard(bad); err == nil {
+			t.Errorf("ParseShard(%q) succeeded", bad)
+		}
+	}
+
+	// Each shard is built in its own checkout, as on separate CI machines
+	sources := map[string]string{
+		"a/a.go": "package a\n\nfunc A() {}\n",
+		"b/b.go": "package b\n\nfunc B() {}\n",
+		"c/c.py": "def c():\n    pass\n",
+		"d/d.py": "def d():\n    pass\n",
+	}
+	const count = 3
+	var shardPaths []string
+	for index := 1; index <= count; index++ {
+		shard, err := ParseShard(fmt.Sprintf("%d/%d", index, count))
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkout := t.TempDir()
+		var files []FileInfo
+		for name, src := range sources {
+			path := filepath.Join(checkout, name)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+				t.Fatal(err)
+			}
+			language := "go"
+			if filepath.Ext(name) == ".py" {
+				language = "python"
+			}
+			files = append(files, FileInfo{Path: path, RelPath: name, Language: language})
+		}
+
+		dbPath := filepath.Join(checkout, "shard.db")
+		database, err := db.NewManager(dbPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := database.Initialize(); err != nil {
+			t.Fatal(err)
+		}
+		for _, file := range shard.Files(files) {
+			if _, err := NewTreeSitterIndexer(database, checkout).IndexFile(context.Background(), file); err != nil {
+				t.Fatal(err)
+			}
+		}
+		idx := &Indexer{db: database, rootPath: checkout, shard: &shard}
+		if err := idx.recordMeta(); err != nil {
+			t.Fatal(err)
+		}
+		database.Close()
+		shardPaths = append(shardPaths, dbPath)
+	}
+
+	root := t.TempDir()
+	merged, err := db.NewManager(filepath.Join(root, "graph.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer merged.Close()
+	if err := merged.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range shardPaths {
+		if err := merged.MergeIndex(path, root); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	symbols, err := merged.ListSymbols(db.QueryOptions{Sort: db.SortName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, s := range symbols {
+		got = append(got, s.ID)
+		if !strings.HasPrefix(s.File, root+string(filepath.Separator)) {
+			t.Errorf("%s file = %s, want it under %s", s.ID, s.File, root)
+		}
+	}
+	if want := "a/a.go#A,b/b.go#B,c/c.py#c,d/d.py#d"; strings.Join(got, ",") != want {
+		t.Errorf("merged symbols = %v, want %s", got, want)
+	}
+	if shard, _ := merged.GetMeta(db.MetaShard); shard != "" {
+		t.Errorf("merged index kept shard %q", shard)
+	}
+}
+
+func TestFunctionIndexFindsContainingFunction(t *testing.T) {
+	end := func(line int) *int { return &line }
+	file := filepath.Join(t.TempDir(), "a.py")
+	idx := newFunctionIndex([]db.Symbol{
+		{ID: "helper", File: file, Line: 20, EndLine: end(25)},
+		{ID: "outer", File: file, Line: 1, EndLine: end(10)},
+		{ID: "inner", File: file, Line: 3, EndLine: end(5)},
+		{ID: "open", File: file, Line: 30}, // No end line: runs to the end of the file
+		{ID: "other", File: filepath.Join(filepath.Dir(file), "b.py"), Line: 1, EndLine: end(100)},
+	})
+
+	for line, want := range map[int]string{
+		1: "outer", 4: "outer", 10: "outer", 11: "", 19: "",
+		20: "helper", 25: "helper", 26: "", 30: "open", 500: "open",
+	} {
+		if got := idx.containing(file, line); got != want {
+			t.Errorf("containing(a.py, %d) = %q, want %q", line, got, want)
+		}
+	}
+	if got := idx.containing(filepath.Join(filepath.Dir(file), "c.py"), 1); got != "" {
+		t.Errorf("containing(c.py, 1) = %q, want none", got)
+	}
+}
+
+// cancelAfterFirstFile interrupts a build once its first file is handled
+type cancelAfterFirstFile struct {
+	cancel context.CancelFunc
+}
+
+func (p cancelAfterFirstFile) LanguageStarted(language string, total int) {}
+func (p cancelAfterFirstFile) FileDone(language string, done, total, symbols int) {
+	p.cancel()
+}
+func (p cancelAfterFirstFile) LanguageFinished(language string) {}
+
+func TestIndexProjectResumesInterruptedBuild(t *testing.T) {
+	root := t.TempDir()
+	var files []FileInfo
+	for name, src := range map[string]string{
+		"a.py": "from b import helper\n\ndef run():\n    return helper()\n",
+		"b.py": "def helper():\n    return 1\n",
+	} {
+		path := filepath.Join(root, name)
+		if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, FileInfo{Path: path, RelPath: name, Language: "python"})
+	}
+	slices.SortFunc(files, func(a, b FileInfo) int { return strings.Compare(a.RelPath, b.RelPath) })
+
+	cfg := config.DefaultConfig()
+	cfg.LSP["python"] = config.LSPConfig{Command: "missing-python-lsp"}
+	database, err := db.NewManager(filepath.Join(root, "graph.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	if err := database.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	idx := NewIndexer(cfg, database, root)
+	idx.SetProgress(cancelAfterFirstFile{cancel})
+	err = idx.IndexProject(ctx, files, false)
+	var interrupted *InterruptedError
+	if !errors.As(err, &interrupted) || !errors.Is(err, context.Canceled) {
+		t.Fatalf("IndexProject = %v, want an interruption", err)
+	}
+	if interrupted.Indexed != 1 || interrupted.Pending != 1 {
+		t.Fatalf("interrupted = %+v, want 1 indexed and 1 pending", interrupted)
+	}
+	if started, _ := database.GetMeta(db.MetaInterrupted); started == "" {
+		t.Fatal("interrupted build is not marked")
+	}
+
+	// The resumed build skips a.py but still links its call into b.py
+	if err := NewIndexer(cfg, database, root).IndexProject(context.Background(), files, false); err != nil {
+		t.Fatal(err)
+	}
+	callers, err := database.GetCallersByID("b.py#helper", db.QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(callers) != 1 || callers[0].ID != "a.py#run" {
+		t.Fatalf("callers of helper = %+v, want a.py#run", callers)
+	}
+	if started, _ := database.GetMeta(db.MetaInterrupted); started != "" {
+		t.Fatalf("completed build is still marked interrupted at %s", started)
+	}
+}
+
+func TestIndexProjectFastSkipsLanguageServers(t *testing.T) {
+	root := t.TempDir()
+	var files []FileInfo
+	for name, src := range map[string]string{
+		"a.py": "from b import Helper\n\ndef run():\n    return Helper()\n",
+		"b.py": "class Base:\n    pass\n\nclass Helper(Base):\n    pass\n",
+	} {
+		path := filepath.Join(root, name)
+		if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, FileInfo{Path: path, RelPath: name, Language: "python"})
+	}
+
+	// A server that leaves a marker when started
+	marker := filepath.Join(root, "started")
+	cfg := config.DefaultConfig()
+	cfg.LSP["python"] = config.LSPConfig{Command: "sh", Args: []string{"-c", "touch " + marker}}
+	database, err := db.NewManager(filepath.Join(root, "graph.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	if err := database.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	idx := NewIndexer(cfg, database, root)
+	idx.SetFast(true)
+	if err := idx.IndexProject(context.Background(), files, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Fatal("fast build started the language server")
+	}
+	if report := idx.Report(); !report.Fast || report.Indexed != 2 {
+		t.Fatalf("report = %+v, want a fast build of 2 files", report)
+	}
+	callers, err := database.GetCallersByID("b.py#Helper", db.QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(callers) != 1 || callers[0].ID != "a.py#run" {
+		t.Fatalf("callers of Helper = %+v, want a.py#run", callers)
+	}
+	subtypes, err := database.GetImplementationsByName("Base", db.QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subtypes) != 1 || subtypes[0].Name != "Helper" {
+		t.Fatalf("subtypes of Base = %+v, want Helper", subtypes)
+	}
+}
+
+func TestIndexProjectLSPStrategyDoesNotFallBack(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "a.py")
+	if err := os.WriteFile(path, []byte("def run():\n    return 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	files := []FileInfo{{Path: path, RelPath: "a.py", Language: "python"}}
+
+	cfg := config.DefaultConfig()
+	cfg.LSP["python"] = config.LSPConfig{Command: "missing-python-lsp"}
+	cfg.Index.Languages = map[string]config.LanguageFilter{"python": {Strategy: config.StrategyLSP}}
+	database, err := db.NewManager(filepath.Join(root, "graph.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	if err := database.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	idx := NewIndexer(cfg, database, root)
+	if err := idx.IndexProject(context.Background(), files, false); err != nil {
+		t.Fatal(err)
+	}
+	report := idx.Report()
+	if report.Failed != 1 || report.Indexed != 0 || report.Strategies["python"] != config.StrategyLSP {
+		t.Fatalf("report = %+v, want a.py failed with strategy lsp", report)
+	}
+	if symbols, _ := database.GetFileSymbols(path); len(symbols) != 0 {
+		t.Fatalf("symbols = %+v, want none without the server", symbols)
+	}
+
+	// The command-line strategy overrides config.toml
+	idx = NewIndexer(cfg, database, root)
+	idx.SetStrategy(config.StrategyHybrid)
+	if err := idx.IndexProject(context.Background(), files, false); err != nil {
+		t.Fatal(err)
+	}
+	if report := idx.Report(); report.Indexed != 1 || report.Files[0].Source != sourceTreeSitter {
+		t.Fatalf("report = %+v, want a.py indexed by tree-sitter", report)
+	}
+}
+
+func TestIndexProjectReconcilesExtractorChange(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "a.py")
+	src := "class Service:\n    def fetch(self):\n        pass\n\ndef helper():\n    pass\n"
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	files := []FileInfo{{Path: path, RelPath: "a.py", Language: "python"}}
+
+	cfg := config.DefaultConfig()
+	cfg.LSP["python"] = config.LSPConfig{Command: "missing-python-lsp"}
+	database, err := db.NewManager(filepath.Join(root, "graph.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	if err := database.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A previous build indexed an older a.py with the language server
+	for _, s := range []*db.Symbol{
+		{ID: "a.py#Service", Name: "Service", Kind: "class", Line: 1},
+		{ID: "a.py#Service.fetch", Name: "fetch", Kind: "function", Scope: "Service", Line: 2},
+		{ID: "a.py#legacy", Name: "legacy", Kind: "function", Line: 8},
+	} {
+		s.File, s.Language, s.Source = path, "python", sourceLSP
+		if err := database.InsertSymbol(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := database.UpdateFileMeta(path, time.Now().Add(-time.Hour), "python", sourceLSP); err != nil {
+		t.Fatal(err)
+	}
+
+	idx := NewIndexer(cfg, database, root)
+	if err := idx.IndexProject(context.Background(), files, false); err != nil {
+		t.Fatal(err)
+	}
+	symbols, err := database.GetFileSymbols(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range symbols {
+		if s.Source != sourceTreeSitter {
+			t.Errorf("%s from %s survived the reconciliation", s.ID, s.Source)
+		}
+	}
+	conflicts, err := database.ListConflicts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, c := range conflicts {
+		got = append(got, fmt.Sprintf("%s:%s:%s:%s:%s", c.Name, c.Field, c.LSP, c.TreeSitter, c.Kept))
+	}
+	want := []string{
+		"fetch:kind:function:method:tree-sitter",
+		"legacy:missing:function::tree-sitter",
+		"helper:missing::function:tree-sitter",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("conflicts = %q, want %q", got, want)
+	}
+	if report := idx.Report(); report.Conflicts != 3 {
+		t.Errorf("report conflicts = %d, want 3", report.Conflicts)
+	}
+}
+
+func TestBenchTimesEveryPhase(t *testing.T) {
+	root := t.TempDir()
+	for name, src := range map[string]string{
+		"a.py":      "from b import helper\n\ndef run():\n    return helper()\n",
+		"b.py":      "def helper():\n    return 1\n",
+		".cgignore": "",
+	} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	phases, err := Bench(context.Background(), config.DefaultConfig(), root, filepath.Join(root, ".cgignore"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, p := range phases {
+		got = append(got, fmt.Sprintf("%s:%d:%d", p.Name, p.Files, p.Items))
+	}
+	if want := "scan:2:2,parse:2:2,insert:2:2,calls:2:1"; strings.Join(got, ",") != want {
+		t.Fatalf("phases = %s, want %s", strings.Join(got, ","), want)
+	}
+	if _, err := os.Stat(filepath.Join(root, ".codegraph")); !os.IsNotExist(err) {
+		t.Errorf("bench wrote into the project: %v", err)
+	}
+}
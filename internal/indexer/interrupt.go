@@ -0,0 +1,44 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// InterruptedError is returned by IndexProject and LinkProject when their
+// context is canceled (Ctrl-C). The files indexed before are kept and the
+// file being indexed is rolled back, so the next build indexes the
+// remaining files and links every file again.
+type InterruptedError struct {
+	Phase   string // What the build was doing, e.g. "indexing files"
+	Indexed int    // Files whose symbols were stored before the interrupt
+	Pending int    // Files left to index
+	Err     error  // The context's error
+}
+
+func (e *InterruptedError) Error() string {
+	return fmt.Sprintf("build interrupted while %s: %v", e.Phase, e.Err)
+}
+
+func (e *InterruptedError) Unwrap() error {
+	return e.Err
+}
+
+// canceled reports whether the build was interrupted. A context deadline
+// does not interrupt it: the requests to language servers fail and files
+// fall back to tree-sitter.
+func canceled(ctx context.Context) bool {
+	return errors.Is(ctx.Err(), context.Canceled)
+}
+
+// sleepContext waits for d, returning early when ctx is canceled
+func sleepContext(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
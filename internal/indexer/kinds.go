@@ -0,0 +1,100 @@
+package indexer
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+
+	"github.com/tk-425/Codegraph/internal/config"
+	"github.com/tk-425/Codegraph/internal/db"
+	"github.com/tk-425/Codegraph/internal/lsp"
+)
+
+// KindMap relabels symbol kinds as config.toml's [kinds] section asks. A
+// nil KindMap keeps the built-in kinds.
+type KindMap struct {
+	lsp   map[lsp.SymbolKind]string
+	nodes map[string]string
+	rules []kindRule
+}
+
+// kindRule is a config.KindRule with its patterns compiled
+type kindRule struct {
+	config.KindRule
+	name, signature *regexp.Regexp
+}
+
+// NewKindMap compiles the kind mappings of cfg
+func NewKindMap(cfg config.KindsConfig) (*KindMap, error) {
+	k := &KindMap{
+		lsp:   make(map[lsp.SymbolKind]string, len(cfg.LSP)),
+		nodes: cfg.TreeSitter,
+	}
+	for name, kind := range cfg.LSP {
+		symbolKind, ok := lsp.ParseSymbolKind(name)
+		if !ok {
+			return nil, fmt.Errorf("invalid config: kinds.lsp: unknown LSP symbol kind %q", name)
+		}
+		k.lsp[symbolKind] = kind
+	}
+	for i, rule := range cfg.Rules {
+		compiled := kindRule{KindRule: rule}
+		var err error
+		if rule.Name != "" {
+			if compiled.name, err = regexp.Compile(rule.Name); err != nil {
+				return nil, fmt.Errorf("invalid config: kinds.rules[%d].name: %w", i, err)
+			}
+		}
+		if rule.Signature != "" {
+			if compiled.signature, err = regexp.Compile(rule.Signature); err != nil {
+				return nil, fmt.Errorf("invalid config: kinds.rules[%d].signature: %w", i, err)
+			}
+		}
+		k.rules = append(k.rules, compiled)
+	}
+	return k, nil
+}
+
+// LSPKind returns the kind of a symbol a language server reports as kind
+func (k *KindMap) LSPKind(kind lsp.SymbolKind) string {
+	if k != nil {
+		if mapped, ok := k.lsp[kind]; ok {
+			return mapped
+		}
+	}
+	return lsp.SymbolKindToString(kind)
+}
+
+// nodeKind returns the kind of a symbol declared by a tree-sitter node of
+// type nodeType, which the extractor labelled kind
+func (k *KindMap) nodeKind(nodeType, kind string) string {
+	if k != nil {
+		if mapped, ok := k.nodes[nodeType]; ok {
+			return mapped
+		}
+	}
+	return kind
+}
+
+// apply relabels sym with the first rule it matches
+func (k *KindMap) apply(sym *db.Symbol) {
+	if k == nil {
+		return
+	}
+	for _, rule := range k.rules {
+		if len(rule.Languages) > 0 && !slices.Contains(rule.Languages, sym.Language) {
+			continue
+		}
+		if len(rule.From) > 0 && !slices.Contains(rule.From, sym.Kind) {
+			continue
+		}
+		if rule.name != nil && !rule.name.MatchString(sym.Name) {
+			continue
+		}
+		if rule.signature != nil && !rule.signature.MatchString(sym.Signature) {
+			continue
+		}
+		sym.Kind = rule.Kind
+		return
+	}
+}
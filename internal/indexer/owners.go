@@ -0,0 +1,79 @@
+package indexer
+
+import (
+	"context"
+
+	"github.com/tk-425/Codegraph/internal/db"
+	"github.com/tk-425/Codegraph/internal/owners"
+)
+
+// OwnershipIndexer records who owns each symbol: the CODEOWNERS owners of
+// its file and, optionally, its main git blame author
+type OwnershipIndexer struct {
+	db       *db.Manager
+	rootPath string
+	blame    bool
+}
+
+// NewOwnershipIndexer creates an ownership indexer; blame enables git blame
+func NewOwnershipIndexer(dbManager *db.Manager, rootPath string, blame bool) *OwnershipIndexer {
+	return &OwnershipIndexer{
+		db:       dbManager,
+		rootPath: rootPath,
+		blame:    blame,
+	}
+}
+
+// IndexOwners applies CODEOWNERS to every file, since the rules may have
+// changed, and blames the changed files, whose symbols were just rewritten.
+// It returns the number of files with owners and of symbols with an author.
+func (o *OwnershipIndexer) IndexOwners(ctx context.Context, files, changed []FileInfo) (int, int, error) {
+	rules, err := owners.Load(o.rootPath)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	owned := 0
+	for _, file := range files {
+		fileOwners := rules.Owners(file.RelPath)
+		if len(fileOwners) > 0 {
+			owned++
+		}
+		if err := o.db.SetFileOwners(file.Path, fileOwners); err != nil {
+			return owned, 0, err
+		}
+	}
+	if !o.blame {
+		return owned, 0, nil
+	}
+
+	authored := 0
+	for _, file := range changed {
+		if err := ctx.Err(); err != nil {
+			return owned, authored, err
+		}
+		lines, err := owners.Blame(ctx, o.rootPath, file.Path)
+		if err != nil {
+			continue // Untracked file, or not a git repository
+		}
+		symbols, err := o.db.GetFileSymbols(file.Path)
+		if err != nil {
+			return owned, authored, err
+		}
+		for _, sym := range symbols {
+			end := sym.Line
+			if sym.EndLine != nil {
+				end = *sym.EndLine
+			}
+			author := owners.TopAuthor(lines, sym.Line, end)
+			if author == "" {
+				continue
+			}
+			if err := o.db.SetSymbolAuthor(sym.ID, author); err != nil {
+				return owned, authored, err
+			}
+			authored++
+		}
+	}
+	return owned, authored, nil
+}
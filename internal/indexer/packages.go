@@ -0,0 +1,46 @@
+package indexer
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Package clauses, found on the first line that declares one
+var (
+	goPackageClause   = regexp.MustCompile(`(?m)^package\s+(\w+)`)
+	javaPackageClause = regexp.MustCompile(`(?m)^\s*package\s+([\w.]+)\s*;`)
+)
+
+// filePackage returns the package a file's symbols are grouped under: the
+// package clause of Go and Java files, the dotted module path of Python
+// files (pkg.sub.mod, pkg.sub for pkg/sub/__init__.py) and the module path
+// of TypeScript and JavaScript files (src/db/manager, src/db for
+// src/db/index.ts). Other languages have none.
+func filePackage(file FileInfo, content []byte) string {
+	rel := path.Clean(strings.ReplaceAll(file.RelPath, "\\", "/"))
+	switch file.Language {
+	case "go":
+		if m := goPackageClause.FindSubmatch(content); m != nil {
+			return string(m[1])
+		}
+	case "java":
+		if m := javaPackageClause.FindSubmatch(content); m != nil {
+			return string(m[1])
+		}
+	case "python":
+		module := strings.TrimSuffix(rel, path.Ext(rel))
+		module = strings.TrimSuffix(strings.TrimSuffix(module, "__init__"), "/")
+		return strings.ReplaceAll(module, "/", ".")
+	case "typescript", "typescriptreact", "javascript":
+		module := strings.TrimSuffix(rel, path.Ext(rel))
+		if base := path.Base(module); base == "index" {
+			module = path.Dir(module)
+		}
+		if module == "." {
+			return ""
+		}
+		return module
+	}
+	return ""
+}
@@ -0,0 +1,16 @@
+package indexer
+
+// Progress receives the progress of IndexProject as each language's files
+// are indexed. Renderers (progress bars, JSON event streams) implement it;
+// a nil Progress reports nothing.
+type Progress interface {
+	// LanguageStarted is called before the files of a language are indexed
+	LanguageStarted(language string, total int)
+	// FileDone is called after each file, indexed or skipped as unchanged.
+	// done counts the language's files handled so far and symbols the
+	// symbols they produced.
+	FileDone(language string, done, total, symbols int)
+	// LanguageFinished is called once a language's files are all handled,
+	// before its summary is printed
+	LanguageFinished(language string)
+}
@@ -0,0 +1,198 @@
+package indexer
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	sitter "github.com/smacker/go-tree-sitter"
+	"github.com/tk-425/Codegraph/internal/config"
+)
+
+// Symbol extraction rules can be written as tree-sitter queries instead of
+// AST walks. A query file, queries/<grammar>.scm, marks each declaration
+// with these captures:
+//
+//	@definition.<kind>  the declaring node; its range is the symbol's range
+//	                    and the scope of the symbols nested in it
+//	@name               the node holding the symbol's name
+//	@signature          optional; its first line becomes the signature
+//
+// Built-in queries are embedded in the binary. A project overrides them, or
+// replaces the built-in AST walk of a grammar without one, with
+// .codegraph/queries/<grammar>.scm.
+//
+// Only C, C++, C#, Java and Protocol Buffers ship a built-in query. Go,
+// Python, TypeScript, JavaScript, Rust, Swift and OCaml keep their AST
+// walks in treesitter.go: they name symbols by more than a capture can
+// express (a function's binding in TS/JS, a Go embedded field's type, an
+// OCaml let pattern), or pick kinds and signatures from context (Python
+// decorators and Enum bases, Swift extensions). A project query still
+// replaces their walk.
+//
+//go:embed queries/*.scm
+var builtinQueries embed.FS
+
+// definitionCapture prefixes the capture naming a symbol's declaration
+const definitionCapture = "definition."
+
+// definition is a symbol found by a query
+type definition struct {
+	name, kind, signature string
+}
+
+// nodeKey identifies a node within one tree
+type nodeKey struct {
+	start, end uint32
+	typ        string
+}
+
+func keyOf(node *sitter.Node) nodeKey {
+	return nodeKey{node.StartByte(), node.EndByte(), node.Type()}
+}
+
+// queryCache holds the compiled extraction queries, so each is compiled
+// once per process rather than for every file parsed
+var queryCache sync.Map // map[queryKey]*compiledQuery
+
+// queryKey identifies a query file; an override's size and modification
+// time make watch pick up edits to it
+type queryKey struct {
+	path    string
+	builtin bool
+	size    int64
+	modTime time.Time
+}
+
+// compiledQuery is a query, or why it did not compile; a nil query with no
+// error means the grammar has none
+type compiledQuery struct {
+	query *sitter.Query
+	err   error
+}
+
+// symbolQuery returns the extraction query source for grammar, preferring
+// the project's override. ok is false when the grammar is extracted by its
+// AST walk.
+func (t *TreeSitterIndexer) symbolQuery(grammar string) (source []byte, path string, ok bool, err error) {
+	key, err := t.symbolQueryKey(grammar)
+	if err != nil {
+		return nil, key.path, false, err
+	}
+	return readQuery(key)
+}
+
+// symbolQueryKey returns the key of grammar's query: the project's
+// override when there is one, else the built-in query
+func (t *TreeSitterIndexer) symbolQueryKey(grammar string) (queryKey, error) {
+	if t.rootPath != "" {
+		path := filepath.Join(t.rootPath, config.DefaultConfigDir, "queries", grammar+".scm")
+		info, err := os.Stat(path)
+		if err == nil {
+			return queryKey{path: path, size: info.Size(), modTime: info.ModTime()}, nil
+		}
+		if !os.IsNotExist(err) {
+			return queryKey{path: path}, fmt.Errorf("failed to read query: %w", err)
+		}
+	}
+	return queryKey{path: "queries/" + grammar + ".scm", builtin: true}, nil
+}
+
+// readQuery reads the query file of key; ok is false for a grammar without
+// a built-in query
+func readQuery(key queryKey) (source []byte, path string, ok bool, err error) {
+	if key.builtin {
+		source, err = builtinQueries.ReadFile(key.path)
+		if err != nil {
+			return nil, "", false, nil
+		}
+		return source, key.path, true, nil
+	}
+	source, err = os.ReadFile(key.path)
+	if err != nil {
+		return nil, key.path, false, fmt.Errorf("failed to read query: %w", err)
+	}
+	return source, key.path, true, nil
+}
+
+// compiledSymbolQuery returns grammar's extraction query, compiling it on
+// first use; nil when the grammar is extracted by its AST walk
+func (t *TreeSitterIndexer) compiledSymbolQuery(grammar string, lang *sitter.Language) (*sitter.Query, error) {
+	key, err := t.symbolQueryKey(grammar)
+	if err != nil {
+		return nil, err
+	}
+	if cached, ok := queryCache.Load(key); ok {
+		c := cached.(*compiledQuery)
+		return c.query, c.err
+	}
+
+	source, path, ok, err := readQuery(key)
+	if err != nil {
+		return nil, err // Not cached: reading may work next time
+	}
+	c := &compiledQuery{}
+	if ok {
+		c.query, c.err = sitter.NewQuery(source, lang)
+		if c.err != nil {
+			c.err = fmt.Errorf("invalid query %s: %w", path, c.err)
+		}
+	}
+	if cached, loaded := queryCache.LoadOrStore(key, c); loaded {
+		if c.query != nil {
+			c.query.Close()
+		}
+		c = cached.(*compiledQuery)
+	}
+	return c.query, c.err
+}
+
+// queryDefinitions runs the extraction query for grammar over root and
+// returns the declarations it captures, keyed by declaring node. It returns
+// nil when grammar has no query.
+func (t *TreeSitterIndexer) queryDefinitions(grammar string, lang *sitter.Language, root *sitter.Node, content []byte) (map[nodeKey]definition, error) {
+	query, err := t.compiledSymbolQuery(grammar, lang)
+	if err != nil || query == nil {
+		return nil, err
+	}
+
+	cursor := sitter.NewQueryCursor()
+	defer cursor.Close()
+	cursor.Exec(query, root)
+
+	definitions := make(map[nodeKey]definition)
+	for {
+		match, ok := cursor.NextMatch()
+		if !ok {
+			break
+		}
+		match = cursor.FilterPredicates(match, content)
+
+		var declaring *sitter.Node
+		var def definition
+		for _, c := range match.Captures {
+			capture := query.CaptureNameForId(c.Index)
+			switch {
+			case strings.HasPrefix(capture, definitionCapture):
+				declaring = c.Node
+				def.kind = strings.TrimPrefix(capture, definitionCapture)
+			case capture == "name":
+				def.name = c.Node.Content(content)
+			case capture == "signature":
+				def.signature = getFirstLine(c.Node.Content(content))
+			}
+		}
+		if declaring == nil || def.name == "" {
+			continue
+		}
+		// The first pattern to match a node wins
+		if _, seen := definitions[keyOf(declaring)]; !seen {
+			definitions[keyOf(declaring)] = def
+		}
+	}
+	return definitions, nil
+}
@@ -0,0 +1,37 @@
+; C++ symbol extraction. Captures: @definition.<kind> on the declaring
+; node, @name on its name, @signature (first line) when there is one.
+
+; Out-of-line definitions keep their qualifier: Widget::draw
+(function_definition
+  declarator: (function_declarator declarator: (_) @name)) @definition.function @signature
+(function_definition
+  declarator: (pointer_declarator
+    declarator: (function_declarator declarator: (_) @name))) @definition.function @signature
+(function_definition
+  declarator: (reference_declarator
+    (function_declarator declarator: (_) @name))) @definition.function @signature
+
+(class_specifier name: (type_identifier) @name) @definition.class
+(struct_specifier name: (type_identifier) @name) @definition.struct
+(enum_specifier name: (type_identifier) @name) @definition.enum
+
+; Data members, including "*b", "&r" and "c[3]" declarators; one
+; declaration may name several. Member functions are not fields.
+(field_declaration
+  type: (_) @signature
+  declarator: (field_identifier) @name @definition.field)
+(field_declaration
+  type: (_) @signature
+  declarator: (pointer_declarator declarator: (field_identifier) @name @definition.field))
+(field_declaration
+  type: (_) @signature
+  declarator: (pointer_declarator
+    declarator: (pointer_declarator declarator: (field_identifier) @name @definition.field)))
+(field_declaration
+  type: (_) @signature
+  declarator: (reference_declarator (field_identifier) @name @definition.field))
+(field_declaration
+  type: (_) @signature
+  declarator: (array_declarator declarator: (field_identifier) @name @definition.field))
+
+(enumerator name: (identifier) @name value: (_)? @signature) @definition.enum_member
@@ -0,0 +1,26 @@
+; C# symbol extraction. Captures: @definition.<kind> on the declaring node,
+; @name on its name, @signature (first line) when there is one.
+
+(class_declaration name: (identifier) @name) @definition.class
+(record_declaration name: (identifier) @name) @definition.class
+(struct_declaration name: (identifier) @name) @definition.struct
+(interface_declaration name: (identifier) @name) @definition.interface
+(enum_declaration name: (identifier) @name) @definition.enum
+
+; Methods keep just the return type, as the LSP path does
+(method_declaration returns: (_) @signature name: (identifier) @name) @definition.method
+(constructor_declaration name: (identifier) @name) @definition.constructor
+(property_declaration type: (_) @signature name: (identifier) @name) @definition.property
+(delegate_declaration name: (identifier) @name) @definition.type @signature
+
+; Fields and events; one declaration may name several: int x, y
+(field_declaration
+  (variable_declaration
+    type: (_) @signature
+    (variable_declarator name: (identifier) @name) @definition.field))
+(event_field_declaration
+  (variable_declaration
+    type: (_) @signature
+    (variable_declarator name: (identifier) @name) @definition.field))
+
+(enum_member_declaration name: (identifier) @name value: (_)? @signature) @definition.enum_member
@@ -0,0 +1,18 @@
+; Java symbol extraction. Captures: @definition.<kind> on the declaring
+; node, @name on its name, @signature (first line) when there is one.
+
+(class_declaration name: (identifier) @name) @definition.class
+(interface_declaration name: (identifier) @name) @definition.interface
+(enum_declaration name: (identifier) @name) @definition.enum
+
+(method_declaration name: (identifier) @name) @definition.method @signature
+
+; Fields and interface constants; one declaration may name several
+(field_declaration
+  type: (_) @signature
+  declarator: (variable_declarator name: (identifier) @name) @definition.field)
+(constant_declaration
+  type: (_) @signature
+  declarator: (variable_declarator name: (identifier) @name) @definition.field)
+
+(enum_constant name: (identifier) @name) @definition.enum_member
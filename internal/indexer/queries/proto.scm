@@ -0,0 +1,17 @@
+; Protocol Buffers symbol extraction. Captures: @definition.<kind> on the
+; declaring node, @name on its name, @signature (first line) when there is
+; one. Services are interfaces and their RPCs methods, so an RPC's ID is
+; file.proto#Service.Method, which gRPC linking attaches servers and clients to.
+
+(service (service_name (identifier) @name)) @definition.interface
+(rpc (rpc_name (identifier) @name)) @definition.method @signature
+
+(message (message_name (identifier) @name)) @definition.struct
+(enum (enum_name (identifier) @name)) @definition.enum
+
+; Fields keep their type: "repeated Address addresses = 2" has Address
+(field (type) @signature (identifier) @name) @definition.field
+(oneof_field (type) @signature (identifier) @name) @definition.field
+(map_field (identifier) @name) @definition.field @signature
+
+(enum_field (identifier) @name) @definition.enum_member
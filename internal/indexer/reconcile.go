@@ -0,0 +1,129 @@
+package indexer
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+// fileSnapshot is what the index held for a file before it was re-indexed
+type fileSnapshot struct {
+	source  string // Extractor of the previous build
+	symbols []db.Symbol
+}
+
+// snapshot keeps the stored symbols of a file about to be re-indexed, so
+// reconcile can compare them with the new ones if the extractor changes
+// and trackRenames can tell which ones the file no longer declares
+func (i *Indexer) snapshot(file FileInfo) {
+	meta, err := i.db.GetFileMeta(file.Path)
+	if err != nil || meta == nil || meta.Source == "" {
+		return
+	}
+	symbols, err := i.db.GetFileSymbols(file.Path)
+	if err != nil || len(symbols) == 0 {
+		return
+	}
+	hashes, err := i.db.GetFileBodyHashes(file.Path)
+	if err != nil {
+		return
+	}
+	for idx := range symbols {
+		symbols[idx].BodyHash = hashes[symbols[idx].ID]
+	}
+	i.snapshots[file.Path] = fileSnapshot{source: meta.Source, symbols: symbols}
+}
+
+// reconcile compares the symbols of the files re-indexed by another
+// extractor than last time with their previous ones. The extractor that
+// indexed the file's current content wins: symbols left by the other are
+// deleted, and every disagreement is recorded in symbol_conflicts for
+// `codegraph verify --conflicts`. It returns the files reconciled and the
+// conflicts found.
+func (i *Indexer) reconcile(files []FileInfo) (int, int, error) {
+	reconciled, total := 0, 0
+	for _, file := range files {
+		before, ok := i.snapshots[file.Path]
+		if !ok {
+			continue
+		}
+		meta, err := i.db.GetFileMeta(file.Path)
+		if err != nil {
+			return reconciled, total, err
+		}
+		if meta == nil || meta.Source == before.source {
+			continue
+		}
+		current, err := i.db.GetFileSymbols(file.Path)
+		if err != nil {
+			return reconciled, total, err
+		}
+
+		var fresh []db.Symbol
+		var stale []string
+		for _, s := range current {
+			if s.Source == meta.Source {
+				fresh = append(fresh, s)
+			} else {
+				stale = append(stale, s.ID)
+			}
+		}
+		conflicts := compareSymbols(before.source, before.symbols, meta.Source, fresh)
+		if err := i.db.DeleteSymbols(stale); err != nil {
+			return reconciled, total, fmt.Errorf("failed to drop %s symbols of %s: %w", before.source, file.RelPath, err)
+		}
+		if err := i.db.ReplaceFileConflicts(file.Path, conflicts); err != nil {
+			return reconciled, total, err
+		}
+		reconciled++
+		total += len(conflicts)
+	}
+	return reconciled, total, nil
+}
+
+// compareSymbols pairs the symbols two extractors found in a file by scope
+// and name, in source order, and returns where they disagree. The symbols
+// of kept are the ones the index keeps.
+func compareSymbols(oldSource string, old []db.Symbol, kept string, fresh []db.Symbol) []db.SymbolConflict {
+	key := func(s db.Symbol) string { return s.Scope + "\x00" + s.Name }
+	unpaired := make(map[string][]db.Symbol)
+	for _, s := range fresh {
+		unpaired[key(s)] = append(unpaired[key(s)], s)
+	}
+
+	now := time.Now()
+	var conflicts []db.SymbolConflict
+	add := func(s db.Symbol, field, oldValue, freshValue string) {
+		c := db.SymbolConflict{File: s.File, SymbolID: s.ID, Name: s.Name, Field: field, Kept: kept, FoundAt: now}
+		if oldSource == sourceLSP {
+			c.LSP, c.TreeSitter = oldValue, freshValue
+		} else {
+			c.LSP, c.TreeSitter = freshValue, oldValue
+		}
+		conflicts = append(conflicts, c)
+	}
+	for _, o := range old {
+		matches := unpaired[key(o)]
+		if len(matches) == 0 {
+			add(o, db.ConflictMissing, o.Kind, "")
+			continue
+		}
+		f := matches[0]
+		unpaired[key(o)] = matches[1:]
+		if o.Kind != f.Kind {
+			add(f, db.ConflictKind, o.Kind, f.Kind)
+		}
+		if o.Line != f.Line {
+			add(f, db.ConflictLine, strconv.Itoa(o.Line), strconv.Itoa(f.Line))
+		}
+	}
+	for _, s := range fresh {
+		if matches := unpaired[key(s)]; len(matches) > 0 && matches[0].ID == s.ID {
+			unpaired[key(s)] = matches[1:]
+			add(s, db.ConflictMissing, "", s.Kind)
+		}
+	}
+	return conflicts
+}
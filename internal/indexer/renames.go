@@ -0,0 +1,132 @@
+package indexer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+// bodyHash hashes a symbol's definition with its name and whitespace left
+// out, so a symbol renamed without other edits keeps its hash
+func bodyHash(body, name string) string {
+	if body == "" || name == "" {
+		return ""
+	}
+	normalized := strings.Join(strings.Fields(strings.ReplaceAll(body, name, "")), " ")
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:8])
+}
+
+// sourceLines returns lines start to end (0-indexed, inclusive) of a file
+func sourceLines(lines []string, start, end int) string {
+	if start < 0 || start >= len(lines) || end < start {
+		return ""
+	}
+	return strings.Join(lines[start:min(end+1, len(lines))], "\n")
+}
+
+// trackRenames compares the symbols of the files re-indexed since started
+// with what they held before. Symbols a file no longer declares are
+// dropped; when one of them and a new symbol have the same kind and body
+// hash, and no other symbol on either side does, the pair is recorded as
+// a rename. It returns the renames recorded.
+func (i *Indexer) trackRenames(files []FileInfo, started time.Time) (int, error) {
+	var gone, added []db.Symbol
+	seen := make(map[string]bool)
+	for _, file := range files {
+		current, err := i.db.GetFileSymbols(file.Path)
+		if err != nil {
+			return 0, err
+		}
+		hashes, err := i.db.GetFileBodyHashes(file.Path)
+		if err != nil {
+			return 0, err
+		}
+		before := i.snapshots[file.Path].symbols
+		existed := make(map[string]bool, len(before))
+		for _, s := range before {
+			existed[s.ID] = true
+		}
+		stored := make(map[string]bool, len(current))
+		for _, s := range current {
+			s.BodyHash = hashes[s.ID]
+			switch {
+			case !s.CreatedAt.Before(started):
+				stored[s.ID] = true
+				if !existed[s.ID] {
+					added = append(added, s)
+				}
+			case !seen[s.ID]:
+				// Left over from the previous content of the file
+				seen[s.ID] = true
+				gone = append(gone, s)
+			}
+		}
+		// Dropped by reconcile already, when the extractor changed
+		for _, s := range before {
+			if !stored[s.ID] && !seen[s.ID] {
+				seen[s.ID] = true
+				gone = append(gone, s)
+			}
+		}
+	}
+	if len(gone) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]string, len(gone))
+	for idx, s := range gone {
+		ids[idx] = s.ID
+	}
+	if err := i.db.DeleteSymbols(ids); err != nil {
+		return 0, err
+	}
+
+	key := func(s db.Symbol) string {
+		if s.BodyHash == "" {
+			return ""
+		}
+		return s.Kind + "\x00" + s.BodyHash
+	}
+	oldByKey := groupSymbols(gone, key)
+	newByKey := groupSymbols(added, key)
+	now := time.Now()
+	count := 0
+	for k, olds := range oldByKey {
+		news := newByKey[k]
+		if k == "" || len(olds) != 1 || len(news) != 1 {
+			continue
+		}
+		o, n := olds[0], news[0]
+		if o.Name == n.Name && o.File == n.File {
+			continue // Only its line moved
+		}
+		err := i.db.InsertRename(&db.Rename{
+			OldID:     o.ID,
+			NewID:     n.ID,
+			OldName:   o.Name,
+			NewName:   n.Name,
+			Kind:      n.Kind,
+			OldFile:   o.File,
+			NewFile:   n.File,
+			RenamedAt: now,
+		})
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+func groupSymbols(symbols []db.Symbol, key func(db.Symbol) string) map[string][]db.Symbol {
+	groups := make(map[string][]db.Symbol)
+	for _, s := range symbols {
+		k := key(s)
+		groups[k] = append(groups[k], s)
+	}
+	return groups
+}
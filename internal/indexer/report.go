@@ -0,0 +1,87 @@
+package indexer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ReportFile is the build report's name inside .codegraph/
+const ReportFile = "last-build.json"
+
+// File statuses recorded in a BuildReport
+const (
+	StatusIndexed = "indexed"
+	StatusSkipped = "skipped"
+	StatusFailed  = "failed"
+)
+
+// BuildReport records the outcome of one IndexProject run
+type BuildReport struct {
+	StartedAt     time.Time         `json:"started_at"`
+	DurationMs    int64             `json:"duration_ms"`
+	Force         bool              `json:"force"`
+	Shard         string            `json:"shard,omitempty"`      // "i/N" when one shard was built
+	Fast          bool              `json:"fast,omitempty"`       // Tree-sitter only (build --fast)
+	Strategies    map[string]string `json:"strategies,omitempty"` // Extraction strategy of each language built
+	Indexed       int               `json:"indexed"`
+	Skipped       int               `json:"skipped"`
+	Failed        int               `json:"failed"`
+	Symbols       int               `json:"symbols"`
+	Calls         int               `json:"calls"`
+	TypeRelations int               `json:"type_relations"`
+	Conflicts     int               `json:"conflicts,omitempty"` // Extractor disagreements recorded for review
+	Renames       int               `json:"renames,omitempty"`   // Symbols recorded as renamed by this build
+	Files         []FileReport      `json:"files"`
+	// Warnings are build-wide problems not tied to one file, such as a
+	// language's call graph failing to update
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// FileReport records how one file was handled
+type FileReport struct {
+	Path       string `json:"path"`
+	Language   string `json:"language"`
+	Status     string `json:"status"`
+	Source     string `json:"source,omitempty"` // "lsp" or "tree-sitter" for indexed files
+	Symbols    int    `json:"symbols"`
+	Calls      int    `json:"calls"`
+	DurationMs int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// ReportPath returns where the build report of the project at root is kept
+func ReportPath(root string) string {
+	return filepath.Join(root, ".codegraph", ReportFile)
+}
+
+// LoadReport reads the build report of the project at root
+func LoadReport(root string) (*BuildReport, error) {
+	data, err := os.ReadFile(ReportPath(root))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no build report found. Run 'codegraph build' first")
+		}
+		return nil, err
+	}
+	var report BuildReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", ReportFile, err)
+	}
+	return &report, nil
+}
+
+// writeReport saves report as the project's last build report
+func writeReport(root string, report *BuildReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := ReportPath(root)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,334 @@
+package indexer
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+// anonymousHandler is the handler name of routes served by a function
+// literal or lambda
+const anonymousHandler = "anonymous"
+
+// Route registrations: the registering method and the quoted path, up to
+// the comma before the handler
+var (
+	goRouteRegistration = regexp.MustCompile(`\.(HandleFunc|Handle|Get|Post|Put|Patch|Delete|Head|Options|Connect|Trace|Any|GET|POST|PUT|PATCH|DELETE|HEAD|OPTIONS|CONNECT|TRACE)\(\s*"([^"]*)"\s*,`)
+	jsRouteRegistration = regexp.MustCompile("\\b(\\w+)\\.(get|post|put|patch|delete|all|head|options)\\(\\s*['\"`]([^'\"`]*)['\"`]\\s*,")
+	goMethodsCall       = regexp.MustCompile(`^\s*\.Methods\(([^)]*)\)`) // gorilla/mux
+	goPatternMethod     = regexp.MustCompile(`^([A-Z]+)\s+(\S+)$`)       // net/http since Go 1.22: "GET /users/{id}"
+	goQuoted            = regexp.MustCompile(`"(\w+)"`)
+)
+
+// Decorators and annotations that make the function below them a route
+var (
+	pyRouteDecorator = regexp.MustCompile(`^@[\w.]*\.(get|post|put|patch|delete|head|options|route|api_route)\(\s*[rf]?['"]([^'"]*)['"](.*)`)
+	pyMethodsArg     = regexp.MustCompile(`methods\s*=\s*[\[(]([^\])]*)`)
+	pyImportsFlask   = regexp.MustCompile(`(?m)^\s*(?:from|import)\s+flask\b`)
+	springMapping    = regexp.MustCompile(`^@(Get|Post|Put|Patch|Delete|Request)Mapping\b(?:\((.*)\))?`)
+	springMethod     = regexp.MustCompile(`RequestMethod\.(\w+)`)
+	javaQuoted       = regexp.MustCompile(`"([^"]*)"`)
+)
+
+// goRouters are the Go router packages, by import path prefix; files
+// importing none of them register routes with net/http
+var goRouters = []struct{ importPath, framework string }{
+	{`"github.com/gin-gonic/gin`, "gin"},
+	{`"github.com/labstack/echo`, "echo"},
+	{`"github.com/go-chi/chi`, "chi"},
+	{`"github.com/gorilla/mux`, "gorilla/mux"},
+}
+
+// httpClients are JavaScript receivers whose get/post calls send requests
+// rather than register routes
+var httpClients = map[string]bool{
+	"axios": true, "http": true, "https": true, "request": true, "superagent": true,
+	"client": true, "api": true, "fetch": true, "got": true, "ky": true,
+}
+
+// RouteIndexer extracts HTTP route registrations and links them to their
+// handler functions
+type RouteIndexer struct {
+	db       *db.Manager
+	rootPath string
+}
+
+// NewRouteIndexer creates a route indexer
+func NewRouteIndexer(dbManager *db.Manager, rootPath string) *RouteIndexer {
+	return &RouteIndexer{
+		db:       dbManager,
+		rootPath: rootPath,
+	}
+}
+
+// IndexRoutes re-extracts the routes of every file, since a route can name
+// a handler declared in another file, and returns how many were found
+func (r *RouteIndexer) IndexRoutes(ctx context.Context, files []FileInfo) (int, error) {
+	if err := r.db.ClearRoutes(); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return count, err
+		}
+		if IsTestFile(file.RelPath) {
+			continue
+		}
+		switch file.Language {
+		case "go", "typescript", "typescriptreact", "javascript", "python", "java":
+		default:
+			continue
+		}
+		content, err := os.ReadFile(file.Path)
+		if err != nil {
+			continue // Removed since it was scanned
+		}
+		symbols, err := r.db.GetFileSymbols(file.Path)
+		if err != nil {
+			return count, err
+		}
+
+		for _, route := range fileRoutes(file, string(content), symbols) {
+			if route.HandlerID == nil && route.Handler != anonymousHandler {
+				ids, err := resolveHandler(r.db, route.Handler, file)
+				if err != nil {
+					return count, err
+				}
+				if len(ids) > 0 {
+					route.HandlerID = &ids[0]
+				}
+			}
+			if err := r.db.InsertRoute(route); err != nil {
+				return count, err
+			}
+			count++
+		}
+	}
+	return count, nil
+}
+
+// fileRoutes returns the routes a file registers. Routes registered by a
+// call name their handler but leave it unresolved; decorated functions are
+// their own handler.
+func fileRoutes(file FileInfo, content string, symbols []db.Symbol) []*db.Route {
+	lines := strings.Split(content, "\n")
+	var routes []*db.Route
+	add := func(methods []string, path, handler string, handlerID *string, framework string, line, column int) {
+		for _, method := range methods {
+			routes = append(routes, &db.Route{
+				Method:    method,
+				Path:      path,
+				Handler:   handler,
+				HandlerID: handlerID,
+				Framework: framework,
+				Language:  file.Language,
+				File:      file.Path,
+				Line:      line,
+				Column:    column,
+			})
+		}
+	}
+	registered := func(expr string) string {
+		if name := handlerName(expr); name != "" {
+			return name
+		}
+		return anonymousHandler
+	}
+
+	switch file.Language {
+	case "go":
+		framework := goFramework(content)
+		for i, line := range lines {
+			for _, m := range goRouteRegistration.FindAllStringSubmatchIndex(line, -1) {
+				call, path := line[m[2]:m[3]], line[m[4]:m[5]]
+				args, rest := enclosedArguments(line[m[1]:])
+				methods := []string{strings.ToUpper(call)}
+				switch call {
+				case "Handle", "HandleFunc", "Any":
+					methods = []string{db.RouteAnyMethod}
+					if p := goPatternMethod.FindStringSubmatch(path); p != nil {
+						methods, path = []string{p[1]}, p[2]
+					} else if mm := goMethodsCall.FindStringSubmatch(rest); mm != nil {
+						methods = nil
+						for _, q := range goQuoted.FindAllStringSubmatch(mm[1], -1) {
+							methods = append(methods, strings.ToUpper(q[1]))
+						}
+					}
+				}
+				add(methods, path, registered(lastArgument(args)), nil, framework, i+1, indentation(line))
+			}
+		}
+	case "typescript", "typescriptreact", "javascript":
+		if !strings.Contains(content, "express") {
+			return nil
+		}
+		for i, line := range lines {
+			for _, m := range jsRouteRegistration.FindAllStringSubmatchIndex(line, -1) {
+				receiver, call, path := line[m[2]:m[3]], line[m[4]:m[5]], line[m[6]:m[7]]
+				if httpClients[receiver] {
+					continue
+				}
+				method := strings.ToUpper(call)
+				if call == "all" {
+					method = db.RouteAnyMethod
+				}
+				args, _ := enclosedArguments(line[m[1]:])
+				add([]string{method}, path, registered(lastArgument(args)), nil, "express", i+1, m[0])
+			}
+		}
+	case "python":
+		framework := "fastapi"
+		if pyImportsFlask.MatchString(content) {
+			framework = "flask"
+		}
+		for _, s := range symbols {
+			if s.Kind != "function" && s.Kind != "method" {
+				continue
+			}
+			for _, line := range annotationLines(lines, s.Line) {
+				text := lines[line-1]
+				m := pyRouteDecorator.FindStringSubmatch(strings.TrimSpace(text))
+				if m == nil {
+					continue
+				}
+				var methods []string
+				if mm := pyMethodsArg.FindStringSubmatch(m[3]); mm != nil {
+					for _, q := range pyQuoted.FindAllStringSubmatch(mm[1], -1) {
+						methods = append(methods, strings.ToUpper(q[1]))
+					}
+				}
+				if len(methods) == 0 {
+					switch m[1] {
+					case "route":
+						methods = []string{"GET"} // Flask's default
+					case "api_route":
+						methods = []string{db.RouteAnyMethod}
+					default:
+						methods = []string{strings.ToUpper(m[1])}
+					}
+				}
+				routeFramework := framework
+				if m[1] == "route" {
+					routeFramework = "flask"
+				}
+				id := s.ID
+				add(methods, m[2], s.Name, &id, routeFramework, line, indentation(text))
+			}
+		}
+	case "java":
+		for _, s := range symbols {
+			if s.Kind != "method" {
+				continue
+			}
+			for _, line := range annotationLines(lines, s.Line) {
+				text := lines[line-1]
+				m := springMapping.FindStringSubmatch(strings.TrimSpace(text))
+				if m == nil {
+					continue
+				}
+				methods := []string{strings.ToUpper(m[1])}
+				if m[1] == "Request" {
+					methods = nil
+					for _, rm := range springMethod.FindAllStringSubmatch(m[2], -1) {
+						methods = append(methods, rm[1])
+					}
+					if len(methods) == 0 {
+						methods = []string{db.RouteAnyMethod}
+					}
+				}
+				path := joinRoutePath(springClassPrefix(lines, symbols, s), springPath(m[2]))
+				id := s.ID
+				add(methods, path, s.Name, &id, "spring", line, indentation(text))
+			}
+		}
+	}
+	return routes
+}
+
+// goFramework returns the router a Go file imports
+func goFramework(content string) string {
+	for _, r := range goRouters {
+		if strings.Contains(content, r.importPath) {
+			return r.framework
+		}
+	}
+	return "net/http"
+}
+
+// enclosedArguments splits the text after a call's opening parenthesis (or
+// after one of its arguments) into the remaining arguments and what follows
+// the closing parenthesis. A call continuing on the next line has no rest.
+func enclosedArguments(text string) (string, string) {
+	depth := 0
+	for i, r := range text {
+		switch r {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			if depth == 0 {
+				return text[:i], text[i+1:]
+			}
+			depth--
+		}
+	}
+	return text, ""
+}
+
+// springPath returns the path of a Spring mapping's arguments: its value or
+// path, or the first string when given positionally
+func springPath(args string) string {
+	if m := javaQuoted.FindStringSubmatch(args); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// springClassPrefix returns the path of the @RequestMapping on the class
+// declaring method, which prefixes the paths of its methods
+func springClassPrefix(lines []string, symbols []db.Symbol, method db.Symbol) string {
+	for _, class := range symbols {
+		if class.Kind != "class" || class.EndLine == nil || class.Line >= method.Line || *class.EndLine < method.Line {
+			continue
+		}
+		for _, line := range annotationLines(lines, class.Line) {
+			if m := springMapping.FindStringSubmatch(strings.TrimSpace(lines[line-1])); m != nil && m[1] == "Request" {
+				return springPath(m[2])
+			}
+		}
+	}
+	return ""
+}
+
+// annotationLines returns the line numbers of the decorators of the
+// declaration at line: those above it and, when the symbol's range starts
+// at its annotations (as tree-sitter reports Java methods and C# attributes),
+// those from line on
+func annotationLines(lines []string, line int) []int {
+	found := decoratorLines(lines, line)
+	for i := line; i >= 1 && i <= len(lines) && isDecoratorLine(lines[i-1]); i++ {
+		found = append(found, i)
+	}
+	return found
+}
+
+// joinRoutePath joins a route prefix and path with a single slash
+func joinRoutePath(prefix, path string) string {
+	if prefix == "" {
+		return path
+	}
+	if path == "" {
+		return prefix
+	}
+	return strings.TrimSuffix(prefix, "/") + "/" + strings.TrimPrefix(path, "/")
+}
+
+// indentation returns the column (0-indexed) of a line's first non-blank
+// character
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
@@ -1,10 +1,12 @@
 package indexer
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/tk-425/Codegraph/internal/config"
 	"github.com/tk-425/Codegraph/internal/ignore"
 	"github.com/tk-425/Codegraph/internal/lsp/adapters"
 )
@@ -16,68 +18,289 @@ type FileInfo struct {
 	RelPath  string
 }
 
+// Reasons reported by Scanner.Skipped
+const (
+	SkipTooLarge  = "too large"
+	SkipGenerated = "generated"
+	SkipLanguage  = "language filter"
+	SkipSymlink   = "symlink"
+	SkipWorkspace = "outside workspaces"
+)
+
 // Scanner discovers source files in a project
 type Scanner struct {
 	rootPath string
 	ignore   *ignore.Matcher
+	cfg      config.IndexConfig
+	include  map[string]*ignore.PatternList // Per-language include globs
+	exclude  map[string]*ignore.PatternList // Per-language exclude globs
+	// workspaces, when set, limits the scan to files inside a workspace
+	// root that indexes the file's language
+	workspaces []config.WorkspaceConfig
+	skipped    map[string]int
+	warnings   []string
 }
 
 // NewScanner creates a new file scanner
 func NewScanner(rootPath string, ignorePath string) (*Scanner, error) {
+	return NewScannerWithConfig(rootPath, ignorePath, config.IndexConfig{})
+}
+
+// NewScannerWithConfig creates a file scanner applying the [index] options
+// from config.toml
+func NewScannerWithConfig(rootPath string, ignorePath string, cfg config.IndexConfig) (*Scanner, error) {
 	matcher, err := ignore.NewMatcher(ignorePath)
 	if err != nil {
 		return nil, err
 	}
+	if cfg.UseGitignore {
+		if err := matcher.EnableGitignore(rootPath); err != nil {
+			return nil, err
+		}
+	}
 
-	return &Scanner{
+	s := &Scanner{
 		rootPath: rootPath,
 		ignore:   matcher,
-	}, nil
+		cfg:      cfg,
+		include:  make(map[string]*ignore.PatternList),
+		exclude:  make(map[string]*ignore.PatternList),
+		skipped:  make(map[string]int),
+	}
+	for lang, filter := range cfg.Languages {
+		if len(filter.Include) > 0 {
+			if s.include[lang], err = ignore.NewPatternList("[index.languages."+lang+"] include", filter.Include); err != nil {
+				return nil, err
+			}
+		}
+		if len(filter.Exclude) > 0 {
+			if s.exclude[lang], err = ignore.NewPatternList("[index.languages."+lang+"] exclude", filter.Exclude); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return s, nil
 }
 
-// Scan discovers all source files in the project
+// SetWorkspaces limits the scan to the given workspace roots. Directories
+// outside every root are not walked.
+func (s *Scanner) SetWorkspaces(workspaces []config.WorkspaceConfig) {
+	s.workspaces = workspaces
+}
+
+// Skipped returns how many source files the last Scan left out, by reason
+// (SkipTooLarge, SkipGenerated, SkipLanguage, SkipSymlink, SkipWorkspace).
+// Ignore-file matches and files in directories outside every workspace are
+// not counted.
+func (s *Scanner) Skipped() map[string]int {
+	counts := make(map[string]int, len(s.skipped))
+	for reason, n := range s.skipped {
+		counts[reason] = n
+	}
+	return counts
+}
+
+// skipReason returns why an otherwise eligible source file is excluded by
+// the [index] options, or "" to keep it.
+func (s *Scanner) skipReason(path, relPath, language string, info os.FileInfo) string {
+	if len(s.workspaces) > 0 && !s.inWorkspace(relPath, language) {
+		return SkipWorkspace
+	}
+	if !s.cfg.IndexesLanguage(language) {
+		return SkipLanguage
+	}
+	if include := s.include[language]; include != nil && !include.Match(relPath) {
+		return SkipLanguage
+	}
+	if exclude := s.exclude[language]; exclude != nil && exclude.Match(relPath) {
+		return SkipLanguage
+	}
+	if s.cfg.MaxFileSize > 0 && info.Size() > s.cfg.MaxFileSize {
+		return SkipTooLarge
+	}
+	if s.cfg.ExcludeGenerated {
+		// Unreadable files are left for the indexer to report
+		if generated, err := isGeneratedFile(path, language); err == nil && generated {
+			return SkipGenerated
+		}
+	}
+	return ""
+}
+
+// inWorkspace reports whether some workspace contains relPath and indexes
+// language
+func (s *Scanner) inWorkspace(relPath, language string) bool {
+	for _, ws := range s.workspaces {
+		if ws.Contains(relPath) && ws.HasLanguage(language) {
+			return true
+		}
+	}
+	return false
+}
+
+// walksIntoWorkspace reports whether the directory relPath is inside a
+// workspace root or on the way to one
+func (s *Scanner) walksIntoWorkspace(relPath string) bool {
+	if len(s.workspaces) == 0 {
+		return true
+	}
+	for _, ws := range s.workspaces {
+		if ws.Contains(relPath) || strings.HasPrefix(ws.RelRoot(), relPath+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Scan discovers all source files in the project. Problems with individual
+// entries (broken or cyclic symlinks, unreadable directories) do not stop the
+// scan; they are reported by Warnings.
 func (s *Scanner) Scan() ([]FileInfo, error) {
-	var files []FileInfo
+	s.skipped = make(map[string]int)
+	s.warnings = nil
 
-	err := filepath.Walk(s.rootPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
+	w := &walk{visited: make(map[dirID]bool)}
+	if err := s.walkDir(w, s.rootPath, "."); err != nil {
+		return nil, err
+	}
+	// Symlinked directories come last, so files reachable both directly and
+	// through a link keep their real path
+	for len(w.links) > 0 {
+		link := w.links[0]
+		w.links = w.links[1:]
+		if err := s.walkSymlinkDir(w, link.path, link.relPath); err != nil {
+			return nil, err
+		}
+	}
+	return w.files, nil
+}
+
+// Warnings returns the problems the last Scan worked around
+func (s *Scanner) Warnings() []string {
+	return append([]string(nil), s.warnings...)
+}
+
+// walk is the state of one Scan
+type walk struct {
+	files []FileInfo
+	// visited holds every directory walked, by device and inode. A link to
+	// one of them would revisit files or loop forever.
+	visited map[dirID]bool
+	// links are the symlinked directories found, walked after the real tree
+	links []symlinkDir
+}
+
+// symlinkDir is a symlinked directory waiting to be walked
+type symlinkDir struct {
+	path, relPath string
+}
+
+// walkDir scans the directory at path, relPath being its project-relative
+// (slash-separated) name as reached by the walk.
+func (s *Scanner) walkDir(w *walk, path, relPath string) error {
+	if id, err := statDirID(path); err == nil {
+		w.visited[id] = true
+	}
+	// Pick up the directory's .gitignore before matching its entries
+	if err := s.ignore.LoadGitignore(relPath); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		if relPath == "." {
 			return err
 		}
+		s.warnings = append(s.warnings, fmt.Sprintf("%s: %v", relPath, err))
+		return nil
+	}
 
-		// Get relative path
-		relPath, _ := filepath.Rel(s.rootPath, path)
-		relPath = filepath.ToSlash(relPath)
+	for _, entry := range entries {
+		childPath := filepath.Join(path, entry.Name())
+		childRel := entry.Name()
+		if relPath != "." {
+			childRel = relPath + "/" + entry.Name()
+		}
+
+		info, err := os.Lstat(childPath)
+		if err != nil {
+			s.warnings = append(s.warnings, fmt.Sprintf("%s: %v", childRel, err))
+			continue
+		}
+
+		// Symlinked files are indexed like any other; symlinked directories
+		// only with follow_symlinks
+		symlink := info.Mode()&os.ModeSymlink != 0
+		if symlink {
+			if info, err = os.Stat(childPath); err != nil {
+				s.warnings = append(s.warnings, fmt.Sprintf("%s: broken symlink: %v", childRel, err))
+				continue
+			}
+			if info.IsDir() && !s.cfg.FollowSymlinks {
+				s.skipped[SkipSymlink]++
+				continue
+			}
+		}
 
 		// Skip ignored paths
-		if s.ignore.ShouldIgnore(relPath, info.IsDir()) {
-			if info.IsDir() && s.ignore.ShouldSkipDir(relPath) {
-				return filepath.SkipDir
+		if s.ignore.ShouldIgnore(childRel, info.IsDir()) {
+			if !info.IsDir() || s.ignore.ShouldSkipDir(childRel) {
+				continue
 			}
-			return nil
 		}
 
-		// Skip directories
 		if info.IsDir() {
-			return nil
+			if !s.walksIntoWorkspace(childRel) {
+				continue
+			}
+			if symlink {
+				w.links = append(w.links, symlinkDir{path: childPath, relPath: childRel})
+				continue
+			}
+			if err := s.walkDir(w, childPath, childRel); err != nil {
+				return err
+			}
+			continue
+		}
+		if !info.Mode().IsRegular() {
+			continue
 		}
 
 		// Check if supported extension
-		ext := strings.ToLower(filepath.Ext(path))
+		ext := strings.ToLower(filepath.Ext(childPath))
 		language := adapters.LanguageFromExtension(ext)
 		if language == "" {
-			return nil
+			continue
+		}
+		if reason := s.skipReason(childPath, childRel, language, info); reason != "" {
+			s.skipped[reason]++
+			continue
 		}
 
-		files = append(files, FileInfo{
-			Path:     path,
+		w.files = append(w.files, FileInfo{
+			Path:     childPath,
 			Language: language,
-			RelPath:  relPath,
+			RelPath:  childRel,
 		})
+	}
+	return nil
+}
 
+// walkSymlinkDir walks the symlinked directory at path unless its target
+// was already walked, directly or through another link. Such links are
+// reported as cycles and skipped.
+func (s *Scanner) walkSymlinkDir(w *walk, path, relPath string) error {
+	id, err := statDirID(path)
+	if err != nil {
+		s.warnings = append(s.warnings, fmt.Sprintf("%s: %v", relPath, err))
 		return nil
-	})
-
-	return files, err
+	}
+	if w.visited[id] {
+		target, _ := filepath.EvalSymlinks(path)
+		s.warnings = append(s.warnings, fmt.Sprintf("%s: symlink loop or duplicate tree (-> %s), skipped", relPath, target))
+		return nil
+	}
+	return s.walkDir(w, path, relPath)
 }
 
 // GroupByLanguage groups files by their language
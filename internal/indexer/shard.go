@@ -0,0 +1,52 @@
+package indexer
+
+import (
+	"fmt"
+	"hash/fnv"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Shard is one of Count parts of a project's files, numbered from 1, that
+// a build indexes on its own (build --shard 2/4)
+type Shard struct {
+	Index int
+	Count int
+}
+
+// ParseShard parses "i/N", with 1 <= i <= N
+func ParseShard(s string) (Shard, error) {
+	index, count, ok := strings.Cut(s, "/")
+	i, err1 := strconv.Atoi(strings.TrimSpace(index))
+	n, err2 := strconv.Atoi(strings.TrimSpace(count))
+	if !ok || err1 != nil || err2 != nil || n < 1 || i < 1 || i > n {
+		return Shard{}, fmt.Errorf("invalid shard %q (expected i/N with 1 <= i <= N, e.g. 2/4)", s)
+	}
+	return Shard{Index: i, Count: n}, nil
+}
+
+// String formats the shard as ParseShard reads it
+func (s Shard) String() string {
+	return fmt.Sprintf("%d/%d", s.Index, s.Count)
+}
+
+// Contains reports whether a file belongs to the shard. Files are assigned
+// by a hash of their project-relative path, so every machine computes the
+// same partition whatever its checkout directory or file order.
+func (s Shard) Contains(relPath string) bool {
+	h := fnv.New32a()
+	h.Write([]byte(filepath.ToSlash(relPath)))
+	return int(h.Sum32()%uint32(s.Count)) == s.Index-1
+}
+
+// Files returns the files of the shard, in their original order
+func (s Shard) Files(files []FileInfo) []FileInfo {
+	var kept []FileInfo
+	for _, file := range files {
+		if s.Contains(file.RelPath) {
+			kept = append(kept, file)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,287 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+// tagLanguages are the languages with annotation, decorator or attribute
+// syntax
+var tagLanguages = map[string]bool{
+	"java": true, "python": true, "typescript": true, "typescriptreact": true, "javascript": true,
+	"csharp": true, "rust": true, "swift": true, "c": true, "cpp": true,
+}
+
+var (
+	annotationName   = regexp.MustCompile(`^[\w$]+(?:(?:\.|::)[\w$]+)*`)
+	attributeTarget  = regexp.MustCompile(`^(?:assembly|module|return|field|property|param|method|type|event):\s*`) // C# [return: NotNull]
+	pythonDefinition = regexp.MustCompile(`^\s*(?:async\s+)?(?:def|class)\s`)
+)
+
+// TagIndexer records the annotations, decorators and attributes on symbols
+// as tags, so queries can filter on them (search --tag deprecated). Doc
+// comments with a deprecation notice add a deprecated tag in any language.
+type TagIndexer struct {
+	db *db.Manager
+}
+
+// NewTagIndexer creates a tag indexer
+func NewTagIndexer(dbManager *db.Manager) *TagIndexer {
+	return &TagIndexer{db: dbManager}
+}
+
+// IndexTags re-extracts the tags of files and returns how many were found
+func (t *TagIndexer) IndexTags(ctx context.Context, files []FileInfo) (int, error) {
+	paths := make([]string, len(files))
+	for i, file := range files {
+		paths[i] = file.Path
+	}
+	if err := t.db.ClearTags(paths); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return count, err
+		}
+		content, err := os.ReadFile(file.Path)
+		if err != nil {
+			continue // Removed since it was scanned
+		}
+		symbols, err := t.db.GetFileSymbols(file.Path)
+		if err != nil {
+			return count, err
+		}
+		for _, tag := range fileTags(file, strings.Split(string(content), "\n"), symbols) {
+			if err := t.db.InsertTag(tag); err != nil {
+				return count, fmt.Errorf("failed to insert tag: %w", err)
+			}
+			count++
+		}
+	}
+	return count, nil
+}
+
+// fileTags returns the tags on the symbols of a file, read from the
+// annotation lines of each declaration, plus a deprecated tag for the ones
+// whose doc comment says so
+func fileTags(file FileInfo, lines []string, symbols []db.Symbol) []*db.Tag {
+	var tags []*db.Tag
+	for _, s := range symbols {
+		if tag := docDeprecation(file, lines, s); tag != nil {
+			tags = append(tags, tag)
+		}
+		if !tagLanguages[file.Language] {
+			continue
+		}
+		for _, line := range annotationLines(lines, s.Line) {
+			for _, a := range lineAnnotations(lines[line-1], file.Language) {
+				tags = append(tags, &db.Tag{
+					SymbolID: s.ID,
+					Name:     a.name,
+					Tag:      annotationTag(a, file.Language),
+					Args:     a.args,
+					File:     file.Path,
+					Line:     line,
+				})
+			}
+		}
+	}
+	return tags
+}
+
+// annotation is one annotation of a line: @app.route("/x") has name
+// app.route and args "/x"
+type annotation struct {
+	name, args string
+}
+
+// lineAnnotations parses the annotations a line starts with. Several may
+// share a line (@Override @Deprecated public void f(), [Test, Category("x")]);
+// parsing stops at the first text that is not an annotation.
+func lineAnnotations(text, language string) []annotation {
+	text = strings.TrimSpace(text)
+	var found []annotation
+	for text != "" {
+		switch {
+		case strings.HasPrefix(text, "@"):
+			a, rest, ok := readAnnotation(text[1:])
+			if !ok || a.name == "interface" { // Java @interface declares an annotation type
+				return found
+			}
+			found = append(found, a)
+			text = strings.TrimSpace(rest)
+		case strings.HasPrefix(text, "#[") || strings.HasPrefix(text, "[["):
+			inner, rest := enclosedArguments(text[2:])
+			found = append(found, attributeList(inner)...)
+			text = strings.TrimSpace(strings.TrimPrefix(rest, "]"))
+		case strings.HasPrefix(text, "[") && language == "csharp":
+			inner, rest := enclosedArguments(text[1:])
+			found = append(found, attributeList(attributeTarget.ReplaceAllString(inner, ""))...)
+			text = strings.TrimSpace(rest)
+		default:
+			return found
+		}
+	}
+	return found
+}
+
+// attributeList parses the comma-separated attributes between brackets:
+// derive(Debug), test or Obsolete("use Y")
+func attributeList(text string) []annotation {
+	var found []annotation
+	for _, part := range splitArguments(text) {
+		if a, _, ok := readAnnotation(part); ok {
+			found = append(found, a)
+		}
+	}
+	return found
+}
+
+// readAnnotation reads a name and its parenthesized arguments from the
+// start of text and returns the rest. Arguments continuing on the next
+// line are kept up to the end of text.
+func readAnnotation(text string) (annotation, string, bool) {
+	name := annotationName.FindString(text)
+	if name == "" {
+		return annotation{}, text, false
+	}
+	a, rest := annotation{name: name}, text[len(name):]
+	if strings.HasPrefix(rest, "(") {
+		a.args, rest = enclosedArguments(rest[1:])
+		a.args = strings.TrimSpace(a.args)
+	}
+	return a, rest, true
+}
+
+// annotationTag normalizes an annotation to its tag: the lower-case last
+// segment of its name (app.route is route, tokio::test is test), with the
+// deprecation markers of every language as TagDeprecated
+func annotationTag(a annotation, language string) string {
+	name := a.name
+	if i := strings.LastIndexAny(name, ".:"); i >= 0 {
+		name = name[i+1:]
+	}
+	if language == "csharp" && name != "Attribute" {
+		name = strings.TrimSuffix(name, "Attribute") // [ObsoleteAttribute] is [Obsolete]
+	}
+	tag := strings.ToLower(name)
+	switch {
+	case tag == "obsolete":
+		return db.TagDeprecated
+	case tag == "available" && containsWord(a.args, "deprecated"):
+		return db.TagDeprecated // Swift @available(*, deprecated, message: "...")
+	}
+	return tag
+}
+
+// deprecationMarkers start the deprecation notice of a doc comment line:
+// Go's "Deprecated:" paragraph, JSDoc, Javadoc and PHPDoc @deprecated, and
+// Sphinx ".. deprecated::"
+var deprecationMarkers = []string{"Deprecated:", "@deprecated", ".. deprecated::"}
+
+// docDeprecation returns a deprecated tag for s when its doc comment has a
+// deprecation notice, with the rest of the notice's line as its args. The
+// comment is read from the source (a docstring for Python), falling back
+// to the documentation the language server returned.
+func docDeprecation(file FileInfo, lines []string, s db.Symbol) *db.Tag {
+	doc := docComment(lines, s.Line, file.Language)
+	for _, d := range doc {
+		if marker, notice, ok := deprecationNotice(lines[d-1]); ok {
+			return &db.Tag{SymbolID: s.ID, Name: marker, Tag: db.TagDeprecated, Args: notice, File: file.Path, Line: d}
+		}
+	}
+	if len(doc) > 0 {
+		return nil
+	}
+	for _, text := range strings.Split(s.Documentation, "\n") {
+		if marker, notice, ok := deprecationNotice(text); ok {
+			return &db.Tag{SymbolID: s.ID, Name: marker, Tag: db.TagDeprecated, Args: notice, File: file.Path, Line: s.Line}
+		}
+	}
+	return nil
+}
+
+// deprecationNotice finds a deprecation marker at the start of a doc
+// comment line and returns it without @ or colons, with the text after it
+func deprecationNotice(text string) (string, string, bool) {
+	text = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(text), "/*#!"))
+	text = strings.TrimSpace(strings.Trim(text, `"'`)) // One-line docstrings
+	for _, marker := range deprecationMarkers {
+		if strings.HasPrefix(text, marker) {
+			name := strings.Trim(marker, "@.: ")
+			notice := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(text[len(marker):]), "*/"))
+			return name, strings.TrimSpace(strings.Trim(notice, `"'`)), true
+		}
+	}
+	return "", "", false
+}
+
+// docComment returns the lines of the doc comment of the declaration at
+// line: the comment lines right above it and its annotations, or for Python
+// the docstring below it. Comments separated by a blank line are not docs.
+func docComment(lines []string, line int, language string) []int {
+	if language == "python" {
+		return docstring(lines, line)
+	}
+	top := line
+	if above := decoratorLines(lines, line); len(above) > 0 {
+		top = above[len(above)-1]
+	}
+	var found []int
+	for i := top - 1; i >= 1 && i <= len(lines); i-- {
+		trimmed := strings.TrimSpace(lines[i-1])
+		if !strings.HasPrefix(trimmed, "//") && !strings.HasPrefix(trimmed, "/*") &&
+			!strings.HasPrefix(trimmed, "*") && !(language == "ruby" && strings.HasPrefix(trimmed, "#")) {
+			break
+		}
+		found = append(found, i)
+	}
+	return found
+}
+
+// docstring returns the lines of the docstring of the Python function or
+// class at line, which follows the (possibly multi-line) signature
+func docstring(lines []string, line int) []int {
+	if line < 1 || line > len(lines) || !pythonDefinition.MatchString(lines[line-1]) {
+		return nil // Variables have no docstring
+	}
+	i := line
+	for i >= 1 && i <= len(lines) && !strings.HasSuffix(strings.TrimSpace(lines[i-1]), ":") {
+		i++ // Signature continues
+	}
+	i++
+	for i <= len(lines) && strings.TrimSpace(lines[i-1]) == "" {
+		i++
+	}
+	if i > len(lines) {
+		return nil
+	}
+	first := strings.TrimSpace(lines[i-1])
+	quote := ""
+	for _, q := range []string{`"""`, `'''`} {
+		if strings.HasPrefix(strings.TrimLeft(first, "rRuU"), q) {
+			quote = q
+		}
+	}
+	if quote == "" {
+		return nil
+	}
+	found := []int{i}
+	if strings.Count(first, quote) >= 2 {
+		return found
+	}
+	for i++; i <= len(lines); i++ {
+		found = append(found, i)
+		if strings.Contains(lines[i-1], quote) {
+			break
+		}
+	}
+	return found
+}
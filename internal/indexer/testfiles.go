@@ -0,0 +1,45 @@
+package indexer
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IsTestFile reports whether the file at relPath (relative to the project
+// root) holds tests, by the naming conventions of its language:
+//
+//	Go             *_test.go
+//	Python         test_*.py, *_test.py, conftest.py
+//	TS/JavaScript  *.test.ts, *.spec.ts (any JS/TS extension), __tests__/
+//	Java           *Test.java, *Tests.java, *IT.java, src/test/
+//	C#, Swift      *Test.cs, *Tests.swift, ...
+//	Rust           tests/ (integration tests)
+//	OCaml, C/C++   test_*.ml, *_test.ml, ...
+func IsTestFile(relPath string) bool {
+	relPath = filepath.ToSlash(relPath)
+	base := path.Base(relPath)
+	ext := path.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+	dirs := "/" + path.Dir(relPath) + "/"
+
+	switch strings.ToLower(ext) {
+	case ".go":
+		return strings.HasSuffix(stem, "_test")
+	case ".py":
+		return strings.HasPrefix(stem, "test_") || strings.HasSuffix(stem, "_test") || stem == "conftest"
+	case ".ts", ".tsx", ".mts", ".cts", ".js", ".jsx", ".mjs", ".cjs":
+		return strings.HasSuffix(stem, ".test") || strings.HasSuffix(stem, ".spec") ||
+			strings.Contains(dirs, "/__tests__/")
+	case ".java":
+		return strings.HasSuffix(stem, "Test") || strings.HasSuffix(stem, "Tests") ||
+			strings.HasSuffix(stem, "IT") || strings.Contains(dirs, "/src/test/")
+	case ".cs", ".swift":
+		return strings.HasSuffix(stem, "Test") || strings.HasSuffix(stem, "Tests")
+	case ".rs":
+		return strings.Contains(dirs, "/tests/")
+	case ".ml", ".mli", ".c", ".h", ".cc", ".cpp", ".cxx", ".hpp":
+		return strings.HasPrefix(stem, "test_") || strings.HasSuffix(stem, "_test")
+	}
+	return false
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -13,7 +14,9 @@ import (
 	"github.com/smacker/go-tree-sitter/csharp"
 	"github.com/smacker/go-tree-sitter/golang"
 	"github.com/smacker/go-tree-sitter/java"
+	"github.com/smacker/go-tree-sitter/javascript"
 	"github.com/smacker/go-tree-sitter/ocaml"
+	"github.com/smacker/go-tree-sitter/protobuf"
 	"github.com/smacker/go-tree-sitter/python"
 	"github.com/smacker/go-tree-sitter/rust"
 	"github.com/smacker/go-tree-sitter/swift"
@@ -26,6 +29,8 @@ import (
 type TreeSitterIndexer struct {
 	db       *db.Manager
 	rootPath string
+	kinds    *KindMap
+	writer   *db.Writer // Queues the stores of IndexFile when set
 }
 
 // NewTreeSitterIndexer creates a new tree-sitter based indexer
@@ -36,48 +41,129 @@ func NewTreeSitterIndexer(dbManager *db.Manager, rootPath string) *TreeSitterInd
 	}
 }
 
+// SetKinds relabels the kinds of the symbols extracted from now on
+func (t *TreeSitterIndexer) SetKinds(kinds *KindMap) {
+	t.kinds = kinds
+}
+
+// SetWriter makes IndexFile queue its stores on w instead of writing them
+// before it returns; they are visible once w is flushed
+func (t *TreeSitterIndexer) SetWriter(w *db.Writer) {
+	t.writer = w
+}
+
 // IndexFile extracts symbols from a file using tree-sitter
 func (t *TreeSitterIndexer) IndexFile(ctx context.Context, file FileInfo) (int, error) {
-	// Get the appropriate language
-	lang := t.getLanguage(file.Language)
-	if lang == nil {
-		return 0, fmt.Errorf("tree-sitter does not support language: %s", file.Language)
-	}
-
-	// Read file content
 	content, err := os.ReadFile(file.Path)
 	if err != nil {
 		return 0, fmt.Errorf("failed to read file: %w", err)
 	}
-
-	// Parse using tree-sitter
-	parser := sitter.NewParser()
-	parser.SetLanguage(lang)
-
-	tree, err := parser.ParseCtx(ctx, nil, content)
+	symbols, contains, err := t.parse(ctx, file, content)
 	if err != nil {
-		return 0, fmt.Errorf("tree-sitter parse error: %w", err)
+		return 0, err
 	}
-	defer tree.Close()
 
-	// Extract symbols from the tree
-	symbols := t.extractSymbols(tree.RootNode(), content, file, "")
+	isTest := IsTestFile(file.RelPath)
+	for _, sym := range symbols {
+		sym.IsTest = isTest
+	}
+	if t.writer != nil {
+		t.writer.Write(&db.FileWrite{
+			Path:     file.Path,
+			Language: file.Language,
+			Source:   sourceTreeSitter,
+			Symbols:  symbols,
+			Contains: contains,
+			ModTime:  time.Now(),
+		})
+		return len(symbols), nil
+	}
 
 	// Store symbols in database
+	if err := t.db.ClearFileContainment(file.Path); err != nil {
+		return 0, err
+	}
 	for _, sym := range symbols {
 		if err := t.db.InsertSymbol(sym); err != nil {
 			return 0, err
 		}
 	}
+	for _, c := range contains {
+		if err := t.db.InsertContainment(c); err != nil {
+			return 0, err
+		}
+	}
 
 	// Update file metadata
-	if err := t.db.UpdateFileMeta(file.Path, time.Now(), file.Language); err != nil {
+	if err := t.db.UpdateFileMeta(file.Path, time.Now(), file.Language, sourceTreeSitter); err != nil {
 		return 0, err
 	}
 
 	return len(symbols), nil
 }
 
+// ParseFile extracts symbols from a file without storing them
+func (t *TreeSitterIndexer) ParseFile(ctx context.Context, file FileInfo) ([]*db.Symbol, error) {
+	content, err := os.ReadFile(file.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return t.ParseContent(ctx, file, content)
+}
+
+// ParseContent extracts symbols from already-loaded file content
+func (t *TreeSitterIndexer) ParseContent(ctx context.Context, file FileInfo, content []byte) ([]*db.Symbol, error) {
+	symbols, _, err := t.parse(ctx, file, content)
+	return symbols, err
+}
+
+// parse extracts the symbols of a file and the containment between them
+func (t *TreeSitterIndexer) parse(ctx context.Context, file FileInfo, content []byte) ([]*db.Symbol, []*db.Containment, error) {
+	// Get the appropriate language
+	lang := t.getLanguage(grammarFor(file))
+	if lang == nil {
+		return nil, nil, fmt.Errorf("tree-sitter does not support language: %s", file.Language)
+	}
+
+	// Parse using tree-sitter
+	parser := sitter.NewParser()
+	parser.SetLanguage(lang)
+
+	tree, err := parser.ParseCtx(ctx, nil, content)
+	if err != nil {
+		return nil, nil, fmt.Errorf("tree-sitter parse error: %w", err)
+	}
+	defer tree.Close()
+
+	// Extract symbols from the tree, by query when the grammar has one
+	symbolTree := newSymbolTree()
+	symbolTree.pkg = filePackage(file, content)
+	symbolTree.definitions, err = t.queryDefinitions(grammarFor(file), lang, tree.RootNode(), content)
+	if err != nil {
+		return nil, nil, err
+	}
+	symbols := t.extractSymbols(tree.RootNode(), content, file, nil, symbolTree)
+	contains := symbolTree.contains
+
+	// Rust methods sit in impl blocks, which are not symbols; they belong
+	// to the type named by their scope when it is declared in this file
+	contained := make(map[string]bool, len(contains))
+	for _, c := range contains {
+		contained[c.ChildID] = true
+	}
+	ids := make(map[string]bool, len(symbols))
+	for _, sym := range symbols {
+		ids[sym.ID] = true
+	}
+	for _, sym := range symbols {
+		owner := fmt.Sprintf("%s#%s", file.RelPath, sym.Scope)
+		if sym.Scope != "" && !contained[sym.ID] && ids[owner] {
+			contains = append(contains, &db.Containment{ChildID: sym.ID, ParentID: owner})
+		}
+	}
+	return symbols, contains, nil
+}
+
 // getLanguage returns the tree-sitter language for a given language name
 func (t *TreeSitterIndexer) getLanguage(lang string) *sitter.Language {
 	switch lang {
@@ -90,7 +176,7 @@ func (t *TreeSitterIndexer) getLanguage(lang string) *sitter.Language {
 	case "typescriptreact":
 		return tsx.GetLanguage()
 	case "javascript":
-		return typescript.GetLanguage()
+		return javascript.GetLanguage()
 	case "java":
 		return java.GetLanguage()
 	case "swift":
@@ -105,69 +191,103 @@ func (t *TreeSitterIndexer) getLanguage(lang string) *sitter.Language {
 		return cpp.GetLanguage()
 	case "csharp":
 		return csharp.GetLanguage()
+	case "proto":
+		return protobuf.GetLanguage()
 	default:
 		return nil
 	}
 }
 
+// grammarFor returns the tree-sitter grammar to parse file with. JavaScript
+// files are indexed as "typescript" so they share the TypeScript language
+// server, but parse with the JavaScript grammar.
+func grammarFor(file FileInfo) string {
+	switch strings.ToLower(filepath.Ext(file.Path)) {
+	case ".js", ".mjs", ".cjs":
+		return "javascript"
+	}
+	return file.Language
+}
+
 // extractSymbols walks the AST and extracts symbol definitions
-func (t *TreeSitterIndexer) extractSymbols(node *sitter.Node, content []byte, file FileInfo, scope string) []*db.Symbol {
+func (t *TreeSitterIndexer) extractSymbols(node *sitter.Node, content []byte, file FileInfo, parent *db.Symbol, tree *symbolTree) []*db.Symbol {
 	var symbols []*db.Symbol
 
+	// Children are scoped by the full chain of their parents: Outer.Inner
+	scope := ""
+	if parent != nil {
+		scope = qualifyName(parent.Scope, scopeSeparator(file.Language), parent.Name)
+	}
+
 	// Check if this node is a symbol we care about
-	if sym := t.nodeToSymbol(node, content, file, scope); sym != nil {
+	if sym := t.nodeToSymbol(node, content, file, scope, tree); sym != nil {
 		symbols = append(symbols, sym)
-		// Update scope for children
-		scope = sym.Name
+		if parent != nil {
+			tree.link(sym.ID, parent.ID)
+		}
+		// Children are scoped to and contained by this symbol
+		parent = sym
 	}
 
 	// Recursively process children
 	for i := 0; i < int(node.NamedChildCount()); i++ {
 		child := node.NamedChild(i)
-		childSymbols := t.extractSymbols(child, content, file, scope)
+		childSymbols := t.extractSymbols(child, content, file, parent, tree)
 		symbols = append(symbols, childSymbols...)
 	}
 
 	return symbols
 }
 
-// nodeToSymbol converts a tree-sitter node to a Symbol if applicable
-func (t *TreeSitterIndexer) nodeToSymbol(node *sitter.Node, content []byte, file FileInfo, scope string) *db.Symbol {
+// nodeToSymbol converts a tree-sitter node to a Symbol if applicable,
+// claiming its ID in tree
+func (t *TreeSitterIndexer) nodeToSymbol(node *sitter.Node, content []byte, file FileInfo, scope string, tree *symbolTree) *db.Symbol {
 	var name, kind, signature string
 
-	switch file.Language {
-	case "go":
-		name, kind, signature = t.extractGoSymbol(node, content)
-	case "python":
-		name, kind, signature = t.extractPythonSymbol(node, content)
-	case "swift":
-		name, kind, signature = t.extractSwiftSymbol(node, content)
-	case "typescript", "javascript", "typescriptreact":
-		name, kind, signature = t.extractTypeScriptSymbol(node, content)
-	case "java":
-		name, kind, signature = t.extractJavaSymbol(node, content)
-	case "rust":
-		name, kind, signature = t.extractRustSymbol(node, content)
-	case "ocaml":
-		name, kind, signature = t.extractOCamlSymbol(node, content)
-	case "c":
-		name, kind, signature = t.extractCSymbol(node, content)
-	case "cpp":
-		name, kind, signature = t.extractCppSymbol(node, content)
-	case "csharp":
-		name, kind, signature = t.extractCSharpSymbol(node, content)
-	default:
-		return nil
+	if tree.definitions != nil {
+		def := tree.definitions[keyOf(node)]
+		name, kind, signature = def.name, def.kind, def.signature
+	} else {
+		// Grammars without an extraction query (queries/*.scm)
+		switch grammarFor(file) {
+		case "go":
+			name, kind, signature = t.extractGoSymbol(node, content)
+		case "python":
+			name, kind, signature = t.extractPythonSymbol(node, content)
+		case "swift":
+			name, kind, signature = t.extractSwiftSymbol(node, content)
+		case "typescript", "typescriptreact":
+			name, kind, signature = t.extractTypeScriptSymbol(node, content)
+		case "javascript":
+			name, kind, signature = t.extractJavaScriptSymbol(node, content)
+		case "rust":
+			name, kind, signature = t.extractRustSymbol(node, content)
+		case "ocaml":
+			name, kind, signature = t.extractOCamlSymbol(node, content)
+		default:
+			return nil
+		}
 	}
 
 	if name == "" {
 		return nil
 	}
+	// Members of anonymous types (typedef struct { ... }, object literal
+	// types) have no type to list them under
+	if isMemberKind(kind) && scope == "" {
+		return nil
+	}
 
-	// Create symbol ID
-	id := fmt.Sprintf("%s#%s", file.RelPath, name)
-	if scope != "" {
-		id = fmt.Sprintf("%s#%s.%s", file.RelPath, scope, name)
+	// Rust qualifies members with its own path separator, and methods by
+	// their impl type or trait: Foo::bar, or m::Foo::bar inside mod m
+	sep := scopeSeparator(file.Language)
+	if file.Language == "rust" {
+		if owner := rustMethodOwner(node, content); owner != "" {
+			kind = "method"
+			if scope != owner && !strings.HasSuffix(scope, sep+owner) {
+				scope = qualifyName(scope, sep, owner)
+			}
+		}
 	}
 
 	startLine := int(node.StartPoint().Row) + 1
@@ -175,8 +295,8 @@ func (t *TreeSitterIndexer) nodeToSymbol(node *sitter.Node, content []byte, file
 	startCol := int(node.StartPoint().Column)
 	endCol := int(node.EndPoint().Column)
 
-	return &db.Symbol{
-		ID:        id,
+	sym := &db.Symbol{
+		ID:        tree.symbolID(file, scope, sep, name, startLine),
 		Name:      name,
 		Kind:      kind,
 		File:      file.Path,
@@ -189,7 +309,12 @@ func (t *TreeSitterIndexer) nodeToSymbol(node *sitter.Node, content []byte, file
 		Language:  file.Language,
 		Source:    "tree-sitter",
 		CreatedAt: time.Now(),
+		Package:   tree.pkg,
+		BodyHash:  bodyHash(node.Content(content), name),
 	}
+	sym.Kind = t.kinds.nodeKind(node.Type(), sym.Kind)
+	t.kinds.apply(sym)
+	return sym
 }
 
 // Language-specific extractors
@@ -232,6 +357,30 @@ func (t *TreeSitterIndexer) extractGoSymbol(node *sitter.Node, content []byte) (
 				}
 			}
 		}
+	case "field_identifier":
+		// Struct field; one declaration may name several: A, B int
+		if parent := node.Parent(); parent != nil && parent.Type() == "field_declaration" {
+			name, kind = node.Content(content), "field"
+			if typeNode := parent.ChildByFieldName("type"); typeNode != nil {
+				signature = typeNode.Content(content)
+			}
+		}
+	case "field_declaration":
+		// Embedded field, named after its type: *pkg.Reader -> Reader
+		if node.ChildByFieldName("name") == nil {
+			if typeNode := node.ChildByFieldName("type"); typeNode != nil {
+				// The declaration minus any tag keeps the pointer: *pkg.Reader
+				signature = string(content[node.StartByte():typeNode.EndByte()])
+				name = strings.TrimPrefix(signature, "*")
+				if idx := strings.LastIndex(name, "."); idx >= 0 {
+					name = name[idx+1:]
+				}
+				if idx := strings.Index(name, "["); idx >= 0 {
+					name = name[:idx]
+				}
+				kind = "field"
+			}
+		}
 	case "const_declaration", "var_declaration":
 		for i := 0; i < int(node.NamedChildCount()); i++ {
 			child := node.NamedChild(i)
@@ -256,7 +405,17 @@ func (t *TreeSitterIndexer) extractPythonSymbol(node *sitter.Node, content []byt
 		if nameNode := node.ChildByFieldName("name"); nameNode != nil {
 			name = nameNode.Content(content)
 			kind = "function"
-			signature = getFirstLine(node.Content(content))
+			decorators := pythonDecorators(node, content)
+			if enclosing := pythonEnclosingDefinition(node); enclosing != nil && enclosing.Type() == "class_definition" {
+				kind = "method"
+				for _, d := range decorators {
+					if d == "@property" || strings.HasSuffix(d, ".setter") || strings.HasPrefix(d, "@cached_property") {
+						kind = "property"
+					}
+				}
+			}
+			// Keep decorators with the def line: "@staticmethod async def f(x):"
+			signature = strings.Join(append(decorators, getFirstLine(node.Content(content))), " ")
 		}
 	case "class_definition":
 		if nameNode := node.ChildByFieldName("name"); nameNode != nil {
@@ -264,10 +423,105 @@ func (t *TreeSitterIndexer) extractPythonSymbol(node *sitter.Node, content []byt
 			kind = "class"
 			signature = getFirstLine(node.Content(content))
 		}
+	case "assignment":
+		name, kind, signature = pythonClassAttribute(node, content)
+	case "lambda":
+		if name = pythonLambdaName(node, content); name != "" {
+			kind = "function"
+			if enclosing := pythonEnclosingDefinition(node); enclosing != nil && enclosing.Type() == "class_definition" {
+				kind = "method"
+			}
+			signature = getFirstLine(node.Parent().Content(content))
+		}
 	}
 	return
 }
 
+// pythonDecorators returns the decorators applied to a definition, e.g.
+// ["@staticmethod", "@app.get(\"/items\")"]
+func pythonDecorators(node *sitter.Node, content []byte) []string {
+	parent := node.Parent()
+	if parent == nil || parent.Type() != "decorated_definition" {
+		return nil
+	}
+	var decorators []string
+	for i := 0; i < int(parent.NamedChildCount()); i++ {
+		if child := parent.NamedChild(i); child.Type() == "decorator" {
+			decorators = append(decorators, strings.Join(strings.Fields(child.Content(content)), " "))
+		}
+	}
+	return decorators
+}
+
+// pythonEnclosingDefinition returns the nearest class or function definition
+// containing node, or nil at module level
+func pythonEnclosingDefinition(node *sitter.Node) *sitter.Node {
+	for parent := node.Parent(); parent != nil; parent = parent.Parent() {
+		switch parent.Type() {
+		case "class_definition", "function_definition":
+			return parent
+		}
+	}
+	return nil
+}
+
+// pythonClassAttribute indexes a class-level assignment ("x: int = 0") as a
+// field, or as an enum member when the class derives from an Enum type.
+// Lambdas are left to the lambda case, which indexes them as methods.
+func pythonClassAttribute(node *sitter.Node, content []byte) (name, kind, signature string) {
+	left := node.ChildByFieldName("left")
+	if left == nil || left.Type() != "identifier" {
+		return
+	}
+	if right := node.ChildByFieldName("right"); right != nil && right.Type() == "lambda" {
+		return
+	}
+	statement := node.Parent()
+	if statement == nil || statement.Type() != "expression_statement" {
+		return
+	}
+	block := statement.Parent()
+	if block == nil || block.Type() != "block" {
+		return
+	}
+	class := block.Parent()
+	if class == nil || class.Type() != "class_definition" {
+		return
+	}
+	name, kind = left.Content(content), "field"
+	if typeNode := node.ChildByFieldName("type"); typeNode != nil {
+		signature = typeNode.Content(content)
+	}
+	if bases := class.ChildByFieldName("superclasses"); bases != nil {
+		for i := 0; i < int(bases.NamedChildCount()); i++ {
+			base := bases.NamedChild(i).Content(content)
+			if idx := strings.LastIndex(base, "."); idx >= 0 {
+				base = base[idx+1:]
+			}
+			switch base {
+			case "Enum", "IntEnum", "StrEnum", "Flag", "IntFlag":
+				kind = "enum_member"
+			}
+		}
+	}
+	return
+}
+
+// pythonLambdaName returns the variable a lambda is assigned to
+// ("handler = lambda req: ..."), or "" for inline lambdas
+func pythonLambdaName(node *sitter.Node, content []byte) string {
+	parent := node.Parent()
+	if parent == nil || parent.Type() != "assignment" {
+		return ""
+	}
+	right := parent.ChildByFieldName("right")
+	left := parent.ChildByFieldName("left")
+	if right == nil || !right.Equal(node) || left == nil || left.Type() != "identifier" {
+		return ""
+	}
+	return left.Content(content)
+}
+
 func (t *TreeSitterIndexer) extractSwiftSymbol(node *sitter.Node, content []byte) (name, kind, signature string) {
 	switch node.Type() {
 	case "function_declaration":
@@ -280,6 +534,11 @@ func (t *TreeSitterIndexer) extractSwiftSymbol(node *sitter.Node, content []byte
 		if nameNode := node.ChildByFieldName("name"); nameNode != nil {
 			name = nameNode.Content(content)
 			kind = "class"
+			// Extensions are indexed under the extended type's name; the
+			// signature tells them apart from its declaration
+			if swiftDeclarationKind(node) == "extension" {
+				signature = strings.TrimSpace(strings.TrimSuffix(getFirstLine(node.Content(content)), "{"))
+			}
 		}
 	case "struct_declaration":
 		if nameNode := node.ChildByFieldName("name"); nameNode != nil {
@@ -296,10 +555,52 @@ func (t *TreeSitterIndexer) extractSwiftSymbol(node *sitter.Node, content []byte
 			name = nameNode.Content(content)
 			kind = "enum"
 		}
+	case "simple_identifier":
+		name, kind, signature = swiftMember(node, content)
 	}
 	return
 }
 
+// swiftDeclarationKind returns the keyword a Swift class_declaration starts
+// with: class, struct, enum, actor or extension
+func swiftDeclarationKind(node *sitter.Node) string {
+	for i := 0; i < int(node.ChildCount()); i++ {
+		if child := node.Child(i); !child.IsNamed() {
+			return child.Type()
+		}
+	}
+	return ""
+}
+
+// swiftMember indexes the names bound by a stored or computed property
+// ("let y, z: String") and by an enum case ("case a, b(Int)")
+func swiftMember(node *sitter.Node, content []byte) (name, kind, signature string) {
+	parent := node.Parent()
+	if parent == nil {
+		return
+	}
+	if parent.Type() == "enum_entry" {
+		return node.Content(content), "enum_member", ""
+	}
+	if parent.Type() != "pattern" {
+		return
+	}
+	property := parent.Parent()
+	if property == nil || property.Type() != "property_declaration" {
+		return
+	}
+	body := property.Parent()
+	if body == nil || (body.Type() != "class_body" && body.Type() != "enum_class_body") {
+		return
+	}
+	for i := 0; i < int(property.NamedChildCount()); i++ {
+		if child := property.NamedChild(i); child.Type() == "type_annotation" {
+			signature = strings.TrimSpace(strings.TrimPrefix(child.Content(content), ":"))
+		}
+	}
+	return node.Content(content), "field", signature
+}
+
 func (t *TreeSitterIndexer) extractTypeScriptSymbol(node *sitter.Node, content []byte) (name, kind, signature string) {
 	switch node.Type() {
 	case "function_declaration":
@@ -318,22 +619,61 @@ func (t *TreeSitterIndexer) extractTypeScriptSymbol(node *sitter.Node, content [
 			name = nameNode.Content(content)
 			kind = "interface"
 		}
+	case "enum_declaration":
+		if nameNode := node.ChildByFieldName("name"); nameNode != nil {
+			name = nameNode.Content(content)
+			kind = "enum"
+		}
+	case "type_alias_declaration":
+		// type Shape = Circle | Square; the value is the signature
+		if nameNode := node.ChildByFieldName("name"); nameNode != nil {
+			name = nameNode.Content(content)
+			kind = "type"
+			if value := node.ChildByFieldName("value"); value != nil {
+				signature = getFirstLine(value.Content(content))
+			}
+		}
 	case "method_definition":
 		if nameNode := node.ChildByFieldName("name"); nameNode != nil {
 			name = nameNode.Content(content)
 			kind = "method"
 			signature = getFirstLine(node.Content(content))
 		}
+	case "arrow_function", "function_expression", "function":
+		name, kind, signature = extractBoundFunction(node, content)
+	case "public_field_definition", "property_signature":
+		// Interface members only; object literal types have no owner
+		if node.Type() == "property_signature" && (node.Parent() == nil || node.Parent().Type() != "interface_body") {
+			break
+		}
+		if nameNode := node.ChildByFieldName("name"); nameNode != nil && !isFunctionValue(node.ChildByFieldName("value")) {
+			name, kind = nameNode.Content(content), "field"
+			if typeNode := node.ChildByFieldName("type"); typeNode != nil {
+				signature = strings.TrimSpace(strings.TrimPrefix(typeNode.Content(content), ":"))
+			}
+		}
+	case "property_identifier":
+		// Enum member without an initializer
+		if parent := node.Parent(); parent != nil && parent.Type() == "enum_body" {
+			name, kind = node.Content(content), "enum_member"
+		}
+	case "enum_assignment":
+		if nameNode := node.ChildByFieldName("name"); nameNode != nil {
+			name, kind = nameNode.Content(content), "enum_member"
+			if value := node.ChildByFieldName("value"); value != nil {
+				signature = value.Content(content)
+			}
+		}
 	}
 	return
 }
 
-func (t *TreeSitterIndexer) extractJavaSymbol(node *sitter.Node, content []byte) (name, kind, signature string) {
+func (t *TreeSitterIndexer) extractJavaScriptSymbol(node *sitter.Node, content []byte) (name, kind, signature string) {
 	switch node.Type() {
-	case "method_declaration":
+	case "function_declaration", "generator_function_declaration":
 		if nameNode := node.ChildByFieldName("name"); nameNode != nil {
 			name = nameNode.Content(content)
-			kind = "method"
+			kind = "function"
 			signature = getFirstLine(node.Content(content))
 		}
 	case "class_declaration":
@@ -341,20 +681,118 @@ func (t *TreeSitterIndexer) extractJavaSymbol(node *sitter.Node, content []byte)
 			name = nameNode.Content(content)
 			kind = "class"
 		}
-	case "interface_declaration":
+	case "method_definition":
 		if nameNode := node.ChildByFieldName("name"); nameNode != nil {
 			name = nameNode.Content(content)
-			kind = "interface"
+			kind = "method"
+			signature = getFirstLine(node.Content(content))
 		}
-	case "enum_declaration":
-		if nameNode := node.ChildByFieldName("name"); nameNode != nil {
-			name = nameNode.Content(content)
-			kind = "enum"
+	case "arrow_function", "function_expression", "function":
+		name, kind, signature = extractBoundFunction(node, content)
+	case "field_definition":
+		if nameNode := node.ChildByFieldName("property"); nameNode != nil && !isFunctionValue(node.ChildByFieldName("value")) {
+			name, kind = nameNode.Content(content), "field"
 		}
 	}
 	return
 }
 
+// isFunctionValue reports whether a field initializer is a function, which
+// is indexed as a method by extractBoundFunction instead
+func isFunctionValue(value *sitter.Node) bool {
+	if value == nil {
+		return false
+	}
+	switch value.Type() {
+	case "arrow_function", "function_expression", "function":
+		return true
+	}
+	return false
+}
+
+// extractBoundFunction indexes an arrow function or function expression
+// under the name it is bound to. Callbacks and other unnamed values are
+// skipped; their bodies belong to the enclosing function.
+func extractBoundFunction(node *sitter.Node, content []byte) (name, kind, signature string) {
+	name, method := boundFunctionName(node, content)
+	if name == "" {
+		return "", "", ""
+	}
+	kind = "function"
+	if method {
+		kind = "method"
+	}
+	// The binding's first line reads better than the bare "async () => {"
+	declaration := node
+	if parent := node.Parent(); parent != nil && parent.Type() != "arguments" {
+		declaration = parent
+	}
+	return name, kind, getFirstLine(declaration.Content(content))
+}
+
+// boundFunctionName returns the name a TS/JS function value is bound to:
+//
+//	const handler = async () => {}         -> handler
+//	module.exports.load = function () {}   -> load
+//	Foo.prototype.render = function () {}  -> render (method)
+//	class A { onClick = () => {} }         -> onClick (method)
+//	{ save: () => {} }                     -> save (method)
+//	const Card = memo((props) => <div />)  -> Card
+//
+// Function expressions with their own name fall back to it.
+func boundFunctionName(node *sitter.Node, content []byte) (name string, method bool) {
+	if parent := node.Parent(); parent != nil {
+		switch parent.Type() {
+		case "variable_declarator":
+			if value := parent.ChildByFieldName("value"); value != nil && value.Equal(node) {
+				if nameNode := parent.ChildByFieldName("name"); nameNode != nil && nameNode.Type() == "identifier" {
+					return nameNode.Content(content), false
+				}
+			}
+		case "assignment_expression":
+			right := parent.ChildByFieldName("right")
+			left := parent.ChildByFieldName("left")
+			if right == nil || !right.Equal(node) || left == nil {
+				break
+			}
+			switch left.Type() {
+			case "identifier":
+				return left.Content(content), false
+			case "member_expression":
+				prop := left.ChildByFieldName("property")
+				if prop == nil || prop.Content(content) == "exports" {
+					break // module.exports = function name() {}
+				}
+				object := left.ChildByFieldName("object")
+				isPrototype := object != nil && object.Type() == "member_expression" &&
+					object.ChildByFieldName("property") != nil &&
+					object.ChildByFieldName("property").Content(content) == "prototype"
+				return prop.Content(content), isPrototype
+			}
+		case "public_field_definition", "field_definition":
+			nameNode := parent.ChildByFieldName("name")
+			if nameNode == nil {
+				nameNode = parent.ChildByFieldName("property") // JavaScript grammar
+			}
+			if nameNode != nil {
+				return nameNode.Content(content), true
+			}
+		case "pair":
+			if key := parent.ChildByFieldName("key"); key != nil && key.Type() == "property_identifier" {
+				return key.Content(content), true
+			}
+		case "arguments":
+			if name := wrappedComponentName(parent, content); name != "" {
+				return name, false
+			}
+		}
+	}
+	if nameNode := node.ChildByFieldName("name"); nameNode != nil {
+		return nameNode.Content(content), false
+	}
+	return "", false
+}
+
 func (t *TreeSitterIndexer) extractRustSymbol(node *sitter.Node, content []byte) (name, kind, signature string) {
 	switch node.Type() {
 	case "function_item":
@@ -368,6 +806,13 @@ func (t *TreeSitterIndexer) extractRustSymbol(node *sitter.Node, content []byte)
 			name = nameNode.Content(content)
 			kind = "struct"
 		}
+	case "function_signature_item":
+		// Trait method without a default body
+		if nameNode := node.ChildByFieldName("name"); nameNode != nil {
+			name = nameNode.Content(content)
+			kind = "function"
+			signature = getFirstLine(node.Content(content))
+		}
 	case "impl_item":
 		// Skip impl blocks, we extract methods from inside
 	case "enum_item":
@@ -380,10 +825,64 @@ func (t *TreeSitterIndexer) extractRustSymbol(node *sitter.Node, content []byte)
 			name = nameNode.Content(content)
 			kind = "interface"
 		}
+	case "field_declaration":
+		if nameNode := node.ChildByFieldName("name"); nameNode != nil {
+			name, kind = nameNode.Content(content), "field"
+			if typeNode := node.ChildByFieldName("type"); typeNode != nil {
+				signature = typeNode.Content(content)
+			}
+		}
+	case "enum_variant":
+		if nameNode := node.ChildByFieldName("name"); nameNode != nil {
+			name, kind = nameNode.Content(content), "enum_member"
+			signature = getFirstLine(node.Content(content))
+		}
 	}
 	return
 }
 
+// rustMethodOwner returns the type of the impl block, or the trait, that a
+// Rust function item is declared in; "" for free functions
+func rustMethodOwner(node *sitter.Node, content []byte) string {
+	switch node.Type() {
+	case "function_item", "function_signature_item":
+	default:
+		return ""
+	}
+	body := node.Parent()
+	if body == nil || body.Type() != "declaration_list" {
+		return ""
+	}
+	owner := body.Parent()
+	if owner == nil {
+		return ""
+	}
+	switch owner.Type() {
+	case "impl_item":
+		return rustTypeName(owner.ChildByFieldName("type"), content)
+	case "trait_item":
+		if nameNode := owner.ChildByFieldName("name"); nameNode != nil {
+			return nameNode.Content(content)
+		}
+	}
+	return ""
+}
+
+// rustTypeName returns the bare name of a Rust type: Foo for Foo<T>,
+// a::b::Foo and &Foo
+func rustTypeName(node *sitter.Node, content []byte) string {
+	if node == nil {
+		return ""
+	}
+	switch node.Type() {
+	case "generic_type", "reference_type":
+		return rustTypeName(node.ChildByFieldName("type"), content)
+	case "scoped_type_identifier":
+		return rustTypeName(node.ChildByFieldName("name"), content)
+	}
+	return node.Content(content)
+}
+
 func (t *TreeSitterIndexer) extractOCamlSymbol(node *sitter.Node, content []byte) (name, kind, signature string) {
 	switch node.Type() {
 	case "value_definition":
@@ -400,11 +899,22 @@ func (t *TreeSitterIndexer) extractOCamlSymbol(node *sitter.Node, content []byte
 			signature = extractOCamlSignature(node, content)
 		}
 	case "let_binding":
-		if patternNode := node.ChildByFieldName("pattern"); patternNode != nil {
-			name = patternNode.Content(content)
+		// let f x y = ...: the pattern names the value, the parameters
+		// and return type go to the signature. Destructuring bindings
+		// (let (a, b) = ..., let () = ...) bind no single name.
+		patternNode := node.ChildByFieldName("pattern")
+		if patternNode == nil {
+			break
+		}
+		name = ocamlValueName(patternNode, content)
+		if name == "" {
+			break
+		}
+		kind = "variable"
+		if isOCamlFunctionBinding(node) {
 			kind = "function"
-			signature = extractOCamlSignature(node, content)
 		}
+		signature = ocamlBindingSignature(node, patternNode, content)
 	case "value_specification":
 		// val declarations in .mli files: val add : float -> float -> float
 		if nameNode := node.ChildByFieldName("name"); nameNode != nil {
@@ -421,11 +931,29 @@ func (t *TreeSitterIndexer) extractOCamlSymbol(node *sitter.Node, content []byte
 				}
 			}
 		}
-	case "type_definition":
+	case "type_binding":
 		if nameNode := node.ChildByFieldName("name"); nameNode != nil {
 			name = nameNode.Content(content)
 			kind = "type"
 		}
+	case "field_declaration":
+		// Record field: { a : int; mutable b : string }
+		for i := 0; i < int(node.NamedChildCount()); i++ {
+			child := node.NamedChild(i)
+			if child.Type() == "field_name" {
+				name, kind = child.Content(content), "field"
+			} else if name != "" {
+				signature = child.Content(content)
+			}
+		}
+	case "constructor_declaration":
+		// Variant constructor: Red | Green of int
+		for i := 0; i < int(node.NamedChildCount()); i++ {
+			if child := node.NamedChild(i); child.Type() == "constructor_name" {
+				name, kind = child.Content(content), "enum_member"
+				signature = node.Content(content)
+			}
+		}
 	case "module_definition":
 		if nameNode := node.ChildByFieldName("name"); nameNode != nil {
 			name = nameNode.Content(content)
@@ -440,6 +968,47 @@ func (t *TreeSitterIndexer) extractOCamlSymbol(node *sitter.Node, content []byte
 	return
 }
 
+// ocamlValueName returns the value a let pattern or call target names:
+// the identifier, or the operator of ( +! ), looking through type
+// annotations and parentheses. Other patterns name no single value.
+func ocamlValueName(node *sitter.Node, content []byte) string {
+	switch node.Type() {
+	case "value_name":
+		if name := node.Content(content); name != "_" {
+			return name
+		}
+	case "parenthesized_operator":
+		return strings.Join(strings.Fields(strings.Trim(node.Content(content), "()")), "")
+	case "typed_pattern", "parenthesized_pattern", "value_path":
+		// The name comes last in a path (List.map), first in a pattern
+		index := 0
+		if node.Type() == "value_path" {
+			index = int(node.NamedChildCount()) - 1
+		}
+		if child := node.NamedChild(index); child != nil {
+			return ocamlValueName(child, content)
+		}
+	}
+	return ""
+}
+
+// ocamlBindingSignature returns what a let binding declares between its
+// name and its body: the parameters and return type, e.g.
+// "(x : int) ~y : int" for let f (x : int) ~y : int = ...
+func ocamlBindingSignature(node, pattern *sitter.Node, content []byte) string {
+	end := node.EndByte()
+	if body := node.ChildByFieldName("body"); body != nil {
+		end = body.StartByte()
+	}
+	signature := strings.TrimSuffix(strings.TrimSpace(string(content[pattern.EndByte():end])), "=")
+	if pattern.Type() == "typed_pattern" && pattern.NamedChildCount() > 1 {
+		// let (x : int) = ...
+		typeNode := pattern.NamedChild(int(pattern.NamedChildCount()) - 1)
+		signature = ": " + typeNode.Content(content) + " " + signature
+	}
+	return strings.Join(strings.Fields(signature), " ")
+}
+
 // extractOCamlSignature extracts return type from OCaml function definitions
 func extractOCamlSignature(node *sitter.Node, content []byte) string {
 	// Look for type annotation in the node
@@ -459,6 +1028,12 @@ func extractOCamlSignature(node *sitter.Node, content []byte) string {
 
 // Helper functions
 
+// isMemberKind reports whether kind is a data member listed by
+// `codegraph fields`
+func isMemberKind(kind string) bool {
+	return kind == "field" || kind == "property" || kind == "enum_member"
+}
+
 func findNewline(s string) int {
 	for i, c := range s {
 		if c == '\n' {
@@ -474,110 +1049,3 @@ func getFirstLine(s string) string {
 	}
 	return s
 }
-
-func (t *TreeSitterIndexer) extractCSymbol(node *sitter.Node, content []byte) (name, kind, signature string) {
-	switch node.Type() {
-	case "function_definition":
-		if declarator := node.ChildByFieldName("declarator"); declarator != nil {
-			if funcDecl := declarator.ChildByFieldName("declarator"); funcDecl != nil {
-				name = funcDecl.Content(content)
-			} else {
-				name = declarator.Content(content)
-			}
-			if idx := findParen(name); idx > 0 {
-				name = name[:idx]
-			}
-			kind = "function"
-			signature = getFirstLine(node.Content(content))
-		}
-	case "struct_specifier":
-		if nameNode := node.ChildByFieldName("name"); nameNode != nil {
-			name = nameNode.Content(content)
-			kind = "struct"
-		}
-	case "enum_specifier":
-		if nameNode := node.ChildByFieldName("name"); nameNode != nil {
-			name = nameNode.Content(content)
-			kind = "enum"
-		}
-	}
-	return
-}
-
-func (t *TreeSitterIndexer) extractCppSymbol(node *sitter.Node, content []byte) (name, kind, signature string) {
-	switch node.Type() {
-	case "function_definition":
-		if declarator := node.ChildByFieldName("declarator"); declarator != nil {
-			name = declarator.Content(content)
-			if idx := findParen(name); idx > 0 {
-				name = name[:idx]
-			}
-			kind = "function"
-			signature = getFirstLine(node.Content(content))
-		}
-	case "class_specifier":
-		if nameNode := node.ChildByFieldName("name"); nameNode != nil {
-			name = nameNode.Content(content)
-			kind = "class"
-		}
-	case "struct_specifier":
-		if nameNode := node.ChildByFieldName("name"); nameNode != nil {
-			name = nameNode.Content(content)
-			kind = "struct"
-		}
-	}
-	return
-}
-
-func (t *TreeSitterIndexer) extractCSharpSymbol(node *sitter.Node, content []byte) (name, kind, signature string) {
-	switch node.Type() {
-	case "class_declaration":
-		if nameNode := node.ChildByFieldName("name"); nameNode != nil {
-			name = nameNode.Content(content)
-			kind = "class"
-		}
-	case "interface_declaration":
-		if nameNode := node.ChildByFieldName("name"); nameNode != nil {
-			name = nameNode.Content(content)
-			kind = "interface"
-		}
-	case "method_declaration":
-		if nameNode := node.ChildByFieldName("name"); nameNode != nil {
-			name = nameNode.Content(content)
-			kind = "method"
-			// Extract just the return type, not the full declaration
-			if returnType := node.ChildByFieldName("type"); returnType != nil {
-				signature = returnType.Content(content)
-			} else {
-				// Fallback: look for predefined_type or type child
-				for i := 0; i < int(node.NamedChildCount()); i++ {
-					child := node.NamedChild(i)
-					if child.Type() == "predefined_type" || child.Type() == "type" || child.Type() == "void_keyword" {
-						signature = child.Content(content)
-						break
-					}
-				}
-			}
-		}
-	case "struct_declaration":
-		if nameNode := node.ChildByFieldName("name"); nameNode != nil {
-			name = nameNode.Content(content)
-			kind = "struct"
-		}
-	case "enum_declaration":
-		if nameNode := node.ChildByFieldName("name"); nameNode != nil {
-			name = nameNode.Content(content)
-			kind = "enum"
-		}
-	}
-	return
-}
-
-func findParen(s string) int {
-	for i, c := range s {
-		if c == '(' {
-			return i
-		}
-	}
-	return -1
-}
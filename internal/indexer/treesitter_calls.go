@@ -4,17 +4,22 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path"
+	"regexp"
+	"strconv"
 	"strings"
 
 	sitter "github.com/smacker/go-tree-sitter"
 	"github.com/smacker/go-tree-sitter/csharp"
 	"github.com/smacker/go-tree-sitter/golang"
 	"github.com/smacker/go-tree-sitter/java"
+	"github.com/smacker/go-tree-sitter/javascript"
 	"github.com/smacker/go-tree-sitter/ocaml"
 	"github.com/smacker/go-tree-sitter/python"
 	"github.com/smacker/go-tree-sitter/rust"
 	"github.com/smacker/go-tree-sitter/swift"
 	"github.com/smacker/go-tree-sitter/typescript/typescript"
+	"github.com/tk-425/Codegraph/internal/config"
 	"github.com/tk-425/Codegraph/internal/db"
 )
 
@@ -22,6 +27,18 @@ import (
 type CallExtractor struct {
 	db       *db.Manager
 	rootPath string
+	// fileSymbols are the indexed symbols of the file being extracted,
+	// which give callers their stored IDs
+	fileSymbols []db.Symbol
+	// aliases are the names the file imports under another name, by alias
+	aliases map[string]db.Alias
+	// goImports are the package names of a Go file's imports, by the name
+	// they are used by
+	goImports map[string]string
+	pkg       string // Package of the file being extracted
+	filter    *CallFilter
+	// external are the calls of the file whose callee is not indexed
+	external []*db.ExternalCall
 }
 
 // NewCallExtractor creates a new call extractor
@@ -32,9 +49,15 @@ func NewCallExtractor(dbManager *db.Manager, rootPath string) *CallExtractor {
 	}
 }
 
+// SetFilter applies the [calls] settings of config.toml to the calls
+// extracted from now on
+func (c *CallExtractor) SetFilter(filter *CallFilter) {
+	c.filter = filter
+}
+
 // ExtractCalls extracts call relationships from a file using tree-sitter
 func (c *CallExtractor) ExtractCalls(ctx context.Context, file FileInfo) (int, error) {
-	lang := c.getLanguage(file.Language)
+	lang := c.getLanguage(grammarFor(file))
 	if lang == nil {
 		return 0, nil // Language not supported
 	}
@@ -53,6 +76,18 @@ func (c *CallExtractor) ExtractCalls(ctx context.Context, file FileInfo) (int, e
 	}
 	defer tree.Close()
 
+	c.fileSymbols, _ = c.db.GetFileSymbols(file.Path)
+	defer func() { c.fileSymbols = nil }()
+	c.aliases = c.importedAliases(file.Path)
+	defer func() { c.aliases = nil }()
+	c.pkg = filePackage(file, content)
+	defer func() { c.pkg = "" }()
+	if file.Language == "go" {
+		c.goImports = goImportNames(tree.RootNode(), content)
+		defer func() { c.goImports = nil }()
+	}
+	defer func() { c.external = nil }()
+
 	// Extract all function/method calls
 	calls := c.extractCalls(tree.RootNode(), content, file)
 
@@ -65,10 +100,34 @@ func (c *CallExtractor) ExtractCalls(ctx context.Context, file FileInfo) (int, e
 		}
 		count++
 	}
+	// Kept apart, so the call graph only links indexed symbols
+	for _, call := range c.external {
+		if err := c.db.InsertExternalCall(call); err != nil {
+			return count, fmt.Errorf("failed to insert external call: %w", err)
+		}
+	}
 
 	return count, nil
 }
 
+// addExternalCall records a call by callerID to calleeName that resolved
+// to no indexed symbol
+func (c *CallExtractor) addExternalCall(n *sitter.Node, content []byte, file FileInfo, callerID, calleeName string) {
+	call := &db.ExternalCall{
+		CallerID:   callerID,
+		CalleeName: calleeName,
+		File:       file.Path,
+		Line:       int(n.StartPoint().Row) + 1,
+		Column:     int(n.StartPoint().Column),
+	}
+	call.ArgCount, call.Args = callArguments(n, content)
+	call.Context = callContext(n, file.Language)
+	if file.Language == "go" {
+		call.Package = c.goCallPackage(n, content)
+	}
+	c.external = append(c.external, call)
+}
+
 // getLanguage returns the tree-sitter language
 func (c *CallExtractor) getLanguage(lang string) *sitter.Language {
 	switch lang {
@@ -76,8 +135,10 @@ func (c *CallExtractor) getLanguage(lang string) *sitter.Language {
 		return csharp.GetLanguage()
 	case "java":
 		return java.GetLanguage()
-	case "typescript", "typescriptreact", "javascript":
+	case "typescript", "typescriptreact":
 		return typescript.GetLanguage()
+	case "javascript":
+		return javascript.GetLanguage()
 	case "python":
 		return python.GetLanguage()
 	case "swift":
@@ -138,6 +199,7 @@ func (c *CallExtractor) extractCSharpCalls(node *sitter.Node, content []byte, fi
 			// Find the callee symbol in database
 			calleeID := c.resolveSymbolID(calleeName, file.Language)
 			if calleeID == "" {
+				c.addExternalCall(n, content, file, currentFunctionID, calleeName)
 				return
 			}
 
@@ -148,6 +210,8 @@ func (c *CallExtractor) extractCSharpCalls(node *sitter.Node, content []byte, fi
 				Line:     int(n.StartPoint().Row) + 1,
 				Column:   int(n.StartPoint().Column),
 			}
+			call.ArgCount, call.Args = callArguments(n, content)
+			call.Context = callContext(n, file.Language)
 			calls = append(calls, call)
 		}
 	})
@@ -169,6 +233,7 @@ func (c *CallExtractor) extractJavaCalls(node *sitter.Node, content []byte, file
 
 			calleeID := c.resolveSymbolID(calleeName, file.Language)
 			if calleeID == "" {
+				c.addExternalCall(n, content, file, enclosingFuncID, calleeName)
 				return
 			}
 
@@ -179,6 +244,8 @@ func (c *CallExtractor) extractJavaCalls(node *sitter.Node, content []byte, file
 				Line:     int(n.StartPoint().Row) + 1,
 				Column:   int(n.StartPoint().Column),
 			}
+			call.ArgCount, call.Args = callArguments(n, content)
+			call.Context = callContext(n, file.Language)
 			calls = append(calls, call)
 		}
 	})
@@ -199,6 +266,7 @@ func (c *CallExtractor) extractTypeScriptCalls(node *sitter.Node, content []byte
 
 			calleeID := c.resolveSymbolID(calleeName, file.Language)
 			if calleeID == "" {
+				c.addExternalCall(n, content, file, enclosingFuncID, calleeName)
 				return
 			}
 
@@ -209,6 +277,8 @@ func (c *CallExtractor) extractTypeScriptCalls(node *sitter.Node, content []byte
 				Line:     int(n.StartPoint().Row) + 1,
 				Column:   int(n.StartPoint().Column),
 			}
+			call.ArgCount, call.Args = callArguments(n, content)
+			call.Context = callContext(n, file.Language)
 			calls = append(calls, call)
 		}
 	})
@@ -229,6 +299,7 @@ func (c *CallExtractor) extractPythonCalls(node *sitter.Node, content []byte, fi
 
 			calleeID := c.resolveSymbolID(calleeName, file.Language)
 			if calleeID == "" {
+				c.addExternalCall(n, content, file, enclosingFuncID, calleeName)
 				return
 			}
 
@@ -239,6 +310,8 @@ func (c *CallExtractor) extractPythonCalls(node *sitter.Node, content []byte, fi
 				Line:     int(n.StartPoint().Row) + 1,
 				Column:   int(n.StartPoint().Column),
 			}
+			call.ArgCount, call.Args = callArguments(n, content)
+			call.Context = callContext(n, file.Language)
 			calls = append(calls, call)
 		}
 	})
@@ -257,8 +330,9 @@ func (c *CallExtractor) extractGoCalls(node *sitter.Node, content []byte, file F
 				return
 			}
 
-			calleeID := c.resolveSymbolID(calleeName, file.Language)
+			calleeID := c.resolvePackageSymbolID(calleeName, file.Language, c.goCallPackage(n, content))
 			if calleeID == "" {
+				c.addExternalCall(n, content, file, enclosingFuncID, calleeName)
 				return
 			}
 
@@ -269,6 +343,8 @@ func (c *CallExtractor) extractGoCalls(node *sitter.Node, content []byte, file F
 				Line:     int(n.StartPoint().Row) + 1,
 				Column:   int(n.StartPoint().Column),
 			}
+			call.ArgCount, call.Args = callArguments(n, content)
+			call.Context = callContext(n, file.Language)
 			calls = append(calls, call)
 		}
 	})
@@ -289,6 +365,7 @@ func (c *CallExtractor) extractRustCalls(node *sitter.Node, content []byte, file
 
 			calleeID := c.resolveSymbolID(calleeName, file.Language)
 			if calleeID == "" {
+				c.addExternalCall(n, content, file, enclosingFuncID, calleeName)
 				return
 			}
 
@@ -299,6 +376,8 @@ func (c *CallExtractor) extractRustCalls(node *sitter.Node, content []byte, file
 				Line:     int(n.StartPoint().Row) + 1,
 				Column:   int(n.StartPoint().Column),
 			}
+			call.ArgCount, call.Args = callArguments(n, content)
+			call.Context = callContext(n, file.Language)
 			calls = append(calls, call)
 		}
 	})
@@ -319,6 +398,7 @@ func (c *CallExtractor) extractSwiftCalls(node *sitter.Node, content []byte, fil
 
 			calleeID := c.resolveSymbolID(calleeName, file.Language)
 			if calleeID == "" {
+				c.addExternalCall(n, content, file, enclosingFuncID, calleeName)
 				return
 			}
 
@@ -329,6 +409,8 @@ func (c *CallExtractor) extractSwiftCalls(node *sitter.Node, content []byte, fil
 				Line:     int(n.StartPoint().Row) + 1,
 				Column:   int(n.StartPoint().Column),
 			}
+			call.ArgCount, call.Args = callArguments(n, content)
+			call.Context = callContext(n, file.Language)
 			calls = append(calls, call)
 		}
 	})
@@ -336,6 +418,65 @@ func (c *CallExtractor) extractSwiftCalls(node *sitter.Node, content []byte, fil
 	return calls
 }
 
+// callArguments returns the number of arguments passed at a call site and
+// their source text, or nil when the call node has no argument list
+func callArguments(n *sitter.Node, content []byte) (*int, string) {
+	// OCaml applies arguments by juxtaposition: f x y
+	if n.Type() == "application_expression" {
+		var args []string
+		for i := 0; i < int(n.ChildCount()); i++ {
+			if n.FieldNameForChild(i) == "argument" {
+				args = append(args, n.Child(i).Content(content))
+			}
+		}
+		count := len(args)
+		return &count, compactArgs(strings.Join(args, " "))
+	}
+
+	args := n.ChildByFieldName("arguments")
+	if args == nil {
+		// Swift: call_expression > call_suffix > value_arguments
+		for i := 0; i < int(n.NamedChildCount()) && args == nil; i++ {
+			if suffix := n.NamedChild(i); suffix.Type() == "call_suffix" {
+				for j := 0; j < int(suffix.NamedChildCount()); j++ {
+					if suffix.NamedChild(j).Type() == "value_arguments" {
+						args = suffix.NamedChild(j)
+						break
+					}
+				}
+			}
+		}
+	}
+	if args == nil {
+		return nil, ""
+	}
+
+	count := 0
+	if args.Type() == "generator_expression" {
+		count = 1 // Python: f(x for x in xs)
+	} else {
+		for i := 0; i < int(args.NamedChildCount()); i++ {
+			if args.NamedChild(i).Type() != "comment" {
+				count++
+			}
+		}
+	}
+	return &count, compactArgs(args.Content(content))
+}
+
+// maxArgsText caps the argument text stored on a call edge
+const maxArgsText = 120
+
+// compactArgs collapses whitespace in argument text onto one line and caps
+// its length
+func compactArgs(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if runes := []rune(text); len(runes) > maxArgsText {
+		text = string(runes[:maxArgsText-1]) + "…"
+	}
+	return text
+}
+
 // walkTreeWithContext walks the tree tracking the enclosing function
 func (c *CallExtractor) walkTreeWithContext(node *sitter.Node, content []byte, file FileInfo, callback func(*sitter.Node, string, string)) {
 	c.walkWithEnclosing(node, content, file, "", "", callback)
@@ -346,7 +487,7 @@ func (c *CallExtractor) walkWithEnclosing(node *sitter.Node, content []byte, fil
 	newFunc, newFuncID := c.getFunctionName(node, content, file)
 	if newFunc != "" {
 		enclosingFunc = newFunc
-		enclosingFuncID = newFuncID
+		enclosingFuncID = c.definitionID(node, newFunc, newFuncID)
 	}
 
 	callback(node, enclosingFunc, enclosingFuncID)
@@ -387,21 +528,41 @@ func (c *CallExtractor) getFunctionName(node *sitter.Node, content []byte, file
 			}
 		}
 	case "typescript", "typescriptreact", "javascript":
-		if node.Type() == "function_declaration" || node.Type() == "method_definition" {
+		if node.Type() == "function_declaration" || node.Type() == "generator_function_declaration" || node.Type() == "method_definition" {
 			nameNode := node.ChildByFieldName("name")
 			if nameNode != nil {
 				name := nameNode.Content(content)
 				return name, fmt.Sprintf("%s#%s", file.RelPath, name)
 			}
 		}
-	case "python":
-		if node.Type() == "function_definition" {
-			nameNode := node.ChildByFieldName("name")
-			if nameNode != nil {
-				name := nameNode.Content(content)
+		// Arrow functions and function expressions bound to a name
+		if node.Type() == "arrow_function" || node.Type() == "function_expression" || node.Type() == "function" {
+			if name, _ := boundFunctionName(node, content); name != "" {
 				return name, fmt.Sprintf("%s#%s", file.RelPath, name)
 			}
 		}
+	case "python":
+		// IDs follow the symbol extractor: qualified by the immediately
+		// enclosing class or function (Class.method, outer.inner)
+		name := ""
+		switch node.Type() {
+		case "function_definition":
+			if nameNode := node.ChildByFieldName("name"); nameNode != nil {
+				name = nameNode.Content(content)
+			}
+		case "lambda":
+			name = pythonLambdaName(node, content)
+		}
+		if name == "" {
+			break
+		}
+		fullName := name
+		for enclosing := pythonEnclosingDefinition(node); enclosing != nil; enclosing = pythonEnclosingDefinition(enclosing) {
+			if scopeNode := enclosing.ChildByFieldName("name"); scopeNode != nil {
+				fullName = scopeNode.Content(content) + "." + fullName
+			}
+		}
+		return fullName, fmt.Sprintf("%s#%s", file.RelPath, fullName)
 	case "go":
 		if node.Type() == "function_declaration" || node.Type() == "method_declaration" {
 			nameNode := node.ChildByFieldName("name")
@@ -415,6 +576,9 @@ func (c *CallExtractor) getFunctionName(node *sitter.Node, content []byte, file
 			nameNode := node.ChildByFieldName("name")
 			if nameNode != nil {
 				name := nameNode.Content(content)
+				if owner := rustMethodOwner(node, content); owner != "" {
+					name = owner + "::" + name
+				}
 				return name, fmt.Sprintf("%s#%s", file.RelPath, name)
 			}
 		}
@@ -431,14 +595,50 @@ func (c *CallExtractor) getFunctionName(node *sitter.Node, content []byte, file
 		if node.Type() == "let_binding" || node.Type() == "value_definition" {
 			patternNode := node.ChildByFieldName("pattern")
 			if patternNode != nil {
-				name := patternNode.Content(content)
-				return name, fmt.Sprintf("%s#%s", file.RelPath, name)
+				if name := ocamlValueName(patternNode, content); name != "" {
+					return name, fmt.Sprintf("%s#%s", file.RelPath, name)
+				}
 			}
 		}
 	}
 	return "", ""
 }
 
+// definitionID returns the stored ID of the symbol defined by node, found
+// among the file's indexed symbols by name and position, so callers carry
+// the scope chain and clash suffix the symbol was indexed with. fallbackID
+// is used when the symbol is not indexed.
+func (c *CallExtractor) definitionID(node *sitter.Node, name, fallbackID string) string {
+	if i := strings.LastIndexAny(name, ".:"); i >= 0 {
+		name = name[i+1:]
+	}
+	start := int(node.StartPoint().Row) + 1
+	end := int(node.EndPoint().Row) + 1
+
+	id, best := fallbackID, -1
+	for _, sym := range c.fileSymbols {
+		if sym.Name != name && !strings.HasPrefix(sym.Name, name+"(") {
+			continue
+		}
+		symEnd := sym.Line
+		if sym.EndLine != nil {
+			symEnd = *sym.EndLine
+		}
+		// Decorators and annotations can put either start first
+		if sym.Line > end || symEnd < start {
+			continue
+		}
+		distance := sym.Line - start
+		if distance < 0 {
+			distance = -distance
+		}
+		if best < 0 || distance < best {
+			id, best = sym.ID, distance
+		}
+	}
+	return id
+}
+
 // getEnclosingClassName finds the name of the enclosing class
 func (c *CallExtractor) getEnclosingClassName(node *sitter.Node, content []byte, language string) string {
 	parent := node.Parent()
@@ -473,20 +673,168 @@ func (c *CallExtractor) getEnclosingClassName(node *sitter.Node, content []byte,
 	return ""
 }
 
+// maxAliasHops bounds how many re-exports a callee name is followed through
+const maxAliasHops = 4
+
+// importedAliases returns the names a file imports under another name, by alias
+func (c *CallExtractor) importedAliases(path string) map[string]db.Alias {
+	imported, err := c.db.GetFileAliases(path)
+	if err != nil || len(imported) == 0 {
+		return nil
+	}
+	aliases := make(map[string]db.Alias, len(imported))
+	for _, a := range imported {
+		aliases[a.Alias] = a
+	}
+	return aliases
+}
+
 // resolveSymbolID looks up a symbol ID from the database
 func (c *CallExtractor) resolveSymbolID(name string, language string) string {
+	return c.resolvePackageSymbolID(name, language, "")
+}
+
+// resolvePackageSymbolID looks up a symbol ID from the database, preferring
+// a symbol of package pkg when one is given. A name the file imports under
+// an alias is looked up by its real name, and a name no symbol has is
+// followed through the exports that rename it (export { a as b }). Builtin
+// and ignored names, unless imported, only resolve to a symbol of the
+// calling file.
+func (c *CallExtractor) resolvePackageSymbolID(name, language, pkg string) string {
+	if a, ok := c.aliases[name]; ok {
+		name = a.Name
+		if pkg == "" && language == "python" {
+			pkg = a.Module // from a.b import f as g: f of module a.b
+		}
+	} else if c.filter.ignores(language, name) {
+		return c.localSymbolID(name)
+	}
+	for hop := 0; ; hop++ {
+		if id := c.lookupSymbolID(name, language, pkg); id != "" {
+			return id
+		}
+		if hop == maxAliasHops {
+			return ""
+		}
+		exported, err := c.db.GetExportedAliases(name)
+		if err != nil || len(exported) == 0 {
+			return ""
+		}
+		name = exported[0].Name
+	}
+}
+
+// lookupSymbolID returns the ID of a symbol named name, preferring one in
+// language and then one in package pkg, within the bounds of the filter's
+// resolution mode
+func (c *CallExtractor) lookupSymbolID(name, language, pkg string) string {
+	mode := c.filter.mode()
 	// Try to find the symbol in the database
 	symbols, err := c.db.GetSymbolByName(name, []string{language})
-	if err != nil || len(symbols) == 0 {
+	if (err != nil || len(symbols) == 0) && mode == config.ResolveAny {
 		// Try without language filter
 		symbols, err = c.db.GetSymbolByName(name, nil)
-		if err != nil || len(symbols) == 0 {
-			return ""
+	}
+	if err != nil || len(symbols) == 0 {
+		return ""
+	}
+	// The call's qualifier picks among same-named symbols; in package mode
+	// the caller's own package does too
+	preferred := []string{pkg}
+	if mode == config.ResolvePackage {
+		preferred = append(preferred, c.pkg)
+	}
+	for _, want := range preferred {
+		if want == "" {
+			continue
 		}
+		for _, sym := range symbols {
+			if sym.Package == want {
+				return sym.ID
+			}
+		}
+	}
+	if mode == config.ResolvePackage {
+		return ""
 	}
 	return symbols[0].ID
 }
 
+// localSymbolID returns the ID of the symbol named name in the file being
+// extracted, if it defines one
+func (c *CallExtractor) localSymbolID(name string) string {
+	for _, sym := range c.fileSymbols {
+		if sym.Name == name {
+			return sym.ID
+		}
+	}
+	return ""
+}
+
+// goCallPackage returns the package a Go call is qualified with: db for
+// db.Open() when the file imports a db package, and mathutil for m.Add()
+// when it imports m "example.com/mathutil". Method calls have none.
+func (c *CallExtractor) goCallPackage(node *sitter.Node, content []byte) string {
+	funcNode := node.ChildByFieldName("function")
+	if funcNode == nil || funcNode.Type() != "selector_expression" {
+		return ""
+	}
+	operand := funcNode.ChildByFieldName("operand")
+	if operand == nil || operand.Type() != "identifier" {
+		return ""
+	}
+	return c.goImports[operand.Content(content)]
+}
+
+// goImportNames maps the names a Go file's imports are used by to their
+// package names: m to mathutil for import m "example.com/mathutil", toml to
+// toml for "github.com/pelletier/go-toml/v2". Dot and blank imports bind no
+// name.
+func goImportNames(root *sitter.Node, content []byte) map[string]string {
+	imports := make(map[string]string)
+	walkNodes(root, func(n *sitter.Node) {
+		if n.Type() != "import_spec" {
+			return
+		}
+		pathNode := n.ChildByFieldName("path")
+		if pathNode == nil {
+			return
+		}
+		importPath, err := strconv.Unquote(pathNode.Content(content))
+		if err != nil {
+			return
+		}
+		pkg := goImportName(importPath)
+		name := pkg
+		if nameNode := n.ChildByFieldName("name"); nameNode != nil {
+			if nameNode.Type() != "package_identifier" {
+				return
+			}
+			name = nameNode.Content(content)
+		}
+		imports[name] = pkg
+	})
+	return imports
+}
+
+// goMajorVersion matches the major version suffix of a module path
+var goMajorVersion = regexp.MustCompile(`^v[0-9]+$`)
+
+// goImportName guesses the package name of an import path the usual way:
+// its last element, without a major version (/v2, gopkg.in's .v3) or a go-
+// prefix or -go suffix
+func goImportName(importPath string) string {
+	name := path.Base(importPath)
+	if goMajorVersion.MatchString(name) && path.Dir(importPath) != "." {
+		name = path.Base(path.Dir(importPath))
+	}
+	if i := strings.LastIndex(name, ".v"); i > 0 && goMajorVersion.MatchString(name[i+1:]) {
+		name = name[:i]
+	}
+	name = strings.TrimSuffix(strings.TrimPrefix(name, "go-"), "-go")
+	return strings.ReplaceAll(name, "-", "")
+}
+
 // Language-specific callee name extractors
 
 func (c *CallExtractor) getCSharpCalleeName(node *sitter.Node, content []byte) string {
@@ -622,6 +970,7 @@ func (c *CallExtractor) extractOCamlCalls(node *sitter.Node, content []byte, fil
 
 			calleeID := c.resolveSymbolID(calleeName, file.Language)
 			if calleeID == "" {
+				c.addExternalCall(n, content, file, enclosingFuncID, calleeName)
 				return
 			}
 
@@ -632,6 +981,8 @@ func (c *CallExtractor) extractOCamlCalls(node *sitter.Node, content []byte, fil
 				Line:     int(n.StartPoint().Row) + 1,
 				Column:   int(n.StartPoint().Column),
 			}
+			call.ArgCount, call.Args = callArguments(n, content)
+			call.Context = callContext(n, file.Language)
 			calls = append(calls, call)
 		}
 	})
@@ -654,9 +1005,8 @@ func (c *CallExtractor) getOCamlCalleeName(node *sitter.Node, content []byte) st
 	switch funcNode.Type() {
 	case "value_path":
 		// Module.func - get the last part (the actual function name)
-		if funcNode.NamedChildCount() > 0 {
-			lastPart := funcNode.NamedChild(int(funcNode.NamedChildCount()) - 1)
-			return lastPart.Content(content)
+		if name := ocamlValueName(funcNode, content); name != "" {
+			return name
 		}
 		return funcNode.Content(content)
 	case "value_name":
@@ -38,7 +38,7 @@ func (a *BaseAdapter) NormalizeSymbol(sym *lsp.DocumentSymbol) *lsp.DocumentSymb
 }
 
 func (a *BaseAdapter) FileURI(path string) string {
-	return "file://" + path
+	return lsp.PathToURI(path)
 }
 
 // LanguageFromExtension returns the language for a file extension
@@ -68,6 +68,8 @@ func LanguageFromExtension(ext string) string {
 		return "cpp"
 	case ".cs":
 		return "csharp"
+	case ".proto":
+		return "proto"
 	default:
 		return ""
 	}
@@ -87,5 +89,6 @@ func SupportedExtensions() []string {
 		".c", ".h",
 		".cpp", ".hpp", ".cc", ".cxx", ".hh",
 		".cs",
+		".proto",
 	}
 }
@@ -35,8 +35,5 @@ func (a *GoAdapter) NormalizeSymbol(sym *lsp.DocumentSymbol) *lsp.DocumentSymbol
 // FileURI converts a file path to a URI for gopls
 func (a *GoAdapter) FileURI(path string) string {
 	// gopls expects file:// URIs
-	if !strings.HasPrefix(path, "/") {
-		path = "/" + path
-	}
-	return "file://" + path
+	return lsp.PathToURI(path)
 }
@@ -6,12 +6,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"os/exec"
 	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 // Client is a JSON-RPC 2.0 client for LSP communication
@@ -25,9 +27,16 @@ type Client struct {
 	nextID      int64
 	pending     map[int64]chan *Response
 	initialized bool
+	// Capabilities are what the server advertised when initialized
+	Capabilities ServerCapabilities
+	// paths translates file URIs for a server running in a container
+	paths *PathMapping
 	
 	Language string
 	RootURI  string
+	// WorkspaceFolders lists the roots of a multi-root workspace. When
+	// empty, RootURI is reported as the only folder.
+	WorkspaceFolders []WorkspaceFolder
 }
 
 // Request represents a JSON-RPC 2.0 request
@@ -68,7 +77,20 @@ func (e *ResponseError) Error() string {
 
 // NewClient creates a new LSP client
 func NewClient(command string, args []string, rootURI, language string) (*Client, error) {
+	return newProcessClient(command, args, rootURI, language, nil)
+}
+
+// newProcessClient starts the server command and connects a client to its
+// stdio, translating file URIs with paths when set
+func newProcessClient(command string, args []string, rootURI, language string, paths *PathMapping) (*Client, error) {
 	cmd := exec.Command(command, args...)
+	// Run the server from its root so relative paths in its own config
+	// (tsconfig.json, Cargo.toml, ...) resolve per workspace
+	if dir := projectRootFromURI(rootURI); dir != "" {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			cmd.Dir = dir
+		}
+	}
 	
 	// Use filtered writer for all LSP servers to suppress noisy stderr
 	cmd.Stderr = &filteredWriter{
@@ -99,6 +121,30 @@ func NewClient(command string, args []string, rootURI, language string) (*Client
 		stdout:   stdout,
 		reader:   bufio.NewReader(stdout),
 		pending:  make(map[int64]chan *Response),
+		paths:    paths,
+		Language: language,
+		RootURI:  rootURI,
+	}
+
+	// Start response reader goroutine
+	go client.readResponses()
+
+	return client, nil
+}
+
+// DialClient creates an LSP client connected to a server already listening
+// on address, over network "tcp" or "unix"
+func DialClient(network, address, rootURI, language string) (*Client, error) {
+	conn, err := net.DialTimeout(network, address, 10*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to LSP server at %s: %w", address, err)
+	}
+
+	client := &Client{
+		stdin:    conn,
+		stdout:   conn,
+		reader:   bufio.NewReader(conn),
+		pending:  make(map[int64]chan *Response),
 		Language: language,
 		RootURI:  rootURI,
 	}
@@ -174,9 +220,15 @@ func (f *filteredWriter) Write(p []byte) (n int, err error) {
 // Initialize sends the initialize request to the LSP server
 func (c *Client) Initialize(ctx context.Context) (*InitializeResult, error) {
 	params := InitializeParams{
-		ProcessID:    os.Getpid(),
-		RootURI:      c.RootURI,
-		Capabilities: DefaultClientCapabilities(),
+		RootURI:          c.RootURI,
+		Capabilities:     DefaultClientCapabilities(),
+		WorkspaceFolders: c.WorkspaceFolders,
+	}
+	// A server we connected to outlives us, so it must not exit with our
+	// process
+	if c.cmd != nil {
+		pid := os.Getpid()
+		params.ProcessID = &pid
 	}
 
 	var result InitializeResult
@@ -190,11 +242,17 @@ func (c *Client) Initialize(ctx context.Context) (*InitializeResult, error) {
 	}
 
 	c.initialized = true
+	c.Capabilities = result.Capabilities
 	return &result, nil
 }
 
-// Shutdown sends shutdown request and exit notification
+// Shutdown sends shutdown request and exit notification. A server connected
+// to over a socket is left running; only the connection is closed.
 func (c *Client) Shutdown(ctx context.Context) error {
+	if c.cmd == nil {
+		c.stdin.Close()
+		return nil
+	}
 	if !c.initialized {
 		return nil
 	}
@@ -208,10 +266,22 @@ func (c *Client) Shutdown(ctx context.Context) error {
 	// Send exit notification
 	c.Notify("exit", nil)
 
-	// Close pipes and wait for process
+	// Close pipes and wait for process, killing it if it does not exit in
+	// time so an interrupted build leaves no server behind
 	c.stdin.Close()
 	c.stdout.Close()
-	c.cmd.Wait()
+	exited := make(chan struct{})
+	go func() {
+		c.cmd.Wait()
+		close(exited)
+	}()
+	select {
+	case <-exited:
+	case <-ctx.Done():
+		c.cmd.Process.Kill()
+		<-exited
+		return fmt.Errorf("server did not exit, killed it: %w", ctx.Err())
+	}
 
 	return nil
 }
@@ -275,6 +345,7 @@ func (c *Client) send(req Request) error {
 	if err != nil {
 		return fmt.Errorf("failed to marshal request: %w", err)
 	}
+	data = c.paths.toServer(data)
 
 	header := fmt.Sprintf("Content-Length: %d\r\n\r\n", len(data))
 	
@@ -321,6 +392,8 @@ func (c *Client) readResponses() {
 			return
 		}
 
+		body = c.paths.toClient(body)
+
 		var message wireMessage
 		if err := json.Unmarshal(body, &message); err != nil {
 			continue
@@ -356,7 +429,11 @@ func (c *Client) respondToServerRequest(id json.RawMessage, method string) error
 	case "workspace/configuration":
 		result = []any{}
 	case "workspace/workspaceFolders":
-		result = []map[string]string{{"uri": c.RootURI, "name": "workspace"}}
+		if len(c.WorkspaceFolders) > 0 {
+			result = c.WorkspaceFolders
+		} else {
+			result = []WorkspaceFolder{{URI: c.RootURI, Name: "workspace"}}
+		}
 	default:
 		responseErr = &ResponseError{Code: -32601, Message: "method not found"}
 	}
@@ -371,6 +448,7 @@ func (c *Client) respondToServerRequest(id json.RawMessage, method string) error
 	if err != nil {
 		return err
 	}
+	data = c.paths.toServer(data)
 	header := fmt.Sprintf("Content-Length: %d\r\n\r\n", len(data))
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -412,6 +490,35 @@ func (c *Client) DidOpenTextDocument(uri string, languageID string, content stri
 	return c.Notify("textDocument/didOpen", params)
 }
 
+// DidChangeTextDocument notifies the server that an opened file now holds
+// content, sent in full; version must increase with every change
+func (c *Client) DidChangeTextDocument(uri string, version int, content string) error {
+	params := struct {
+		TextDocument struct {
+			URI     string `json:"uri"`
+			Version int    `json:"version"`
+		} `json:"textDocument"`
+		ContentChanges []struct {
+			Text string `json:"text"`
+		} `json:"contentChanges"`
+	}{}
+	params.TextDocument.URI = uri
+	params.TextDocument.Version = version
+	params.ContentChanges = []struct {
+		Text string `json:"text"`
+	}{{Text: content}}
+
+	return c.Notify("textDocument/didChange", params)
+}
+
+// DidChangeWatchedFiles notifies the server that files changed on disk
+func (c *Client) DidChangeWatchedFiles(changes []FileEvent) error {
+	params := struct {
+		Changes []FileEvent `json:"changes"`
+	}{Changes: changes}
+	return c.Notify("workspace/didChangeWatchedFiles", params)
+}
+
 // DidCloseTextDocument notifies the server that a file has been closed
 func (c *Client) DidCloseTextDocument(uri string) error {
 	params := struct {
@@ -519,6 +626,21 @@ func (c *Client) Implementation(ctx context.Context, uri string, pos Position) (
 	return result, nil
 }
 
+// Hover requests hover information for the symbol at a position. A nil
+// result means the server had nothing to show.
+func (c *Client) Hover(ctx context.Context, uri string, pos Position) (*Hover, error) {
+	params := HoverParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+		Position:     pos,
+	}
+
+	var result *Hover
+	if err := c.Call(ctx, "textDocument/hover", params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
 // References finds all references to a symbol at a position
 func (c *Client) References(ctx context.Context, uri string, pos Position, includeDeclaration bool) ([]Location, error) {
 	params := struct {
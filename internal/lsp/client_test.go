@@ -2,9 +2,11 @@ package lsp
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"strings"
 	"testing"
 	"time"
@@ -74,3 +76,72 @@ func readTestLSPMessage(reader io.Reader) ([]byte, error) {
 	_, err := io.ReadFull(buffered, body)
 	return body, err
 }
+
+func TestDialClientTalksToRunningServer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	received := make(chan []byte, 1)
+	closed := make(chan struct{})
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		request, err := readTestLSPMessage(reader)
+		if err != nil {
+			return
+		}
+		received <- request
+		response := []byte(`{"jsonrpc":"2.0","id":1,"result":{"capabilities":{}}}`)
+		fmt.Fprintf(conn, "Content-Length: %d\r\n\r\n%s", len(response), response)
+		// The client's initialized notification, then EOF without exit
+		for {
+			message, err := readTestLSPMessage(reader)
+			if err != nil {
+				close(closed)
+				return
+			}
+			if strings.Contains(string(message), `"exit"`) {
+				return
+			}
+		}
+	}()
+
+	client, err := DialClient("tcp", listener.Addr().String(), "file:///project", "go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := client.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var params struct {
+		Params struct {
+			ProcessID *int   `json:"processId"`
+			RootURI   string `json:"rootUri"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(<-received, &params); err != nil {
+		t.Fatal(err)
+	}
+	if params.Params.ProcessID != nil || params.Params.RootURI != "file:///project" {
+		t.Fatalf("initialize params = %+v", params.Params)
+	}
+
+	if err := client.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("shutdown did not just close the connection")
+	}
+}
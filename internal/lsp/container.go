@@ -0,0 +1,95 @@
+package lsp
+
+import (
+	"bytes"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/tk-425/Codegraph/internal/config"
+)
+
+// PathMapping translates file URIs between a host directory and the path it
+// is mounted at inside a container
+type PathMapping struct {
+	hostURI      []byte // `"file:///home/me/project`
+	containerURI []byte // `"file:///workspace`
+}
+
+// NewPathMapping maps the host directory hostDir to containerDir
+func NewPathMapping(hostDir, containerDir string) *PathMapping {
+	return &PathMapping{
+		hostURI:      []byte(`"` + PathToURI(filepath.Clean(hostDir))),
+		containerURI: []byte(`"` + pathToURI(path.Clean(containerDir), false)),
+	}
+}
+
+// toServer rewrites host URIs in a message to container URIs
+func (p *PathMapping) toServer(data []byte) []byte {
+	if p == nil {
+		return data
+	}
+	return replaceURIPrefix(data, p.hostURI, p.containerURI)
+}
+
+// toClient rewrites container URIs in a message to host URIs
+func (p *PathMapping) toClient(data []byte) []byte {
+	if p == nil {
+		return data
+	}
+	return replaceURIPrefix(data, p.containerURI, p.hostURI)
+}
+
+// replaceURIPrefix replaces the JSON string prefix from with to where it is
+// the whole string or followed by a path separator, so "/workspace" does
+// not match "/workspace2"
+func replaceURIPrefix(data, from, to []byte) []byte {
+	if !bytes.Contains(data, from) {
+		return data
+	}
+	var out bytes.Buffer
+	for {
+		i := bytes.Index(data, from)
+		if i < 0 {
+			out.Write(data)
+			return out.Bytes()
+		}
+		end := i + len(from)
+		out.Write(data[:i])
+		if end < len(data) && (data[end] == '/' || data[end] == '"') {
+			out.Write(to)
+		} else {
+			out.Write(from)
+		}
+		data = data[end:]
+	}
+}
+
+// NewContainerClient creates an LSP client for command run by the container
+// runtime in cfg, with projectRoot mounted and serverDir (inside it) as the
+// working directory. File URIs are translated between host and container
+// paths in both directions.
+func NewContainerClient(cfg config.ContainerConfig, command string, args []string, projectRoot, serverDir, rootURI, language string) (*Client, error) {
+	runtime, runArgs := ContainerCommand(cfg, command, args, projectRoot, serverDir)
+	return newProcessClient(runtime, runArgs, rootURI, language, NewPathMapping(projectRoot, cfg.MountPath()))
+}
+
+// ContainerCommand returns the runtime command line that runs command in a
+// container with projectRoot mounted at the configured path, starting in
+// the container path of serverDir
+func ContainerCommand(cfg config.ContainerConfig, command string, args []string, projectRoot, serverDir string) (string, []string) {
+	mount := cfg.MountPath()
+	workdir := mount
+	if rel, err := filepath.Rel(projectRoot, serverDir); err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		workdir = path.Join(mount, filepath.ToSlash(rel))
+	}
+
+	runArgs := []string{"run", "--rm", "-i", "--init",
+		"-v", projectRoot + ":" + mount,
+		"-w", workdir,
+	}
+	runArgs = append(runArgs, cfg.Args...)
+	runArgs = append(runArgs, cfg.Image, command)
+	runArgs = append(runArgs, args...)
+	return cfg.RuntimeCommand(), runArgs
+}
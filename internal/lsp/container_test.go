@@ -0,0 +1,49 @@
+package lsp
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tk-425/Codegraph/internal/config"
+)
+
+func TestContainerCommandMountsProjectAndStartsInServerRoot(t *testing.T) {
+	root := filepath.Join(string(filepath.Separator), "home", "me", "project")
+	cfg := config.ContainerConfig{Image: "rust:1.80", Args: []string{"--network=none"}}
+	runtime, args := ContainerCommand(cfg, "rust-analyzer", nil, root, filepath.Join(root, "crates", "core"))
+	want := "run --rm -i --init -v " + root + ":/workspace -w /workspace/crates/core --network=none rust:1.80 rust-analyzer"
+	if runtime != "docker" || strings.Join(args, " ") != want {
+		t.Fatalf("command = %s %s", runtime, strings.Join(args, " "))
+	}
+
+	cfg = config.ContainerConfig{Image: "node:22", Runtime: "podman", Mount: "/src/"}
+	runtime, args = ContainerCommand(cfg, "typescript-language-server", []string{"--stdio"}, root, root)
+	want = "run --rm -i --init -v " + root + ":/src -w /src node:22 typescript-language-server --stdio"
+	if runtime != "podman" || strings.Join(args, " ") != want {
+		t.Fatalf("command = %s %s", runtime, strings.Join(args, " "))
+	}
+}
+
+func TestPathMappingTranslatesURIsBothWays(t *testing.T) {
+	root := filepath.Join(string(filepath.Separator), "home", "me", "project")
+	paths := NewPathMapping(root, "/workspace")
+	hostURI := PathToURI(filepath.Join(root, "src", "main.rs"))
+
+	out := string(paths.toServer([]byte(`{"rootUri":"` + PathToURI(root) + `","uri":"` + hostURI + `","text":"see ` + hostURI + `"}`)))
+	want := `{"rootUri":"file:///workspace","uri":"file:///workspace/src/main.rs","text":"see ` + hostURI + `"}`
+	if out != want {
+		t.Errorf("toServer = %s, want %s", out, want)
+	}
+
+	in := string(paths.toClient([]byte(`[{"uri":"file:///workspace/src/lib.rs"},{"uri":"file:///workspace2/x.rs"},{"uri":"file:///usr/lib/std.rs"}]`)))
+	want = `[{"uri":"` + PathToURI(filepath.Join(root, "src", "lib.rs")) + `"},{"uri":"file:///workspace2/x.rs"},{"uri":"file:///usr/lib/std.rs"}]`
+	if in != want {
+		t.Errorf("toClient = %s, want %s", in, want)
+	}
+
+	var none *PathMapping
+	if got := string(none.toServer([]byte(`"` + hostURI + `"`))); got != `"`+hostURI+`"` {
+		t.Errorf("nil mapping changed %s", got)
+	}
+}
@@ -0,0 +1,140 @@
+package lsp
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Hover is the result of a textDocument/hover request. Contents is kept raw
+// because servers may answer with MarkupContent, a single MarkedString, or an
+// array of MarkedStrings.
+type Hover struct {
+	Contents json.RawMessage `json:"contents"`
+	Range    *Range          `json:"range,omitempty"`
+}
+
+// hoverPrefixes are labels some servers put in front of the declaration
+// (pyright: "(function) def foo(...)").
+var hoverPrefixes = []string{
+	"(function) ", "(method) ", "(class) ", "(variable) ", "(constant) ",
+	"(property) ", "(module) ", "(type) ", "(constructor) ",
+}
+
+// Markdown flattens the hover contents into a single markdown string.
+func (h *Hover) Markdown() string {
+	if h == nil || len(h.Contents) == 0 {
+		return ""
+	}
+	return markedToMarkdown(h.Contents)
+}
+
+// Signature returns the declaration shown in the hover, collapsed onto one
+// line. When several code blocks are present (rust-analyzer prints the module
+// path first), the block mentioning name wins.
+func (h *Hover) Signature(name string) string {
+	blocks, prose := splitHoverMarkdown(h.Markdown())
+	sig := ""
+	for _, block := range blocks {
+		if name != "" && strings.Contains(block, name) {
+			sig = block
+			break
+		}
+	}
+	if sig == "" && len(blocks) > 0 {
+		sig = blocks[0]
+	}
+	if sig == "" && prose != "" {
+		// Plaintext hovers put the declaration on the first line.
+		sig = getFirstLine(prose)
+	}
+
+	sig = strings.Join(strings.Fields(sig), " ")
+	for _, prefix := range hoverPrefixes {
+		sig = strings.TrimPrefix(sig, prefix)
+	}
+	return sig
+}
+
+// Documentation returns the prose portion of the hover (everything outside
+// code fences), with horizontal rules removed.
+func (h *Hover) Documentation() string {
+	blocks, prose := splitHoverMarkdown(h.Markdown())
+	if len(blocks) == 0 {
+		// Plaintext hover: first line is the declaration, the rest is docs.
+		if idx := strings.IndexByte(prose, '\n'); idx >= 0 {
+			return strings.TrimSpace(prose[idx+1:])
+		}
+		return ""
+	}
+	return prose
+}
+
+func markedToMarkdown(raw json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text
+	}
+
+	var list []json.RawMessage
+	if err := json.Unmarshal(raw, &list); err == nil {
+		parts := make([]string, 0, len(list))
+		for _, item := range list {
+			if part := markedToMarkdown(item); part != "" {
+				parts = append(parts, part)
+			}
+		}
+		return strings.Join(parts, "\n\n")
+	}
+
+	var obj struct {
+		Kind     string `json:"kind"`
+		Language string `json:"language"`
+		Value    string `json:"value"`
+	}
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return ""
+	}
+	if obj.Language != "" {
+		return "```" + obj.Language + "\n" + obj.Value + "\n```"
+	}
+	return obj.Value
+}
+
+// splitHoverMarkdown separates fenced code blocks from the surrounding prose.
+func splitHoverMarkdown(md string) ([]string, string) {
+	var blocks []string
+	var prose []string
+	var current []string
+	inBlock := false
+
+	for _, line := range strings.Split(md, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			if inBlock {
+				if block := strings.TrimSpace(strings.Join(current, "\n")); block != "" {
+					blocks = append(blocks, block)
+				}
+				current = nil
+			}
+			inBlock = !inBlock
+			continue
+		}
+		if inBlock {
+			current = append(current, line)
+			continue
+		}
+		if trimmed == "---" || trimmed == "***" {
+			continue
+		}
+		prose = append(prose, line)
+	}
+
+	return blocks, strings.TrimSpace(strings.Join(prose, "\n"))
+}
+
+func getFirstLine(s string) string {
+	if idx := strings.IndexByte(s, '\n'); idx >= 0 {
+		return s[:idx]
+	}
+	return s
+}
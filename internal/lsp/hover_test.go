@@ -0,0 +1,50 @@
+package lsp
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestHoverSignatureFromMarkupContent(t *testing.T) {
+	hover := &Hover{Contents: []byte(`{"kind":"markdown","value":"` + "```go\\nfunc Load(projectRoot string) (*Config, error)\\n```\\n\\nLoad loads the configuration." + `"}`)}
+	if got := hover.Signature("Load"); got != "func Load(projectRoot string) (*Config, error)" {
+		t.Fatalf("signature = %q", got)
+	}
+	if got := hover.Documentation(); got != "Load loads the configuration." {
+		t.Fatalf("documentation = %q", got)
+	}
+}
+
+func TestHoverSignaturePrefersBlockMentioningName(t *testing.T) {
+	hover := &Hover{Contents: []byte(`{"kind":"markdown","value":"` + "```rust\\ncalc::ops\\n```\\n\\n```rust\\npub fn add(a: i32,\\n    b: i32) -> i32\\n```" + `"}`)}
+	if got := hover.Signature("add"); got != "pub fn add(a: i32, b: i32) -> i32" {
+		t.Fatalf("signature = %q", got)
+	}
+}
+
+func TestHoverSignatureFromMarkedStringsStripsServerLabel(t *testing.T) {
+	hover := &Hover{Contents: []byte(`[{"language":"python","value":"(function) def greet(name: str) -> str"},"Say hello."]`)}
+	if got := hover.Signature("greet"); got != "def greet(name: str) -> str" {
+		t.Fatalf("signature = %q", got)
+	}
+	if got := hover.Documentation(); got != "Say hello." {
+		t.Fatalf("documentation = %q", got)
+	}
+}
+
+func TestServerCapabilitiesSupportsHover(t *testing.T) {
+	for capabilities, want := range map[string]bool{
+		`{}`:                      false,
+		`{"hoverProvider":false}`: false,
+		`{"hoverProvider":true}`:  true,
+		`{"hoverProvider":{"workDoneProgress":true}}`: true,
+	} {
+		var c ServerCapabilities
+		if err := json.Unmarshal([]byte(capabilities), &c); err != nil {
+			t.Fatal(err)
+		}
+		if got := c.SupportsHover(); got != want {
+			t.Errorf("SupportsHover() for %s = %v, want %v", capabilities, got, want)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package lsp
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/tk-425/Codegraph/internal/config"
+)
+
+// InstallPlan describes how to install the language server for one language.
+// Exactly one of Command, URL or Manual is set.
+type InstallPlan struct {
+	Language string
+	Server   string   // Binary the plan provides (gopls, pyright-langserver, ...)
+	Command  []string // Package-manager command to run
+	URL      string   // Gzipped release binary to download into the bin dir
+	Manual   string   // Instructions when the server cannot be installed automatically
+}
+
+// Description returns a one-line summary of what the plan will do
+func (p InstallPlan) Description() string {
+	switch {
+	case len(p.Command) > 0:
+		return strings.Join(p.Command, " ")
+	case p.URL != "":
+		return "download " + p.URL
+	default:
+		return p.Manual
+	}
+}
+
+// Automatic reports whether Run can install the server without user action
+func (p InstallPlan) Automatic() bool {
+	return len(p.Command) > 0 || p.URL != ""
+}
+
+// installRecipe builds the plan for one language. version is the pinned
+// version from config.toml, or "" for the latest release.
+type installRecipe func(version, goos, goarch string) InstallPlan
+
+var installRecipes = map[string]installRecipe{
+	"go": func(version, _, _ string) InstallPlan {
+		return InstallPlan{Server: "gopls", Command: []string{"go", "install", "golang.org/x/tools/gopls@" + orLatest(version)}}
+	},
+	"python": func(version, _, _ string) InstallPlan {
+		return InstallPlan{Server: "pyright-langserver", Command: []string{"npm", "install", "-g", "pyright@" + orLatest(version)}}
+	},
+	"typescript":      typeScriptRecipe,
+	"typescriptreact": typeScriptRecipe,
+	"rust": func(version, goos, goarch string) InstallPlan {
+		plan := InstallPlan{Server: "rust-analyzer"}
+		target := rustAnalyzerTarget(goos, goarch)
+		if target == "" {
+			plan.Manual = "rustup component add rust-analyzer"
+			return plan
+		}
+		release := "latest/download"
+		if version != "" {
+			release = "download/" + version
+		}
+		plan.URL = fmt.Sprintf("https://github.com/rust-lang/rust-analyzer/releases/%s/rust-analyzer-%s.gz", release, target)
+		return plan
+	},
+	"ocaml": func(version, _, _ string) InstallPlan {
+		pkg := "ocaml-lsp-server"
+		if version != "" {
+			pkg += "." + version
+		}
+		return InstallPlan{Server: "ocamllsp", Command: []string{"opam", "install", "-y", pkg}}
+	},
+	"csharp": func(version, _, _ string) InstallPlan {
+		cmd := []string{"dotnet", "tool", "install", "--global", "csharp-ls"}
+		if version != "" {
+			cmd = append(cmd, "--version", version)
+		}
+		return InstallPlan{Server: "csharp-ls", Command: cmd}
+	},
+	"java": func(string, string, string) InstallPlan {
+		return InstallPlan{Server: "jdtls", Manual: "install jdtls with your package manager (brew install jdtls) or see https://github.com/eclipse/eclipse.jdt.ls#installation"}
+	},
+	"swift": func(string, string, string) InstallPlan {
+		return InstallPlan{Server: "sourcekit-lsp", Manual: "sourcekit-lsp ships with Xcode and the Swift toolchain (https://www.swift.org/install)"}
+	},
+}
+
+func typeScriptRecipe(version, _, _ string) InstallPlan {
+	return InstallPlan{Server: "typescript-language-server", Command: []string{"npm", "install", "-g", "typescript-language-server@" + orLatest(version), "typescript"}}
+}
+
+func orLatest(version string) string {
+	if version == "" {
+		return "latest"
+	}
+	return version
+}
+
+// rustAnalyzerTarget returns the release target triple for a platform, or ""
+// when no gzipped binary is published for it
+func rustAnalyzerTarget(goos, goarch string) string {
+	arch := map[string]string{"amd64": "x86_64", "arm64": "aarch64"}[goarch]
+	if arch == "" {
+		return ""
+	}
+	switch goos {
+	case "linux":
+		return arch + "-unknown-linux-gnu"
+	case "darwin":
+		return arch + "-apple-darwin"
+	default:
+		return ""
+	}
+}
+
+// InstallableLanguages lists the languages PlanInstall knows about
+func InstallableLanguages() []string {
+	languages := make([]string, 0, len(installRecipes))
+	for lang := range installRecipes {
+		languages = append(languages, lang)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// PlanInstall returns how to install the server for language on this
+// platform, honouring the version pinned in cfg
+func PlanInstall(language string, cfg config.LSPConfig) (InstallPlan, error) {
+	return planInstall(language, cfg, runtime.GOOS, runtime.GOARCH)
+}
+
+func planInstall(language string, cfg config.LSPConfig, goos, goarch string) (InstallPlan, error) {
+	recipe, ok := installRecipes[language]
+	if !ok {
+		return InstallPlan{}, fmt.Errorf("no installer for language: %s (known: %s)", language, strings.Join(InstallableLanguages(), ", "))
+	}
+	plan := recipe(cfg.Version, goos, goarch)
+	plan.Language = language
+	return plan, nil
+}
+
+var (
+	runInstallCommand = func(ctx context.Context, out io.Writer, name string, args ...string) error {
+		cmd := exec.CommandContext(ctx, name, args...)
+		cmd.Stdout = out
+		cmd.Stderr = out
+		return cmd.Run()
+	}
+	installHTTPClient = http.DefaultClient
+)
+
+// Run installs the server. Release binaries are written to binDir, which is
+// created if needed. Command output is copied to out.
+func (p InstallPlan) Run(ctx context.Context, binDir string, out io.Writer) error {
+	switch {
+	case len(p.Command) > 0:
+		if _, err := exec.LookPath(p.Command[0]); err != nil {
+			return fmt.Errorf("%s is required to install %s: %w", p.Command[0], p.Server, err)
+		}
+		if err := runInstallCommand(ctx, out, p.Command[0], p.Command[1:]...); err != nil {
+			return fmt.Errorf("%s failed: %w", p.Description(), err)
+		}
+		return nil
+	case p.URL != "":
+		return p.download(ctx, binDir)
+	default:
+		return fmt.Errorf("%s must be installed manually: %s", p.Server, p.Manual)
+	}
+}
+
+// download fetches the gzipped binary at p.URL into binDir/p.Server
+func (p InstallPlan) download(ctx context.Context, binDir string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.URL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := installHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", p.Server, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download %s: %s returned %s", p.Server, p.URL, resp.Status)
+	}
+	gz, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to unpack %s: %w", p.Server, err)
+	}
+	defer gz.Close()
+
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", binDir, err)
+	}
+	// Write next to the target and rename so a failed download never
+	// leaves a truncated binary on PATH
+	tmp, err := os.CreateTemp(binDir, "."+p.Server+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, gz); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to unpack %s: %w", p.Server, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(binDir, p.Server))
+}
@@ -0,0 +1,74 @@
+package lsp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tk-425/Codegraph/internal/config"
+)
+
+func TestPlanInstallHonoursPinnedVersion(t *testing.T) {
+	plan, err := planInstall("go", config.LSPConfig{Version: "v0.16.2"}, "linux", "amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := plan.Description(); got != "go install golang.org/x/tools/gopls@v0.16.2" {
+		t.Fatalf("go plan = %q", got)
+	}
+
+	plan, _ = planInstall("python", config.LSPConfig{}, "linux", "amd64")
+	if got := plan.Description(); got != "npm install -g pyright@latest" {
+		t.Fatalf("python plan = %q", got)
+	}
+
+	plan, _ = planInstall("rust", config.LSPConfig{Version: "2024-06-03"}, "darwin", "arm64")
+	if plan.URL != "https://github.com/rust-lang/rust-analyzer/releases/download/2024-06-03/rust-analyzer-aarch64-apple-darwin.gz" {
+		t.Fatalf("rust url = %q", plan.URL)
+	}
+	plan, _ = planInstall("rust", config.LSPConfig{}, "windows", "amd64")
+	if plan.Automatic() || !strings.Contains(plan.Manual, "rustup") {
+		t.Fatalf("windows rust plan = %#v", plan)
+	}
+
+	if _, err := planInstall("cobol", config.LSPConfig{}, "linux", "amd64"); err == nil {
+		t.Fatal("expected an error for an unknown language")
+	}
+}
+
+func TestInstallPlanDownloadsGzippedBinary(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gz := gzip.NewWriter(w)
+		_, _ = io.WriteString(gz, "#!/bin/sh\necho rust-analyzer\n")
+		_ = gz.Close()
+	}))
+	defer server.Close()
+
+	binDir := filepath.Join(t.TempDir(), "bin")
+	plan := InstallPlan{Language: "rust", Server: "rust-analyzer", URL: server.URL}
+	if err := plan.Run(context.Background(), binDir, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	target := filepath.Join(binDir, "rust-analyzer")
+	data, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte("echo rust-analyzer")) {
+		t.Fatalf("binary = %q", data)
+	}
+	if info, _ := os.Stat(target); info.Mode().Perm()&0100 == 0 {
+		t.Fatalf("binary is not executable: %v", info.Mode())
+	}
+	entries, _ := os.ReadDir(binDir)
+	if len(entries) != 1 {
+		t.Fatalf("temporary files left behind: %v", entries)
+	}
+}
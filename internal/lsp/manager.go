@@ -3,6 +3,10 @@ package lsp
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,15 +17,23 @@ import (
 type Manager struct {
 	cfg     *config.Config
 	rootURI string
+	out     io.Writer // Receives shutdown warnings
 
 	mu      sync.Mutex
-	clients map[string]*Client // language -> client
+	clients map[string]*Client // clientKey -> client
+	// failed remembers sub-root servers that could not start, so files
+	// under them fall back to the root server without retrying each time
+	failed map[string]error
+	// subRoots caches the project directory found for each directory
+	subRoots map[string]string
 }
 
 const nativeTypeScriptMaxAttempts = 3
 
 var (
 	newLSPClient = NewClient
+	dialLSPClient = DialClient
+	newContainerLSPClient = NewContainerClient
 	initializeLSP = func(ctx context.Context, client *Client) error {
 		_, err := client.Initialize(ctx)
 		return err
@@ -36,17 +48,143 @@ var (
 // NewManager creates a new LSP manager
 func NewManager(cfg *config.Config, rootURI string) *Manager {
 	return &Manager{
-		cfg:     cfg,
-		rootURI: rootURI,
-		clients: make(map[string]*Client),
+		cfg:      cfg,
+		rootURI:  rootURI,
+		out:      os.Stdout,
+		clients:  make(map[string]*Client),
+		failed:   make(map[string]error),
+		subRoots: make(map[string]string),
 	}
 }
 
+// SetOutput sends the manager's warnings to w instead of standard output
+func (m *Manager) SetOutput(w io.Writer) {
+	m.out = w
+}
+
+// multiplexedLanguages run one server per project directory, the nearest
+// directory holding one of the marker files, since the server only
+// resolves a file accurately from its own tsconfig project
+var multiplexedLanguages = map[string][]string{
+	"typescript":      {"tsconfig.json", "jsconfig.json"},
+	"typescriptreact": {"tsconfig.json", "jsconfig.json"},
+	"javascript":      {"tsconfig.json", "jsconfig.json"},
+}
+
+// clientKey identifies the server of language started in subRoot (relative
+// to the project root); the root server's key is the language alone
+func clientKey(language, subRoot string) string {
+	if subRoot == "" {
+		return language
+	}
+	return language + "@" + subRoot
+}
+
+// clientLanguage returns the language of a clientKey
+func clientLanguage(key string) string {
+	if i := strings.IndexByte(key, '@'); i >= 0 {
+		return key[:i]
+	}
+	return key
+}
+
 // GetClient gets or creates an LSP client for a language
 func (m *Manager) GetClient(ctx context.Context, language string) (*Client, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	return m.getClient(ctx, language)
+}
+
+// GetClientForFile gets or creates the LSP client that should answer
+// requests about the file at path. For TypeScript and JavaScript in a
+// monorepo, that is a server started in the nearest directory below the
+// project root with a tsconfig.json (or jsconfig.json); other files, and
+// languages with a single server, get the language's root client. A
+// sub-root server that fails to start falls back to the root client too.
+func (m *Manager) GetClientForFile(ctx context.Context, language, path string) (*Client, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	subRoot := m.subRoot(language, path)
+	if subRoot == "" {
+		return m.getClient(ctx, language)
+	}
+	key := clientKey(language, subRoot)
+	if client, ok := m.clients[key]; ok {
+		return client, nil
+	}
+	if _, failed := m.failed[key]; failed {
+		return m.getClient(ctx, language)
+	}
+
+	lspConfig, ok := m.cfg.LSP[language]
+	if !ok {
+		return nil, fmt.Errorf("no LSP configuration for language: %s", language)
+	}
+	dir := filepath.Join(projectRootFromURI(m.rootURI), filepath.FromSlash(subRoot))
+	rootURI := PathToURI(dir)
+	if languageRoot, _, err := m.workspaceRoot(language, lspConfig); err == nil && languageRoot == rootURI {
+		// The project is a workspace the root server already starts in
+		return m.getClient(ctx, language)
+	}
+	folders := []WorkspaceFolder{{URI: rootURI, Name: filepath.Base(dir)}}
+	client, err := m.startClient(ctx, key, language, rootURI, folders)
+	if err != nil {
+		m.failed[key] = err
+		return m.getClient(ctx, language)
+	}
+	return client, nil
+}
+
+// subRoot returns the project directory, relative to the project root, of
+// a file of a multiplexed language, or "" when the file belongs to the
+// root server: other languages, a `root` override in the language's [lsp]
+// entry, and files whose nearest project directory is the root itself
+func (m *Manager) subRoot(language, path string) string {
+	markers, ok := multiplexedLanguages[language]
+	if !ok || m.cfg.LSP[language].Root != "" {
+		return ""
+	}
+	projectRoot := filepath.Clean(projectRootFromURI(m.rootURI))
+	dir := filepath.Dir(filepath.Clean(path))
+
+	var visited []string
+	subRoot := ""
+	for {
+		if cached, ok := m.subRoots[dir]; ok {
+			subRoot = cached
+			break
+		}
+		visited = append(visited, dir)
+		if dir == projectRoot || !strings.HasPrefix(dir, projectRoot+string(filepath.Separator)) {
+			break
+		}
+		if hasAnyFile(dir, markers) {
+			rel, _ := filepath.Rel(projectRoot, dir)
+			subRoot = filepath.ToSlash(rel)
+			break
+		}
+		dir = filepath.Dir(dir)
+	}
+	for _, d := range visited {
+		m.subRoots[d] = subRoot
+	}
+	return subRoot
+}
+
+func hasAnyFile(dir string, names []string) bool {
+	for _, name := range names {
+		if info, err := os.Stat(filepath.Join(dir, name)); err == nil && !info.IsDir() {
+			return true
+		}
+	}
+	return false
+}
+
+// getClient returns the root client of language, starting it if needed;
+// m.mu must be held
+func (m *Manager) getClient(ctx context.Context, language string) (*Client, error) {
 	// Return existing client if available
 	if client, ok := m.clients[language]; ok {
 		return client, nil
@@ -58,9 +196,33 @@ func (m *Manager) GetClient(ctx context.Context, language string) (*Client, erro
 	if !ok {
 		return nil, fmt.Errorf("no LSP configuration for language: %s", language)
 	}
+	rootURI, folders, err := m.workspaceRoot(language, lspConfig)
+	if err != nil {
+		return nil, err
+	}
+	return m.startClient(ctx, language, language, rootURI, folders)
+}
+
+// startClient starts and initializes the server of language in rootURI and
+// stores it under key; m.mu must be held
+func (m *Manager) startClient(ctx context.Context, key, language, rootURI string, folders []WorkspaceFolder) (*Client, error) {
+	lspConfig := m.cfg.LSP[language]
 	server := typeScriptServer{command: lspConfig.Command, args: lspConfig.Args}
-	if language == "typescript" || language == "typescriptreact" {
-		resolved, resolveErr := resolveTypeScriptServer(m.cfg, projectRootFromURI(m.rootURI), language)
+	start := func() (*Client, error) {
+		return newLSPClient(server.command, server.args, rootURI, language)
+	}
+	if lspConfig.IsSocket() {
+		start = func() (*Client, error) {
+			return dialLSPClient(lspConfig.TransportMode(), lspConfig.Address, rootURI, language)
+		}
+	} else if lspConfig.Container != nil {
+		// The configured command runs in the image, never a host-local one
+		start = func() (*Client, error) {
+			return newContainerLSPClient(*lspConfig.Container, lspConfig.Command, lspConfig.Args,
+				projectRootFromURI(m.rootURI), projectRootFromURI(rootURI), rootURI, language)
+		}
+	} else if language == "typescript" || language == "typescriptreact" {
+		resolved, resolveErr := resolveTypeScriptServer(m.cfg, projectRootFromURI(rootURI), language)
 		if resolveErr != nil {
 			return nil, resolveErr
 		}
@@ -73,14 +235,15 @@ func (m *Manager) GetClient(ctx context.Context, language string) (*Client, erro
 	}
 	var lastErr error
 	for attempt := 1; attempt <= attempts; attempt++ {
-		client, err := newLSPClient(server.command, server.args, m.rootURI, language)
+		client, err := start()
 		if err == nil {
+			client.WorkspaceFolders = folders
 			initCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 			err = initializeLSP(initCtx, client)
 			cancel()
 		}
 		if err == nil {
-			m.clients[language] = client
+			m.clients[key] = client
 			return client, nil
 		}
 
@@ -96,6 +259,48 @@ func (m *Manager) GetClient(ctx context.Context, language string) (*Client, erro
 	return nil, fmt.Errorf("failed to initialize LSP for %s: %w", language, lastErr)
 }
 
+// workspaceRoot returns the root URI and workspace folders a language server
+// is started with. Without [[workspaces]] in config.toml the project root is
+// the root and only folder. Otherwise every workspace indexing language
+// becomes a folder, and a single matching workspace also becomes the root.
+// A `root` set in the language's [lsp] entry overrides the root URI.
+func (m *Manager) workspaceRoot(language string, lspConfig config.LSPConfig) (string, []WorkspaceFolder, error) {
+	projectRoot := projectRootFromURI(m.rootURI)
+	rootURI := m.rootURI
+	var folders []WorkspaceFolder
+
+	if len(m.cfg.Workspaces) == 0 {
+		folders = []WorkspaceFolder{{URI: m.rootURI, Name: filepath.Base(projectRoot)}}
+	} else {
+		workspaces := m.cfg.WorkspacesForLanguage(language)
+		if len(workspaces) == 0 {
+			return "", nil, fmt.Errorf("no workspace in config.toml includes language: %s", language)
+		}
+		for _, ws := range workspaces {
+			dir := filepath.Join(projectRoot, filepath.FromSlash(ws.RelRoot()))
+			folders = append(folders, WorkspaceFolder{URI: PathToURI(dir), Name: ws.DisplayName()})
+		}
+		if len(folders) == 1 {
+			rootURI = folders[0].URI
+		}
+	}
+
+	if lspConfig.Root != "" {
+		dir := lspConfig.Root
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(projectRoot, filepath.FromSlash(dir))
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return "", nil, fmt.Errorf("lsp.%s.root %q is not a directory", language, lspConfig.Root)
+		}
+		rootURI = PathToURI(dir)
+		if len(m.cfg.Workspaces) == 0 {
+			folders = []WorkspaceFolder{{URI: rootURI, Name: filepath.Base(dir)}}
+		}
+	}
+	return rootURI, folders, nil
+}
+
 // ShutdownAll shuts down all LSP servers
 func cleanupFailedClient(client *Client) {
 	if client.initialized {
@@ -120,24 +325,32 @@ func (m *Manager) ShutdownAll() {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	for lang, client := range m.clients {
+	for key, client := range m.clients {
 		if err := client.Shutdown(ctx); err != nil {
-			fmt.Printf("Warning: failed to shutdown %s LSP: %v\n", lang, err)
+			fmt.Fprintf(m.out, "Warning: failed to shutdown %s LSP: %v\n", key, err)
 		}
 	}
 	m.clients = make(map[string]*Client)
+	m.failed = make(map[string]error)
 }
 
-// ShutdownLanguage shuts down a specific language server
+// ShutdownLanguage shuts down the servers of a specific language
 func (m *Manager) ShutdownLanguage(language string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if client, ok := m.clients[language]; ok {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		client.Shutdown(ctx)
-		delete(m.clients, language)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for key, client := range m.clients {
+		if clientLanguage(key) == language {
+			client.Shutdown(ctx)
+			delete(m.clients, key)
+		}
+	}
+	for key := range m.failed {
+		if clientLanguage(key) == language {
+			delete(m.failed, key)
+		}
 	}
 }
 
@@ -146,13 +359,29 @@ func (m *Manager) ActiveLanguages() []string {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	seen := make(map[string]bool)
 	languages := make([]string, 0, len(m.clients))
-	for lang := range m.clients {
-		languages = append(languages, lang)
+	for key := range m.clients {
+		if lang := clientLanguage(key); !seen[lang] {
+			seen[lang] = true
+			languages = append(languages, lang)
+		}
 	}
 	return languages
 }
 
+// Clients returns the running servers of every language
+func (m *Manager) Clients() []*Client {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	clients := make([]*Client, 0, len(m.clients))
+	for _, client := range m.clients {
+		clients = append(clients, client)
+	}
+	return clients
+}
+
 // IsAvailable checks if an LSP is configured for a language
 func (m *Manager) IsAvailable(language string) bool {
 	_, ok := m.cfg.LSP[language]
@@ -134,3 +134,202 @@ func TestManagerDoesNotRetryAfterInitialization(t *testing.T) {
 		t.Fatalf("err=%v client=%v calls=%d", err, client != nil, calls)
 	}
 }
+
+func TestManagerStartsServerWithWorkspaceFolders(t *testing.T) {
+	root := t.TempDir()
+	var gotRoot string
+	oldNew, oldInit := newLSPClient, initializeLSP
+	defer func() { newLSPClient, initializeLSP = oldNew, oldInit }()
+	newLSPClient = func(_ string, _ []string, rootURI, _ string) (*Client, error) {
+		gotRoot = rootURI
+		return &Client{RootURI: rootURI}, nil
+	}
+	initializeLSP = func(context.Context, *Client) error { return nil }
+
+	cfg := config.DefaultConfig()
+	cfg.Workspaces = []config.WorkspaceConfig{
+		{Root: "backend", Languages: []string{"go"}},
+		{Name: "web", Root: "frontend", Languages: []string{"typescript"}},
+		{Root: "tools", Languages: []string{"go", "python"}},
+	}
+	rootURI := (&url.URL{Scheme: "file", Path: root}).String()
+	manager := NewManager(cfg, rootURI)
+
+	client, err := manager.GetClient(context.Background(), "go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotRoot != rootURI || len(client.WorkspaceFolders) != 2 ||
+		client.WorkspaceFolders[0].URI != rootURI+"/backend" || client.WorkspaceFolders[1].Name != "tools" {
+		t.Fatalf("go root=%s folders=%#v", gotRoot, client.WorkspaceFolders)
+	}
+
+	client, err = manager.GetClient(context.Background(), "python")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotRoot != rootURI+"/tools" || len(client.WorkspaceFolders) != 1 {
+		t.Fatalf("python root=%s folders=%#v", gotRoot, client.WorkspaceFolders)
+	}
+
+	if _, err := manager.GetClient(context.Background(), "rust"); err == nil {
+		t.Fatal("expected an error for a language outside every workspace")
+	}
+}
+
+func TestManagerUsesConfiguredServerRoot(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "crates", "core"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	var gotRoot string
+	oldNew, oldInit := newLSPClient, initializeLSP
+	defer func() { newLSPClient, initializeLSP = oldNew, oldInit }()
+	newLSPClient = func(_ string, _ []string, rootURI, _ string) (*Client, error) {
+		gotRoot = rootURI
+		return &Client{RootURI: rootURI}, nil
+	}
+	initializeLSP = func(context.Context, *Client) error { return nil }
+
+	cfg := config.DefaultConfig()
+	rootURI := (&url.URL{Scheme: "file", Path: root}).String()
+	client, err := NewManager(cfg, rootURI).GetClient(context.Background(), "go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotRoot != rootURI || len(client.WorkspaceFolders) != 1 || client.WorkspaceFolders[0].URI != rootURI {
+		t.Fatalf("default root=%s folders=%#v", gotRoot, client.WorkspaceFolders)
+	}
+
+	rust := cfg.LSP["rust"]
+	rust.Root = "crates/core"
+	cfg.LSP["rust"] = rust
+	client, err = NewManager(cfg, rootURI).GetClient(context.Background(), "rust")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := rootURI + "/crates/core"
+	if gotRoot != want || len(client.WorkspaceFolders) != 1 || client.WorkspaceFolders[0].URI != want || client.WorkspaceFolders[0].Name != "core" {
+		t.Fatalf("override root=%s folders=%#v", gotRoot, client.WorkspaceFolders)
+	}
+
+	rust.Root = "missing"
+	cfg.LSP["rust"] = rust
+	if _, err := NewManager(cfg, rootURI).GetClient(context.Background(), "rust"); err == nil {
+		t.Fatal("expected an error for a missing server root")
+	}
+}
+
+func TestManagerStartsServerPerTSConfigProject(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"packages/web/src", "packages/api", "scripts"} {
+		if err := os.MkdirAll(filepath.Join(root, filepath.FromSlash(dir)), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, file := range []string{"tsconfig.json", "packages/web/tsconfig.json", "packages/api/jsconfig.json"} {
+		if err := os.WriteFile(filepath.Join(root, filepath.FromSlash(file)), []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var started []string
+	oldNew, oldInit := newLSPClient, initializeLSP
+	defer func() { newLSPClient, initializeLSP = oldNew, oldInit }()
+	newLSPClient = func(_ string, _ []string, rootURI, _ string) (*Client, error) {
+		if rootURI == PathToURI(filepath.Join(root, "packages", "api")) {
+			return nil, errors.New("failed to start")
+		}
+		started = append(started, rootURI)
+		return &Client{RootURI: rootURI}, nil
+	}
+	initializeLSP = func(context.Context, *Client) error { return nil }
+
+	manager := NewManager(config.DefaultConfig(), PathToURI(root))
+	ctx := context.Background()
+	clientFor := func(rel string) *Client {
+		t.Helper()
+		client, err := manager.GetClientForFile(ctx, "typescript", filepath.Join(root, filepath.FromSlash(rel)))
+		if err != nil {
+			t.Fatalf("%s: %v", rel, err)
+		}
+		return client
+	}
+
+	web := clientFor("packages/web/src/app.ts")
+	if want := PathToURI(filepath.Join(root, "packages", "web")); web.RootURI != want ||
+		len(web.WorkspaceFolders) != 1 || web.WorkspaceFolders[0].URI != want || web.WorkspaceFolders[0].Name != "web" {
+		t.Fatalf("web root=%s folders=%#v", web.RootURI, web.WorkspaceFolders)
+	}
+	if clientFor("packages/web/index.ts") != web {
+		t.Error("files of one tsconfig project got different servers")
+	}
+
+	rootClient := clientFor("scripts/build.ts")
+	if rootClient.RootURI != PathToURI(root) || rootClient == web {
+		t.Fatalf("root file server root=%s", rootClient.RootURI)
+	}
+	if clientFor("packages/api/server.ts") != rootClient {
+		t.Error("a project whose server failed to start should fall back to the root server")
+	}
+	if len(started) != 2 {
+		t.Errorf("started %d servers, want 2: %v", len(started), started)
+	}
+
+	if langs := manager.ActiveLanguages(); len(langs) != 1 || langs[0] != "typescript" {
+		t.Errorf("ActiveLanguages = %v, want [typescript]", langs)
+	}
+}
+
+func TestManagerConnectsToSocketServer(t *testing.T) {
+	root := t.TempDir()
+	var network, address string
+	oldNew, oldDial, oldInit := newLSPClient, dialLSPClient, initializeLSP
+	defer func() { newLSPClient, dialLSPClient, initializeLSP = oldNew, oldDial, oldInit }()
+	newLSPClient = func(string, []string, string, string) (*Client, error) {
+		t.Fatal("a socket server must not be started")
+		return nil, nil
+	}
+	dialLSPClient = func(n, a, rootURI, _ string) (*Client, error) {
+		network, address = n, a
+		return &Client{RootURI: rootURI}, nil
+	}
+	initializeLSP = func(context.Context, *Client) error { return nil }
+
+	cfg := config.DefaultConfig()
+	typescript := cfg.LSP["typescript"]
+	typescript.Transport, typescript.Address = config.TransportTCP, "localhost:2089"
+	cfg.LSP["typescript"] = typescript
+	if _, err := NewManager(cfg, PathToURI(root)).GetClient(context.Background(), "typescript"); err != nil {
+		t.Fatal(err)
+	}
+	if network != "tcp" || address != "localhost:2089" {
+		t.Fatalf("dialed %s %s", network, address)
+	}
+}
+
+func TestManagerRunsContainerizedServer(t *testing.T) {
+	root := t.TempDir()
+	var gotCommand, gotProject, gotServerDir string
+	oldNew, oldContainer, oldInit := newLSPClient, newContainerLSPClient, initializeLSP
+	defer func() { newLSPClient, newContainerLSPClient, initializeLSP = oldNew, oldContainer, oldInit }()
+	newLSPClient = func(string, []string, string, string) (*Client, error) {
+		t.Fatal("a containerized server must not run on the host")
+		return nil, nil
+	}
+	newContainerLSPClient = func(c config.ContainerConfig, command string, _ []string, projectRoot, serverDir, rootURI, _ string) (*Client, error) {
+		gotCommand, gotProject, gotServerDir = c.Image+" "+command, projectRoot, serverDir
+		return &Client{RootURI: rootURI}, nil
+	}
+	initializeLSP = func(context.Context, *Client) error { return nil }
+
+	cfg := config.DefaultConfig()
+	typescript := cfg.LSP["typescript"]
+	typescript.Container = &config.ContainerConfig{Image: "node:22"}
+	cfg.LSP["typescript"] = typescript
+	if _, err := NewManager(cfg, PathToURI(root)).GetClient(context.Background(), "typescript"); err != nil {
+		t.Fatal(err)
+	}
+	if gotCommand != "node:22 typescript-language-server" || gotProject != root || gotServerDir != root {
+		t.Fatalf("command=%q project=%s server dir=%s", gotCommand, gotProject, gotServerDir)
+	}
+}
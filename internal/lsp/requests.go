@@ -4,9 +4,16 @@ package lsp
 
 // InitializeParams sent to server during initialization
 type InitializeParams struct {
-	ProcessID    int                `json:"processId"`
-	RootURI      string             `json:"rootUri"`
-	Capabilities ClientCapabilities `json:"capabilities"`
+	ProcessID        *int               `json:"processId"`
+	RootURI          string             `json:"rootUri"`
+	Capabilities     ClientCapabilities `json:"capabilities"`
+	WorkspaceFolders []WorkspaceFolder  `json:"workspaceFolders,omitempty"`
+}
+
+// WorkspaceFolder is one root of a multi-root workspace
+type WorkspaceFolder struct {
+	URI  string `json:"uri"`
+	Name string `json:"name"`
 }
 
 // ClientCapabilities describes client capabilities
@@ -20,6 +27,7 @@ type TextDocumentClientCapabilities struct {
 	DocumentSymbol DocumentSymbolClientCapabilities `json:"documentSymbol,omitempty"`
 	CallHierarchy  CallHierarchyClientCapabilities  `json:"callHierarchy,omitempty"`
 	TypeHierarchy  TypeHierarchyClientCapabilities  `json:"typeHierarchy,omitempty"`
+	Hover          HoverClientCapabilities          `json:"hover,omitempty"`
 }
 
 // DocumentSymbolClientCapabilities for document symbols
@@ -37,9 +45,15 @@ type TypeHierarchyClientCapabilities struct {
 	DynamicRegistration bool `json:"dynamicRegistration,omitempty"`
 }
 
+// HoverClientCapabilities for hover
+type HoverClientCapabilities struct {
+	ContentFormat []string `json:"contentFormat,omitempty"`
+}
+
 // WorkspaceClientCapabilities for workspace features
 type WorkspaceClientCapabilities struct {
-	Symbol WorkspaceSymbolClientCapabilities `json:"symbol,omitempty"`
+	Symbol           WorkspaceSymbolClientCapabilities `json:"symbol,omitempty"`
+	WorkspaceFolders bool                              `json:"workspaceFolders,omitempty"`
 }
 
 // WorkspaceSymbolClientCapabilities for workspace symbols
@@ -50,6 +64,13 @@ type WorkspaceSymbolClientCapabilities struct {
 // InitializeResult returned by server after initialization
 type InitializeResult struct {
 	Capabilities ServerCapabilities `json:"capabilities"`
+	ServerInfo   *ServerInfo        `json:"serverInfo,omitempty"`
+}
+
+// ServerInfo names the server in an InitializeResult
+type ServerInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
 }
 
 // ServerCapabilities describes what the server can do
@@ -64,6 +85,25 @@ type ServerCapabilities struct {
 	ImplementationProvider     any `json:"implementationProvider,omitempty"`
 	CallHierarchyProvider      any `json:"callHierarchyProvider,omitempty"`
 	TypeHierarchyProvider      any `json:"typeHierarchyProvider,omitempty"`
+	HoverProvider              any `json:"hoverProvider,omitempty"`
+}
+
+// SupportsHover reports whether the server answers textDocument/hover
+func (c ServerCapabilities) SupportsHover() bool {
+	return provides(c.HoverProvider)
+}
+
+// provides reports whether a capability is advertised: true, or an
+// options object in place of true
+func provides(capability any) bool {
+	switch v := capability.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	default:
+		return true
+	}
 }
 
 // DocumentSymbolParams for textDocument/documentSymbol request
@@ -114,6 +154,24 @@ type ImplementationParams struct {
 	Position     Position               `json:"position"`
 }
 
+// ReferenceParams for textDocument/references
+type ReferenceParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Position     Position               `json:"position"`
+	Context      ReferenceContext       `json:"context"`
+}
+
+// ReferenceContext for textDocument/references
+type ReferenceContext struct {
+	IncludeDeclaration bool `json:"includeDeclaration"`
+}
+
+// HoverParams for textDocument/hover
+type HoverParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Position     Position               `json:"position"`
+}
+
 // DefaultClientCapabilities returns capabilities we advertise to servers
 func DefaultClientCapabilities() ClientCapabilities {
 	return ClientCapabilities{
@@ -127,11 +185,15 @@ func DefaultClientCapabilities() ClientCapabilities {
 			TypeHierarchy: TypeHierarchyClientCapabilities{
 				DynamicRegistration: false,
 			},
+			Hover: HoverClientCapabilities{
+				ContentFormat: []string{"markdown", "plaintext"},
+			},
 		},
 		Workspace: WorkspaceClientCapabilities{
 			Symbol: WorkspaceSymbolClientCapabilities{
 				DynamicRegistration: false,
 			},
+			WorkspaceFolders: true,
 		},
 	}
 }
@@ -1,5 +1,10 @@
 package lsp
 
+import (
+	"strings"
+	"unicode/utf16"
+)
+
 // LSP Protocol Types
 // Based on the Language Server Protocol specification
 
@@ -9,6 +14,19 @@ type Position struct {
 	Character int `json:"character"`
 }
 
+// ByteOffset returns the byte offset in line of character, which LSP
+// counts in UTF-16 code units; past the end of line, len(line)
+func ByteOffset(line string, character int) int {
+	units := 0
+	for i, r := range line {
+		if units >= character {
+			return i
+		}
+		units += utf16.RuneLen(r)
+	}
+	return len(line)
+}
+
 // Range in a text document
 type Range struct {
 	Start Position `json:"start"`
@@ -64,6 +82,25 @@ const (
 	SymbolKindTypeParameter SymbolKind = 26
 )
 
+// symbolKindNames are the SymbolKind names of the LSP specification, in
+// SymbolKind order
+var symbolKindNames = []string{
+	"File", "Module", "Namespace", "Package", "Class", "Method", "Property", "Field", "Constructor",
+	"Enum", "Interface", "Function", "Variable", "Constant", "String", "Number", "Boolean", "Array",
+	"Object", "Key", "Null", "EnumMember", "Struct", "Event", "Operator", "TypeParameter",
+}
+
+// ParseSymbolKind returns the SymbolKind named as in the LSP specification
+// (Property, EnumMember, ...), ignoring case
+func ParseSymbolKind(name string) (SymbolKind, bool) {
+	for i, n := range symbolKindNames {
+		if strings.EqualFold(n, name) {
+			return SymbolKind(i + 1), true
+		}
+	}
+	return 0, false
+}
+
 // SymbolKindToString converts SymbolKind to our internal kind strings
 func SymbolKindToString(k SymbolKind) string {
 	switch k {
@@ -98,6 +135,45 @@ func SymbolKindToString(k SymbolKind) string {
 	}
 }
 
+// SymbolKindFromString converts one of our internal kind strings back to
+// the closest SymbolKind, for serving the index to LSP clients
+func SymbolKindFromString(kind string) SymbolKind {
+	switch kind {
+	case "file":
+		return SymbolKindFile
+	case "module", "package", "namespace":
+		return SymbolKindModule
+	case "class", "type", "record", "trait", "protocol":
+		return SymbolKindClass
+	case "struct":
+		return SymbolKindStruct
+	case "method":
+		return SymbolKindMethod
+	case "field":
+		return SymbolKindField
+	case "property":
+		return SymbolKindProperty
+	case "constructor":
+		return SymbolKindConstructor
+	case "enum":
+		return SymbolKindEnum
+	case "interface":
+		return SymbolKindInterface
+	case "function":
+		return SymbolKindFunction
+	case "variable":
+		return SymbolKindVariable
+	case "constant":
+		return SymbolKindConstant
+	case "enum_member":
+		return SymbolKindEnumMember
+	case "type_parameter":
+		return SymbolKindTypeParameter
+	default:
+		return SymbolKindVariable
+	}
+}
+
 // DocumentSymbol represents a symbol in a document (hierarchical)
 type DocumentSymbol struct {
 	Name           string           `json:"name"`
@@ -157,3 +233,18 @@ type Diagnostic struct {
 	Message  string `json:"message"`
 	Source   string `json:"source,omitempty"`
 }
+
+// FileChangeType is how a watched file changed
+type FileChangeType int
+
+const (
+	FileCreated FileChangeType = 1
+	FileChanged FileChangeType = 2
+	FileDeleted FileChangeType = 3
+)
+
+// FileEvent reports a change to a file the client watches
+type FileEvent struct {
+	URI  string         `json:"uri"`
+	Type FileChangeType `json:"type"`
+}
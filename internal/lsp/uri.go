@@ -0,0 +1,71 @@
+package lsp
+
+import (
+	"net/url"
+	"runtime"
+	"strings"
+)
+
+// onWindows selects Windows path rules for PathToURI and URIToPath
+var onWindows = runtime.GOOS == "windows"
+
+// PathToURI returns the file:// URI of an absolute path, percent-encoding
+// it as language servers expect. On Windows, drive letters become
+// "file:///C:/..." and UNC paths "file://server/share/...".
+func PathToURI(path string) string {
+	return pathToURI(path, onWindows)
+}
+
+// URIToPath returns the file path of a file:// URI, decoding escapes and,
+// on Windows, restoring upper-case drive letters, UNC hosts, and
+// backslashes so paths compare equal to those from the scanner. Values
+// that are not file URIs are returned unchanged.
+func URIToPath(uri string) string {
+	return uriToPath(uri, onWindows)
+}
+
+func pathToURI(path string, windows bool) string {
+	u := url.URL{Scheme: "file", Path: path}
+	if windows {
+		path = strings.ReplaceAll(path, `\`, "/")
+		if unc, ok := strings.CutPrefix(path, "//"); ok {
+			host, rest, _ := strings.Cut(unc, "/")
+			u.Host, path = host, "/"+rest
+		} else if hasDriveLetter(path) {
+			path = "/" + path
+		}
+		u.Path = path
+	}
+	return u.String()
+}
+
+func uriToPath(uri string, windows bool) string {
+	if !strings.HasPrefix(uri, "file:") {
+		return uri
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return strings.TrimPrefix(uri, "file://")
+	}
+	path := u.Path
+	if !windows {
+		return path
+	}
+	if len(path) > 0 && path[0] == '/' && hasDriveLetter(path[1:]) {
+		// Servers vary the drive's case; match filepath.Abs ("C:")
+		path = strings.ToUpper(path[1:2]) + path[2:]
+	}
+	if u.Host != "" && u.Host != "localhost" {
+		path = "//" + u.Host + path
+	}
+	return strings.ReplaceAll(path, "/", `\`)
+}
+
+// hasDriveLetter reports whether path starts with a drive such as "C:"
+func hasDriveLetter(path string) bool {
+	if len(path) < 2 || path[1] != ':' {
+		return false
+	}
+	c := path[0]
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
@@ -0,0 +1,41 @@
+package lsp
+
+import "testing"
+
+func TestPathURIRoundTrip(t *testing.T) {
+	for _, tc := range []struct {
+		path    string
+		windows bool
+		uri     string
+	}{
+		{"/home/me/src/main.go", false, "file:///home/me/src/main.go"},
+		{"/home/me/my project/a#b.go", false, "file:///home/me/my%20project/a%23b.go"},
+		{`C:\Users\me\src\main.go`, true, "file:///C:/Users/me/src/main.go"},
+		{`D:\My Code\app.ts`, true, "file:///D:/My%20Code/app.ts"},
+		{`\\server\share\lib\x.py`, true, "file://server/share/lib/x.py"},
+	} {
+		if got := pathToURI(tc.path, tc.windows); got != tc.uri {
+			t.Errorf("pathToURI(%q) = %q, want %q", tc.path, got, tc.uri)
+		}
+		if got := uriToPath(tc.uri, tc.windows); got != tc.path {
+			t.Errorf("uriToPath(%q) = %q, want %q", tc.uri, got, tc.path)
+		}
+	}
+}
+
+func TestURIToPathAcceptsServerSpellings(t *testing.T) {
+	for _, tc := range []struct {
+		uri     string
+		windows bool
+		path    string
+	}{
+		// VS Code-style servers escape the drive colon
+		{"file:///c%3A/Users/me/main.go", true, `C:\Users\me\main.go`},
+		{"file://localhost/C:/src/a.go", true, `C:\src\a.go`},
+		{"/already/a/path.go", false, "/already/a/path.go"},
+	} {
+		if got := uriToPath(tc.uri, tc.windows); got != tc.path {
+			t.Errorf("uriToPath(%q) = %q, want %q", tc.uri, got, tc.path)
+		}
+	}
+}
@@ -0,0 +1,484 @@
+// Package lspserver serves the index over the Language Server Protocol.
+// `codegraph lsp` answers workspace/symbol, textDocument/references,
+// textDocument/implementation and the call hierarchy requests from the
+// SQLite index alone, so an editor gets cross-language navigation as soon
+// as it connects, without waiting for each language's own server to start
+// and analyze the project.
+package lspserver
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/tk-425/Codegraph/internal/db"
+	"github.com/tk-425/Codegraph/internal/lsp"
+)
+
+// JSON-RPC error codes
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+)
+
+// workspaceSymbolLimit bounds the symbols returned for one workspace/symbol
+// query, which editors send on every keystroke
+const workspaceSymbolLimit = 200
+
+// Server answers LSP requests from a project's index
+type Server struct {
+	db *db.Manager
+	// Version is reported to clients as the server's version
+	Version string
+
+	out      io.Writer
+	mu       sync.Mutex // Serializes writes to out
+	shutdown bool
+}
+
+// NewServer creates a server answering from database
+func NewServer(database *db.Manager) *Server {
+	return &Server{db: database}
+}
+
+// message is a JSON-RPC request or notification from the client
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// response answers a request with the client's own ID, which may be a
+// number or a string
+type response struct {
+	JSONRPC string             `json:"jsonrpc"`
+	ID      json.RawMessage    `json:"id"`
+	Result  any                `json:"result"`
+	Error   *lsp.ResponseError `json:"error,omitempty"`
+}
+
+// Serve reads requests from in and writes responses to out until the
+// client sends exit, in is closed, or ctx is done. Requests are answered
+// in order, one at a time.
+func (s *Server) Serve(ctx context.Context, in io.Reader, out io.Writer) error {
+	s.out = out
+	reader := bufio.NewReader(in)
+	for ctx.Err() == nil {
+		body, err := readMessage(reader)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var msg message
+		if err := json.Unmarshal(body, &msg); err != nil {
+			s.reply(json.RawMessage("null"), nil, &lsp.ResponseError{Code: codeParseError, Message: err.Error()})
+			continue
+		}
+		if msg.Method == "exit" {
+			return nil
+		}
+		if len(msg.ID) == 0 {
+			continue // Notifications need no answer
+		}
+
+		result, rerr := s.handle(msg)
+		s.reply(msg.ID, result, rerr)
+	}
+	return ctx.Err()
+}
+
+// readMessage reads the body of one Content-Length framed message
+func readMessage(reader *bufio.Reader) ([]byte, error) {
+	contentLength := -1
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			if err == io.EOF && line == "" && contentLength < 0 {
+				return nil, io.EOF
+			}
+			return nil, fmt.Errorf("failed to read message header: %w", err)
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			if contentLength < 0 {
+				continue // Stray blank line between messages
+			}
+			break // End of headers
+		}
+		if value, ok := strings.CutPrefix(line, "Content-Length:"); ok {
+			contentLength, err = strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				return nil, fmt.Errorf("invalid Content-Length %q", value)
+			}
+		}
+	}
+
+	body := make([]byte, contentLength)
+	if _, err := io.ReadFull(reader, body); err != nil {
+		return nil, fmt.Errorf("failed to read message body: %w", err)
+	}
+	return body, nil
+}
+
+func (s *Server) reply(id json.RawMessage, result any, rerr *lsp.ResponseError) {
+	resp := response{JSONRPC: "2.0", ID: id, Result: result, Error: rerr}
+	if rerr != nil {
+		resp.Result = nil
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		resp = response{JSONRPC: "2.0", ID: id, Error: &lsp.ResponseError{Code: codeInternalError, Message: err.Error()}}
+		data, _ = json.Marshal(resp)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n%s", len(data), data)
+}
+
+// handle answers one request
+func (s *Server) handle(msg message) (any, *lsp.ResponseError) {
+	if s.shutdown {
+		return nil, &lsp.ResponseError{Code: codeInvalidRequest, Message: "server is shutting down"}
+	}
+
+	var result any
+	var err error
+	switch msg.Method {
+	case "initialize":
+		return s.initialize(), nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "workspace/symbol":
+		var params lsp.WorkspaceSymbolParams
+		if perr := decodeParams(msg.Params, &params); perr != nil {
+			return nil, perr
+		}
+		result, err = s.workspaceSymbols(params.Query)
+	case "textDocument/references":
+		var params lsp.ReferenceParams
+		if perr := decodeParams(msg.Params, &params); perr != nil {
+			return nil, perr
+		}
+		result, err = s.references(params)
+	case "textDocument/implementation":
+		var params lsp.ImplementationParams
+		if perr := decodeParams(msg.Params, &params); perr != nil {
+			return nil, perr
+		}
+		result, err = s.implementations(params.TextDocument.URI, params.Position)
+	case "textDocument/prepareCallHierarchy":
+		var params lsp.CallHierarchyPrepareParams
+		if perr := decodeParams(msg.Params, &params); perr != nil {
+			return nil, perr
+		}
+		result, err = s.prepareCallHierarchy(params.TextDocument.URI, params.Position)
+	case "callHierarchy/incomingCalls":
+		var params lsp.CallHierarchyIncomingCallsParams
+		if perr := decodeParams(msg.Params, &params); perr != nil {
+			return nil, perr
+		}
+		result, err = s.incomingCalls(params.Item)
+	case "callHierarchy/outgoingCalls":
+		var params lsp.CallHierarchyOutgoingCallsParams
+		if perr := decodeParams(msg.Params, &params); perr != nil {
+			return nil, perr
+		}
+		result, err = s.outgoingCalls(params.Item)
+	default:
+		return nil, &lsp.ResponseError{Code: codeMethodNotFound, Message: fmt.Sprintf("method not found: %s", msg.Method)}
+	}
+	if err != nil {
+		return nil, &lsp.ResponseError{Code: codeInternalError, Message: err.Error()}
+	}
+	return result, nil
+}
+
+func decodeParams(raw json.RawMessage, params any) *lsp.ResponseError {
+	if err := json.Unmarshal(raw, params); err != nil {
+		return &lsp.ResponseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+func (s *Server) initialize() lsp.InitializeResult {
+	return lsp.InitializeResult{
+		Capabilities: lsp.ServerCapabilities{
+			WorkspaceSymbolProvider: true,
+			ReferencesProvider:      true,
+			ImplementationProvider:  true,
+			CallHierarchyProvider:   true,
+		},
+		ServerInfo: &lsp.ServerInfo{Name: "codegraph", Version: s.Version},
+	}
+}
+
+func (s *Server) workspaceSymbols(query string) ([]lsp.SymbolInformation, error) {
+	symbols, err := s.db.SearchSymbols(query, db.QueryOptions{Limit: workspaceSymbolLimit})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]lsp.SymbolInformation, 0, len(symbols))
+	for _, sym := range symbols {
+		result = append(result, lsp.SymbolInformation{
+			Name:          sym.Name,
+			Kind:          lsp.SymbolKindFromString(sym.Kind),
+			Location:      lsp.Location{URI: lsp.PathToURI(sym.File), Range: selectionRange(sym)},
+			ContainerName: sym.Scope,
+		})
+	}
+	return result, nil
+}
+
+// references returns the call sites of the symbols at the position, and
+// their declarations when the client asks for them
+func (s *Server) references(params lsp.ReferenceParams) ([]lsp.Location, error) {
+	symbols, err := s.symbolsAt(params.TextDocument.URI, params.Position)
+	if err != nil {
+		return nil, err
+	}
+
+	locations := make([]lsp.Location, 0)
+	seen := make(map[lsp.Location]bool)
+	add := func(loc lsp.Location) {
+		if !seen[loc] {
+			seen[loc] = true
+			locations = append(locations, loc)
+		}
+	}
+	for _, sym := range symbols {
+		if params.Context.IncludeDeclaration {
+			add(lsp.Location{URI: lsp.PathToURI(sym.File), Range: selectionRange(sym)})
+		}
+		callers, err := s.db.GetCallersByID(sym.ID, db.QueryOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range callers {
+			add(lsp.Location{URI: lsp.PathToURI(c.CallFile), Range: callRange(c.CallLine, c.CallColumn, sym.Name)})
+		}
+	}
+	return locations, nil
+}
+
+// implementations returns the types implementing or extending the types
+// at the position
+func (s *Server) implementations(uri string, pos lsp.Position) ([]lsp.Location, error) {
+	symbols, err := s.symbolsAt(uri, pos)
+	if err != nil {
+		return nil, err
+	}
+
+	locations := make([]lsp.Location, 0)
+	for _, sym := range symbols {
+		impls, err := s.db.GetImplementations(sym.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, impl := range impls {
+			locations = append(locations, lsp.Location{URI: lsp.PathToURI(impl.File), Range: selectionRange(impl)})
+		}
+	}
+	return locations, nil
+}
+
+func (s *Server) prepareCallHierarchy(uri string, pos lsp.Position) ([]lsp.CallHierarchyItem, error) {
+	symbols, err := s.symbolsAt(uri, pos)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]lsp.CallHierarchyItem, 0, len(symbols))
+	for _, sym := range symbols {
+		items = append(items, callHierarchyItem(sym))
+	}
+	return items, nil
+}
+
+// incomingCalls groups the callers of item by calling symbol
+func (s *Server) incomingCalls(item lsp.CallHierarchyItem) ([]lsp.CallHierarchyIncomingCall, error) {
+	id, ok := item.Data.(string)
+	if !ok {
+		return nil, fmt.Errorf("call hierarchy item %q was not prepared by codegraph", item.Name)
+	}
+	callers, err := s.db.GetCallersByID(id, db.QueryOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	calls := make([]lsp.CallHierarchyIncomingCall, 0)
+	byCaller := make(map[string]int)
+	for _, c := range callers {
+		r := callRange(c.CallLine, c.CallColumn, item.Name)
+		if i, ok := byCaller[c.ID]; ok {
+			calls[i].FromRanges = append(calls[i].FromRanges, r)
+			continue
+		}
+		byCaller[c.ID] = len(calls)
+		calls = append(calls, lsp.CallHierarchyIncomingCall{From: callHierarchyItem(c.Symbol), FromRanges: []lsp.Range{r}})
+	}
+	return calls, nil
+}
+
+// outgoingCalls groups the callees of item by called symbol; the ranges
+// are the call sites inside item
+func (s *Server) outgoingCalls(item lsp.CallHierarchyItem) ([]lsp.CallHierarchyOutgoingCall, error) {
+	id, ok := item.Data.(string)
+	if !ok {
+		return nil, fmt.Errorf("call hierarchy item %q was not prepared by codegraph", item.Name)
+	}
+	callees, err := s.db.GetCalleesByID(id, db.QueryOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	calls := make([]lsp.CallHierarchyOutgoingCall, 0)
+	byCallee := make(map[string]int)
+	for _, c := range callees {
+		r := callRange(c.CallLine, c.CallColumn, c.Name)
+		if i, ok := byCallee[c.ID]; ok {
+			calls[i].FromRanges = append(calls[i].FromRanges, r)
+			continue
+		}
+		byCallee[c.ID] = len(calls)
+		calls = append(calls, lsp.CallHierarchyOutgoingCall{To: callHierarchyItem(c.Symbol), FromRanges: []lsp.Range{r}})
+	}
+	return calls, nil
+}
+
+// symbolsAt returns the symbols named by the identifier at pos: the one
+// declared there when pos is on a declaration, otherwise every indexed
+// symbol of that name, declarations in the same file first
+func (s *Server) symbolsAt(uri string, pos lsp.Position) ([]db.Symbol, error) {
+	path := lsp.URIToPath(uri)
+	word := identifierAt(path, pos)
+	if word == "" {
+		return nil, nil
+	}
+
+	fileSymbols, err := s.db.GetFileSymbols(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, sym := range fileSymbols {
+		if sym.Line == pos.Line+1 && baseName(sym.Name) == word {
+			return []db.Symbol{sym}, nil
+		}
+	}
+
+	candidates, err := s.db.FindSymbolsByName(word, db.QueryOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var local, other []db.Symbol
+	for _, sym := range candidates {
+		if baseName(sym.Name) != word {
+			continue
+		}
+		if sym.File == path {
+			local = append(local, sym)
+		} else {
+			other = append(other, sym)
+		}
+	}
+	return append(local, other...), nil
+}
+
+// identifierAt returns the identifier in file at pos, or "" when pos is
+// not on one. Characters are counted in bytes, which matches the client's
+// UTF-16 offsets on ASCII lines.
+func identifierAt(file string, pos lsp.Position) string {
+	f, err := os.Open(file)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 0; scanner.Scan(); line++ {
+		if line == pos.Line {
+			return identifierIn(scanner.Text(), pos.Character)
+		}
+	}
+	return ""
+}
+
+// identifierIn returns the identifier of text spanning column col
+func identifierIn(text string, col int) string {
+	if col < 0 || col > len(text) {
+		return ""
+	}
+	start, end := col, col
+	for start > 0 && isIdentByte(text[start-1]) {
+		start--
+	}
+	for end < len(text) && isIdentByte(text[end]) {
+		end++
+	}
+	return text[start:end]
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '$' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+// baseName strips parameters and qualifiers from an indexed name, so
+// "Class.method(String)" is "method"
+func baseName(name string) string {
+	if i := strings.IndexByte(name, '('); i >= 0 {
+		name = name[:i]
+	}
+	if i := strings.LastIndexAny(name, ".:"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+func callHierarchyItem(sym db.Symbol) lsp.CallHierarchyItem {
+	return lsp.CallHierarchyItem{
+		Name:           sym.Name,
+		Kind:           lsp.SymbolKindFromString(sym.Kind),
+		Detail:         sym.Signature,
+		URI:            lsp.PathToURI(sym.File),
+		Range:          symbolRange(sym),
+		SelectionRange: selectionRange(sym),
+		Data:           sym.ID,
+	}
+}
+
+// symbolRange spans the symbol's whole definition when its end was
+// indexed, and its name otherwise
+func symbolRange(sym db.Symbol) lsp.Range {
+	if sym.EndLine == nil || sym.EndColumn == nil {
+		return selectionRange(sym)
+	}
+	return lsp.Range{
+		Start: lsp.Position{Line: sym.Line - 1, Character: sym.Column},
+		End:   lsp.Position{Line: *sym.EndLine - 1, Character: *sym.EndColumn},
+	}
+}
+
+// selectionRange spans the symbol's name at its declaration
+func selectionRange(sym db.Symbol) lsp.Range {
+	return callRange(sym.Line, sym.Column, sym.Name)
+}
+
+// callRange spans name at a 1-indexed line and 0-indexed column
+func callRange(line, column int, name string) lsp.Range {
+	start := lsp.Position{Line: line - 1, Character: column}
+	return lsp.Range{Start: start, End: lsp.Position{Line: start.Line, Character: column + len(baseName(name))}}
+}
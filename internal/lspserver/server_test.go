@@ -0,0 +1,180 @@
+package lspserver
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tk-425/Codegraph/internal/db"
+	"github.com/tk-425/Codegraph/internal/lsp"
+)
+
+func TestServeAnswersFromIndex(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "shapes.go")
+	source := `package shapes
+
+type Shape interface{}
+
+type Circle struct{}
+
+func Area() int { return 0 }
+
+func Report() int {
+	return Area() + Area()
+}
+`
+	if err := os.WriteFile(file, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	database, err := db.NewManager(filepath.Join(dir, "graph.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	if err := database.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []db.Symbol{
+		{ID: file + "#Shape", Name: "Shape", Kind: "interface", File: file, Line: 3, Column: 5, Language: "go"},
+		{ID: file + "#Circle", Name: "Circle", Kind: "struct", File: file, Line: 5, Column: 5, Language: "go"},
+		{ID: file + "#Area", Name: "Area", Kind: "function", File: file, Line: 7, Column: 5, Language: "go"},
+		{ID: file + "#Report", Name: "Report", Kind: "function", File: file, Line: 9, Column: 5, Language: "go"},
+	} {
+		s.CreatedAt = time.Now()
+		if err := database.InsertSymbol(&s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, col := range []int{8, 17} {
+		if err := database.InsertCall(&db.Call{CallerID: file + "#Report", CalleeID: file + "#Area", File: file, Line: 10, Column: col}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := database.InsertTypeHierarchy(&db.TypeHierarchy{ChildID: file + "#Circle", ParentID: file + "#Shape", Relationship: "implements"}); err != nil {
+		t.Fatal(err)
+	}
+
+	uri := lsp.PathToURI(file)
+	var in bytes.Buffer
+	send := func(id int, method string, params any) {
+		data, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": id, "method": method, "params": params})
+		fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(data), data)
+	}
+	at := func(line, char int) map[string]any {
+		return map[string]any{"textDocument": map[string]string{"uri": uri}, "position": lsp.Position{Line: line, Character: char}}
+	}
+	send(1, "initialize", map[string]any{})
+	send(2, "workspace/symbol", lsp.WorkspaceSymbolParams{Query: "Are"})
+	send(3, "textDocument/references", at(9, 9)) // Area, at a call
+	send(4, "textDocument/implementation", at(2, 7))
+	send(5, "textDocument/prepareCallHierarchy", at(6, 6))
+	item := lsp.CallHierarchyItem{Name: "Area", URI: uri, Data: file + "#Area"}
+	send(6, "callHierarchy/incomingCalls", lsp.CallHierarchyIncomingCallsParams{Item: item})
+	send(7, "textDocument/hover", at(6, 6))
+	send(8, "shutdown", nil)
+	fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(`{"jsonrpc":"2.0","method":"exit"}`), `{"jsonrpc":"2.0","method":"exit"}`)
+
+	var out bytes.Buffer
+	if err := NewServer(database).Serve(context.Background(), &in, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	responses := make(map[int]response)
+	reader := bufio.NewReader(&out)
+	for {
+		body, err := readMessage(reader)
+		if err != nil {
+			break
+		}
+		var resp struct {
+			ID     int                `json:"id"`
+			Result json.RawMessage    `json:"result"`
+			Error  *lsp.ResponseError `json:"error"`
+		}
+		if err := json.Unmarshal(body, &resp); err != nil {
+			t.Fatal(err)
+		}
+		responses[resp.ID] = response{Result: resp.Result, Error: resp.Error}
+	}
+	if len(responses) != 8 {
+		t.Fatalf("got %d responses, want 8", len(responses))
+	}
+	result := func(id int, v any) {
+		t.Helper()
+		if responses[id].Error != nil {
+			t.Fatalf("request %d failed: %v", id, responses[id].Error)
+		}
+		if err := json.Unmarshal(responses[id].Result.(json.RawMessage), v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var init lsp.InitializeResult
+	result(1, &init)
+	if init.Capabilities.CallHierarchyProvider != true || init.ServerInfo == nil || init.ServerInfo.Name != "codegraph" {
+		t.Errorf("initialize = %+v", init)
+	}
+
+	var symbols []lsp.SymbolInformation
+	result(2, &symbols)
+	if len(symbols) != 1 || symbols[0].Name != "Area" || symbols[0].Kind != lsp.SymbolKindFunction {
+		t.Errorf("workspace/symbol = %+v", symbols)
+	}
+
+	var refs []lsp.Location
+	result(3, &refs)
+	if len(refs) != 2 || refs[0].Range.Start != (lsp.Position{Line: 9, Character: 8}) || refs[1].Range.Start.Character != 17 {
+		t.Errorf("references = %+v", refs)
+	}
+
+	var impls []lsp.Location
+	result(4, &impls)
+	if len(impls) != 1 || impls[0].Range.Start.Line != 4 {
+		t.Errorf("implementation = %+v, want Circle", impls)
+	}
+
+	var items []lsp.CallHierarchyItem
+	result(5, &items)
+	if len(items) != 1 || items[0].Name != "Area" || items[0].Data != file+"#Area" {
+		t.Errorf("prepareCallHierarchy = %+v", items)
+	}
+
+	var incoming []lsp.CallHierarchyIncomingCall
+	result(6, &incoming)
+	if len(incoming) != 1 || incoming[0].From.Name != "Report" || len(incoming[0].FromRanges) != 2 {
+		t.Errorf("incomingCalls = %+v, want Report calling twice", incoming)
+	}
+
+	if err := responses[7].Error; err == nil || err.Code != codeMethodNotFound {
+		t.Errorf("hover error = %v, want method not found", err)
+	}
+}
+
+func TestIdentifierIn(t *testing.T) {
+	for _, tc := range []struct {
+		text string
+		col  int
+		want string
+	}{
+		{"\treturn Area() + Area()", 9, "Area"},
+		{"\treturn Area() + Area()", 8, "Area"},
+		{"\treturn Area() + Area()", 12, "Area"},
+		{"\treturn Area() + Area()", 14, ""},
+		{"x", 5, ""},
+	} {
+		if got := identifierIn(tc.text, tc.col); got != tc.want {
+			t.Errorf("identifierIn(%q, %d) = %q, want %q", tc.text, tc.col, got, tc.want)
+		}
+	}
+	if got := baseName("Shape.area(double)"); got != "area" {
+		t.Errorf("baseName = %q", got)
+	}
+}
@@ -0,0 +1,73 @@
+package owners
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// uncommitted is the author git blame gives lines not committed yet
+const uncommitted = "Not Committed Yet"
+
+// Blame returns the author of each line of the file at path, inside the
+// git repository at root; lines[0] is line 1. Uncommitted lines have an
+// empty author. It fails when git is missing or the file is not tracked.
+func Blame(ctx context.Context, root, path string) ([]string, error) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		rel = path
+	}
+	cmd := exec.CommandContext(ctx, "git", "-C", root, "blame", "--line-porcelain", "--", filepath.ToSlash(rel))
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	// Each line's header holds "author <name>"; the source line itself
+	// follows, prefixed with a tab
+	var lines []string
+	author := ""
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		text := scanner.Text()
+		switch {
+		case strings.HasPrefix(text, "\t"):
+			lines = append(lines, author)
+		case strings.HasPrefix(text, "author "):
+			author = strings.TrimPrefix(text, "author ")
+			if author == uncommitted {
+				author = ""
+			}
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// TopAuthor returns the author of most of the lines start through end
+// (1-indexed, inclusive), or "" when none is known. Ties go to the author
+// who reached the count first.
+func TopAuthor(lines []string, start, end int) string {
+	if start < 1 {
+		start = 1
+	}
+	if end > len(lines) {
+		end = len(lines)
+	}
+	counts := make(map[string]int)
+	top := ""
+	for line := start; line <= end; line++ {
+		author := lines[line-1]
+		if author == "" {
+			continue
+		}
+		counts[author]++
+		if counts[author] > counts[top] {
+			top = author
+		}
+	}
+	return top
+}
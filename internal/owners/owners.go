@@ -0,0 +1,87 @@
+// Package owners resolves who owns the files and symbols of a project, from
+// its CODEOWNERS file and from git blame.
+package owners
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tk-425/Codegraph/internal/ignore"
+)
+
+// Locations are the CODEOWNERS paths GitHub reads, in its order of
+// precedence, relative to the project root
+var Locations = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// Rules are the entries of a CODEOWNERS file
+type Rules struct {
+	Path  string // File the rules were read from, relative to the root
+	rules []rule
+}
+
+type rule struct {
+	match  *ignore.PatternList
+	owners []string
+}
+
+// Load reads the project's CODEOWNERS file, or returns nil when it has none
+func Load(root string) (*Rules, error) {
+	for _, loc := range Locations {
+		content, err := os.ReadFile(filepath.Join(root, loc))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		rules, err := Parse(content)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", loc, err)
+		}
+		rules.Path = loc
+		return rules, nil
+	}
+	return nil, nil
+}
+
+// Parse parses CODEOWNERS content: one gitignore-style pattern per line
+// followed by its owners (@user, @org/team or an email). A pattern with no
+// owners leaves matching files unowned.
+func Parse(content []byte) (*Rules, error) {
+	r := &Rules{}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := scanner.Text()
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		match, err := ignore.NewPatternList(fmt.Sprintf("line %d", lineNum), fields[:1])
+		if err != nil {
+			return nil, err
+		}
+		r.rules = append(r.rules, rule{match: match, owners: fields[1:]})
+	}
+	return r, scanner.Err()
+}
+
+// Owners returns the owners of the file at relPath (relative to the
+// project root). The last matching rule wins, as on GitHub.
+func (r *Rules) Owners(relPath string) []string {
+	if r == nil {
+		return nil
+	}
+	for i := len(r.rules) - 1; i >= 0; i-- {
+		if r.rules[i].match.Match(relPath) {
+			return r.rules[i].owners
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package owners
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOwners(t *testing.T) {
+	rules, err := Parse([]byte(`# Default owners
+*            @acme/core
+
+docs/        @acme/docs   # documentation
+*.go         @acme/go
+/cmd/tool/   @alice bob@example.com
+vendor/
+`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	for _, tc := range []struct {
+		path string
+		want string
+	}{
+		{"README.md", "@acme/core"},
+		{"docs/guide/intro.md", "@acme/docs"},
+		{"docs/gen.go", "@acme/go"}, // last match wins
+		{"internal/db/query.go", "@acme/go"},
+		{"cmd/tool/main.go", "@alice bob@example.com"},
+		{"vendor/lib/lib.go", ""}, // no owners
+	} {
+		if got := strings.Join(rules.Owners(tc.path), " "); got != tc.want {
+			t.Errorf("Owners(%s) = %q, want %q", tc.path, got, tc.want)
+		}
+	}
+
+	var none *Rules
+	if got := none.Owners("main.go"); got != nil {
+		t.Errorf("nil rules: Owners = %v, want nil", got)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	root := t.TempDir()
+	rules, err := Load(root)
+	if err != nil || rules != nil {
+		t.Fatalf("Load without CODEOWNERS = %v, %v, want nil, nil", rules, err)
+	}
+
+	for _, loc := range []string{"docs/CODEOWNERS", ".github/CODEOWNERS"} {
+		path := filepath.Join(root, loc)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("* @"+filepath.Dir(loc)+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rules, err = Load(root)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if rules.Path != ".github/CODEOWNERS" || strings.Join(rules.Owners("main.go"), " ") != "@.github" {
+		t.Errorf("Load read %s, owners %v; want .github/CODEOWNERS first", rules.Path, rules.Owners("main.go"))
+	}
+}
+
+func TestTopAuthor(t *testing.T) {
+	lines := []string{"alice", "bob", "bob", "", "alice", "carol"}
+	for _, tc := range []struct {
+		start, end int
+		want       string
+	}{
+		{1, 6, "bob"}, // tie: bob had two lines before alice did
+		{2, 4, "bob"},
+		{4, 4, ""},
+		{6, 10, "carol"},
+	} {
+		if got := TopAuthor(lines, tc.start, tc.end); got != tc.want {
+			t.Errorf("TopAuthor(%d, %d) = %q, want %q", tc.start, tc.end, got, tc.want)
+		}
+	}
+}
+
+func TestBlame(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", root, "-c", "user.name=Alice", "-c", "user.email=alice@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	path := filepath.Join(root, "main.go")
+	git("init", "-q")
+	if err := os.WriteFile(path, []byte("package main\n\nfunc main() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	git("add", "main.go")
+	git("commit", "-q", "-m", "init")
+	if err := os.WriteFile(path, []byte("package main\n\nfunc main() {}\n\nfunc helper() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	lines, err := Blame(context.Background(), root, path)
+	if err != nil {
+		t.Fatalf("Blame: %v", err)
+	}
+	if got := strings.Join(lines, ","); got != "Alice,Alice,Alice,," {
+		t.Errorf("Blame = %q, want committed lines by Alice and uncommitted ones empty", got)
+	}
+}
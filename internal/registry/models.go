@@ -19,6 +19,18 @@ type Project struct {
 	Path          string    `json:"path"`
 	InitializedAt time.Time `json:"initialized_at"`
 	LastSeen      time.Time `json:"last_seen"`
+	// Stats is a snapshot of the project's index, taken after each build
+	Stats *Stats `json:"stats,omitempty"`
+}
+
+// Stats is a snapshot of a project's index size and freshness
+type Stats struct {
+	Symbols      int        `json:"symbols"`
+	Files        int        `json:"files"`
+	CallEdges    int        `json:"call_edges"`
+	DatabaseSize int64      `json:"database_size"`
+	LastBuild    *time.Time `json:"last_build,omitempty"`
+	RecordedAt   time.Time  `json:"recorded_at"`
 }
 
 // Registry represents the root structure of the registry file
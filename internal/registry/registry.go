@@ -5,16 +5,65 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 )
 
-// DefaultRegistryPath returns the default path for the registry file (~/.codegraph/registry.json)
-func DefaultRegistryPath() (string, error) {
+// Environment variables that relocate global state
+const (
+	// HomeEnv names a directory that holds all global state
+	HomeEnv = "CODEGRAPH_HOME"
+	// XDG base directories, consulted when HomeEnv is unset
+	xdgDataHomeEnv   = "XDG_DATA_HOME"
+	xdgConfigHomeEnv = "XDG_CONFIG_HOME"
+	// xdgDirName is codegraph's directory under an XDG base directory
+	xdgDirName = "codegraph"
+)
+
+// HomeDir returns the directory for global state such as the registry:
+//
+//  1. $CODEGRAPH_HOME
+//  2. $XDG_DATA_HOME/codegraph, then $XDG_CONFIG_HOME/codegraph
+//  3. ~/.codegraph
+//
+// An XDG directory is skipped while ~/.codegraph still holds a registry
+// and the XDG one does not, so existing projects are not forgotten.
+func HomeDir() (string, error) {
+	if dir := os.Getenv(HomeEnv); dir != "" {
+		return dir, nil
+	}
 	home, err := os.UserHomeDir()
 	if err != nil {
-		return "", fmt.Errorf("failed to get user home directory: %w", err)
+		return "", fmt.Errorf("failed to get user home directory (set %s): %w", HomeEnv, err)
+	}
+	legacy := filepath.Join(home, ConfigDirName)
+	for _, env := range []string{xdgDataHomeEnv, xdgConfigHomeEnv} {
+		base := os.Getenv(env)
+		if base == "" {
+			continue
+		}
+		dir := filepath.Join(base, xdgDirName)
+		if !exists(filepath.Join(dir, RegistryFile)) && exists(filepath.Join(legacy, RegistryFile)) {
+			return legacy, nil
+		}
+		return dir, nil
+	}
+	return legacy, nil
+}
+
+// DefaultRegistryPath returns the path of the registry file in HomeDir
+func DefaultRegistryPath() (string, error) {
+	dir, err := HomeDir()
+	if err != nil {
+		return "", err
 	}
-	return filepath.Join(home, ConfigDirName, RegistryFile), nil
+	return filepath.Join(dir, RegistryFile), nil
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
 }
 
 // Load reads the registry file from disk
@@ -79,6 +128,41 @@ func (r *Registry) Add(path, name string) {
 	}
 }
 
+// Find returns the project registered at path, or else the one project
+// registered under that name
+func (r *Registry) Find(pathOrName string) (*Project, error) {
+	if abs, err := filepath.Abs(pathOrName); err == nil {
+		if proj, ok := r.Projects[abs]; ok {
+			return proj, nil
+		}
+	}
+	found := r.FindByName(pathOrName)
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("no registered project %q (see 'codegraph projects')", pathOrName)
+	case 1:
+		return found[0], nil
+	default:
+		paths := make([]string, len(found))
+		for i, proj := range found {
+			paths[i] = proj.Path
+		}
+		return nil, fmt.Errorf("project name %q is ambiguous; use a path: %s", pathOrName, strings.Join(paths, ", "))
+	}
+}
+
+// FindByName returns the projects registered under name, sorted by path
+func (r *Registry) FindByName(name string) []*Project {
+	var found []*Project
+	for _, proj := range r.Projects {
+		if proj.Name == name {
+			found = append(found, proj)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].Path < found[j].Path })
+	return found
+}
+
 // Remove deletes a project from the registry
 func (r *Registry) Remove(path string) {
 	delete(r.Projects, filepath.Clean(path))
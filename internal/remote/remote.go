@@ -0,0 +1,201 @@
+// Package remote syncs a project's index database with a shared location,
+// so an index built once (typically in CI) can be reused by everyone.
+package remote
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+// Supported remote schemes. A location without a scheme is a file path.
+const (
+	SchemeS3   = "s3"
+	SchemeGCS  = "gs"
+	SchemeFile = "file"
+)
+
+// runCommand runs a storage CLI; replaced in tests
+var runCommand = func(ctx context.Context, name string, args ...string) error {
+	if _, err := exec.LookPath(name); err != nil {
+		return fmt.Errorf("%s is required for this remote: %w", name, err)
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s failed: %s", name, msg)
+		}
+		return fmt.Errorf("%s failed: %w", name, err)
+	}
+	return nil
+}
+
+// Scheme returns the scheme of a remote location: s3, gs or file
+func Scheme(location string) (string, error) {
+	if location == "" {
+		return "", fmt.Errorf("no remote configured (set database.remote in config.toml or pass --remote)")
+	}
+	u, err := url.Parse(location)
+	if err != nil || u.Scheme == "" || filepath.VolumeName(location) != "" {
+		return SchemeFile, nil
+	}
+	switch u.Scheme {
+	case SchemeS3, SchemeGCS, SchemeFile:
+		return u.Scheme, nil
+	}
+	return "", fmt.Errorf("unsupported remote %q: use s3://, gs://, or a file path", location)
+}
+
+// filePath returns the path of a file remote
+func filePath(location string) string {
+	if strings.HasPrefix(location, "file://") {
+		u, err := url.Parse(location)
+		if err == nil {
+			return u.Path
+		}
+	}
+	return location
+}
+
+// copyTo copies the file at src to location
+func copyTo(ctx context.Context, src, location string) error {
+	scheme, err := Scheme(location)
+	if err != nil {
+		return err
+	}
+	switch scheme {
+	case SchemeS3:
+		return runCommand(ctx, "aws", "s3", "cp", "--only-show-errors", src, location)
+	case SchemeGCS:
+		return runCommand(ctx, "gcloud", "storage", "cp", src, location)
+	}
+	dst := filePath(location)
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	// Write next to the target, then rename, so readers of a shared mount
+	// never see a partial index
+	tmp := dst + ".upload"
+	if err := copyFile(src, tmp); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
+
+// copyFrom copies location to the file at dst
+func copyFrom(ctx context.Context, location, dst string) error {
+	scheme, err := Scheme(location)
+	if err != nil {
+		return err
+	}
+	switch scheme {
+	case SchemeS3:
+		return runCommand(ctx, "aws", "s3", "cp", "--only-show-errors", location, dst)
+	case SchemeGCS:
+		return runCommand(ctx, "gcloud", "storage", "cp", location, dst)
+	}
+	return copyFile(filePath(location), dst)
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// Push uploads a consistent copy of the index in dbManager to location and
+// returns its size in bytes
+func Push(ctx context.Context, dbManager *db.Manager, location string) (int64, error) {
+	if _, err := Scheme(location); err != nil {
+		return 0, err
+	}
+	dir, err := os.MkdirTemp("", "codegraph-push-")
+	if err != nil {
+		return 0, err
+	}
+	defer os.RemoveAll(dir)
+
+	copyPath := filepath.Join(dir, "index.db")
+	if err := dbManager.SnapshotTo(copyPath); err != nil {
+		return 0, err
+	}
+	info, err := os.Stat(copyPath)
+	if err != nil {
+		return 0, err
+	}
+	if err := copyTo(ctx, copyPath, location); err != nil {
+		return 0, fmt.Errorf("failed to upload index: %w", err)
+	}
+	return info.Size(), nil
+}
+
+// Pull downloads the index at location over the database at dbPath and
+// returns its size in bytes. The download is checked to be an index built
+// with the current SchemaVersion, and its file paths are moved from the
+// checkout it was built in to root, before it replaces the local one.
+func Pull(ctx context.Context, location, dbPath, root string) (int64, error) {
+	if _, err := Scheme(location); err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		return 0, err
+	}
+	tmp := dbPath + ".pull"
+	defer os.Remove(tmp)
+
+	if err := copyFrom(ctx, location, tmp); err != nil {
+		return 0, fmt.Errorf("failed to download index: %w", err)
+	}
+	version, err := db.FileSchemaVersion(tmp)
+	if err != nil {
+		return 0, fmt.Errorf("downloaded file is not a codegraph index: %w", err)
+	}
+	if version != db.SchemaVersion {
+		return 0, fmt.Errorf("remote index has schema version %d, this codegraph needs %d; rebuild and push it with a matching version", version, db.SchemaVersion)
+	}
+
+	pulled, err := db.NewManager(tmp)
+	if err != nil {
+		return 0, err
+	}
+	oldRoot, err := pulled.GetMeta(db.MetaRoot)
+	if err == nil && oldRoot != "" {
+		err = pulled.RebaseFiles(oldRoot, root)
+	}
+	if closeErr := pulled.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to move the index to this checkout: %w", err)
+	}
+	info, err := os.Stat(tmp)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp, dbPath); err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
@@ -0,0 +1,155 @@
+package remote
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+func newIndex(t *testing.T, path string) *db.Manager {
+	t.Helper()
+	m, err := db.NewManager(path)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	t.Cleanup(func() { m.Close() })
+	if err := m.Initialize(); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+	sym := &db.Symbol{ID: "a.go#f", Name: "f", Kind: "function", Language: "go", File: "a.go", Line: 1, CreatedAt: time.Unix(0, 0)}
+	if err := m.InsertSymbol(sym); err != nil {
+		t.Fatalf("InsertSymbol: %v", err)
+	}
+	return m
+}
+
+func TestScheme(t *testing.T) {
+	cases := map[string]string{
+		"s3://bucket/index.db":      SchemeS3,
+		"gs://bucket/index.db":      SchemeGCS,
+		"file:///mnt/shared/idx.db": SchemeFile,
+		"/mnt/shared/idx.db":        SchemeFile,
+		"shared/idx.db":             SchemeFile,
+	}
+	for location, want := range cases {
+		if got, err := Scheme(location); err != nil || got != want {
+			t.Errorf("Scheme(%q) = %q, %v; want %q", location, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "https://example.com/index.db"} {
+		if _, err := Scheme(bad); err == nil {
+			t.Errorf("Scheme(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestPushPullFileRemote(t *testing.T) {
+	dir := t.TempDir()
+	m := newIndex(t, filepath.Join(dir, "built", "index.db"))
+	shared := filepath.Join(dir, "mnt", "shared", "index.db")
+
+	if size, err := Push(context.Background(), m, "file://"+shared); err != nil || size == 0 {
+		t.Fatalf("Push = %d, %v", size, err)
+	}
+	local := filepath.Join(dir, "project", ".codegraph", "codegraph.db")
+	if size, err := Pull(context.Background(), shared, local, filepath.Join(dir, "project")); err != nil || size == 0 {
+		t.Fatalf("Pull = %d, %v", size, err)
+	}
+
+	pulled, err := db.NewReadOnlyManager(local)
+	if err != nil {
+		t.Fatalf("NewReadOnlyManager: %v", err)
+	}
+	defer pulled.Close()
+	syms, err := pulled.ListSymbols(db.QueryOptions{})
+	if err != nil || len(syms) != 1 || syms[0].Name != "f" {
+		t.Fatalf("pulled symbols = %+v, %v", syms, err)
+	}
+
+	// A file that is not an index never replaces the local one
+	bogus := filepath.Join(dir, "bogus.db")
+	if err := os.WriteFile(bogus, []byte("not a database"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Pull(context.Background(), bogus, local, filepath.Join(dir, "project")); err == nil {
+		t.Fatal("Pull of a non-index succeeded")
+	}
+	if _, err := os.Stat(local + ".pull"); !os.IsNotExist(err) {
+		t.Errorf("partial download left behind: %v", err)
+	}
+}
+
+func TestPullRebasesFiles(t *testing.T) {
+	dir := t.TempDir()
+	ciRoot := filepath.Join(dir, "ci", "checkout")
+	built := filepath.Join(dir, "built.db")
+	m, err := db.NewManager(built)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	if err := m.Initialize(); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+	ciFile := filepath.Join(ciRoot, "a.go")
+	sym := &db.Symbol{ID: "a.go#f", Name: "f", Kind: "function", Language: "go", File: ciFile, Line: 1, CreatedAt: time.Unix(0, 0)}
+	if err := m.InsertSymbol(sym); err != nil {
+		t.Fatalf("InsertSymbol: %v", err)
+	}
+	if err := m.UpdateFileMeta(ciFile, time.Unix(0, 0), "go", ""); err != nil {
+		t.Fatalf("UpdateFileMeta: %v", err)
+	}
+	if err := m.SetMeta(db.MetaRoot, ciRoot); err != nil {
+		t.Fatalf("SetMeta: %v", err)
+	}
+	m.Close()
+
+	root := filepath.Join(dir, "home", "project")
+	local := filepath.Join(root, ".codegraph", "graphs", "codegraph.db")
+	if _, err := Pull(context.Background(), built, local, root); err != nil {
+		t.Fatalf("Pull: %v", err)
+	}
+
+	pulled, err := db.NewReadOnlyManager(local)
+	if err != nil {
+		t.Fatalf("NewReadOnlyManager: %v", err)
+	}
+	defer pulled.Close()
+	localFile := filepath.Join(root, "a.go")
+	if syms, err := pulled.ListSymbols(db.QueryOptions{}); err != nil || len(syms) != 1 || syms[0].File != localFile {
+		t.Errorf("pulled symbols = %+v, %v; want file %s", syms, err, localFile)
+	}
+	if meta, err := pulled.GetFileMeta(localFile); err != nil || meta == nil {
+		t.Errorf("file_meta for %s = %+v, %v", localFile, meta, err)
+	}
+	if got, err := pulled.GetMeta(db.MetaRoot); err != nil || got != root {
+		t.Errorf("root = %q, %v; want %q", got, err, root)
+	}
+}
+
+func TestPushToObjectStores(t *testing.T) {
+	var commands []string
+	old := runCommand
+	defer func() { runCommand = old }()
+	runCommand = func(_ context.Context, name string, args ...string) error {
+		// Drop the source and destination, which include a temp path
+		commands = append(commands, name+" "+strings.Join(args[:len(args)-2], " "))
+		return nil
+	}
+
+	dir := t.TempDir()
+	m := newIndex(t, filepath.Join(dir, "index.db"))
+	if _, err := Push(context.Background(), m, "s3://ci/index.db"); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	if _, err := Push(context.Background(), m, "gs://ci/index.db"); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	if got, want := strings.Join(commands, "; "), "aws s3 cp --only-show-errors; gcloud storage cp"; got != want {
+		t.Errorf("commands = %s, want %s", got, want)
+	}
+}
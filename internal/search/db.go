@@ -2,6 +2,7 @@ package search
 
 import (
 	"context"
+	"strings"
 
 	"github.com/tk-425/Codegraph/internal/db"
 )
@@ -23,15 +24,27 @@ func (d *DatabaseTier) Name() string {
 
 // Search searches the database for symbols
 func (d *DatabaseTier) Search(ctx context.Context, opts SearchOptions) ([]SearchResult, error) {
-	var symbols []db.Symbol
-	var err error
-
-	if opts.ExactMatch {
-		symbols, err = d.db.GetSymbolByName(opts.Query, opts.Languages)
-	} else {
-		symbols, err = d.db.SearchSymbols(opts.Query, opts.Kind, opts.Languages)
+	filter := db.QueryOptions{
+		Languages:    opts.Languages,
+		Kinds:        opts.Kinds,
+		ExcludeKinds: opts.ExcludeKinds,
+		Sort:         opts.Sort,
+		Offset:       opts.Offset,
+		Limit:        opts.Limit,
+		Tests:        opts.Tests,
+		Owner:        opts.Owner,
+		Package:      opts.Package,
+		Tags:         opts.Tags,
+	}
+	symbols, err := d.search(opts.Query, filter, opts)
+	// A package-qualified name, db.Manager, finds Manager in the db
+	// package, unless a symbol is named so itself
+	if err == nil && len(symbols) == 0 && filter.Package == "" && !opts.Regex && !opts.Glob {
+		if i := strings.LastIndex(opts.Query, "."); i > 0 && i < len(opts.Query)-1 {
+			filter.Package = opts.Query[:i]
+			symbols, err = d.search(opts.Query[i+1:], filter, opts)
+		}
 	}
-
 	if err != nil {
 		return nil, err
 	}
@@ -48,8 +61,23 @@ func (d *DatabaseTier) Search(ctx context.Context, opts SearchOptions) ([]Search
 			Language:  sym.Language,
 			Source:    "db",
 			Score:     1.0,
+			Package:   sym.Package,
 		})
 	}
 
 	return results, nil
 }
+
+// search runs the database query matching how opts treats name
+func (d *DatabaseTier) search(name string, filter db.QueryOptions, opts SearchOptions) ([]db.Symbol, error) {
+	switch {
+	case opts.Regex:
+		return d.db.SearchSymbolsRegex(name, filter)
+	case opts.Glob:
+		return d.db.SearchSymbolsGlob(name, filter)
+	case opts.ExactMatch:
+		return d.db.FindSymbolsByName(name, filter)
+	default:
+		return d.db.SearchSymbols(name, filter)
+	}
+}
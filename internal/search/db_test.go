@@ -0,0 +1,54 @@
+package search
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+func TestDatabaseTierResolvesPackageQualifiedNames(t *testing.T) {
+	database, err := db.NewManager(filepath.Join(t.TempDir(), "graph.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	if err := database.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []db.Symbol{
+		{ID: "internal/db/manager.go#Manager", Name: "Manager", Kind: "struct", File: "internal/db/manager.go", Line: 3, Language: "go", Package: "db"},
+		{ID: "internal/lsp/manager.go#Manager", Name: "Manager", Kind: "struct", File: "internal/lsp/manager.go", Line: 3, Language: "go", Package: "lsp"},
+		{ID: "app.js#app.listen", Name: "app.listen", Kind: "function", File: "app.js", Line: 1, Language: "javascript", Package: "app"},
+	} {
+		s.CreatedAt = time.Now()
+		if err := database.InsertSymbol(&s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tier := NewDatabaseTier(database)
+
+	for query, want := range map[string]string{
+		"db.Manager":  "internal/db/manager.go",
+		"lsp.Manager": "internal/lsp/manager.go",
+		"app.listen":  "app.js", // Named so itself, not listen in package app
+	} {
+		results, err := tier.Search(context.Background(), SearchOptions{Query: query, ExactMatch: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 1 || results[0].File != want {
+			t.Errorf("search %s = %+v, want the symbol in %s", query, results, want)
+		}
+	}
+
+	results, err := tier.Search(context.Background(), SearchOptions{Query: "Manager", Package: "lsp"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Package != "lsp" {
+		t.Errorf("search Manager --package=lsp = %+v, want lsp's Manager", results)
+	}
+}
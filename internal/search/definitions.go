@@ -0,0 +1,198 @@
+package search
+
+import (
+	"regexp"
+	"strings"
+)
+
+// definitionPattern recognises one kind of declaration on a single source
+// line. The symbol name sits between prefix and suffix.
+type definitionPattern struct {
+	kinds  []string // Kinds the pattern finds; the first is reported by default
+	prefix string   // Regex preceding the name
+	suffix string   // Regex following the name
+	re     *regexp.Regexp
+}
+
+// Modifier runs shared by the C-family patterns below
+const (
+	jsExport       = `^\s*(?:export\s+)?(?:default\s+)?`
+	javaModifiers  = `^\s*(?:(?:public|protected|private|abstract|final|static|sealed|strictfp)\s+)*`
+	csModifiers    = `^\s*(?:(?:public|protected|private|internal|abstract|sealed|static|partial|virtual|override|async|readonly|unsafe|extern|new)\s+)*`
+	rustVisibility = `^\s*(?:pub(?:\([^)]*\))?\s+)?`
+	swiftModifiers = `^\s*(?:(?:public|private|internal|fileprivate|open|static|class|final|override|mutating|@\w+)\s+)*`
+)
+
+// definitionPatterns lists declaration shapes per language, most specific
+// first. They are heuristics for the ripgrep tier, not a parser: a line is
+// tagged with the first pattern it matches.
+var definitionPatterns = map[string][]*definitionPattern{
+	"go": {
+		{kinds: []string{"method"}, prefix: `^\s*func\s+\([^)]*\)\s*`, suffix: `\s*[\[(]`},
+		{kinds: []string{"function"}, prefix: `^\s*func\s+`, suffix: `\s*[\[(]`},
+		{kinds: []string{"class", "struct"}, prefix: `^\s*(?:type\s+)?`, suffix: `(?:\[[^\]]*\])?\s+struct\b`},
+		{kinds: []string{"interface"}, prefix: `^\s*(?:type\s+)?`, suffix: `(?:\[[^\]]*\])?\s+interface\b`},
+		{kinds: []string{"constant"}, prefix: `^\s*const\s+`, suffix: `\b`},
+		{kinds: []string{"variable"}, prefix: `^\s*var\s+`, suffix: `\b`},
+	},
+	"python": {
+		{kinds: []string{"function"}, prefix: `^(?:async\s+)?def\s+`, suffix: `\s*\(`},
+		{kinds: []string{"method"}, prefix: `^\s+(?:async\s+)?def\s+`, suffix: `\s*\(`},
+		{kinds: []string{"class"}, prefix: `^\s*class\s+`, suffix: `\b`},
+		{kinds: []string{"variable", "constant"}, prefix: `^`, suffix: `\s*(?::[^=]*)?=[^=]`},
+	},
+	"typescript": {
+		{kinds: []string{"function"}, prefix: jsExport + `(?:async\s+)?function\s*\*?\s*`, suffix: `\s*[<(]`},
+		{kinds: []string{"function"}, prefix: jsExport + `(?:const|let|var)\s+`, suffix: `\s*(?::[^=]+)?=\s*(?:async\s+)?(?:function\b|\([^)]*\)\s*(?::[^=]+)?=>|\w+\s*=>)`},
+		{kinds: []string{"class"}, prefix: jsExport + `(?:abstract\s+)?class\s+`, suffix: `\b`},
+		{kinds: []string{"interface"}, prefix: jsExport + `interface\s+`, suffix: `\b`},
+		{kinds: []string{"type"}, prefix: jsExport + `type\s+`, suffix: `\s*(?:<[^=]*>)?\s*=`},
+		{kinds: []string{"enum"}, prefix: jsExport + `(?:const\s+)?enum\s+`, suffix: `\b`},
+		{kinds: []string{"method"}, prefix: `^\s+(?:(?:public|private|protected|static|async|readonly|override|abstract|get|set)\s+)*`, suffix: `\s*(?:<[^>]*>)?\([^)]*\)\s*(?::[^{=]+)?\{`},
+		{kinds: []string{"variable", "constant"}, prefix: jsExport + `(?:const|let|var)\s+`, suffix: `\b`},
+	},
+	"java": {
+		{kinds: []string{"class"}, prefix: javaModifiers + `(?:class|record)\s+`, suffix: `\b`},
+		{kinds: []string{"interface"}, prefix: javaModifiers + `@?interface\s+`, suffix: `\b`},
+		{kinds: []string{"enum"}, prefix: javaModifiers + `enum\s+`, suffix: `\b`},
+		{kinds: []string{"method", "function"}, prefix: javaModifiers + `(?:(?:synchronized|native|default)\s+)*(?:<[^>]*>\s*)?[\w<>\[\],.?]+\s+`, suffix: `\s*\(`},
+	},
+	"csharp": {
+		{kinds: []string{"struct"}, prefix: csModifiers + `(?:record\s+)?struct\s+`, suffix: `\b`},
+		{kinds: []string{"class"}, prefix: csModifiers + `(?:class|record)\s+`, suffix: `\b`},
+		{kinds: []string{"interface"}, prefix: csModifiers + `interface\s+`, suffix: `\b`},
+		{kinds: []string{"enum"}, prefix: csModifiers + `enum\s+`, suffix: `\b`},
+		{kinds: []string{"method", "function"}, prefix: csModifiers + `[\w<>\[\],.?]+\s+`, suffix: `\s*(?:<[^>]*>)?\s*\(`},
+		{kinds: []string{"property"}, prefix: csModifiers + `[\w<>\[\],.?]+\s+`, suffix: `\s*\{\s*(?:get|set|init)\b`},
+	},
+	"rust": {
+		{kinds: []string{"function", "method"}, prefix: rustVisibility + `(?:(?:const|async|unsafe|extern(?:\s+"[^"]*")?)\s+)*fn\s+`, suffix: `\s*[<(]`},
+		{kinds: []string{"class", "struct"}, prefix: rustVisibility + `struct\s+`, suffix: `\b`},
+		{kinds: []string{"enum"}, prefix: rustVisibility + `enum\s+`, suffix: `\b`},
+		{kinds: []string{"interface"}, prefix: rustVisibility + `(?:unsafe\s+)?trait\s+`, suffix: `\b`},
+		{kinds: []string{"type"}, prefix: rustVisibility + `type\s+`, suffix: `\b`},
+		{kinds: []string{"constant", "variable"}, prefix: rustVisibility + `(?:const|static)\s+(?:mut\s+)?`, suffix: `\s*:`},
+		{kinds: []string{"module"}, prefix: rustVisibility + `mod\s+`, suffix: `\b`},
+	},
+	"swift": {
+		{kinds: []string{"function", "method"}, prefix: swiftModifiers + `func\s+`, suffix: `\s*[<(]`},
+		{kinds: []string{"class"}, prefix: swiftModifiers + `class\s+`, suffix: `\b`},
+		{kinds: []string{"class", "struct"}, prefix: swiftModifiers + `struct\s+`, suffix: `\b`},
+		{kinds: []string{"interface"}, prefix: swiftModifiers + `protocol\s+`, suffix: `\b`},
+		{kinds: []string{"enum"}, prefix: swiftModifiers + `enum\s+`, suffix: `\b`},
+	},
+	"ocaml": {
+		{kinds: []string{"function", "variable"}, prefix: `^\s*let\s+(?:rec\s+)?`, suffix: `\b`},
+		{kinds: []string{"type"}, prefix: `^\s*type\s+(?:'\w+\s+|\([^)]*\)\s+)?`, suffix: `\b`},
+		{kinds: []string{"module"}, prefix: `^\s*module\s+(?:type\s+)?`, suffix: `\b`},
+	},
+	"proto": {
+		{kinds: []string{"interface"}, prefix: `^\s*service\s+`, suffix: `\b`},
+		{kinds: []string{"method"}, prefix: `^\s*rpc\s+`, suffix: `\s*\(`},
+		{kinds: []string{"struct", "class"}, prefix: `^\s*message\s+`, suffix: `\b`},
+		{kinds: []string{"enum"}, prefix: `^\s*enum\s+`, suffix: `\b`},
+	},
+}
+
+// definitionKeywords are statement words that the looser patterns (method
+// declarations in particular) would otherwise mistake for names or types, as
+// in "if (ok) {" or "return compute(x);".
+var definitionKeywords = map[string]bool{
+	"if": true, "for": true, "while": true, "switch": true, "catch": true,
+	"return": true, "function": true, "new": true, "else": true, "do": true,
+	"throw": true, "await": true, "yield": true, "case": true,
+}
+
+func init() {
+	definitionPatterns["javascript"] = definitionPatterns["typescript"]
+	for _, patterns := range definitionPatterns {
+		for _, p := range patterns {
+			if p.re == nil {
+				p.re = regexp.MustCompile(p.prefix + `(\w+)` + p.suffix)
+			}
+		}
+	}
+}
+
+// allowsKinds reports whether the pattern can find one of kinds
+func (p *definitionPattern) allowsKinds(kinds []string) bool {
+	if len(kinds) == 0 {
+		return true
+	}
+	for _, kind := range kinds {
+		if containsString(p.kinds, kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// reportedKind prefers the kind the user asked for over the default label,
+// so --kind=struct results are not reported as classes.
+func (p *definitionPattern) reportedKind(kinds []string) string {
+	for _, kind := range kinds {
+		if containsString(p.kinds, kind) {
+			return kind
+		}
+	}
+	return p.kinds[0]
+}
+
+// definitionRegexp builds a ripgrep pattern matching declarations of the
+// requested kinds whose name fits the query. Names found this way are checked
+// again by matchDefinition, which also rejects cross-language matches.
+func definitionRegexp(opts SearchOptions) string {
+	var name string
+	switch {
+	case opts.Regex:
+		name = `\w+` // Filtered by nameMatcher afterwards
+	case opts.Glob:
+		name = translateGlob(opts.Query, `\w*`, `\w`)
+	case opts.ExactMatch:
+		name = regexp.QuoteMeta(opts.Query)
+	default:
+		name = `(?i:\w*` + regexp.QuoteMeta(opts.Query) + `\w*)`
+	}
+
+	languages := opts.Languages
+	if len(languages) == 0 {
+		for lang := range definitionPatterns {
+			languages = append(languages, lang)
+		}
+	}
+
+	seen := make(map[string]bool)
+	var alternatives []string
+	for _, lang := range languages {
+		for _, p := range definitionPatterns[lang] {
+			alt := p.prefix + name + p.suffix
+			if p.allowsKinds(opts.Kinds) && !seen[alt] {
+				seen[alt] = true
+				alternatives = append(alternatives, "(?:"+alt+")")
+			}
+		}
+	}
+	if len(alternatives) == 0 {
+		return ""
+	}
+	return strings.Join(alternatives, "|")
+}
+
+// matchDefinition extracts the declared name and guessed kind from line,
+// considering only patterns for the given kinds (all kinds when empty).
+func matchDefinition(line, language string, kinds []string, match func(string) bool) (name, kind string, ok bool) {
+	if fields := strings.Fields(line); len(fields) > 0 && definitionKeywords[fields[0]] {
+		return "", "", false
+	}
+	for _, p := range definitionPatterns[language] {
+		if !p.allowsKinds(kinds) {
+			continue
+		}
+		m := p.re.FindStringSubmatch(line)
+		if m == nil || definitionKeywords[m[1]] || !match(m[1]) {
+			continue
+		}
+		return m[1], p.reportedKind(kinds), true
+	}
+	return "", "", false
+}
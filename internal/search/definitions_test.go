@@ -0,0 +1,103 @@
+package search
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestMatchDefinition(t *testing.T) {
+	cases := []struct {
+		lang, line string
+		kinds      []string
+		wantName   string
+		wantKind   string
+	}{
+		{"go", "func (s *Server) handleRequest(w http.ResponseWriter) {", nil, "handleRequest", "method"},
+		{"go", "func handleRequest[T any](v T) {", nil, "handleRequest", "function"},
+		{"go", "type handleRequest struct {", []string{"struct"}, "handleRequest", "struct"},
+		{"go", "\treturn handleRequest(w)", nil, "", ""},
+		{"python", "async def handle_request(self):", nil, "handle_request", "function"},
+		{"python", "    def handle_request(self):", nil, "handle_request", "method"},
+		{"python", "class HandleRequest(Base):", nil, "HandleRequest", "class"},
+		{"typescript", "export const handleRequest = async (req: Req) => {", nil, "handleRequest", "function"},
+		{"typescript", "export interface HandleRequest {", nil, "HandleRequest", "interface"},
+		{"typescript", "  handleRequest(req: Req): void {", nil, "handleRequest", "method"},
+		{"typescript", "  if (handleRequest) {", nil, "", ""},
+		{"java", "    public static Response handleRequest(Request req) {", nil, "handleRequest", "method"},
+		{"java", "        return handleRequest(req);", nil, "", ""},
+		{"csharp", "    public async Task<Response> HandleRequest(Request req)", nil, "HandleRequest", "method"},
+		{"csharp", "public sealed record HandleRequest(int Id);", nil, "HandleRequest", "class"},
+		{"csharp", "    public string HandleRequest { get; set; }", nil, "HandleRequest", "property"},
+		{"csharp", "        await HandleRequest(req);", nil, "", ""},
+		{"rust", "pub(crate) async fn handle_request(req: Request) -> Response {", nil, "handle_request", "function"},
+		{"rust", "pub trait HandleRequest {", nil, "HandleRequest", "interface"},
+		{"swift", "    @objc private func handleRequest(_ req: Request) {", nil, "handleRequest", "function"},
+		{"ocaml", "let rec handle_request req =", nil, "handle_request", "function"},
+	}
+
+	any := func(string) bool { return true }
+	for _, tc := range cases {
+		name, kind, ok := matchDefinition(tc.line, tc.lang, tc.kinds, any)
+		if !ok {
+			if tc.wantName != "" {
+				t.Errorf("%s %q: no match, want %s %s", tc.lang, tc.line, tc.wantKind, tc.wantName)
+			}
+			continue
+		}
+		if name != tc.wantName || kind != tc.wantKind {
+			t.Errorf("%s %q = %s %s, want %s %s", tc.lang, tc.line, kind, name, tc.wantKind, tc.wantName)
+		}
+	}
+}
+
+func TestDefinitionRegexpFiltersByKindAndName(t *testing.T) {
+	opts := SearchOptions{Query: "handle", Kinds: []string{"function"}, Languages: []string{"go"}}
+	re := regexp.MustCompile(definitionRegexp(opts))
+
+	for line, want := range map[string]bool{
+		"func handleRequest(w http.ResponseWriter) {": true,
+		"func HandleLogin() {":                        true, // Plain queries ignore case
+		"func (s *Server) handleRequest() {":          false,
+		"\thandleRequest(w)":                          false,
+		"func parse() {":                              false,
+	} {
+		if got := re.MatchString(line); got != want {
+			t.Errorf("%q matched = %v, want %v", line, got, want)
+		}
+	}
+
+	if got := definitionRegexp(SearchOptions{Query: "x", Kinds: []string{"enum"}, Languages: []string{"python"}}); got != "" {
+		t.Errorf("python has no enum pattern, got %q", got)
+	}
+}
+
+func TestRipgrepParseOutputTagsDefinitions(t *testing.T) {
+	r := NewRipgrepTier("/repo")
+	output := "/repo/a.go:3:1:func handleRequest() {\n" +
+		"/repo/a.go:9:2:\thandleRequest()\n" +
+		"/repo/b.py:1:1:def handleRequest():\n"
+
+	results, err := r.parseOutput(output, SearchOptions{Query: "handle"})
+	if err != nil {
+		t.Fatalf("parseOutput: %v", err)
+	}
+	got := []string{}
+	for _, res := range results {
+		got = append(got, res.Kind+" "+res.Name)
+	}
+	want := []string{"function handleRequest", "match handle", "function handleRequest"}
+	if len(got) != len(want) {
+		t.Fatalf("results = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("results[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	// With --kind, call sites are dropped and paging counts definitions only
+	results, _ = r.parseOutput(output, SearchOptions{Query: "handle", Kinds: []string{"function"}, Offset: 1})
+	if len(results) != 1 || results[0].File != "b.py" {
+		t.Errorf("kind-filtered results = %+v", results)
+	}
+}
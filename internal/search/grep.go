@@ -0,0 +1,189 @@
+package search
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/tk-425/Codegraph/internal/config"
+	"github.com/tk-425/Codegraph/internal/db"
+	"github.com/tk-425/Codegraph/internal/indexer"
+	"github.com/tk-425/Codegraph/internal/owners"
+)
+
+// GrepTier is a pure-Go text search used when ripgrep is not installed. It
+// walks the project's source files (respecting .cgignore) and matches lines
+// with the same patterns the ripgrep tier would use.
+type GrepTier struct {
+	rootPath   string
+	ignorePath string
+	indexCfg   config.IndexConfig
+}
+
+// NewGrepTier creates a new native grep search tier
+func NewGrepTier(rootPath, ignorePath string, indexCfg config.IndexConfig) *GrepTier {
+	return &GrepTier{rootPath: rootPath, ignorePath: ignorePath, indexCfg: indexCfg}
+}
+
+// Name returns the tier name
+func (g *GrepTier) Name() string {
+	return "grep"
+}
+
+// Search scans source files line by line for the query
+func (g *GrepTier) Search(ctx context.Context, opts SearchOptions) ([]SearchResult, error) {
+	if len(opts.Tags) > 0 || opts.Package != "" {
+		return []SearchResult{}, nil // Tags and packages are only known to the index
+	}
+	pattern := textRegexp(opts)
+	if pattern == "" {
+		return []SearchResult{}, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	match, err := nameMatcher(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := newFileFilter(g.rootPath, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	scanner, err := indexer.NewScannerWithConfig(g.rootPath, g.ignorePath, g.indexCfg)
+	if err != nil {
+		return nil, err
+	}
+	files, err := scanner.Scan()
+	if err != nil {
+		return nil, err
+	}
+
+	results := []SearchResult{}
+	skipped := 0
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		relPath := filepath.ToSlash(file.RelPath)
+		if !languageAllowed(opts.Languages, extensionToLanguage(filepath.Ext(relPath))) || !filter.allows(relPath) {
+			continue
+		}
+
+		f, err := os.Open(file.Path)
+		if err != nil {
+			continue
+		}
+		lines := bufio.NewScanner(f)
+		lines.Buffer(make([]byte, 64*1024), 1024*1024)
+		for lineNum := 1; lines.Scan(); lineNum++ {
+			text := lines.Text()
+			loc := re.FindStringIndex(text)
+			if loc == nil {
+				continue
+			}
+			result, ok := textMatchResult(relPath, lineNum, loc[0]+1, text, opts, match)
+			if !ok {
+				continue
+			}
+			if skipped < opts.Offset {
+				skipped++
+				continue
+			}
+			result.Source = "grep"
+			results = append(results, result)
+			if opts.Limit > 0 && len(results) >= opts.Limit {
+				f.Close()
+				return results, nil
+			}
+		}
+		f.Close()
+	}
+
+	return results, nil
+}
+
+// textRegexp returns the Go regular expression matching the lines ripgrep
+// would report for opts, or "" when no line can match.
+func textRegexp(opts SearchOptions) string {
+	switch {
+	case len(opts.Kinds) > 0:
+		return definitionRegexp(opts)
+	case opts.Regex:
+		return opts.Query
+	case opts.Glob:
+		return globToRegexp(opts.Query)
+	case opts.ExactMatch:
+		return `\b` + regexp.QuoteMeta(opts.Query) + `\b`
+	default:
+		return regexp.QuoteMeta(opts.Query)
+	}
+}
+
+// languageAllowed applies a --lang filter the way ripgrep's type filters do:
+// typescript and javascript select each other's files.
+func languageAllowed(languages []string, lang string) bool {
+	if len(languages) == 0 {
+		return true
+	}
+	for _, l := range languages {
+		if l == lang || (isJSFamily(l) && isJSFamily(lang)) {
+			return true
+		}
+	}
+	return false
+}
+
+// fileFilter applies the file-level filters of opts (test files, owner) to
+// the files the scanning tiers read. Without an index, an owner is matched
+// against CODEOWNERS only.
+type fileFilter struct {
+	tests string
+	owner string
+	rules *owners.Rules
+}
+
+func newFileFilter(rootPath string, opts SearchOptions) (*fileFilter, error) {
+	f := &fileFilter{tests: opts.Tests, owner: opts.Owner}
+	if opts.Owner != "" {
+		rules, err := owners.Load(rootPath)
+		if err != nil {
+			return nil, err
+		}
+		f.rules = rules
+	}
+	return f, nil
+}
+
+// allows reports whether the file at relPath passes the filters
+func (f *fileFilter) allows(relPath string) bool {
+	switch f.tests {
+	case db.TestsExclude:
+		if indexer.IsTestFile(relPath) {
+			return false
+		}
+	case db.TestsOnly:
+		if !indexer.IsTestFile(relPath) {
+			return false
+		}
+	}
+	if f.owner == "" {
+		return true
+	}
+	for _, owner := range f.rules.Owners(relPath) {
+		if strings.EqualFold(owner, f.owner) {
+			return true
+		}
+	}
+	return false
+}
+
+func isJSFamily(lang string) bool {
+	return lang == "typescript" || lang == "javascript"
+}
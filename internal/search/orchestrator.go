@@ -17,15 +17,25 @@ type SearchResult struct {
 	Source     string  `json:"source"` // "db", "treesitter", "ripgrep"
 	Score      float64 `json:"score"`
 	Context    string  `json:"context,omitempty"` // Line content for ripgrep results
+	Package    string  `json:"package,omitempty"` // Package or module, for index results
 }
 
 // SearchOptions configures search behavior
 type SearchOptions struct {
-	Query     string
-	Kind      string   // Optional: filter by kind (function, class, etc.)
-	Languages []string // Optional: filter by language
-	Limit     int      // Max results (0 = unlimited)
-	ExactMatch bool    // Require exact name match
+	Query        string
+	Kinds        []string // Optional: filter by kind (function, class, etc.)
+	ExcludeKinds []string // Optional: drop these kinds
+	Languages    []string // Optional: filter by language
+	Offset       int      // Results to skip (for paging)
+	Limit        int      // Max results (0 = unlimited)
+	Sort         string   // One of db.SortKeys; empty keeps each tier's order
+	ExactMatch   bool     // Require exact name match
+	Regex        bool     // Treat Query as a regular expression
+	Glob         bool     // Treat Query as a shell glob (*, ?, [...])
+	Tests        string   // Test files: db.TestsInclude, db.TestsExclude or db.TestsOnly
+	Owner        string   // Only symbols of this CODEOWNERS owner or git blame author
+	Tags         []string // Only symbols with one of these annotation tags; index tiers only
+	Package      string   // Only symbols in this package or module; index tiers only
 }
 
 // Tier represents a search tier in the fallback chain
@@ -44,13 +54,22 @@ func NewOrchestrator(tiers ...Tier) *Orchestrator {
 	return &Orchestrator{tiers: tiers}
 }
 
-// Search executes search across all tiers until results are found
+// Search executes search across all tiers until results are found. It
+// returns an error only when every tier failed.
 func (o *Orchestrator) Search(ctx context.Context, opts SearchOptions) ([]SearchResult, error) {
+	var lastErr error
+	failed := 0
 	for _, tier := range o.tiers {
 		results, err := tier.Search(ctx, opts)
 		if err != nil {
+			// Later tiers would fail the same way once time is up
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("%s tier: %w", tier.Name(), err)
+			}
 			// Log error but continue to next tier
 			fmt.Printf("   ⚠️  %s tier error: %v\n", tier.Name(), err)
+			lastErr = err
+			failed++
 			continue
 		}
 
@@ -61,11 +80,26 @@ func (o *Orchestrator) Search(ctx context.Context, opts SearchOptions) ([]Search
 			}
 			return results, nil
 		}
+		// A page past this tier's last match stays empty: the next tier
+		// would page through different results in a different order
+		if opts.Offset > 0 && hasResults(ctx, tier, opts) {
+			return []SearchResult{}, nil
+		}
 	}
 
+	if failed > 0 && failed == len(o.tiers) {
+		return nil, lastErr
+	}
 	return []SearchResult{}, nil
 }
 
+// hasResults reports whether tier finds anything for opts before paging
+func hasResults(ctx context.Context, tier Tier, opts SearchOptions) bool {
+	opts.Offset, opts.Limit = 0, 1
+	results, err := tier.Search(ctx, opts)
+	return err == nil && len(results) > 0
+}
+
 // SearchAll executes search across all tiers and merges results
 func (o *Orchestrator) SearchAll(ctx context.Context, opts SearchOptions) ([]SearchResult, error) {
 	var allResults []SearchResult
@@ -0,0 +1,82 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+type stubTier struct {
+	name string
+	ran  bool
+	err  error
+}
+
+func (s *stubTier) Name() string { return s.name }
+
+func (s *stubTier) Search(ctx context.Context, opts SearchOptions) ([]SearchResult, error) {
+	s.ran = true
+	if s.err != nil {
+		return nil, s.err
+	}
+	return []SearchResult{{Name: opts.Query, Source: s.name}}, nil
+}
+
+func TestOrchestratorStopsWhenContextIsDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	slow := &stubTier{name: "ripgrep", err: ctx.Err()}
+	next := &stubTier{name: "grep"}
+	_, err := NewOrchestrator(slow, next).Search(ctx, SearchOptions{Query: "parse"})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want the context's error", err)
+	}
+	if next.ran {
+		t.Error("tiers after the context is done should not run")
+	}
+
+	// A tier failing on its own still falls through to the next
+	broken := &stubTier{name: "ripgrep", err: errors.New("rg: not found")}
+	next = &stubTier{name: "grep"}
+	results, err := NewOrchestrator(broken, next).Search(context.Background(), SearchOptions{Query: "parse"})
+	if err != nil || len(results) != 1 || results[0].Source != "grep" {
+		t.Errorf("results = %+v, err = %v, want the grep tier's", results, err)
+	}
+}
+
+func TestOrchestratorPagePastTheLastMatchIsEmpty(t *testing.T) {
+	database, err := db.NewManager(filepath.Join(t.TempDir(), "graph.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	if err := database.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"parseArgs", "parseFlags"} {
+		s := db.Symbol{ID: "main.go#" + name, Name: name, Kind: "function", File: "main.go", Line: 1, Language: "go", CreatedAt: time.Now()}
+		if err := database.InsertSymbol(&s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	grep := &stubTier{name: "ripgrep"}
+	search := NewOrchestrator(NewDatabaseTier(database), grep)
+	results, err := search.Search(context.Background(), SearchOptions{Query: "parse", Offset: 2, Limit: 2})
+	if err != nil || len(results) != 0 {
+		t.Errorf("page past the database's matches = %+v, %v; want empty", results, err)
+	}
+	if grep.ran {
+		t.Error("a page past the database's matches fell back to ripgrep")
+	}
+
+	// With no database matches at all, every page comes from ripgrep
+	results, err = search.Search(context.Background(), SearchOptions{Query: "render", Offset: 2, Limit: 2})
+	if err != nil || len(results) != 1 || results[0].Source != "ripgrep" {
+		t.Errorf("page without database matches = %+v, %v; want ripgrep's", results, err)
+	}
+}
@@ -0,0 +1,71 @@
+package search
+
+import (
+	"regexp"
+	"strings"
+)
+
+// globToRegexp converts a shell glob into a regular expression for matching
+// identifiers in source text: * and ? never cross non-word characters, so
+// "Handle*" matches HandleRequest but not the "Handle(" in "Handle(req)".
+func globToRegexp(glob string) string {
+	return `\b` + translateGlob(glob, `\w*`, `\w`) + `\b`
+}
+
+// globToNameRegexp converts a shell glob into a regular expression that must
+// match a whole symbol name, like SQLite GLOB.
+func globToNameRegexp(glob string) string {
+	return "^" + translateGlob(glob, ".*", ".") + "$"
+}
+
+// translateGlob rewrites glob as a regular expression, using star and any for
+// the * and ? wildcards.
+func translateGlob(glob, star, any string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			b.WriteString(star)
+		case '?':
+			b.WriteString(any)
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// nameMatcher returns a predicate matching symbol names the way the database
+// tier does: case-insensitive substring by default, exact with ExactMatch,
+// unanchored for Regex, and whole-name for Glob.
+func nameMatcher(opts SearchOptions) (func(string) bool, error) {
+	switch {
+	case opts.Regex, opts.Glob:
+		pattern := opts.Query
+		if opts.Glob {
+			pattern = globToNameRegexp(opts.Query)
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString, nil
+	case opts.ExactMatch:
+		return func(name string) bool { return name == opts.Query }, nil
+	default:
+		query := strings.ToLower(opts.Query)
+		return func(name string) bool { return strings.Contains(strings.ToLower(name), query) }, nil
+	}
+}
@@ -26,6 +26,9 @@ func (r *RipgrepTier) Name() string {
 
 // Search uses ripgrep to find matches
 func (r *RipgrepTier) Search(ctx context.Context, opts SearchOptions) ([]SearchResult, error) {
+	if len(opts.Tags) > 0 || opts.Package != "" {
+		return []SearchResult{}, nil // Tags and packages are only known to the index
+	}
 	args := []string{
 		"--line-number",
 		"--column",
@@ -47,15 +50,41 @@ func (r *RipgrepTier) Search(ctx context.Context, opts SearchOptions) ([]SearchR
 			args = append(args, "--type", "rust")
 		case "java":
 			args = append(args, "--type", "java")
+		case "csharp":
+			args = append(args, "--type", "csharp")
+		case "proto":
+			args = append(args, "--type", "protobuf")
+		}
+	}
+
+	pattern := opts.Query
+	switch {
+	case len(opts.Kinds) > 0:
+		// Only declarations of the requested kinds, not every occurrence
+		pattern = definitionRegexp(opts)
+		if pattern == "" {
+			return []SearchResult{}, nil
+		}
+	case opts.Regex:
+		// Passed through as-is
+	case opts.Glob:
+		pattern = globToRegexp(opts.Query)
+	default:
+		// Plain queries are literal text, not patterns
+		args = append(args, "--fixed-strings")
+		// Word boundary for better matching
+		if opts.ExactMatch {
+			args = append(args, "--word-regexp")
 		}
 	}
 
-	// Word boundary for better matching
-	if opts.ExactMatch {
-		args = append(args, "--word-regexp")
+	// Sorting disables rg's parallelism but makes paging deterministic
+	if opts.Sort != "" || opts.Offset > 0 {
+		args = append(args, "--sort", "path")
 	}
 
-	args = append(args, opts.Query, r.rootPath)
+	// "--" keeps patterns starting with "-" from being read as flags
+	args = append(args, "--", pattern, r.rootPath)
 
 	cmd := exec.CommandContext(ctx, "rg", args...)
 	output, err := cmd.Output()
@@ -73,8 +102,18 @@ func (r *RipgrepTier) Search(ctx context.Context, opts SearchOptions) ([]SearchR
 // parseOutput parses ripgrep output into SearchResults
 func (r *RipgrepTier) parseOutput(output string, opts SearchOptions) ([]SearchResult, error) {
 	var results []SearchResult
+	skipped := 0
 	scanner := bufio.NewScanner(strings.NewReader(output))
 
+	match, err := nameMatcher(opts)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := newFileFilter(r.rootPath, opts)
+	if err != nil {
+		return nil, err
+	}
+
 	for scanner.Scan() {
 		line := scanner.Text()
 		// Format: file:line:column:content
@@ -86,29 +125,28 @@ func (r *RipgrepTier) parseOutput(output string, opts SearchOptions) ([]SearchRe
 		file := parts[0]
 		lineNum, _ := strconv.Atoi(parts[1])
 		colNum, _ := strconv.Atoi(parts[2])
-		content := strings.TrimSpace(parts[3])
 
 		// Make file path relative if possible
 		relPath, err := filepath.Rel(r.rootPath, file)
 		if err == nil {
 			file = relPath
 		}
+		if !filter.allows(file) {
+			continue
+		}
 
-		// Detect language from extension
-		ext := filepath.Ext(file)
-		lang := extensionToLanguage(ext)
-
-		results = append(results, SearchResult{
-			Name:     opts.Query,
-			Kind:     "match",
-			File:     file,
-			Line:     lineNum,
-			Column:   colNum,
-			Language: lang,
-			Source:   "ripgrep",
-			Score:    0.5, // Lower score than DB results
-			Context:  content,
-		})
+		result, ok := textMatchResult(file, lineNum, colNum, parts[3], opts, match)
+		if !ok {
+			continue
+		}
+
+		if skipped < opts.Offset {
+			skipped++
+			continue
+		}
+
+		result.Source = "ripgrep"
+		results = append(results, result)
 
 		// Apply limit
 		if opts.Limit > 0 && len(results) >= opts.Limit {
@@ -119,6 +157,34 @@ func (r *RipgrepTier) parseOutput(output string, opts SearchOptions) ([]SearchRe
 	return results, scanner.Err()
 }
 
+// textMatchResult turns a matching source line into a SearchResult. Lines that
+// look like a declaration are tagged with the declared name and a guessed
+// kind; with opts.Kinds set, all other lines are rejected.
+func textMatchResult(file string, line, column int, content string, opts SearchOptions, match func(string) bool) (SearchResult, bool) {
+	lang := extensionToLanguage(filepath.Ext(file))
+
+	name, kind := opts.Query, "match"
+	if defName, defKind, ok := matchDefinition(content, lang, opts.Kinds, match); ok {
+		name, kind = defName, defKind
+	} else if len(opts.Kinds) > 0 {
+		return SearchResult{}, false // Matched another language's pattern, or a filtered name
+	}
+	if containsString(opts.ExcludeKinds, kind) {
+		return SearchResult{}, false
+	}
+
+	return SearchResult{
+		Name:     name,
+		Kind:     kind,
+		File:     file,
+		Line:     line,
+		Column:   column,
+		Language: lang,
+		Score:    0.5, // Lower score than DB results
+		Context:  strings.TrimSpace(content),
+	}, true
+}
+
 func extensionToLanguage(ext string) string {
 	switch ext {
 	case ".go":
@@ -137,6 +203,8 @@ func extensionToLanguage(ext string) string {
 		return "swift"
 	case ".ml", ".mli":
 		return "ocaml"
+	case ".proto":
+		return "proto"
 	default:
 		return "unknown"
 	}
@@ -0,0 +1,91 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/tk-425/Codegraph/internal/db"
+	"github.com/tk-425/Codegraph/internal/embed"
+)
+
+// SemanticTier ranks symbols by embedding similarity to a natural-language
+// query. It requires embeddings computed by `codegraph build`.
+type SemanticTier struct {
+	db       *db.Manager
+	provider embed.Provider
+}
+
+// NewSemanticTier creates a new semantic search tier
+func NewSemanticTier(dbManager *db.Manager, provider embed.Provider) *SemanticTier {
+	return &SemanticTier{db: dbManager, provider: provider}
+}
+
+// Name returns the tier name
+func (s *SemanticTier) Name() string {
+	return "semantic"
+}
+
+// Search embeds the query and returns the most similar symbols
+func (s *SemanticTier) Search(ctx context.Context, opts SearchOptions) ([]SearchResult, error) {
+	candidates, err := s.db.GetSymbolVectors(s.provider.Model(), db.QueryOptions{
+		Languages:    opts.Languages,
+		Kinds:        opts.Kinds,
+		ExcludeKinds: opts.ExcludeKinds,
+		Tests:        opts.Tests,
+		Owner:        opts.Owner,
+		Package:      opts.Package,
+		Tags:         opts.Tags,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no embeddings for %s; run 'codegraph build' with [embeddings] configured", s.provider.Model())
+	}
+
+	vectors, err := s.provider.Embed(ctx, []string{opts.Query})
+	if err != nil {
+		return nil, err
+	}
+	query := vectors[0]
+
+	results := make([]SearchResult, 0, len(candidates))
+	for _, c := range candidates {
+		score := embed.Cosine(query, c.Vector)
+		if score <= 0 {
+			continue
+		}
+		results = append(results, SearchResult{
+			Name:      c.Name,
+			Kind:      c.Kind,
+			File:      c.File,
+			Line:      c.Line,
+			Column:    c.Column,
+			Signature: c.Signature,
+			Language:  c.Language,
+			Source:    "semantic",
+			Score:     score,
+		})
+	}
+
+	// Most similar first; ties keep a stable, deterministic order
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if results[i].File != results[j].File {
+			return results[i].File < results[j].File
+		}
+		return results[i].Line < results[j].Line
+	})
+
+	if opts.Offset >= len(results) {
+		return []SearchResult{}, nil
+	}
+	results = results[opts.Offset:]
+	if opts.Limit > 0 && len(results) > opts.Limit {
+		results = results[:opts.Limit]
+	}
+	return results, nil
+}
@@ -0,0 +1,201 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/tk-425/Codegraph/internal/config"
+	"github.com/tk-425/Codegraph/internal/db"
+	"github.com/tk-425/Codegraph/internal/indexer"
+)
+
+// TreeSitterTier parses project files on demand and matches the symbols they
+// define. It finds symbols the database has not indexed yet, at the cost of
+// scanning the tree on every search.
+type TreeSitterTier struct {
+	rootPath   string
+	ignorePath string
+	indexCfg   config.IndexConfig
+	kindsCfg   config.KindsConfig
+}
+
+// NewTreeSitterTier creates a new tree-sitter search tier. ignorePath (the
+// project's .cgignore) and indexCfg make it skip the same files as
+// `codegraph build`, and kindsCfg label symbols with the same kinds.
+func NewTreeSitterTier(rootPath, ignorePath string, indexCfg config.IndexConfig, kindsCfg config.KindsConfig) *TreeSitterTier {
+	return &TreeSitterTier{rootPath: rootPath, ignorePath: ignorePath, indexCfg: indexCfg, kindsCfg: kindsCfg}
+}
+
+// Name returns the tier name
+func (t *TreeSitterTier) Name() string {
+	return "treesitter"
+}
+
+// Search parses every candidate file and returns the matching symbols
+func (t *TreeSitterTier) Search(ctx context.Context, opts SearchOptions) ([]SearchResult, error) {
+	if len(opts.Tags) > 0 || opts.Package != "" {
+		return []SearchResult{}, nil // Tags and packages are only known to the index
+	}
+	match, err := nameMatcher(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	scanner, err := indexer.NewScannerWithConfig(t.rootPath, t.ignorePath, t.indexCfg)
+	if err != nil {
+		return nil, err
+	}
+	files, err := scanner.Scan()
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := newFileFilter(t.rootPath, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	kinds, err := indexer.NewKindMap(t.kindsCfg)
+	if err != nil {
+		return nil, err
+	}
+	parser := indexer.NewTreeSitterIndexer(nil, t.rootPath)
+	parser.SetKinds(kinds)
+	results := []SearchResult{}
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if len(opts.Languages) > 0 && !containsString(opts.Languages, file.Language) {
+			continue
+		}
+		if !filter.allows(file.RelPath) {
+			continue
+		}
+
+		content, err := os.ReadFile(file.Path)
+		if err != nil || !mayContainName(content, opts) {
+			continue
+		}
+		symbols, err := parser.ParseContent(ctx, file, content)
+		if err != nil {
+			continue // Unsupported language or unparsable file
+		}
+
+		for _, sym := range symbols {
+			if !symbolKindAllowed(sym, opts) || !match(sym.Name) {
+				continue
+			}
+			results = append(results, SearchResult{
+				Name:      sym.Name,
+				Kind:      sym.Kind,
+				File:      sym.File,
+				Line:      sym.Line,
+				Column:    sym.Column,
+				Signature: sym.Signature,
+				Language:  sym.Language,
+				Source:    "treesitter",
+				Score:     1.0,
+			})
+		}
+	}
+
+	sortResults(results, opts)
+
+	if opts.Offset >= len(results) {
+		return []SearchResult{}, nil
+	}
+	results = results[opts.Offset:]
+	if opts.Limit > 0 && len(results) > opts.Limit {
+		results = results[:opts.Limit]
+	}
+	return results, nil
+}
+
+// mayContainName cheaply rules out files that cannot define a plain or exact
+// match. Patterns are not checked, since they may match without a literal.
+func mayContainName(content []byte, opts SearchOptions) bool {
+	switch {
+	case opts.Regex, opts.Glob:
+		return true
+	case opts.ExactMatch:
+		return bytes.Contains(content, []byte(opts.Query))
+	default:
+		return bytes.Contains(bytes.ToLower(content), []byte(strings.ToLower(opts.Query)))
+	}
+}
+
+// symbolKindAllowed applies the kind filters, dropping modules unless kinds
+// were requested explicitly (as the database tier does).
+func symbolKindAllowed(sym *db.Symbol, opts SearchOptions) bool {
+	if len(opts.Kinds) > 0 && !containsString(opts.Kinds, sym.Kind) {
+		return false
+	}
+	if len(opts.Kinds) == 0 && sym.Kind == "module" {
+		return false
+	}
+	return !containsString(opts.ExcludeKinds, sym.Kind)
+}
+
+// sortResults orders results by opts.Sort, defaulting to name like the
+// database tier. Every ordering ends on file and line.
+func sortResults(results []SearchResult, opts SearchOptions) {
+	byLocation := func(a, b SearchResult) bool {
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		switch opts.Sort {
+		case db.SortFile:
+			return byLocation(a, b)
+		case db.SortLine:
+			if a.Line != b.Line {
+				return a.Line < b.Line
+			}
+			return byLocation(a, b)
+		case db.SortScore:
+			if ra, rb := matchRank(a.Name, opts), matchRank(b.Name, opts); ra != rb {
+				return ra < rb
+			}
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return byLocation(a, b)
+	})
+}
+
+// matchRank mirrors the database scores: exact, prefix, then substring for
+// plain queries, and shorter names first for patterns.
+func matchRank(name string, opts SearchOptions) int {
+	if opts.Regex || opts.Glob {
+		return len(name)
+	}
+	switch lower, query := strings.ToLower(name), strings.ToLower(opts.Query); {
+	case name == opts.Query:
+		return 0
+	case strings.HasPrefix(lower, query):
+		return 1
+	default:
+		return 2
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,270 @@
+package snapshot
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+// Diff is the structural change from one index state to another
+type Diff struct {
+	Added        []db.Symbol
+	Removed      []db.Symbol
+	Renamed      []Rename
+	AddedCalls   []Edge
+	RemovedCalls []Edge
+}
+
+// Rename is a symbol whose name or file changed while its definition did
+// not
+type Rename struct {
+	Old db.Symbol
+	New db.Symbol
+}
+
+// Edge is a caller → callee relationship, however many call sites it has
+type Edge struct {
+	Caller db.Symbol
+	Callee db.Symbol
+}
+
+// Empty reports whether the two index states are structurally the same
+func (d *Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Renamed) == 0 &&
+		len(d.AddedCalls) == 0 && len(d.RemovedCalls) == 0
+}
+
+// Compare diffs the index from against the index to, over the symbols
+// matching opts. Symbols are matched by ID; the symbols left over on both
+// sides are then paired up as renames where possible, first by the renames
+// the builds in between recorded and then by their definitions, so that a
+// rename does not also show up as changes to every call edge through it.
+func Compare(from, to *db.Manager, opts db.QueryOptions) (*Diff, error) {
+	oldSyms, err := from.ListSymbols(opts)
+	if err != nil {
+		return nil, err
+	}
+	newSyms, err := to.ListSymbols(opts)
+	if err != nil {
+		return nil, err
+	}
+	oldByID := indexSymbols(oldSyms)
+	newByID := indexSymbols(newSyms)
+
+	d := &Diff{}
+	for _, s := range oldSyms {
+		if _, ok := newByID[s.ID]; !ok {
+			d.Removed = append(d.Removed, s)
+		}
+	}
+	for _, s := range newSyms {
+		if _, ok := oldByID[s.ID]; !ok {
+			d.Added = append(d.Added, s)
+		}
+	}
+	recorded, err := to.ListRenames()
+	if err != nil {
+		return nil, err
+	}
+	var found []Rename
+	d.Renamed, d.Removed, d.Added = recordedRenames(recorded, d.Removed, d.Added)
+	found, d.Removed, d.Added = matchRenames(d.Removed, d.Added)
+	d.Renamed = append(d.Renamed, found...)
+	sort.Slice(d.Renamed, func(i, j int) bool { return symbolLess(d.Renamed[i].New, d.Renamed[j].New) })
+
+	// renamedTo maps old IDs to new ones so edges are compared in the
+	// new index's terms
+	renamedTo := make(map[string]string, len(d.Renamed))
+	renames := d.Renamed[:0]
+	for _, r := range d.Renamed {
+		renamedTo[r.Old.ID] = r.New.ID
+		// Only the ID's clash suffix changed (a.py#parse@42)
+		if r.Old.Name == r.New.Name && idFile(r.Old.ID) == idFile(r.New.ID) {
+			continue
+		}
+		renames = append(renames, r)
+	}
+	d.Renamed = renames
+
+	oldEdges, err := listEdges(from, oldByID, renamedTo)
+	if err != nil {
+		return nil, err
+	}
+	newEdges, err := listEdges(to, newByID, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, e := range oldEdges {
+		if _, ok := newEdges[key]; !ok {
+			d.RemovedCalls = append(d.RemovedCalls, e)
+		}
+	}
+	for key, e := range newEdges {
+		if _, ok := oldEdges[key]; !ok {
+			d.AddedCalls = append(d.AddedCalls, e)
+		}
+	}
+	sortEdges(d.RemovedCalls)
+	sortEdges(d.AddedCalls)
+	return d, nil
+}
+
+func indexSymbols(symbols []db.Symbol) map[string]db.Symbol {
+	byID := make(map[string]db.Symbol, len(symbols))
+	for _, s := range symbols {
+		byID[s.ID] = s
+	}
+	return byID
+}
+
+// listEdges returns the call edges of m between symbols in byID, keyed by
+// caller and callee ID after applying renamedTo
+func listEdges(m *db.Manager, byID map[string]db.Symbol, renamedTo map[string]string) (map[[2]string]Edge, error) {
+	calls, err := m.ListCalls()
+	if err != nil {
+		return nil, err
+	}
+	edges := make(map[[2]string]Edge)
+	for _, c := range calls {
+		caller, ok := byID[c.CallerID]
+		if !ok {
+			continue
+		}
+		callee, ok := byID[c.CalleeID]
+		if !ok {
+			continue
+		}
+		key := [2]string{c.CallerID, c.CalleeID}
+		for i, id := range key {
+			if to, ok := renamedTo[id]; ok {
+				key[i] = to
+			}
+		}
+		edges[key] = Edge{Caller: caller, Callee: callee}
+	}
+	return edges, nil
+}
+
+// recordedRenames pairs the removed and added symbols that renames
+// recorded by the builds link, following renames of renamed symbols, and
+// returns them with the symbols left unpaired
+func recordedRenames(recorded []db.Rename, removed, added []db.Symbol) ([]Rename, []db.Symbol, []db.Symbol) {
+	if len(recorded) == 0 {
+		return nil, removed, added
+	}
+	next := make(map[string]string, len(recorded))
+	for _, r := range recorded {
+		next[r.OldID] = r.NewID // A later rename of the same ID wins
+	}
+	addedByID := indexSymbols(added)
+	var renames []Rename
+	paired := make(map[string]bool)
+	for _, old := range removed {
+		id, ok := next[old.ID]
+		// Bounded, as a symbol renamed back and forth forms a cycle
+		for hops := 1; ok && hops < len(recorded); hops++ {
+			if _, found := addedByID[id]; found {
+				break
+			}
+			id, ok = next[id]
+		}
+		s, found := addedByID[id]
+		if !ok || !found || paired[id] {
+			continue
+		}
+		renames = append(renames, Rename{Old: old, New: s})
+		paired[old.ID] = true
+		paired[id] = true
+	}
+	return renames, unpaired(removed, paired), unpaired(added, paired)
+}
+
+// matchRenames pairs removed and added symbols that are the same
+// definition under a new name or in a new file, and returns the renames
+// with the symbols left unpaired. A symbol counts as moved when one
+// removed and one added symbol share its name, kind and scope, and as
+// renamed when they share file, kind and scope and have the same
+// signature apart from the name (or, lacking signatures, the same length).
+// Ambiguous candidates are left unpaired.
+func matchRenames(removed, added []db.Symbol) ([]Rename, []db.Symbol, []db.Symbol) {
+	var renames []Rename
+	for _, key := range []func(db.Symbol) string{movedKey, renamedKey} {
+		oldByKey := groupByKey(removed, key)
+		newByKey := groupByKey(added, key)
+		paired := make(map[string]bool)
+		for k, olds := range oldByKey {
+			news := newByKey[k]
+			if k == "" || len(olds) != 1 || len(news) != 1 {
+				continue
+			}
+			renames = append(renames, Rename{Old: olds[0], New: news[0]})
+			paired[olds[0].ID] = true
+			paired[news[0].ID] = true
+		}
+		removed = unpaired(removed, paired)
+		added = unpaired(added, paired)
+	}
+	sort.Slice(renames, func(i, j int) bool { return symbolLess(renames[i].New, renames[j].New) })
+	return renames, removed, added
+}
+
+func movedKey(s db.Symbol) string {
+	return strings.Join([]string{s.Language, s.Kind, s.Scope, s.Name}, "\x00")
+}
+
+func renamedKey(s db.Symbol) string {
+	shape := strings.Replace(s.Signature, s.Name, "", 1)
+	if s.Signature == "" {
+		if s.EndLine == nil {
+			return ""
+		}
+		shape = strings.Repeat("\n", *s.EndLine-s.Line)
+	}
+	return strings.Join([]string{s.Language, s.Kind, s.Scope, idFile(s.ID), shape}, "\x00")
+}
+
+// idFile returns the project-relative file of a symbol ID, which unlike
+// Symbol.File does not change when the project is moved
+func idFile(id string) string {
+	file, _, _ := strings.Cut(id, "#")
+	return file
+}
+
+func groupByKey(symbols []db.Symbol, key func(db.Symbol) string) map[string][]db.Symbol {
+	groups := make(map[string][]db.Symbol)
+	for _, s := range symbols {
+		k := key(s)
+		groups[k] = append(groups[k], s)
+	}
+	return groups
+}
+
+func unpaired(symbols []db.Symbol, paired map[string]bool) []db.Symbol {
+	var out []db.Symbol
+	for _, s := range symbols {
+		if !paired[s.ID] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func symbolLess(a, b db.Symbol) bool {
+	if fa, fb := idFile(a.ID), idFile(b.ID); fa != fb {
+		return fa < fb
+	}
+	if a.Line != b.Line {
+		return a.Line < b.Line
+	}
+	return a.ID < b.ID
+}
+
+func sortEdges(edges []Edge) {
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].Caller.ID != edges[j].Caller.ID {
+			return symbolLess(edges[i].Caller, edges[j].Caller)
+		}
+		return symbolLess(edges[i].Callee, edges[j].Callee)
+	})
+}
@@ -0,0 +1,140 @@
+// Package snapshot keeps labelled copies of a project's index and compares
+// two index states: symbols added, removed and renamed, and call edges
+// added and removed.
+package snapshot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tk-425/Codegraph/internal/config"
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+// Current is the label that stands for the live index rather than a
+// snapshot
+const Current = "current"
+
+// dirName is the directory under .codegraph that holds the snapshots
+const dirName = "snapshots"
+
+// labelPattern restricts labels to names that are safe as file names
+var labelPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Info describes a stored snapshot
+type Info struct {
+	Label   string
+	Path    string
+	Created time.Time
+	Size    int64
+}
+
+// Dir returns the directory holding the snapshots of the project at root
+func Dir(root string) string {
+	return filepath.Join(root, config.DefaultConfigDir, dirName)
+}
+
+// Path returns the database file of the snapshot labelled label
+func Path(root, label string) string {
+	return filepath.Join(Dir(root), label+".db")
+}
+
+// ValidateLabel checks that label can name a snapshot
+func ValidateLabel(label string) error {
+	if label == Current {
+		return fmt.Errorf("%q is reserved for the live index", Current)
+	}
+	if !labelPattern.MatchString(label) {
+		return fmt.Errorf("invalid snapshot label %q: use letters, digits, '.', '_' and '-'", label)
+	}
+	return nil
+}
+
+// Create stores a copy of the index m under label. Labels are not
+// overwritten; delete the old snapshot first.
+func Create(m *db.Manager, root, label string) (*Info, error) {
+	if err := ValidateLabel(label); err != nil {
+		return nil, err
+	}
+	path := Path(root, label)
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("snapshot %q already exists", label)
+	}
+	if err := os.MkdirAll(Dir(root), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	if err := m.SnapshotTo(path); err != nil {
+		return nil, err
+	}
+	return stat(label, path)
+}
+
+// List returns the project's snapshots, oldest first
+func List(root string) ([]Info, error) {
+	entries, err := os.ReadDir(Dir(root))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var infos []Info
+	for _, e := range entries {
+		label, ok := strings.CutSuffix(e.Name(), ".db")
+		if !ok || e.IsDir() {
+			continue
+		}
+		info, err := stat(label, filepath.Join(Dir(root), e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, *info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if !infos[i].Created.Equal(infos[j].Created) {
+			return infos[i].Created.Before(infos[j].Created)
+		}
+		return infos[i].Label < infos[j].Label
+	})
+	return infos, nil
+}
+
+// Delete removes the snapshot labelled label
+func Delete(root, label string) error {
+	err := os.Remove(Path(root, label))
+	if os.IsNotExist(err) {
+		return fmt.Errorf("no snapshot named %q", label)
+	}
+	return err
+}
+
+// Open opens the snapshot labelled label. Snapshots taken before the last
+// SchemaVersion change are refused, as their IDs can no longer be read.
+func Open(root, label string) (*db.Manager, error) {
+	path := Path(root, label)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, fmt.Errorf("no snapshot named %q", label)
+	}
+	version, err := db.FileSchemaVersion(path)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot %q: %w", label, err)
+	}
+	if version < db.SchemaVersion {
+		return nil, fmt.Errorf("snapshot %q was taken by an older codegraph and can no longer be read", label)
+	}
+	return db.NewManager(path)
+}
+
+func stat(label, path string) (*Info, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Info{Label: label, Path: path, Created: fi.ModTime(), Size: fi.Size()}, nil
+}
@@ -0,0 +1,173 @@
+package snapshot
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+func newIndex(t *testing.T, path string, symbols []db.Symbol, calls [][2]string) *db.Manager {
+	t.Helper()
+	m, err := db.NewManager(path)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	t.Cleanup(func() { m.Close() })
+	if err := m.Initialize(); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+	for _, s := range symbols {
+		s.Kind, s.Language, s.CreatedAt = "function", "go", time.Unix(0, 0)
+		s.File, _, _ = strings.Cut(s.ID, "#")
+		if err := m.InsertSymbol(&s); err != nil {
+			t.Fatalf("InsertSymbol: %v", err)
+		}
+	}
+	for _, c := range calls {
+		if err := m.InsertCall(&db.Call{CallerID: c[0], CalleeID: c[1]}); err != nil {
+			t.Fatalf("InsertCall: %v", err)
+		}
+	}
+	return m
+}
+
+func TestCreateListDelete(t *testing.T) {
+	root := t.TempDir()
+	m := newIndex(t, filepath.Join(root, "index.db"), []db.Symbol{{ID: "a.go#f", Name: "f", Line: 1}}, nil)
+
+	for _, label := range []string{Current, "../escape", ".hidden", ""} {
+		if _, err := Create(m, root, label); err == nil {
+			t.Errorf("Create(%q) succeeded, want an invalid label error", label)
+		}
+	}
+	if _, err := Create(m, root, "v1"); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := Create(m, root, "v1"); err == nil {
+		t.Error("Create over an existing label succeeded")
+	}
+
+	infos, err := List(root)
+	if err != nil || len(infos) != 1 || infos[0].Label != "v1" || infos[0].Size == 0 {
+		t.Fatalf("List = %+v, %v", infos, err)
+	}
+
+	snap, err := Open(root, "v1")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	syms, err := snap.ListSymbols(db.QueryOptions{})
+	snap.Close()
+	if err != nil || len(syms) != 1 || syms[0].Name != "f" {
+		t.Errorf("snapshot symbols = %+v, %v", syms, err)
+	}
+
+	if err := Delete(root, "v1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := Open(root, "v1"); err == nil {
+		t.Error("Open after Delete succeeded")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	dir := t.TempDir()
+	from := newIndex(t, filepath.Join(dir, "from.db"), []db.Symbol{
+		{ID: "a.go#main", Name: "main", Line: 1, Signature: "func main()"},
+		{ID: "a.go#save", Name: "save", Line: 5, Signature: "func save(p string) error"},
+		{ID: "a.go#load", Name: "load", Line: 9, Signature: "func load(p string) error"},
+		{ID: "a.go#old", Name: "old", Line: 13, Signature: "func old()"},
+		{ID: "b.go#util", Name: "util", Line: 1, Signature: "func util()"},
+	}, [][2]string{{"a.go#main", "a.go#save"}, {"a.go#main", "a.go#old"}, {"a.go#save", "b.go#util"}})
+	to := newIndex(t, filepath.Join(dir, "to.db"), []db.Symbol{
+		{ID: "a.go#main", Name: "main", Line: 1, Signature: "func main()"},
+		{ID: "a.go#store", Name: "store", Line: 5, Signature: "func store(p string) error"},
+		{ID: "a.go#load", Name: "load", Line: 9, Signature: "func load(p string) error"},
+		{ID: "a.go#fresh", Name: "fresh", Line: 13, Signature: "func fresh(n int)"},
+		{ID: "c.go#util", Name: "util", Line: 1, Signature: "func util()"},
+	}, [][2]string{{"a.go#main", "a.go#store"}, {"a.go#store", "c.go#util"}, {"a.go#main", "a.go#load"}})
+
+	d, err := Compare(from, to, db.QueryOptions{})
+	if err != nil {
+		t.Fatalf("Compare: %v", err)
+	}
+	names := func(syms []db.Symbol) string {
+		var out []string
+		for _, s := range syms {
+			out = append(out, s.Name)
+		}
+		return strings.Join(out, ",")
+	}
+	if got := names(d.Added); got != "fresh" {
+		t.Errorf("added = %s, want fresh", got)
+	}
+	if got := names(d.Removed); got != "old" {
+		t.Errorf("removed = %s, want old", got)
+	}
+	var renames []string
+	for _, r := range d.Renamed {
+		renames = append(renames, r.Old.ID+"→"+r.New.ID)
+	}
+	if got, want := strings.Join(renames, ","), "a.go#save→a.go#store,b.go#util→c.go#util"; got != want {
+		t.Errorf("renamed = %s, want %s", got, want)
+	}
+
+	// Edges through renamed symbols carry over
+	edges := func(es []Edge) string {
+		var out []string
+		for _, e := range es {
+			out = append(out, e.Caller.Name+"→"+e.Callee.Name)
+		}
+		return strings.Join(out, ",")
+	}
+	if got := edges(d.AddedCalls); got != "main→load" {
+		t.Errorf("added calls = %s, want main→load", got)
+	}
+	if got := edges(d.RemovedCalls); got != "main→old" {
+		t.Errorf("removed calls = %s, want main→old", got)
+	}
+
+	if d, err := Compare(from, from, db.QueryOptions{}); err != nil || !d.Empty() {
+		t.Errorf("Compare with itself = %+v, %v, want no changes", d, err)
+	}
+}
+
+func TestCompareFollowsRecordedRenames(t *testing.T) {
+	dir := t.TempDir()
+	from := newIndex(t, filepath.Join(dir, "from.db"), []db.Symbol{
+		{ID: "a.go#parse", Name: "parse", Line: 1, Signature: "func parse(s string) int"},
+		{ID: "a.go#gone", Name: "gone", Line: 5, Signature: "func gone()"},
+	}, nil)
+	to := newIndex(t, filepath.Join(dir, "to.db"), []db.Symbol{
+		{ID: "b.go#decode", Name: "decode", Line: 3, Signature: "func decode(s string, strict bool) int"},
+		{ID: "a.go#added", Name: "added", Line: 5, Signature: "func added()"},
+	}, nil)
+	// parse became read, then read moved to b.go as decode
+	for _, r := range []db.Rename{
+		{OldID: "a.go#parse", NewID: "a.go#read"},
+		{OldID: "a.go#read", NewID: "b.go#decode"},
+	} {
+		if err := to.InsertRename(&r); err != nil {
+			t.Fatalf("InsertRename: %v", err)
+		}
+	}
+
+	d, err := Compare(from, to, db.QueryOptions{})
+	if err != nil {
+		t.Fatalf("Compare: %v", err)
+	}
+	var renames []string
+	for _, r := range d.Renamed {
+		renames = append(renames, r.Old.ID+"→"+r.New.ID)
+	}
+	// gone→added is still matched by signature
+	if got, want := strings.Join(renames, ","), "a.go#gone→a.go#added,a.go#parse→b.go#decode"; got != want {
+		t.Errorf("renamed = %s, want %s", got, want)
+	}
+	if len(d.Added) != 0 || len(d.Removed) != 0 {
+		t.Errorf("added = %+v, removed = %+v, want none", d.Added, d.Removed)
+	}
+}
@@ -0,0 +1,403 @@
+// Package tui implements `codegraph tui`: a search box, a results list,
+// and definition/callers/callees panes for the selected symbol, with
+// keyboard navigation through the call graph.
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+// maxResults caps the symbols listed for a search
+const maxResults = 200
+
+// pane identifies which part of the screen has keyboard focus
+type pane int
+
+const (
+	searchPane pane = iota
+	resultsPane
+	callersPane
+	calleesPane
+	paneCount
+)
+
+// entry is one row of a list pane: the symbol it refers to and the
+// location shown for it (the call site for callers and callees)
+type entry struct {
+	symbol db.Symbol
+	file   string
+	line   int
+}
+
+// list is a scrollable, selectable list of entries
+type list struct {
+	entries []entry
+	cursor  int
+}
+
+func (l *list) move(delta int) {
+	l.cursor += delta
+	if l.cursor >= len(l.entries) {
+		l.cursor = len(l.entries) - 1
+	}
+	if l.cursor < 0 {
+		l.cursor = 0
+	}
+}
+
+func (l *list) selected() (entry, bool) {
+	if len(l.entries) == 0 {
+		return entry{}, false
+	}
+	return l.entries[l.cursor], true
+}
+
+// view stores a search and its results so jumps can be undone
+type view struct {
+	query   string
+	results list
+}
+
+// Model is the bubbletea model of the TUI
+type Model struct {
+	db   *db.Manager
+	root string
+
+	input   textinput.Model
+	focus   pane
+	results list
+	callers list
+	callees list
+	history []view
+	err     error
+
+	width, height int
+}
+
+// New returns the TUI model for the index of the project at root
+func New(dbManager *db.Manager, root string) Model {
+	input := textinput.New()
+	input.Placeholder = "search symbols"
+	input.Prompt = "🔍 "
+	input.Focus()
+	return Model{db: dbManager, root: root, input: input, width: 100, height: 30}
+}
+
+// Run starts the TUI and blocks until the user quits
+func Run(dbManager *db.Manager, root string) error {
+	_, err := tea.NewProgram(New(dbManager, root), tea.WithAltScreen()).Run()
+	return err
+}
+
+func (m Model) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		return m, nil
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+	return m, nil
+}
+
+func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "tab":
+		m.setFocus((m.focus + 1) % paneCount)
+		return m, nil
+	case "shift+tab":
+		m.setFocus((m.focus + paneCount - 1) % paneCount)
+		return m, nil
+	}
+
+	if m.focus == searchPane {
+		switch msg.String() {
+		case "enter", "down":
+			m.setFocus(resultsPane)
+			return m, nil
+		case "esc":
+			return m, tea.Quit
+		}
+		var cmd tea.Cmd
+		before := m.input.Value()
+		m.input, cmd = m.input.Update(msg)
+		if m.input.Value() != before {
+			m.search()
+		}
+		return m, cmd
+	}
+
+	switch msg.String() {
+	case "q":
+		return m, tea.Quit
+	case "/":
+		m.setFocus(searchPane)
+	case "up", "k":
+		m.focused().move(-1)
+		if m.focus == resultsPane {
+			m.loadSelected()
+		}
+	case "down", "j":
+		m.focused().move(1)
+		if m.focus == resultsPane {
+			m.loadSelected()
+		}
+	case "enter", "l", "right":
+		if m.focus != resultsPane {
+			m.jump()
+		}
+	case "esc", "backspace", "h", "left":
+		m.back()
+	}
+	return m, nil
+}
+
+func (m *Model) setFocus(p pane) {
+	m.focus = p
+	if p == searchPane {
+		m.input.Focus()
+	} else {
+		m.input.Blur()
+	}
+}
+
+// focused returns the list pane with focus
+func (m *Model) focused() *list {
+	switch m.focus {
+	case callersPane:
+		return &m.callers
+	case calleesPane:
+		return &m.callees
+	default:
+		return &m.results
+	}
+}
+
+// search lists the symbols matching the search box
+func (m *Model) search() {
+	m.results = list{}
+	query := strings.TrimSpace(m.input.Value())
+	if query != "" {
+		symbols, err := m.db.SearchSymbols(query, db.QueryOptions{Sort: db.SortScore, Limit: maxResults})
+		m.err = err
+		for _, s := range symbols {
+			m.results.entries = append(m.results.entries, entry{symbol: s, file: s.File, line: s.Line})
+		}
+	}
+	m.loadSelected()
+}
+
+// loadSelected fills the callers and callees panes for the selected result
+func (m *Model) loadSelected() {
+	m.callers, m.callees = list{}, list{}
+	sel, ok := m.results.selected()
+	if !ok {
+		return
+	}
+	callers, err := m.db.GetCallersByID(sel.symbol.ID, db.QueryOptions{})
+	if err != nil {
+		m.err = err
+		return
+	}
+	for _, c := range callers {
+		m.callers.entries = append(m.callers.entries, entry{symbol: c.Symbol, file: c.CallFile, line: c.CallLine})
+	}
+	callees, err := m.db.GetCalleesByID(sel.symbol.ID, db.QueryOptions{})
+	if err != nil {
+		m.err = err
+		return
+	}
+	for _, c := range callees {
+		m.callees.entries = append(m.callees.entries, entry{symbol: c.Symbol, file: c.CallFile, line: c.CallLine})
+	}
+	m.err = nil
+}
+
+// jump makes the caller or callee under the cursor the selected symbol,
+// remembering the current view for back
+func (m *Model) jump() {
+	target, ok := m.focused().selected()
+	if !ok {
+		return
+	}
+	m.history = append(m.history, view{query: m.input.Value(), results: m.results})
+	m.input.SetValue(target.symbol.Name)
+	m.results = list{entries: []entry{{symbol: target.symbol, file: target.symbol.File, line: target.symbol.Line}}}
+	m.loadSelected()
+	m.setFocus(resultsPane)
+}
+
+// back restores the view before the last jump
+func (m *Model) back() {
+	if len(m.history) == 0 {
+		return
+	}
+	prev := m.history[len(m.history)-1]
+	m.history = m.history[:len(m.history)-1]
+	m.input.SetValue(prev.query)
+	m.results = prev.results
+	m.loadSelected()
+	m.setFocus(resultsPane)
+}
+
+var (
+	borderStyle = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("8"))
+	focusStyle  = borderStyle.BorderForeground(lipgloss.Color("6"))
+	titleStyle  = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("6"))
+	cursorStyle = lipgloss.NewStyle().Reverse(true)
+	dimStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	kindStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("5"))
+	errorStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+)
+
+const (
+	helpText     = "tab: switch pane · ↑/↓: move · enter: jump to caller/callee · esc: back · /: search · q: quit"
+	paneChrome   = 2 // border lines around a pane
+	headerHeight = 3 // search box with border
+	// noPane marks a pane that never takes focus
+	noPane pane = -1
+)
+
+func (m Model) View() string {
+	leftWidth := m.width * 2 / 5
+	rightWidth := m.width - leftWidth
+	bodyHeight := m.height - headerHeight - 1 // help line
+	if bodyHeight < 9 {
+		bodyHeight = 9
+	}
+	defHeight := bodyHeight / 3
+	callHeight := (bodyHeight - defHeight) / 2
+
+	search := m.box(searchPane, m.width, 1, m.input.View())
+	results := m.box(resultsPane, leftWidth, bodyHeight-paneChrome,
+		m.renderList("Results", &m.results, leftWidth-paneChrome, bodyHeight-paneChrome, m.focus == resultsPane))
+	definition := m.box(noPane, rightWidth, defHeight-paneChrome, m.renderDefinition(rightWidth-paneChrome, defHeight-paneChrome))
+	callers := m.box(callersPane, rightWidth, callHeight-paneChrome,
+		m.renderList("Callers", &m.callers, rightWidth-paneChrome, callHeight-paneChrome, m.focus == callersPane))
+	callees := m.box(calleesPane, rightWidth, bodyHeight-defHeight-callHeight-paneChrome,
+		m.renderList("Callees", &m.callees, rightWidth-paneChrome, bodyHeight-defHeight-callHeight-paneChrome, m.focus == calleesPane))
+
+	footer := dimStyle.Render(helpText)
+	if m.err != nil {
+		footer = errorStyle.Render(m.err.Error())
+	}
+	body := lipgloss.JoinHorizontal(lipgloss.Top, results, lipgloss.JoinVertical(lipgloss.Left, definition, callers, callees))
+	return lipgloss.JoinVertical(lipgloss.Left, search, body, footer)
+}
+
+// box draws content in a bordered pane, highlighted when p has focus
+func (m Model) box(p pane, width, height int, content string) string {
+	style := borderStyle
+	if p == m.focus {
+		style = focusStyle
+	}
+	return style.Width(max(width-paneChrome, 1)).Height(max(height, 1)).MaxHeight(height + paneChrome).Render(content)
+}
+
+// renderList renders a titled list, scrolled to keep the cursor visible
+func (m Model) renderList(title string, l *list, width, height int, focused bool) string {
+	lines := []string{titleStyle.Render(fmt.Sprintf("%s (%d)", title, len(l.entries)))}
+	rows := height - 1
+	start := 0
+	if l.cursor >= rows {
+		start = l.cursor - rows + 1
+	}
+	for i := start; i < len(l.entries) && i < start+rows; i++ {
+		e := l.entries[i]
+		line := fmt.Sprintf("%s %s %s", e.symbol.Name, kindStyle.Render(e.symbol.Kind),
+			dimStyle.Render(fmt.Sprintf("%s:%d", m.relative(e.file), e.line)))
+		line = truncate(line, width)
+		if focused && i == l.cursor {
+			line = cursorStyle.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderDefinition shows the selected symbol's location, signature,
+// documentation, and the start of its source
+func (m Model) renderDefinition(width, height int) string {
+	sel, ok := m.results.selected()
+	if !ok {
+		return titleStyle.Render("Definition") + "\n" + dimStyle.Render("Type to search the index")
+	}
+	s := sel.symbol
+	lines := []string{
+		titleStyle.Render(s.Name) + " " + kindStyle.Render(s.Kind) + " " + dimStyle.Render(fmt.Sprintf("%s:%d", m.relative(s.File), s.Line)),
+	}
+	if s.Signature != "" {
+		lines = append(lines, s.Signature)
+	}
+	if s.Documentation != "" {
+		lines = append(lines, dimStyle.Render(strings.SplitN(s.Documentation, "\n", 2)[0]))
+	}
+	lines = append(lines, m.sourceLines(s, height-len(lines))...)
+	for i := range lines {
+		lines[i] = truncate(lines[i], width)
+	}
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// sourceLines returns up to n lines of the symbol's source
+func (m Model) sourceLines(s db.Symbol, n int) []string {
+	if n <= 0 {
+		return nil
+	}
+	path := s.File
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(m.root, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	all := strings.Split(string(data), "\n")
+	end := s.Line - 1 + n
+	if s.EndLine != nil && *s.EndLine < end {
+		end = *s.EndLine
+	}
+	if s.Line < 1 || s.Line > len(all) {
+		return nil
+	}
+	if end > len(all) {
+		end = len(all)
+	}
+	var lines []string
+	for i := s.Line - 1; i < end; i++ {
+		lines = append(lines, dimStyle.Render(fmt.Sprintf("%4d ", i+1))+strings.ReplaceAll(all[i], "\t", "    "))
+	}
+	return lines
+}
+
+func (m Model) relative(path string) string {
+	if rel, err := filepath.Rel(m.root, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
+
+// truncate shortens s to width display cells
+func truncate(s string, width int) string {
+	if width <= 0 || lipgloss.Width(s) <= width {
+		return s
+	}
+	return lipgloss.NewStyle().MaxWidth(width).Render(s)
+}
@@ -0,0 +1,98 @@
+package tui
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+func newTestModel(t *testing.T) Model {
+	t.Helper()
+	root := t.TempDir()
+	m, err := db.NewManager(filepath.Join(root, "graph.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { m.Close() })
+	if err := m.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(root, "main.go")
+	for _, name := range []string{"main", "parseConfig", "readFile"} {
+		if err := m.InsertSymbol(&db.Symbol{ID: "main.go#" + name, Name: name, Kind: "function", File: file, Line: 1, Language: "go"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, c := range [][2]string{{"main", "parseConfig"}, {"parseConfig", "readFile"}} {
+		if err := m.InsertCall(&db.Call{CallerID: "main.go#" + c[0], CalleeID: "main.go#" + c[1], File: file, Line: 2}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return New(m, root)
+}
+
+func press(m Model, keys ...tea.KeyMsg) Model {
+	for _, k := range keys {
+		next, _ := m.Update(k)
+		m = next.(Model)
+	}
+	return m
+}
+
+func names(l list) string {
+	var out []string
+	for _, e := range l.entries {
+		out = append(out, e.symbol.Name)
+	}
+	return strings.Join(out, ",")
+}
+
+var (
+	tab   = tea.KeyMsg{Type: tea.KeyTab}
+	enter = tea.KeyMsg{Type: tea.KeyEnter}
+	esc   = tea.KeyMsg{Type: tea.KeyEsc}
+)
+
+func typed(s string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func TestSearchShowsCallersAndCallees(t *testing.T) {
+	m := press(newTestModel(t), typed("parseConfig"))
+	if got := names(m.results); got != "parseConfig" {
+		t.Fatalf("results = %s, want parseConfig", got)
+	}
+	if got := names(m.callers); got != "main" {
+		t.Errorf("callers = %s, want main", got)
+	}
+	if got := names(m.callees); got != "readFile" {
+		t.Errorf("callees = %s, want readFile", got)
+	}
+	if view := m.View(); !strings.Contains(view, "Callers (1)") || !strings.Contains(view, "readFile") {
+		t.Errorf("view is missing the panes:\n%s", view)
+	} else if got := lipgloss.Height(view); got != m.height {
+		t.Errorf("view is %d lines tall, want the window's %d", got, m.height)
+	}
+}
+
+func TestJumpThroughGraphAndBack(t *testing.T) {
+	m := press(newTestModel(t), typed("parseConfig"))
+
+	// tab → results, tab → callers, enter jumps to main
+	m = press(m, tab, tab, enter)
+	if got := names(m.results); got != "main" || m.focus != resultsPane {
+		t.Fatalf("after jumping to the caller: results = %s, focus = %d", got, m.focus)
+	}
+	if got := names(m.callees); got != "parseConfig" {
+		t.Errorf("callees of main = %s, want parseConfig", got)
+	}
+
+	m = press(m, esc)
+	if got := names(m.results); got != "parseConfig" || m.input.Value() != "parseConfig" {
+		t.Errorf("after back: results = %s, query = %q", got, m.input.Value())
+	}
+}
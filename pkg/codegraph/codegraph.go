@@ -0,0 +1,213 @@
+// Package codegraph is the public Go API of codegraph. It lets editor
+// plugins, CI tools and other programs index a project and query its
+// symbols, call graph and type hierarchy without running the CLI.
+//
+// A project must have been set up with `codegraph init` (a .codegraph
+// directory at its root):
+//
+//	p, err := codegraph.Open("/path/to/project")
+//	if err != nil {
+//		return err
+//	}
+//	defer p.Close()
+//	if err := p.Index(ctx, codegraph.IndexOptions{}); err != nil {
+//		return err
+//	}
+//	callers, err := p.Callers("handleLogin", codegraph.Options{})
+package codegraph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/tk-425/Codegraph/internal/config"
+	"github.com/tk-425/Codegraph/internal/db"
+	"github.com/tk-425/Codegraph/internal/indexer"
+)
+
+// ErrNotInitialized is returned by Open for a directory without .codegraph
+var ErrNotInitialized = errors.New("codegraph not initialized. Run 'codegraph init' first")
+
+// ErrReadOnly is returned by Index for a project whose config.toml marks
+// its database as a read-only shared index
+var ErrReadOnly = errors.New("the index is read-only (database.read_only in config.toml)")
+
+// Project is an open codegraph project and its database
+type Project struct {
+	root string
+	cfg  *config.Config
+	db   *db.Manager
+}
+
+// Open opens the project rooted at root, loading .codegraph/config.toml and
+// creating the database tables when the project has not been built yet
+func Open(root string) (*Project, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve project root: %w", err)
+	}
+	if _, err := os.Stat(filepath.Join(absRoot, config.DefaultConfigDir)); os.IsNotExist(err) {
+		return nil, ErrNotInitialized
+	}
+	cfg, err := config.Load(absRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	if cfg.Database.ReadOnly {
+		dbManager, err := db.NewSharedManager(cfg.GetDatabasePath(absRoot), absRoot, cfg.GetSharedCachePath(absRoot))
+		if err != nil {
+			return nil, err
+		}
+		return &Project{root: absRoot, cfg: cfg, db: dbManager}, nil
+	}
+	dbManager, err := db.NewManager(cfg.GetDatabasePath(absRoot))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if err := dbManager.Initialize(); err != nil {
+		dbManager.Close()
+		return nil, fmt.Errorf("failed to initialize database: %w", err)
+	}
+	return &Project{root: absRoot, cfg: cfg, db: dbManager}, nil
+}
+
+// Root returns the absolute path of the project root
+func (p *Project) Root() string {
+	return p.root
+}
+
+// Close closes the project's database
+func (p *Project) Close() error {
+	return p.db.Close()
+}
+
+// IndexOptions configures Index
+type IndexOptions struct {
+	// Force re-indexes every file instead of only files changed since the
+	// last build
+	Force bool
+	// Output receives the build's progress messages; nil keeps Index
+	// silent
+	Output io.Writer
+}
+
+// Index scans the project (honouring .cgignore and config.toml) and indexes
+// its symbols, call graph and type hierarchy, like `codegraph build`.
+// Progress is written to opts.Output when set.
+func (p *Project) Index(ctx context.Context, opts IndexOptions) error {
+	if p.cfg.Database.ReadOnly {
+		return ErrReadOnly
+	}
+	// Without a .cgignore only the built-in ignore patterns apply
+	cgignorePath := filepath.Join(p.root, config.DefaultConfigDir, ".cgignore")
+	if _, err := os.Stat(cgignorePath); os.IsNotExist(err) {
+		cgignorePath = ""
+	}
+	scanner, err := indexer.NewScannerWithConfig(p.root, cgignorePath, p.cfg.Index)
+	if err != nil {
+		return fmt.Errorf("failed to prepare scanner: %w", err)
+	}
+	scanner.SetWorkspaces(p.cfg.Workspaces)
+	files, err := scanner.Scan()
+	if err != nil {
+		return fmt.Errorf("failed to scan files: %w", err)
+	}
+	if len(files) == 0 {
+		return nil
+	}
+
+	idx := indexer.NewIndexer(p.cfg, p.db, p.root)
+	defer idx.Close()
+	output := opts.Output
+	if output == nil {
+		output = io.Discard
+	}
+	idx.SetOutput(output)
+	if err := idx.IndexProject(ctx, files, opts.Force); err != nil {
+		return fmt.Errorf("indexing failed: %w", err)
+	}
+	return nil
+}
+
+// Options narrows query results. Zero values apply no filtering.
+type Options struct {
+	Languages []string // Only symbols in these languages
+	Kinds     []string // Only symbols of these kinds
+	Limit     int      // Max results (0 = unlimited)
+}
+
+func (o Options) query() db.QueryOptions {
+	return db.QueryOptions{Languages: o.Languages, Kinds: o.Kinds, Limit: o.Limit}
+}
+
+// Search returns the symbols whose name contains name, best matches first
+func (p *Project) Search(name string, opts Options) ([]Symbol, error) {
+	q := opts.query()
+	q.Sort = db.SortScore
+	symbols, err := p.db.SearchSymbols(name, q)
+	if err != nil {
+		return nil, fmt.Errorf("search failed: %w", err)
+	}
+	return p.symbols(symbols), nil
+}
+
+// Callers returns the call sites of the functions named name, each with the
+// calling symbol. opts filters the callers.
+func (p *Project) Callers(name string, opts Options) ([]CallSite, error) {
+	sites := []CallSite{}
+	err := p.EachCaller(name, opts, func(site CallSite) error {
+		sites = append(sites, site)
+		return nil
+	})
+	return sites, err
+}
+
+// EachCaller calls fn with each call site Callers would return, as it is
+// read from the index, so large results are not held in memory. An error
+// from fn stops the iteration and is returned.
+func (p *Project) EachCaller(name string, opts Options, fn func(CallSite) error) error {
+	err := p.db.EachCaller(name, opts.query(), func(c db.CallerInfo) error {
+		return fn(p.callSite(c.Symbol, c.CallFile, c.CallLine, c.CallColumn, c.CallArgCount, c.CallArgs))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to find callers: %w", err)
+	}
+	return nil
+}
+
+// Callees returns the calls made by the functions named name, each with the
+// called symbol. opts filters the callees.
+func (p *Project) Callees(name string, opts Options) ([]CallSite, error) {
+	sites := []CallSite{}
+	err := p.EachCallee(name, opts, func(site CallSite) error {
+		sites = append(sites, site)
+		return nil
+	})
+	return sites, err
+}
+
+// EachCallee calls fn with each call site Callees would return, as it is
+// read from the index. An error from fn stops the iteration and is returned.
+func (p *Project) EachCallee(name string, opts Options, fn func(CallSite) error) error {
+	err := p.db.EachCallee(name, opts.query(), func(c db.CalleeInfo) error {
+		return fn(p.callSite(c.Symbol, c.CallFile, c.CallLine, c.CallColumn, c.CallArgCount, c.CallArgs))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to find callees: %w", err)
+	}
+	return nil
+}
+
+// Implementations returns the types that implement or extend the type named
+// typeName
+func (p *Project) Implementations(typeName string, opts Options) ([]Symbol, error) {
+	impls, err := p.db.GetImplementationsByName(typeName, opts.query())
+	if err != nil {
+		return nil, fmt.Errorf("failed to find implementations: %w", err)
+	}
+	return p.symbols(impls), nil
+}
@@ -0,0 +1,129 @@
+package codegraph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tk-425/Codegraph/internal/config"
+)
+
+func TestOpenRequiresInit(t *testing.T) {
+	if _, err := Open(t.TempDir()); !errors.Is(err, ErrNotInitialized) {
+		t.Fatalf("Open error = %v, want ErrNotInitialized", err)
+	}
+}
+
+func TestProjectIndexAndQuery(t *testing.T) {
+	root := t.TempDir()
+	cfg := config.DefaultConfig()
+	// No server: index with the tree-sitter fallback
+	cfg.LSP["python"] = config.LSPConfig{Command: "missing-python-lsp"}
+	if err := config.Save(root, cfg); err != nil {
+		t.Fatal(err)
+	}
+	src := "class Shape:\n    pass\n\nclass Square(Shape):\n    def area(self):\n        return scale(2)\n\ndef scale(n):\n    return n\n"
+	if err := os.WriteFile(filepath.Join(root, "shapes.py"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := Open(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	if err := p.Index(context.Background(), IndexOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	symbols, err := p.Search("area", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(symbols) != 1 || symbols[0].ID != "shapes.py#Square.area" || symbols[0].File != "shapes.py" {
+		t.Fatalf("Search = %#v", symbols)
+	}
+
+	callers, err := p.Callers("scale", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(callers) != 1 || callers[0].Symbol.Name != "area" || callers[0].Line != 6 || callers[0].Args != "(2)" {
+		t.Fatalf("Callers = %#v", callers)
+	}
+
+	callees, err := p.Callees("area", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(callees) != 1 || callees[0].Symbol.ID != "shapes.py#scale" {
+		t.Fatalf("Callees = %#v", callees)
+	}
+
+	impls, err := p.Implementations("Shape", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(impls) != 1 || impls[0].Name != "Square" {
+		t.Fatalf("Implementations = %#v", impls)
+	}
+
+	var buf bytes.Buffer
+	if err := p.ExportGraph(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var g Graph
+	if err := json.Unmarshal(buf.Bytes(), &g); err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Symbols) != 4 || len(g.Calls) != 1 || len(g.Hierarchy) != 1 || len(g.Contains) != 1 {
+		t.Fatalf("graph = %s", buf.String())
+	}
+}
+
+func TestIndexIsSilentWithoutOutput(t *testing.T) {
+	root := t.TempDir()
+	cfg := config.DefaultConfig()
+	cfg.LSP["python"] = config.LSPConfig{Command: "missing-python-lsp"}
+	if err := config.Save(root, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "a.py"), []byte("def f():\n    pass\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := Open(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	err = p.Index(context.Background(), IndexOptions{})
+	os.Stdout = stdout
+	w.Close()
+	printed, _ := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(printed) != 0 {
+		t.Errorf("Index printed %q to stdout", printed)
+	}
+
+	var out bytes.Buffer
+	if err := p.Index(context.Background(), IndexOptions{Force: true, Output: &out}); err != nil {
+		t.Fatal(err)
+	}
+	if out.Len() == 0 {
+		t.Error("Index wrote no progress to Output")
+	}
+}
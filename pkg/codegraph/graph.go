@@ -0,0 +1,99 @@
+package codegraph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+// Graph is the whole indexed project: its symbols and the edges between
+// them. Edges refer to symbols by ID.
+type Graph struct {
+	Symbols   []Symbol   `json:"symbols"`
+	Calls     []CallEdge `json:"calls"`
+	Hierarchy []TypeEdge `json:"hierarchy"`
+	Contains  []Contains `json:"contains"`
+}
+
+// CallEdge is a call from one symbol to another
+type CallEdge struct {
+	CallerID string `json:"caller_id"`
+	CalleeID string `json:"callee_id"`
+	File     string `json:"file"` // Call site path, relative to the project root
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	ArgCount *int   `json:"arg_count"`
+	Args     string `json:"args,omitempty"`
+}
+
+// TypeEdge is an extends or implements relationship between two types
+type TypeEdge struct {
+	ChildID      string `json:"child_id"`
+	ParentID     string `json:"parent_id"`
+	Relationship string `json:"relationship"`        // "extends" or "implements"
+	TypeArgs     string `json:"type_args,omitempty"` // Arguments of a generic parent, "User" in Base<User>
+}
+
+// Contains links a symbol to the symbol declaring it (method -> class)
+type Contains struct {
+	ChildID  string `json:"child_id"`
+	ParentID string `json:"parent_id"`
+}
+
+// Graph loads the whole indexed graph
+func (p *Project) Graph() (*Graph, error) {
+	symbols, err := p.db.ListSymbols(db.QueryOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list symbols: %w", err)
+	}
+	calls, err := p.db.ListCalls()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list calls: %w", err)
+	}
+	rels, err := p.db.ListTypeHierarchy()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list type hierarchy: %w", err)
+	}
+	links, err := p.db.ListContainment()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containment: %w", err)
+	}
+
+	g := &Graph{
+		Symbols:   p.symbols(symbols),
+		Calls:     make([]CallEdge, 0, len(calls)),
+		Hierarchy: make([]TypeEdge, 0, len(rels)),
+		Contains:  make([]Contains, 0, len(links)),
+	}
+	for _, c := range calls {
+		g.Calls = append(g.Calls, CallEdge{
+			CallerID: c.CallerID,
+			CalleeID: c.CalleeID,
+			File:     p.relative(c.File),
+			Line:     c.Line,
+			Column:   c.Column,
+			ArgCount: c.ArgCount,
+			Args:     c.Args,
+		})
+	}
+	for _, r := range rels {
+		g.Hierarchy = append(g.Hierarchy, TypeEdge{ChildID: r.ChildID, ParentID: r.ParentID, Relationship: r.Relationship, TypeArgs: r.TypeArgs})
+	}
+	for _, l := range links {
+		g.Contains = append(g.Contains, Contains{ChildID: l.ChildID, ParentID: l.ParentID})
+	}
+	return g, nil
+}
+
+// ExportGraph writes the whole indexed graph to w as JSON
+func (p *Project) ExportGraph(w io.Writer) error {
+	g, err := p.Graph()
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(g)
+}
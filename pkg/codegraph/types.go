@@ -0,0 +1,82 @@
+package codegraph
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/tk-425/Codegraph/internal/db"
+)
+
+// Symbol is an indexed declaration: a function, type, method, field, ...
+type Symbol struct {
+	ID            string `json:"id"`   // "path/file.go#Scope.Name"
+	Name          string `json:"name"` // Declared name
+	Kind          string `json:"kind"` // function, method, class, struct, field, ...
+	File          string `json:"file"` // Path relative to the project root
+	Line          int    `json:"line"` // 1-indexed
+	Column        int    `json:"column"`
+	EndLine       int    `json:"end_line,omitempty"` // 0 when unknown
+	Scope         string `json:"scope,omitempty"`    // Enclosing scope chain
+	Signature     string `json:"signature,omitempty"`
+	Documentation string `json:"documentation,omitempty"`
+	Language      string `json:"language"`
+}
+
+// CallSite is one call: the symbol on the other end of the call (the caller
+// for Callers, the callee for Callees) and where the call is made
+type CallSite struct {
+	Symbol   Symbol `json:"symbol"`
+	File     string `json:"file"` // Call site path, relative to the project root
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	ArgCount *int   `json:"arg_count"` // nil when unknown
+	Args     string `json:"args,omitempty"`
+}
+
+// symbols converts database symbols to the public type
+func (p *Project) symbols(symbols []db.Symbol) []Symbol {
+	out := make([]Symbol, 0, len(symbols))
+	for _, s := range symbols {
+		out = append(out, p.symbol(s))
+	}
+	return out
+}
+
+func (p *Project) symbol(s db.Symbol) Symbol {
+	sym := Symbol{
+		ID:            s.ID,
+		Name:          s.Name,
+		Kind:          s.Kind,
+		File:          p.relative(s.File),
+		Line:          s.Line,
+		Column:        s.Column,
+		Scope:         s.Scope,
+		Signature:     s.Signature,
+		Documentation: s.Documentation,
+		Language:      s.Language,
+	}
+	if s.EndLine != nil {
+		sym.EndLine = *s.EndLine
+	}
+	return sym
+}
+
+func (p *Project) callSite(s db.Symbol, file string, line, column int, argCount *int, args string) CallSite {
+	return CallSite{
+		Symbol:   p.symbol(s),
+		File:     p.relative(file),
+		Line:     line,
+		Column:   column,
+		ArgCount: argCount,
+		Args:     args,
+	}
+}
+
+// relative returns path relative to the project root, or path unchanged
+// when it lies outside it
+func (p *Project) relative(path string) string {
+	if rel, err := filepath.Rel(p.root, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}